@@ -0,0 +1,134 @@
+package app
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"io/ioutil"
+	nethttp "net/http"
+	"os"
+	"path/filepath"
+	"strings"
+
+	jsoniter "github.com/json-iterator/go"
+	"github.com/spf13/cobra"
+
+	"github.com/ustackq/indagate/pkg/http"
+	"github.com/ustackq/indagate/pkg/service"
+	"github.com/ustackq/indagate/pkg/store/bolt"
+)
+
+var json = jsoniter.ConfigCompatibleWithStandardLibrary
+
+const (
+	backupSnapshotFile = "indagate.bolt"
+	backupManifestFile = "manifest.json"
+)
+
+// NewBackupCommand returns the command downloading a snapshot of a running server.
+func NewBackupCommand() *cobra.Command {
+	var host, token string
+	cmd := &cobra.Command{
+		Use:   "backup <dir>",
+		Short: "Back up the database of a running server into dir",
+		Args:  cobra.ExactArgs(1),
+		Run: func(cmd *cobra.Command, args []string) {
+			if err := backup(context.Background(), host, token, args[0]); err != nil {
+				fmt.Fprintln(os.Stderr, err)
+				os.Exit(1)
+			}
+		},
+	}
+	cmd.Flags().StringVar(&host, "host", "http://localhost:9999", "Address of the indagate server.")
+	cmd.Flags().StringVar(&token, "token", "", "Token of an instance admin.")
+	return cmd
+}
+
+func backup(ctx context.Context, host, token, dir string) error {
+	req, err := nethttp.NewRequest("GET", strings.TrimSuffix(host, "/")+"/api/v1/backup", nil)
+	if err != nil {
+		return err
+	}
+	req = req.WithContext(ctx)
+	http.SetToken(req, token)
+
+	resp, err := nethttp.DefaultClient.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != nethttp.StatusOK {
+		b, _ := ioutil.ReadAll(resp.Body)
+		return fmt.Errorf("backup failed with status %d: %s", resp.StatusCode, b)
+	}
+
+	if err := os.MkdirAll(dir, 0700); err != nil {
+		return err
+	}
+
+	snapshot := filepath.Join(dir, backupSnapshotFile)
+	f, err := os.OpenFile(snapshot, os.O_CREATE|os.O_TRUNC|os.O_WRONLY, 0600)
+	if err != nil {
+		return err
+	}
+	if _, err := io.Copy(f, resp.Body); err != nil {
+		f.Close()
+		return err
+	}
+	if err := f.Close(); err != nil {
+		return err
+	}
+
+	// the trailer is only available once the body has been read.
+	raw := resp.Trailer.Get(http.BackupManifestTrailer)
+	if raw == "" {
+		return fmt.Errorf("backup is incomplete, the server sent no manifest")
+	}
+
+	m := &service.BackupManifest{}
+	if err := json.Unmarshal([]byte(raw), m); err != nil {
+		return fmt.Errorf("invalid backup manifest: %v", err)
+	}
+	if err := bolt.VerifyBackup(snapshot, m); err != nil {
+		return err
+	}
+
+	b, err := json.MarshalIndent(m, "", "  ")
+	if err != nil {
+		return err
+	}
+	return ioutil.WriteFile(filepath.Join(dir, backupManifestFile), b, 0600)
+}
+
+// NewRestoreCommand returns the command replacing the database with a backup.
+func NewRestoreCommand() *cobra.Command {
+	var boltPath string
+	cmd := &cobra.Command{
+		Use:   "restore <dir>",
+		Short: "Restore the database from the backup in dir, the server must be stopped",
+		Args:  cobra.ExactArgs(1),
+		Run: func(cmd *cobra.Command, args []string) {
+			if err := restore(args[0], boltPath); err != nil {
+				fmt.Fprintln(os.Stderr, err)
+				os.Exit(1)
+			}
+		},
+	}
+	cmd.Flags().StringVar(&boltPath, "bolt-path", "indagate.bolt", "Path to the bolt database file.")
+	return cmd
+}
+
+func restore(dir, boltPath string) error {
+	b, err := ioutil.ReadFile(filepath.Join(dir, backupManifestFile))
+	if err != nil {
+		return err
+	}
+
+	m := &service.BackupManifest{}
+	if err := json.Unmarshal(b, m); err != nil {
+		return fmt.Errorf("invalid backup manifest: %v", err)
+	}
+
+	return bolt.Restore(filepath.Join(dir, backupSnapshotFile), m, boltPath)
+}
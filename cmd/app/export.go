@@ -0,0 +1,105 @@
+package app
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"os"
+	"sort"
+	"text/tabwriter"
+
+	"github.com/spf13/cobra"
+	"github.com/spf13/viper"
+
+	"github.com/ustackq/indagate/cmd/app/options"
+	"github.com/ustackq/indagate/pkg/store"
+)
+
+// NewExportCommand returns the command writing all entities of the store to an NDJSON archive.
+func NewExportCommand() *cobra.Command {
+	ing := options.NewIndagateOptions(viper.GetString("config"))
+
+	cmd := &cobra.Command{
+		Use:   "export [file]",
+		Short: "Export all entities of the store, to stdout if no file is given",
+		Args:  cobra.MaximumNArgs(1),
+		Run: func(cmd *cobra.Command, args []string) {
+			runWithStore(ing, func(ctx context.Context, s *store.Service) error {
+				var w io.Writer = ing.Stdout
+				if len(args) == 1 {
+					f, err := os.OpenFile(args[0], os.O_CREATE|os.O_TRUNC|os.O_WRONLY, 0600)
+					if err != nil {
+						return err
+					}
+					defer f.Close()
+					w = f
+				}
+				return s.Export(ctx, w)
+			})
+		},
+	}
+	ing.AddFlags(cmd.Flags())
+	return cmd
+}
+
+// NewImportCommand returns the command importing an archive written by export.
+func NewImportCommand() *cobra.Command {
+	ing := options.NewIndagateOptions(viper.GetString("config"))
+
+	cmd := &cobra.Command{
+		Use:   "import <file>",
+		Short: "Import an archive written by export, keeping the IDs of the entities",
+		Args:  cobra.ExactArgs(1),
+		Run: func(cmd *cobra.Command, args []string) {
+			runWithStore(ing, func(ctx context.Context, s *store.Service) error {
+				f, err := os.Open(args[0])
+				if err != nil {
+					return err
+				}
+				defer f.Close()
+
+				// the archive holds entities of the latest schema.
+				if err := s.MigrateUp(ctx, 0); err != nil {
+					return err
+				}
+
+				res, err := s.Import(ctx, f)
+				if err != nil {
+					return err
+				}
+				return printImportResult(ing.Stdout, res)
+			})
+		},
+	}
+	ing.AddFlags(cmd.Flags())
+	return cmd
+}
+
+func printImportResult(w io.Writer, res *store.ImportResult) error {
+	kinds := make([]string, 0, len(res.Imported))
+	for kind := range res.Imported {
+		kinds = append(kinds, kind)
+	}
+	sort.Strings(kinds)
+
+	tw := tabwriter.NewWriter(w, 0, 8, 2, ' ', 0)
+	fmt.Fprintln(tw, "KIND\tIMPORTED")
+	for _, kind := range kinds {
+		fmt.Fprintf(tw, "%s\t%d\n", kind, res.Imported[kind])
+	}
+	if err := tw.Flush(); err != nil {
+		return err
+	}
+
+	if len(res.Conflicts) == 0 {
+		return nil
+	}
+
+	fmt.Fprintf(w, "\n%d conflicts were skipped:\n", len(res.Conflicts))
+	tw = tabwriter.NewWriter(w, 0, 8, 2, ' ', 0)
+	fmt.Fprintln(tw, "KIND\tKEY\tREASON")
+	for _, c := range res.Conflicts {
+		fmt.Fprintf(tw, "%s\t%s\t%s\n", c.Kind, c.Key, c.Msg)
+	}
+	return tw.Flush()
+}
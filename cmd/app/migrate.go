@@ -0,0 +1,147 @@
+package app
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"strconv"
+	"text/tabwriter"
+	"time"
+
+	"github.com/spf13/cobra"
+	"github.com/spf13/viper"
+
+	"github.com/ustackq/indagate/cmd/app/options"
+	"github.com/ustackq/indagate/pkg/store"
+)
+
+// NewMigrateCommand returns the command listing, applying and reverting the store migrations.
+func NewMigrateCommand() *cobra.Command {
+	ing := options.NewIndagateOptions(viper.GetString("config"))
+
+	cmd := &cobra.Command{
+		Use:   "migrate",
+		Short: "Manage the migrations of the store",
+	}
+	ing.AddFlags(cmd.PersistentFlags())
+
+	cmd.AddCommand(
+		newMigrateStatusCommand(ing),
+		newMigrateUpCommand(ing),
+		newMigrateDownCommand(ing),
+	)
+	return cmd
+}
+
+func newMigrateStatusCommand(ing *options.Indagate) *cobra.Command {
+	return &cobra.Command{
+		Use:   "status",
+		Short: "List the migrations and when they were applied",
+		Args:  cobra.NoArgs,
+		Run: func(cmd *cobra.Command, args []string) {
+			runWithStore(ing, func(ctx context.Context, s *store.Service) error {
+				states, err := s.MigrationStatus(ctx)
+				if err != nil {
+					return err
+				}
+
+				w := tabwriter.NewWriter(ing.Stdout, 0, 8, 2, ' ', 0)
+				fmt.Fprintln(w, "ID\tNAME\tAPPLIED AT")
+				for _, state := range states {
+					applied := "pending"
+					if state.AppliedAt != nil {
+						applied = state.AppliedAt.Format(time.RFC3339)
+					}
+					fmt.Fprintf(w, "%d\t%s\t%s\n", state.ID, state.Name, applied)
+				}
+				return w.Flush()
+			})
+		},
+	}
+}
+
+func newMigrateUpCommand(ing *options.Indagate) *cobra.Command {
+	return &cobra.Command{
+		Use:   "up [id]",
+		Short: "Apply the pending migrations, up to id if given",
+		Args:  cobra.MaximumNArgs(1),
+		Run: func(cmd *cobra.Command, args []string) {
+			target := parseMigrationID(args)
+			runWithStore(ing, func(ctx context.Context, s *store.Service) error {
+				return s.MigrateUp(ctx, target)
+			})
+		},
+	}
+}
+
+func newMigrateDownCommand(ing *options.Indagate) *cobra.Command {
+	var to uint64
+	cmd := &cobra.Command{
+		Use:   "down",
+		Short: "Revert the last applied migration, or all migrations after --to",
+		Args:  cobra.NoArgs,
+		Run: func(cmd *cobra.Command, args []string) {
+			runWithStore(ing, func(ctx context.Context, s *store.Service) error {
+				target := to
+				if !cmd.Flags().Changed("to") {
+					last, err := lastAppliedMigration(ctx, s)
+					if err != nil {
+						return err
+					}
+					if last == 0 {
+						return nil
+					}
+					target = last - 1
+				}
+				return s.MigrateDown(ctx, target)
+			})
+		},
+	}
+	cmd.Flags().Uint64Var(&to, "to", 0, "Revert every migration with a greater id.")
+	return cmd
+}
+
+func runWithStore(ing *options.Indagate, fn func(ctx context.Context, s *store.Service) error) {
+	ctx := context.Background()
+	s, err := ing.OpenStore(ctx)
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		os.Exit(1)
+	}
+
+	err = fn(ctx, s)
+	ing.CloseStore()
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		os.Exit(1)
+	}
+}
+
+func parseMigrationID(args []string) uint64 {
+	if len(args) == 0 {
+		return 0
+	}
+
+	id, err := strconv.ParseUint(args[0], 10, 64)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "invalid migration id %s: %v\n", args[0], err)
+		os.Exit(1)
+	}
+	return id
+}
+
+// lastAppliedMigration returns the id of the newest applied migration, zero if there is none.
+func lastAppliedMigration(ctx context.Context, s *store.Service) (uint64, error) {
+	states, err := s.MigrationStatus(ctx)
+	if err != nil {
+		return 0, err
+	}
+
+	var last uint64
+	for _, state := range states {
+		if state.AppliedAt != nil {
+			last = state.ID
+		}
+	}
+	return last, nil
+}
@@ -0,0 +1,100 @@
+package options
+
+import (
+	"context"
+	"os"
+	"os/signal"
+	"syscall"
+	"time"
+
+	"go.uber.org/zap"
+
+	"github.com/ustackq/indagate/pkg/filter"
+	"github.com/ustackq/indagate/pkg/service"
+)
+
+// contentFilterConfig define the filters run on questions, answers, comments and
+// messages before they are written.
+type contentFilterConfig struct {
+	// rejectWords and holdWords are the paths of the word lists
+	rejectWords []string
+	holdWords   []string
+	// holdLinks and rejectLinks limit the links of a content
+	holdLinks   int
+	rejectLinks int
+	// repeatWindow define how long in minutes the contents of users are remembered
+	repeatWindow int64
+	// spam enables the Bayesian spam filter
+	spam bool
+}
+
+// openContentFilter builds the content filter chain of the store service, the
+// word lists are reloaded on SIGHUP until the server stops.
+func (ing *Indagate) openContentFilter(ctx context.Context) error {
+	cfg := ing.contentFilter
+
+	var (
+		chain    service.ContentFilters
+		keywords []*filter.KeywordFilter
+	)
+	lists := []struct {
+		verdict service.FilterVerdict
+		paths   []string
+	}{
+		{service.RejectContent, cfg.rejectWords},
+		{service.HoldContent, cfg.holdWords},
+	}
+	for _, l := range lists {
+		if len(l.paths) == 0 {
+			continue
+		}
+
+		f, err := filter.NewKeywordFilter(l.verdict, l.paths...)
+		if err != nil {
+			return err
+		}
+		ing.Logger.Info("loaded word lists", zap.Strings("paths", l.paths), zap.Int("words", f.Len()))
+		keywords = append(keywords, f)
+		chain = append(chain, f)
+	}
+
+	if cfg.holdLinks > 0 || cfg.rejectLinks > 0 {
+		chain = append(chain, &filter.LinkFilter{Hold: cfg.holdLinks, Reject: cfg.rejectLinks})
+	}
+
+	chain = append(chain, filter.NewRepeatFilter(time.Duration(cfg.repeatWindow)*time.Minute))
+
+	if cfg.spam {
+		bayes := filter.NewBayes()
+		chain = append(chain, bayes)
+		ing.storeService.SpamTrainer = bayes
+	}
+	ing.storeService.ContentFilter = chain
+
+	if len(keywords) == 0 {
+		return nil
+	}
+
+	hup := make(chan os.Signal, 1)
+	signal.Notify(hup, syscall.SIGHUP)
+	ing.wg.Add(1)
+	go func() {
+		defer ing.wg.Done()
+		defer signal.Stop(hup)
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case <-hup:
+				for _, f := range keywords {
+					if err := f.Reload(); err != nil {
+						ing.Logger.Warn("failed to reload word lists", zap.Error(err))
+						continue
+					}
+					ing.Logger.Info("reloaded word lists", zap.Int("words", f.Len()))
+				}
+			}
+		}
+	}()
+	return nil
+}
@@ -0,0 +1,53 @@
+package options
+
+import (
+	"context"
+	"os"
+
+	"go.uber.org/zap"
+
+	"github.com/ustackq/indagate/pkg/mail"
+)
+
+// mailConfig define the mail server outbound emails are sent to.
+type mailConfig struct {
+	// smtp is the mail server, no mail is sent without a host
+	smtp mail.SMTPConfig
+	// from is the sender of the mails
+	from string
+	// templates is the directory of the mail templates overriding the default ones
+	templates string
+	// baseURL is the address of the site the links of the mails point to
+	baseURL string
+}
+
+// openMailer builds the mailer which queues the account and notification mails,
+// and delivers the queued mails until the server stops. Without a smtp host the
+// mails are queued but not delivered.
+func (ing *Indagate) openMailer(ctx context.Context) error {
+	cfg := ing.mail
+	m := mail.NewMailer(ing.storeService, mail.NewSMTPSender(cfg.smtp), cfg.from)
+	m.Logger = ing.Logger.With(zap.String("service", "mail"))
+	m.BaseURL = cfg.baseURL
+	if cfg.templates != "" {
+		t, err := mail.ParseTemplates(os.DirFS(cfg.templates))
+		if err != nil {
+			return err
+		}
+		m.Templates = t
+	}
+	ing.mailer = m
+	ing.storeService.NotificationMailer = m
+
+	if cfg.smtp.Host == "" {
+		ing.Logger.Info("no smtp host, mails stay queued")
+		return nil
+	}
+
+	ing.wg.Add(1)
+	go func() {
+		defer ing.wg.Done()
+		m.Run(ctx)
+	}()
+	return nil
+}
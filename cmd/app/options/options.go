@@ -14,16 +14,23 @@ import (
 	"go.uber.org/zap/zapcore"
 	"gopkg.in/yaml.v2"
 
+	_ "github.com/go-sql-driver/mysql"
+	_ "github.com/lib/pq"
+	_ "github.com/mattn/go-sqlite3"
 	"github.com/prometheus/client_golang/prometheus"
+	"github.com/spf13/pflag"
 	"github.com/ustackq/indagate/config"
 	"github.com/ustackq/indagate/pkg/http"
 	"github.com/ustackq/indagate/pkg/logger"
+	"github.com/ustackq/indagate/pkg/mail"
 	"github.com/ustackq/indagate/pkg/metrics"
 	"github.com/ustackq/indagate/pkg/nats"
 	"github.com/ustackq/indagate/pkg/server"
 	"github.com/ustackq/indagate/pkg/service"
 	"github.com/ustackq/indagate/pkg/store"
 	"github.com/ustackq/indagate/pkg/store/bolt"
+	"github.com/ustackq/indagate/pkg/store/inmem"
+	isql "github.com/ustackq/indagate/pkg/store/sql"
 	"github.com/ustackq/indagate/pkg/tracing"
 	"github.com/ustackq/indagate/pkg/version"
 	"github.com/ustackq/indagate/routes"
@@ -53,10 +60,29 @@ type Indagate struct {
 	// bolt config
 	boltClient *bolt.Client
 	boltPath   string
+	// sql config, used by the mysql, postgres and sqlite3 stores
+	sqlConfig isql.Config
+	sqlStore  *isql.KVStore
 	// storeConfig means the kind of store,now supported:mysql、postsql
 	storeService *store.Service
 	// sessionLength define session store time
 	sessionLength int64
+	// commentEditWindow define how long in minutes authors can edit their comments
+	commentEditWindow int64
+	// messageQPS and messageBurst limit the private messages sent by each user
+	messageQPS   float32
+	messageBurst int
+	// feedActiveWindow define how long in hours after reading their feed users get activities fanned out
+	feedActiveWindow int64
+	// approvalPolicy define which posts wait for the approval of a reviewer
+	approvalPolicy service.ApprovalPolicy
+	// contentFilter define the filters run on user content before it is written
+	contentFilter contentFilterConfig
+	// trustedProxies define the proxies whose X-Forwarded-For header gives the client address
+	trustedProxies []string
+	// mail define the mail server and templates of outbound emails
+	mail   mailConfig
+	mailer *mail.Mailer
 	// secretConfig define the kind of store, now supported:mysql、vault
 	secretConfig config.Store
 	// tracingType define app tracing type: now supported: opentracing、opencensus
@@ -90,6 +116,43 @@ func NewIndagateFlags() *Indagate {
 	return &Indagate{}
 }
 
+// AddFlags adds the flags configuring the store to fs.
+func (ing *Indagate) AddFlags(fs *pflag.FlagSet) {
+	fs.StringVar(&ing.storeType, "store", store.BblotStore, "Backing store, now supported: bblot, mysql, postgres, sqlite3 and memory.")
+	fs.StringVar(&ing.boltPath, "bolt-path", "indagate.bolt", "Path to the bolt database file.")
+	fs.Int64Var(&ing.sessionLength, "session-length", 60, "TTL in minutes of newly created sessions.")
+	fs.Int64Var(&ing.commentEditWindow, "comment-edit-window", 15, "Minutes during which authors can edit their comments.")
+	fs.Int64Var(&ing.feedActiveWindow, "feed-active-window", 168, "Hours after their last feed read during which activities are written to the feed of users.")
+	fs.IntVar(&ing.approvalPolicy.ReputationThreshold, "approval-reputation", 0, "Reputation under which posts and edits wait for approval, 0 disables the rule.")
+	fs.IntVar(&ing.approvalPolicy.FirstPosts, "approval-first-posts", 0, "Number of first posts of each user which wait for approval.")
+	fs.BoolVar(&ing.approvalPolicy.Links, "approval-links", false, "Posts and edits containing links wait for approval.")
+	fs.StringSliceVar(&ing.contentFilter.rejectWords, "filter-reject-words", nil, "Word lists whose words get content rejected, reloaded on SIGHUP.")
+	fs.StringSliceVar(&ing.contentFilter.holdWords, "filter-hold-words", nil, "Word lists whose words get content held for approval, reloaded on SIGHUP.")
+	fs.IntVar(&ing.contentFilter.holdLinks, "filter-hold-links", 0, "Number of links from which content is held for approval, 0 disables the rule.")
+	fs.IntVar(&ing.contentFilter.rejectLinks, "filter-reject-links", 0, "Number of links from which content is rejected, 0 disables the rule.")
+	fs.Int64Var(&ing.contentFilter.repeatWindow, "filter-repeat-window", 10, "Minutes during which content posted again by a user is held for approval, 0 disables the rule.")
+	fs.BoolVar(&ing.contentFilter.spam, "filter-spam", false, "Hold or reject content scored as spam from moderator decisions.")
+	fs.StringSliceVar(&ing.trustedProxies, "trusted-proxies", nil, "Addresses or CIDR ranges of the proxies whose X-Forwarded-For header gives the client address.")
+	fs.StringVar(&ing.mail.smtp.Host, "smtp-host", "", "Host of the mail server, no mail is sent without one.")
+	fs.StringVar(&ing.mail.smtp.Port, "smtp-port", "587", "Port of the mail server, 465 for SMTPS.")
+	fs.StringVar(&ing.mail.smtp.User, "smtp-user", "", "User of the mail server.")
+	fs.StringVar(&ing.mail.smtp.Passwd, "smtp-password", "", "Password of the mail server user.")
+	fs.BoolVar(&ing.mail.smtp.SkipVerify, "smtp-skip-verify", false, "Skip the verification of the mail server certificate.")
+	fs.DurationVar(&ing.mail.smtp.Timeout, "smtp-timeout", mail.DefaultSMTPTimeout, "Time limit of the delivery of each mail.")
+	fs.StringVar(&ing.mail.from, "mail-from", "Indagate <noreply@localhost>", "Sender of the mails.")
+	fs.StringVar(&ing.mail.templates, "mail-templates", "", "Directory of mail templates overriding the default ones.")
+	fs.StringVar(&ing.mail.baseURL, "mail-base-url", "http://localhost", "Address of the site the links of the mails point to.")
+	fs.Float32Var(&ing.messageQPS, "message-qps", http.DefaultMessageQPS, "Private messages per second each user may send.")
+	fs.IntVar(&ing.messageBurst, "message-burst", http.DefaultMessageBurst, "Private messages each user may send at once.")
+	fs.StringVar(&ing.sqlConfig.Host, "sql-host", "127.0.0.1:3306", "Address of the sql database.")
+	fs.StringVar(&ing.sqlConfig.Port, "sql-port", "", "Port of the sql database, postgres only.")
+	fs.StringVar(&ing.sqlConfig.Name, "sql-name", "indagate", "Name of the sql database.")
+	fs.StringVar(&ing.sqlConfig.User, "sql-user", "indagate", "User of the sql database.")
+	fs.StringVar(&ing.sqlConfig.Passwd, "sql-password", "", "Password of the sql database user.")
+	fs.StringVar(&ing.sqlConfig.SSLMode, "sql-sslmode", "disable", "SSL mode of the postgres connection.")
+	fs.StringVar(&ing.sqlConfig.Path, "sql-path", "data/indagate.db", "Path to the sqlite3 database file.")
+}
+
 // ValidataIndagate validate Indagate flag config
 func ValidataIndagate(ing *Indagate) error {
 	return nil
@@ -112,6 +175,12 @@ func (ing *Indagate) Shutdown(ctx context.Context) {
 	ing.natsServer.Close()
 	ing.wg.Wait()
 
+	if ing.sqlStore != nil {
+		if err := ing.sqlStore.Close(); err != nil {
+			ing.Logger.Warn("failed to close sql store", zap.Error(err))
+		}
+	}
+
 	if ing.jaegerTracerCloser != nil {
 		if err := ing.jaegerTracerCloser.Close(); err != nil {
 			ing.Logger.Warn("failed to close jaeger tracer", zap.Error(err))
@@ -206,37 +275,23 @@ func (ing *Indagate) Run(ctx context.Context) (err error) {
 		// sth need to be done here.
 	}
 
-	// TODO: will using sql instead
-	// init store client
-	ing.boltClient = bolt.NewClient()
-	ing.boltClient.Path = ing.boltPath
-	ing.boltClient.WithLogger(ing.Logger.With(zap.String("service", "bbolt")))
-
-	// Open bbolt
-	if err := ing.boltClient.Open(ctx); err != nil {
-		ing.Logger.Error("failed open", zap.String("service", "bbolt"), zap.Error(err))
-		return err
-	}
-
 	serviceConfig := store.ServiceConfig{
-		SessionLength: time.Duration(ing.sessionLength) * time.Minute,
+		SessionLength:     time.Duration(ing.sessionLength) * time.Minute,
+		CommentEditWindow: time.Duration(ing.commentEditWindow) * time.Minute,
+		FeedActiveWindow:  time.Duration(ing.feedActiveWindow) * time.Hour,
+		ApprovalPolicy:    ing.approvalPolicy,
 	}
-
-	// config store
-	switch ing.storeType {
-	case store.BblotStore:
-		s := bolt.NewKVStore(ing.boltPath)
-		s.WithDB(ing.boltClient.DB())
-		ing.storeService = store.NewService(s, serviceConfig)
-		// TODO: how to testing
-	default:
-		err := fmt.Errorf("unknown store type %s: excepted bolt", ing.storeType)
-		ing.Logger.Error("expected bolt, unknown type", zap.String("store", ing.storeType))
+	if err := ing.openStore(ctx, serviceConfig); err != nil {
 		return err
 	}
 
 	// config log
 	ing.storeService.Logger = ing.Logger.With(zap.String("store", ing.storeType))
+	// filters are set before init trains the spam filter
+	if err := ing.openContentFilter(ctx); err != nil {
+		ing.Logger.Error("failed to open content filters", zap.Error(err))
+		return err
+	}
 	// init store
 	if err := ing.storeService.Init(ctx); err != nil {
 		ing.Logger.Error("failed to init store", zap.Error(err))
@@ -254,10 +309,43 @@ func (ing *Indagate) Run(ctx context.Context) (err error) {
 	)
 	ing.register.WithLogger(ing.Logger)
 	// TODO: serviceCollector
-	ing.register.MustRegister(ing.boltClient)
+	if ing.boltClient != nil {
+		ing.register.MustRegister(ing.boltClient)
+	}
+	ing.register.MustRegister(ing.storeService.PrometheusCollectors()...)
+
+	// sweep expired sessions until the server stops
+	ing.wg.Add(1)
+	go func() {
+		defer ing.wg.Done()
+		ing.storeService.RunSessionSweeper(ctx, store.DefaultSessionSweepPeriod, ctx.Done())
+	}()
+	// deliver the queued mails until the server stops
+	if err := ing.openMailer(ctx); err != nil {
+		ing.Logger.Error("failed to open mailer", zap.Error(err))
+		return err
+	}
 	// TODO: add other services
 	var (
-		auth service.AuthorizationService = ing.storeService
+		auth     service.AuthorizationService       = ing.storeService
+		question service.QuestionService            = ing.storeService
+		answer   service.AnswerService              = ing.storeService
+		urm      service.UserResourceMappingService = ing.storeService
+		role     service.RoleService                = ing.storeService
+		session  service.SessionService             = ing.storeService
+		vote     service.VoteService                = ing.storeService
+		comment  service.CommentService             = ing.storeService
+		topic    service.TopicService               = ing.storeService
+		article  service.ArticleService             = ing.storeService
+		draft    service.DraftService               = ing.storeService
+		notify   service.NotificationService        = ing.storeService
+		message  service.MessageService             = ing.storeService
+		follow   service.FollowService              = ing.storeService
+		feed     service.FeedService                = ing.storeService
+		search   service.SearchService              = ing.storeService
+		moderate service.ModerationService          = ing.storeService
+		approval service.ApprovalService            = ing.storeService
+		ban      service.BanService                 = ing.storeService
 	)
 	// todo： supported other store
 	switch ing.secretType {
@@ -288,10 +376,47 @@ func (ing *Indagate) Run(ctx context.Context) (err error) {
 
 	// registry prometheus metrics
 
+	trustedProxies := make([]*net.IPNet, 0, len(ing.trustedProxies))
+	for _, p := range ing.trustedProxies {
+		n, err := service.ParseCIDR(p)
+		if err != nil {
+			ing.Logger.Error("invalid trusted proxy", zap.String("proxy", p), zap.Error(err))
+			return err
+		}
+		trustedProxies = append(trustedProxies, n)
+	}
+
 	// build backend
 	ing.backend = &http.APIBackend{
-		Logger:                ing.Logger,
-		AuthenticationService: auth,
+		Logger:                     ing.Logger,
+		AuthenticationService:      auth,
+		QuestionService:            question,
+		AnswerService:              answer,
+		UserResourceMappingService: urm,
+		RoleService:                role,
+		SessionService:             session,
+		VoteService:                vote,
+		CommentService:             comment,
+		TopicService:               topic,
+		ArticleService:             article,
+		DraftService:               draft,
+		NotificationService:        notify,
+		MessageService:             message,
+		FollowService:              follow,
+		FeedService:                feed,
+		SearchService:              search,
+		ModerationService:          moderate,
+		ApprovalService:            approval,
+		BanService:                 ban,
+		AccountTokenService:        ing.storeService,
+		AccountMailer:              ing.mailer,
+		MessageQPS:                 ing.messageQPS,
+		MessageBurst:               ing.messageBurst,
+		TrustedProxies:             trustedProxies,
+	}
+	// snapshots are taken of the bolt database, other stores have their own tools.
+	if ing.storeType == store.BblotStore {
+		ing.backend.BackupService = ing.boltClient
 	}
 
 	// http logger
@@ -330,3 +455,50 @@ func (ing *Indagate) Run(ctx context.Context) (err error) {
 	}(httpLogger)
 	return nil
 }
+
+// openStore opens the configured backing store and builds the store service on top of it.
+func (ing *Indagate) openStore(ctx context.Context, serviceConfig store.ServiceConfig) error {
+	// config store
+	switch ing.storeType {
+	case store.BblotStore:
+		// init store client
+		ing.boltClient = bolt.NewClient()
+		ing.boltClient.Path = ing.boltPath
+		ing.boltClient.WithLogger(ing.Logger.With(zap.String("service", "bbolt")))
+
+		// Open bbolt
+		if err := ing.boltClient.Open(ctx); err != nil {
+			ing.Logger.Error("failed open", zap.String("service", "bbolt"), zap.Error(err))
+			return err
+		}
+
+		s := bolt.NewKVStore(ing.boltPath)
+		s.WithDB(ing.boltClient.DB())
+		ing.storeService = store.NewService(s, serviceConfig)
+		// TODO: how to testing
+	case store.MysqlStore, store.PostgresStore, store.SQLiteStore:
+		ing.sqlConfig.Type = ing.storeType
+		dsn, err := isql.ConnStr(ing.sqlConfig)
+		if err != nil {
+			ing.Logger.Error("invalid sql config", zap.String("store", ing.storeType), zap.Error(err))
+			return err
+		}
+
+		s := isql.NewKVStore(ing.storeType, dsn)
+		s.WithLogger(ing.Logger.With(zap.String("service", ing.storeType)))
+		if err := s.Open(ctx); err != nil {
+			ing.Logger.Error("failed open", zap.String("service", ing.storeType), zap.Error(err))
+			return err
+		}
+		ing.sqlStore = s
+		ing.storeService = store.NewService(s, serviceConfig)
+	case store.MemoryStore:
+		// nothing is persisted, meant for testing and demos.
+		ing.storeService = store.NewService(inmem.NewKVStore(), serviceConfig)
+	default:
+		err := fmt.Errorf("unknown store type %s: excepted bolt, mysql, postgres, sqlite3 or memory", ing.storeType)
+		ing.Logger.Error("expected bolt, mysql, postgres, sqlite3 or memory, unknown type", zap.String("store", ing.storeType))
+		return err
+	}
+	return nil
+}
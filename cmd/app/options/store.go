@@ -0,0 +1,62 @@
+package options
+
+import (
+	"context"
+	"time"
+
+	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
+
+	"github.com/ustackq/indagate/pkg/logger"
+	"github.com/ustackq/indagate/pkg/store"
+)
+
+// OpenStore opens the configured store for the administrative commands,
+// contrary to Run pending migrations are not applied.
+func (ing *Indagate) OpenStore(ctx context.Context) (*store.Service, error) {
+	if ing.Logger == nil {
+		logConf := &logger.Config{
+			Format: "auto",
+			Level:  zapcore.InfoLevel,
+		}
+		log, err := logConf.New(ing.Stderr)
+		if err != nil {
+			return nil, err
+		}
+		ing.Logger = log
+	}
+
+	serviceConfig := store.ServiceConfig{
+		SessionLength:     time.Duration(ing.sessionLength) * time.Minute,
+		CommentEditWindow: time.Duration(ing.commentEditWindow) * time.Minute,
+		FeedActiveWindow:  time.Duration(ing.feedActiveWindow) * time.Hour,
+		ApprovalPolicy:    ing.approvalPolicy,
+		SkipMigrations:    true,
+	}
+	if err := ing.openStore(ctx, serviceConfig); err != nil {
+		return nil, err
+	}
+
+	ing.storeService.Logger = ing.Logger.With(zap.String("store", ing.storeType))
+	if err := ing.storeService.Init(ctx); err != nil {
+		ing.CloseStore()
+		return nil, err
+	}
+	return ing.storeService, nil
+}
+
+// CloseStore closes the store opened by OpenStore.
+func (ing *Indagate) CloseStore() {
+	if ing.sqlStore != nil {
+		if err := ing.sqlStore.Close(); err != nil {
+			ing.Logger.Warn("failed to close sql store", zap.Error(err))
+		}
+	}
+
+	if ing.boltClient != nil {
+		if err := ing.boltClient.Close(); err != nil {
+			ing.Logger.Warn("failed to close bolt", zap.Error(err))
+		}
+	}
+	ing.Logger.Sync()
+}
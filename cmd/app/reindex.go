@@ -0,0 +1,36 @@
+package app
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/spf13/cobra"
+	"github.com/spf13/viper"
+
+	"github.com/ustackq/indagate/cmd/app/options"
+	"github.com/ustackq/indagate/pkg/store"
+)
+
+// NewReindexCommand returns the command rebuilding the full-text search index.
+func NewReindexCommand() *cobra.Command {
+	ing := options.NewIndagateOptions(viper.GetString("config"))
+
+	cmd := &cobra.Command{
+		Use:   "reindex",
+		Short: "Rebuild the full-text search index from the content of the store",
+		Args:  cobra.NoArgs,
+		Run: func(cmd *cobra.Command, args []string) {
+			runWithStore(ing, func(ctx context.Context, s *store.Service) error {
+				n, err := s.Reindex(ctx)
+				if err != nil {
+					return err
+				}
+
+				fmt.Fprintf(ing.Stdout, "indexed %d documents\n", n)
+				return nil
+			})
+		},
+	}
+	ing.AddFlags(cmd.Flags())
+	return cmd
+}
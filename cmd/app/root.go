@@ -30,4 +30,11 @@ func init() {
 	// Bind env config
 	viper.BindEnv("config")
 	viper.BindPFlag("config", RootCmd.PersistentFlags().Lookup("config"))
+
+	RootCmd.AddCommand(NewMigrateCommand())
+	RootCmd.AddCommand(NewBackupCommand())
+	RootCmd.AddCommand(NewRestoreCommand())
+	RootCmd.AddCommand(NewExportCommand())
+	RootCmd.AddCommand(NewImportCommand())
+	RootCmd.AddCommand(NewReindexCommand())
 }
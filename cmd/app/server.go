@@ -58,6 +58,7 @@ func NewServeCommand() *cobra.Command {
 
 		},
 	}
+	ing.AddFlags(cmd.Flags())
 	// init context
 	return cmd
 }
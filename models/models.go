@@ -3,7 +3,6 @@ package models
 import (
 	"encoding/json"
 	"fmt"
-	"net/url"
 	"os"
 	"path"
 	"strings"
@@ -20,6 +19,7 @@ import (
 	_ "github.com/mattn/go-sqlite3"
 
 	"github.com/ustack/Yunus/src/app/backend/pkg/setting"
+	isql "github.com/ustackq/indagate/pkg/store/sql"
 )
 
 // Engine define XORM engine or session
@@ -126,30 +126,16 @@ func getEngine() (*xorm.Engine, error) {
 
 // GeneratorconnStr return sql connection string
 func GeneratorconnStr() (string, error) {
-	connStr := ""
-	Param := "?"
-	if strings.Contains(DbCfg.Name, Param) {
-		Param = "&"
-	}
-	// Checkout SQL Type
-	switch DbCfg.Type {
-	case "mysql":
-		connStr = fmt.Sprintf("%s:%s@tcp(%s)/%s%scharset=utf8&parseTime=true",
-			DbCfg.User, DbCfg.Passwd, DbCfg.Host, DbCfg.Name, Param)
-	case "postgres":
-		connStr = fmt.Sprintf("postgres://%s:%s@%s:%s/%s%ssslmode=%s",
-			url.QueryEscape(DbCfg.User), url.QueryEscape(DbCfg.Passwd), DbCfg.Host, DbCfg.Port, DbCfg.Name, Param, DbCfg.SSLMode)
-	case "mssql":
-		connStr = fmt.Sprintf("server=%s; port=%s; database=%s; user id=%s; password=%s;", DbCfg.Host, DbCfg.Port, DbCfg.Name, DbCfg.User, DbCfg.Passwd)
-	case "sqlite3":
-		if err := os.MkdirAll(path.Dir(DbCfg.Path), os.ModePerm); err != nil {
-			return "", fmt.Errorf("Fail to create directories: %v", err)
-		}
-		connStr = "file:" + DbCfg.Path + "?cache=shared&mode=rwc"
-	default:
-		return "", fmt.Errorf("Unknown database type: %s", DbCfg.Type)
-	}
-	return connStr, nil
+	return isql.ConnStr(isql.Config{
+		Type:    DbCfg.Type,
+		Host:    DbCfg.Host,
+		Port:    DbCfg.Port,
+		Name:    DbCfg.Name,
+		User:    DbCfg.User,
+		Passwd:  DbCfg.Passwd,
+		SSLMode: DbCfg.SSLMode,
+		Path:    DbCfg.Path,
+	})
 }
 
 // Statistic define resource count
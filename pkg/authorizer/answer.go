@@ -0,0 +1,190 @@
+package authorizer
+
+import (
+	"context"
+
+	icontext "github.com/ustackq/indagate/pkg/context"
+	"github.com/ustackq/indagate/pkg/service"
+	"github.com/ustackq/indagate/pkg/utils/errors"
+)
+
+var _ service.AnswerService = (*AnswerService)(nil)
+
+// AnswerService wraps a service.AnswerService and authorizes actions
+// against it appropriately.
+type AnswerService struct {
+	s service.AnswerService
+	q service.QuestionService
+}
+
+// NewAnswerService constructs an instance of an authorizing answer service.
+// The question service is used to look up the question an answer belongs to.
+func NewAnswerService(s service.AnswerService, q service.QuestionService) *AnswerService {
+	return &AnswerService{
+		s: s,
+		q: q,
+	}
+}
+
+func authorizeAnswerByAction(ctx context.Context, action service.Action, orgID, id service.ID) error {
+	p, err := service.NewPermissionAtID(id, action, service.AnswersResourceType, orgID)
+	if err != nil {
+		return err
+	}
+
+	if err := isAllowed(ctx, *p); err != nil {
+		return err
+	}
+
+	return nil
+}
+
+// authorizeEditAnswer allows the author of the answer, otherwise it requires
+// write access to the answer as a moderator would have.
+func authorizeEditAnswer(ctx context.Context, a *service.Answer) error {
+	auth, err := icontext.GetAuthorizer(ctx)
+	if err != nil {
+		return err
+	}
+
+	if auth.GetUserID() == a.UserID {
+		return nil
+	}
+
+	return authorizeAnswerByAction(ctx, service.WriteAction, a.OrgID, a.ID)
+}
+
+// authorizeReadAnswer requires read access to the answer, hidden answers are only
+// readable by those who can moderate them and pending ones by those who can edit them.
+func authorizeReadAnswer(ctx context.Context, a *service.Answer) error {
+	if a.Hidden {
+		return authorizeAnswerByAction(ctx, service.WriteAction, a.OrgID, a.ID)
+	}
+
+	if a.Pending {
+		return authorizeEditAnswer(ctx, a)
+	}
+
+	return authorizeAnswerByAction(ctx, service.ReadAction, a.OrgID, a.ID)
+}
+
+// redactAnswer removes the edit waiting for approval from the answer unless the
+// authorizer on context can edit it.
+func redactAnswer(ctx context.Context, a *service.Answer) error {
+	if a.UnverifiedModify == nil {
+		return nil
+	}
+
+	err := authorizeEditAnswer(ctx, a)
+	if err != nil && errors.ErrorCode(err) != errors.Unauthorized {
+		return err
+	}
+
+	if err != nil {
+		a.UnverifiedModify = nil
+	}
+	return nil
+}
+
+// FindAnswerByID checks to see if the authorizer on context has read access to the answer.
+func (s *AnswerService) FindAnswerByID(ctx context.Context, id service.ID) (*service.Answer, error) {
+	a, err := s.s.FindAnswerByID(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := authorizeReadAnswer(ctx, a); err != nil {
+		return nil, err
+	}
+
+	if err := redactAnswer(ctx, a); err != nil {
+		return nil, err
+	}
+
+	return a, nil
+}
+
+// FindAnswers retrieves all answers that match the provided filter and then filters the list down to only the resources that are authorized.
+func (s *AnswerService) FindAnswers(ctx context.Context, filter service.AnswerFilter, opts ...service.FindOptions) ([]*service.Answer, int, error) {
+	as, _, err := s.s.FindAnswers(ctx, filter, unpaged(opts)...)
+	if err != nil {
+		return nil, 0, err
+	}
+
+	answers := as[:0]
+	for _, a := range as {
+		err := authorizeReadAnswer(ctx, a)
+		if err != nil && errors.ErrorCode(err) != errors.Unauthorized {
+			return nil, 0, err
+		}
+
+		if errors.ErrorCode(err) == errors.Unauthorized {
+			continue
+		}
+
+		if err := redactAnswer(ctx, a); err != nil {
+			return nil, 0, err
+		}
+
+		answers = append(answers, a)
+	}
+
+	start, end := service.PageWindow(len(answers), opts...)
+	return answers[start:end], len(answers), nil
+}
+
+// CreateAnswer checks to see if the authorizer on context is able to read the organization of the question.
+func (s *AnswerService) CreateAnswer(ctx context.Context, a *service.Answer) error {
+	q, err := s.q.FindQuestionByID(ctx, a.QuestionID)
+	if err != nil {
+		return err
+	}
+
+	if err := authorizeOrgByAction(service.ReadAction, ctx, q.OrgID); err != nil {
+		return err
+	}
+
+	return s.s.CreateAnswer(ctx, a)
+}
+
+// UpdateAnswer checks to see if the authorizer on context owns the answer or has write access to it.
+func (s *AnswerService) UpdateAnswer(ctx context.Context, id service.ID, upd service.AnswerUpdate) (*service.Answer, error) {
+	a, err := s.s.FindAnswerByID(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := authorizeEditAnswer(ctx, a); err != nil {
+		return nil, err
+	}
+
+	return s.s.UpdateAnswer(ctx, id, upd)
+}
+
+// DeleteAnswer checks to see if the authorizer on context owns the answer or has write access to it.
+func (s *AnswerService) DeleteAnswer(ctx context.Context, id service.ID) error {
+	a, err := s.s.FindAnswerByID(ctx, id)
+	if err != nil {
+		return err
+	}
+
+	if err := authorizeEditAnswer(ctx, a); err != nil {
+		return err
+	}
+
+	return s.s.DeleteAnswer(ctx, id)
+}
+
+// SetBestAnswer checks to see if the authorizer on context owns the question or has write access to it.
+func (s *AnswerService) SetBestAnswer(ctx context.Context, questionID, answerID service.ID) (*service.Question, error) {
+	q, err := s.q.FindQuestionByID(ctx, questionID)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := authorizeEditQuestion(ctx, q); err != nil {
+		return nil, err
+	}
+
+	return s.s.SetBestAnswer(ctx, questionID, answerID)
+}
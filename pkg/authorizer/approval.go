@@ -0,0 +1,120 @@
+package authorizer
+
+import (
+	"context"
+
+	icontext "github.com/ustackq/indagate/pkg/context"
+	"github.com/ustackq/indagate/pkg/service"
+	"github.com/ustackq/indagate/pkg/utils/errors"
+)
+
+var _ service.ApprovalService = (*ApprovalService)(nil)
+
+// ApprovalService wraps a service.ApprovalService and authorizes actions
+// against it appropriately.
+type ApprovalService struct {
+	s service.ApprovalService
+}
+
+// NewApprovalService constructs an instance of an authorizing approval service.
+func NewApprovalService(s service.ApprovalService) *ApprovalService {
+	return &ApprovalService{
+		s: s,
+	}
+}
+
+// authorizeReadApproval allows the author of the post, otherwise it requires the
+// moderation of the org of the post.
+func authorizeReadApproval(ctx context.Context, a *service.Approval) error {
+	auth, err := icontext.GetAuthorizer(ctx)
+	if err != nil {
+		return err
+	}
+
+	if auth.GetUserID() == a.UserID {
+		return nil
+	}
+
+	return authorizeModerate(ctx, service.ReadAction, a.OrgID)
+}
+
+// FindApprovalByID checks to see if the authorizer on context authored the post or moderates its org.
+func (s *ApprovalService) FindApprovalByID(ctx context.Context, id service.ID) (*service.Approval, error) {
+	a, err := s.s.FindApprovalByID(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := authorizeReadApproval(ctx, a); err != nil {
+		return nil, err
+	}
+
+	return a, nil
+}
+
+// FindApprovals retrieves the approvals matching the filter and then filters them down to
+// the ones of the posts authored by, or of the orgs moderated by, the authorizer on context.
+func (s *ApprovalService) FindApprovals(ctx context.Context, filter service.ApprovalFilter, opts ...service.FindOptions) ([]*service.Approval, int, error) {
+	if filter.OrgID != nil {
+		if err := authorizeModerate(ctx, service.ReadAction, *filter.OrgID); err != nil {
+			return nil, 0, err
+		}
+
+		return s.s.FindApprovals(ctx, filter, opts...)
+	}
+
+	as, _, err := s.s.FindApprovals(ctx, filter, unpaged(opts)...)
+	if err != nil {
+		return nil, 0, err
+	}
+
+	approvals := as[:0]
+	for _, a := range as {
+		err := authorizeReadApproval(ctx, a)
+		if err != nil && errors.ErrorCode(err) != errors.Unauthorized {
+			return nil, 0, err
+		}
+
+		if errors.ErrorCode(err) == errors.Unauthorized {
+			continue
+		}
+
+		approvals = append(approvals, a)
+	}
+
+	start, end := service.PageWindow(len(approvals), opts...)
+	return approvals[start:end], len(approvals), nil
+}
+
+// FindApprovalDiff checks to see if the authorizer on context authored the post or moderates its org.
+func (s *ApprovalService) FindApprovalDiff(ctx context.Context, id service.ID) ([]*service.ApprovalDiff, error) {
+	a, err := s.s.FindApprovalByID(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := authorizeReadApproval(ctx, a); err != nil {
+		return nil, err
+	}
+
+	return s.s.FindApprovalDiff(ctx, id)
+}
+
+// ReviewApproval checks to see if the authorizer on context is the reviewer of the review
+// and can write to the moderation of the org of the post.
+func (s *ApprovalService) ReviewApproval(ctx context.Context, id service.ID, r *service.ApprovalReview) (*service.Approval, error) {
+	if err := authorizeSelf(ctx, r.ReviewerID); err != nil {
+		return nil, err
+	}
+
+	a, err := s.s.FindApprovalByID(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := authorizeModerate(ctx, service.WriteAction, a.OrgID); err != nil {
+		return nil, err
+	}
+
+	return s.s.ReviewApproval(ctx, id, r)
+}
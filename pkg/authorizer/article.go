@@ -0,0 +1,189 @@
+package authorizer
+
+import (
+	"context"
+
+	icontext "github.com/ustackq/indagate/pkg/context"
+	"github.com/ustackq/indagate/pkg/service"
+	"github.com/ustackq/indagate/pkg/utils/errors"
+)
+
+var _ service.ArticleService = (*ArticleService)(nil)
+
+// ArticleService wraps a service.ArticleService and authorizes actions
+// against it appropriately.
+type ArticleService struct {
+	s service.ArticleService
+}
+
+// NewArticleService constructs an instance of an authorizing article service.
+func NewArticleService(s service.ArticleService) *ArticleService {
+	return &ArticleService{
+		s: s,
+	}
+}
+
+func authorizeArticleByAction(ctx context.Context, action service.Action, orgID, id service.ID) error {
+	p, err := service.NewPermissionAtID(id, action, service.ArticlesResourceType, orgID)
+	if err != nil {
+		return err
+	}
+
+	if err := isAllowed(ctx, *p); err != nil {
+		return err
+	}
+
+	return nil
+}
+
+// authorizeEditArticle allows the author of the article, otherwise it requires
+// write access to the article as a moderator would have.
+func authorizeEditArticle(ctx context.Context, a *service.Article) error {
+	auth, err := icontext.GetAuthorizer(ctx)
+	if err != nil {
+		return err
+	}
+
+	if auth.GetUserID() == a.UserID {
+		return nil
+	}
+
+	return authorizeArticleByAction(ctx, service.WriteAction, a.OrgID, a.ID)
+}
+
+// authorizeReadArticle requires read access to the article, drafts are only
+// readable by those who can edit them.
+func authorizeReadArticle(ctx context.Context, a *service.Article) error {
+	if a.State == service.ArticleDraft {
+		return authorizeEditArticle(ctx, a)
+	}
+
+	return authorizeArticleByAction(ctx, service.ReadAction, a.OrgID, a.ID)
+}
+
+// FindArticleByID checks to see if the authorizer on context has read access to the article.
+func (s *ArticleService) FindArticleByID(ctx context.Context, id service.ID) (*service.Article, error) {
+	a, err := s.s.FindArticleByID(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := authorizeReadArticle(ctx, a); err != nil {
+		return nil, err
+	}
+
+	return a, nil
+}
+
+// FindArticles retrieves all articles that match the provided filter and then filters the list down to only the resources that are authorized.
+func (s *ArticleService) FindArticles(ctx context.Context, filter service.ArticleFilter, opts ...service.FindOptions) ([]*service.Article, int, error) {
+	as, _, err := s.s.FindArticles(ctx, filter, unpaged(opts)...)
+	if err != nil {
+		return nil, 0, err
+	}
+
+	articles := as[:0]
+	for _, a := range as {
+		err := authorizeReadArticle(ctx, a)
+		if err != nil && errors.ErrorCode(err) != errors.Unauthorized {
+			return nil, 0, err
+		}
+
+		if errors.ErrorCode(err) == errors.Unauthorized {
+			continue
+		}
+
+		articles = append(articles, a)
+	}
+
+	start, end := service.PageWindow(len(articles), opts...)
+	return articles[start:end], len(articles), nil
+}
+
+// CreateArticle checks to see if the authorizer on context is able to read the organization,
+// every member of an organization can write an article.
+func (s *ArticleService) CreateArticle(ctx context.Context, a *service.Article) error {
+	if err := authorizeOrgByAction(service.ReadAction, ctx, a.OrgID); err != nil {
+		return err
+	}
+
+	return s.s.CreateArticle(ctx, a)
+}
+
+// UpdateArticle checks to see if the authorizer on context owns the article or has write access to it.
+func (s *ArticleService) UpdateArticle(ctx context.Context, id service.ID, upd service.ArticleUpdate) (*service.Article, error) {
+	a, err := s.s.FindArticleByID(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := authorizeEditArticle(ctx, a); err != nil {
+		return nil, err
+	}
+
+	return s.s.UpdateArticle(ctx, id, upd)
+}
+
+// DeleteArticle checks to see if the authorizer on context owns the article or has write access to it.
+func (s *ArticleService) DeleteArticle(ctx context.Context, id service.ID) error {
+	a, err := s.s.FindArticleByID(ctx, id)
+	if err != nil {
+		return err
+	}
+
+	if err := authorizeEditArticle(ctx, a); err != nil {
+		return err
+	}
+
+	return s.s.DeleteArticle(ctx, id)
+}
+
+// PublishArticle checks to see if the authorizer on context owns the article or has write access to it.
+func (s *ArticleService) PublishArticle(ctx context.Context, id service.ID) (*service.Article, error) {
+	a, err := s.s.FindArticleByID(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := authorizeEditArticle(ctx, a); err != nil {
+		return nil, err
+	}
+
+	return s.s.PublishArticle(ctx, id)
+}
+
+// ArchiveArticle checks to see if the authorizer on context owns the article or has write access to it.
+func (s *ArticleService) ArchiveArticle(ctx context.Context, id service.ID) (*service.Article, error) {
+	a, err := s.s.FindArticleByID(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := authorizeEditArticle(ctx, a); err != nil {
+		return nil, err
+	}
+
+	return s.s.ArchiveArticle(ctx, id)
+}
+
+// ViewArticle checks to see if the authorizer on context has read access to the article
+// and counts the view against the authorizer itself.
+func (s *ArticleService) ViewArticle(ctx context.Context, id, sessionID service.ID) (*service.Article, error) {
+	auth, err := icontext.GetAuthorizer(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	if auth.Identifier() != sessionID {
+		return nil, &errors.Error{
+			Code: errors.Forbidden,
+			Msg:  "views can only be counted for the current session",
+		}
+	}
+
+	if _, err := s.FindArticleByID(ctx, id); err != nil {
+		return nil, err
+	}
+
+	return s.s.ViewArticle(ctx, id, sessionID)
+}
@@ -22,3 +22,35 @@ func isAllowed(ctx context.Context, p service.Permission) error {
 	}
 	return nil
 }
+
+// authorizeAdmin checks the authorizer on context has full access to every org and user,
+// which is only granted to the admins of the instance.
+func authorizeAdmin(ctx context.Context) error {
+	for _, rt := range []service.ResourceType{service.OrgsResourceType, service.UsersResourceType} {
+		p, err := service.NewGlobalPermission(service.READWRITEACTION, rt)
+		if err != nil {
+			return err
+		}
+
+		if err := isAllowed(ctx, *p); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// authorizeSelf checks the authorizer on context belongs to the user acted for.
+func authorizeSelf(ctx context.Context, userID service.ID) error {
+	auth, err := icontext.GetAuthorizer(ctx)
+	if err != nil {
+		return err
+	}
+
+	if auth.GetUserID() != userID {
+		return &errors.Error{
+			Code: errors.Unauthorized,
+			Msg:  "users can only act for themselves",
+		}
+	}
+	return nil
+}
@@ -0,0 +1,41 @@
+package authorizer_test
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	icontext "github.com/ustackq/indagate/pkg/context"
+	"github.com/ustackq/indagate/pkg/service"
+	"github.com/ustackq/indagate/pkg/store"
+	"github.com/ustackq/indagate/pkg/store/inmem"
+)
+
+func newInmemService(t *testing.T) *store.Service {
+	t.Helper()
+
+	s := store.NewService(inmem.NewKVStore())
+	if err := s.Init(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+	return s
+}
+
+// withRole returns a context authorized as the user holding the role in the org.
+func withRole(t *testing.T, userID, orgID service.ID, role service.Role) context.Context {
+	t.Helper()
+
+	ps, err := service.UserPermissions(userID, []*service.UserResourceMapping{
+		{UserID: userID, UserType: service.Member, ResourceType: service.OrgsResourceType, ResourceID: orgID, Role: role},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	return icontext.SetAuthorizer(context.Background(), &service.Session{
+		ID:          userID,
+		UserID:      userID,
+		ExpiresAt:   time.Now().Add(time.Hour),
+		Permissions: ps,
+	})
+}
@@ -0,0 +1,32 @@
+package authorizer
+
+import (
+	"context"
+	"io"
+
+	"github.com/ustackq/indagate/pkg/service"
+)
+
+var _ service.BackupService = (*BackupService)(nil)
+
+// BackupService wraps a service.BackupService and authorizes actions
+// against it appropriately.
+type BackupService struct {
+	s service.BackupService
+}
+
+// NewBackupService constructs an instance of an authorizing backup service.
+func NewBackupService(s service.BackupService) *BackupService {
+	return &BackupService{
+		s: s,
+	}
+}
+
+// Backup checks to see if the authorizer on context is an admin of the instance.
+func (s *BackupService) Backup(ctx context.Context, w io.Writer) (*service.BackupManifest, error) {
+	if err := authorizeAdmin(ctx); err != nil {
+		return nil, err
+	}
+
+	return s.s.Backup(ctx, w)
+}
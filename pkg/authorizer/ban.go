@@ -0,0 +1,135 @@
+package authorizer
+
+import (
+	"context"
+	"net"
+
+	"github.com/ustackq/indagate/pkg/service"
+)
+
+var _ service.BanService = (*BanService)(nil)
+
+// BanService wraps a service.BanService and authorizes actions
+// against it appropriately.
+type BanService struct {
+	s service.BanService
+}
+
+// NewBanService constructs an instance of an authorizing ban service.
+func NewBanService(s service.BanService) *BanService {
+	return &BanService{
+		s: s,
+	}
+}
+
+// FindBanByID checks to see if the authorizer on context is an admin of the instance.
+func (s *BanService) FindBanByID(ctx context.Context, id service.ID) (*service.Ban, error) {
+	if err := authorizeAdmin(ctx); err != nil {
+		return nil, err
+	}
+
+	return s.s.FindBanByID(ctx, id)
+}
+
+// FindBans checks to see if the authorizer on context is an admin of the instance.
+func (s *BanService) FindBans(ctx context.Context, filter service.BanFilter, opt ...service.FindOptions) ([]*service.Ban, int, error) {
+	if err := authorizeAdmin(ctx); err != nil {
+		return nil, 0, err
+	}
+
+	return s.s.FindBans(ctx, filter, opt...)
+}
+
+// BanUser checks to see if the authorizer on context is an admin of the instance
+// banning in their own name.
+func (s *BanService) BanUser(ctx context.Context, b *service.Ban) error {
+	if err := authorizeSelf(ctx, b.CreatedBy); err != nil {
+		return err
+	}
+
+	if err := authorizeAdmin(ctx); err != nil {
+		return err
+	}
+
+	return s.s.BanUser(ctx, b)
+}
+
+// LiftBan checks to see if the authorizer on context is an admin of the instance
+// lifting in their own name.
+func (s *BanService) LiftBan(ctx context.Context, id service.ID, liftedBy service.ID) (*service.Ban, error) {
+	if err := authorizeSelf(ctx, liftedBy); err != nil {
+		return nil, err
+	}
+
+	if err := authorizeAdmin(ctx); err != nil {
+		return nil, err
+	}
+
+	return s.s.LiftBan(ctx, id, liftedBy)
+}
+
+// CheckUser checks to see if the authorizer on context is the user or an admin of the instance.
+func (s *BanService) CheckUser(ctx context.Context, userID service.ID) error {
+	if err := authorizeSelf(ctx, userID); err != nil {
+		if err := authorizeAdmin(ctx); err != nil {
+			return err
+		}
+	}
+
+	return s.s.CheckUser(ctx, userID)
+}
+
+// FindIPBlocks checks to see if the authorizer on context is an admin of the instance.
+func (s *BanService) FindIPBlocks(ctx context.Context, filter service.IPBlockFilter, opt ...service.FindOptions) ([]*service.IPBlock, int, error) {
+	if err := authorizeAdmin(ctx); err != nil {
+		return nil, 0, err
+	}
+
+	return s.s.FindIPBlocks(ctx, filter, opt...)
+}
+
+// CreateIPBlock checks to see if the authorizer on context is an admin of the instance
+// blocking in their own name.
+func (s *BanService) CreateIPBlock(ctx context.Context, b *service.IPBlock) error {
+	if err := authorizeSelf(ctx, b.CreatedBy); err != nil {
+		return err
+	}
+
+	if err := authorizeAdmin(ctx); err != nil {
+		return err
+	}
+
+	return s.s.CreateIPBlock(ctx, b)
+}
+
+// DeleteIPBlock checks to see if the authorizer on context is an admin of the instance
+// unblocking in their own name.
+func (s *BanService) DeleteIPBlock(ctx context.Context, id service.ID, deletedBy service.ID) error {
+	if err := authorizeSelf(ctx, deletedBy); err != nil {
+		return err
+	}
+
+	if err := authorizeAdmin(ctx); err != nil {
+		return err
+	}
+
+	return s.s.DeleteIPBlock(ctx, id, deletedBy)
+}
+
+// CheckIP checks to see if the authorizer on context is an admin of the instance.
+func (s *BanService) CheckIP(ctx context.Context, ip net.IP, scope service.IPBlockScope) error {
+	if err := authorizeAdmin(ctx); err != nil {
+		return err
+	}
+
+	return s.s.CheckIP(ctx, ip, scope)
+}
+
+// FindBanAudits checks to see if the authorizer on context is an admin of the instance.
+func (s *BanService) FindBanAudits(ctx context.Context, filter service.BanAuditFilter, opt ...service.FindOptions) ([]*service.BanAudit, int, error) {
+	if err := authorizeAdmin(ctx); err != nil {
+		return nil, 0, err
+	}
+
+	return s.s.FindBanAudits(ctx, filter, opt...)
+}
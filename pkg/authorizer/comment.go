@@ -0,0 +1,176 @@
+package authorizer
+
+import (
+	"context"
+	"fmt"
+
+	icontext "github.com/ustackq/indagate/pkg/context"
+	"github.com/ustackq/indagate/pkg/service"
+	"github.com/ustackq/indagate/pkg/utils/errors"
+)
+
+var _ service.CommentService = (*CommentService)(nil)
+
+// CommentService wraps a service.CommentService and authorizes actions
+// against it appropriately.
+type CommentService struct {
+	s  service.CommentService
+	a  service.AnswerService
+	ar service.ArticleService
+}
+
+// NewCommentService constructs an instance of an authorizing comment service.
+// The answer and article services are used to look up the item a comment is attached to.
+func NewCommentService(s service.CommentService, a service.AnswerService, ar service.ArticleService) *CommentService {
+	return &CommentService{
+		s:  s,
+		a:  a,
+		ar: ar,
+	}
+}
+
+func authorizeCommentByAction(ctx context.Context, action service.Action, orgID, id service.ID) error {
+	p, err := service.NewPermissionAtID(id, action, service.CommentsResourceType, orgID)
+	if err != nil {
+		return err
+	}
+
+	if err := isAllowed(ctx, *p); err != nil {
+		return err
+	}
+
+	return nil
+}
+
+// authorizeEditComment allows the author of the comment, otherwise it requires
+// write access to the comment as a moderator would have.
+func authorizeEditComment(ctx context.Context, c *service.Comment) error {
+	auth, err := icontext.GetAuthorizer(ctx)
+	if err != nil {
+		return err
+	}
+
+	if auth.GetUserID() == c.UserID {
+		return nil
+	}
+
+	return authorizeCommentByAction(ctx, service.WriteAction, c.OrgID, c.ID)
+}
+
+// authorizeReadComment requires read access to the comment, hidden comments are only
+// readable by those who can moderate them and pending ones by those who can edit them.
+func authorizeReadComment(ctx context.Context, c *service.Comment) error {
+	if c.Hidden {
+		return authorizeCommentByAction(ctx, service.WriteAction, c.OrgID, c.ID)
+	}
+
+	if c.Pending {
+		return authorizeEditComment(ctx, c)
+	}
+
+	return authorizeCommentByAction(ctx, service.ReadAction, c.OrgID, c.ID)
+}
+
+// itemOrg returns the organization of the item commented on.
+func (s *CommentService) itemOrg(ctx context.Context, t service.CommentType, id service.ID) (service.ID, error) {
+	switch t {
+	case service.AnswerComment:
+		a, err := s.a.FindAnswerByID(ctx, id)
+		if err != nil {
+			return 0, err
+		}
+		return a.OrgID, nil
+	case service.ArticleComment:
+		a, err := s.ar.FindArticleByID(ctx, id)
+		if err != nil {
+			return 0, err
+		}
+		return a.OrgID, nil
+	default:
+		return 0, &errors.Error{
+			Code: errors.Invalid,
+			Msg:  fmt.Sprintf("commenting on %s is not supported", t),
+		}
+	}
+}
+
+// FindCommentByID checks to see if the authorizer on context has read access to the comment.
+func (s *CommentService) FindCommentByID(ctx context.Context, id service.ID) (*service.Comment, error) {
+	c, err := s.s.FindCommentByID(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := authorizeReadComment(ctx, c); err != nil {
+		return nil, err
+	}
+
+	return c, nil
+}
+
+// FindComments retrieves all comments that match the provided filter and then filters the list down to only the resources that are authorized.
+func (s *CommentService) FindComments(ctx context.Context, filter service.CommentFilter, opts ...service.FindOptions) ([]*service.Comment, int, error) {
+	cs, _, err := s.s.FindComments(ctx, filter, unpaged(opts)...)
+	if err != nil {
+		return nil, 0, err
+	}
+
+	comments := cs[:0]
+	for _, c := range cs {
+		err := authorizeReadComment(ctx, c)
+		if err != nil && errors.ErrorCode(err) != errors.Unauthorized {
+			return nil, 0, err
+		}
+
+		if errors.ErrorCode(err) == errors.Unauthorized {
+			continue
+		}
+
+		comments = append(comments, c)
+	}
+
+	start, end := service.PageWindow(len(comments), opts...)
+	return comments[start:end], len(comments), nil
+}
+
+// CreateComment checks to see if the authorizer on context is able to read the organization of the item.
+func (s *CommentService) CreateComment(ctx context.Context, c *service.Comment) error {
+	orgID, err := s.itemOrg(ctx, c.Type, c.ItemID)
+	if err != nil {
+		return err
+	}
+
+	if err := authorizeOrgByAction(service.ReadAction, ctx, orgID); err != nil {
+		return err
+	}
+
+	return s.s.CreateComment(ctx, c)
+}
+
+// UpdateComment checks to see if the authorizer on context owns the comment or has write access to it.
+func (s *CommentService) UpdateComment(ctx context.Context, id service.ID, upd service.CommentUpdate) (*service.Comment, error) {
+	c, err := s.s.FindCommentByID(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := authorizeEditComment(ctx, c); err != nil {
+		return nil, err
+	}
+
+	return s.s.UpdateComment(ctx, id, upd)
+}
+
+// DeleteComment checks to see if the authorizer on context owns the comment or has write access to it.
+func (s *CommentService) DeleteComment(ctx context.Context, id service.ID) error {
+	c, err := s.s.FindCommentByID(ctx, id)
+	if err != nil {
+		return err
+	}
+
+	if err := authorizeEditComment(ctx, c); err != nil {
+		return err
+	}
+
+	return s.s.DeleteComment(ctx, id)
+}
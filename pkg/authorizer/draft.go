@@ -0,0 +1,58 @@
+package authorizer
+
+import (
+	"context"
+
+	"github.com/ustackq/indagate/pkg/service"
+)
+
+var _ service.DraftService = (*DraftService)(nil)
+
+// DraftService wraps a service.DraftService and authorizes actions
+// against it appropriately, drafts are only ever accessible to their owner.
+type DraftService struct {
+	s service.DraftService
+}
+
+// NewDraftService constructs an instance of an authorizing draft service.
+func NewDraftService(s service.DraftService) *DraftService {
+	return &DraftService{
+		s: s,
+	}
+}
+
+// FindDraft checks to see if the authorizer on context is the owner of the draft.
+func (s *DraftService) FindDraft(ctx context.Context, userID service.ID, t service.DraftType, itemID service.ID) (*service.Draft, error) {
+	if err := authorizeSelf(ctx, userID); err != nil {
+		return nil, err
+	}
+
+	return s.s.FindDraft(ctx, userID, t, itemID)
+}
+
+// FindDrafts checks to see if the authorizer on context is the owner of the drafts.
+func (s *DraftService) FindDrafts(ctx context.Context, filter service.DraftFilter, opts ...service.FindOptions) ([]*service.Draft, int, error) {
+	if err := authorizeSelf(ctx, filter.UserID); err != nil {
+		return nil, 0, err
+	}
+
+	return s.s.FindDrafts(ctx, filter, opts...)
+}
+
+// PutDraft checks to see if the authorizer on context is the owner of the draft.
+func (s *DraftService) PutDraft(ctx context.Context, d *service.Draft) (bool, error) {
+	if err := authorizeSelf(ctx, d.UserID); err != nil {
+		return false, err
+	}
+
+	return s.s.PutDraft(ctx, d)
+}
+
+// DeleteDraft checks to see if the authorizer on context is the owner of the draft.
+func (s *DraftService) DeleteDraft(ctx context.Context, userID service.ID, t service.DraftType, itemID service.ID) error {
+	if err := authorizeSelf(ctx, userID); err != nil {
+		return err
+	}
+
+	return s.s.DeleteDraft(ctx, userID, t, itemID)
+}
@@ -0,0 +1,82 @@
+package authorizer
+
+import (
+	"context"
+
+	"github.com/ustackq/indagate/pkg/service"
+	"github.com/ustackq/indagate/pkg/utils/errors"
+)
+
+var _ service.FeedService = (*FeedService)(nil)
+
+// FeedService wraps a service.FeedService and authorizes actions
+// against it appropriately.
+type FeedService struct {
+	s     service.FeedService
+	items *itemServices
+}
+
+// NewFeedService constructs an instance of an authorizing feed service.
+// The question, answer, article and comment services are used to look up the items of the activities.
+func NewFeedService(s service.FeedService, q service.QuestionService, a service.AnswerService, ar service.ArticleService, c service.CommentService) *FeedService {
+	return &FeedService{
+		s:     s,
+		items: &itemServices{q: q, a: a, ar: ar, c: c},
+	}
+}
+
+// authorizeReadActivity checks to see if the authorizer on context can read the item of
+// the activity, hidden and pending items are left out as their own services would.
+func (s *FeedService) authorizeReadActivity(ctx context.Context, a *service.Activity) error {
+	if a.ItemType == service.ItemType(service.UserFollow) {
+		return authorizeUserByAction(service.ReadAction, ctx, a.ItemID)
+	}
+
+	return s.items.authorizeReadItem(ctx, a.ItemType, a.OrgID, a.ItemID)
+}
+
+// filterActivities filters the activities down to the ones the authorizer on context can read
+// and then pages them. Activities on items removed since are left out.
+func (s *FeedService) filterActivities(ctx context.Context, as []*service.Activity, opts ...service.FindOptions) ([]*service.Activity, int, error) {
+	activities := as[:0]
+	for _, a := range as {
+		err := s.authorizeReadActivity(ctx, a)
+		if err != nil && errors.ErrorCode(err) != errors.Unauthorized && errors.ErrorCode(err) != errors.NotFound {
+			return nil, 0, err
+		}
+
+		if err != nil {
+			continue
+		}
+
+		activities = append(activities, a)
+	}
+
+	start, end := service.PageWindow(len(activities), opts...)
+	return activities[start:end], len(activities), nil
+}
+
+// FindFeed checks to see if the authorizer on context is the user and then filters the
+// feed down to the readable activities.
+func (s *FeedService) FindFeed(ctx context.Context, userID service.ID, opts ...service.FindOptions) ([]*service.Activity, int, error) {
+	if err := authorizeSelf(ctx, userID); err != nil {
+		return nil, 0, err
+	}
+
+	as, _, err := s.s.FindFeed(ctx, userID, unpaged(opts)...)
+	if err != nil {
+		return nil, 0, err
+	}
+
+	return s.filterActivities(ctx, as, opts...)
+}
+
+// FindUserActivities retrieves the activities of the user and then filters them down to the readable ones.
+func (s *FeedService) FindUserActivities(ctx context.Context, userID service.ID, opts ...service.FindOptions) ([]*service.Activity, int, error) {
+	as, _, err := s.s.FindUserActivities(ctx, userID, unpaged(opts)...)
+	if err != nil {
+		return nil, 0, err
+	}
+
+	return s.filterActivities(ctx, as, opts...)
+}
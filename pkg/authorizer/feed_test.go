@@ -0,0 +1,63 @@
+package authorizer_test
+
+import (
+	"context"
+	"testing"
+
+	"github.com/ustackq/indagate/pkg/authorizer"
+	"github.com/ustackq/indagate/pkg/service"
+)
+
+func TestFeedService_PendingItems(t *testing.T) {
+	ctx := context.Background()
+	s := newInmemService(t)
+	fs := authorizer.NewFeedService(s, s, s, s, s)
+
+	org, author, newcomer, reader, moderator := service.ID(1), service.ID(2), service.ID(3), service.ID(4), service.ID(5)
+	q := &service.Question{OrgID: org, UserID: author, Content: "why?"}
+	if err := s.CreateQuestion(ctx, q); err != nil {
+		t.Fatal(err)
+	}
+	if err := s.Follow(ctx, &service.Follow{UserID: reader, Type: service.QuestionFollow, ItemID: q.ID}); err != nil {
+		t.Fatal(err)
+	}
+	// the first read makes the feed of the reader active.
+	if _, _, err := s.FindFeed(ctx, reader); err != nil {
+		t.Fatal(err)
+	}
+
+	s.Config.ApprovalPolicy = service.ApprovalPolicy{FirstPosts: 1}
+	a := &service.Answer{QuestionID: q.ID, UserID: newcomer, Content: "because"}
+	if err := s.CreateAnswer(ctx, a); err != nil {
+		t.Fatal(err)
+	}
+	if !a.Pending {
+		t.Fatalf("answer = %+v, want it pending", a)
+	}
+
+	// the votes of a moderator on the question and the pending answer reach the followers of the question.
+	for _, v := range []*service.Vote{
+		{ItemType: service.QuestionItemType, ItemID: q.ID, UserID: moderator, Value: service.UpVote},
+		{ItemType: service.AnswerItemType, ItemID: a.ID, UserID: moderator, Value: service.UpVote},
+	} {
+		if _, err := s.Vote(ctx, v); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if _, n, err := s.FindFeed(ctx, reader); err != nil || n != 3 {
+		t.Fatalf("unauthorized feed = %d activities, %v, want 3", n, err)
+	}
+
+	as, n, err := fs.FindFeed(withRole(t, reader, org, service.RoleMember), reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if n != 2 {
+		t.Errorf("feed = %d activities, want the question and the vote on it", n)
+	}
+	for _, activity := range as {
+		if activity.ItemID == a.ID {
+			t.Errorf("feed = %+v, want no activity on the pending answer", activity)
+		}
+	}
+}
@@ -0,0 +1,132 @@
+package authorizer
+
+import (
+	"context"
+
+	"github.com/ustackq/indagate/pkg/service"
+	"github.com/ustackq/indagate/pkg/utils/errors"
+)
+
+var _ service.FollowService = (*FollowService)(nil)
+
+// FollowService wraps a service.FollowService and authorizes actions
+// against it appropriately.
+type FollowService struct {
+	s service.FollowService
+	q service.QuestionService
+	t service.TopicService
+}
+
+// NewFollowService constructs an instance of an authorizing follow service.
+// The question and topic services are used to look up the followed items.
+func NewFollowService(s service.FollowService, q service.QuestionService, t service.TopicService) *FollowService {
+	return &FollowService{
+		s: s,
+		q: q,
+		t: t,
+	}
+}
+
+// authorizeReadItem checks to see if the authorizer on context has read access to the followed item,
+// hidden and pending questions can only be followed by those who can read them.
+func (s *FollowService) authorizeReadItem(ctx context.Context, t service.FollowType, itemID service.ID) error {
+	switch t {
+	case service.UserFollow:
+		return authorizeUserByAction(service.ReadAction, ctx, itemID)
+	case service.QuestionFollow:
+		q, err := s.q.FindQuestionByID(ctx, itemID)
+		if err != nil {
+			return err
+		}
+		return authorizeReadQuestion(ctx, q)
+	case service.TopicFollow:
+		topic, err := s.t.FindTopicByID(ctx, itemID)
+		if err != nil {
+			return err
+		}
+		return authorizeTopicByAction(ctx, service.ReadAction, topic.OrgID, topic.ID)
+	default:
+		return t.Valid()
+	}
+}
+
+// FindFollow checks to see if the authorizer on context has read access to the item.
+func (s *FollowService) FindFollow(ctx context.Context, userID service.ID, t service.FollowType, itemID service.ID) (*service.Follow, error) {
+	if err := s.authorizeReadItem(ctx, t, itemID); err != nil {
+		return nil, err
+	}
+
+	return s.s.FindFollow(ctx, userID, t, itemID)
+}
+
+// FindFollowers checks to see if the authorizer on context has read access to the item.
+func (s *FollowService) FindFollowers(ctx context.Context, t service.FollowType, itemID service.ID, cursor service.FollowCursor) (*service.FollowPage, error) {
+	if err := s.authorizeReadItem(ctx, t, itemID); err != nil {
+		return nil, err
+	}
+
+	return s.s.FindFollowers(ctx, t, itemID, cursor)
+}
+
+// FindFollowing retrieves the followed items and filters them down to the items the
+// authorizer on context has read access to before cutting the page, so that pages are
+// full and the cursor of the next page starts after the last item returned.
+func (s *FollowService) FindFollowing(ctx context.Context, userID service.ID, t service.FollowType, cursor service.FollowCursor) (*service.FollowPage, error) {
+	limit := cursor.Limit
+	if limit <= 0 {
+		limit = service.DefaultFollowPageSize
+	}
+
+	page := &service.FollowPage{Follows: []*service.Follow{}}
+	next := service.FollowCursor{After: cursor.After, Limit: limit}
+	for {
+		p, err := s.s.FindFollowing(ctx, userID, t, next)
+		if err != nil {
+			return nil, err
+		}
+
+		for _, f := range p.Follows {
+			err := s.authorizeReadItem(ctx, f.Type, f.ItemID)
+			if err != nil && errors.ErrorCode(err) != errors.Unauthorized {
+				return nil, err
+			}
+
+			if errors.ErrorCode(err) == errors.Unauthorized {
+				continue
+			}
+
+			if len(page.Follows) == limit {
+				page.Next = page.Follows[limit-1].ItemID
+				return page, nil
+			}
+			page.Follows = append(page.Follows, f)
+		}
+
+		if !p.Next.Valid() {
+			return page, nil
+		}
+		next.After = p.Next
+	}
+}
+
+// Follow checks to see if the authorizer on context is the follower and has read access to the item.
+func (s *FollowService) Follow(ctx context.Context, f *service.Follow) error {
+	if err := authorizeSelf(ctx, f.UserID); err != nil {
+		return err
+	}
+
+	if err := s.authorizeReadItem(ctx, f.Type, f.ItemID); err != nil {
+		return err
+	}
+
+	return s.s.Follow(ctx, f)
+}
+
+// Unfollow checks to see if the authorizer on context is the follower.
+func (s *FollowService) Unfollow(ctx context.Context, userID service.ID, t service.FollowType, itemID service.ID) error {
+	if err := authorizeSelf(ctx, userID); err != nil {
+		return err
+	}
+
+	return s.s.Unfollow(ctx, userID, t, itemID)
+}
@@ -0,0 +1,83 @@
+package authorizer_test
+
+import (
+	"context"
+	"testing"
+
+	"github.com/ustackq/indagate/pkg/authorizer"
+	"github.com/ustackq/indagate/pkg/service"
+	"github.com/ustackq/indagate/pkg/utils/errors"
+)
+
+func TestFollowService_HiddenAndPendingQuestions(t *testing.T) {
+	ctx := context.Background()
+	s := newInmemService(t)
+	fs := authorizer.NewFollowService(s, s, s)
+
+	org, author, newcomer, reader, moderator := service.ID(1), service.ID(2), service.ID(3), service.ID(4), service.ID(5)
+	qs := make([]*service.Question, 3)
+	for i := range qs {
+		qs[i] = &service.Question{OrgID: org, UserID: author, Content: "why?"}
+		if err := s.CreateQuestion(ctx, qs[i]); err != nil {
+			t.Fatal(err)
+		}
+		if err := s.Follow(ctx, &service.Follow{UserID: reader, Type: service.QuestionFollow, ItemID: qs[i].ID}); err != nil {
+			t.Fatal(err)
+		}
+	}
+	hidden := qs[1]
+	if _, err := s.Report(ctx, &service.Report{Type: service.QuestionReport, TargetID: hidden.ID, OrgID: org, UserID: reader, Reason: "spam"}); err != nil {
+		t.Fatal(err)
+	}
+	res := &service.Resolution{Action: service.HideModeration, Reason: "spam", ModeratorID: moderator}
+	if _, err := s.ResolveReports(ctx, org, service.QuestionReport, hidden.ID, res); err != nil {
+		t.Fatal(err)
+	}
+
+	s.Config.ApprovalPolicy = service.ApprovalPolicy{FirstPosts: 1}
+	pending := &service.Question{OrgID: org, UserID: newcomer, Content: "how?"}
+	if err := s.CreateQuestion(ctx, pending); err != nil {
+		t.Fatal(err)
+	}
+
+	for _, tt := range []struct {
+		name   string
+		userID service.ID
+		itemID service.ID
+		want   string
+	}{
+		{name: "hidden question", userID: reader, itemID: hidden.ID, want: errors.Unauthorized},
+		{name: "pending question", userID: reader, itemID: pending.ID, want: errors.Unauthorized},
+		{name: "own pending question", userID: newcomer, itemID: pending.ID},
+	} {
+		t.Run(tt.name, func(t *testing.T) {
+			ctx := withRole(t, tt.userID, org, service.RoleMember)
+			f := &service.Follow{UserID: tt.userID, Type: service.QuestionFollow, ItemID: tt.itemID}
+			if err := fs.Follow(ctx, f); errors.ErrorCode(err) != tt.want {
+				t.Errorf("Follow() error = %v, want %q", err, tt.want)
+			}
+		})
+	}
+
+	// the hidden question is left out before the page is cut.
+	readerCtx := withRole(t, reader, org, service.RoleMember)
+	var got []service.ID
+	cursor := service.FollowCursor{Limit: 1}
+	for i := 0; i < len(qs); i++ {
+		page, err := fs.FindFollowing(readerCtx, reader, service.QuestionFollow, cursor)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if len(page.Follows) != 1 {
+			t.Fatalf("page %d = %+v, want a single follow", i, page.Follows)
+		}
+		got = append(got, page.Follows[0].ItemID)
+		if !page.Next.Valid() {
+			break
+		}
+		cursor.After = page.Next
+	}
+	if len(got) != 2 || got[0] != qs[0].ID || got[1] != qs[2].ID {
+		t.Errorf("followed = %v, want %v and %v", got, qs[0].ID, qs[2].ID)
+	}
+}
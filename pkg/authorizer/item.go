@@ -0,0 +1,55 @@
+package authorizer
+
+import (
+	"context"
+
+	"github.com/ustackq/indagate/pkg/service"
+)
+
+// itemServices looks up the items voted on, followed or shown in feeds, so that they
+// are authorized with the rules of their own services, hidden and pending items included.
+type itemServices struct {
+	q  service.QuestionService
+	a  service.AnswerService
+	ar service.ArticleService
+	c  service.CommentService
+}
+
+// authorizeReadItem checks to see if the authorizer on context can read the item as
+// its own service would allow, items of other types require read access to the
+// resource type they are named after.
+func (s *itemServices) authorizeReadItem(ctx context.Context, itemType service.ItemType, orgID, id service.ID) error {
+	switch itemType {
+	case service.QuestionItemType:
+		q, err := s.q.FindQuestionByID(ctx, id)
+		if err != nil {
+			return err
+		}
+		return authorizeReadQuestion(ctx, q)
+	case service.AnswerItemType:
+		a, err := s.a.FindAnswerByID(ctx, id)
+		if err != nil {
+			return err
+		}
+		return authorizeReadAnswer(ctx, a)
+	case service.ArticleItemType:
+		a, err := s.ar.FindArticleByID(ctx, id)
+		if err != nil {
+			return err
+		}
+		return authorizeReadArticle(ctx, a)
+	case service.CommentItemType:
+		c, err := s.c.FindCommentByID(ctx, id)
+		if err != nil {
+			return err
+		}
+		return authorizeReadComment(ctx, c)
+	}
+
+	p, err := service.NewPermissionAtID(id, service.ReadAction, service.ResourceType(itemType), orgID)
+	if err != nil {
+		return err
+	}
+
+	return isAllowed(ctx, *p)
+}
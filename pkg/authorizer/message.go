@@ -0,0 +1,136 @@
+package authorizer
+
+import (
+	"context"
+
+	icontext "github.com/ustackq/indagate/pkg/context"
+	"github.com/ustackq/indagate/pkg/service"
+	"github.com/ustackq/indagate/pkg/utils/errors"
+)
+
+var _ service.MessageService = (*MessageService)(nil)
+
+// MessageService wraps a service.MessageService and authorizes actions
+// against it appropriately, dialogs are only accessible to their participants.
+type MessageService struct {
+	s service.MessageService
+}
+
+// NewMessageService constructs an instance of an authorizing message service.
+func NewMessageService(s service.MessageService) *MessageService {
+	return &MessageService{
+		s: s,
+	}
+}
+
+// FindDialogByID checks to see if the authorizer on context takes part in the dialog.
+func (s *MessageService) FindDialogByID(ctx context.Context, id service.ID) (*service.Dialog, error) {
+	d, err := s.s.FindDialogByID(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+
+	auth, err := icontext.GetAuthorizer(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	if !d.Includes(auth.GetUserID()) {
+		return nil, &errors.Error{
+			Code: errors.Unauthorized,
+			Msg:  "users can only access their own dialogs",
+		}
+	}
+
+	return d, nil
+}
+
+// FindDialogs checks to see if the authorizer on context is the user.
+func (s *MessageService) FindDialogs(ctx context.Context, userID service.ID, opts ...service.FindOptions) ([]*service.Dialog, int, error) {
+	if err := authorizeSelf(ctx, userID); err != nil {
+		return nil, 0, err
+	}
+
+	return s.s.FindDialogs(ctx, userID, opts...)
+}
+
+// CreateDialog checks to see if the authorizer on context is the sender.
+func (s *MessageService) CreateDialog(ctx context.Context, d *service.Dialog) error {
+	if err := authorizeSelf(ctx, d.SenderID); err != nil {
+		return err
+	}
+
+	return s.s.CreateDialog(ctx, d)
+}
+
+// DeleteDialog checks to see if the authorizer on context is the user.
+func (s *MessageService) DeleteDialog(ctx context.Context, id, userID service.ID) error {
+	if err := authorizeSelf(ctx, userID); err != nil {
+		return err
+	}
+
+	return s.s.DeleteDialog(ctx, id, userID)
+}
+
+// MarkDialogRead checks to see if the authorizer on context is the user.
+func (s *MessageService) MarkDialogRead(ctx context.Context, id, userID service.ID) (*service.Dialog, error) {
+	if err := authorizeSelf(ctx, userID); err != nil {
+		return nil, err
+	}
+
+	return s.s.MarkDialogRead(ctx, id, userID)
+}
+
+// FindMessages checks to see if the authorizer on context is the user.
+func (s *MessageService) FindMessages(ctx context.Context, dialogID, userID service.ID, opts ...service.FindOptions) ([]*service.Message, int, error) {
+	if err := authorizeSelf(ctx, userID); err != nil {
+		return nil, 0, err
+	}
+
+	return s.s.FindMessages(ctx, dialogID, userID, opts...)
+}
+
+// SendMessage checks to see if the authorizer on context is the sender.
+func (s *MessageService) SendMessage(ctx context.Context, m *service.Message) error {
+	if err := authorizeSelf(ctx, m.SenderID); err != nil {
+		return err
+	}
+
+	return s.s.SendMessage(ctx, m)
+}
+
+// DeleteMessage checks to see if the authorizer on context is the user.
+func (s *MessageService) DeleteMessage(ctx context.Context, id, userID service.ID) error {
+	if err := authorizeSelf(ctx, userID); err != nil {
+		return err
+	}
+
+	return s.s.DeleteMessage(ctx, id, userID)
+}
+
+// FindBlockedUsers checks to see if the authorizer on context is the user.
+func (s *MessageService) FindBlockedUsers(ctx context.Context, userID service.ID) ([]*service.Block, error) {
+	if err := authorizeSelf(ctx, userID); err != nil {
+		return nil, err
+	}
+
+	return s.s.FindBlockedUsers(ctx, userID)
+}
+
+// BlockUser checks to see if the authorizer on context is the user.
+func (s *MessageService) BlockUser(ctx context.Context, userID, blockedID service.ID) (*service.Block, error) {
+	if err := authorizeSelf(ctx, userID); err != nil {
+		return nil, err
+	}
+
+	return s.s.BlockUser(ctx, userID, blockedID)
+}
+
+// UnblockUser checks to see if the authorizer on context is the user.
+func (s *MessageService) UnblockUser(ctx context.Context, userID, blockedID service.ID) error {
+	if err := authorizeSelf(ctx, userID); err != nil {
+		return err
+	}
+
+	return s.s.UnblockUser(ctx, userID, blockedID)
+}
@@ -0,0 +1,113 @@
+package authorizer
+
+import (
+	"context"
+
+	"github.com/ustackq/indagate/pkg/service"
+	"github.com/ustackq/indagate/pkg/utils/errors"
+)
+
+var _ service.ModerationService = (*ModerationService)(nil)
+
+// ModerationService wraps a service.ModerationService and authorizes actions
+// against it appropriately.
+type ModerationService struct {
+	s service.ModerationService
+}
+
+// NewModerationService constructs an instance of an authorizing moderation service.
+func NewModerationService(s service.ModerationService) *ModerationService {
+	return &ModerationService{
+		s: s,
+	}
+}
+
+// authorizeModerate checks to see if the authorizer on context moderates the org.
+func authorizeModerate(ctx context.Context, action service.Action, orgID service.ID) error {
+	p, err := service.NewPermission(action, service.ModerationResourceType, orgID)
+	if err != nil {
+		return err
+	}
+
+	return isAllowed(ctx, *p)
+}
+
+// Report checks to see if the authorizer on context is the reporter and can read the org
+// the target is reported in.
+func (s *ModerationService) Report(ctx context.Context, r *service.Report) (*service.ModerationItem, error) {
+	if err := authorizeSelf(ctx, r.UserID); err != nil {
+		return nil, err
+	}
+
+	if err := authorizeOrgByAction(service.ReadAction, ctx, r.OrgID); err != nil {
+		return nil, err
+	}
+
+	return s.s.Report(ctx, r)
+}
+
+// FindReports checks to see if the authorizer on context moderates the org.
+func (s *ModerationService) FindReports(ctx context.Context, orgID service.ID, t service.ReportType, targetID service.ID) ([]*service.Report, error) {
+	if err := authorizeModerate(ctx, service.ReadAction, orgID); err != nil {
+		return nil, err
+	}
+
+	return s.s.FindReports(ctx, orgID, t, targetID)
+}
+
+// FindModerationItem checks to see if the authorizer on context moderates the org.
+func (s *ModerationService) FindModerationItem(ctx context.Context, orgID service.ID, t service.ReportType, targetID service.ID) (*service.ModerationItem, error) {
+	if err := authorizeModerate(ctx, service.ReadAction, orgID); err != nil {
+		return nil, err
+	}
+
+	return s.s.FindModerationItem(ctx, orgID, t, targetID)
+}
+
+// FindModerationQueue retrieves the moderation items and then filters them down to the
+// ones of the orgs moderated by the authorizer on context.
+func (s *ModerationService) FindModerationQueue(ctx context.Context, filter service.ModerationFilter, opts ...service.FindOptions) ([]*service.ModerationItem, int, error) {
+	if filter.OrgID != nil {
+		if err := authorizeModerate(ctx, service.ReadAction, *filter.OrgID); err != nil {
+			return nil, 0, err
+		}
+
+		return s.s.FindModerationQueue(ctx, filter, opts...)
+	}
+
+	ms, _, err := s.s.FindModerationQueue(ctx, filter, unpaged(opts)...)
+	if err != nil {
+		return nil, 0, err
+	}
+
+	items := ms[:0]
+	for _, m := range ms {
+		err := authorizeModerate(ctx, service.ReadAction, m.OrgID)
+		if err != nil && errors.ErrorCode(err) != errors.Unauthorized {
+			return nil, 0, err
+		}
+
+		if errors.ErrorCode(err) == errors.Unauthorized {
+			continue
+		}
+
+		items = append(items, m)
+	}
+
+	start, end := service.PageWindow(len(items), opts...)
+	return items[start:end], len(items), nil
+}
+
+// ResolveReports checks to see if the authorizer on context is the moderator of the
+// resolution and can write to the moderation of the org.
+func (s *ModerationService) ResolveReports(ctx context.Context, orgID service.ID, t service.ReportType, targetID service.ID, res *service.Resolution) (*service.ModerationItem, error) {
+	if err := authorizeSelf(ctx, res.ModeratorID); err != nil {
+		return nil, err
+	}
+
+	if err := authorizeModerate(ctx, service.WriteAction, orgID); err != nil {
+		return nil, err
+	}
+
+	return s.s.ResolveReports(ctx, orgID, t, targetID, res)
+}
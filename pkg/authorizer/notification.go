@@ -0,0 +1,81 @@
+package authorizer
+
+import (
+	"context"
+
+	"github.com/ustackq/indagate/pkg/service"
+)
+
+var _ service.NotificationService = (*NotificationService)(nil)
+
+// NotificationService wraps a service.NotificationService and authorizes actions
+// against it appropriately, notifications are only accessible to their recipient.
+type NotificationService struct {
+	s service.NotificationService
+}
+
+// NewNotificationService constructs an instance of an authorizing notification service.
+func NewNotificationService(s service.NotificationService) *NotificationService {
+	return &NotificationService{
+		s: s,
+	}
+}
+
+// FindNotificationByID checks to see if the authorizer on context is the recipient of the notification.
+func (s *NotificationService) FindNotificationByID(ctx context.Context, id service.ID) (*service.Notification, error) {
+	n, err := s.s.FindNotificationByID(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := authorizeSelf(ctx, n.RecipientID); err != nil {
+		return nil, err
+	}
+
+	return n, nil
+}
+
+// FindNotifications checks to see if the authorizer on context is the recipient of the notifications.
+func (s *NotificationService) FindNotifications(ctx context.Context, filter service.NotificationFilter, opts ...service.FindOptions) ([]*service.Notification, int, error) {
+	if err := authorizeSelf(ctx, filter.RecipientID); err != nil {
+		return nil, 0, err
+	}
+
+	return s.s.FindNotifications(ctx, filter, opts...)
+}
+
+// MarkNotificationRead checks to see if the authorizer on context is the recipient of the notification.
+func (s *NotificationService) MarkNotificationRead(ctx context.Context, id service.ID) (*service.Notification, error) {
+	if _, err := s.FindNotificationByID(ctx, id); err != nil {
+		return nil, err
+	}
+
+	return s.s.MarkNotificationRead(ctx, id)
+}
+
+// MarkAllNotificationsRead checks to see if the authorizer on context is the recipient of the notifications.
+func (s *NotificationService) MarkAllNotificationsRead(ctx context.Context, recipientID service.ID) (int, error) {
+	if err := authorizeSelf(ctx, recipientID); err != nil {
+		return 0, err
+	}
+
+	return s.s.MarkAllNotificationsRead(ctx, recipientID)
+}
+
+// FindNotificationSettings checks to see if the authorizer on context is the user.
+func (s *NotificationService) FindNotificationSettings(ctx context.Context, userID service.ID) (*service.NotificationSettings, error) {
+	if err := authorizeSelf(ctx, userID); err != nil {
+		return nil, err
+	}
+
+	return s.s.FindNotificationSettings(ctx, userID)
+}
+
+// UpdateNotificationSettings checks to see if the authorizer on context is the user.
+func (s *NotificationService) UpdateNotificationSettings(ctx context.Context, settings *service.NotificationSettings) error {
+	if err := authorizeSelf(ctx, settings.UserID); err != nil {
+		return err
+	}
+
+	return s.s.UpdateNotificationSettings(ctx, settings)
+}
@@ -0,0 +1,17 @@
+package authorizer
+
+import (
+	"github.com/ustackq/indagate/pkg/service"
+)
+
+// unpaged returns opts without the page bounds. Lists are authorized before they
+// are paged, so that pages are full and totals only count what can be read.
+func unpaged(opts []service.FindOptions) []service.FindOptions {
+	if len(opts) == 0 {
+		return nil
+	}
+
+	opt := opts[0]
+	opt.Offset, opt.Limit = 0, 0
+	return []service.FindOptions{opt}
+}
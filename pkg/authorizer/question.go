@@ -0,0 +1,203 @@
+package authorizer
+
+import (
+	"context"
+
+	icontext "github.com/ustackq/indagate/pkg/context"
+	"github.com/ustackq/indagate/pkg/service"
+	"github.com/ustackq/indagate/pkg/utils/errors"
+)
+
+var _ service.QuestionService = (*QuestionService)(nil)
+
+// QuestionService wraps a service.QuestionService and authorizes actions
+// against it appropriately.
+type QuestionService struct {
+	s service.QuestionService
+}
+
+// NewQuestionService constructs an instance of an authorizing question service.
+func NewQuestionService(s service.QuestionService) *QuestionService {
+	return &QuestionService{
+		s: s,
+	}
+}
+
+func newQuestionPermission(action service.Action, orgID, id service.ID) (*service.Permission, error) {
+	return service.NewPermissionAtID(id, action, service.QuestionsResourceType, orgID)
+}
+
+func authorizeQuestionByAction(ctx context.Context, action service.Action, orgID, id service.ID) error {
+	p, err := newQuestionPermission(action, orgID, id)
+	if err != nil {
+		return err
+	}
+
+	if err := isAllowed(ctx, *p); err != nil {
+		return err
+	}
+
+	return nil
+}
+
+// authorizeEditQuestion allows the author of the question, otherwise it requires
+// write access to the question as a moderator would have.
+func authorizeEditQuestion(ctx context.Context, q *service.Question) error {
+	a, err := icontext.GetAuthorizer(ctx)
+	if err != nil {
+		return err
+	}
+
+	if a.GetUserID() == q.UserID {
+		return nil
+	}
+
+	return authorizeQuestionByAction(ctx, service.WriteAction, q.OrgID, q.ID)
+}
+
+// authorizeReadQuestion requires read access to the question, hidden questions are only
+// readable by those who can moderate them and pending ones by those who can edit them.
+func authorizeReadQuestion(ctx context.Context, q *service.Question) error {
+	if q.Hidden {
+		return authorizeQuestionByAction(ctx, service.WriteAction, q.OrgID, q.ID)
+	}
+
+	if q.Pending {
+		return authorizeEditQuestion(ctx, q)
+	}
+
+	return authorizeQuestionByAction(ctx, service.ReadAction, q.OrgID, q.ID)
+}
+
+// redactQuestion removes the edit waiting for approval from the question unless the
+// authorizer on context can edit it.
+func redactQuestion(ctx context.Context, q *service.Question) error {
+	if q.UnverifiedModify == nil {
+		return nil
+	}
+
+	err := authorizeEditQuestion(ctx, q)
+	if err != nil && errors.ErrorCode(err) != errors.Unauthorized {
+		return err
+	}
+
+	if err != nil {
+		q.UnverifiedModify = nil
+	}
+	return nil
+}
+
+// FindQuestionByID checks to see if the authorizer on context has read access to the question.
+func (s *QuestionService) FindQuestionByID(ctx context.Context, id service.ID) (*service.Question, error) {
+	q, err := s.s.FindQuestionByID(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := authorizeReadQuestion(ctx, q); err != nil {
+		return nil, err
+	}
+
+	if err := redactQuestion(ctx, q); err != nil {
+		return nil, err
+	}
+
+	return q, nil
+}
+
+// FindQuestions retrieves all questions that match the provided filter, filters the list down to
+// only the resources that are authorized and then pages it.
+func (s *QuestionService) FindQuestions(ctx context.Context, filter service.QuestionFilter, opts ...service.FindOptions) ([]*service.Question, int, error) {
+	// TODO: push the authorization into the store, fetching the whole list is expensive.
+	qs, _, err := s.s.FindQuestions(ctx, filter, unpaged(opts)...)
+	if err != nil {
+		return nil, 0, err
+	}
+
+	questions := qs[:0]
+	for _, q := range qs {
+		err := authorizeReadQuestion(ctx, q)
+		if err != nil && errors.ErrorCode(err) != errors.Unauthorized {
+			return nil, 0, err
+		}
+
+		if errors.ErrorCode(err) == errors.Unauthorized {
+			continue
+		}
+
+		if err := redactQuestion(ctx, q); err != nil {
+			return nil, 0, err
+		}
+
+		questions = append(questions, q)
+	}
+
+	start, end := service.PageWindow(len(questions), opts...)
+	return questions[start:end], len(questions), nil
+}
+
+// CreateQuestion checks to see if the authorizer on context is able to read the organization,
+// every member of an organization can ask a question.
+func (s *QuestionService) CreateQuestion(ctx context.Context, q *service.Question) error {
+	if err := authorizeOrgByAction(service.ReadAction, ctx, q.OrgID); err != nil {
+		return err
+	}
+
+	return s.s.CreateQuestion(ctx, q)
+}
+
+// UpdateQuestion checks to see if the authorizer on context owns the question or has write access to it.
+func (s *QuestionService) UpdateQuestion(ctx context.Context, id service.ID, upd service.QuestionUpdate) (*service.Question, error) {
+	q, err := s.s.FindQuestionByID(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := authorizeEditQuestion(ctx, q); err != nil {
+		return nil, err
+	}
+
+	return s.s.UpdateQuestion(ctx, id, upd)
+}
+
+// DeleteQuestion checks to see if the authorizer on context owns the question or has write access to it.
+func (s *QuestionService) DeleteQuestion(ctx context.Context, id service.ID) error {
+	q, err := s.s.FindQuestionByID(ctx, id)
+	if err != nil {
+		return err
+	}
+
+	if err := authorizeEditQuestion(ctx, q); err != nil {
+		return err
+	}
+
+	return s.s.DeleteQuestion(ctx, id)
+}
+
+// LockQuestion checks to see if the authorizer on context has write access to the question.
+func (s *QuestionService) LockQuestion(ctx context.Context, id service.ID) error {
+	q, err := s.s.FindQuestionByID(ctx, id)
+	if err != nil {
+		return err
+	}
+
+	if err := authorizeQuestionByAction(ctx, service.WriteAction, q.OrgID, q.ID); err != nil {
+		return err
+	}
+
+	return s.s.LockQuestion(ctx, id)
+}
+
+// UnlockQuestion checks to see if the authorizer on context has write access to the question.
+func (s *QuestionService) UnlockQuestion(ctx context.Context, id service.ID) error {
+	q, err := s.s.FindQuestionByID(ctx, id)
+	if err != nil {
+		return err
+	}
+
+	if err := authorizeQuestionByAction(ctx, service.WriteAction, q.OrgID, q.ID); err != nil {
+		return err
+	}
+
+	return s.s.UnlockQuestion(ctx, id)
+}
@@ -0,0 +1,49 @@
+package authorizer
+
+import (
+	"context"
+
+	"github.com/ustackq/indagate/pkg/service"
+)
+
+var _ service.RoleService = (*RoleService)(nil)
+
+// RoleService wraps a service.RoleService and authorizes actions
+// against it appropriately.
+type RoleService struct {
+	s service.RoleService
+}
+
+// NewRoleService constructs an instance of an authorizing role service.
+func NewRoleService(s service.RoleService) *RoleService {
+	return &RoleService{
+		s: s,
+	}
+}
+
+// FindOrgRoles checks to see if the authorizer on context has read access to the org.
+func (s *RoleService) FindOrgRoles(ctx context.Context, orgID service.ID) ([]*service.UserResourceMapping, error) {
+	if err := authorizeOrgByAction(service.ReadAction, ctx, orgID); err != nil {
+		return nil, err
+	}
+
+	return s.s.FindOrgRoles(ctx, orgID)
+}
+
+// SetUserRole checks to see if the authorizer on context has write access to the org.
+func (s *RoleService) SetUserRole(ctx context.Context, orgID, userID service.ID, role service.Role) (*service.UserResourceMapping, error) {
+	if err := authorizeOrgByAction(service.WriteAction, ctx, orgID); err != nil {
+		return nil, err
+	}
+
+	return s.s.SetUserRole(ctx, orgID, userID, role)
+}
+
+// FindUserPermissions checks to see if the authorizer on context has read access to the user.
+func (s *RoleService) FindUserPermissions(ctx context.Context, userID service.ID) ([]*service.Permission, error) {
+	if err := authorizeUserByAction(service.ReadAction, ctx, userID); err != nil {
+		return nil, err
+	}
+
+	return s.s.FindUserPermissions(ctx, userID)
+}
@@ -0,0 +1,51 @@
+package authorizer
+
+import (
+	"context"
+
+	"github.com/ustackq/indagate/pkg/service"
+	"github.com/ustackq/indagate/pkg/utils/errors"
+)
+
+var _ service.SearchService = (*SearchService)(nil)
+
+// SearchService wraps a service.SearchService and authorizes actions
+// against it appropriately.
+type SearchService struct {
+	s service.SearchService
+}
+
+// NewSearchService constructs an instance of an authorizing search service.
+func NewSearchService(s service.SearchService) *SearchService {
+	return &SearchService{
+		s: s,
+	}
+}
+
+// Search restricts the query to the content the authorizer on context can read, so that
+// the results are filtered before they are paged. Search types are named after their resource types.
+func (s *SearchService) Search(ctx context.Context, q service.SearchQuery, opts ...service.FindOptions) ([]*service.SearchResult, int, error) {
+	readable := q.Readable
+	q.Readable = func(r *service.SearchResult) (bool, error) {
+		p, err := service.NewPermissionAtID(r.ID, service.ReadAction, service.ResourceType(r.Type), r.OrgID)
+		if err != nil {
+			return false, err
+		}
+
+		err = isAllowed(ctx, *p)
+		if err != nil && errors.ErrorCode(err) != errors.Unauthorized {
+			return false, err
+		}
+
+		if errors.ErrorCode(err) == errors.Unauthorized {
+			return false, nil
+		}
+
+		if readable != nil {
+			return readable(r)
+		}
+		return true, nil
+	}
+
+	return s.s.Search(ctx, q, opts...)
+}
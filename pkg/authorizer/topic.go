@@ -0,0 +1,323 @@
+package authorizer
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/ustackq/indagate/pkg/service"
+	"github.com/ustackq/indagate/pkg/utils/errors"
+)
+
+var _ service.TopicService = (*TopicService)(nil)
+
+// TopicService wraps a service.TopicService and authorizes actions
+// against it appropriately.
+type TopicService struct {
+	s service.TopicService
+	q service.QuestionService
+	a service.ArticleService
+}
+
+// NewTopicService constructs an instance of an authorizing topic service.
+// The question and article services are used to look up the items related to topics.
+func NewTopicService(s service.TopicService, q service.QuestionService, a service.ArticleService) *TopicService {
+	return &TopicService{
+		s: s,
+		q: q,
+		a: a,
+	}
+}
+
+func authorizeTopicByAction(ctx context.Context, action service.Action, orgID, id service.ID) error {
+	p, err := service.NewPermissionAtID(id, action, service.TopicsResourceType, orgID)
+	if err != nil {
+		return err
+	}
+
+	if err := isAllowed(ctx, *p); err != nil {
+		return err
+	}
+
+	return nil
+}
+
+// findReadableTopic returns the topic if the authorizer on context has read access to it.
+func (s *TopicService) findReadableTopic(ctx context.Context, id service.ID) (*service.Topic, error) {
+	t, err := s.s.FindTopicByID(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := authorizeTopicByAction(ctx, service.ReadAction, t.OrgID, t.ID); err != nil {
+		return nil, err
+	}
+
+	return t, nil
+}
+
+// authorizeWriteTopic checks to see if the authorizer on context has write access to the topic.
+func (s *TopicService) authorizeWriteTopic(ctx context.Context, id service.ID) error {
+	t, err := s.s.FindTopicByID(ctx, id)
+	if err != nil {
+		return err
+	}
+
+	return authorizeTopicByAction(ctx, service.WriteAction, t.OrgID, t.ID)
+}
+
+// authorizeRelation allows moderators of the topic and the authors of the item
+// to change the topics of the item.
+func (s *TopicService) authorizeRelation(ctx context.Context, topicID service.ID, itemType service.ItemType, itemID service.ID) error {
+	t, err := s.findReadableTopic(ctx, topicID)
+	if err != nil {
+		return err
+	}
+
+	if err := authorizeTopicByAction(ctx, service.WriteAction, t.OrgID, t.ID); err == nil {
+		return nil
+	}
+
+	switch itemType {
+	case service.QuestionItemType:
+		q, err := s.q.FindQuestionByID(ctx, itemID)
+		if err != nil {
+			return err
+		}
+		return authorizeEditQuestion(ctx, q)
+	case service.ArticleItemType:
+		a, err := s.a.FindArticleByID(ctx, itemID)
+		if err != nil {
+			return err
+		}
+		return authorizeEditArticle(ctx, a)
+	default:
+		return &errors.Error{
+			Code: errors.Invalid,
+			Msg:  fmt.Sprintf("%s can not be related to topics", itemType),
+		}
+	}
+}
+
+// FindTopicByID checks to see if the authorizer on context has read access to the topic.
+func (s *TopicService) FindTopicByID(ctx context.Context, id service.ID) (*service.Topic, error) {
+	return s.findReadableTopic(ctx, id)
+}
+
+// FindTopicByURLToken checks to see if the authorizer on context has read access to the topic.
+func (s *TopicService) FindTopicByURLToken(ctx context.Context, token string) (*service.Topic, error) {
+	t, err := s.s.FindTopicByURLToken(ctx, token)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := authorizeTopicByAction(ctx, service.ReadAction, t.OrgID, t.ID); err != nil {
+		return nil, err
+	}
+
+	return t, nil
+}
+
+// FindTopics retrieves all topics that match the provided filter and then filters the list down to only the resources that are authorized.
+func (s *TopicService) FindTopics(ctx context.Context, filter service.TopicFilter, opts ...service.FindOptions) ([]*service.Topic, int, error) {
+	ts, _, err := s.s.FindTopics(ctx, filter, unpaged(opts)...)
+	if err != nil {
+		return nil, 0, err
+	}
+
+	topics := ts[:0]
+	for _, t := range ts {
+		err := authorizeTopicByAction(ctx, service.ReadAction, t.OrgID, t.ID)
+		if err != nil && errors.ErrorCode(err) != errors.Unauthorized {
+			return nil, 0, err
+		}
+
+		if errors.ErrorCode(err) == errors.Unauthorized {
+			continue
+		}
+
+		topics = append(topics, t)
+	}
+
+	start, end := service.PageWindow(len(topics), opts...)
+	return topics[start:end], len(topics), nil
+}
+
+// FindTopicPath checks to see if the authorizer on context has read access to the topic.
+func (s *TopicService) FindTopicPath(ctx context.Context, id service.ID) ([]*service.Topic, error) {
+	if _, err := s.findReadableTopic(ctx, id); err != nil {
+		return nil, err
+	}
+
+	return s.s.FindTopicPath(ctx, id)
+}
+
+// CreateTopic checks to see if the authorizer on context has write access to the topics of the organization.
+func (s *TopicService) CreateTopic(ctx context.Context, t *service.Topic) error {
+	p, err := service.NewPermission(service.WriteAction, service.TopicsResourceType, t.OrgID)
+	if err != nil {
+		return err
+	}
+
+	if err := isAllowed(ctx, *p); err != nil {
+		return err
+	}
+
+	return s.s.CreateTopic(ctx, t)
+}
+
+// UpdateTopic checks to see if the authorizer on context has write access to the topic.
+func (s *TopicService) UpdateTopic(ctx context.Context, id service.ID, upd service.TopicUpdate) (*service.Topic, error) {
+	if err := s.authorizeWriteTopic(ctx, id); err != nil {
+		return nil, err
+	}
+
+	return s.s.UpdateTopic(ctx, id, upd)
+}
+
+// DeleteTopic checks to see if the authorizer on context has write access to the topic.
+func (s *TopicService) DeleteTopic(ctx context.Context, id service.ID) error {
+	if err := s.authorizeWriteTopic(ctx, id); err != nil {
+		return err
+	}
+
+	return s.s.DeleteTopic(ctx, id)
+}
+
+// MergeTopic checks to see if the authorizer on context has write access to both topics
+// and is the user recorded for the merge.
+func (s *TopicService) MergeTopic(ctx context.Context, sourceID, targetID, userID service.ID) (*service.Topic, error) {
+	if err := authorizeSelf(ctx, userID); err != nil {
+		return nil, err
+	}
+
+	if err := s.authorizeWriteTopic(ctx, sourceID); err != nil {
+		return nil, err
+	}
+
+	if err := s.authorizeWriteTopic(ctx, targetID); err != nil {
+		return nil, err
+	}
+
+	return s.s.MergeTopic(ctx, sourceID, targetID, userID)
+}
+
+// FindTopicMerges checks to see if the authorizer on context has read access to the topic.
+func (s *TopicService) FindTopicMerges(ctx context.Context, targetID service.ID) ([]*service.TopicMerge, error) {
+	if _, err := s.findReadableTopic(ctx, targetID); err != nil {
+		return nil, err
+	}
+
+	return s.s.FindTopicMerges(ctx, targetID)
+}
+
+// FindTopicRelations retrieves all relations that match the provided filter and then filters the list down to the relations of readable topics.
+func (s *TopicService) FindTopicRelations(ctx context.Context, filter service.TopicRelationFilter, opts ...service.FindOptions) ([]*service.TopicRelation, int, error) {
+	rs, _, err := s.s.FindTopicRelations(ctx, filter, unpaged(opts)...)
+	if err != nil {
+		return nil, 0, err
+	}
+
+	relations := rs[:0]
+	for _, r := range rs {
+		_, err := s.findReadableTopic(ctx, r.TopicID)
+		if err != nil && errors.ErrorCode(err) != errors.Unauthorized {
+			return nil, 0, err
+		}
+
+		if errors.ErrorCode(err) == errors.Unauthorized {
+			continue
+		}
+
+		relations = append(relations, r)
+	}
+
+	start, end := service.PageWindow(len(relations), opts...)
+	return relations[start:end], len(relations), nil
+}
+
+// AddTopicRelation checks to see if the authorizer on context moderates the topic or owns the item.
+func (s *TopicService) AddTopicRelation(ctx context.Context, r *service.TopicRelation) error {
+	if err := s.authorizeRelation(ctx, r.TopicID, r.ItemType, r.ItemID); err != nil {
+		return err
+	}
+
+	return s.s.AddTopicRelation(ctx, r)
+}
+
+// RemoveTopicRelation checks to see if the authorizer on context moderates the topic or owns the item.
+func (s *TopicService) RemoveTopicRelation(ctx context.Context, topicID service.ID, itemType service.ItemType, itemID service.ID) error {
+	if err := s.authorizeRelation(ctx, topicID, itemType, itemID); err != nil {
+		return err
+	}
+
+	return s.s.RemoveTopicRelation(ctx, topicID, itemType, itemID)
+}
+
+// FindTopicFocus checks to see if the authorizer on context is the user and has read access to the topic.
+func (s *TopicService) FindTopicFocus(ctx context.Context, topicID, userID service.ID) (*service.TopicFocus, error) {
+	if err := authorizeSelf(ctx, userID); err != nil {
+		return nil, err
+	}
+
+	if _, err := s.findReadableTopic(ctx, topicID); err != nil {
+		return nil, err
+	}
+
+	return s.s.FindTopicFocus(ctx, topicID, userID)
+}
+
+// FindFocusedTopics checks to see if the authorizer on context is the user and filters the
+// topics down to the readable ones.
+func (s *TopicService) FindFocusedTopics(ctx context.Context, userID service.ID, opts ...service.FindOptions) ([]*service.Topic, int, error) {
+	if err := authorizeSelf(ctx, userID); err != nil {
+		return nil, 0, err
+	}
+
+	ts, _, err := s.s.FindFocusedTopics(ctx, userID, unpaged(opts)...)
+	if err != nil {
+		return nil, 0, err
+	}
+
+	topics := ts[:0]
+	for _, t := range ts {
+		err := authorizeTopicByAction(ctx, service.ReadAction, t.OrgID, t.ID)
+		if err != nil && errors.ErrorCode(err) != errors.Unauthorized {
+			return nil, 0, err
+		}
+
+		if errors.ErrorCode(err) == errors.Unauthorized {
+			continue
+		}
+
+		topics = append(topics, t)
+	}
+
+	start, end := service.PageWindow(len(topics), opts...)
+	return topics[start:end], len(topics), nil
+}
+
+// FocusTopic checks to see if the authorizer on context is the user and has read access to the topic.
+func (s *TopicService) FocusTopic(ctx context.Context, topicID, userID service.ID) (*service.Topic, error) {
+	if err := authorizeSelf(ctx, userID); err != nil {
+		return nil, err
+	}
+
+	if _, err := s.findReadableTopic(ctx, topicID); err != nil {
+		return nil, err
+	}
+
+	return s.s.FocusTopic(ctx, topicID, userID)
+}
+
+// UnfocusTopic checks to see if the authorizer on context is the user and has read access to the topic.
+func (s *TopicService) UnfocusTopic(ctx context.Context, topicID, userID service.ID) (*service.Topic, error) {
+	if err := authorizeSelf(ctx, userID); err != nil {
+		return nil, err
+	}
+
+	if _, err := s.findReadableTopic(ctx, topicID); err != nil {
+		return nil, err
+	}
+
+	return s.s.UnfocusTopic(ctx, topicID, userID)
+}
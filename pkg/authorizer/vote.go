@@ -0,0 +1,100 @@
+package authorizer
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/ustackq/indagate/pkg/service"
+	"github.com/ustackq/indagate/pkg/utils/errors"
+)
+
+var _ service.VoteService = (*VoteService)(nil)
+
+// VoteService wraps a service.VoteService and authorizes actions
+// against it appropriately.
+type VoteService struct {
+	s     service.VoteService
+	items *itemServices
+}
+
+// NewVoteService constructs an instance of an authorizing vote service.
+// The question, answer, article and comment services are used to look up the items voted on.
+func NewVoteService(s service.VoteService, q service.QuestionService, a service.AnswerService, ar service.ArticleService, c service.CommentService) *VoteService {
+	return &VoteService{
+		s:     s,
+		items: &itemServices{q: q, a: a, ar: ar, c: c},
+	}
+}
+
+// itemResourceTypes maps the item types onto the resource types guarding them.
+var itemResourceTypes = map[service.ItemType]service.ResourceType{
+	service.QuestionItemType: service.QuestionsResourceType,
+	service.AnswerItemType:   service.AnswersResourceType,
+	service.ArticleItemType:  service.ArticlesResourceType,
+	service.CommentItemType:  service.CommentsResourceType,
+}
+
+// authorizeReadItem checks the authorizer on context has read access to the item, hidden
+// and pending items can only be voted on by those who can read them.
+func (s *VoteService) authorizeReadItem(ctx context.Context, itemType service.ItemType, itemID service.ID) (*service.VoteItem, error) {
+	if _, ok := itemResourceTypes[itemType]; !ok {
+		return nil, &errors.Error{
+			Code: errors.Invalid,
+			Msg:  fmt.Sprintf("voting on %s is not supported", itemType),
+		}
+	}
+
+	item, err := s.s.FindVoteItem(ctx, itemType, itemID)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := s.items.authorizeReadItem(ctx, itemType, item.OrgID, item.ID); err != nil {
+		return nil, err
+	}
+	return item, nil
+}
+
+// FindVoteItem checks to see if the authorizer on context has read access to the item.
+func (s *VoteService) FindVoteItem(ctx context.Context, itemType service.ItemType, itemID service.ID) (*service.VoteItem, error) {
+	return s.authorizeReadItem(ctx, itemType, itemID)
+}
+
+// FindVote checks to see if the authorizer on context is the voter and has read access to the item.
+func (s *VoteService) FindVote(ctx context.Context, itemType service.ItemType, itemID, userID service.ID) (*service.Vote, error) {
+	if err := authorizeSelf(ctx, userID); err != nil {
+		return nil, err
+	}
+
+	if _, err := s.authorizeReadItem(ctx, itemType, itemID); err != nil {
+		return nil, err
+	}
+
+	return s.s.FindVote(ctx, itemType, itemID, userID)
+}
+
+// Vote checks to see if the authorizer on context is the voter and has read access to the item.
+func (s *VoteService) Vote(ctx context.Context, v *service.Vote) (*service.VoteItem, error) {
+	if err := authorizeSelf(ctx, v.UserID); err != nil {
+		return nil, err
+	}
+
+	if _, err := s.authorizeReadItem(ctx, v.ItemType, v.ItemID); err != nil {
+		return nil, err
+	}
+
+	return s.s.Vote(ctx, v)
+}
+
+// DeleteVote checks to see if the authorizer on context is the voter and has read access to the item.
+func (s *VoteService) DeleteVote(ctx context.Context, itemType service.ItemType, itemID, userID service.ID) (*service.VoteItem, error) {
+	if err := authorizeSelf(ctx, userID); err != nil {
+		return nil, err
+	}
+
+	if _, err := s.authorizeReadItem(ctx, itemType, itemID); err != nil {
+		return nil, err
+	}
+
+	return s.s.DeleteVote(ctx, itemType, itemID, userID)
+}
@@ -0,0 +1,71 @@
+package authorizer_test
+
+import (
+	"context"
+	"testing"
+
+	"github.com/ustackq/indagate/pkg/authorizer"
+	"github.com/ustackq/indagate/pkg/service"
+	"github.com/ustackq/indagate/pkg/utils/errors"
+)
+
+func TestVoteService_HiddenAndPendingItems(t *testing.T) {
+	ctx := context.Background()
+	s := newInmemService(t)
+	vs := authorizer.NewVoteService(s, s, s, s, s)
+
+	org, author, newcomer, reader, moderator := service.ID(1), service.ID(2), service.ID(3), service.ID(4), service.ID(5)
+	q := &service.Question{OrgID: org, UserID: author, Content: "why?"}
+	if err := s.CreateQuestion(ctx, q); err != nil {
+		t.Fatal(err)
+	}
+	res := &service.Resolution{Action: service.HideModeration, Reason: "spam", ModeratorID: moderator}
+	if _, err := s.Report(ctx, &service.Report{Type: service.QuestionReport, TargetID: q.ID, OrgID: org, UserID: reader, Reason: "spam"}); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := s.ResolveReports(ctx, org, service.QuestionReport, q.ID, res); err != nil {
+		t.Fatal(err)
+	}
+
+	other := &service.Question{OrgID: org, UserID: author, Content: "how?"}
+	if err := s.CreateQuestion(ctx, other); err != nil {
+		t.Fatal(err)
+	}
+	s.Config.ApprovalPolicy = service.ApprovalPolicy{FirstPosts: 1}
+	a := &service.Answer{QuestionID: other.ID, UserID: newcomer, Content: "because"}
+	if err := s.CreateAnswer(ctx, a); err != nil {
+		t.Fatal(err)
+	}
+	if !a.Pending {
+		t.Fatalf("answer = %+v, want it pending", a)
+	}
+
+	for _, tt := range []struct {
+		name     string
+		userID   service.ID
+		role     service.Role
+		itemType service.ItemType
+		itemID   service.ID
+		want     string
+	}{
+		{name: "hidden question by a reader", userID: reader, role: service.RoleMember, itemType: service.QuestionItemType, itemID: q.ID, want: errors.Unauthorized},
+		{name: "hidden question by a moderator", userID: moderator, role: service.RoleModerator, itemType: service.QuestionItemType, itemID: q.ID},
+		{name: "pending answer by a reader", userID: reader, role: service.RoleMember, itemType: service.AnswerItemType, itemID: a.ID, want: errors.Unauthorized},
+		{name: "pending answer by its author", userID: newcomer, role: service.RoleMember, itemType: service.AnswerItemType, itemID: a.ID},
+		{name: "published question by a reader", userID: reader, role: service.RoleMember, itemType: service.QuestionItemType, itemID: other.ID},
+	} {
+		t.Run(tt.name, func(t *testing.T) {
+			ctx := withRole(t, tt.userID, org, tt.role)
+			if _, err := vs.FindVoteItem(ctx, tt.itemType, tt.itemID); errors.ErrorCode(err) != tt.want {
+				t.Errorf("FindVoteItem() error = %v, want %q", err, tt.want)
+			}
+			if tt.want == "" {
+				return
+			}
+			v := &service.Vote{ItemType: tt.itemType, ItemID: tt.itemID, UserID: tt.userID, Value: service.UpVote}
+			if _, err := vs.Vote(ctx, v); errors.ErrorCode(err) != tt.want {
+				t.Errorf("Vote() error = %v, want %q", err, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,123 @@
+package filter
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"sync"
+
+	"github.com/ustackq/indagate/pkg/search"
+	"github.com/ustackq/indagate/pkg/service"
+)
+
+var (
+	_ service.ContentFilter = (*Bayes)(nil)
+	_ service.SpamTrainer   = (*Bayes)(nil)
+)
+
+const (
+	// DefaultBayesHoldScore is the default spam probability from which contents are held.
+	DefaultBayesHoldScore = 0.9
+	// DefaultBayesRejectScore is the default spam probability from which contents are rejected.
+	DefaultBayesRejectScore = 0.99
+	// DefaultBayesMinSamples is the default number of samples of each class needed to score.
+	DefaultBayesMinSamples = 10
+)
+
+// Bayes is a naive Bayesian spam scorer over the terms of the contents, it learns
+// from the contents moderators approve or remove.
+type Bayes struct {
+	// HoldScore and RejectScore are the spam probabilities from which contents are
+	// held or rejected, a zero score disables the verdict.
+	HoldScore   float64
+	RejectScore float64
+	// MinSamples is the number of spam and of ham samples needed before scoring.
+	MinSamples int
+
+	mu       sync.RWMutex
+	spamDocs int
+	hamDocs  int
+	spam     map[string]int
+	ham      map[string]int
+}
+
+// NewBayes returns an untrained scorer with the default scores.
+func NewBayes() *Bayes {
+	return &Bayes{
+		HoldScore:   DefaultBayesHoldScore,
+		RejectScore: DefaultBayesRejectScore,
+		MinSamples:  DefaultBayesMinSamples,
+		spam:        map[string]int{},
+		ham:         map[string]int{},
+	}
+}
+
+// Train counts the distinct terms of the content as spam or ham.
+func (b *Bayes) Train(ctx context.Context, c *service.Content, spam bool) error {
+	terms := search.Terms(c.Text())
+
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	counts := b.ham
+	if spam {
+		counts = b.spam
+		b.spamDocs++
+	} else {
+		b.hamDocs++
+	}
+	for _, t := range terms {
+		counts[t]++
+	}
+	return nil
+}
+
+// Score returns the probability of the text being spam, false until the scorer
+// has been trained with enough samples.
+func (b *Bayes) Score(text string) (float64, bool) {
+	terms := search.Terms(text)
+
+	b.mu.RLock()
+	defer b.mu.RUnlock()
+
+	if b.spamDocs < b.MinSamples || b.hamDocs < b.MinSamples {
+		return 0, false
+	}
+
+	// log odds of spam, with Laplace smoothing of the term probabilities.
+	odds := math.Log(float64(b.spamDocs) / float64(b.hamDocs))
+	for _, t := range terms {
+		s, h := b.spam[t], b.ham[t]
+		if s == 0 && h == 0 {
+			continue
+		}
+		ps := float64(s+1) / float64(b.spamDocs+2)
+		ph := float64(h+1) / float64(b.hamDocs+2)
+		odds += math.Log(ps / ph)
+	}
+	return 1 / (1 + math.Exp(-odds)), true
+}
+
+// Filter holds or rejects the contents likely to be spam.
+func (b *Bayes) Filter(ctx context.Context, c *service.Content) (*service.FilterResult, error) {
+	score, ok := b.Score(c.Text())
+	if !ok {
+		return service.Allowed, nil
+	}
+
+	verdict := service.AllowContent
+	switch {
+	case b.RejectScore > 0 && score >= b.RejectScore:
+		verdict = service.RejectContent
+	case b.HoldScore > 0 && score >= b.HoldScore:
+		verdict = service.HoldContent
+	default:
+		return service.Allowed, nil
+	}
+
+	return &service.FilterResult{
+		Verdict: verdict,
+		Filter:  "bayes",
+		Reason:  fmt.Sprintf("looks like spam (%.0f%%)", score*100),
+	}, nil
+}
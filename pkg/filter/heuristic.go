@@ -0,0 +1,180 @@
+package filter
+
+import (
+	"context"
+	"fmt"
+	"hash/fnv"
+	"regexp"
+	"sync"
+	"time"
+
+	"github.com/ustackq/indagate/pkg/search"
+	"github.com/ustackq/indagate/pkg/service"
+)
+
+var (
+	_ service.ContentFilter = (*LinkFilter)(nil)
+	_ service.ContentFilter = (*RepeatFilter)(nil)
+)
+
+var linkPattern = regexp.MustCompile(`(?i)(https?://|www\.)\S+`)
+
+// CountLinks returns the number of links of the text.
+func CountLinks(text string) int {
+	return len(linkPattern.FindAllStringIndex(text, -1))
+}
+
+// LinkFilter holds or rejects the contents with too many links, a zero limit disables it.
+type LinkFilter struct {
+	// Hold is the number of links from which contents are held.
+	Hold int
+	// Reject is the number of links from which contents are rejected.
+	Reject int
+}
+
+// Filter counts the links of the content.
+func (f *LinkFilter) Filter(ctx context.Context, c *service.Content) (*service.FilterResult, error) {
+	n := CountLinks(c.Text())
+	switch {
+	case f.Reject > 0 && n >= f.Reject:
+		return &service.FilterResult{
+			Verdict: service.RejectContent,
+			Filter:  "links",
+			Reason:  fmt.Sprintf("contains %d links", n),
+		}, nil
+	case f.Hold > 0 && n >= f.Hold:
+		return &service.FilterResult{
+			Verdict: service.HoldContent,
+			Filter:  "links",
+			Reason:  fmt.Sprintf("contains %d links", n),
+		}, nil
+	}
+	return service.Allowed, nil
+}
+
+const (
+	// DefaultRepeatMinTerms is the default number of terms from which repetition is measured.
+	DefaultRepeatMinTerms = 10
+	// DefaultRepeatMaxRatio is the default share of a content a single term may take.
+	DefaultRepeatMaxRatio = 0.5
+	// DefaultRepeatHistory is the default number of contents remembered per user.
+	DefaultRepeatHistory = 20
+
+	// repeatSweepUsers is the number of users remembered from which expired contents are swept.
+	repeatSweepUsers = 10000
+)
+
+type recentContent struct {
+	hash uint64
+	at   time.Time
+}
+
+// RepeatFilter holds the contents repeating a single term over and over, and the
+// contents a user has already posted within the window. Short contents such as
+// greetings are never held.
+type RepeatFilter struct {
+	// MinTerms is the number of terms from which contents are checked.
+	MinTerms int
+	// MaxRatio is the share of the terms of a content a single term may take.
+	MaxRatio float64
+	// Window is how long the contents of a user are remembered, zero disables the
+	// detection of duplicates.
+	Window time.Duration
+	// History bounds the number of contents remembered per user.
+	History int
+
+	mu     sync.Mutex
+	recent map[service.ID][]recentContent
+	now    func() time.Time
+}
+
+// NewRepeatFilter returns a repetition filter remembering the contents of users for window.
+func NewRepeatFilter(window time.Duration) *RepeatFilter {
+	return &RepeatFilter{
+		MinTerms: DefaultRepeatMinTerms,
+		MaxRatio: DefaultRepeatMaxRatio,
+		Window:   window,
+		History:  DefaultRepeatHistory,
+		recent:   map[service.ID][]recentContent{},
+		now:      time.Now,
+	}
+}
+
+// Filter holds repetitive and duplicated contents.
+func (f *RepeatFilter) Filter(ctx context.Context, c *service.Content) (*service.FilterResult, error) {
+	text := c.Text()
+	tokens := search.Tokenize(text)
+	if len(tokens) < f.MinTerms {
+		return service.Allowed, nil
+	}
+
+	if term, ok := f.repeated(tokens); ok {
+		return &service.FilterResult{
+			Verdict: service.HoldContent,
+			Filter:  "repeat",
+			Reason:  fmt.Sprintf("repeats %q", term),
+		}, nil
+	}
+
+	if f.Window > 0 && f.duplicated(c.UserID, text) {
+		return &service.FilterResult{
+			Verdict: service.HoldContent,
+			Filter:  "repeat",
+			Reason:  "has already been posted",
+		}, nil
+	}
+	return service.Allowed, nil
+}
+
+// repeated returns the term taking more than MaxRatio of the tokens.
+func (f *RepeatFilter) repeated(tokens []search.Token) (string, bool) {
+	freqs := map[string]int{}
+	for _, t := range tokens {
+		freqs[t.Term]++
+	}
+
+	for term, n := range freqs {
+		if float64(n) > f.MaxRatio*float64(len(tokens)) {
+			return term, true
+		}
+	}
+	return "", false
+}
+
+// duplicated reports whether the user posted the text within the window and remembers it.
+func (f *RepeatFilter) duplicated(userID service.ID, text string) bool {
+	h := fnv.New64a()
+	h.Write([]byte(text))
+	sum := h.Sum64()
+
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	now := f.now()
+	if len(f.recent) > repeatSweepUsers {
+		for id, rs := range f.recent {
+			if len(rs) == 0 || now.Sub(rs[len(rs)-1].at) > f.Window {
+				delete(f.recent, id)
+			}
+		}
+	}
+
+	found := false
+	recent := f.recent[userID][:0]
+	for _, r := range f.recent[userID] {
+		if now.Sub(r.at) > f.Window {
+			continue
+		}
+		if r.hash == sum {
+			found = true
+		}
+		recent = append(recent, r)
+	}
+
+	recent = append(recent, recentContent{hash: sum, at: now})
+	if len(recent) > f.History {
+		recent = recent[len(recent)-f.History:]
+	}
+	f.recent[userID] = recent
+	return found
+}
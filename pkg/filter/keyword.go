@@ -0,0 +1,108 @@
+package filter
+
+import (
+	"bufio"
+	"context"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+	"sync"
+
+	"github.com/ustackq/indagate/pkg/service"
+)
+
+var _ service.ContentFilter = (*KeywordFilter)(nil)
+
+// KeywordFilter gives its verdict on the contents containing a word of its lists.
+// The lists are plain text files of one word per line, Chinese and English words
+// can be mixed, lines starting with # are comments.
+type KeywordFilter struct {
+	verdict service.FilterVerdict
+	paths   []string
+
+	mu   sync.RWMutex
+	trie *Trie
+}
+
+// NewKeywordFilter returns a filter giving the verdict on the words of the lists at paths.
+func NewKeywordFilter(verdict service.FilterVerdict, paths ...string) (*KeywordFilter, error) {
+	f := &KeywordFilter{
+		verdict: verdict,
+		paths:   paths,
+		trie:    NewTrie(),
+	}
+	if err := f.Reload(); err != nil {
+		return nil, err
+	}
+	return f, nil
+}
+
+// ReadWords reads a word list.
+func ReadWords(r io.Reader) ([]string, error) {
+	var words []string
+	scanner := bufio.NewScanner(r)
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		words = append(words, line)
+	}
+	return words, scanner.Err()
+}
+
+// Reload reads the lists again, the filter keeps its words if a list can not be read.
+func (f *KeywordFilter) Reload() error {
+	var words []string
+	for _, path := range f.paths {
+		file, err := os.Open(path)
+		if err != nil {
+			return err
+		}
+
+		ws, err := ReadWords(file)
+		file.Close()
+		if err != nil {
+			return fmt.Errorf("reading word list %s: %v", path, err)
+		}
+		words = append(words, ws...)
+	}
+
+	f.SetWords(words)
+	return nil
+}
+
+// SetWords replaces the words of the filter.
+func (f *KeywordFilter) SetWords(words []string) {
+	trie := NewTrie(words...)
+
+	f.mu.Lock()
+	f.trie = trie
+	f.mu.Unlock()
+}
+
+// Len returns the number of words of the filter.
+func (f *KeywordFilter) Len() int {
+	f.mu.RLock()
+	defer f.mu.RUnlock()
+	return f.trie.Len()
+}
+
+// Filter gives the verdict of the filter on contents containing one of its words.
+func (f *KeywordFilter) Filter(ctx context.Context, c *service.Content) (*service.FilterResult, error) {
+	f.mu.RLock()
+	trie := f.trie
+	f.mu.RUnlock()
+
+	matches := trie.Find(c.Text())
+	if len(matches) == 0 {
+		return service.Allowed, nil
+	}
+
+	return &service.FilterResult{
+		Verdict: f.verdict,
+		Filter:  "keyword",
+		Reason:  fmt.Sprintf("contains the word %q", matches[0].Word),
+	}, nil
+}
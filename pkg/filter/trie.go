@@ -0,0 +1,113 @@
+// Package filter holds the content filters run before user content is written:
+// keyword lists matched through a trie, link and repetition heuristics and a
+// Bayesian spam scorer trained from moderator decisions.
+package filter
+
+import (
+	"strings"
+	"unicode"
+)
+
+type trieNode struct {
+	children map[rune]*trieNode
+	// word is set on the nodes ending a word.
+	word string
+}
+
+// Trie finds the words of a list in texts in a single pass.
+type Trie struct {
+	root *trieNode
+	size int
+}
+
+// NewTrie returns a trie holding the words.
+func NewTrie(words ...string) *Trie {
+	t := &Trie{root: &trieNode{}}
+	for _, w := range words {
+		t.Insert(w)
+	}
+	return t
+}
+
+// Insert adds a word to the trie, words are matched case insensitively.
+func (t *Trie) Insert(word string) {
+	word = strings.ToLower(strings.TrimSpace(word))
+	if word == "" {
+		return
+	}
+
+	n := t.root
+	for _, r := range word {
+		child, ok := n.children[r]
+		if !ok {
+			if n.children == nil {
+				n.children = map[rune]*trieNode{}
+			}
+			child = &trieNode{}
+			n.children[r] = child
+		}
+		n = child
+	}
+	if n.word == "" {
+		t.size++
+	}
+	n.word = word
+}
+
+// Len returns the number of words of the trie.
+func (t *Trie) Len() int {
+	return t.size
+}
+
+// Match is a word of the trie found in a text, Start and End are rune offsets.
+type Match struct {
+	Word  string
+	Start int
+	End   int
+}
+
+// isCJK reports whether the rune is written without spaces between words.
+func isCJK(r rune) bool {
+	return unicode.In(r, unicode.Han, unicode.Hiragana, unicode.Katakana, unicode.Hangul)
+}
+
+// isLatin reports whether the rune is part of a space separated word.
+func isLatin(r rune) bool {
+	return (unicode.IsLetter(r) || unicode.IsDigit(r)) && !isCJK(r)
+}
+
+// Find returns the longest word found at each position of the text, matches do
+// not overlap. Latin words only match whole words so that "ass" is not found in
+// "class", CJK words, which have no boundaries, match anywhere.
+func (t *Trie) Find(text string) []Match {
+	runes := []rune(strings.ToLower(text))
+
+	var matches []Match
+	for i := 0; i < len(runes); i++ {
+		if i > 0 && isLatin(runes[i]) && isLatin(runes[i-1]) {
+			continue
+		}
+
+		var m *Match
+		n := t.root
+		for j := i; j < len(runes); j++ {
+			n = n.children[runes[j]]
+			if n == nil {
+				break
+			}
+			if n.word == "" {
+				continue
+			}
+			if isLatin(runes[j]) && j+1 < len(runes) && isLatin(runes[j+1]) {
+				continue
+			}
+			m = &Match{Word: n.word, Start: i, End: j + 1}
+		}
+
+		if m != nil {
+			matches = append(matches, *m)
+			i = m.End - 1
+		}
+	}
+	return matches
+}
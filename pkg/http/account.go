@@ -0,0 +1,181 @@
+package http
+
+import (
+	"net/http"
+
+	"github.com/julienschmidt/httprouter"
+	"go.uber.org/zap"
+
+	"github.com/ustackq/indagate/pkg/service"
+	"github.com/ustackq/indagate/pkg/utils/errors"
+)
+
+// AccountBackend is all services and associated parameters required to construct
+// the AccountHandler.
+type AccountBackend struct {
+	Logger *zap.Logger
+
+	AccountTokenService service.AccountTokenService
+	AccountMailer       service.AccountMailer
+	UserService         service.UserService
+	PasswordsService    service.PasswordsService
+}
+
+// NewAccountBackend returns a new instance of AccountBackend.
+func NewAccountBackend(ab *APIBackend) *AccountBackend {
+	return &AccountBackend{
+		Logger: ab.Logger.With(zap.String("handler", "account")),
+
+		AccountTokenService: ab.AccountTokenService,
+		AccountMailer:       ab.AccountMailer,
+		UserService:         ab.UserService,
+		PasswordsService:    ab.PasswordsService,
+	}
+}
+
+// AccountHandler represents an HTTP API handler for the links of the activation and
+// password reset mails, its routes are used without signing in.
+type AccountHandler struct {
+	*httprouter.Router
+	Logger *zap.Logger
+
+	AccountTokenService service.AccountTokenService
+	AccountMailer       service.AccountMailer
+	UserService         service.UserService
+	PasswordsService    service.PasswordsService
+}
+
+const (
+	accountPath              = "/api/v1/account"
+	accountActivatePath      = "/api/v1/account/activate"
+	accountPasswordPath      = "/api/v1/account/password"
+	accountPasswordResetPath = "/api/v1/account/password/reset"
+)
+
+// NewAccountHandler returns a new instance of AccountHandler.
+func NewAccountHandler(ab *AccountBackend) *AccountHandler {
+	h := &AccountHandler{
+		Router: NewRouter(),
+		Logger: ab.Logger,
+
+		AccountTokenService: ab.AccountTokenService,
+		AccountMailer:       ab.AccountMailer,
+		UserService:         ab.UserService,
+		PasswordsService:    ab.PasswordsService,
+	}
+
+	h.POST(accountActivatePath, h.handleActivate)
+	h.POST(accountPasswordResetPath, h.handleResetPassword)
+	h.PUT(accountPasswordPath, h.handlePutPassword)
+
+	return h
+}
+
+type activateRequest struct {
+	Token string `json:"token"`
+}
+
+func (h *AccountHandler) handleActivate(rw http.ResponseWriter, r *http.Request, ps httprouter.Params) {
+	ctx := r.Context()
+
+	req := &activateRequest{}
+	if err := json.NewDecoder(r.Body).Decode(req); err != nil {
+		EncodeError(ctx, &errors.Error{
+			Code: errors.Invalid,
+			Err:  err,
+		}, rw)
+		return
+	}
+
+	u, err := h.AccountTokenService.ActivateUser(ctx, req.Token)
+	if err != nil {
+		EncodeError(ctx, err, rw)
+		return
+	}
+
+	if err := encodeResponse(ctx, rw, http.StatusOK, newUserResponse(u)); err != nil {
+		EncodeError(ctx, err, rw)
+		return
+	}
+}
+
+type resetPasswordRequest struct {
+	Name string `json:"name"`
+}
+
+// handleResetPassword mails a password reset link to the named user. It answers the
+// same whether the user exists or not, so that it can not be used to find users.
+func (h *AccountHandler) handleResetPassword(rw http.ResponseWriter, r *http.Request, ps httprouter.Params) {
+	ctx := r.Context()
+
+	req := &resetPasswordRequest{}
+	if err := json.NewDecoder(r.Body).Decode(req); err != nil {
+		EncodeError(ctx, &errors.Error{
+			Code: errors.Invalid,
+			Err:  err,
+		}, rw)
+		return
+	}
+
+	u, err := h.UserService.FindUser(ctx, service.UserFilter{Name: &req.Name})
+	if errors.ErrorCode(err) == errors.NotFound {
+		rw.WriteHeader(http.StatusNoContent)
+		return
+	}
+	if err != nil {
+		EncodeError(ctx, err, rw)
+		return
+	}
+
+	if u.Email != "" {
+		t, err := h.AccountTokenService.CreateAccountToken(ctx, service.ResetPasswordToken, u.ID)
+		if err != nil {
+			EncodeError(ctx, err, rw)
+			return
+		}
+
+		if err := h.AccountMailer.SendAccountMail(ctx, u, t); err != nil {
+			EncodeError(ctx, err, rw)
+			return
+		}
+	}
+
+	rw.WriteHeader(http.StatusNoContent)
+}
+
+type putPasswordRequest struct {
+	Token    string `json:"token"`
+	Password string `json:"password"`
+}
+
+func (h *AccountHandler) handlePutPassword(rw http.ResponseWriter, r *http.Request, ps httprouter.Params) {
+	ctx := r.Context()
+
+	req := &putPasswordRequest{}
+	if err := json.NewDecoder(r.Body).Decode(req); err != nil {
+		EncodeError(ctx, &errors.Error{
+			Code: errors.Invalid,
+			Err:  err,
+		}, rw)
+		return
+	}
+
+	t, err := h.AccountTokenService.ConsumeAccountToken(ctx, service.ResetPasswordToken, req.Token)
+	if err != nil {
+		EncodeError(ctx, err, rw)
+		return
+	}
+
+	u, err := h.UserService.FindUserByID(ctx, t.UserID)
+	if err != nil {
+		EncodeError(ctx, err, rw)
+		return
+	}
+
+	if err := h.PasswordsService.SetPassword(ctx, u.Name, req.Password); err != nil {
+		EncodeError(ctx, err, rw)
+		return
+	}
+
+	rw.WriteHeader(http.StatusNoContent)
+}
@@ -0,0 +1,316 @@
+package http
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+
+	"github.com/julienschmidt/httprouter"
+	"go.uber.org/zap"
+
+	icontext "github.com/ustackq/indagate/pkg/context"
+	"github.com/ustackq/indagate/pkg/service"
+	"github.com/ustackq/indagate/pkg/utils/errors"
+)
+
+// AnswerBackend is all services and associated parameters required to construct
+// the AnswerHandler and the answer routes of the QuestionHandler.
+type AnswerBackend struct {
+	Logger *zap.Logger
+
+	AnswerService service.AnswerService
+}
+
+// NewAnswerBackend returns a new instance of AnswerBackend.
+func NewAnswerBackend(ab *APIBackend) *AnswerBackend {
+	return &AnswerBackend{
+		Logger: ab.Logger.With(zap.String("handler", "answer")),
+
+		AnswerService: ab.AnswerService,
+	}
+}
+
+// AnswerHandler represents an HTTP API handler for answers.
+type AnswerHandler struct {
+	*httprouter.Router
+	Logger *zap.Logger
+
+	AnswerService service.AnswerService
+}
+
+const (
+	answersIDPath = "/api/v1/answers/:id"
+
+	questionsIDAnswersPath    = "/api/v1/questions/:id/answers"
+	questionsIDBestAnswerPath = "/api/v1/questions/:id/best-answer"
+)
+
+// NewAnswerHandler returns a new instance of AnswerHandler.
+func NewAnswerHandler(ab *AnswerBackend) *AnswerHandler {
+	ah := &AnswerHandler{
+		Router: NewRouter(),
+		Logger: ab.Logger,
+
+		AnswerService: ab.AnswerService,
+	}
+
+	ah.GET(answersIDPath, ah.handleGetAnswer)
+	ah.PATCH(answersIDPath, ah.handlePatchAnswer)
+	ah.DELETE(answersIDPath, ah.handleDeleteAnswer)
+
+	return ah
+}
+
+type answerResponse struct {
+	Links map[string]string `json:"links"`
+	service.Answer
+}
+
+func newAnswerResponse(a *service.Answer) *answerResponse {
+	return &answerResponse{
+		Links: map[string]string{
+			"self":     fmt.Sprintf("/api/v1/answers/%s", a.ID),
+			"question": fmt.Sprintf("/api/v1/questions/%s", a.QuestionID),
+		},
+		Answer: *a,
+	}
+}
+
+type answersResponse struct {
+	Links   map[string]string `json:"links"`
+	Answers []*answerResponse `json:"answers"`
+	Total   int               `json:"total"`
+}
+
+func newAnswersResponse(questionID service.ID, opts service.FindOptions, as []*service.Answer, total int) *answersResponse {
+	res := &answersResponse{
+		Links:   pagingLinks(fmt.Sprintf("/api/v1/questions/%s/answers", questionID), opts, len(as), total),
+		Answers: make([]*answerResponse, 0, len(as)),
+		Total:   total,
+	}
+
+	for _, a := range as {
+		res.Answers = append(res.Answers, newAnswerResponse(a))
+	}
+	return res
+}
+
+type postAnswerRequest struct {
+	Content   string `json:"content"`
+	Anonymous bool   `json:"anonymous"`
+}
+
+func decodePostAnswerRequest(ctx context.Context, r *http.Request, ps httprouter.Params) (*service.Answer, error) {
+	qr, err := decodeRequest(r, ps)
+	if err != nil {
+		return nil, err
+	}
+
+	req := &postAnswerRequest{}
+	if err := json.NewDecoder(r.Body).Decode(req); err != nil {
+		return nil, &errors.Error{
+			Code: errors.Invalid,
+			Msg:  "invalid json structure",
+			Err:  err,
+		}
+	}
+
+	auth, err := icontext.GetAuthorizer(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	a := &service.Answer{
+		QuestionID: qr.ID,
+		UserID:     auth.GetUserID(),
+		Content:    req.Content,
+		Anonymous:  req.Anonymous,
+	}
+	return a, a.Valid()
+}
+
+func newPostQuestionAnswerHandler(ab AnswerBackend) httprouter.Handle {
+	return func(rw http.ResponseWriter, r *http.Request, ps httprouter.Params) {
+		ctx := r.Context()
+
+		a, err := decodePostAnswerRequest(ctx, r, ps)
+		if err != nil {
+			EncodeError(ctx, err, rw)
+			return
+		}
+
+		if err := ab.AnswerService.CreateAnswer(ctx, a); err != nil {
+			EncodeError(ctx, err, rw)
+			return
+		}
+
+		if err := encodeResponse(ctx, rw, http.StatusCreated, newAnswerResponse(a)); err != nil {
+			LogEncodeError(ab.Logger, r, err)
+			return
+		}
+	}
+}
+
+func newGetQuestionAnswersHandler(ab AnswerBackend) httprouter.Handle {
+	return func(rw http.ResponseWriter, r *http.Request, ps httprouter.Params) {
+		ctx := r.Context()
+
+		req, err := decodeRequest(r, ps)
+		if err != nil {
+			EncodeError(ctx, err, rw)
+			return
+		}
+
+		opts, err := decodeFindOptions(ctx, r)
+		if err != nil {
+			EncodeError(ctx, err, rw)
+			return
+		}
+
+		switch opts.SortBy {
+		case "":
+			opts.SortBy = service.AnswerSortByVotes
+			opts.Descending = true
+		case service.AnswerSortByVotes, service.AnswerSortByTime:
+		default:
+			EncodeError(ctx, &errors.Error{
+				Code: errors.Invalid,
+				Msg:  fmt.Sprintf("answers can only be sorted by %s or %s", service.AnswerSortByVotes, service.AnswerSortByTime),
+			}, rw)
+			return
+		}
+
+		filter := service.AnswerFilter{
+			QuestionID: &req.ID,
+		}
+		as, total, err := ab.AnswerService.FindAnswers(ctx, filter, *opts)
+		if err != nil {
+			EncodeError(ctx, err, rw)
+			return
+		}
+
+		if err := encodeResponse(ctx, rw, http.StatusOK, newAnswersResponse(req.ID, *opts, as, total)); err != nil {
+			LogEncodeError(ab.Logger, r, err)
+			return
+		}
+	}
+}
+
+type postBestAnswerRequest struct {
+	AnswerID service.ID `json:"answerID"`
+}
+
+func newPostBestAnswerHandler(ab AnswerBackend) httprouter.Handle {
+	return func(rw http.ResponseWriter, r *http.Request, ps httprouter.Params) {
+		ctx := r.Context()
+
+		qr, err := decodeRequest(r, ps)
+		if err != nil {
+			EncodeError(ctx, err, rw)
+			return
+		}
+
+		req := &postBestAnswerRequest{}
+		if err := json.NewDecoder(r.Body).Decode(req); err != nil {
+			EncodeError(ctx, &errors.Error{
+				Code: errors.Invalid,
+				Msg:  "invalid json structure",
+				Err:  err,
+			}, rw)
+			return
+		}
+
+		if !req.AnswerID.Valid() {
+			EncodeError(ctx, &errors.Error{
+				Code: errors.Invalid,
+				Msg:  "answer id required",
+			}, rw)
+			return
+		}
+
+		q, err := ab.AnswerService.SetBestAnswer(ctx, qr.ID, req.AnswerID)
+		if err != nil {
+			EncodeError(ctx, err, rw)
+			return
+		}
+
+		if err := encodeResponse(ctx, rw, http.StatusOK, newQuestionResponse(q)); err != nil {
+			LogEncodeError(ab.Logger, r, err)
+			return
+		}
+	}
+}
+
+func (ah *AnswerHandler) handleGetAnswer(rw http.ResponseWriter, r *http.Request, ps httprouter.Params) {
+	ctx := r.Context()
+
+	req, err := decodeRequest(r, ps)
+	if err != nil {
+		EncodeError(ctx, err, rw)
+		return
+	}
+
+	a, err := ah.AnswerService.FindAnswerByID(ctx, req.ID)
+	if err != nil {
+		EncodeError(ctx, err, rw)
+		return
+	}
+
+	if err := encodeResponse(ctx, rw, http.StatusOK, newAnswerResponse(a)); err != nil {
+		LogEncodeError(ah.Logger, r, err)
+		return
+	}
+}
+
+func (ah *AnswerHandler) handlePatchAnswer(rw http.ResponseWriter, r *http.Request, ps httprouter.Params) {
+	ctx := r.Context()
+
+	req, err := decodeRequest(r, ps)
+	if err != nil {
+		EncodeError(ctx, err, rw)
+		return
+	}
+
+	var upd service.AnswerUpdate
+	if err := json.NewDecoder(r.Body).Decode(&upd); err != nil {
+		EncodeError(ctx, &errors.Error{
+			Code: errors.Invalid,
+			Msg:  "invalid json structure",
+			Err:  err,
+		}, rw)
+		return
+	}
+
+	if err := upd.Valid(); err != nil {
+		EncodeError(ctx, err, rw)
+		return
+	}
+
+	a, err := ah.AnswerService.UpdateAnswer(ctx, req.ID, upd)
+	if err != nil {
+		EncodeError(ctx, err, rw)
+		return
+	}
+
+	if err := encodeResponse(ctx, rw, http.StatusOK, newAnswerResponse(a)); err != nil {
+		LogEncodeError(ah.Logger, r, err)
+		return
+	}
+}
+
+func (ah *AnswerHandler) handleDeleteAnswer(rw http.ResponseWriter, r *http.Request, ps httprouter.Params) {
+	ctx := r.Context()
+
+	req, err := decodeRequest(r, ps)
+	if err != nil {
+		EncodeError(ctx, err, rw)
+		return
+	}
+
+	if err := ah.AnswerService.DeleteAnswer(ctx, req.ID); err != nil {
+		EncodeError(ctx, err, rw)
+		return
+	}
+
+	rw.WriteHeader(http.StatusNoContent)
+}
@@ -1,6 +1,7 @@
 package http
 
 import (
+	"net"
 	"net/http"
 	"strings"
 
@@ -14,8 +15,25 @@ type APIHandler struct {
 	SessionHandler       *SessionHandler
 	OrgHandler           *OrgHandler
 	UserHandler          *UserHandler
+	AccountHandler       *AccountHandler
 	SetupHandler         *SetupHandler
 	AuthorizationHandler *AuthorizationHandler
+	QuestionHandler      *QuestionHandler
+	AnswerHandler        *AnswerHandler
+	BackupHandler        *BackupHandler
+	VoteHandler          *VoteHandler
+	CommentHandler       *CommentHandler
+	TopicHandler         *TopicHandler
+	ArticleHandler       *ArticleHandler
+	DraftHandler         *DraftHandler
+	NotificationHandler  *NotificationHandler
+	MessageHandler       *MessageHandler
+	FollowHandler        *FollowHandler
+	FeedHandler          *FeedHandler
+	SearchHandler        *SearchHandler
+	ModerationHandler    *ModerationHandler
+	ApprovalHandler      *ApprovalHandler
+	BanHandler           *BanHandler
 	SwaggerHandler       http.Handler
 }
 
@@ -25,15 +43,39 @@ type APIBackend struct {
 	AssetPath            string
 	Logger               *zap.Logger
 	SessionRenewDisabled bool
+	// MessageQPS and MessageBurst limit the messages sent by each user.
+	MessageQPS   float32
+	MessageBurst int
+	// TrustedProxies are the ranges of the proxies whose X-Forwarded-For header is trusted.
+	TrustedProxies []*net.IPNet
 
 	PasswordsService           service.PasswordsService
+	AccountTokenService        service.AccountTokenService
+	AccountMailer              service.AccountMailer
 	BucketService              service.BucketService
 	SetupService               service.SetupService
 	AuthenticationService      service.AuthorizationService
 	SessionService             service.SessionService
 	UserService                service.UserService
 	UserResourceMappingService service.UserResourceMappingService
+	RoleService                service.RoleService
 	OrganizationService        service.OrganizationService
+	QuestionService            service.QuestionService
+	AnswerService              service.AnswerService
+	BackupService              service.BackupService
+	VoteService                service.VoteService
+	CommentService             service.CommentService
+	TopicService               service.TopicService
+	ArticleService             service.ArticleService
+	DraftService               service.DraftService
+	NotificationService        service.NotificationService
+	MessageService             service.MessageService
+	FollowService              service.FollowService
+	FeedService                service.FeedService
+	SearchService              service.SearchService
+	ModerationService          service.ModerationService
+	ApprovalService            service.ApprovalService
+	BanService                 service.BanService
 	LookupService              service.LookupService
 	OrgLookupService           authorizer.OrganizationService
 }
@@ -53,6 +95,7 @@ func NewAPIHandler(ab *APIBackend) *APIHandler {
 	// create org handler
 	orgBackend := NewOrgBackend(ab)
 	orgBackend.OrganizationService = authorizer.NewOrgService(ab.OrganizationService)
+	orgBackend.RoleService = authorizer.NewRoleService(ab.RoleService)
 	ah.OrgHandler = NewOrgHandler(orgBackend)
 
 	// create user handler
@@ -60,11 +103,98 @@ func NewAPIHandler(ab *APIBackend) *APIHandler {
 	userBackend.UserService = authorizer.NewUserService(ab.UserService)
 	ah.UserHandler = NewUserHandler(userBackend)
 
+	// create account handler, its routes are used without signing in.
+	ah.AccountHandler = NewAccountHandler(NewAccountBackend(ab))
+
 	// create authorization handler
 	authorizationBackend := NewAuthorizationBackend(ab)
 	authorizationBackend.AuthorizationService = authorizer.NewAuthorizationService(ab.AuthenticationService)
 	ah.AuthorizationHandler = NewAuthorizationHandler(authorizationBackend)
 
+	// create question handler
+	questionBackend := NewQuestionBackend(ab)
+	questionBackend.QuestionService = authorizer.NewQuestionService(ab.QuestionService)
+	questionBackend.AnswerService = authorizer.NewAnswerService(ab.AnswerService, ab.QuestionService)
+	ah.QuestionHandler = NewQuestionHandler(questionBackend)
+
+	// create answer handler
+	answerBackend := NewAnswerBackend(ab)
+	answerBackend.AnswerService = authorizer.NewAnswerService(ab.AnswerService, ab.QuestionService)
+	ah.AnswerHandler = NewAnswerHandler(answerBackend)
+
+	// create vote handler
+	voteBackend := NewVoteBackend(ab)
+	voteBackend.VoteService = authorizer.NewVoteService(ab.VoteService, ab.QuestionService, ab.AnswerService, ab.ArticleService, ab.CommentService)
+	ah.VoteHandler = NewVoteHandler(voteBackend)
+
+	// create comment handler
+	commentBackend := NewCommentBackend(ab)
+	commentBackend.CommentService = authorizer.NewCommentService(ab.CommentService, ab.AnswerService, ab.ArticleService)
+	ah.CommentHandler = NewCommentHandler(commentBackend)
+
+	// create topic handler
+	topicBackend := NewTopicBackend(ab)
+	topicBackend.TopicService = authorizer.NewTopicService(ab.TopicService, ab.QuestionService, ab.ArticleService)
+	ah.TopicHandler = NewTopicHandler(topicBackend)
+
+	// create article handler
+	articleBackend := NewArticleBackend(ab)
+	articleBackend.ArticleService = authorizer.NewArticleService(ab.ArticleService)
+	articleBackend.TopicService = topicBackend.TopicService
+	ah.ArticleHandler = NewArticleHandler(articleBackend)
+
+	// create draft handler
+	draftBackend := NewDraftBackend(ab)
+	draftBackend.DraftService = authorizer.NewDraftService(ab.DraftService)
+	ah.DraftHandler = NewDraftHandler(draftBackend)
+
+	// create notification handler
+	notificationBackend := NewNotificationBackend(ab)
+	notificationBackend.NotificationService = authorizer.NewNotificationService(ab.NotificationService)
+	ah.NotificationHandler = NewNotificationHandler(notificationBackend)
+
+	// create message handler
+	messageBackend := NewMessageBackend(ab)
+	messageBackend.MessageService = authorizer.NewMessageService(ab.MessageService)
+	ah.MessageHandler = NewMessageHandler(messageBackend)
+
+	// create follow handler
+	followBackend := NewFollowBackend(ab)
+	followBackend.FollowService = authorizer.NewFollowService(ab.FollowService, ab.QuestionService, ab.TopicService)
+	ah.FollowHandler = NewFollowHandler(followBackend)
+
+	// create feed handler
+	feedBackend := NewFeedBackend(ab)
+	feedBackend.FeedService = authorizer.NewFeedService(ab.FeedService, ab.QuestionService, ab.AnswerService, ab.ArticleService, ab.CommentService)
+	ah.FeedHandler = NewFeedHandler(feedBackend)
+
+	// create search handler
+	searchBackend := NewSearchBackend(ab)
+	searchBackend.SearchService = authorizer.NewSearchService(ab.SearchService)
+	ah.SearchHandler = NewSearchHandler(searchBackend)
+
+	// create moderation handler
+	moderationBackend := NewModerationBackend(ab)
+	moderationBackend.ModerationService = authorizer.NewModerationService(ab.ModerationService)
+	ah.ModerationHandler = NewModerationHandler(moderationBackend)
+
+	// create approval handler
+	approvalBackend := NewApprovalBackend(ab)
+	approvalBackend.ApprovalService = authorizer.NewApprovalService(ab.ApprovalService)
+	ah.ApprovalHandler = NewApprovalHandler(approvalBackend)
+
+	// create ban handler
+	banBackend := NewBanBackend(ab)
+	banBackend.BanService = authorizer.NewBanService(ab.BanService)
+	ah.BanHandler = NewBanHandler(banBackend)
+
+	// create backup handler
+	backupBackend := NewBackupBackend(ab)
+	if ab.BackupService != nil {
+		backupBackend.BackupService = authorizer.NewBackupService(ab.BackupService)
+	}
+	ah.BackupHandler = NewBackupHandler(backupBackend)
+
 	stb := NewSetupBackend(ab)
 	ah.SetupHandler = NewSetupHandler(stb)
 	ah.SwaggerHandler = newSwaggerLoader(stb.Logger.With(zap.String("SERVICE", "swagger-loader")))
@@ -72,11 +202,21 @@ func NewAPIHandler(ab *APIBackend) *APIHandler {
 }
 
 var api = map[string]interface{}{
+	"account":        "/api/v1/account",
+	"answers":        "/api/v1/answers",
+	"articles":       "/api/v1/articles",
 	"authorizations": "/api/v1/authorizations",
+	"backup":         "/api/v1/backup",
+	"banaudits":      "/api/v1/banaudits",
+	"bans":           "/api/v1/bans",
 	"buckets":        "/api/v1/buckets",
+	"comments":       "/api/v1/comments",
+	"ipblocks":       "/api/v1/ipblocks",
 	"me":             "/api/v1/me",
 	"orgs":           "/api/v1/orgs",
+	"questions":      "/api/v1/questions",
 	"setup":          "/api/v1/setup",
+	"topics":         "/api/v1/topics",
 	"signin":         "/api/v1/signin",
 	"signout":        "/api/v1/signout",
 	"system": map[string]string{
@@ -121,6 +261,74 @@ func (ah *APIHandler) ServeHTTP(rw http.ResponseWriter, r *http.Request) {
 		return
 	}
 
+	// votes share the path layout of all item types.
+	if strings.HasSuffix(r.URL.Path, voteSuffix) {
+		ah.VoteHandler.ServeHTTP(rw, r)
+		return
+	}
+
+	// as do the comments of all commentable item types.
+	if strings.HasSuffix(r.URL.Path, commentsSuffix) || strings.HasPrefix(r.URL.Path, commentsPrefix) {
+		ah.CommentHandler.ServeHTTP(rw, r)
+		return
+	}
+
+	// as do the follows of all followable item types.
+	if strings.HasSuffix(r.URL.Path, followersSuffix) || strings.Contains(r.URL.Path, followingSegment) {
+		ah.FollowHandler.ServeHTTP(rw, r)
+		return
+	}
+
+	if r.URL.Path == meFeedPath || strings.HasSuffix(r.URL.Path, activitiesSuffix) {
+		ah.FeedHandler.ServeHTTP(rw, r)
+		return
+	}
+
+	if r.URL.Path == searchPath {
+		ah.SearchHandler.ServeHTTP(rw, r)
+		return
+	}
+
+	if r.URL.Path == reportsPath || strings.HasPrefix(r.URL.Path, moderationPrefix) {
+		ah.ModerationHandler.ServeHTTP(rw, r)
+		return
+	}
+
+	if strings.HasPrefix(r.URL.Path, approvalsPath) {
+		ah.ApprovalHandler.ServeHTTP(rw, r)
+		return
+	}
+
+	if strings.HasPrefix(r.URL.Path, bansPath) || strings.HasPrefix(r.URL.Path, ipBlocksPath) || r.URL.Path == banAuditsPath {
+		ah.BanHandler.ServeHTTP(rw, r)
+		return
+	}
+
+	if r.URL.Path == meTopicsPath {
+		ah.TopicHandler.ServeHTTP(rw, r)
+		return
+	}
+
+	if strings.HasPrefix(r.URL.Path, meDraftsPath) {
+		ah.DraftHandler.ServeHTTP(rw, r)
+		return
+	}
+
+	if strings.HasPrefix(r.URL.Path, notificationsPrefix) {
+		ah.NotificationHandler.ServeHTTP(rw, r)
+		return
+	}
+
+	if strings.HasPrefix(r.URL.Path, meDialogsPath) || strings.HasPrefix(r.URL.Path, meBlocksPath) {
+		ah.MessageHandler.ServeHTTP(rw, r)
+		return
+	}
+
+	if strings.HasPrefix(r.URL.Path, accountPath) {
+		ah.AccountHandler.ServeHTTP(rw, r)
+		return
+	}
+
 	if strings.HasPrefix(r.URL.Path, "/api/v1/users") {
 		ah.UserHandler.ServeHTTP(rw, r)
 		return
@@ -141,5 +349,30 @@ func (ah *APIHandler) ServeHTTP(rw http.ResponseWriter, r *http.Request) {
 		return
 	}
 
+	if strings.HasPrefix(r.URL.Path, "/api/v1/questions") {
+		ah.QuestionHandler.ServeHTTP(rw, r)
+		return
+	}
+
+	if strings.HasPrefix(r.URL.Path, "/api/v1/answers") {
+		ah.AnswerHandler.ServeHTTP(rw, r)
+		return
+	}
+
+	if strings.HasPrefix(r.URL.Path, articlesPath) {
+		ah.ArticleHandler.ServeHTTP(rw, r)
+		return
+	}
+
+	if strings.HasPrefix(r.URL.Path, topicsPath) {
+		ah.TopicHandler.ServeHTTP(rw, r)
+		return
+	}
+
+	if r.URL.Path == "/api/v1/backup" {
+		ah.BackupHandler.ServeHTTP(rw, r)
+		return
+	}
+
 	notFoundHandler(rw, r)
 }
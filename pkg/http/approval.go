@@ -0,0 +1,249 @@
+package http
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+
+	"github.com/julienschmidt/httprouter"
+	"go.uber.org/zap"
+
+	icontext "github.com/ustackq/indagate/pkg/context"
+	"github.com/ustackq/indagate/pkg/service"
+	"github.com/ustackq/indagate/pkg/utils/errors"
+)
+
+// ApprovalBackend is all services and associated parameters required to construct
+// the ApprovalHandler.
+type ApprovalBackend struct {
+	Logger *zap.Logger
+
+	ApprovalService service.ApprovalService
+}
+
+// NewApprovalBackend returns a new instance of ApprovalBackend.
+func NewApprovalBackend(ab *APIBackend) *ApprovalBackend {
+	return &ApprovalBackend{
+		Logger: ab.Logger.With(zap.String("handler", "approval")),
+
+		ApprovalService: ab.ApprovalService,
+	}
+}
+
+// ApprovalHandler represents an HTTP API handler for reviewing the posts waiting for approval.
+type ApprovalHandler struct {
+	*httprouter.Router
+	Logger *zap.Logger
+
+	ApprovalService service.ApprovalService
+}
+
+const (
+	approvalsPath       = "/api/v1/approvals"
+	approvalsIDPath     = "/api/v1/approvals/:id"
+	approvalApprovePath = "/api/v1/approvals/:id/approve"
+	approvalRejectPath  = "/api/v1/approvals/:id/reject"
+)
+
+// NewApprovalHandler returns a new instance of ApprovalHandler.
+func NewApprovalHandler(ab *ApprovalBackend) *ApprovalHandler {
+	h := &ApprovalHandler{
+		Router: NewRouter(),
+		Logger: ab.Logger,
+
+		ApprovalService: ab.ApprovalService,
+	}
+
+	h.GET(approvalsPath, h.handleGetApprovals)
+	h.GET(approvalsIDPath, h.handleGetApproval)
+	h.POST(approvalApprovePath, h.handleReview(service.ApprovalApproved))
+	h.POST(approvalRejectPath, h.handleReview(service.ApprovalRejected))
+
+	return h
+}
+
+type approvalResponse struct {
+	Links map[string]string `json:"links"`
+	service.Approval
+	// Diff is only set on single approvals.
+	Diff []*service.ApprovalDiff `json:"diff,omitempty"`
+}
+
+func newApprovalResponse(a *service.Approval, diff []*service.ApprovalDiff) *approvalResponse {
+	self := fmt.Sprintf("%s/%s", approvalsPath, a.ID)
+	return &approvalResponse{
+		Links: map[string]string{
+			"self":    self,
+			"item":    fmt.Sprintf("/api/v1/%s/%s", a.ItemType, a.ItemID),
+			"approve": self + "/approve",
+			"reject":  self + "/reject",
+		},
+		Approval: *a,
+		Diff:     diff,
+	}
+}
+
+type approvalsResponse struct {
+	Links     map[string]string   `json:"links"`
+	Approvals []*approvalResponse `json:"approvals"`
+	Total     int                 `json:"total"`
+}
+
+func newApprovalsResponse(opts service.FindOptions, as []*service.Approval, total int) *approvalsResponse {
+	res := &approvalsResponse{
+		Links:     pagingLinks(approvalsPath, opts, len(as), total),
+		Approvals: make([]*approvalResponse, 0, len(as)),
+		Total:     total,
+	}
+
+	for _, a := range as {
+		res.Approvals = append(res.Approvals, newApprovalResponse(a, nil))
+	}
+	return res
+}
+
+type reviewRequest struct {
+	Comment string `json:"comment"`
+}
+
+func decodeApprovalFilter(ctx context.Context, r *http.Request) (*service.ApprovalFilter, error) {
+	query := r.URL.Query()
+	filter := &service.ApprovalFilter{}
+
+	if orgID := query.Get(OrgID); orgID != "" {
+		id, err := service.IDFromString(orgID)
+		if err != nil {
+			return nil, err
+		}
+		filter.OrgID = id
+	}
+
+	if userID := query.Get("userID"); userID != "" {
+		id, err := service.IDFromString(userID)
+		if err != nil {
+			return nil, err
+		}
+		filter.UserID = id
+	}
+
+	if typ := query.Get("type"); typ != "" {
+		t := service.ItemType(typ)
+		if t != service.QuestionItemType && t != service.AnswerItemType && t != service.CommentItemType {
+			return nil, &errors.Error{
+				Code: errors.Invalid,
+				Msg:  fmt.Sprintf("only %s, %s and %s wait for approval", service.QuestionItemType, service.AnswerItemType, service.CommentItemType),
+			}
+		}
+		filter.ItemType = &t
+	}
+
+	if status := query.Get("status"); status != "" {
+		s := service.ApprovalStatus(status)
+		if err := s.Valid(); err != nil {
+			return nil, err
+		}
+		filter.Status = &s
+	}
+	return filter, nil
+}
+
+func (h *ApprovalHandler) handleGetApprovals(rw http.ResponseWriter, r *http.Request, ps httprouter.Params) {
+	ctx := r.Context()
+
+	filter, err := decodeApprovalFilter(ctx, r)
+	if err != nil {
+		EncodeError(ctx, err, rw)
+		return
+	}
+
+	opts, err := decodeFindOptions(ctx, r)
+	if err != nil {
+		EncodeError(ctx, err, rw)
+		return
+	}
+
+	as, total, err := h.ApprovalService.FindApprovals(ctx, *filter, *opts)
+	if err != nil {
+		EncodeError(ctx, err, rw)
+		return
+	}
+
+	if err := encodeResponse(ctx, rw, http.StatusOK, newApprovalsResponse(*opts, as, total)); err != nil {
+		LogEncodeError(h.Logger, r, err)
+		return
+	}
+}
+
+func (h *ApprovalHandler) handleGetApproval(rw http.ResponseWriter, r *http.Request, ps httprouter.Params) {
+	ctx := r.Context()
+
+	req, err := decodeRequest(r, ps)
+	if err != nil {
+		EncodeError(ctx, err, rw)
+		return
+	}
+
+	a, err := h.ApprovalService.FindApprovalByID(ctx, req.ID)
+	if err != nil {
+		EncodeError(ctx, err, rw)
+		return
+	}
+
+	diff, err := h.ApprovalService.FindApprovalDiff(ctx, req.ID)
+	if err != nil {
+		EncodeError(ctx, err, rw)
+		return
+	}
+
+	if err := encodeResponse(ctx, rw, http.StatusOK, newApprovalResponse(a, diff)); err != nil {
+		LogEncodeError(h.Logger, r, err)
+		return
+	}
+}
+
+// handleReview returns the handler approving or rejecting an approval by the decision.
+func (h *ApprovalHandler) handleReview(decision service.ApprovalStatus) httprouter.Handle {
+	return func(rw http.ResponseWriter, r *http.Request, ps httprouter.Params) {
+		ctx := r.Context()
+
+		req, err := decodeRequest(r, ps)
+		if err != nil {
+			EncodeError(ctx, err, rw)
+			return
+		}
+
+		auth, err := icontext.GetAuthorizer(ctx)
+		if err != nil {
+			EncodeError(ctx, err, rw)
+			return
+		}
+
+		body := &reviewRequest{}
+		if r.ContentLength != 0 {
+			if err := json.NewDecoder(r.Body).Decode(body); err != nil {
+				EncodeError(ctx, &errors.Error{
+					Code: errors.Invalid,
+					Msg:  "invalid json structure",
+					Err:  err,
+				}, rw)
+				return
+			}
+		}
+
+		review := &service.ApprovalReview{
+			Decision:   decision,
+			Comment:    body.Comment,
+			ReviewerID: auth.GetUserID(),
+		}
+		a, err := h.ApprovalService.ReviewApproval(ctx, req.ID, review)
+		if err != nil {
+			EncodeError(ctx, err, rw)
+			return
+		}
+
+		if err := encodeResponse(ctx, rw, http.StatusOK, newApprovalResponse(a, nil)); err != nil {
+			LogEncodeError(h.Logger, r, err)
+			return
+		}
+	}
+}
@@ -0,0 +1,402 @@
+package http
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+
+	"github.com/julienschmidt/httprouter"
+	"go.uber.org/zap"
+
+	icontext "github.com/ustackq/indagate/pkg/context"
+	"github.com/ustackq/indagate/pkg/service"
+	"github.com/ustackq/indagate/pkg/utils/errors"
+)
+
+// ArticleBackend is all services and associated parameters required to construct
+// the ArticleHandler.
+type ArticleBackend struct {
+	Logger *zap.Logger
+
+	ArticleService service.ArticleService
+	TopicService   service.TopicService
+}
+
+// NewArticleBackend returns a new instance of ArticleBackend.
+func NewArticleBackend(ab *APIBackend) *ArticleBackend {
+	return &ArticleBackend{
+		Logger: ab.Logger.With(zap.String("handler", "article")),
+
+		ArticleService: ab.ArticleService,
+		TopicService:   ab.TopicService,
+	}
+}
+
+// ArticleHandler represents an HTTP API handler for articles.
+type ArticleHandler struct {
+	*httprouter.Router
+	Logger *zap.Logger
+
+	ArticleService service.ArticleService
+	TopicService   service.TopicService
+}
+
+const (
+	articlesPath          = "/api/v1/articles"
+	articlesIDPath        = "/api/v1/articles/:id"
+	articlesIDPublishPath = "/api/v1/articles/:id/publish"
+	articlesIDArchivePath = "/api/v1/articles/:id/archive"
+	articlesIDTopicsPath  = "/api/v1/articles/:id/topics"
+)
+
+// NewArticleHandler returns a new instance of ArticleHandler.
+func NewArticleHandler(ab *ArticleBackend) *ArticleHandler {
+	ah := &ArticleHandler{
+		Router: NewRouter(),
+		Logger: ab.Logger,
+
+		ArticleService: ab.ArticleService,
+		TopicService:   ab.TopicService,
+	}
+
+	ah.POST(articlesPath, ah.handlePostArticle)
+	ah.GET(articlesPath, ah.handleGetArticles)
+	ah.GET(articlesIDPath, ah.handleGetArticle)
+	ah.PATCH(articlesIDPath, ah.handlePatchArticle)
+	ah.DELETE(articlesIDPath, ah.handleDeleteArticle)
+	ah.PUT(articlesIDPublishPath, ah.handlePublishArticle)
+	ah.PUT(articlesIDArchivePath, ah.handleArchiveArticle)
+	ah.GET(articlesIDTopicsPath, ah.handleGetArticleTopics)
+
+	return ah
+}
+
+type articleResponse struct {
+	Links map[string]string `json:"links"`
+	service.Article
+}
+
+func newArticleResponse(a *service.Article) *articleResponse {
+	return &articleResponse{
+		Links: map[string]string{
+			"self":     fmt.Sprintf("/api/v1/articles/%s", a.ID),
+			"publish":  fmt.Sprintf("/api/v1/articles/%s/publish", a.ID),
+			"archive":  fmt.Sprintf("/api/v1/articles/%s/archive", a.ID),
+			"topics":   fmt.Sprintf("/api/v1/articles/%s/topics", a.ID),
+			"comments": fmt.Sprintf("/api/v1/articles/%s/comments", a.ID),
+			"vote":     fmt.Sprintf("/api/v1/articles/%s/vote", a.ID),
+			"org":      fmt.Sprintf("/api/v1/orgs/%s", a.OrgID),
+		},
+		Article: *a,
+	}
+}
+
+type articlesResponse struct {
+	Links    map[string]string  `json:"links"`
+	Articles []*articleResponse `json:"articles"`
+	Total    int                `json:"total"`
+}
+
+func newArticlesResponse(opts service.FindOptions, as []*service.Article, total int) *articlesResponse {
+	res := &articlesResponse{
+		Links:    pagingLinks(articlesPath, opts, len(as), total),
+		Articles: make([]*articleResponse, 0, len(as)),
+		Total:    total,
+	}
+
+	for _, a := range as {
+		res.Articles = append(res.Articles, newArticleResponse(a))
+	}
+	return res
+}
+
+type postArticleRequest struct {
+	OrgID    service.ID `json:"orgID"`
+	Title    string     `json:"title"`
+	Abstract string     `json:"abstract"`
+	Content  string     `json:"content"`
+}
+
+func decodePostArticleRequest(ctx context.Context, r *http.Request) (*service.Article, error) {
+	req := &postArticleRequest{}
+	if err := json.NewDecoder(r.Body).Decode(req); err != nil {
+		return nil, &errors.Error{
+			Code: errors.Invalid,
+			Msg:  "invalid json structure",
+			Err:  err,
+		}
+	}
+
+	auth, err := icontext.GetAuthorizer(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	a := &service.Article{
+		OrgID:    req.OrgID,
+		UserID:   auth.GetUserID(),
+		Title:    req.Title,
+		Abstract: req.Abstract,
+		Content:  req.Content,
+	}
+	return a, a.Valid()
+}
+
+func (ah *ArticleHandler) handlePostArticle(rw http.ResponseWriter, r *http.Request, ps httprouter.Params) {
+	ctx := r.Context()
+
+	a, err := decodePostArticleRequest(ctx, r)
+	if err != nil {
+		EncodeError(ctx, err, rw)
+		return
+	}
+
+	if err := ah.ArticleService.CreateArticle(ctx, a); err != nil {
+		EncodeError(ctx, err, rw)
+		return
+	}
+
+	if err := encodeResponse(ctx, rw, http.StatusCreated, newArticleResponse(a)); err != nil {
+		LogEncodeError(ah.Logger, r, err)
+		return
+	}
+}
+
+type getArticlesRequest struct {
+	filter service.ArticleFilter
+	opts   service.FindOptions
+}
+
+func decodeGetArticlesRequest(ctx context.Context, r *http.Request) (*getArticlesRequest, error) {
+	query := r.URL.Query()
+	req := &getArticlesRequest{}
+
+	if orgID := query.Get(OrgID); orgID != "" {
+		id, err := service.IDFromString(orgID)
+		if err != nil {
+			return nil, err
+		}
+		req.filter.OrgID = id
+	}
+
+	if userID := query.Get("userID"); userID != "" {
+		id, err := service.IDFromString(userID)
+		if err != nil {
+			return nil, err
+		}
+		req.filter.UserID = id
+	}
+
+	if state := query.Get("state"); state != "" {
+		st := service.ArticleState(state)
+		if err := st.Valid(); err != nil {
+			return nil, err
+		}
+		req.filter.State = &st
+	}
+
+	opts, err := decodeFindOptions(ctx, r)
+	if err != nil {
+		return nil, err
+	}
+	req.opts = *opts
+
+	return req, nil
+}
+
+func (ah *ArticleHandler) handleGetArticles(rw http.ResponseWriter, r *http.Request, ps httprouter.Params) {
+	ctx := r.Context()
+
+	req, err := decodeGetArticlesRequest(ctx, r)
+	if err != nil {
+		EncodeError(ctx, err, rw)
+		return
+	}
+
+	as, total, err := ah.ArticleService.FindArticles(ctx, req.filter, req.opts)
+	if err != nil {
+		EncodeError(ctx, err, rw)
+		return
+	}
+
+	if err := encodeResponse(ctx, rw, http.StatusOK, newArticlesResponse(req.opts, as, total)); err != nil {
+		LogEncodeError(ah.Logger, r, err)
+		return
+	}
+}
+
+// handleGetArticle returns the article and counts the view of the requesting session.
+func (ah *ArticleHandler) handleGetArticle(rw http.ResponseWriter, r *http.Request, ps httprouter.Params) {
+	ctx := r.Context()
+
+	req, err := decodeRequest(r, ps)
+	if err != nil {
+		EncodeError(ctx, err, rw)
+		return
+	}
+
+	auth, err := icontext.GetAuthorizer(ctx)
+	if err != nil {
+		EncodeError(ctx, err, rw)
+		return
+	}
+
+	a, err := ah.ArticleService.ViewArticle(ctx, req.ID, auth.Identifier())
+	if err != nil {
+		EncodeError(ctx, err, rw)
+		return
+	}
+
+	if err := encodeResponse(ctx, rw, http.StatusOK, newArticleResponse(a)); err != nil {
+		LogEncodeError(ah.Logger, r, err)
+		return
+	}
+}
+
+type patchArticleRequest struct {
+	ID     service.ID
+	Update service.ArticleUpdate
+}
+
+func decodePatchArticleRequest(r *http.Request, ps httprouter.Params) (*patchArticleRequest, error) {
+	req, err := decodeRequest(r, ps)
+	if err != nil {
+		return nil, err
+	}
+
+	var upd service.ArticleUpdate
+	if err := json.NewDecoder(r.Body).Decode(&upd); err != nil {
+		return nil, &errors.Error{
+			Code: errors.Invalid,
+			Msg:  "invalid json structure",
+			Err:  err,
+		}
+	}
+
+	return &patchArticleRequest{
+		ID:     req.ID,
+		Update: upd,
+	}, upd.Valid()
+}
+
+func (ah *ArticleHandler) handlePatchArticle(rw http.ResponseWriter, r *http.Request, ps httprouter.Params) {
+	ctx := r.Context()
+
+	req, err := decodePatchArticleRequest(r, ps)
+	if err != nil {
+		EncodeError(ctx, err, rw)
+		return
+	}
+
+	a, err := ah.ArticleService.UpdateArticle(ctx, req.ID, req.Update)
+	if err != nil {
+		EncodeError(ctx, err, rw)
+		return
+	}
+
+	if err := encodeResponse(ctx, rw, http.StatusOK, newArticleResponse(a)); err != nil {
+		LogEncodeError(ah.Logger, r, err)
+		return
+	}
+}
+
+func (ah *ArticleHandler) handleDeleteArticle(rw http.ResponseWriter, r *http.Request, ps httprouter.Params) {
+	ctx := r.Context()
+
+	req, err := decodeRequest(r, ps)
+	if err != nil {
+		EncodeError(ctx, err, rw)
+		return
+	}
+
+	if err := ah.ArticleService.DeleteArticle(ctx, req.ID); err != nil {
+		EncodeError(ctx, err, rw)
+		return
+	}
+
+	rw.WriteHeader(http.StatusNoContent)
+}
+
+func (ah *ArticleHandler) handlePublishArticle(rw http.ResponseWriter, r *http.Request, ps httprouter.Params) {
+	ctx := r.Context()
+
+	req, err := decodeRequest(r, ps)
+	if err != nil {
+		EncodeError(ctx, err, rw)
+		return
+	}
+
+	a, err := ah.ArticleService.PublishArticle(ctx, req.ID)
+	if err != nil {
+		EncodeError(ctx, err, rw)
+		return
+	}
+
+	if err := encodeResponse(ctx, rw, http.StatusOK, newArticleResponse(a)); err != nil {
+		LogEncodeError(ah.Logger, r, err)
+		return
+	}
+}
+
+func (ah *ArticleHandler) handleArchiveArticle(rw http.ResponseWriter, r *http.Request, ps httprouter.Params) {
+	ctx := r.Context()
+
+	req, err := decodeRequest(r, ps)
+	if err != nil {
+		EncodeError(ctx, err, rw)
+		return
+	}
+
+	a, err := ah.ArticleService.ArchiveArticle(ctx, req.ID)
+	if err != nil {
+		EncodeError(ctx, err, rw)
+		return
+	}
+
+	if err := encodeResponse(ctx, rw, http.StatusOK, newArticleResponse(a)); err != nil {
+		LogEncodeError(ah.Logger, r, err)
+		return
+	}
+}
+
+// handleGetArticleTopics returns the topics the article is tagged with.
+func (ah *ArticleHandler) handleGetArticleTopics(rw http.ResponseWriter, r *http.Request, ps httprouter.Params) {
+	ctx := r.Context()
+
+	req, err := decodeRequest(r, ps)
+	if err != nil {
+		EncodeError(ctx, err, rw)
+		return
+	}
+
+	if _, err := ah.ArticleService.FindArticleByID(ctx, req.ID); err != nil {
+		EncodeError(ctx, err, rw)
+		return
+	}
+
+	itemType := service.ArticleItemType
+	rs, _, err := ah.TopicService.FindTopicRelations(ctx, service.TopicRelationFilter{
+		ItemType: &itemType,
+		ItemID:   &req.ID,
+	})
+	if err != nil {
+		EncodeError(ctx, err, rw)
+		return
+	}
+
+	ts := make([]*service.Topic, 0, len(rs))
+	for _, rel := range rs {
+		t, err := ah.TopicService.FindTopicByID(ctx, rel.TopicID)
+		if err != nil {
+			EncodeError(ctx, err, rw)
+			return
+		}
+		ts = append(ts, t)
+	}
+
+	basePath := fmt.Sprintf("/api/v1/articles/%s/topics", req.ID)
+	if err := encodeResponse(ctx, rw, http.StatusOK, newTopicsResponse(basePath, service.FindOptions{}, ts, len(ts))); err != nil {
+		LogEncodeError(ah.Logger, r, err)
+		return
+	}
+}
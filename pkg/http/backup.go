@@ -0,0 +1,106 @@
+package http
+
+import (
+	"net/http"
+
+	"github.com/julienschmidt/httprouter"
+	"go.uber.org/zap"
+
+	"github.com/ustackq/indagate/pkg/service"
+	"github.com/ustackq/indagate/pkg/utils/errors"
+)
+
+// BackupManifestTrailer is the trailer carrying the JSON manifest of the snapshot
+// streamed by GET /api/v1/backup.
+const BackupManifestTrailer = "Indagate-Backup-Manifest"
+
+// BackupBackend is all services and associated parameters required to construct
+// the BackupHandler.
+type BackupBackend struct {
+	Logger *zap.Logger
+
+	BackupService service.BackupService
+}
+
+// NewBackupBackend returns a new instance of BackupBackend.
+func NewBackupBackend(ab *APIBackend) *BackupBackend {
+	return &BackupBackend{
+		Logger: ab.Logger.With(zap.String("handler", "backup")),
+
+		BackupService: ab.BackupService,
+	}
+}
+
+// BackupHandler represents an HTTP API handler for backups.
+type BackupHandler struct {
+	*httprouter.Router
+	Logger *zap.Logger
+
+	BackupService service.BackupService
+}
+
+const (
+	backupPath = "/api/v1/backup"
+)
+
+// NewBackupHandler returns a new instance of BackupHandler.
+func NewBackupHandler(bb *BackupBackend) *BackupHandler {
+	bh := &BackupHandler{
+		Router: NewRouter(),
+		Logger: bb.Logger,
+
+		BackupService: bb.BackupService,
+	}
+
+	bh.GET(backupPath, bh.handleGetBackup)
+
+	return bh
+}
+
+// handleGetBackup is the HTTP handler for the GET /api/v1/backup route,
+// the manifest is sent in a trailer once the snapshot is written.
+func (h *BackupHandler) handleGetBackup(rw http.ResponseWriter, r *http.Request, ps httprouter.Params) {
+	ctx := r.Context()
+	if h.BackupService == nil {
+		EncodeError(ctx, &errors.Error{
+			Code: errors.MethodNotAllowed,
+			Msg:  "backups are only supported by the bolt store",
+		}, rw)
+		return
+	}
+
+	rw.Header().Set("Content-Type", "application/octet-stream")
+	rw.Header().Set("Content-Disposition", `attachment; filename="indagate.bolt"`)
+	rw.Header().Set("Trailer", BackupManifestTrailer)
+
+	w := &writeTracker{ResponseWriter: rw}
+	m, err := h.BackupService.Backup(ctx, w)
+	if err != nil {
+		if !w.written {
+			EncodeError(ctx, err, rw)
+			return
+		}
+		// the status is gone, a missing manifest fails the client.
+		h.Logger.Error("failed to stream backup", zap.Error(err))
+		return
+	}
+
+	b, err := json.Marshal(m)
+	if err != nil {
+		h.Logger.Error("failed to encode backup manifest", zap.Error(err))
+		return
+	}
+	rw.Header().Set(BackupManifestTrailer, string(b))
+	h.Logger.Debug("backup streamed", zap.Int64("size", m.Size))
+}
+
+// writeTracker records whether the response has been started.
+type writeTracker struct {
+	http.ResponseWriter
+	written bool
+}
+
+func (w *writeTracker) Write(b []byte) (int, error) {
+	w.written = true
+	return w.ResponseWriter.Write(b)
+}
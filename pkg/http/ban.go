@@ -0,0 +1,435 @@
+package http
+
+import (
+	"fmt"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/julienschmidt/httprouter"
+	"go.uber.org/zap"
+
+	icontext "github.com/ustackq/indagate/pkg/context"
+	"github.com/ustackq/indagate/pkg/service"
+	"github.com/ustackq/indagate/pkg/utils/errors"
+)
+
+// BanBackend is all services and associated parameters required to construct
+// the BanHandler.
+type BanBackend struct {
+	Logger *zap.Logger
+
+	BanService service.BanService
+}
+
+// NewBanBackend returns a new instance of BanBackend.
+func NewBanBackend(ab *APIBackend) *BanBackend {
+	return &BanBackend{
+		Logger: ab.Logger.With(zap.String("handler", "ban")),
+
+		BanService: ab.BanService,
+	}
+}
+
+// BanHandler represents an HTTP API handler for the admins managing bans and IP blocks.
+type BanHandler struct {
+	*httprouter.Router
+	Logger *zap.Logger
+
+	BanService service.BanService
+}
+
+const (
+	bansPath       = "/api/v1/bans"
+	bansIDPath     = "/api/v1/bans/:id"
+	banLiftPath    = "/api/v1/bans/:id/lift"
+	ipBlocksPath   = "/api/v1/ipblocks"
+	ipBlocksIDPath = "/api/v1/ipblocks/:id"
+	banAuditsPath  = "/api/v1/banaudits"
+)
+
+// NewBanHandler returns a new instance of BanHandler.
+func NewBanHandler(bb *BanBackend) *BanHandler {
+	h := &BanHandler{
+		Router: NewRouter(),
+		Logger: bb.Logger,
+
+		BanService: bb.BanService,
+	}
+
+	h.GET(bansPath, h.handleGetBans)
+	h.POST(bansPath, h.handlePostBan)
+	h.GET(bansIDPath, h.handleGetBan)
+	h.POST(banLiftPath, h.handleLiftBan)
+	h.GET(ipBlocksPath, h.handleGetIPBlocks)
+	h.POST(ipBlocksPath, h.handlePostIPBlock)
+	h.DELETE(ipBlocksIDPath, h.handleDeleteIPBlock)
+	h.GET(banAuditsPath, h.handleGetBanAudits)
+
+	return h
+}
+
+type banResponse struct {
+	Links map[string]string `json:"links"`
+	service.Ban
+}
+
+func newBanResponse(b *service.Ban) *banResponse {
+	self := fmt.Sprintf("%s/%s", bansPath, b.ID)
+	return &banResponse{
+		Links: map[string]string{
+			"self": self,
+			"user": fmt.Sprintf("/api/v1/users/%s", b.UserID),
+			"lift": self + "/lift",
+		},
+		Ban: *b,
+	}
+}
+
+type bansResponse struct {
+	Links map[string]string `json:"links"`
+	Bans  []*banResponse    `json:"bans"`
+	Total int               `json:"total"`
+}
+
+func newBansResponse(opts service.FindOptions, bs []*service.Ban, total int) *bansResponse {
+	res := &bansResponse{
+		Links: pagingLinks(bansPath, opts, len(bs), total),
+		Bans:  make([]*banResponse, 0, len(bs)),
+		Total: total,
+	}
+
+	for _, b := range bs {
+		res.Bans = append(res.Bans, newBanResponse(b))
+	}
+	return res
+}
+
+type ipBlockResponse struct {
+	Links map[string]string `json:"links"`
+	service.IPBlock
+}
+
+func newIPBlockResponse(b *service.IPBlock) *ipBlockResponse {
+	return &ipBlockResponse{
+		Links: map[string]string{
+			"self": fmt.Sprintf("%s/%s", ipBlocksPath, b.ID),
+		},
+		IPBlock: *b,
+	}
+}
+
+type ipBlocksResponse struct {
+	Links    map[string]string  `json:"links"`
+	IPBlocks []*ipBlockResponse `json:"ipBlocks"`
+	Total    int                `json:"total"`
+}
+
+func newIPBlocksResponse(opts service.FindOptions, bs []*service.IPBlock, total int) *ipBlocksResponse {
+	res := &ipBlocksResponse{
+		Links:    pagingLinks(ipBlocksPath, opts, len(bs), total),
+		IPBlocks: make([]*ipBlockResponse, 0, len(bs)),
+		Total:    total,
+	}
+
+	for _, b := range bs {
+		res.IPBlocks = append(res.IPBlocks, newIPBlockResponse(b))
+	}
+	return res
+}
+
+type banAuditsResponse struct {
+	Links  map[string]string   `json:"links"`
+	Audits []*service.BanAudit `json:"audits"`
+	Total  int                 `json:"total"`
+}
+
+// postBanRequest bans a user for good unless ExpiresAt is set.
+type postBanRequest struct {
+	UserID    service.ID `json:"userID"`
+	Reason    string     `json:"reason"`
+	ExpiresAt *time.Time `json:"expiresAt"`
+}
+
+type postIPBlockRequest struct {
+	CIDR      string               `json:"cidr"`
+	Scope     service.IPBlockScope `json:"scope"`
+	Reason    string               `json:"reason"`
+	ExpiresAt *time.Time           `json:"expiresAt"`
+}
+
+func decodeBanFilter(r *http.Request) (*service.BanFilter, error) {
+	query := r.URL.Query()
+	filter := &service.BanFilter{}
+
+	if userID := query.Get("userID"); userID != "" {
+		id, err := service.IDFromString(userID)
+		if err != nil {
+			return nil, err
+		}
+		filter.UserID = id
+	}
+
+	if active := query.Get("active"); active != "" {
+		a, err := strconv.ParseBool(active)
+		if err != nil {
+			return nil, &errors.Error{
+				Code: errors.Invalid,
+				Msg:  "active must be true or false",
+				Err:  err,
+			}
+		}
+		filter.Active = &a
+	}
+	return filter, nil
+}
+
+func (h *BanHandler) handleGetBans(rw http.ResponseWriter, r *http.Request, ps httprouter.Params) {
+	ctx := r.Context()
+
+	filter, err := decodeBanFilter(r)
+	if err != nil {
+		EncodeError(ctx, err, rw)
+		return
+	}
+
+	opts, err := decodeFindOptions(ctx, r)
+	if err != nil {
+		EncodeError(ctx, err, rw)
+		return
+	}
+
+	bs, total, err := h.BanService.FindBans(ctx, *filter, *opts)
+	if err != nil {
+		EncodeError(ctx, err, rw)
+		return
+	}
+
+	if err := encodeResponse(ctx, rw, http.StatusOK, newBansResponse(*opts, bs, total)); err != nil {
+		LogEncodeError(h.Logger, r, err)
+		return
+	}
+}
+
+func (h *BanHandler) handlePostBan(rw http.ResponseWriter, r *http.Request, ps httprouter.Params) {
+	ctx := r.Context()
+
+	auth, err := icontext.GetAuthorizer(ctx)
+	if err != nil {
+		EncodeError(ctx, err, rw)
+		return
+	}
+
+	req := &postBanRequest{}
+	if err := json.NewDecoder(r.Body).Decode(req); err != nil {
+		EncodeError(ctx, &errors.Error{
+			Code: errors.Invalid,
+			Msg:  "invalid json structure",
+			Err:  err,
+		}, rw)
+		return
+	}
+
+	b := &service.Ban{
+		UserID:    req.UserID,
+		Reason:    req.Reason,
+		ExpiresAt: req.ExpiresAt,
+		CreatedBy: auth.GetUserID(),
+	}
+	if err := h.BanService.BanUser(ctx, b); err != nil {
+		EncodeError(ctx, err, rw)
+		return
+	}
+
+	if err := encodeResponse(ctx, rw, http.StatusCreated, newBanResponse(b)); err != nil {
+		LogEncodeError(h.Logger, r, err)
+		return
+	}
+}
+
+func (h *BanHandler) handleGetBan(rw http.ResponseWriter, r *http.Request, ps httprouter.Params) {
+	ctx := r.Context()
+
+	req, err := decodeRequest(r, ps)
+	if err != nil {
+		EncodeError(ctx, err, rw)
+		return
+	}
+
+	b, err := h.BanService.FindBanByID(ctx, req.ID)
+	if err != nil {
+		EncodeError(ctx, err, rw)
+		return
+	}
+
+	if err := encodeResponse(ctx, rw, http.StatusOK, newBanResponse(b)); err != nil {
+		LogEncodeError(h.Logger, r, err)
+		return
+	}
+}
+
+func (h *BanHandler) handleLiftBan(rw http.ResponseWriter, r *http.Request, ps httprouter.Params) {
+	ctx := r.Context()
+
+	req, err := decodeRequest(r, ps)
+	if err != nil {
+		EncodeError(ctx, err, rw)
+		return
+	}
+
+	auth, err := icontext.GetAuthorizer(ctx)
+	if err != nil {
+		EncodeError(ctx, err, rw)
+		return
+	}
+
+	b, err := h.BanService.LiftBan(ctx, req.ID, auth.GetUserID())
+	if err != nil {
+		EncodeError(ctx, err, rw)
+		return
+	}
+
+	if err := encodeResponse(ctx, rw, http.StatusOK, newBanResponse(b)); err != nil {
+		LogEncodeError(h.Logger, r, err)
+		return
+	}
+}
+
+func (h *BanHandler) handleGetIPBlocks(rw http.ResponseWriter, r *http.Request, ps httprouter.Params) {
+	ctx := r.Context()
+
+	filter := service.IPBlockFilter{}
+	if scope := r.URL.Query().Get("scope"); scope != "" {
+		s := service.IPBlockScope(scope)
+		if err := s.Valid(); err != nil {
+			EncodeError(ctx, err, rw)
+			return
+		}
+		filter.Scope = &s
+	}
+
+	opts, err := decodeFindOptions(ctx, r)
+	if err != nil {
+		EncodeError(ctx, err, rw)
+		return
+	}
+
+	bs, total, err := h.BanService.FindIPBlocks(ctx, filter, *opts)
+	if err != nil {
+		EncodeError(ctx, err, rw)
+		return
+	}
+
+	if err := encodeResponse(ctx, rw, http.StatusOK, newIPBlocksResponse(*opts, bs, total)); err != nil {
+		LogEncodeError(h.Logger, r, err)
+		return
+	}
+}
+
+func (h *BanHandler) handlePostIPBlock(rw http.ResponseWriter, r *http.Request, ps httprouter.Params) {
+	ctx := r.Context()
+
+	auth, err := icontext.GetAuthorizer(ctx)
+	if err != nil {
+		EncodeError(ctx, err, rw)
+		return
+	}
+
+	req := &postIPBlockRequest{}
+	if err := json.NewDecoder(r.Body).Decode(req); err != nil {
+		EncodeError(ctx, &errors.Error{
+			Code: errors.Invalid,
+			Msg:  "invalid json structure",
+			Err:  err,
+		}, rw)
+		return
+	}
+
+	if req.Scope == "" {
+		req.Scope = service.AccessIPBlock
+	}
+	b := &service.IPBlock{
+		CIDR:      req.CIDR,
+		Scope:     req.Scope,
+		Reason:    req.Reason,
+		ExpiresAt: req.ExpiresAt,
+		CreatedBy: auth.GetUserID(),
+	}
+	if err := h.BanService.CreateIPBlock(ctx, b); err != nil {
+		EncodeError(ctx, err, rw)
+		return
+	}
+
+	if err := encodeResponse(ctx, rw, http.StatusCreated, newIPBlockResponse(b)); err != nil {
+		LogEncodeError(h.Logger, r, err)
+		return
+	}
+}
+
+func (h *BanHandler) handleDeleteIPBlock(rw http.ResponseWriter, r *http.Request, ps httprouter.Params) {
+	ctx := r.Context()
+
+	req, err := decodeRequest(r, ps)
+	if err != nil {
+		EncodeError(ctx, err, rw)
+		return
+	}
+
+	auth, err := icontext.GetAuthorizer(ctx)
+	if err != nil {
+		EncodeError(ctx, err, rw)
+		return
+	}
+
+	if err := h.BanService.DeleteIPBlock(ctx, req.ID, auth.GetUserID()); err != nil {
+		EncodeError(ctx, err, rw)
+		return
+	}
+
+	rw.WriteHeader(http.StatusNoContent)
+}
+
+func (h *BanHandler) handleGetBanAudits(rw http.ResponseWriter, r *http.Request, ps httprouter.Params) {
+	ctx := r.Context()
+	query := r.URL.Query()
+
+	filter := service.BanAuditFilter{}
+	if userID := query.Get("userID"); userID != "" {
+		id, err := service.IDFromString(userID)
+		if err != nil {
+			EncodeError(ctx, err, rw)
+			return
+		}
+		filter.UserID = id
+	}
+	if actorID := query.Get("actorID"); actorID != "" {
+		id, err := service.IDFromString(actorID)
+		if err != nil {
+			EncodeError(ctx, err, rw)
+			return
+		}
+		filter.ActorID = id
+	}
+
+	opts, err := decodeFindOptions(ctx, r)
+	if err != nil {
+		EncodeError(ctx, err, rw)
+		return
+	}
+
+	as, total, err := h.BanService.FindBanAudits(ctx, filter, *opts)
+	if err != nil {
+		EncodeError(ctx, err, rw)
+		return
+	}
+
+	res := &banAuditsResponse{
+		Links:  pagingLinks(banAuditsPath, *opts, len(as), total),
+		Audits: as,
+		Total:  total,
+	}
+	if err := encodeResponse(ctx, rw, http.StatusOK, res); err != nil {
+		LogEncodeError(h.Logger, r, err)
+		return
+	}
+}
@@ -0,0 +1,355 @@
+package http
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"strings"
+
+	"github.com/julienschmidt/httprouter"
+	"go.uber.org/zap"
+
+	icontext "github.com/ustackq/indagate/pkg/context"
+	"github.com/ustackq/indagate/pkg/service"
+	"github.com/ustackq/indagate/pkg/utils/errors"
+)
+
+// CommentBackend is all services and associated parameters required to construct
+// the CommentHandler.
+type CommentBackend struct {
+	Logger *zap.Logger
+
+	CommentService service.CommentService
+}
+
+// NewCommentBackend returns a new instance of CommentBackend.
+func NewCommentBackend(ab *APIBackend) *CommentBackend {
+	return &CommentBackend{
+		Logger: ab.Logger.With(zap.String("handler", "comment")),
+
+		CommentService: ab.CommentService,
+	}
+}
+
+// CommentHandler represents an HTTP API handler for comments and the comments
+// of all commentable item types.
+type CommentHandler struct {
+	*httprouter.Router
+	Logger *zap.Logger
+
+	CommentService service.CommentService
+}
+
+const (
+	commentsPrefix = "/api/v1/comments"
+	// commentsSuffix is the last element of the comment routes of the item types.
+	commentsSuffix = "/comments"
+
+	commentsIDPath        = "/api/v1/comments/:id"
+	commentsIDRepliesPath = "/api/v1/comments/:id/replies"
+)
+
+// NewCommentHandler returns a new instance of CommentHandler.
+func NewCommentHandler(cb *CommentBackend) *CommentHandler {
+	ch := &CommentHandler{
+		Router: NewRouter(),
+		Logger: cb.Logger,
+
+		CommentService: cb.CommentService,
+	}
+
+	for _, t := range service.AllCommentTypes {
+		path := fmt.Sprintf("/api/v1/%s/:id%s", t, commentsSuffix)
+		ch.GET(path, ch.handleGetItemComments)
+		ch.POST(path, ch.handlePostItemComment)
+	}
+
+	ch.GET(commentsIDPath, ch.handleGetComment)
+	ch.PATCH(commentsIDPath, ch.handlePatchComment)
+	ch.DELETE(commentsIDPath, ch.handleDeleteComment)
+	ch.GET(commentsIDRepliesPath, ch.handleGetCommentReplies)
+
+	return ch
+}
+
+type commentResponse struct {
+	Links map[string]string `json:"links"`
+	service.Comment
+}
+
+func newCommentResponse(c *service.Comment) *commentResponse {
+	res := &commentResponse{
+		Links: map[string]string{
+			"self":    fmt.Sprintf("/api/v1/comments/%s", c.ID),
+			"item":    fmt.Sprintf("/api/v1/%s/%s", c.Type, c.ItemID),
+			"replies": fmt.Sprintf("/api/v1/comments/%s/replies", c.ID),
+			"vote":    fmt.Sprintf("/api/v1/comments/%s/vote", c.ID),
+		},
+		Comment: *c,
+	}
+
+	if c.ReplyTo.Valid() {
+		res.Links["replyTo"] = fmt.Sprintf("/api/v1/comments/%s", c.ReplyTo)
+	}
+	return res
+}
+
+type commentsResponse struct {
+	Links    map[string]string  `json:"links"`
+	Comments []*commentResponse `json:"comments"`
+	Total    int                `json:"total"`
+}
+
+func newCommentsResponse(basePath string, opts service.FindOptions, cs []*service.Comment, total int) *commentsResponse {
+	res := &commentsResponse{
+		Links:    pagingLinks(basePath, opts, len(cs), total),
+		Comments: make([]*commentResponse, 0, len(cs)),
+		Total:    total,
+	}
+
+	for _, c := range cs {
+		res.Comments = append(res.Comments, newCommentResponse(c))
+	}
+	return res
+}
+
+// decodeCommentType reads the comment type from the path, /api/v1/:itemType/:id/comments.
+func decodeCommentType(r *http.Request) (service.CommentType, error) {
+	parts := strings.Split(strings.TrimPrefix(r.URL.Path, "/api/v1/"), "/")
+	t := service.CommentType(parts[0])
+	return t, t.Valid()
+}
+
+// decodeCommentFindOptions returns the find options of a comment listing,
+// comments are sorted by creation time unless asked otherwise.
+func decodeCommentFindOptions(ctx context.Context, r *http.Request) (*service.FindOptions, error) {
+	opts, err := decodeFindOptions(ctx, r)
+	if err != nil {
+		return nil, err
+	}
+
+	switch opts.SortBy {
+	case "":
+		opts.SortBy = service.CommentSortByTime
+	case service.CommentSortByTime, service.CommentSortByVotes:
+	default:
+		return nil, &errors.Error{
+			Code: errors.Invalid,
+			Msg:  fmt.Sprintf("comments can only be sorted by %s or %s", service.CommentSortByTime, service.CommentSortByVotes),
+		}
+	}
+	return opts, nil
+}
+
+type postCommentRequest struct {
+	Content string     `json:"content"`
+	ReplyTo service.ID `json:"replyTo"`
+	AtUID   service.ID `json:"atUID"`
+}
+
+func decodePostCommentRequest(ctx context.Context, r *http.Request, ps httprouter.Params) (*service.Comment, error) {
+	ir, err := decodeRequest(r, ps)
+	if err != nil {
+		return nil, err
+	}
+
+	t, err := decodeCommentType(r)
+	if err != nil {
+		return nil, err
+	}
+
+	req := &postCommentRequest{}
+	if err := json.NewDecoder(r.Body).Decode(req); err != nil {
+		return nil, &errors.Error{
+			Code: errors.Invalid,
+			Msg:  "invalid json structure",
+			Err:  err,
+		}
+	}
+
+	auth, err := icontext.GetAuthorizer(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	c := &service.Comment{
+		Type:    t,
+		ItemID:  ir.ID,
+		UserID:  auth.GetUserID(),
+		ReplyTo: req.ReplyTo,
+		AtUID:   req.AtUID,
+		Content: req.Content,
+	}
+	return c, c.Valid()
+}
+
+func (ch *CommentHandler) handlePostItemComment(rw http.ResponseWriter, r *http.Request, ps httprouter.Params) {
+	ctx := r.Context()
+
+	c, err := decodePostCommentRequest(ctx, r, ps)
+	if err != nil {
+		EncodeError(ctx, err, rw)
+		return
+	}
+
+	if err := ch.CommentService.CreateComment(ctx, c); err != nil {
+		EncodeError(ctx, err, rw)
+		return
+	}
+
+	if err := encodeResponse(ctx, rw, http.StatusCreated, newCommentResponse(c)); err != nil {
+		LogEncodeError(ch.Logger, r, err)
+		return
+	}
+}
+
+func (ch *CommentHandler) handleGetItemComments(rw http.ResponseWriter, r *http.Request, ps httprouter.Params) {
+	ctx := r.Context()
+
+	req, err := decodeRequest(r, ps)
+	if err != nil {
+		EncodeError(ctx, err, rw)
+		return
+	}
+
+	t, err := decodeCommentType(r)
+	if err != nil {
+		EncodeError(ctx, err, rw)
+		return
+	}
+
+	opts, err := decodeCommentFindOptions(ctx, r)
+	if err != nil {
+		EncodeError(ctx, err, rw)
+		return
+	}
+
+	filter := service.CommentFilter{
+		Type:   &t,
+		ItemID: &req.ID,
+	}
+	cs, total, err := ch.CommentService.FindComments(ctx, filter, *opts)
+	if err != nil {
+		EncodeError(ctx, err, rw)
+		return
+	}
+
+	basePath := fmt.Sprintf("/api/v1/%s/%s/comments", t, req.ID)
+	if err := encodeResponse(ctx, rw, http.StatusOK, newCommentsResponse(basePath, *opts, cs, total)); err != nil {
+		LogEncodeError(ch.Logger, r, err)
+		return
+	}
+}
+
+func (ch *CommentHandler) handleGetCommentReplies(rw http.ResponseWriter, r *http.Request, ps httprouter.Params) {
+	ctx := r.Context()
+
+	req, err := decodeRequest(r, ps)
+	if err != nil {
+		EncodeError(ctx, err, rw)
+		return
+	}
+
+	opts, err := decodeCommentFindOptions(ctx, r)
+	if err != nil {
+		EncodeError(ctx, err, rw)
+		return
+	}
+
+	c, err := ch.CommentService.FindCommentByID(ctx, req.ID)
+	if err != nil {
+		EncodeError(ctx, err, rw)
+		return
+	}
+
+	filter := service.CommentFilter{
+		Type:    &c.Type,
+		ItemID:  &c.ItemID,
+		ReplyTo: &c.ID,
+	}
+	cs, total, err := ch.CommentService.FindComments(ctx, filter, *opts)
+	if err != nil {
+		EncodeError(ctx, err, rw)
+		return
+	}
+
+	basePath := fmt.Sprintf("/api/v1/comments/%s/replies", c.ID)
+	if err := encodeResponse(ctx, rw, http.StatusOK, newCommentsResponse(basePath, *opts, cs, total)); err != nil {
+		LogEncodeError(ch.Logger, r, err)
+		return
+	}
+}
+
+func (ch *CommentHandler) handleGetComment(rw http.ResponseWriter, r *http.Request, ps httprouter.Params) {
+	ctx := r.Context()
+
+	req, err := decodeRequest(r, ps)
+	if err != nil {
+		EncodeError(ctx, err, rw)
+		return
+	}
+
+	c, err := ch.CommentService.FindCommentByID(ctx, req.ID)
+	if err != nil {
+		EncodeError(ctx, err, rw)
+		return
+	}
+
+	if err := encodeResponse(ctx, rw, http.StatusOK, newCommentResponse(c)); err != nil {
+		LogEncodeError(ch.Logger, r, err)
+		return
+	}
+}
+
+func (ch *CommentHandler) handlePatchComment(rw http.ResponseWriter, r *http.Request, ps httprouter.Params) {
+	ctx := r.Context()
+
+	req, err := decodeRequest(r, ps)
+	if err != nil {
+		EncodeError(ctx, err, rw)
+		return
+	}
+
+	var upd service.CommentUpdate
+	if err := json.NewDecoder(r.Body).Decode(&upd); err != nil {
+		EncodeError(ctx, &errors.Error{
+			Code: errors.Invalid,
+			Msg:  "invalid json structure",
+			Err:  err,
+		}, rw)
+		return
+	}
+
+	if err := upd.Valid(); err != nil {
+		EncodeError(ctx, err, rw)
+		return
+	}
+
+	c, err := ch.CommentService.UpdateComment(ctx, req.ID, upd)
+	if err != nil {
+		EncodeError(ctx, err, rw)
+		return
+	}
+
+	if err := encodeResponse(ctx, rw, http.StatusOK, newCommentResponse(c)); err != nil {
+		LogEncodeError(ch.Logger, r, err)
+		return
+	}
+}
+
+func (ch *CommentHandler) handleDeleteComment(rw http.ResponseWriter, r *http.Request, ps httprouter.Params) {
+	ctx := r.Context()
+
+	req, err := decodeRequest(r, ps)
+	if err != nil {
+		EncodeError(ctx, err, rw)
+		return
+	}
+
+	if err := ch.CommentService.DeleteComment(ctx, req.ID); err != nil {
+		EncodeError(ctx, err, rw)
+		return
+	}
+
+	rw.WriteHeader(http.StatusNoContent)
+}
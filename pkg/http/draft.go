@@ -0,0 +1,247 @@
+package http
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+
+	"github.com/julienschmidt/httprouter"
+	"go.uber.org/zap"
+
+	icontext "github.com/ustackq/indagate/pkg/context"
+	"github.com/ustackq/indagate/pkg/service"
+	"github.com/ustackq/indagate/pkg/utils/errors"
+)
+
+// DraftBackend is all services and associated parameters required to construct
+// the DraftHandler.
+type DraftBackend struct {
+	Logger *zap.Logger
+
+	DraftService service.DraftService
+}
+
+// NewDraftBackend returns a new instance of DraftBackend.
+func NewDraftBackend(ab *APIBackend) *DraftBackend {
+	return &DraftBackend{
+		Logger: ab.Logger.With(zap.String("handler", "draft")),
+
+		DraftService: ab.DraftService,
+	}
+}
+
+// DraftHandler represents an HTTP API handler for the drafts of the current user.
+type DraftHandler struct {
+	*httprouter.Router
+	Logger *zap.Logger
+
+	DraftService service.DraftService
+}
+
+const (
+	meDraftsPath     = "/api/v1/me/drafts"
+	meDraftsItemPath = "/api/v1/me/drafts/:type/:itemID"
+)
+
+// NewDraftHandler returns a new instance of DraftHandler.
+func NewDraftHandler(db *DraftBackend) *DraftHandler {
+	dh := &DraftHandler{
+		Router: NewRouter(),
+		Logger: db.Logger,
+
+		DraftService: db.DraftService,
+	}
+
+	dh.GET(meDraftsPath, dh.handleGetDrafts)
+	dh.GET(meDraftsItemPath, dh.handleGetDraft)
+	dh.PUT(meDraftsItemPath, dh.handlePutDraft)
+	dh.DELETE(meDraftsItemPath, dh.handleDeleteDraft)
+
+	return dh
+}
+
+type draftResponse struct {
+	Links map[string]string `json:"links"`
+	service.Draft
+	// Overwritten reports that the save replaced a newer revision than the one it was based on.
+	Overwritten bool `json:"overwritten,omitempty"`
+}
+
+func newDraftResponse(d *service.Draft) *draftResponse {
+	return &draftResponse{
+		Links: map[string]string{
+			"self": fmt.Sprintf("/api/v1/me/drafts/%s/%s", d.Type, d.ItemID),
+			"item": fmt.Sprintf("/api/v1/%s/%s", d.Type, d.ItemID),
+		},
+		Draft: *d,
+	}
+}
+
+type draftsResponse struct {
+	Links  map[string]string `json:"links"`
+	Drafts []*draftResponse  `json:"drafts"`
+	Total  int               `json:"total"`
+}
+
+func newDraftsResponse(opts service.FindOptions, ds []*service.Draft, total int) *draftsResponse {
+	res := &draftsResponse{
+		Links:  pagingLinks(meDraftsPath, opts, len(ds), total),
+		Drafts: make([]*draftResponse, 0, len(ds)),
+		Total:  total,
+	}
+
+	for _, d := range ds {
+		res.Drafts = append(res.Drafts, newDraftResponse(d))
+	}
+	return res
+}
+
+type draftRequest struct {
+	UserID service.ID
+	Type   service.DraftType
+	ItemID service.ID
+}
+
+func decodeDraftRequest(ctx context.Context, ps httprouter.Params) (*draftRequest, error) {
+	t := service.DraftType(ps.ByName("type"))
+	if err := t.Valid(); err != nil {
+		return nil, err
+	}
+
+	var itemID service.ID
+	if err := itemID.DecodeFromString(ps.ByName("itemID")); err != nil {
+		return nil, err
+	}
+
+	auth, err := icontext.GetAuthorizer(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	return &draftRequest{
+		UserID: auth.GetUserID(),
+		Type:   t,
+		ItemID: itemID,
+	}, nil
+}
+
+func (dh *DraftHandler) handleGetDrafts(rw http.ResponseWriter, r *http.Request, ps httprouter.Params) {
+	ctx := r.Context()
+
+	auth, err := icontext.GetAuthorizer(ctx)
+	if err != nil {
+		EncodeError(ctx, err, rw)
+		return
+	}
+
+	filter := service.DraftFilter{UserID: auth.GetUserID()}
+	if t := r.URL.Query().Get("type"); t != "" {
+		dt := service.DraftType(t)
+		if err := dt.Valid(); err != nil {
+			EncodeError(ctx, err, rw)
+			return
+		}
+		filter.Type = &dt
+	}
+
+	opts, err := decodeFindOptions(ctx, r)
+	if err != nil {
+		EncodeError(ctx, err, rw)
+		return
+	}
+
+	ds, total, err := dh.DraftService.FindDrafts(ctx, filter, *opts)
+	if err != nil {
+		EncodeError(ctx, err, rw)
+		return
+	}
+
+	if err := encodeResponse(ctx, rw, http.StatusOK, newDraftsResponse(*opts, ds, total)); err != nil {
+		LogEncodeError(dh.Logger, r, err)
+		return
+	}
+}
+
+func (dh *DraftHandler) handleGetDraft(rw http.ResponseWriter, r *http.Request, ps httprouter.Params) {
+	ctx := r.Context()
+
+	req, err := decodeDraftRequest(ctx, ps)
+	if err != nil {
+		EncodeError(ctx, err, rw)
+		return
+	}
+
+	d, err := dh.DraftService.FindDraft(ctx, req.UserID, req.Type, req.ItemID)
+	if err != nil {
+		EncodeError(ctx, err, rw)
+		return
+	}
+
+	if err := encodeResponse(ctx, rw, http.StatusOK, newDraftResponse(d)); err != nil {
+		LogEncodeError(dh.Logger, r, err)
+		return
+	}
+}
+
+type putDraftRequest struct {
+	Data string `json:"data"`
+	// Revision is the revision the draft was based on, zero for a new draft.
+	Revision int `json:"revision"`
+}
+
+func (dh *DraftHandler) handlePutDraft(rw http.ResponseWriter, r *http.Request, ps httprouter.Params) {
+	ctx := r.Context()
+
+	req, err := decodeDraftRequest(ctx, ps)
+	if err != nil {
+		EncodeError(ctx, err, rw)
+		return
+	}
+
+	body := &putDraftRequest{}
+	if err := json.NewDecoder(r.Body).Decode(body); err != nil {
+		EncodeError(ctx, &errors.Error{
+			Code: errors.Invalid,
+			Msg:  "invalid json structure",
+			Err:  err,
+		}, rw)
+		return
+	}
+
+	d := &service.Draft{
+		UserID:   req.UserID,
+		Type:     req.Type,
+		ItemID:   req.ItemID,
+		Data:     body.Data,
+		Revision: body.Revision,
+	}
+	overwritten, err := dh.DraftService.PutDraft(ctx, d)
+	if err != nil {
+		EncodeError(ctx, err, rw)
+		return
+	}
+
+	res := newDraftResponse(d)
+	res.Overwritten = overwritten
+	if err := encodeResponse(ctx, rw, http.StatusOK, res); err != nil {
+		LogEncodeError(dh.Logger, r, err)
+		return
+	}
+}
+
+func (dh *DraftHandler) handleDeleteDraft(rw http.ResponseWriter, r *http.Request, ps httprouter.Params) {
+	ctx := r.Context()
+
+	req, err := decodeDraftRequest(ctx, ps)
+	if err != nil {
+		EncodeError(ctx, err, rw)
+		return
+	}
+
+	if err := dh.DraftService.DeleteDraft(ctx, req.UserID, req.Type, req.ItemID); err != nil {
+		EncodeError(ctx, err, rw)
+		return
+	}
+
+	rw.WriteHeader(http.StatusNoContent)
+}
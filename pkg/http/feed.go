@@ -0,0 +1,153 @@
+package http
+
+import (
+	"fmt"
+	"net/http"
+
+	"github.com/julienschmidt/httprouter"
+	"go.uber.org/zap"
+
+	icontext "github.com/ustackq/indagate/pkg/context"
+	"github.com/ustackq/indagate/pkg/service"
+)
+
+// FeedBackend is all services and associated parameters required to construct
+// the FeedHandler.
+type FeedBackend struct {
+	Logger *zap.Logger
+
+	FeedService service.FeedService
+}
+
+// NewFeedBackend returns a new instance of FeedBackend.
+func NewFeedBackend(ab *APIBackend) *FeedBackend {
+	return &FeedBackend{
+		Logger: ab.Logger.With(zap.String("handler", "feed")),
+
+		FeedService: ab.FeedService,
+	}
+}
+
+// FeedHandler represents an HTTP API handler for activity feeds.
+type FeedHandler struct {
+	*httprouter.Router
+	Logger *zap.Logger
+
+	FeedService service.FeedService
+}
+
+const (
+	meFeedPath = "/api/v1/me/feed"
+	// activitiesSuffix is the last element of the activities of a user.
+	activitiesSuffix   = "/activities"
+	userActivitiesPath = "/api/v1/users/:id/activities"
+)
+
+// NewFeedHandler returns a new instance of FeedHandler.
+func NewFeedHandler(fb *FeedBackend) *FeedHandler {
+	fh := &FeedHandler{
+		Router: NewRouter(),
+		Logger: fb.Logger,
+
+		FeedService: fb.FeedService,
+	}
+
+	fh.GET(meFeedPath, fh.handleGetFeed)
+	fh.GET(userActivitiesPath, fh.handleGetUserActivities)
+
+	return fh
+}
+
+type activityResponse struct {
+	Links map[string]string `json:"links"`
+	service.Activity
+}
+
+func newActivityResponse(a *service.Activity) *activityResponse {
+	links := map[string]string{
+		"user": fmt.Sprintf("/api/v1/users/%s", a.UserID),
+		"item": fmt.Sprintf("/api/v1/%s/%s", a.ItemType, a.ItemID),
+	}
+	if a.QuestionID.Valid() {
+		links["question"] = fmt.Sprintf("/api/v1/questions/%s", a.QuestionID)
+	}
+
+	return &activityResponse{
+		Links:    links,
+		Activity: *a,
+	}
+}
+
+type activitiesResponse struct {
+	Links      map[string]string   `json:"links"`
+	Activities []*activityResponse `json:"activities"`
+	Total      int                 `json:"total"`
+}
+
+func newActivitiesResponse(basePath string, opts service.FindOptions, as []*service.Activity, total int) *activitiesResponse {
+	res := &activitiesResponse{
+		Links:      pagingLinks(basePath, opts, len(as), total),
+		Activities: make([]*activityResponse, 0, len(as)),
+		Total:      total,
+	}
+
+	for _, a := range as {
+		res.Activities = append(res.Activities, newActivityResponse(a))
+	}
+	return res
+}
+
+func (fh *FeedHandler) handleGetFeed(rw http.ResponseWriter, r *http.Request, ps httprouter.Params) {
+	ctx := r.Context()
+
+	auth, err := icontext.GetAuthorizer(ctx)
+	if err != nil {
+		EncodeError(ctx, err, rw)
+		return
+	}
+
+	opts, err := decodeFindOptions(ctx, r)
+	if err != nil {
+		EncodeError(ctx, err, rw)
+		return
+	}
+
+	as, total, err := fh.FeedService.FindFeed(ctx, auth.GetUserID(), *opts)
+	if err != nil {
+		EncodeError(ctx, err, rw)
+		return
+	}
+
+	if err := encodeResponse(ctx, rw, http.StatusOK, newActivitiesResponse(meFeedPath, *opts, as, total)); err != nil {
+		LogEncodeError(fh.Logger, r, err)
+		return
+	}
+}
+
+func (fh *FeedHandler) handleGetUserActivities(rw http.ResponseWriter, r *http.Request, ps httprouter.Params) {
+	ctx := r.Context()
+
+	req, err := decodeRequest(r, ps)
+	if err != nil {
+		EncodeError(ctx, err, rw)
+		return
+	}
+
+	opts, err := decodeFindOptions(ctx, r)
+	if err != nil {
+		EncodeError(ctx, err, rw)
+		return
+	}
+
+	as, total, err := fh.FeedService.FindUserActivities(ctx, req.ID, *opts)
+	if err != nil {
+		EncodeError(ctx, err, rw)
+		return
+	}
+
+	basePath := fmt.Sprintf("/api/v1/users/%s%s", req.ID, activitiesSuffix)
+	if err := encodeResponse(ctx, rw, http.StatusOK, newActivitiesResponse(basePath, *opts, as, total)); err != nil {
+		LogEncodeError(fh.Logger, r, err)
+		return
+	}
+}
@@ -0,0 +1,273 @@
+package http
+
+import (
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/julienschmidt/httprouter"
+	"go.uber.org/zap"
+
+	icontext "github.com/ustackq/indagate/pkg/context"
+	"github.com/ustackq/indagate/pkg/service"
+	"github.com/ustackq/indagate/pkg/utils/errors"
+)
+
+// FollowBackend is all services and associated parameters required to construct
+// the FollowHandler.
+type FollowBackend struct {
+	Logger *zap.Logger
+
+	FollowService service.FollowService
+}
+
+// NewFollowBackend returns a new instance of FollowBackend.
+func NewFollowBackend(ab *APIBackend) *FollowBackend {
+	return &FollowBackend{
+		Logger: ab.Logger.With(zap.String("handler", "follow")),
+
+		FollowService: ab.FollowService,
+	}
+}
+
+// FollowHandler represents an HTTP API handler for the follow graph.
+type FollowHandler struct {
+	*httprouter.Router
+	Logger *zap.Logger
+
+	FollowService service.FollowService
+}
+
+const (
+	// followersSuffix is the last element of the followers routes of all followable types.
+	followersSuffix = "/followers"
+	// followingSegment is part of the paths listing followed items.
+	followingSegment = "/following"
+
+	meFollowingTypePath = "/api/v1/me/following/:type"
+	meFollowingItemPath = "/api/v1/me/following/:type/:id"
+	userFollowingPath   = "/api/v1/users/:id/following/:type"
+)
+
+// NewFollowHandler returns a new instance of FollowHandler.
+func NewFollowHandler(fb *FollowBackend) *FollowHandler {
+	fh := &FollowHandler{
+		Router: NewRouter(),
+		Logger: fb.Logger,
+
+		FollowService: fb.FollowService,
+	}
+
+	for _, t := range service.AllFollowTypes {
+		fh.GET(fmt.Sprintf("/api/v1/%s/:id%s", t, followersSuffix), fh.handleGetFollowers)
+	}
+	fh.GET(userFollowingPath, fh.handleGetUserFollowing)
+	fh.GET(meFollowingTypePath, fh.handleGetMeFollowing)
+	fh.GET(meFollowingItemPath, fh.handleGetFollow)
+	fh.PUT(meFollowingItemPath, fh.handlePutFollow)
+	fh.DELETE(meFollowingItemPath, fh.handleDeleteFollow)
+
+	return fh
+}
+
+type followsResponse struct {
+	Links map[string]string `json:"links"`
+	*service.FollowPage
+}
+
+func newFollowsResponse(basePath string, cursor service.FollowCursor, page *service.FollowPage) *followsResponse {
+	links := map[string]string{
+		"self": basePath,
+	}
+	if page.Next.Valid() {
+		links["next"] = fmt.Sprintf("%s?after=%s", basePath, page.Next)
+		if cursor.Limit > 0 {
+			links["next"] += fmt.Sprintf("&limit=%d", cursor.Limit)
+		}
+	}
+
+	return &followsResponse{
+		Links:      links,
+		FollowPage: page,
+	}
+}
+
+// decodeFollowCursor reads the after and limit query parameters.
+func decodeFollowCursor(r *http.Request) (*service.FollowCursor, error) {
+	cursor := &service.FollowCursor{}
+	qp := r.URL.Query()
+
+	if after := qp.Get("after"); after != "" {
+		if err := cursor.After.DecodeFromString(after); err != nil {
+			return nil, &errors.Error{
+				Code: errors.Invalid,
+				Msg:  "after must be an id",
+				Err:  err,
+			}
+		}
+	}
+
+	if limit := qp.Get("limit"); limit != "" {
+		n, err := strconv.Atoi(limit)
+		if err != nil || n < 1 {
+			return nil, &errors.Error{
+				Code: errors.Invalid,
+				Msg:  "limit must be a positive number",
+			}
+		}
+		cursor.Limit = n
+	}
+	return cursor, nil
+}
+
+// handleGetFollowers lists the followers of /api/v1/:type/:id/followers.
+func (fh *FollowHandler) handleGetFollowers(rw http.ResponseWriter, r *http.Request, ps httprouter.Params) {
+	ctx := r.Context()
+
+	req, err := decodeRequest(r, ps)
+	if err != nil {
+		EncodeError(ctx, err, rw)
+		return
+	}
+
+	parts := strings.Split(strings.TrimPrefix(r.URL.Path, "/api/v1/"), "/")
+	t := service.FollowType(parts[0])
+
+	cursor, err := decodeFollowCursor(r)
+	if err != nil {
+		EncodeError(ctx, err, rw)
+		return
+	}
+
+	page, err := fh.FollowService.FindFollowers(ctx, t, req.ID, *cursor)
+	if err != nil {
+		EncodeError(ctx, err, rw)
+		return
+	}
+
+	basePath := fmt.Sprintf("/api/v1/%s/%s%s", t, req.ID, followersSuffix)
+	if err := encodeResponse(ctx, rw, http.StatusOK, newFollowsResponse(basePath, *cursor, page)); err != nil {
+		LogEncodeError(fh.Logger, r, err)
+		return
+	}
+}
+
+func (fh *FollowHandler) handleGetUserFollowing(rw http.ResponseWriter, r *http.Request, ps httprouter.Params) {
+	req, err := decodeRequest(r, ps)
+	if err != nil {
+		EncodeError(r.Context(), err, rw)
+		return
+	}
+
+	fh.getFollowing(rw, r, req.ID, fmt.Sprintf("/api/v1/users/%s/following", req.ID), service.FollowType(ps.ByName("type")))
+}
+
+func (fh *FollowHandler) handleGetMeFollowing(rw http.ResponseWriter, r *http.Request, ps httprouter.Params) {
+	auth, err := icontext.GetAuthorizer(r.Context())
+	if err != nil {
+		EncodeError(r.Context(), err, rw)
+		return
+	}
+
+	fh.getFollowing(rw, r, auth.GetUserID(), "/api/v1/me/following", service.FollowType(ps.ByName("type")))
+}
+
+func (fh *FollowHandler) getFollowing(rw http.ResponseWriter, r *http.Request, userID service.ID, basePath string, t service.FollowType) {
+	ctx := r.Context()
+
+	cursor, err := decodeFollowCursor(r)
+	if err != nil {
+		EncodeError(ctx, err, rw)
+		return
+	}
+
+	page, err := fh.FollowService.FindFollowing(ctx, userID, t, *cursor)
+	if err != nil {
+		EncodeError(ctx, err, rw)
+		return
+	}
+
+	if err := encodeResponse(ctx, rw, http.StatusOK, newFollowsResponse(fmt.Sprintf("%s/%s", basePath, t), *cursor, page)); err != nil {
+		LogEncodeError(fh.Logger, r, err)
+		return
+	}
+}
+
+// decodeFollowRequest reads the item from /api/v1/me/following/:type/:id and the
+// follower from the authorizer.
+func decodeFollowRequest(r *http.Request, ps httprouter.Params) (*service.Follow, error) {
+	req, err := decodeRequest(r, ps)
+	if err != nil {
+		return nil, err
+	}
+
+	auth, err := icontext.GetAuthorizer(r.Context())
+	if err != nil {
+		return nil, err
+	}
+
+	return &service.Follow{
+		UserID: auth.GetUserID(),
+		Type:   service.FollowType(ps.ByName("type")),
+		ItemID: req.ID,
+	}, nil
+}
+
+func (fh *FollowHandler) handleGetFollow(rw http.ResponseWriter, r *http.Request, ps httprouter.Params) {
+	ctx := r.Context()
+
+	req, err := decodeFollowRequest(r, ps)
+	if err != nil {
+		EncodeError(ctx, err, rw)
+		return
+	}
+
+	f, err := fh.FollowService.FindFollow(ctx, req.UserID, req.Type, req.ItemID)
+	if err != nil {
+		EncodeError(ctx, err, rw)
+		return
+	}
+
+	if err := encodeResponse(ctx, rw, http.StatusOK, f); err != nil {
+		LogEncodeError(fh.Logger, r, err)
+		return
+	}
+}
+
+func (fh *FollowHandler) handlePutFollow(rw http.ResponseWriter, r *http.Request, ps httprouter.Params) {
+	ctx := r.Context()
+
+	f, err := decodeFollowRequest(r, ps)
+	if err != nil {
+		EncodeError(ctx, err, rw)
+		return
+	}
+
+	if err := fh.FollowService.Follow(ctx, f); err != nil {
+		EncodeError(ctx, err, rw)
+		return
+	}
+
+	if err := encodeResponse(ctx, rw, http.StatusOK, f); err != nil {
+		LogEncodeError(fh.Logger, r, err)
+		return
+	}
+}
+
+func (fh *FollowHandler) handleDeleteFollow(rw http.ResponseWriter, r *http.Request, ps httprouter.Params) {
+	ctx := r.Context()
+
+	req, err := decodeFollowRequest(r, ps)
+	if err != nil {
+		EncodeError(ctx, err, rw)
+		return
+	}
+
+	if err := fh.FollowService.Unfollow(ctx, req.UserID, req.Type, req.ItemID); err != nil {
+		EncodeError(ctx, err, rw)
+		return
+	}
+
+	rw.WriteHeader(http.StatusNoContent)
+}
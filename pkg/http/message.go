@@ -0,0 +1,556 @@
+package http
+
+import (
+	"fmt"
+	"net/http"
+	"sync"
+	"time"
+
+	"github.com/julienschmidt/httprouter"
+	"go.uber.org/zap"
+
+	icontext "github.com/ustackq/indagate/pkg/context"
+	"github.com/ustackq/indagate/pkg/flowcontroller"
+	"github.com/ustackq/indagate/pkg/service"
+	"github.com/ustackq/indagate/pkg/utils/errors"
+)
+
+const (
+	// DefaultMessageQPS is the rate at which each user may send messages when none is configured.
+	DefaultMessageQPS = 0.2
+	// DefaultMessageBurst is the number of messages a user may send at once when none is configured.
+	DefaultMessageBurst = 5
+
+	// limiterSweepInterval is how often the limiters of idle users are dropped.
+	limiterSweepInterval = time.Minute
+)
+
+// MessageBackend is all services and associated parameters required to construct
+// the MessageHandler.
+type MessageBackend struct {
+	Logger *zap.Logger
+
+	MessageService service.MessageService
+	// NewRateLimiter returns the limiter of the messages sent by a single user.
+	NewRateLimiter func() flowcontroller.RateLimiter
+}
+
+// NewMessageBackend returns a new instance of MessageBackend.
+func NewMessageBackend(ab *APIBackend) *MessageBackend {
+	qps, burst := ab.MessageQPS, ab.MessageBurst
+	if qps <= 0 {
+		qps = DefaultMessageQPS
+	}
+	if burst <= 0 {
+		burst = DefaultMessageBurst
+	}
+
+	return &MessageBackend{
+		Logger: ab.Logger.With(zap.String("handler", "message")),
+
+		MessageService: ab.MessageService,
+		NewRateLimiter: func() flowcontroller.RateLimiter {
+			return flowcontroller.NewTokenBucketRateLimiter(qps, burst)
+		},
+	}
+}
+
+// MessageHandler represents an HTTP API handler for the dialogs of the current user.
+type MessageHandler struct {
+	*httprouter.Router
+	Logger *zap.Logger
+
+	MessageService service.MessageService
+
+	newRateLimiter func() flowcontroller.RateLimiter
+	mu             sync.Mutex
+	limiters       map[service.ID]flowcontroller.RateLimiter
+	sweptAt        time.Time
+}
+
+const (
+	meDialogsPath          = "/api/v1/me/dialogs"
+	meDialogsIDPath        = "/api/v1/me/dialogs/:id"
+	meDialogsReadPath      = "/api/v1/me/dialogs/:id/read"
+	meDialogsMessagesPath  = "/api/v1/me/dialogs/:id/messages"
+	meDialogsMessageIDPath = "/api/v1/me/dialogs/:id/messages/:messageID"
+	meBlocksPath           = "/api/v1/me/blocks"
+	meBlocksIDPath         = "/api/v1/me/blocks/:id"
+)
+
+// NewMessageHandler returns a new instance of MessageHandler.
+func NewMessageHandler(mb *MessageBackend) *MessageHandler {
+	mh := &MessageHandler{
+		Router: NewRouter(),
+		Logger: mb.Logger,
+
+		MessageService: mb.MessageService,
+
+		newRateLimiter: mb.NewRateLimiter,
+		limiters:       make(map[service.ID]flowcontroller.RateLimiter),
+		sweptAt:        time.Now(),
+	}
+
+	mh.GET(meDialogsPath, mh.handleGetDialogs)
+	mh.POST(meDialogsPath, mh.handlePostDialog)
+	mh.GET(meDialogsIDPath, mh.handleGetDialog)
+	mh.DELETE(meDialogsIDPath, mh.handleDeleteDialog)
+	mh.PUT(meDialogsReadPath, mh.handlePutDialogRead)
+	mh.GET(meDialogsMessagesPath, mh.handleGetMessages)
+	mh.POST(meDialogsMessagesPath, mh.handlePostMessage)
+	mh.DELETE(meDialogsMessageIDPath, mh.handleDeleteMessage)
+	mh.GET(meBlocksPath, mh.handleGetBlocks)
+	mh.PUT(meBlocksIDPath, mh.handlePutBlock)
+	mh.DELETE(meBlocksIDPath, mh.handleDeleteBlock)
+
+	return mh
+}
+
+// acceptMessage reports whether the user is still allowed to send a message.
+func (mh *MessageHandler) acceptMessage(userID service.ID) bool {
+	mh.mu.Lock()
+	if now := time.Now(); now.Sub(mh.sweptAt) >= limiterSweepInterval {
+		mh.sweepLimiters()
+		mh.sweptAt = now
+	}
+	limiter, ok := mh.limiters[userID]
+	if !ok {
+		limiter = mh.newRateLimiter()
+		mh.limiters[userID] = limiter
+	}
+	mh.mu.Unlock()
+
+	return limiter.TryAccept()
+}
+
+// sweepLimiters drops the limiters whose bucket has refilled, they accept
+// as much as a new limiter would. mh.mu must be held.
+func (mh *MessageHandler) sweepLimiters() {
+	for userID, limiter := range mh.limiters {
+		if limiter.Saturation() == 0 {
+			delete(mh.limiters, userID)
+		}
+	}
+}
+
+type dialogResponse struct {
+	Links map[string]string `json:"links"`
+	service.Dialog
+	// Unread is the number of unread messages of the current user.
+	Unread int `json:"unread"`
+}
+
+func newDialogResponse(d *service.Dialog, userID service.ID) *dialogResponse {
+	return &dialogResponse{
+		Links: map[string]string{
+			"self":     fmt.Sprintf("/api/v1/me/dialogs/%s", d.ID),
+			"messages": fmt.Sprintf("/api/v1/me/dialogs/%s/messages", d.ID),
+			"peer":     fmt.Sprintf("/api/v1/users/%s", d.Peer(userID)),
+		},
+		Dialog: *d,
+		Unread: d.Unread(userID),
+	}
+}
+
+type dialogsResponse struct {
+	Links   map[string]string `json:"links"`
+	Dialogs []*dialogResponse `json:"dialogs"`
+	Total   int               `json:"total"`
+}
+
+func newDialogsResponse(opts service.FindOptions, ds []*service.Dialog, total int, userID service.ID) *dialogsResponse {
+	res := &dialogsResponse{
+		Links:   pagingLinks(meDialogsPath, opts, len(ds), total),
+		Dialogs: make([]*dialogResponse, 0, len(ds)),
+		Total:   total,
+	}
+
+	for _, d := range ds {
+		res.Dialogs = append(res.Dialogs, newDialogResponse(d, userID))
+	}
+	return res
+}
+
+type messageResponse struct {
+	Links map[string]string `json:"links"`
+	service.Message
+}
+
+func newMessageResponse(m *service.Message) *messageResponse {
+	return &messageResponse{
+		Links: map[string]string{
+			"self":   fmt.Sprintf("/api/v1/me/dialogs/%s/messages/%s", m.DialogID, m.ID),
+			"dialog": fmt.Sprintf("/api/v1/me/dialogs/%s", m.DialogID),
+			"sender": fmt.Sprintf("/api/v1/users/%s", m.SenderID),
+		},
+		Message: *m,
+	}
+}
+
+type messagesResponse struct {
+	Links    map[string]string  `json:"links"`
+	Messages []*messageResponse `json:"messages"`
+	Total    int                `json:"total"`
+}
+
+func newMessagesResponse(opts service.FindOptions, dialogID service.ID, ms []*service.Message, total int) *messagesResponse {
+	res := &messagesResponse{
+		Links:    pagingLinks(fmt.Sprintf("/api/v1/me/dialogs/%s/messages", dialogID), opts, len(ms), total),
+		Messages: make([]*messageResponse, 0, len(ms)),
+		Total:    total,
+	}
+
+	for _, m := range ms {
+		res.Messages = append(res.Messages, newMessageResponse(m))
+	}
+	return res
+}
+
+func (mh *MessageHandler) handleGetDialogs(rw http.ResponseWriter, r *http.Request, ps httprouter.Params) {
+	ctx := r.Context()
+
+	auth, err := icontext.GetAuthorizer(ctx)
+	if err != nil {
+		EncodeError(ctx, err, rw)
+		return
+	}
+
+	opts, err := decodeFindOptions(ctx, r)
+	if err != nil {
+		EncodeError(ctx, err, rw)
+		return
+	}
+
+	ds, total, err := mh.MessageService.FindDialogs(ctx, auth.GetUserID(), *opts)
+	if err != nil {
+		EncodeError(ctx, err, rw)
+		return
+	}
+
+	if err := encodeResponse(ctx, rw, http.StatusOK, newDialogsResponse(*opts, ds, total, auth.GetUserID())); err != nil {
+		LogEncodeError(mh.Logger, r, err)
+		return
+	}
+}
+
+type postDialogRequest struct {
+	RecipientID service.ID `json:"recipientID"`
+	// Content optionally sends a first message.
+	Content string `json:"content"`
+}
+
+// handlePostDialog starts a dialog with the recipient, or returns the existing one.
+func (mh *MessageHandler) handlePostDialog(rw http.ResponseWriter, r *http.Request, ps httprouter.Params) {
+	ctx := r.Context()
+
+	req := &postDialogRequest{}
+	if err := json.NewDecoder(r.Body).Decode(req); err != nil {
+		EncodeError(ctx, &errors.Error{
+			Code: errors.Invalid,
+			Msg:  "invalid json structure",
+			Err:  err,
+		}, rw)
+		return
+	}
+
+	auth, err := icontext.GetAuthorizer(ctx)
+	if err != nil {
+		EncodeError(ctx, err, rw)
+		return
+	}
+	userID := auth.GetUserID()
+
+	if req.Content != "" && !mh.acceptMessage(userID) {
+		EncodeError(ctx, service.ErrMessageRateLimited, rw)
+		return
+	}
+
+	d := &service.Dialog{
+		SenderID:    userID,
+		RecipientID: req.RecipientID,
+	}
+	if err := mh.MessageService.CreateDialog(ctx, d); err != nil {
+		EncodeError(ctx, err, rw)
+		return
+	}
+
+	if req.Content != "" {
+		m := &service.Message{
+			DialogID: d.ID,
+			SenderID: userID,
+			Content:  req.Content,
+		}
+		if err := mh.MessageService.SendMessage(ctx, m); err != nil {
+			EncodeError(ctx, err, rw)
+			return
+		}
+
+		if d, err = mh.MessageService.FindDialogByID(ctx, d.ID); err != nil {
+			EncodeError(ctx, err, rw)
+			return
+		}
+	}
+
+	if err := encodeResponse(ctx, rw, http.StatusCreated, newDialogResponse(d, userID)); err != nil {
+		LogEncodeError(mh.Logger, r, err)
+		return
+	}
+}
+
+func (mh *MessageHandler) handleGetDialog(rw http.ResponseWriter, r *http.Request, ps httprouter.Params) {
+	ctx := r.Context()
+
+	req, err := decodeRequest(r, ps)
+	if err != nil {
+		EncodeError(ctx, err, rw)
+		return
+	}
+
+	auth, err := icontext.GetAuthorizer(ctx)
+	if err != nil {
+		EncodeError(ctx, err, rw)
+		return
+	}
+
+	d, err := mh.MessageService.FindDialogByID(ctx, req.ID)
+	if err != nil {
+		EncodeError(ctx, err, rw)
+		return
+	}
+
+	if err := encodeResponse(ctx, rw, http.StatusOK, newDialogResponse(d, auth.GetUserID())); err != nil {
+		LogEncodeError(mh.Logger, r, err)
+		return
+	}
+}
+
+func (mh *MessageHandler) handleDeleteDialog(rw http.ResponseWriter, r *http.Request, ps httprouter.Params) {
+	ctx := r.Context()
+
+	req, err := decodeRequest(r, ps)
+	if err != nil {
+		EncodeError(ctx, err, rw)
+		return
+	}
+
+	auth, err := icontext.GetAuthorizer(ctx)
+	if err != nil {
+		EncodeError(ctx, err, rw)
+		return
+	}
+
+	if err := mh.MessageService.DeleteDialog(ctx, req.ID, auth.GetUserID()); err != nil {
+		EncodeError(ctx, err, rw)
+		return
+	}
+
+	rw.WriteHeader(http.StatusNoContent)
+}
+
+func (mh *MessageHandler) handlePutDialogRead(rw http.ResponseWriter, r *http.Request, ps httprouter.Params) {
+	ctx := r.Context()
+
+	req, err := decodeRequest(r, ps)
+	if err != nil {
+		EncodeError(ctx, err, rw)
+		return
+	}
+
+	auth, err := icontext.GetAuthorizer(ctx)
+	if err != nil {
+		EncodeError(ctx, err, rw)
+		return
+	}
+
+	d, err := mh.MessageService.MarkDialogRead(ctx, req.ID, auth.GetUserID())
+	if err != nil {
+		EncodeError(ctx, err, rw)
+		return
+	}
+
+	if err := encodeResponse(ctx, rw, http.StatusOK, newDialogResponse(d, auth.GetUserID())); err != nil {
+		LogEncodeError(mh.Logger, r, err)
+		return
+	}
+}
+
+func (mh *MessageHandler) handleGetMessages(rw http.ResponseWriter, r *http.Request, ps httprouter.Params) {
+	ctx := r.Context()
+
+	req, err := decodeRequest(r, ps)
+	if err != nil {
+		EncodeError(ctx, err, rw)
+		return
+	}
+
+	auth, err := icontext.GetAuthorizer(ctx)
+	if err != nil {
+		EncodeError(ctx, err, rw)
+		return
+	}
+
+	opts, err := decodeFindOptions(ctx, r)
+	if err != nil {
+		EncodeError(ctx, err, rw)
+		return
+	}
+
+	ms, total, err := mh.MessageService.FindMessages(ctx, req.ID, auth.GetUserID(), *opts)
+	if err != nil {
+		EncodeError(ctx, err, rw)
+		return
+	}
+
+	if err := encodeResponse(ctx, rw, http.StatusOK, newMessagesResponse(*opts, req.ID, ms, total)); err != nil {
+		LogEncodeError(mh.Logger, r, err)
+		return
+	}
+}
+
+type postMessageRequest struct {
+	Content string `json:"content"`
+}
+
+func (mh *MessageHandler) handlePostMessage(rw http.ResponseWriter, r *http.Request, ps httprouter.Params) {
+	ctx := r.Context()
+
+	req, err := decodeRequest(r, ps)
+	if err != nil {
+		EncodeError(ctx, err, rw)
+		return
+	}
+
+	body := &postMessageRequest{}
+	if err := json.NewDecoder(r.Body).Decode(body); err != nil {
+		EncodeError(ctx, &errors.Error{
+			Code: errors.Invalid,
+			Msg:  "invalid json structure",
+			Err:  err,
+		}, rw)
+		return
+	}
+
+	auth, err := icontext.GetAuthorizer(ctx)
+	if err != nil {
+		EncodeError(ctx, err, rw)
+		return
+	}
+
+	if !mh.acceptMessage(auth.GetUserID()) {
+		EncodeError(ctx, service.ErrMessageRateLimited, rw)
+		return
+	}
+
+	m := &service.Message{
+		DialogID: req.ID,
+		SenderID: auth.GetUserID(),
+		Content:  body.Content,
+	}
+	if err := mh.MessageService.SendMessage(ctx, m); err != nil {
+		EncodeError(ctx, err, rw)
+		return
+	}
+
+	if err := encodeResponse(ctx, rw, http.StatusCreated, newMessageResponse(m)); err != nil {
+		LogEncodeError(mh.Logger, r, err)
+		return
+	}
+}
+
+func (mh *MessageHandler) handleDeleteMessage(rw http.ResponseWriter, r *http.Request, ps httprouter.Params) {
+	ctx := r.Context()
+
+	var messageID service.ID
+	if err := messageID.DecodeFromString(ps.ByName("messageID")); err != nil {
+		EncodeError(ctx, err, rw)
+		return
+	}
+
+	auth, err := icontext.GetAuthorizer(ctx)
+	if err != nil {
+		EncodeError(ctx, err, rw)
+		return
+	}
+
+	if err := mh.MessageService.DeleteMessage(ctx, messageID, auth.GetUserID()); err != nil {
+		EncodeError(ctx, err, rw)
+		return
+	}
+
+	rw.WriteHeader(http.StatusNoContent)
+}
+
+type blocksResponse struct {
+	Blocks []*service.Block `json:"blocks"`
+}
+
+func (mh *MessageHandler) handleGetBlocks(rw http.ResponseWriter, r *http.Request, ps httprouter.Params) {
+	ctx := r.Context()
+
+	auth, err := icontext.GetAuthorizer(ctx)
+	if err != nil {
+		EncodeError(ctx, err, rw)
+		return
+	}
+
+	bs, err := mh.MessageService.FindBlockedUsers(ctx, auth.GetUserID())
+	if err != nil {
+		EncodeError(ctx, err, rw)
+		return
+	}
+
+	if err := encodeResponse(ctx, rw, http.StatusOK, &blocksResponse{Blocks: bs}); err != nil {
+		LogEncodeError(mh.Logger, r, err)
+		return
+	}
+}
+
+func (mh *MessageHandler) handlePutBlock(rw http.ResponseWriter, r *http.Request, ps httprouter.Params) {
+	ctx := r.Context()
+
+	req, err := decodeRequest(r, ps)
+	if err != nil {
+		EncodeError(ctx, err, rw)
+		return
+	}
+
+	auth, err := icontext.GetAuthorizer(ctx)
+	if err != nil {
+		EncodeError(ctx, err, rw)
+		return
+	}
+
+	b, err := mh.MessageService.BlockUser(ctx, auth.GetUserID(), req.ID)
+	if err != nil {
+		EncodeError(ctx, err, rw)
+		return
+	}
+
+	if err := encodeResponse(ctx, rw, http.StatusOK, b); err != nil {
+		LogEncodeError(mh.Logger, r, err)
+		return
+	}
+}
+
+func (mh *MessageHandler) handleDeleteBlock(rw http.ResponseWriter, r *http.Request, ps httprouter.Params) {
+	ctx := r.Context()
+
+	req, err := decodeRequest(r, ps)
+	if err != nil {
+		EncodeError(ctx, err, rw)
+		return
+	}
+
+	auth, err := icontext.GetAuthorizer(ctx)
+	if err != nil {
+		EncodeError(ctx, err, rw)
+		return
+	}
+
+	if err := mh.MessageService.UnblockUser(ctx, auth.GetUserID(), req.ID); err != nil {
+		EncodeError(ctx, err, rw)
+		return
+	}
+
+	rw.WriteHeader(http.StatusNoContent)
+}
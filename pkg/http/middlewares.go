@@ -2,12 +2,15 @@ package http
 
 import (
 	"context"
+	"net"
+	"net/http"
+	"strings"
+	"time"
+
 	"github.com/julienschmidt/httprouter"
 	icontext "github.com/ustackq/indagate/pkg/context"
 	"github.com/ustackq/indagate/pkg/service"
 	"go.uber.org/zap"
-	"net/http"
-	"time"
 )
 
 const (
@@ -30,6 +33,8 @@ type AuthenticationHandler struct {
 	// SessionService
 	SessionService       service.SessionService
 	SessionRenewDisabled bool
+	// BanService rejects the requests of banned users, when set.
+	BanService service.BanService
 	// use to lookup handler
 	noAuthRouter *httprouter.Router
 	Handler      http.Handler
@@ -66,6 +71,9 @@ func (ah *AuthenticationHandler) ServeHTTP(rw http.ResponseWriter, r *http.Reque
 		if err != nil {
 			break
 		}
+		if !ah.checkUser(ctx, rw) {
+			return
+		}
 		r = r.WithContext(ctx)
 		ah.Handler.ServeHTTP(rw, r)
 		return
@@ -74,6 +82,9 @@ func (ah *AuthenticationHandler) ServeHTTP(rw http.ResponseWriter, r *http.Reque
 		if err != nil {
 			break
 		}
+		if !ah.checkUser(ctx, rw) {
+			return
+		}
 		r = r.WithContext(ctx)
 		ah.Handler.ServeHTTP(rw, r)
 		return
@@ -81,6 +92,25 @@ func (ah *AuthenticationHandler) ServeHTTP(rw http.ResponseWriter, r *http.Reque
 	UnauthorizedError(ctx, rw)
 }
 
+// checkUser encodes the error and returns false if the authenticated user is banned.
+func (ah *AuthenticationHandler) checkUser(ctx context.Context, rw http.ResponseWriter) bool {
+	if ah.BanService == nil {
+		return true
+	}
+
+	auth, err := icontext.GetAuthorizer(ctx)
+	if err != nil {
+		UnauthorizedError(ctx, rw)
+		return false
+	}
+
+	if err := ah.BanService.CheckUser(ctx, auth.GetUserID()); err != nil {
+		EncodeError(ctx, err, rw)
+		return false
+	}
+	return true
+}
+
 func (ah *AuthenticationHandler) extractAuthorization(ctx context.Context, r *http.Request) (context.Context, error) {
 	token, err := GetToken(r)
 	if err != nil {
@@ -114,3 +144,84 @@ func (ah *AuthenticationHandler) extractSession(ctx context.Context, r *http.Req
 
 	return icontext.SetAuthorizer(ctx, s), nil
 }
+
+// IPBlockHandler is a middleware rejecting the requests coming from blocked IP ranges.
+type IPBlockHandler struct {
+	Logger     *zap.Logger
+	BanService service.BanService
+	// TrustedProxies are the ranges of the proxies whose X-Forwarded-For header is
+	// used to find the client address.
+	TrustedProxies []*net.IPNet
+	Handler        http.Handler
+}
+
+// NewIPBlockHandler returns a new instance of IPBlockHandler.
+func NewIPBlockHandler(bs service.BanService, h http.Handler) *IPBlockHandler {
+	return &IPBlockHandler{
+		Logger:     zap.NewNop(),
+		BanService: bs,
+		Handler:    h,
+	}
+}
+
+func (ih *IPBlockHandler) ServeHTTP(rw http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+
+	ip := ih.clientIP(r)
+	if ip == nil {
+		ih.Logger.Info("failed to find the client address", zap.String("remote", r.RemoteAddr))
+		ih.Handler.ServeHTTP(rw, r)
+		return
+	}
+
+	if err := ih.BanService.CheckIP(ctx, ip, service.AccessIPBlock); err != nil {
+		EncodeError(ctx, err, rw)
+		return
+	}
+
+	if r.Method == http.MethodPost && r.URL.Path == usersPath {
+		if err := ih.BanService.CheckIP(ctx, ip, service.RegistrationIPBlock); err != nil {
+			EncodeError(ctx, err, rw)
+			return
+		}
+	}
+
+	ih.Handler.ServeHTTP(rw, r)
+}
+
+// clientIP returns the address of the client, walking X-Forwarded-For back from the
+// peer as long as the hops are trusted proxies.
+func (ih *IPBlockHandler) clientIP(r *http.Request) net.IP {
+	host, _, err := net.SplitHostPort(r.RemoteAddr)
+	if err != nil {
+		host = r.RemoteAddr
+	}
+	ip := net.ParseIP(host)
+	if ip == nil {
+		return nil
+	}
+
+	// without trusted proxies the header can only have been set by the client.
+	if len(ih.TrustedProxies) == 0 {
+		return ip
+	}
+
+	hops := strings.Split(r.Header.Get("X-Forwarded-For"), ",")
+	for i := len(hops) - 1; i >= 0 && ih.trusted(ip); i-- {
+		hop := net.ParseIP(strings.TrimSpace(hops[i]))
+		if hop == nil {
+			break
+		}
+		ip = hop
+	}
+	return ip
+}
+
+func (ih *IPBlockHandler) trusted(ip net.IP) bool {
+	for _, n := range ih.TrustedProxies {
+		if n.Contains(ip) {
+			return true
+		}
+	}
+	return false
+}
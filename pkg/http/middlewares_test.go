@@ -0,0 +1,105 @@
+package http
+
+import (
+	"context"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/ustackq/indagate/pkg/service"
+)
+
+func mustParseCIDR(t *testing.T, s string) *net.IPNet {
+	t.Helper()
+	_, n, err := net.ParseCIDR(s)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return n
+}
+
+func TestIPBlockHandler_clientIP(t *testing.T) {
+	proxies := []*net.IPNet{mustParseCIDR(t, "10.0.0.0/8")}
+
+	tests := []struct {
+		name      string
+		proxies   []*net.IPNet
+		remote    string
+		forwarded string
+		want      string
+	}{
+		{name: "peer", remote: "1.2.3.4:5678", want: "1.2.3.4"},
+		{name: "spoofed header without proxies", remote: "1.2.3.4:5678", forwarded: "5.6.7.8", want: "1.2.3.4"},
+		{name: "spoofed header from an untrusted peer", proxies: proxies, remote: "1.2.3.4:5678", forwarded: "5.6.7.8", want: "1.2.3.4"},
+		{name: "trusted proxy", proxies: proxies, remote: "10.0.0.1:5678", forwarded: "5.6.7.8", want: "5.6.7.8"},
+		{name: "chain of trusted proxies", proxies: proxies, remote: "10.0.0.1:5678", forwarded: "5.6.7.8, 10.0.0.2", want: "5.6.7.8"},
+		{name: "stops at the first untrusted hop", proxies: proxies, remote: "10.0.0.1:5678", forwarded: "9.9.9.9, 5.6.7.8", want: "5.6.7.8"},
+		{name: "invalid hop", proxies: proxies, remote: "10.0.0.1:5678", forwarded: "nope", want: "10.0.0.1"},
+		{name: "invalid peer", remote: "nope", want: ""},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			r := httptest.NewRequest(http.MethodGet, "/", nil)
+			r.RemoteAddr = tt.remote
+			if tt.forwarded != "" {
+				r.Header.Set("X-Forwarded-For", tt.forwarded)
+			}
+
+			ih := &IPBlockHandler{TrustedProxies: tt.proxies}
+			got := ih.clientIP(r)
+			if tt.want == "" {
+				if got != nil {
+					t.Errorf("clientIP() = %s, want nil", got)
+				}
+				return
+			}
+			if !got.Equal(net.ParseIP(tt.want)) {
+				t.Errorf("clientIP() = %s, want %s", got, tt.want)
+			}
+		})
+	}
+}
+
+// blockingBanService blocks the access of a single address.
+type blockingBanService struct {
+	service.BanService
+	blocked net.IP
+}
+
+func (s *blockingBanService) CheckIP(ctx context.Context, ip net.IP, scope service.IPBlockScope) error {
+	if scope == service.AccessIPBlock && ip.Equal(s.blocked) {
+		return service.ErrIPBlocked
+	}
+	return nil
+}
+
+func TestIPBlockHandler_ServeHTTP(t *testing.T) {
+	next := http.HandlerFunc(func(rw http.ResponseWriter, r *http.Request) {
+		rw.WriteHeader(http.StatusNoContent)
+	})
+	ih := NewIPBlockHandler(&blockingBanService{blocked: net.ParseIP("5.6.7.8")}, next)
+
+	serve := func(remote, forwarded string) int {
+		r := httptest.NewRequest(http.MethodGet, "/", nil)
+		r.RemoteAddr = remote
+		r.Header.Set("X-Forwarded-For", forwarded)
+		rw := httptest.NewRecorder()
+		ih.ServeHTTP(rw, r)
+		return rw.Code
+	}
+
+	// the blocked address can not be claimed by a client to get another one blocked,
+	// nor can a blocked client claim another address.
+	if code := serve("1.2.3.4:5678", "5.6.7.8"); code != http.StatusNoContent {
+		t.Errorf("spoofed blocked address status = %d, want %d", code, http.StatusNoContent)
+	}
+	if code := serve("5.6.7.8:5678", "1.2.3.4"); code == http.StatusNoContent {
+		t.Errorf("blocked peer status = %d, want an error", code)
+	}
+
+	ih.TrustedProxies = []*net.IPNet{mustParseCIDR(t, "10.0.0.0/8")}
+	if code := serve("10.0.0.1:5678", "5.6.7.8"); code == http.StatusNoContent {
+		t.Errorf("blocked client behind a trusted proxy status = %d, want an error", code)
+	}
+}
@@ -0,0 +1,336 @@
+package http
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+
+	"github.com/julienschmidt/httprouter"
+	"go.uber.org/zap"
+
+	icontext "github.com/ustackq/indagate/pkg/context"
+	"github.com/ustackq/indagate/pkg/service"
+	"github.com/ustackq/indagate/pkg/utils/errors"
+)
+
+// ModerationBackend is all services and associated parameters required to construct
+// the ModerationHandler.
+type ModerationBackend struct {
+	Logger *zap.Logger
+
+	ModerationService service.ModerationService
+}
+
+// NewModerationBackend returns a new instance of ModerationBackend.
+func NewModerationBackend(ab *APIBackend) *ModerationBackend {
+	return &ModerationBackend{
+		Logger: ab.Logger.With(zap.String("handler", "moderation")),
+
+		ModerationService: ab.ModerationService,
+	}
+}
+
+// ModerationHandler represents an HTTP API handler for reports and their moderation.
+type ModerationHandler struct {
+	*httprouter.Router
+	Logger *zap.Logger
+
+	ModerationService service.ModerationService
+}
+
+const (
+	reportsPath           = "/api/v1/reports"
+	moderationPrefix      = "/api/v1/moderation"
+	moderationQueuePath   = "/api/v1/moderation/queue"
+	moderationItemPath    = "/api/v1/moderation/queue/:orgID/:type/:id"
+	moderationReportPath  = "/api/v1/moderation/queue/:orgID/:type/:id/reports"
+	moderationResolvePath = "/api/v1/moderation/queue/:orgID/:type/:id/resolve"
+)
+
+// NewModerationHandler returns a new instance of ModerationHandler.
+func NewModerationHandler(mb *ModerationBackend) *ModerationHandler {
+	mh := &ModerationHandler{
+		Router: NewRouter(),
+		Logger: mb.Logger,
+
+		ModerationService: mb.ModerationService,
+	}
+
+	mh.POST(reportsPath, mh.handlePostReport)
+	mh.GET(moderationQueuePath, mh.handleGetQueue)
+	mh.GET(moderationItemPath, mh.handleGetItem)
+	mh.GET(moderationReportPath, mh.handleGetReports)
+	mh.POST(moderationResolvePath, mh.handleResolve)
+
+	return mh
+}
+
+type moderationItemResponse struct {
+	Links map[string]string `json:"links"`
+	service.ModerationItem
+}
+
+func newModerationItemResponse(m *service.ModerationItem) *moderationItemResponse {
+	self := fmt.Sprintf("%s/%s/%s/%s", moderationQueuePath, m.OrgID, m.Type, m.TargetID)
+	return &moderationItemResponse{
+		Links: map[string]string{
+			"self":    self,
+			"target":  fmt.Sprintf("/api/v1/%s/%s", m.Type, m.TargetID),
+			"reports": self + "/reports",
+			"resolve": self + "/resolve",
+		},
+		ModerationItem: *m,
+	}
+}
+
+type moderationQueueResponse struct {
+	Links map[string]string         `json:"links"`
+	Items []*moderationItemResponse `json:"items"`
+	Total int                       `json:"total"`
+}
+
+func newModerationQueueResponse(opts service.FindOptions, ms []*service.ModerationItem, total int) *moderationQueueResponse {
+	res := &moderationQueueResponse{
+		Links: pagingLinks(moderationQueuePath, opts, len(ms), total),
+		Items: make([]*moderationItemResponse, 0, len(ms)),
+		Total: total,
+	}
+
+	for _, m := range ms {
+		res.Items = append(res.Items, newModerationItemResponse(m))
+	}
+	return res
+}
+
+type reportsResponse struct {
+	Reports []*service.Report `json:"reports"`
+}
+
+type postReportRequest struct {
+	Type     service.ReportType `json:"type"`
+	TargetID service.ID         `json:"targetID"`
+	OrgID    service.ID         `json:"orgID"`
+	Reason   string             `json:"reason"`
+}
+
+type resolveRequest struct {
+	Action service.ModerationAction `json:"action"`
+	Reason string                   `json:"reason"`
+}
+
+// moderationItemRequest identifies the moderation item of a target within an org.
+type moderationItemRequest struct {
+	OrgID    service.ID
+	Type     service.ReportType
+	TargetID service.ID
+}
+
+func decodeModerationItemRequest(ctx context.Context, ps httprouter.Params) (*moderationItemRequest, error) {
+	orgID, err := service.IDFromString(ps.ByName("orgID"))
+	if err != nil {
+		return nil, err
+	}
+
+	t := service.ReportType(ps.ByName("type"))
+	if err := t.Valid(); err != nil {
+		return nil, err
+	}
+
+	targetID, err := service.IDFromString(ps.ByName("id"))
+	if err != nil {
+		return nil, err
+	}
+
+	return &moderationItemRequest{
+		OrgID:    *orgID,
+		Type:     t,
+		TargetID: *targetID,
+	}, nil
+}
+
+func decodeModerationFilter(ctx context.Context, r *http.Request) (*service.ModerationFilter, error) {
+	query := r.URL.Query()
+	filter := &service.ModerationFilter{}
+
+	if orgID := query.Get(OrgID); orgID != "" {
+		id, err := service.IDFromString(orgID)
+		if err != nil {
+			return nil, err
+		}
+		filter.OrgID = id
+	}
+
+	if typ := query.Get("type"); typ != "" {
+		t := service.ReportType(typ)
+		if err := t.Valid(); err != nil {
+			return nil, err
+		}
+		filter.Type = &t
+	}
+
+	if status := query.Get("status"); status != "" {
+		s := service.ModerationStatus(status)
+		if s != service.ModerationOpen && s != service.ModerationResolved {
+			return nil, &errors.Error{
+				Code: errors.Invalid,
+				Msg:  fmt.Sprintf("unknown moderation status %q", status),
+			}
+		}
+		filter.Status = &s
+	}
+	return filter, nil
+}
+
+func (mh *ModerationHandler) handlePostReport(rw http.ResponseWriter, r *http.Request, ps httprouter.Params) {
+	ctx := r.Context()
+
+	auth, err := icontext.GetAuthorizer(ctx)
+	if err != nil {
+		EncodeError(ctx, err, rw)
+		return
+	}
+
+	body := &postReportRequest{}
+	if err := json.NewDecoder(r.Body).Decode(body); err != nil {
+		EncodeError(ctx, &errors.Error{
+			Code: errors.Invalid,
+			Msg:  "invalid json structure",
+			Err:  err,
+		}, rw)
+		return
+	}
+
+	report := &service.Report{
+		Type:     body.Type,
+		TargetID: body.TargetID,
+		OrgID:    body.OrgID,
+		UserID:   auth.GetUserID(),
+		Reason:   body.Reason,
+	}
+	if err := report.Valid(); err != nil {
+		EncodeError(ctx, err, rw)
+		return
+	}
+
+	m, err := mh.ModerationService.Report(ctx, report)
+	if err != nil {
+		EncodeError(ctx, err, rw)
+		return
+	}
+
+	if err := encodeResponse(ctx, rw, http.StatusCreated, newModerationItemResponse(m)); err != nil {
+		LogEncodeError(mh.Logger, r, err)
+		return
+	}
+}
+
+func (mh *ModerationHandler) handleGetQueue(rw http.ResponseWriter, r *http.Request, ps httprouter.Params) {
+	ctx := r.Context()
+
+	filter, err := decodeModerationFilter(ctx, r)
+	if err != nil {
+		EncodeError(ctx, err, rw)
+		return
+	}
+
+	opts, err := decodeFindOptions(ctx, r)
+	if err != nil {
+		EncodeError(ctx, err, rw)
+		return
+	}
+
+	ms, total, err := mh.ModerationService.FindModerationQueue(ctx, *filter, *opts)
+	if err != nil {
+		EncodeError(ctx, err, rw)
+		return
+	}
+
+	if err := encodeResponse(ctx, rw, http.StatusOK, newModerationQueueResponse(*opts, ms, total)); err != nil {
+		LogEncodeError(mh.Logger, r, err)
+		return
+	}
+}
+
+func (mh *ModerationHandler) handleGetItem(rw http.ResponseWriter, r *http.Request, ps httprouter.Params) {
+	ctx := r.Context()
+
+	req, err := decodeModerationItemRequest(ctx, ps)
+	if err != nil {
+		EncodeError(ctx, err, rw)
+		return
+	}
+
+	m, err := mh.ModerationService.FindModerationItem(ctx, req.OrgID, req.Type, req.TargetID)
+	if err != nil {
+		EncodeError(ctx, err, rw)
+		return
+	}
+
+	if err := encodeResponse(ctx, rw, http.StatusOK, newModerationItemResponse(m)); err != nil {
+		LogEncodeError(mh.Logger, r, err)
+		return
+	}
+}
+
+func (mh *ModerationHandler) handleGetReports(rw http.ResponseWriter, r *http.Request, ps httprouter.Params) {
+	ctx := r.Context()
+
+	req, err := decodeModerationItemRequest(ctx, ps)
+	if err != nil {
+		EncodeError(ctx, err, rw)
+		return
+	}
+
+	rs, err := mh.ModerationService.FindReports(ctx, req.OrgID, req.Type, req.TargetID)
+	if err != nil {
+		EncodeError(ctx, err, rw)
+		return
+	}
+
+	if err := encodeResponse(ctx, rw, http.StatusOK, &reportsResponse{Reports: rs}); err != nil {
+		LogEncodeError(mh.Logger, r, err)
+		return
+	}
+}
+
+func (mh *ModerationHandler) handleResolve(rw http.ResponseWriter, r *http.Request, ps httprouter.Params) {
+	ctx := r.Context()
+
+	req, err := decodeModerationItemRequest(ctx, ps)
+	if err != nil {
+		EncodeError(ctx, err, rw)
+		return
+	}
+
+	auth, err := icontext.GetAuthorizer(ctx)
+	if err != nil {
+		EncodeError(ctx, err, rw)
+		return
+	}
+
+	body := &resolveRequest{}
+	if err := json.NewDecoder(r.Body).Decode(body); err != nil {
+		EncodeError(ctx, &errors.Error{
+			Code: errors.Invalid,
+			Msg:  "invalid json structure",
+			Err:  err,
+		}, rw)
+		return
+	}
+
+	res := &service.Resolution{
+		Action:      body.Action,
+		Reason:      body.Reason,
+		ModeratorID: auth.GetUserID(),
+	}
+	m, err := mh.ModerationService.ResolveReports(ctx, req.OrgID, req.Type, req.TargetID, res)
+	if err != nil {
+		EncodeError(ctx, err, rw)
+		return
+	}
+
+	if err := encodeResponse(ctx, rw, http.StatusOK, newModerationItemResponse(m)); err != nil {
+		LogEncodeError(mh.Logger, r, err)
+		return
+	}
+}
@@ -0,0 +1,310 @@
+package http
+
+import (
+	"fmt"
+	"net/http"
+	"strconv"
+
+	"github.com/julienschmidt/httprouter"
+	"go.uber.org/zap"
+
+	icontext "github.com/ustackq/indagate/pkg/context"
+	"github.com/ustackq/indagate/pkg/service"
+	"github.com/ustackq/indagate/pkg/utils/errors"
+)
+
+// NotificationBackend is all services and associated parameters required to construct
+// the NotificationHandler.
+type NotificationBackend struct {
+	Logger *zap.Logger
+
+	NotificationService service.NotificationService
+}
+
+// NewNotificationBackend returns a new instance of NotificationBackend.
+func NewNotificationBackend(ab *APIBackend) *NotificationBackend {
+	return &NotificationBackend{
+		Logger: ab.Logger.With(zap.String("handler", "notification")),
+
+		NotificationService: ab.NotificationService,
+	}
+}
+
+// NotificationHandler represents an HTTP API handler for the notifications of the current user.
+type NotificationHandler struct {
+	*httprouter.Router
+	Logger *zap.Logger
+
+	NotificationService service.NotificationService
+}
+
+const (
+	// notificationsPrefix is shared by the notification and the settings paths.
+	notificationsPrefix        = "/api/v1/me/notification"
+	meNotificationsPath        = "/api/v1/me/notifications"
+	meNotificationsIDPath      = "/api/v1/me/notifications/:id"
+	meNotificationSettingsPath = "/api/v1/me/notification-settings"
+)
+
+// NewNotificationHandler returns a new instance of NotificationHandler.
+func NewNotificationHandler(nb *NotificationBackend) *NotificationHandler {
+	nh := &NotificationHandler{
+		Router: NewRouter(),
+		Logger: nb.Logger,
+
+		NotificationService: nb.NotificationService,
+	}
+
+	nh.GET(meNotificationsPath, nh.handleGetNotifications)
+	nh.PATCH(meNotificationsPath, nh.handlePatchNotifications)
+	nh.GET(meNotificationsIDPath, nh.handleGetNotification)
+	nh.PATCH(meNotificationsIDPath, nh.handlePatchNotification)
+	nh.GET(meNotificationSettingsPath, nh.handleGetNotificationSettings)
+	nh.PUT(meNotificationSettingsPath, nh.handlePutNotificationSettings)
+
+	return nh
+}
+
+type notificationResponse struct {
+	Links map[string]string `json:"links"`
+	service.Notification
+}
+
+func newNotificationResponse(n *service.Notification) *notificationResponse {
+	links := map[string]string{
+		"self":   fmt.Sprintf("/api/v1/me/notifications/%s", n.ID),
+		"sender": fmt.Sprintf("/api/v1/users/%s", n.SenderID),
+	}
+	if n.ItemType != "" {
+		links["item"] = fmt.Sprintf("/api/v1/%s/%s", n.ItemType, n.ItemID)
+	}
+
+	return &notificationResponse{
+		Links:        links,
+		Notification: *n,
+	}
+}
+
+type notificationsResponse struct {
+	Links         map[string]string       `json:"links"`
+	Notifications []*notificationResponse `json:"notifications"`
+	Total         int                     `json:"total"`
+}
+
+func newNotificationsResponse(opts service.FindOptions, ns []*service.Notification, total int) *notificationsResponse {
+	res := &notificationsResponse{
+		Links:         pagingLinks(meNotificationsPath, opts, len(ns), total),
+		Notifications: make([]*notificationResponse, 0, len(ns)),
+		Total:         total,
+	}
+
+	for _, n := range ns {
+		res.Notifications = append(res.Notifications, newNotificationResponse(n))
+	}
+	return res
+}
+
+func (nh *NotificationHandler) handleGetNotifications(rw http.ResponseWriter, r *http.Request, ps httprouter.Params) {
+	ctx := r.Context()
+
+	auth, err := icontext.GetAuthorizer(ctx)
+	if err != nil {
+		EncodeError(ctx, err, rw)
+		return
+	}
+
+	filter := service.NotificationFilter{RecipientID: auth.GetUserID()}
+	if read := r.URL.Query().Get("read"); read != "" {
+		b, err := strconv.ParseBool(read)
+		if err != nil {
+			EncodeError(ctx, &errors.Error{
+				Code: errors.Invalid,
+				Msg:  "read must be a boolean",
+				Err:  err,
+			}, rw)
+			return
+		}
+		filter.Read = &b
+	}
+
+	opts, err := decodeFindOptions(ctx, r)
+	if err != nil {
+		EncodeError(ctx, err, rw)
+		return
+	}
+
+	ns, total, err := nh.NotificationService.FindNotifications(ctx, filter, *opts)
+	if err != nil {
+		EncodeError(ctx, err, rw)
+		return
+	}
+
+	if err := encodeResponse(ctx, rw, http.StatusOK, newNotificationsResponse(*opts, ns, total)); err != nil {
+		LogEncodeError(nh.Logger, r, err)
+		return
+	}
+}
+
+type patchNotificationRequest struct {
+	Read bool `json:"read"`
+}
+
+// decodePatchNotificationRequest only accepts marking notifications as read.
+func decodePatchNotificationRequest(r *http.Request) error {
+	req := &patchNotificationRequest{}
+	if err := json.NewDecoder(r.Body).Decode(req); err != nil {
+		return &errors.Error{
+			Code: errors.Invalid,
+			Msg:  "invalid json structure",
+			Err:  err,
+		}
+	}
+
+	if !req.Read {
+		return &errors.Error{
+			Code: errors.Invalid,
+			Msg:  "notifications can only be marked as read",
+		}
+	}
+	return nil
+}
+
+type markNotificationsResponse struct {
+	Marked int `json:"marked"`
+}
+
+// handlePatchNotifications marks all notifications of the current user as read.
+func (nh *NotificationHandler) handlePatchNotifications(rw http.ResponseWriter, r *http.Request, ps httprouter.Params) {
+	ctx := r.Context()
+
+	if err := decodePatchNotificationRequest(r); err != nil {
+		EncodeError(ctx, err, rw)
+		return
+	}
+
+	auth, err := icontext.GetAuthorizer(ctx)
+	if err != nil {
+		EncodeError(ctx, err, rw)
+		return
+	}
+
+	n, err := nh.NotificationService.MarkAllNotificationsRead(ctx, auth.GetUserID())
+	if err != nil {
+		EncodeError(ctx, err, rw)
+		return
+	}
+
+	if err := encodeResponse(ctx, rw, http.StatusOK, &markNotificationsResponse{Marked: n}); err != nil {
+		LogEncodeError(nh.Logger, r, err)
+		return
+	}
+}
+
+func (nh *NotificationHandler) handleGetNotification(rw http.ResponseWriter, r *http.Request, ps httprouter.Params) {
+	ctx := r.Context()
+
+	req, err := decodeRequest(r, ps)
+	if err != nil {
+		EncodeError(ctx, err, rw)
+		return
+	}
+
+	n, err := nh.NotificationService.FindNotificationByID(ctx, req.ID)
+	if err != nil {
+		EncodeError(ctx, err, rw)
+		return
+	}
+
+	if err := encodeResponse(ctx, rw, http.StatusOK, newNotificationResponse(n)); err != nil {
+		LogEncodeError(nh.Logger, r, err)
+		return
+	}
+}
+
+func (nh *NotificationHandler) handlePatchNotification(rw http.ResponseWriter, r *http.Request, ps httprouter.Params) {
+	ctx := r.Context()
+
+	req, err := decodeRequest(r, ps)
+	if err != nil {
+		EncodeError(ctx, err, rw)
+		return
+	}
+
+	if err := decodePatchNotificationRequest(r); err != nil {
+		EncodeError(ctx, err, rw)
+		return
+	}
+
+	n, err := nh.NotificationService.MarkNotificationRead(ctx, req.ID)
+	if err != nil {
+		EncodeError(ctx, err, rw)
+		return
+	}
+
+	if err := encodeResponse(ctx, rw, http.StatusOK, newNotificationResponse(n)); err != nil {
+		LogEncodeError(nh.Logger, r, err)
+		return
+	}
+}
+
+func (nh *NotificationHandler) handleGetNotificationSettings(rw http.ResponseWriter, r *http.Request, ps httprouter.Params) {
+	ctx := r.Context()
+
+	auth, err := icontext.GetAuthorizer(ctx)
+	if err != nil {
+		EncodeError(ctx, err, rw)
+		return
+	}
+
+	settings, err := nh.NotificationService.FindNotificationSettings(ctx, auth.GetUserID())
+	if err != nil {
+		EncodeError(ctx, err, rw)
+		return
+	}
+
+	if err := encodeResponse(ctx, rw, http.StatusOK, settings); err != nil {
+		LogEncodeError(nh.Logger, r, err)
+		return
+	}
+}
+
+type putNotificationSettingsRequest struct {
+	Disabled []service.NotificationAction `json:"disabled"`
+}
+
+func (nh *NotificationHandler) handlePutNotificationSettings(rw http.ResponseWriter, r *http.Request, ps httprouter.Params) {
+	ctx := r.Context()
+
+	req := &putNotificationSettingsRequest{}
+	if err := json.NewDecoder(r.Body).Decode(req); err != nil {
+		EncodeError(ctx, &errors.Error{
+			Code: errors.Invalid,
+			Msg:  "invalid json structure",
+			Err:  err,
+		}, rw)
+		return
+	}
+
+	auth, err := icontext.GetAuthorizer(ctx)
+	if err != nil {
+		EncodeError(ctx, err, rw)
+		return
+	}
+
+	settings := &service.NotificationSettings{
+		UserID:   auth.GetUserID(),
+		Disabled: req.Disabled,
+	}
+	if settings.Disabled == nil {
+		settings.Disabled = []service.NotificationAction{}
+	}
+	if err := nh.NotificationService.UpdateNotificationSettings(ctx, settings); err != nil {
+		EncodeError(ctx, err, rw)
+		return
+	}
+
+	if err := encodeResponse(ctx, rw, http.StatusOK, settings); err != nil {
+		LogEncodeError(nh.Logger, r, err)
+		return
+	}
+}
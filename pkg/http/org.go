@@ -17,6 +17,7 @@ type OrgBackend struct {
 	OrganizationService        service.OrganizationService
 	UserResourceMappingService service.UserResourceMappingService
 	UserService                service.UserService
+	RoleService                service.RoleService
 }
 
 func NewOrgBackend(ab *APIBackend) *OrgBackend {
@@ -26,6 +27,7 @@ func NewOrgBackend(ab *APIBackend) *OrgBackend {
 		OrganizationService:        ab.OrganizationService,
 		UserResourceMappingService: ab.UserResourceMappingService,
 		UserService:                ab.UserService,
+		RoleService:                ab.RoleService,
 	}
 }
 
@@ -89,6 +91,14 @@ func NewOrgHandler(ab *OrgBackend) *OrgHandler {
 	oh.GET(orgIDOwnersPath, newGetMembersHandler(ownerBackend))
 	oh.DELETE(orgIDOwnersIDPath, newDeleteMemberHandler(ownerBackend))
 
+	roleBackend := RoleBackend{
+		Logger:      ab.Logger.With(zap.String("handler", "role")),
+		RoleService: ab.RoleService,
+	}
+
+	oh.GET(orgIDRolesPath, newGetRolesHandler(roleBackend))
+	oh.PUT(orgIDRolesIDPath, newPutRoleHandler(roleBackend))
+
 	return oh
 }
 
@@ -103,6 +113,7 @@ func newOrgResponse(org *service.Organization) *orgResponse {
 			"self":    fmt.Sprintf("/api/v1/orgs/%s", org.ID),
 			"members": fmt.Sprintf("/api/v1/orgs/%s/members", org.ID),
 			"owners":  fmt.Sprintf("/api/v1/orgs/%s/owners", org.ID),
+			"roles":   fmt.Sprintf("/api/v1/orgs/%s/roles", org.ID),
 		},
 		Organization: *org,
 	}
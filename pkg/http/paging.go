@@ -0,0 +1,95 @@
+package http
+
+import (
+	"context"
+	"net/http"
+	"strconv"
+
+	"github.com/ustackq/indagate/pkg/service"
+	"github.com/ustackq/indagate/pkg/utils/errors"
+)
+
+const (
+	// DefaultPageSize is the page size used when the request does not provide a limit.
+	DefaultPageSize = 20
+	// MaxPageSize is the largest page size a request can ask for.
+	MaxPageSize = 100
+)
+
+// decodeFindOptions returns the find options from the limit, offset,
+// sortBy and descending query parameters.
+func decodeFindOptions(ctx context.Context, r *http.Request) (*service.FindOptions, error) {
+	opts := &service.FindOptions{
+		Limit: DefaultPageSize,
+	}
+	query := r.URL.Query()
+
+	if limit := query.Get("limit"); limit != "" {
+		l, err := strconv.Atoi(limit)
+		if err != nil || l < 1 || l > MaxPageSize {
+			return nil, &errors.Error{
+				Code: errors.Invalid,
+				Msg:  "limit must be between 1 and 100",
+				Err:  err,
+			}
+		}
+		opts.Limit = int64(l)
+	}
+
+	if offset := query.Get("offset"); offset != "" {
+		o, err := strconv.Atoi(offset)
+		if err != nil || o < 0 {
+			return nil, &errors.Error{
+				Code: errors.Invalid,
+				Msg:  "offset must be a positive number",
+				Err:  err,
+			}
+		}
+		opts.Offset = int64(o)
+	}
+
+	if sortBy := query.Get("sortBy"); sortBy != "" {
+		opts.SortBy = sortBy
+	}
+
+	if descending := query.Get("descending"); descending != "" {
+		desc, err := strconv.ParseBool(descending)
+		if err != nil {
+			return nil, &errors.Error{
+				Code: errors.Invalid,
+				Msg:  "descending must be a boolean",
+				Err:  err,
+			}
+		}
+		opts.Descending = desc
+	}
+
+	return opts, nil
+}
+
+// pagingLinks returns the self, prev and next links of a paged resource.
+func pagingLinks(basePath string, opts service.FindOptions, n, total int) map[string]string {
+	link := func(offset int64) string {
+		return basePath + "?limit=" + strconv.FormatInt(opts.Limit, 10) +
+			"&offset=" + strconv.FormatInt(offset, 10) +
+			"&sortBy=" + opts.SortBy +
+			"&descending=" + strconv.FormatBool(opts.Descending)
+	}
+
+	links := map[string]string{
+		"self": link(opts.Offset),
+	}
+
+	if opts.Offset > 0 {
+		prev := opts.Offset - opts.Limit
+		if prev < 0 {
+			prev = 0
+		}
+		links["prev"] = link(prev)
+	}
+
+	if next := opts.Offset + int64(n); next < int64(total) {
+		links["next"] = link(next)
+	}
+	return links
+}
@@ -0,0 +1,351 @@
+package http
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"strconv"
+
+	"github.com/julienschmidt/httprouter"
+	"go.uber.org/zap"
+
+	icontext "github.com/ustackq/indagate/pkg/context"
+	"github.com/ustackq/indagate/pkg/service"
+	"github.com/ustackq/indagate/pkg/utils/errors"
+)
+
+// QuestionBackend is all services and associated parameters required to construct
+// the QuestionHandler.
+type QuestionBackend struct {
+	Logger *zap.Logger
+
+	QuestionService service.QuestionService
+	AnswerService   service.AnswerService
+}
+
+// NewQuestionBackend returns a new instance of QuestionBackend.
+func NewQuestionBackend(ab *APIBackend) *QuestionBackend {
+	return &QuestionBackend{
+		Logger: ab.Logger.With(zap.String("handler", "question")),
+
+		QuestionService: ab.QuestionService,
+		AnswerService:   ab.AnswerService,
+	}
+}
+
+// QuestionHandler represents an HTTP API handler for questions.
+type QuestionHandler struct {
+	*httprouter.Router
+	Logger *zap.Logger
+
+	QuestionService service.QuestionService
+}
+
+const (
+	questionsPath       = "/api/v1/questions"
+	questionsIDPath     = "/api/v1/questions/:id"
+	questionsIDLockPath = "/api/v1/questions/:id/lock"
+)
+
+// NewQuestionHandler returns a new instance of QuestionHandler.
+func NewQuestionHandler(qb *QuestionBackend) *QuestionHandler {
+	qh := &QuestionHandler{
+		Router: NewRouter(),
+		Logger: qb.Logger,
+
+		QuestionService: qb.QuestionService,
+	}
+
+	qh.POST(questionsPath, qh.handlePostQuestion)
+	qh.GET(questionsPath, qh.handleGetQuestions)
+	qh.GET(questionsIDPath, qh.handleGetQuestion)
+	qh.PATCH(questionsIDPath, qh.handlePatchQuestion)
+	qh.DELETE(questionsIDPath, qh.handleDeleteQuestion)
+	qh.PUT(questionsIDLockPath, qh.handleLockQuestion)
+	qh.DELETE(questionsIDLockPath, qh.handleUnlockQuestion)
+
+	answerBackend := AnswerBackend{
+		Logger:        qb.Logger.With(zap.String("handler", "answer")),
+		AnswerService: qb.AnswerService,
+	}
+
+	qh.POST(questionsIDAnswersPath, newPostQuestionAnswerHandler(answerBackend))
+	qh.GET(questionsIDAnswersPath, newGetQuestionAnswersHandler(answerBackend))
+	qh.POST(questionsIDBestAnswerPath, newPostBestAnswerHandler(answerBackend))
+
+	return qh
+}
+
+type questionResponse struct {
+	Links map[string]string `json:"links"`
+	service.Question
+}
+
+func newQuestionResponse(q *service.Question) *questionResponse {
+	return &questionResponse{
+		Links: map[string]string{
+			"self":    fmt.Sprintf("/api/v1/questions/%s", q.ID),
+			"answers": fmt.Sprintf("/api/v1/questions/%s/answers", q.ID),
+			"lock":    fmt.Sprintf("/api/v1/questions/%s/lock", q.ID),
+			"org":     fmt.Sprintf("/api/v1/orgs/%s", q.OrgID),
+		},
+		Question: *q,
+	}
+}
+
+type questionsResponse struct {
+	Links     map[string]string   `json:"links"`
+	Questions []*questionResponse `json:"questions"`
+	Total     int                 `json:"total"`
+}
+
+func newQuestionsResponse(opts service.FindOptions, qs []*service.Question, total int) *questionsResponse {
+	res := &questionsResponse{
+		Links:     pagingLinks(questionsPath, opts, len(qs), total),
+		Questions: make([]*questionResponse, 0, len(qs)),
+		Total:     total,
+	}
+
+	for _, q := range qs {
+		res.Questions = append(res.Questions, newQuestionResponse(q))
+	}
+	return res
+}
+
+type postQuestionRequest struct {
+	OrgID     service.ID `json:"orgID"`
+	Content   string     `json:"content"`
+	Detail    string     `json:"detail"`
+	Anonymous bool       `json:"anonymous"`
+}
+
+func decodePostQuestionRequest(ctx context.Context, r *http.Request) (*service.Question, error) {
+	req := &postQuestionRequest{}
+	if err := json.NewDecoder(r.Body).Decode(req); err != nil {
+		return nil, &errors.Error{
+			Code: errors.Invalid,
+			Msg:  "invalid json structure",
+			Err:  err,
+		}
+	}
+
+	a, err := icontext.GetAuthorizer(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	q := &service.Question{
+		OrgID:     req.OrgID,
+		UserID:    a.GetUserID(),
+		Content:   req.Content,
+		Detail:    req.Detail,
+		Anonymous: req.Anonymous,
+	}
+	return q, q.Valid()
+}
+
+func (qh *QuestionHandler) handlePostQuestion(rw http.ResponseWriter, r *http.Request, ps httprouter.Params) {
+	ctx := r.Context()
+
+	q, err := decodePostQuestionRequest(ctx, r)
+	if err != nil {
+		EncodeError(ctx, err, rw)
+		return
+	}
+
+	if err := qh.QuestionService.CreateQuestion(ctx, q); err != nil {
+		EncodeError(ctx, err, rw)
+		return
+	}
+
+	if err := encodeResponse(ctx, rw, http.StatusCreated, newQuestionResponse(q)); err != nil {
+		LogEncodeError(qh.Logger, r, err)
+		return
+	}
+}
+
+type getQuestionsRequest struct {
+	filter service.QuestionFilter
+	opts   service.FindOptions
+}
+
+func decodeGetQuestionsRequest(ctx context.Context, r *http.Request) (*getQuestionsRequest, error) {
+	query := r.URL.Query()
+	req := &getQuestionsRequest{}
+
+	if orgID := query.Get(OrgID); orgID != "" {
+		id, err := service.IDFromString(orgID)
+		if err != nil {
+			return nil, err
+		}
+		req.filter.OrgID = id
+	}
+
+	if userID := query.Get("userID"); userID != "" {
+		id, err := service.IDFromString(userID)
+		if err != nil {
+			return nil, err
+		}
+		req.filter.UserID = id
+	}
+
+	if lock := query.Get("lock"); lock != "" {
+		l, err := strconv.ParseBool(lock)
+		if err != nil {
+			return nil, &errors.Error{
+				Code: errors.Invalid,
+				Msg:  "lock must be a boolean",
+				Err:  err,
+			}
+		}
+		req.filter.Lock = &l
+	}
+
+	opts, err := decodeFindOptions(ctx, r)
+	if err != nil {
+		return nil, err
+	}
+	req.opts = *opts
+
+	return req, nil
+}
+
+func (qh *QuestionHandler) handleGetQuestions(rw http.ResponseWriter, r *http.Request, ps httprouter.Params) {
+	ctx := r.Context()
+
+	req, err := decodeGetQuestionsRequest(ctx, r)
+	if err != nil {
+		EncodeError(ctx, err, rw)
+		return
+	}
+
+	qs, total, err := qh.QuestionService.FindQuestions(ctx, req.filter, req.opts)
+	if err != nil {
+		EncodeError(ctx, err, rw)
+		return
+	}
+
+	if err := encodeResponse(ctx, rw, http.StatusOK, newQuestionsResponse(req.opts, qs, total)); err != nil {
+		LogEncodeError(qh.Logger, r, err)
+		return
+	}
+}
+
+func (qh *QuestionHandler) handleGetQuestion(rw http.ResponseWriter, r *http.Request, ps httprouter.Params) {
+	ctx := r.Context()
+
+	req, err := decodeRequest(r, ps)
+	if err != nil {
+		EncodeError(ctx, err, rw)
+		return
+	}
+
+	q, err := qh.QuestionService.FindQuestionByID(ctx, req.ID)
+	if err != nil {
+		EncodeError(ctx, err, rw)
+		return
+	}
+
+	if err := encodeResponse(ctx, rw, http.StatusOK, newQuestionResponse(q)); err != nil {
+		LogEncodeError(qh.Logger, r, err)
+		return
+	}
+}
+
+type patchQuestionRequest struct {
+	ID     service.ID
+	Update service.QuestionUpdate
+}
+
+func decodePatchQuestionRequest(r *http.Request, ps httprouter.Params) (*patchQuestionRequest, error) {
+	req, err := decodeRequest(r, ps)
+	if err != nil {
+		return nil, err
+	}
+
+	var upd service.QuestionUpdate
+	if err := json.NewDecoder(r.Body).Decode(&upd); err != nil {
+		return nil, &errors.Error{
+			Code: errors.Invalid,
+			Msg:  "invalid json structure",
+			Err:  err,
+		}
+	}
+
+	return &patchQuestionRequest{
+		ID:     req.ID,
+		Update: upd,
+	}, upd.Valid()
+}
+
+func (qh *QuestionHandler) handlePatchQuestion(rw http.ResponseWriter, r *http.Request, ps httprouter.Params) {
+	ctx := r.Context()
+
+	req, err := decodePatchQuestionRequest(r, ps)
+	if err != nil {
+		EncodeError(ctx, err, rw)
+		return
+	}
+
+	q, err := qh.QuestionService.UpdateQuestion(ctx, req.ID, req.Update)
+	if err != nil {
+		EncodeError(ctx, err, rw)
+		return
+	}
+
+	if err := encodeResponse(ctx, rw, http.StatusOK, newQuestionResponse(q)); err != nil {
+		LogEncodeError(qh.Logger, r, err)
+		return
+	}
+}
+
+func (qh *QuestionHandler) handleDeleteQuestion(rw http.ResponseWriter, r *http.Request, ps httprouter.Params) {
+	ctx := r.Context()
+
+	req, err := decodeRequest(r, ps)
+	if err != nil {
+		EncodeError(ctx, err, rw)
+		return
+	}
+
+	if err := qh.QuestionService.DeleteQuestion(ctx, req.ID); err != nil {
+		EncodeError(ctx, err, rw)
+		return
+	}
+
+	rw.WriteHeader(http.StatusNoContent)
+}
+
+func (qh *QuestionHandler) handleLockQuestion(rw http.ResponseWriter, r *http.Request, ps httprouter.Params) {
+	ctx := r.Context()
+
+	req, err := decodeRequest(r, ps)
+	if err != nil {
+		EncodeError(ctx, err, rw)
+		return
+	}
+
+	if err := qh.QuestionService.LockQuestion(ctx, req.ID); err != nil {
+		EncodeError(ctx, err, rw)
+		return
+	}
+
+	rw.WriteHeader(http.StatusNoContent)
+}
+
+func (qh *QuestionHandler) handleUnlockQuestion(rw http.ResponseWriter, r *http.Request, ps httprouter.Params) {
+	ctx := r.Context()
+
+	req, err := decodeRequest(r, ps)
+	if err != nil {
+		EncodeError(ctx, err, rw)
+		return
+	}
+
+	if err := qh.QuestionService.UnlockQuestion(ctx, req.ID); err != nil {
+		EncodeError(ctx, err, rw)
+		return
+	}
+
+	rw.WriteHeader(http.StatusNoContent)
+}
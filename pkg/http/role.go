@@ -0,0 +1,169 @@
+package http
+
+import (
+	"fmt"
+	"net/http"
+
+	"github.com/julienschmidt/httprouter"
+	"go.uber.org/zap"
+
+	"github.com/ustackq/indagate/pkg/service"
+	"github.com/ustackq/indagate/pkg/utils/errors"
+)
+
+// RoleBackend is all services and associated parameters required to construct
+// the role routes of the OrgHandler.
+type RoleBackend struct {
+	Logger *zap.Logger
+
+	RoleService service.RoleService
+}
+
+const (
+	orgIDRolesPath   = "/api/v1/orgs/:id/roles"
+	orgIDRolesIDPath = "/api/v1/orgs/:id/roles/:userID"
+)
+
+type roleResponse struct {
+	Links       map[string]string     `json:"links"`
+	UserID      service.ID            `json:"userID"`
+	Role        service.Role          `json:"role"`
+	Permissions []*service.Permission `json:"permissions"`
+}
+
+func newRoleResponse(m *service.UserResourceMapping) (*roleResponse, error) {
+	role := service.MappingRole(m)
+	ps, err := role.Permissions(m.ResourceID)
+	if err != nil {
+		return nil, err
+	}
+
+	return &roleResponse{
+		Links: map[string]string{
+			"self": fmt.Sprintf("/api/v1/orgs/%s/roles/%s", m.ResourceID, m.UserID),
+			"user": fmt.Sprintf("/api/v1/users/%s", m.UserID),
+		},
+		UserID:      m.UserID,
+		Role:        role,
+		Permissions: ps,
+	}, nil
+}
+
+type rolesResponse struct {
+	Links     map[string]string `json:"links"`
+	Available []service.Role    `json:"available"`
+	Roles     []*roleResponse   `json:"roles"`
+}
+
+func newRolesResponse(orgID service.ID, ms []*service.UserResourceMapping) (*rolesResponse, error) {
+	res := &rolesResponse{
+		Links: map[string]string{
+			"self": fmt.Sprintf("/api/v1/orgs/%s/roles", orgID),
+			"org":  fmt.Sprintf("/api/v1/orgs/%s", orgID),
+		},
+		Available: service.AllRoles,
+		Roles:     make([]*roleResponse, 0, len(ms)),
+	}
+
+	for _, m := range ms {
+		r, err := newRoleResponse(m)
+		if err != nil {
+			return nil, err
+		}
+		res.Roles = append(res.Roles, r)
+	}
+	return res, nil
+}
+
+func newGetRolesHandler(rb RoleBackend) httprouter.Handle {
+	return func(rw http.ResponseWriter, r *http.Request, ps httprouter.Params) {
+		ctx := r.Context()
+
+		req, err := decodeRequest(r, ps)
+		if err != nil {
+			EncodeError(ctx, err, rw)
+			return
+		}
+
+		ms, err := rb.RoleService.FindOrgRoles(ctx, req.ID)
+		if err != nil {
+			EncodeError(ctx, err, rw)
+			return
+		}
+
+		res, err := newRolesResponse(req.ID, ms)
+		if err != nil {
+			EncodeError(ctx, err, rw)
+			return
+		}
+
+		if err := encodeResponse(ctx, rw, http.StatusOK, res); err != nil {
+			LogEncodeError(rb.Logger, r, err)
+			return
+		}
+	}
+}
+
+type putRoleRequest struct {
+	OrgID  service.ID
+	UserID service.ID
+	Role   service.Role `json:"role"`
+}
+
+func decodePutRoleRequest(r *http.Request, ps httprouter.Params) (*putRoleRequest, error) {
+	or, err := decodeRequest(r, ps)
+	if err != nil {
+		return nil, err
+	}
+
+	var uid service.ID
+	if err := uid.DecodeFromString(ps.ByName("userID")); err != nil {
+		return nil, &errors.Error{
+			Code: errors.Invalid,
+			Msg:  "url missing or invalid user id",
+			Err:  err,
+		}
+	}
+
+	req := &putRoleRequest{}
+	if err := json.NewDecoder(r.Body).Decode(req); err != nil {
+		return nil, &errors.Error{
+			Code: errors.Invalid,
+			Msg:  "invalid json structure",
+			Err:  err,
+		}
+	}
+	req.OrgID = or.ID
+	req.UserID = uid
+
+	return req, req.Role.Valid()
+}
+
+func newPutRoleHandler(rb RoleBackend) httprouter.Handle {
+	return func(rw http.ResponseWriter, r *http.Request, ps httprouter.Params) {
+		ctx := r.Context()
+
+		req, err := decodePutRoleRequest(r, ps)
+		if err != nil {
+			EncodeError(ctx, err, rw)
+			return
+		}
+
+		m, err := rb.RoleService.SetUserRole(ctx, req.OrgID, req.UserID, req.Role)
+		if err != nil {
+			EncodeError(ctx, err, rw)
+			return
+		}
+
+		res, err := newRoleResponse(m)
+		if err != nil {
+			EncodeError(ctx, err, rw)
+			return
+		}
+
+		if err := encodeResponse(ctx, rw, http.StatusOK, res); err != nil {
+			LogEncodeError(rb.Logger, r, err)
+			return
+		}
+	}
+}
@@ -0,0 +1,166 @@
+package http
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/url"
+
+	"github.com/julienschmidt/httprouter"
+	"go.uber.org/zap"
+
+	"github.com/ustackq/indagate/pkg/service"
+)
+
+// SearchBackend is all services and associated parameters required to construct
+// the SearchHandler.
+type SearchBackend struct {
+	Logger *zap.Logger
+
+	SearchService service.SearchService
+}
+
+// NewSearchBackend returns a new instance of SearchBackend.
+func NewSearchBackend(ab *APIBackend) *SearchBackend {
+	return &SearchBackend{
+		Logger: ab.Logger.With(zap.String("handler", "search")),
+
+		SearchService: ab.SearchService,
+	}
+}
+
+// SearchHandler represents an HTTP API handler for full-text search.
+type SearchHandler struct {
+	*httprouter.Router
+	Logger *zap.Logger
+
+	SearchService service.SearchService
+}
+
+const (
+	searchPath = "/api/v1/search"
+)
+
+// NewSearchHandler returns a new instance of SearchHandler.
+func NewSearchHandler(sb *SearchBackend) *SearchHandler {
+	sh := &SearchHandler{
+		Router: NewRouter(),
+		Logger: sb.Logger,
+
+		SearchService: sb.SearchService,
+	}
+
+	sh.GET(searchPath, sh.handleSearch)
+
+	return sh
+}
+
+type searchResultResponse struct {
+	Links map[string]string `json:"links"`
+	service.SearchResult
+}
+
+func newSearchResultResponse(r *service.SearchResult) *searchResultResponse {
+	links := map[string]string{
+		"self": fmt.Sprintf("/api/v1/%s/%s", r.Type, r.ID),
+	}
+	if r.QuestionID.Valid() {
+		links["question"] = fmt.Sprintf("/api/v1/questions/%s", r.QuestionID)
+	}
+
+	return &searchResultResponse{
+		Links:        links,
+		SearchResult: *r,
+	}
+}
+
+type searchResponse struct {
+	Links   map[string]string       `json:"links"`
+	Results []*searchResultResponse `json:"results"`
+	Total   int                     `json:"total"`
+}
+
+func newSearchResponse(req *searchRequest, opts service.FindOptions, rs []*service.SearchResult, total int) *searchResponse {
+	// the paging links keep the query of the search.
+	links := pagingLinks(searchPath, opts, len(rs), total)
+	for rel, link := range links {
+		links[rel] = link + "&" + req.params.Encode()
+	}
+
+	res := &searchResponse{
+		Links:   links,
+		Results: make([]*searchResultResponse, 0, len(rs)),
+		Total:   total,
+	}
+
+	for _, r := range rs {
+		res.Results = append(res.Results, newSearchResultResponse(r))
+	}
+	return res
+}
+
+type searchRequest struct {
+	query service.SearchQuery
+	// params are the search parameters of the request.
+	params url.Values
+}
+
+func decodeSearchRequest(ctx context.Context, r *http.Request) (*searchRequest, error) {
+	query := r.URL.Query()
+	req := &searchRequest{
+		params: url.Values{},
+	}
+
+	req.query.Query = query.Get("q")
+	req.params.Set("q", req.query.Query)
+
+	if t := query.Get("type"); t != "" {
+		st := service.SearchType(t)
+		if err := st.Valid(); err != nil {
+			return nil, err
+		}
+		req.query.Type = &st
+		req.params.Set("type", t)
+	}
+
+	if topic := query.Get("topic"); topic != "" {
+		id, err := service.IDFromString(topic)
+		if err != nil {
+			return nil, err
+		}
+		req.query.TopicID = id
+		req.params.Set("topic", topic)
+	}
+
+	if err := req.query.Valid(); err != nil {
+		return nil, err
+	}
+	return req, nil
+}
+
+func (sh *SearchHandler) handleSearch(rw http.ResponseWriter, r *http.Request, ps httprouter.Params) {
+	ctx := r.Context()
+
+	req, err := decodeSearchRequest(ctx, r)
+	if err != nil {
+		EncodeError(ctx, err, rw)
+		return
+	}
+
+	opts, err := decodeFindOptions(ctx, r)
+	if err != nil {
+		EncodeError(ctx, err, rw)
+		return
+	}
+
+	rs, total, err := sh.SearchService.Search(ctx, req.query, *opts)
+	if err != nil {
+		EncodeError(ctx, err, rw)
+		return
+	}
+
+	if err := encodeResponse(ctx, rw, http.StatusOK, newSearchResponse(req, *opts, rs, total)); err != nil {
+		LogEncodeError(sh.Logger, r, err)
+		return
+	}
+}
@@ -107,6 +107,11 @@ func (sh *SessionHandler) handleSignin(rw http.ResponseWriter, r *http.Request)
 
 	s, e := sh.SessionService.CreateSession(ctx, req.Username)
 	if e != nil {
+		// banned users are told why they can not sign in.
+		if errors.ErrorCode(e) == errors.Forbidden {
+			EncodeError(ctx, e, rw)
+			return
+		}
 		UnauthorizedError(ctx, rw)
 		return
 	}
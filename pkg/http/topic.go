@@ -0,0 +1,777 @@
+package http
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+
+	"github.com/julienschmidt/httprouter"
+	"go.uber.org/zap"
+
+	icontext "github.com/ustackq/indagate/pkg/context"
+	"github.com/ustackq/indagate/pkg/service"
+	"github.com/ustackq/indagate/pkg/utils/errors"
+)
+
+// TopicBackend is all services and associated parameters required to construct
+// the TopicHandler.
+type TopicBackend struct {
+	Logger *zap.Logger
+
+	TopicService service.TopicService
+}
+
+// NewTopicBackend returns a new instance of TopicBackend.
+func NewTopicBackend(ab *APIBackend) *TopicBackend {
+	return &TopicBackend{
+		Logger: ab.Logger.With(zap.String("handler", "topic")),
+
+		TopicService: ab.TopicService,
+	}
+}
+
+// TopicHandler represents an HTTP API handler for topics.
+type TopicHandler struct {
+	*httprouter.Router
+	Logger *zap.Logger
+
+	TopicService service.TopicService
+}
+
+const (
+	topicsPath                = "/api/v1/topics"
+	topicsIDPath              = "/api/v1/topics/:id"
+	topicsIDChildrenPath      = "/api/v1/topics/:id/children"
+	topicsIDPathPath          = "/api/v1/topics/:id/path"
+	topicsIDMergePath         = "/api/v1/topics/:id/merge"
+	topicsIDMergesPath        = "/api/v1/topics/:id/merges"
+	topicsIDRelationsPath     = "/api/v1/topics/:id/relations"
+	topicsIDRelationsItemPath = "/api/v1/topics/:id/relations/:itemType/:itemID"
+	topicsIDFocusPath         = "/api/v1/topics/:id/focus"
+	meTopicsPath              = "/api/v1/me/topics"
+)
+
+// NewTopicHandler returns a new instance of TopicHandler.
+func NewTopicHandler(tb *TopicBackend) *TopicHandler {
+	th := &TopicHandler{
+		Router: NewRouter(),
+		Logger: tb.Logger,
+
+		TopicService: tb.TopicService,
+	}
+
+	th.POST(topicsPath, th.handlePostTopic)
+	th.GET(topicsPath, th.handleGetTopics)
+	th.GET(topicsIDPath, th.handleGetTopic)
+	th.PATCH(topicsIDPath, th.handlePatchTopic)
+	th.DELETE(topicsIDPath, th.handleDeleteTopic)
+	th.GET(topicsIDChildrenPath, th.handleGetTopicChildren)
+	th.GET(topicsIDPathPath, th.handleGetTopicPath)
+	th.POST(topicsIDMergePath, th.handlePostTopicMerge)
+	th.GET(topicsIDMergesPath, th.handleGetTopicMerges)
+	th.GET(topicsIDRelationsPath, th.handleGetTopicRelations)
+	th.POST(topicsIDRelationsPath, th.handlePostTopicRelation)
+	th.DELETE(topicsIDRelationsItemPath, th.handleDeleteTopicRelation)
+	th.GET(topicsIDFocusPath, th.handleGetTopicFocus)
+	th.PUT(topicsIDFocusPath, th.handlePutTopicFocus)
+	th.DELETE(topicsIDFocusPath, th.handleDeleteTopicFocus)
+	th.GET(meTopicsPath, th.handleGetFocusedTopics)
+
+	return th
+}
+
+type topicResponse struct {
+	Links map[string]string `json:"links"`
+	service.Topic
+}
+
+func newTopicResponse(t *service.Topic) *topicResponse {
+	res := &topicResponse{
+		Links: map[string]string{
+			"self":      fmt.Sprintf("/api/v1/topics/%s", t.ID),
+			"children":  fmt.Sprintf("/api/v1/topics/%s/children", t.ID),
+			"path":      fmt.Sprintf("/api/v1/topics/%s/path", t.ID),
+			"relations": fmt.Sprintf("/api/v1/topics/%s/relations", t.ID),
+			"focus":     fmt.Sprintf("/api/v1/topics/%s/focus", t.ID),
+			"org":       fmt.Sprintf("/api/v1/orgs/%s", t.OrgID),
+		},
+		Topic: *t,
+	}
+
+	if t.ParentID.Valid() {
+		res.Links["parent"] = fmt.Sprintf("/api/v1/topics/%s", t.ParentID)
+	}
+	return res
+}
+
+type topicsResponse struct {
+	Links  map[string]string `json:"links"`
+	Topics []*topicResponse  `json:"topics"`
+	Total  int               `json:"total"`
+}
+
+func newTopicsResponse(basePath string, opts service.FindOptions, ts []*service.Topic, total int) *topicsResponse {
+	res := &topicsResponse{
+		Links:  pagingLinks(basePath, opts, len(ts), total),
+		Topics: make([]*topicResponse, 0, len(ts)),
+		Total:  total,
+	}
+
+	for _, t := range ts {
+		res.Topics = append(res.Topics, newTopicResponse(t))
+	}
+	return res
+}
+
+// decodeTopicFindOptions returns the find options of a topic listing,
+// topics are sorted by title unless asked otherwise.
+func decodeTopicFindOptions(ctx context.Context, r *http.Request) (*service.FindOptions, error) {
+	opts, err := decodeFindOptions(ctx, r)
+	if err != nil {
+		return nil, err
+	}
+
+	switch opts.SortBy {
+	case "":
+		opts.SortBy = service.TopicSortByTitle
+	case service.TopicSortByTitle, service.TopicSortByDiscussions, service.TopicSortByFocus:
+	default:
+		return nil, &errors.Error{
+			Code: errors.Invalid,
+			Msg: fmt.Sprintf("topics can only be sorted by %s, %s or %s",
+				service.TopicSortByTitle, service.TopicSortByDiscussions, service.TopicSortByFocus),
+		}
+	}
+	return opts, nil
+}
+
+type postTopicRequest struct {
+	OrgID       service.ID `json:"orgID"`
+	Title       string     `json:"title"`
+	Description string     `json:"description"`
+	Avatar      string     `json:"avatar"`
+	URLToken    string     `json:"urlToken"`
+	SEOTitle    string     `json:"seoTitle"`
+	ParentID    service.ID `json:"parentID"`
+}
+
+func decodePostTopicRequest(ctx context.Context, r *http.Request) (*service.Topic, error) {
+	req := &postTopicRequest{}
+	if err := json.NewDecoder(r.Body).Decode(req); err != nil {
+		return nil, &errors.Error{
+			Code: errors.Invalid,
+			Msg:  "invalid json structure",
+			Err:  err,
+		}
+	}
+
+	t := &service.Topic{
+		OrgID:       req.OrgID,
+		Title:       req.Title,
+		Description: req.Description,
+		Avatar:      req.Avatar,
+		URLToken:    req.URLToken,
+		SEOTitle:    req.SEOTitle,
+		ParentID:    req.ParentID,
+	}
+	return t, t.Valid()
+}
+
+func (th *TopicHandler) handlePostTopic(rw http.ResponseWriter, r *http.Request, ps httprouter.Params) {
+	ctx := r.Context()
+
+	t, err := decodePostTopicRequest(ctx, r)
+	if err != nil {
+		EncodeError(ctx, err, rw)
+		return
+	}
+
+	if err := th.TopicService.CreateTopic(ctx, t); err != nil {
+		EncodeError(ctx, err, rw)
+		return
+	}
+
+	if err := encodeResponse(ctx, rw, http.StatusCreated, newTopicResponse(t)); err != nil {
+		LogEncodeError(th.Logger, r, err)
+		return
+	}
+}
+
+type getTopicsRequest struct {
+	filter service.TopicFilter
+	opts   service.FindOptions
+}
+
+func decodeGetTopicsRequest(ctx context.Context, r *http.Request) (*getTopicsRequest, error) {
+	query := r.URL.Query()
+	req := &getTopicsRequest{}
+
+	if orgID := query.Get(OrgID); orgID != "" {
+		id, err := service.IDFromString(orgID)
+		if err != nil {
+			return nil, err
+		}
+		req.filter.OrgID = id
+	}
+
+	if parentID := query.Get("parentID"); parentID != "" {
+		id, err := service.IDFromString(parentID)
+		if err != nil {
+			return nil, err
+		}
+		req.filter.ParentID = id
+	}
+
+	if token := query.Get("urlToken"); token != "" {
+		req.filter.URLToken = &token
+	}
+
+	opts, err := decodeTopicFindOptions(ctx, r)
+	if err != nil {
+		return nil, err
+	}
+	req.opts = *opts
+
+	return req, nil
+}
+
+func (th *TopicHandler) handleGetTopics(rw http.ResponseWriter, r *http.Request, ps httprouter.Params) {
+	ctx := r.Context()
+
+	req, err := decodeGetTopicsRequest(ctx, r)
+	if err != nil {
+		EncodeError(ctx, err, rw)
+		return
+	}
+
+	ts, total, err := th.TopicService.FindTopics(ctx, req.filter, req.opts)
+	if err != nil {
+		EncodeError(ctx, err, rw)
+		return
+	}
+
+	if err := encodeResponse(ctx, rw, http.StatusOK, newTopicsResponse(topicsPath, req.opts, ts, total)); err != nil {
+		LogEncodeError(th.Logger, r, err)
+		return
+	}
+}
+
+// handleGetTopic redirects to the topic a merged topic was merged into.
+func (th *TopicHandler) handleGetTopic(rw http.ResponseWriter, r *http.Request, ps httprouter.Params) {
+	ctx := r.Context()
+
+	req, err := decodeRequest(r, ps)
+	if err != nil {
+		EncodeError(ctx, err, rw)
+		return
+	}
+
+	t, err := th.TopicService.FindTopicByID(ctx, req.ID)
+	if err != nil {
+		EncodeError(ctx, err, rw)
+		return
+	}
+
+	if t.MergedID.Valid() {
+		http.Redirect(rw, r, fmt.Sprintf("/api/v1/topics/%s", t.MergedID), http.StatusMovedPermanently)
+		return
+	}
+
+	if err := encodeResponse(ctx, rw, http.StatusOK, newTopicResponse(t)); err != nil {
+		LogEncodeError(th.Logger, r, err)
+		return
+	}
+}
+
+func (th *TopicHandler) handlePatchTopic(rw http.ResponseWriter, r *http.Request, ps httprouter.Params) {
+	ctx := r.Context()
+
+	req, err := decodeRequest(r, ps)
+	if err != nil {
+		EncodeError(ctx, err, rw)
+		return
+	}
+
+	var upd service.TopicUpdate
+	if err := json.NewDecoder(r.Body).Decode(&upd); err != nil {
+		EncodeError(ctx, &errors.Error{
+			Code: errors.Invalid,
+			Msg:  "invalid json structure",
+			Err:  err,
+		}, rw)
+		return
+	}
+
+	if err := upd.Valid(); err != nil {
+		EncodeError(ctx, err, rw)
+		return
+	}
+
+	t, err := th.TopicService.UpdateTopic(ctx, req.ID, upd)
+	if err != nil {
+		EncodeError(ctx, err, rw)
+		return
+	}
+
+	if err := encodeResponse(ctx, rw, http.StatusOK, newTopicResponse(t)); err != nil {
+		LogEncodeError(th.Logger, r, err)
+		return
+	}
+}
+
+func (th *TopicHandler) handleDeleteTopic(rw http.ResponseWriter, r *http.Request, ps httprouter.Params) {
+	ctx := r.Context()
+
+	req, err := decodeRequest(r, ps)
+	if err != nil {
+		EncodeError(ctx, err, rw)
+		return
+	}
+
+	if err := th.TopicService.DeleteTopic(ctx, req.ID); err != nil {
+		EncodeError(ctx, err, rw)
+		return
+	}
+
+	rw.WriteHeader(http.StatusNoContent)
+}
+
+func (th *TopicHandler) handleGetTopicChildren(rw http.ResponseWriter, r *http.Request, ps httprouter.Params) {
+	ctx := r.Context()
+
+	req, err := decodeRequest(r, ps)
+	if err != nil {
+		EncodeError(ctx, err, rw)
+		return
+	}
+
+	opts, err := decodeTopicFindOptions(ctx, r)
+	if err != nil {
+		EncodeError(ctx, err, rw)
+		return
+	}
+
+	// the parent is looked up first, so that a missing topic is not reported as a leaf.
+	if _, err := th.TopicService.FindTopicByID(ctx, req.ID); err != nil {
+		EncodeError(ctx, err, rw)
+		return
+	}
+
+	ts, total, err := th.TopicService.FindTopics(ctx, service.TopicFilter{ParentID: &req.ID}, *opts)
+	if err != nil {
+		EncodeError(ctx, err, rw)
+		return
+	}
+
+	basePath := fmt.Sprintf("/api/v1/topics/%s/children", req.ID)
+	if err := encodeResponse(ctx, rw, http.StatusOK, newTopicsResponse(basePath, *opts, ts, total)); err != nil {
+		LogEncodeError(th.Logger, r, err)
+		return
+	}
+}
+
+type topicPathResponse struct {
+	Links map[string]string `json:"links"`
+	Path  []*topicResponse  `json:"path"`
+}
+
+func (th *TopicHandler) handleGetTopicPath(rw http.ResponseWriter, r *http.Request, ps httprouter.Params) {
+	ctx := r.Context()
+
+	req, err := decodeRequest(r, ps)
+	if err != nil {
+		EncodeError(ctx, err, rw)
+		return
+	}
+
+	ts, err := th.TopicService.FindTopicPath(ctx, req.ID)
+	if err != nil {
+		EncodeError(ctx, err, rw)
+		return
+	}
+
+	res := &topicPathResponse{
+		Links: map[string]string{
+			"self": fmt.Sprintf("/api/v1/topics/%s/path", req.ID),
+		},
+		Path: make([]*topicResponse, 0, len(ts)),
+	}
+	for _, t := range ts {
+		res.Path = append(res.Path, newTopicResponse(t))
+	}
+
+	if err := encodeResponse(ctx, rw, http.StatusOK, res); err != nil {
+		LogEncodeError(th.Logger, r, err)
+		return
+	}
+}
+
+type postTopicMergeRequest struct {
+	TargetID service.ID `json:"targetID"`
+}
+
+// handlePostTopicMerge merges the topic of the path into the target of the request.
+func (th *TopicHandler) handlePostTopicMerge(rw http.ResponseWriter, r *http.Request, ps httprouter.Params) {
+	ctx := r.Context()
+
+	sr, err := decodeRequest(r, ps)
+	if err != nil {
+		EncodeError(ctx, err, rw)
+		return
+	}
+
+	req := &postTopicMergeRequest{}
+	if err := json.NewDecoder(r.Body).Decode(req); err != nil {
+		EncodeError(ctx, &errors.Error{
+			Code: errors.Invalid,
+			Msg:  "invalid json structure",
+			Err:  err,
+		}, rw)
+		return
+	}
+
+	if !req.TargetID.Valid() {
+		EncodeError(ctx, &errors.Error{
+			Code: errors.Invalid,
+			Msg:  "target id required",
+		}, rw)
+		return
+	}
+
+	auth, err := icontext.GetAuthorizer(ctx)
+	if err != nil {
+		EncodeError(ctx, err, rw)
+		return
+	}
+
+	t, err := th.TopicService.MergeTopic(ctx, sr.ID, req.TargetID, auth.GetUserID())
+	if err != nil {
+		EncodeError(ctx, err, rw)
+		return
+	}
+
+	if err := encodeResponse(ctx, rw, http.StatusOK, newTopicResponse(t)); err != nil {
+		LogEncodeError(th.Logger, r, err)
+		return
+	}
+}
+
+type topicMergesResponse struct {
+	Links  map[string]string     `json:"links"`
+	Merges []*service.TopicMerge `json:"merges"`
+}
+
+func (th *TopicHandler) handleGetTopicMerges(rw http.ResponseWriter, r *http.Request, ps httprouter.Params) {
+	ctx := r.Context()
+
+	req, err := decodeRequest(r, ps)
+	if err != nil {
+		EncodeError(ctx, err, rw)
+		return
+	}
+
+	ms, err := th.TopicService.FindTopicMerges(ctx, req.ID)
+	if err != nil {
+		EncodeError(ctx, err, rw)
+		return
+	}
+
+	res := &topicMergesResponse{
+		Links: map[string]string{
+			"self":  fmt.Sprintf("/api/v1/topics/%s/merges", req.ID),
+			"topic": fmt.Sprintf("/api/v1/topics/%s", req.ID),
+		},
+		Merges: ms,
+	}
+	if err := encodeResponse(ctx, rw, http.StatusOK, res); err != nil {
+		LogEncodeError(th.Logger, r, err)
+		return
+	}
+}
+
+type topicRelationResponse struct {
+	Links map[string]string `json:"links"`
+	service.TopicRelation
+}
+
+func newTopicRelationResponse(tr *service.TopicRelation) *topicRelationResponse {
+	return &topicRelationResponse{
+		Links: map[string]string{
+			"self":  fmt.Sprintf("/api/v1/topics/%s/relations/%s/%s", tr.TopicID, tr.ItemType, tr.ItemID),
+			"topic": fmt.Sprintf("/api/v1/topics/%s", tr.TopicID),
+			"item":  fmt.Sprintf("/api/v1/%s/%s", tr.ItemType, tr.ItemID),
+		},
+		TopicRelation: *tr,
+	}
+}
+
+type topicRelationsResponse struct {
+	Links     map[string]string        `json:"links"`
+	Relations []*topicRelationResponse `json:"relations"`
+	Total     int                      `json:"total"`
+}
+
+func (th *TopicHandler) handleGetTopicRelations(rw http.ResponseWriter, r *http.Request, ps httprouter.Params) {
+	ctx := r.Context()
+
+	req, err := decodeRequest(r, ps)
+	if err != nil {
+		EncodeError(ctx, err, rw)
+		return
+	}
+
+	opts, err := decodeFindOptions(ctx, r)
+	if err != nil {
+		EncodeError(ctx, err, rw)
+		return
+	}
+
+	filter := service.TopicRelationFilter{
+		TopicID: &req.ID,
+	}
+	if itemType := r.URL.Query().Get("itemType"); itemType != "" {
+		t := service.ItemType(itemType)
+		if err := t.Valid(); err != nil {
+			EncodeError(ctx, err, rw)
+			return
+		}
+		filter.ItemType = &t
+	}
+
+	rs, total, err := th.TopicService.FindTopicRelations(ctx, filter, *opts)
+	if err != nil {
+		EncodeError(ctx, err, rw)
+		return
+	}
+
+	res := &topicRelationsResponse{
+		Links:     pagingLinks(fmt.Sprintf("/api/v1/topics/%s/relations", req.ID), *opts, len(rs), total),
+		Relations: make([]*topicRelationResponse, 0, len(rs)),
+		Total:     total,
+	}
+	for _, tr := range rs {
+		res.Relations = append(res.Relations, newTopicRelationResponse(tr))
+	}
+
+	if err := encodeResponse(ctx, rw, http.StatusOK, res); err != nil {
+		LogEncodeError(th.Logger, r, err)
+		return
+	}
+}
+
+type postTopicRelationRequest struct {
+	ItemType service.ItemType `json:"itemType"`
+	ItemID   service.ID       `json:"itemID"`
+}
+
+func (th *TopicHandler) handlePostTopicRelation(rw http.ResponseWriter, r *http.Request, ps httprouter.Params) {
+	ctx := r.Context()
+
+	tr, err := decodeRequest(r, ps)
+	if err != nil {
+		EncodeError(ctx, err, rw)
+		return
+	}
+
+	req := &postTopicRelationRequest{}
+	if err := json.NewDecoder(r.Body).Decode(req); err != nil {
+		EncodeError(ctx, &errors.Error{
+			Code: errors.Invalid,
+			Msg:  "invalid json structure",
+			Err:  err,
+		}, rw)
+		return
+	}
+
+	auth, err := icontext.GetAuthorizer(ctx)
+	if err != nil {
+		EncodeError(ctx, err, rw)
+		return
+	}
+
+	rel := &service.TopicRelation{
+		TopicID:  tr.ID,
+		ItemType: req.ItemType,
+		ItemID:   req.ItemID,
+		UserID:   auth.GetUserID(),
+	}
+	if err := rel.Valid(); err != nil {
+		EncodeError(ctx, err, rw)
+		return
+	}
+
+	if err := th.TopicService.AddTopicRelation(ctx, rel); err != nil {
+		EncodeError(ctx, err, rw)
+		return
+	}
+
+	if err := encodeResponse(ctx, rw, http.StatusCreated, newTopicRelationResponse(rel)); err != nil {
+		LogEncodeError(th.Logger, r, err)
+		return
+	}
+}
+
+func (th *TopicHandler) handleDeleteTopicRelation(rw http.ResponseWriter, r *http.Request, ps httprouter.Params) {
+	ctx := r.Context()
+
+	req, err := decodeRequest(r, ps)
+	if err != nil {
+		EncodeError(ctx, err, rw)
+		return
+	}
+
+	itemType := service.ItemType(ps.ByName("itemType"))
+	if err := itemType.Valid(); err != nil {
+		EncodeError(ctx, err, rw)
+		return
+	}
+
+	var itemID service.ID
+	if err := itemID.DecodeFromString(ps.ByName("itemID")); err != nil {
+		EncodeError(ctx, err, rw)
+		return
+	}
+
+	if err := th.TopicService.RemoveTopicRelation(ctx, req.ID, itemType, itemID); err != nil {
+		EncodeError(ctx, err, rw)
+		return
+	}
+
+	rw.WriteHeader(http.StatusNoContent)
+}
+
+type topicFocusResponse struct {
+	Links map[string]string   `json:"links"`
+	Topic *topicResponse      `json:"topic"`
+	Focus *service.TopicFocus `json:"focus,omitempty"`
+}
+
+func newTopicFocusResponse(t *service.Topic, f *service.TopicFocus) *topicFocusResponse {
+	return &topicFocusResponse{
+		Links: map[string]string{
+			"self": fmt.Sprintf("/api/v1/topics/%s/focus", t.ID),
+		},
+		Topic: newTopicResponse(t),
+		Focus: f,
+	}
+}
+
+func (th *TopicHandler) handleGetTopicFocus(rw http.ResponseWriter, r *http.Request, ps httprouter.Params) {
+	ctx := r.Context()
+
+	req, err := decodeRequest(r, ps)
+	if err != nil {
+		EncodeError(ctx, err, rw)
+		return
+	}
+
+	auth, err := icontext.GetAuthorizer(ctx)
+	if err != nil {
+		EncodeError(ctx, err, rw)
+		return
+	}
+
+	t, err := th.TopicService.FindTopicByID(ctx, req.ID)
+	if err != nil {
+		EncodeError(ctx, err, rw)
+		return
+	}
+
+	f, err := th.TopicService.FindTopicFocus(ctx, req.ID, auth.GetUserID())
+	if err != nil && errors.ErrorCode(err) != errors.NotFound {
+		EncodeError(ctx, err, rw)
+		return
+	}
+
+	if err := encodeResponse(ctx, rw, http.StatusOK, newTopicFocusResponse(t, f)); err != nil {
+		LogEncodeError(th.Logger, r, err)
+		return
+	}
+}
+
+func (th *TopicHandler) handlePutTopicFocus(rw http.ResponseWriter, r *http.Request, ps httprouter.Params) {
+	ctx := r.Context()
+
+	req, err := decodeRequest(r, ps)
+	if err != nil {
+		EncodeError(ctx, err, rw)
+		return
+	}
+
+	auth, err := icontext.GetAuthorizer(ctx)
+	if err != nil {
+		EncodeError(ctx, err, rw)
+		return
+	}
+
+	t, err := th.TopicService.FocusTopic(ctx, req.ID, auth.GetUserID())
+	if err != nil {
+		EncodeError(ctx, err, rw)
+		return
+	}
+
+	f, err := th.TopicService.FindTopicFocus(ctx, req.ID, auth.GetUserID())
+	if err != nil {
+		EncodeError(ctx, err, rw)
+		return
+	}
+
+	if err := encodeResponse(ctx, rw, http.StatusOK, newTopicFocusResponse(t, f)); err != nil {
+		LogEncodeError(th.Logger, r, err)
+		return
+	}
+}
+
+func (th *TopicHandler) handleDeleteTopicFocus(rw http.ResponseWriter, r *http.Request, ps httprouter.Params) {
+	ctx := r.Context()
+
+	req, err := decodeRequest(r, ps)
+	if err != nil {
+		EncodeError(ctx, err, rw)
+		return
+	}
+
+	auth, err := icontext.GetAuthorizer(ctx)
+	if err != nil {
+		EncodeError(ctx, err, rw)
+		return
+	}
+
+	t, err := th.TopicService.UnfocusTopic(ctx, req.ID, auth.GetUserID())
+	if err != nil {
+		EncodeError(ctx, err, rw)
+		return
+	}
+
+	if err := encodeResponse(ctx, rw, http.StatusOK, newTopicFocusResponse(t, nil)); err != nil {
+		LogEncodeError(th.Logger, r, err)
+		return
+	}
+}
+
+func (th *TopicHandler) handleGetFocusedTopics(rw http.ResponseWriter, r *http.Request, ps httprouter.Params) {
+	ctx := r.Context()
+
+	auth, err := icontext.GetAuthorizer(ctx)
+	if err != nil {
+		EncodeError(ctx, err, rw)
+		return
+	}
+
+	opts, err := decodeTopicFindOptions(ctx, r)
+	if err != nil {
+		EncodeError(ctx, err, rw)
+		return
+	}
+
+	ts, total, err := th.TopicService.FindFocusedTopics(ctx, auth.GetUserID(), *opts)
+	if err != nil {
+		EncodeError(ctx, err, rw)
+		return
+	}
+
+	if err := encodeResponse(ctx, rw, http.StatusOK, newTopicsResponse(meTopicsPath, *opts, ts, total)); err != nil {
+		LogEncodeError(th.Logger, r, err)
+		return
+	}
+}
@@ -19,6 +19,9 @@ type UserBackend struct {
 	UserService service.UserService
 	// TODO add user service log
 	PasswordsService service.PasswordsService
+	// AccountTokenService and AccountMailer send the activation mail of new users.
+	AccountTokenService service.AccountTokenService
+	AccountMailer       service.AccountMailer
 }
 
 const (
@@ -32,17 +35,21 @@ const (
 
 func NewUserBackend(ab *APIBackend) *UserBackend {
 	return &UserBackend{
-		Logger:           ab.Logger.With(zap.String("handler", "user")),
-		UserService:      ab.UserService,
-		PasswordsService: ab.PasswordsService,
+		Logger:              ab.Logger.With(zap.String("handler", "user")),
+		UserService:         ab.UserService,
+		PasswordsService:    ab.PasswordsService,
+		AccountTokenService: ab.AccountTokenService,
+		AccountMailer:       ab.AccountMailer,
 	}
 }
 
 type UserHandler struct {
 	*httprouter.Router
-	Logger           *zap.Logger
-	UserService      service.UserService
-	PasswordsService service.PasswordsService
+	Logger              *zap.Logger
+	UserService         service.UserService
+	PasswordsService    service.PasswordsService
+	AccountTokenService service.AccountTokenService
+	AccountMailer       service.AccountMailer
 }
 
 func NewUserHandler(ab *UserBackend) *UserHandler {
@@ -50,8 +57,10 @@ func NewUserHandler(ab *UserBackend) *UserHandler {
 		Router: httprouter.New(),
 		Logger: ab.Logger,
 
-		UserService:      ab.UserService,
-		PasswordsService: ab.PasswordsService,
+		UserService:         ab.UserService,
+		PasswordsService:    ab.PasswordsService,
+		AccountTokenService: ab.AccountTokenService,
+		AccountMailer:       ab.AccountMailer,
 	}
 
 	uh.POST(usersPath, uh.handlePostUser)
@@ -95,12 +104,31 @@ func (uh *UserHandler) handlePostUser(rw http.ResponseWriter, r *http.Request, p
 		return
 	}
 
+	// the user is created already, a mail which can not be sent is only logged.
+	if err := uh.sendActivation(ctx, req.User); err != nil {
+		uh.Logger.Error("failed to send activation mail", zap.Stringer("user", req.User.ID), zap.Error(err))
+	}
+
 	if err := encodeResponse(ctx, rw, http.StatusCreated, newUserResponse(req.User)); err != nil {
 		EncodeError(ctx, err, rw)
 		return
 	}
 }
 
+// sendActivation mails the activation link to the new user, users without an
+// email are activated by an admin instead.
+func (uh *UserHandler) sendActivation(ctx context.Context, u *service.User) error {
+	if u.Email == "" || uh.AccountMailer == nil {
+		return nil
+	}
+
+	t, err := uh.AccountTokenService.CreateAccountToken(ctx, service.ActivateToken, u.ID)
+	if err != nil {
+		return err
+	}
+	return uh.AccountMailer.SendAccountMail(ctx, u, t)
+}
+
 type getUsersRequest struct {
 	filter service.UserFilter
 }
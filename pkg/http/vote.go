@@ -0,0 +1,205 @@
+package http
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"strings"
+
+	"github.com/julienschmidt/httprouter"
+	"go.uber.org/zap"
+
+	icontext "github.com/ustackq/indagate/pkg/context"
+	"github.com/ustackq/indagate/pkg/service"
+	"github.com/ustackq/indagate/pkg/utils/errors"
+)
+
+// VoteBackend is all services and associated parameters required to construct
+// the VoteHandler.
+type VoteBackend struct {
+	Logger *zap.Logger
+
+	VoteService service.VoteService
+}
+
+// NewVoteBackend returns a new instance of VoteBackend.
+func NewVoteBackend(ab *APIBackend) *VoteBackend {
+	return &VoteBackend{
+		Logger: ab.Logger.With(zap.String("handler", "vote")),
+
+		VoteService: ab.VoteService,
+	}
+}
+
+// VoteHandler represents an HTTP API handler for the votes on all item types.
+type VoteHandler struct {
+	*httprouter.Router
+	Logger *zap.Logger
+
+	VoteService service.VoteService
+}
+
+// voteSuffix is the last element of the vote routes of all item types.
+const voteSuffix = "/vote"
+
+// NewVoteHandler returns a new instance of VoteHandler.
+func NewVoteHandler(vb *VoteBackend) *VoteHandler {
+	vh := &VoteHandler{
+		Router: NewRouter(),
+		Logger: vb.Logger,
+
+		VoteService: vb.VoteService,
+	}
+
+	for _, t := range service.AllItemTypes {
+		path := fmt.Sprintf("/api/v1/%s/:id%s", t, voteSuffix)
+		vh.GET(path, vh.handleGetVote)
+		vh.PUT(path, vh.handlePutVote)
+		vh.DELETE(path, vh.handleDeleteVote)
+	}
+
+	return vh
+}
+
+type voteResponse struct {
+	Links map[string]string `json:"links"`
+	Item  *service.VoteItem `json:"item"`
+	Vote  *service.Vote     `json:"vote,omitempty"`
+}
+
+func newVoteResponse(item *service.VoteItem, v *service.Vote) *voteResponse {
+	return &voteResponse{
+		Links: map[string]string{
+			"self": fmt.Sprintf("/api/v1/%s/%s/vote", item.Type, item.ID),
+			"item": fmt.Sprintf("/api/v1/%s/%s", item.Type, item.ID),
+		},
+		Item: item,
+		Vote: v,
+	}
+}
+
+type voteRequest struct {
+	ItemType service.ItemType
+	ItemID   service.ID
+	UserID   service.ID
+}
+
+// decodeVoteRequest reads the item from the path, /api/v1/:itemType/:id/vote,
+// and the voter from the authorizer.
+func decodeVoteRequest(ctx context.Context, r *http.Request, ps httprouter.Params) (*voteRequest, error) {
+	req, err := decodeRequest(r, ps)
+	if err != nil {
+		return nil, err
+	}
+
+	parts := strings.Split(strings.TrimPrefix(r.URL.Path, "/api/v1/"), "/")
+	itemType := service.ItemType(parts[0])
+	if err := itemType.Valid(); err != nil {
+		return nil, err
+	}
+
+	auth, err := icontext.GetAuthorizer(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	return &voteRequest{
+		ItemType: itemType,
+		ItemID:   req.ID,
+		UserID:   auth.GetUserID(),
+	}, nil
+}
+
+type putVoteRequest struct {
+	Value service.VoteValue `json:"value"`
+}
+
+func (h *VoteHandler) handleGetVote(rw http.ResponseWriter, r *http.Request, ps httprouter.Params) {
+	ctx := r.Context()
+
+	req, err := decodeVoteRequest(ctx, r, ps)
+	if err != nil {
+		EncodeError(ctx, err, rw)
+		return
+	}
+
+	item, err := h.VoteService.FindVoteItem(ctx, req.ItemType, req.ItemID)
+	if err != nil {
+		EncodeError(ctx, err, rw)
+		return
+	}
+
+	v, err := h.VoteService.FindVote(ctx, req.ItemType, req.ItemID, req.UserID)
+	if err != nil && errors.ErrorCode(err) != errors.NotFound {
+		EncodeError(ctx, err, rw)
+		return
+	}
+
+	if err := encodeResponse(ctx, rw, http.StatusOK, newVoteResponse(item, v)); err != nil {
+		LogEncodeError(h.Logger, r, err)
+		return
+	}
+}
+
+func (h *VoteHandler) handlePutVote(rw http.ResponseWriter, r *http.Request, ps httprouter.Params) {
+	ctx := r.Context()
+
+	req, err := decodeVoteRequest(ctx, r, ps)
+	if err != nil {
+		EncodeError(ctx, err, rw)
+		return
+	}
+
+	body := &putVoteRequest{}
+	if err := json.NewDecoder(r.Body).Decode(body); err != nil {
+		EncodeError(ctx, &errors.Error{
+			Code: errors.Invalid,
+			Msg:  "invalid json structure",
+			Err:  err,
+		}, rw)
+		return
+	}
+
+	v := &service.Vote{
+		ItemType: req.ItemType,
+		ItemID:   req.ItemID,
+		UserID:   req.UserID,
+		Value:    body.Value,
+	}
+	if err := v.Valid(); err != nil {
+		EncodeError(ctx, err, rw)
+		return
+	}
+
+	item, err := h.VoteService.Vote(ctx, v)
+	if err != nil {
+		EncodeError(ctx, err, rw)
+		return
+	}
+
+	if err := encodeResponse(ctx, rw, http.StatusOK, newVoteResponse(item, v)); err != nil {
+		LogEncodeError(h.Logger, r, err)
+		return
+	}
+}
+
+func (h *VoteHandler) handleDeleteVote(rw http.ResponseWriter, r *http.Request, ps httprouter.Params) {
+	ctx := r.Context()
+
+	req, err := decodeVoteRequest(ctx, r, ps)
+	if err != nil {
+		EncodeError(ctx, err, rw)
+		return
+	}
+
+	item, err := h.VoteService.DeleteVote(ctx, req.ItemType, req.ItemID, req.UserID)
+	if err != nil {
+		EncodeError(ctx, err, rw)
+		return
+	}
+
+	if err := encodeResponse(ctx, rw, http.StatusOK, newVoteResponse(item, nil)); err != nil {
+		LogEncodeError(h.Logger, r, err)
+		return
+	}
+}
@@ -0,0 +1,114 @@
+package mail
+
+import (
+	"context"
+	"fmt"
+	"net/url"
+	"strings"
+
+	"github.com/ustackq/indagate/pkg/service"
+)
+
+var (
+	_ service.AccountMailer      = (*Mailer)(nil)
+	_ service.NotificationMailer = (*Mailer)(nil)
+)
+
+// paths of the pages the links of the account mails open, relative to Mailer.BaseURL.
+const (
+	activatePath      = "/account/activate"
+	resetPasswordPath = "/account/password"
+)
+
+// SendAccountMail enqueues the activation or password reset mail carrying the token,
+// users without an email are sent nothing.
+func (m *Mailer) SendAccountMail(ctx context.Context, u *service.User, t *service.AccountToken) error {
+	if u.Email == "" {
+		return nil
+	}
+
+	expires := t.ExpiresAt.Sub(t.CreatedAt)
+	var (
+		tpl  string
+		data interface{}
+	)
+	switch t.Kind {
+	case service.ActivateToken:
+		tpl = ActivateTemplate
+		data = &ActivateData{Username: u.Name, Link: m.tokenLink(activatePath, t), Expires: expires}
+	case service.ResetPasswordToken:
+		tpl = ResetPasswordTemplate
+		data = &ResetPasswordData{Username: u.Name, Link: m.tokenLink(resetPasswordPath, t), Expires: expires}
+	default:
+		return t.Kind.Valid()
+	}
+
+	_, err := m.Send(ctx, tpl, []string{u.Email}, data)
+	return err
+}
+
+func (m *Mailer) tokenLink(path string, t *service.AccountToken) string {
+	return strings.TrimSuffix(m.BaseURL, "/") + path + "?token=" + url.QueryEscape(t.Token)
+}
+
+// NotificationMail renders the mail of the notification, recipients without an
+// email get none. The mail is only rendered, the store queues it along with the
+// notification.
+func (m *Mailer) NotificationMail(recipient, sender *service.User, n *service.Notification) (*service.Mail, error) {
+	if recipient.Email == "" {
+		return nil, nil
+	}
+
+	data := &NotifyData{
+		Username: recipient.Name,
+		Subject:  notificationSubject(n),
+		Content:  notificationContent(sender, n),
+	}
+	if n.ItemType != "" && n.ItemID.Valid() {
+		data.Link = fmt.Sprintf("%s/%s/%s", strings.TrimSuffix(m.BaseURL, "/"), n.ItemType, n.ItemID)
+	}
+
+	mail, err := m.Templates.Render(NotifyTemplate, data)
+	if err != nil {
+		return nil, err
+	}
+	mail.To = []string{recipient.Email}
+	mail.From = m.From
+	return mail, nil
+}
+
+func notificationSubject(n *service.Notification) string {
+	switch n.Action {
+	case service.ApprovedNotification:
+		return "Your post was approved"
+	case service.RejectedNotification:
+		return "Your post was rejected"
+	}
+	return "New " + string(n.Action) + " notification"
+}
+
+func notificationContent(sender *service.User, n *service.Notification) string {
+	name := "Someone"
+	if sender != nil {
+		name = sender.Name
+	}
+	item := strings.TrimSuffix(string(n.ItemType), "s")
+
+	switch n.Action {
+	case service.AnswerNotification:
+		return name + " answered your question."
+	case service.CommentNotification:
+		return name + " commented on your " + item + "."
+	case service.MentionNotification:
+		return name + " mentioned you in a " + item + "."
+	case service.VoteNotification:
+		return name + " voted up your " + item + "."
+	case service.FollowNotification:
+		return name + " followed you."
+	case service.ApprovedNotification:
+		return "A reviewer approved your " + item + "."
+	case service.RejectedNotification:
+		return "A reviewer rejected your " + item + "."
+	}
+	return name + " acted on your " + item + "."
+}
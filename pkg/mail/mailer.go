@@ -1,227 +1,256 @@
 package mail
 
 import (
-	"crypto/tls"
-	"fmt"
-	"io"
-	"net"
-	"net/smtp"
-	"os"
-	"strings"
+	"context"
+	"errors"
+	netmail "net/mail"
+	"net/textproto"
+	"sync"
 	"time"
 
+	"go.uber.org/zap"
 	"gopkg.in/gomail.v2"
 
-	"github.com/golang/glog"
-	"github.com/ustack/Yunus/src/app/backend/pkg/utils/html2text"
+	"github.com/ustackq/indagate/pkg/service"
+	"github.com/ustackq/indagate/pkg/utils/wait"
 )
 
-// Mailer represents mail service.
-type Mailer struct {
-	QueueLength       int
-	Subject           string
-	Host              string
-	Port              string
-	From              string
-	FromEmail         string
-	User, Passwd      string
-	DisableHelo       bool
-	HeloHostname      string
-	SkipVerify        bool
-	UseCertificate    bool
-	CertFile, KeyFile string
-	UsePlainText      bool
-}
-
 var (
-	// MailService ...
-	MailService *Mailer
-)
+	// DefaultBackoff spaces the delivery attempts of a mail over about half an hour.
+	DefaultBackoff = wait.Backoff{
+		Duration: 30 * time.Second,
+		Factor:   2,
+		Jitter:   0.1,
+		Steps:    6,
+	}
 
-type Message struct {
-	// Log info
-	Info string
-	*gomail.Message
-	confirmChan chan struct{}
-}
+	// DefaultPollPeriod is how often the queue is checked for mails left pending.
+	DefaultPollPeriod = time.Minute
+)
 
-type loginAuth struct {
-	username, password string
-}
+// DefaultWorkers is the default number of mails delivered at once.
+const DefaultWorkers = 4
 
-func (a *loginAuth) Start(server *smtp.ServerInfo) (string, []byte, error) {
-	return "LOGIN", []byte{}, nil
+// Mailer renders mails, stores them in the queue of the MailService and delivers them.
+type Mailer struct {
+	Logger      *zap.Logger
+	MailService service.MailService
+	Sender      gomail.Sender
+	Templates   *Templates
+	// From is the sender of the mails without one.
+	From string
+	// BaseURL is the address of the site the links of the mails point to.
+	BaseURL string
+	// Backoff spaces the delivery attempts of each mail, the mail is given up on
+	// after Backoff.Steps failed attempts or a permanent failure. No worker waits
+	// for the next attempt, the mail stays in the queue until it is due.
+	Backoff    wait.Backoff
+	PollPeriod time.Duration
+	Workers    int
+
+	mu       sync.Mutex
+	inflight map[service.ID]bool
+	wg       sync.WaitGroup
+	wake     chan struct{}
 }
 
-func (a *loginAuth) Next(fromServer []byte, more bool) ([]byte, error) {
-	if more {
-		switch string(fromServer) {
-		case "Username:":
-			return []byte(a.username), nil
-		case "Password:":
-			return []byte(a.password), nil
-		default:
-			return nil, fmt.Errorf("unknwon fromServer: %s", string(fromServer))
-		}
+// NewMailer returns a new instance of Mailer with the default templates.
+func NewMailer(ms service.MailService, sender gomail.Sender, from string) *Mailer {
+	return &Mailer{
+		Logger:      zap.NewNop(),
+		MailService: ms,
+		Sender:      sender,
+		Templates:   DefaultTemplates(),
+		From:        from,
+		Backoff:     DefaultBackoff,
+		PollPeriod:  DefaultPollPeriod,
+		Workers:     DefaultWorkers,
+		inflight:    make(map[service.ID]bool),
+		wake:        make(chan struct{}, 1),
 	}
-	return nil, nil
-}
-
-// SMTP AUTH LOGIN Auth Handler
-func LoginAuth(username, password string) smtp.Auth {
-	return &loginAuth{username, password}
 }
 
-func NewMessage(to []string, from, subject, htmlBody string) *Message {
-	msg := gomail.NewMessage()
-	msg.SetHeader("From", from)
-	msg.SetHeader("To", to...)
-	msg.SetHeader("Subject", subject)
-	msg.SetDateHeader("Data", time.Now())
-
-	contentType := "text/html"
-	body := htmlBody
-	body, err := html2text.FromString(htmlBody)
+// Send renders the named template and enqueues the mail, it is delivered by Run.
+func (m *Mailer) Send(ctx context.Context, tpl string, to []string, data interface{}) (*service.Mail, error) {
+	mail, err := m.Templates.Render(tpl, data)
 	if err != nil {
-		glog.V(2).Infof("html2context: %v", err)
+		return nil, err
 	}
 
-	msg.SetBody(contentType, body)
-	return &Message{
-		Message:     msg,
-		confirmChan: make(chan struct{}),
+	mail.To = to
+	mail.From = m.From
+	if err := m.MailService.EnqueueMail(ctx, mail); err != nil {
+		return nil, err
 	}
-}
 
-type Sender struct{}
+	m.notify()
+	return mail, nil
+}
 
-func (sender *Sender) Send(from string, to []string, msg io.WriterTo) error {
-	opts := MailService
-	tlsConfig := &tls.Config{
-		InsecureSkipVerify: opts.SkipVerify,
-		ServerName:         opts.Host,
+func (m *Mailer) notify() {
+	select {
+	case m.wake <- struct{}{}:
+	default:
 	}
+}
 
-	if opts.UseCertificate {
-		cert, err := tls.LoadX509KeyPair(opts.CertFile, opts.KeyFile)
-		if err != nil {
-			return err
-		}
-		tlsConfig.Certificates = []tls.Certificate{cert}
-	}
+// Run delivers the queued mails until ctx is done. Mails waiting for their next
+// attempt stay pending in the store, they are picked up by the first poll after
+// they are due, of this Run or the next one.
+func (m *Mailer) Run(ctx context.Context) {
+	ticker := time.NewTicker(m.PollPeriod)
+	defer ticker.Stop()
 
-	// Server port check
-	conn, err := net.Dial("tcp", net.JoinHostPort(opts.Host, opts.Port))
-	if err != nil {
-		return err
+	for {
+		m.dispatch(ctx, nil)
+		select {
+		case <-ctx.Done():
+			return
+		case <-m.wake:
+		case <-ticker.C:
+		}
 	}
-	defer conn.Close()
+}
 
-	isSecureConn := false
-	if strings.HasSuffix(opts.Port, "465") {
-		conn = tls.Client(conn, tlsConfig)
-		isSecureConn = true
+// Flush delivers the mails due and waits for the deliveries to end, each mail is
+// attempted at most once per Flush.
+func (m *Mailer) Flush(ctx context.Context) {
+	seen := make(map[service.ID]bool)
+	for ctx.Err() == nil {
+		n := m.dispatch(ctx, seen)
+		m.wg.Wait()
+		if n == 0 {
+			return
+		}
 	}
+}
 
-	client, err := smtp.NewClient(conn, opts.Host)
+// dispatch starts the delivery of the pending mails, as many at once as there are
+// workers, and returns the number of deliveries started. Mails in seen are skipped.
+func (m *Mailer) dispatch(ctx context.Context, seen map[service.ID]bool) int {
+	mails, err := m.MailService.PendingMails(ctx, 0)
 	if err != nil {
-		return fmt.Errorf("SMTP NewClient: %v", err)
+		m.Logger.Error("failed to find pending mails", zap.Error(err))
+		return 0
 	}
 
-	if !opts.DisableHelo {
-		hostname := opts.HeloHostname
-		if len(hostname) == 0 {
-			hostname, err = os.Hostname()
-			if err != nil {
-				return err
-			}
+	started := 0
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	for _, mail := range mails {
+		if len(m.inflight) >= m.Workers {
+			break
 		}
-
-		if err = client.Hello(hostname); err != nil {
-			return fmt.Errorf("Hello: %v", err)
+		if m.inflight[mail.ID] || seen[mail.ID] {
+			continue
 		}
-	}
-
-	// If not using SMTPS, alway use STARTTLS if available
-	hasStartTLS, _ := client.Extension("STARTTLS")
-	if !isSecureConn && hasStartTLS {
-		if err = client.StartTLS(tlsConfig); err != nil {
-			return fmt.Errorf("StartTLS: %v", err)
+		if seen != nil {
+			seen[mail.ID] = true
 		}
-	}
 
-	canAuth, options := client.Extension("AUTH")
-	if canAuth && len(opts.User) > 0 {
-		var auth smtp.Auth
+		m.inflight[mail.ID] = true
+		m.wg.Add(1)
+		started++
+		go func(mail *service.Mail) {
+			defer func() {
+				m.mu.Lock()
+				delete(m.inflight, mail.ID)
+				m.mu.Unlock()
+				m.wg.Done()
+				m.notify()
+			}()
+
+			if err := m.deliver(ctx, mail); err != nil {
+				m.Logger.Error("failed to deliver mail", zap.Stringer("id", mail.ID), zap.Error(err))
+			}
+		}(mail)
+	}
+	return started
+}
 
-		if strings.Contains(options, "CRAM-MD5") {
-			auth = smtp.CRAMMD5Auth(opts.User, opts.Passwd)
-		} else if strings.Contains(options, "PLAIN") {
-			auth = smtp.PlainAuth("", opts.User, opts.Passwd, opts.Host)
-		} else if strings.Contains(options, "LOGIN") {
-			// Patch for AUTH LOGIN
-			auth = LoginAuth(opts.User, opts.Passwd)
-		}
+// deliver makes a single attempt to send the mail. A mail which fails is left
+// pending until its next attempt is due, after Backoff, unless it is given up on.
+func (m *Mailer) deliver(ctx context.Context, mail *service.Mail) error {
+	if err := ctx.Err(); err != nil {
+		return nil
+	}
 
-		if auth != nil {
-			if err = client.Auth(auth); err != nil {
-				return fmt.Errorf("Auth: %v", err)
-			}
-		}
+	a := service.MailAttempt{}
+	if err := m.send(mail); err != nil {
+		a.Err = err.Error()
+		a.Dead = permanent(err) || mail.Attempts+1 >= m.Backoff.Steps
+		a.RetryAfter = m.retryAfter(mail.Attempts + 1)
 	}
 
-	if err = client.Mail(from); err != nil {
-		return fmt.Errorf("Mail: %v", err)
+	updated, err := m.MailService.RecordMailAttempt(ctx, mail.ID, a)
+	if err != nil {
+		return err
+	}
+	*mail = *updated
+
+	switch mail.Status {
+	case service.MailSent:
+		m.Logger.Info("mail sent", zap.Stringer("id", mail.ID), zap.String("template", mail.Template))
+	case service.MailDead:
+		m.Logger.Warn("mail given up on", zap.Stringer("id", mail.ID), zap.Int("attempts", mail.Attempts), zap.String("error", mail.LastError))
+	default:
+		m.Logger.Info("failed to send mail, retrying", zap.Stringer("id", mail.ID), zap.Int("attempts", mail.Attempts), zap.String("error", mail.LastError), zap.Time("next", *mail.NextAttemptAt))
 	}
+	return nil
+}
 
-	for _, rec := range to {
-		if err = client.Rcpt(rec); err != nil {
-			return fmt.Errorf("Rcpt: %v", err)
-		}
+// retryAfter returns the wait before the next attempt of a mail which failed
+// the given number of times, it is the last of as many steps of Backoff.
+func (m *Mailer) retryAfter(attempts int) time.Duration {
+	b := m.Backoff
+	b.Steps = attempts
+	var d time.Duration
+	for b.Steps > 0 {
+		d = b.Step()
 	}
+	return d
+}
 
-	w, err := client.Data()
+func (m *Mailer) send(mail *service.Mail) error {
+	from := mail.From
+	if from == "" {
+		from = m.From
+	}
+	addr, err := netmail.ParseAddress(from)
 	if err != nil {
-		return fmt.Errorf("Data: %v", err)
-	} else if _, err = msg.WriteTo(w); err != nil {
-		return fmt.Errorf("WriteTo: %v", err)
-	} else if err = w.Close(); err != nil {
-		return fmt.Errorf("Close: %v", err)
+		return &permanentError{err}
 	}
 
-	return client.Quit()
+	msg := gomail.NewMessage()
+	msg.SetHeader("From", from)
+	msg.SetHeader("To", mail.To...)
+	msg.SetHeader("Subject", mail.Subject)
+	msg.SetDateHeader("Date", time.Now())
+	msg.SetBody("text/plain", mail.Text)
+	if mail.HTML != "" {
+		msg.AddAlternative("text/html", mail.HTML)
+	}
 
+	return m.Sender.Send(addr.Address, mail.To, msg)
 }
 
-var mailQ chan *Message
+// permanentError is a failure which retrying can not fix.
+type permanentError struct {
+	err error
+}
 
-func processMailQ() {
-	sender := &Sender{}
-	for {
-		select {
-		case msg := <-mailQ:
-			glog.V(0).Infof("New email sending request to %s: %s", msg.GetHeader("To"), msg.Info)
-			if err := gomail.Send(sender, msg.Message); err != nil {
-				glog.V(2).Infof("Fail to send email to %s: %s: %v", msg.GetHeader("To"), msg.Info, err)
-			} else {
-				glog.V(0).Infof("email to %s has been sended: %s", msg.GetHeader("To"), msg.Info)
-			}
-			msg.confirmChan <- struct{}{}
-		}
-	}
+func (e *permanentError) Error() string {
+	return e.err.Error()
 }
 
-func NewMailer() {
-	if mailQ != nil {
-		return
+// permanent reports whether the mail can not be delivered, mail servers reply
+// with 5xx codes to such mails.
+func permanent(err error) bool {
+	var pe *permanentError
+	if errors.As(err, &pe) {
+		return true
 	}
-	mailQ = make(chan *Message, MailService.QueueLength)
-	go processMailQ()
-}
 
-func Send(msg *Message) {
-	mailQ <- msg
-	go func() {
-		<-msg.confirmChan
-	}()
+	var te *textproto.Error
+	return errors.As(err, &te) && te.Code >= 500
 }
@@ -0,0 +1,179 @@
+// Package mailtest provides a local SMTP server recording the mails it receives, for tests.
+package mailtest
+
+import (
+	"fmt"
+	"net"
+	"net/textproto"
+	"strings"
+	"sync"
+)
+
+// Message is a mail received by the Server.
+type Message struct {
+	From string
+	To   []string
+	// Data is the mail as sent, headers included.
+	Data []byte
+}
+
+// Server is a minimal SMTP server, without TLS nor authentication.
+type Server struct {
+	// Addr is the address the server listens on, as host:port.
+	Addr string
+
+	ln       net.Listener
+	wg       sync.WaitGroup
+	mu       sync.Mutex
+	messages []*Message
+	failures []int
+}
+
+// NewServer starts a server listening on a port of the loopback interface.
+func NewServer() (*Server, error) {
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		return nil, err
+	}
+
+	s := &Server{
+		Addr: ln.Addr().String(),
+		ln:   ln,
+	}
+	s.wg.Add(1)
+	go s.serve()
+	return s, nil
+}
+
+// Host returns the host the server listens on.
+func (s *Server) Host() string {
+	host, _, _ := net.SplitHostPort(s.Addr)
+	return host
+}
+
+// Port returns the port the server listens on.
+func (s *Server) Port() string {
+	_, port, _ := net.SplitHostPort(s.Addr)
+	return port
+}
+
+// Fail rejects the next mails with the reply codes, one code per mail. Codes 4xx are
+// temporary failures, 5xx permanent ones.
+func (s *Server) Fail(codes ...int) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.failures = append(s.failures, codes...)
+}
+
+// Messages returns the mails received so far.
+func (s *Server) Messages() []*Message {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return append([]*Message(nil), s.messages...)
+}
+
+// Close stops the server and waits for the connections to end.
+func (s *Server) Close() error {
+	err := s.ln.Close()
+	s.wg.Wait()
+	return err
+}
+
+func (s *Server) serve() {
+	defer s.wg.Done()
+	for {
+		conn, err := s.ln.Accept()
+		if err != nil {
+			return
+		}
+		s.wg.Add(1)
+		go func() {
+			defer s.wg.Done()
+			defer conn.Close()
+			s.handle(textproto.NewConn(conn))
+		}()
+	}
+}
+
+func (s *Server) nextFailure() int {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if len(s.failures) == 0 {
+		return 0
+	}
+	code := s.failures[0]
+	s.failures = s.failures[1:]
+	return code
+}
+
+func (s *Server) handle(c *textproto.Conn) {
+	if err := c.PrintfLine("220 mailtest ESMTP"); err != nil {
+		return
+	}
+
+	msg := &Message{}
+	for {
+		line, err := c.ReadLine()
+		if err != nil {
+			return
+		}
+
+		verb, arg := line, ""
+		if i := strings.IndexByte(line, ' '); i >= 0 {
+			verb, arg = line[:i], line[i+1:]
+		}
+
+		var reply string
+		switch strings.ToUpper(verb) {
+		case "EHLO", "HELO":
+			reply = "250 mailtest"
+		case "MAIL":
+			if code := s.nextFailure(); code != 0 {
+				reply = fmt.Sprintf("%d mail rejected", code)
+				break
+			}
+			msg = &Message{From: address(arg)}
+			reply = "250 OK"
+		case "RCPT":
+			msg.To = append(msg.To, address(arg))
+			reply = "250 OK"
+		case "DATA":
+			if err := c.PrintfLine("354 end data with <CR><LF>.<CR><LF>"); err != nil {
+				return
+			}
+			data, err := c.ReadDotBytes()
+			if err != nil {
+				return
+			}
+			msg.Data = data
+			s.mu.Lock()
+			s.messages = append(s.messages, msg)
+			s.mu.Unlock()
+			msg = &Message{}
+			reply = "250 OK"
+		case "RSET":
+			msg = &Message{}
+			reply = "250 OK"
+		case "NOOP":
+			reply = "250 OK"
+		case "QUIT":
+			c.PrintfLine("221 bye")
+			return
+		default:
+			reply = "502 command not implemented"
+		}
+
+		if err := c.PrintfLine("%s", reply); err != nil {
+			return
+		}
+	}
+}
+
+// address returns the address of a FROM:<addr> or TO:<addr> argument.
+func address(arg string) string {
+	start, end := strings.IndexByte(arg, '<'), strings.IndexByte(arg, '>')
+	if start < 0 || end < start {
+		return arg
+	}
+	return arg[start+1 : end]
+}
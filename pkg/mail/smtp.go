@@ -0,0 +1,175 @@
+package mail
+
+import (
+	"crypto/tls"
+	"fmt"
+	"io"
+	"net"
+	"net/smtp"
+	"os"
+	"strings"
+	"time"
+
+	"gopkg.in/gomail.v2"
+)
+
+// SMTPConfig configures the connection to the mail server.
+type SMTPConfig struct {
+	Host         string
+	Port         string
+	User, Passwd string
+	DisableHelo  bool
+	// HeloHostname defaults to the hostname of the machine.
+	HeloHostname      string
+	SkipVerify        bool
+	UseCertificate    bool
+	CertFile, KeyFile string
+	// Timeout bounds the delivery of each mail, zero means no limit.
+	Timeout time.Duration
+}
+
+// DefaultSMTPTimeout is the default time limit of a delivery.
+const DefaultSMTPTimeout = time.Minute
+
+// SMTPSender sends mails to an SMTP server, over TLS on port 465 and with
+// STARTTLS when the server supports it.
+type SMTPSender struct {
+	Config SMTPConfig
+}
+
+var _ gomail.Sender = (*SMTPSender)(nil)
+
+// NewSMTPSender returns a new instance of SMTPSender.
+func NewSMTPSender(c SMTPConfig) *SMTPSender {
+	return &SMTPSender{Config: c}
+}
+
+type loginAuth struct {
+	username, password string
+}
+
+func (a *loginAuth) Start(server *smtp.ServerInfo) (string, []byte, error) {
+	return "LOGIN", []byte{}, nil
+}
+
+func (a *loginAuth) Next(fromServer []byte, more bool) ([]byte, error) {
+	if more {
+		switch string(fromServer) {
+		case "Username:":
+			return []byte(a.username), nil
+		case "Password:":
+			return []byte(a.password), nil
+		default:
+			return nil, fmt.Errorf("unknown fromServer: %s", string(fromServer))
+		}
+	}
+	return nil, nil
+}
+
+// LoginAuth returns an smtp.Auth implementing the LOGIN mechanism.
+func LoginAuth(username, password string) smtp.Auth {
+	return &loginAuth{username, password}
+}
+
+// Send delivers msg, errors returned by the server are wrapped *textproto.Error.
+func (s *SMTPSender) Send(from string, to []string, msg io.WriterTo) error {
+	opts := s.Config
+	tlsConfig := &tls.Config{
+		InsecureSkipVerify: opts.SkipVerify,
+		ServerName:         opts.Host,
+	}
+
+	if opts.UseCertificate {
+		cert, err := tls.LoadX509KeyPair(opts.CertFile, opts.KeyFile)
+		if err != nil {
+			return err
+		}
+		tlsConfig.Certificates = []tls.Certificate{cert}
+	}
+
+	conn, err := net.DialTimeout("tcp", net.JoinHostPort(opts.Host, opts.Port), opts.Timeout)
+	if err != nil {
+		return err
+	}
+	defer conn.Close()
+
+	if opts.Timeout > 0 {
+		if err := conn.SetDeadline(time.Now().Add(opts.Timeout)); err != nil {
+			return err
+		}
+	}
+
+	isSecureConn := false
+	if strings.HasSuffix(opts.Port, "465") {
+		conn = tls.Client(conn, tlsConfig)
+		isSecureConn = true
+	}
+
+	client, err := smtp.NewClient(conn, opts.Host)
+	if err != nil {
+		return fmt.Errorf("SMTP NewClient: %w", err)
+	}
+
+	if !opts.DisableHelo {
+		hostname := opts.HeloHostname
+		if len(hostname) == 0 {
+			hostname, err = os.Hostname()
+			if err != nil {
+				return err
+			}
+		}
+
+		if err = client.Hello(hostname); err != nil {
+			return fmt.Errorf("Hello: %w", err)
+		}
+	}
+
+	// If not using SMTPS, always use STARTTLS if available
+	hasStartTLS, _ := client.Extension("STARTTLS")
+	if !isSecureConn && hasStartTLS {
+		if err = client.StartTLS(tlsConfig); err != nil {
+			return fmt.Errorf("StartTLS: %w", err)
+		}
+	}
+
+	canAuth, options := client.Extension("AUTH")
+	if canAuth && len(opts.User) > 0 {
+		var auth smtp.Auth
+
+		if strings.Contains(options, "CRAM-MD5") {
+			auth = smtp.CRAMMD5Auth(opts.User, opts.Passwd)
+		} else if strings.Contains(options, "PLAIN") {
+			auth = smtp.PlainAuth("", opts.User, opts.Passwd, opts.Host)
+		} else if strings.Contains(options, "LOGIN") {
+			// Patch for AUTH LOGIN
+			auth = LoginAuth(opts.User, opts.Passwd)
+		}
+
+		if auth != nil {
+			if err = client.Auth(auth); err != nil {
+				return fmt.Errorf("Auth: %w", err)
+			}
+		}
+	}
+
+	if err = client.Mail(from); err != nil {
+		return fmt.Errorf("Mail: %w", err)
+	}
+
+	for _, rec := range to {
+		if err = client.Rcpt(rec); err != nil {
+			return fmt.Errorf("Rcpt: %w", err)
+		}
+	}
+
+	w, err := client.Data()
+	if err != nil {
+		return fmt.Errorf("Data: %w", err)
+	} else if _, err = msg.WriteTo(w); err != nil {
+		return fmt.Errorf("WriteTo: %w", err)
+	} else if err = w.Close(); err != nil {
+		return fmt.Errorf("Close: %w", err)
+	}
+
+	return client.Quit()
+}
@@ -0,0 +1,122 @@
+package mail
+
+import (
+	"bytes"
+	"embed"
+	"fmt"
+	"html"
+	"html/template"
+	"io/fs"
+	"strings"
+	"time"
+
+	"github.com/ustackq/indagate/pkg/service"
+	"github.com/ustackq/indagate/pkg/utils/html2text"
+)
+
+// names of the mail templates, each one defines a "subject" and a "body" template
+// rendered within the layout.
+const (
+	ActivateTemplate      = "auth/activate"
+	ResetPasswordTemplate = "auth/reset_passwd"
+	NotifyTemplate        = "notify/notification"
+)
+
+var templateNames = []string{ActivateTemplate, ResetPasswordTemplate, NotifyTemplate}
+
+const layoutTemplate = "layout"
+
+//go:embed templates
+var defaultTemplates embed.FS
+
+// ActivateData fills the ActivateTemplate.
+type ActivateData struct {
+	Username string
+	Link     string
+	// Expires is how long the link is valid.
+	Expires time.Duration
+}
+
+// ResetPasswordData fills the ResetPasswordTemplate.
+type ResetPasswordData struct {
+	Username string
+	Link     string
+	// Expires is how long the link is valid.
+	Expires time.Duration
+}
+
+// NotifyData fills the NotifyTemplate.
+type NotifyData struct {
+	Username string
+	Subject  string
+	Content  string
+	Link     string
+}
+
+var templateFuncs = template.FuncMap{
+	"hours": func(d time.Duration) int {
+		return int(d.Hours())
+	},
+}
+
+// Templates renders mails from html templates, along with their plain text alternative.
+type Templates struct {
+	sets map[string]*template.Template
+}
+
+// DefaultTemplates returns the templates shipped with the binary.
+func DefaultTemplates() *Templates {
+	fsys, err := fs.Sub(defaultTemplates, "templates")
+	if err != nil {
+		panic(err)
+	}
+	t, err := ParseTemplates(fsys)
+	if err != nil {
+		panic(err)
+	}
+	return t
+}
+
+// ParseTemplates parses layout.tmpl and the mail templates, as <name>.tmpl files, of fsys.
+func ParseTemplates(fsys fs.FS) (*Templates, error) {
+	t := &Templates{
+		sets: make(map[string]*template.Template, len(templateNames)),
+	}
+	for _, name := range templateNames {
+		set, err := template.New(name).Funcs(templateFuncs).ParseFS(fsys, layoutTemplate+".tmpl", name+".tmpl")
+		if err != nil {
+			return nil, fmt.Errorf("failed to parse mail template %s: %v", name, err)
+		}
+		t.sets[name] = set
+	}
+	return t, nil
+}
+
+// Render returns the mail rendered from the named template, the recipients are left to the caller.
+func (t *Templates) Render(name string, data interface{}) (*service.Mail, error) {
+	set, ok := t.sets[name]
+	if !ok {
+		return nil, fmt.Errorf("unknown mail template %s", name)
+	}
+
+	var subject, body bytes.Buffer
+	if err := set.ExecuteTemplate(&subject, "subject", data); err != nil {
+		return nil, fmt.Errorf("failed to render the subject of %s: %v", name, err)
+	}
+	if err := set.ExecuteTemplate(&body, layoutTemplate, data); err != nil {
+		return nil, fmt.Errorf("failed to render %s: %v", name, err)
+	}
+
+	text, err := html2text.FromString(body.String())
+	if err != nil {
+		return nil, fmt.Errorf("failed to render the text of %s: %v", name, err)
+	}
+
+	return &service.Mail{
+		// the subject is escaped for html, mail headers are plain text.
+		Subject:  strings.TrimSpace(html.UnescapeString(subject.String())),
+		HTML:     body.String(),
+		Text:     text,
+		Template: name,
+	}, nil
+}
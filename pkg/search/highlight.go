@@ -0,0 +1,109 @@
+package search
+
+import (
+	"html"
+	"strings"
+	"unicode/utf8"
+)
+
+const (
+	// HighlightStart and HighlightEnd surround the matched terms of a fragment.
+	HighlightStart = "<mark>"
+	HighlightEnd   = "</mark>"
+)
+
+type span struct {
+	start, end int
+}
+
+// Highlight returns an HTML escaped fragment of text of about size bytes around the
+// first match of terms, with the matches wrapped in HighlightStart and HighlightEnd.
+// The beginning of text is returned if no term matches.
+func Highlight(text string, terms []string, size int) string {
+	match := make(map[string]bool, len(terms))
+	for _, t := range terms {
+		match[t] = true
+	}
+
+	var spans []span
+	for _, t := range Tokenize(text) {
+		if !match[t.Term] {
+			continue
+		}
+		// bigrams of CJK runs overlap, they are merged into a single span.
+		if n := len(spans); n > 0 && t.Start <= spans[n-1].end {
+			if t.End > spans[n-1].end {
+				spans[n-1].end = t.End
+			}
+			continue
+		}
+		spans = append(spans, span{t.Start, t.End})
+	}
+
+	from, to := fragment(text, spans, size)
+
+	var b strings.Builder
+	if from > 0 {
+		b.WriteString("…")
+	}
+	pos := from
+	for _, s := range spans {
+		if s.end <= from || s.start >= to {
+			continue
+		}
+		start, end := max(s.start, from), min(s.end, to)
+		b.WriteString(html.EscapeString(text[pos:start]))
+		b.WriteString(HighlightStart)
+		b.WriteString(html.EscapeString(text[start:end]))
+		b.WriteString(HighlightEnd)
+		pos = end
+	}
+	b.WriteString(html.EscapeString(text[pos:to]))
+	if to < len(text) {
+		b.WriteString("…")
+	}
+	return b.String()
+}
+
+// fragment returns the byte range of the fragment, starting a little before the
+// first span and aligned on runes.
+func fragment(text string, spans []span, size int) (int, int) {
+	if size <= 0 || len(text) <= size {
+		return 0, len(text)
+	}
+
+	from := 0
+	if len(spans) > 0 {
+		from = spans[0].start - size/4
+		if from < 0 {
+			from = 0
+		}
+	}
+	to := from + size
+	if to > len(text) {
+		to = len(text)
+		from = to - size
+	}
+
+	for from > 0 && !utf8.RuneStart(text[from]) {
+		from--
+	}
+	for to < len(text) && !utf8.RuneStart(text[to]) {
+		to++
+	}
+	return from, to
+}
+
+func max(a, b int) int {
+	if a > b {
+		return a
+	}
+	return b
+}
+
+func min(a, b int) int {
+	if a < b {
+		return a
+	}
+	return b
+}
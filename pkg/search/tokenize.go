@@ -0,0 +1,119 @@
+// Package search holds the text analysis of the full-text index: tokenization
+// of mixed Latin and CJK text and highlighting of the matched terms.
+package search
+
+import (
+	"strings"
+	"unicode"
+	"unicode/utf8"
+)
+
+// MaxTermSize is the size in bytes of the longest term, longer words are cut so
+// that the terms fit in the keys of the index.
+const MaxTermSize = 64
+
+// Token is a term of a text with its byte offsets in the text.
+type Token struct {
+	Term  string
+	Start int
+	End   int
+}
+
+// isCJK reports whether the rune is written without spaces between words.
+func isCJK(r rune) bool {
+	return unicode.In(r, unicode.Han, unicode.Hiragana, unicode.Katakana, unicode.Hangul)
+}
+
+func isWord(r rune) bool {
+	return unicode.IsLetter(r) || unicode.IsDigit(r)
+}
+
+// Tokenize splits text into lower cased words. Runs of CJK characters, which have
+// no word boundaries, are split into overlapping bigrams, a single character stays a unigram.
+func Tokenize(text string) []Token {
+	var tokens []Token
+	for i := 0; i < len(text); {
+		r, size := utf8.DecodeRuneInString(text[i:])
+		switch {
+		case isCJK(r):
+			end := i
+			var runes []int
+			for end < len(text) {
+				r, size := utf8.DecodeRuneInString(text[end:])
+				if !isCJK(r) {
+					break
+				}
+				runes = append(runes, end)
+				end += size
+			}
+			runes = append(runes, end)
+			tokens = append(tokens, cjkBigrams(text, runes)...)
+			i = end
+		case isWord(r):
+			end := i
+			for end < len(text) {
+				r, size := utf8.DecodeRuneInString(text[end:])
+				if !isWord(r) || isCJK(r) {
+					break
+				}
+				end += size
+			}
+			tokens = append(tokens, Token{Term: truncate(strings.ToLower(text[i:end])), Start: i, End: end})
+			i = end
+		default:
+			i += size
+		}
+	}
+	return tokens
+}
+
+// truncate cuts the term to MaxTermSize bytes on a rune boundary.
+func truncate(term string) string {
+	if len(term) <= MaxTermSize {
+		return term
+	}
+
+	end := MaxTermSize
+	for end > 0 && !utf8.RuneStart(term[end]) {
+		end--
+	}
+	return term[:end]
+}
+
+// cjkBigrams returns the bigrams of a CJK run, offsets holds the start of each rune
+// followed by the end of the run.
+func cjkBigrams(text string, offsets []int) []Token {
+	n := len(offsets) - 1
+	if n == 1 {
+		return []Token{{Term: text[offsets[0]:offsets[1]], Start: offsets[0], End: offsets[1]}}
+	}
+
+	tokens := make([]Token, 0, n-1)
+	for j := 0; j+2 <= n; j++ {
+		start, end := offsets[j], offsets[j+2]
+		tokens = append(tokens, Token{Term: text[start:end], Start: start, End: end})
+	}
+	return tokens
+}
+
+// Terms returns the distinct terms of text in order of appearance.
+func Terms(text string) []string {
+	seen := map[string]bool{}
+	var terms []string
+	for _, t := range Tokenize(text) {
+		if !seen[t.Term] {
+			seen[t.Term] = true
+			terms = append(terms, t.Term)
+		}
+	}
+	return terms
+}
+
+// Frequencies returns the number of occurrences of each term of text.
+func Frequencies(text string) map[string]int {
+	tf := map[string]int{}
+	for _, t := range Tokenize(text) {
+		tf[t.Term]++
+	}
+	return tf
+}
@@ -0,0 +1,85 @@
+package service
+
+import (
+	"context"
+	"time"
+
+	"github.com/ustackq/indagate/pkg/utils/errors"
+)
+
+// account service op
+const (
+	OpCreateAccountToken  = "CreateAccountToken"
+	OpConsumeAccountToken = "ConsumeAccountToken"
+	OpActivateUser        = "ActivateUser"
+)
+
+// ErrAccountTokenNotFound is returned for unknown, expired or already used account tokens.
+var ErrAccountTokenNotFound = &errors.Error{
+	Code: errors.NotFound,
+	Msg:  "account token not found or expired",
+}
+
+// AccountTokenKind is what an account token allows its bearer to do.
+type AccountTokenKind string
+
+const (
+	// ActivateToken activates the account of a new user.
+	ActivateToken AccountTokenKind = "activate"
+	// ResetPasswordToken sets a new password for a user who forgot theirs.
+	ResetPasswordToken AccountTokenKind = "reset_password"
+)
+
+// TTL is how long the tokens of the kind are valid.
+func (k AccountTokenKind) TTL() time.Duration {
+	if k == ResetPasswordToken {
+		return time.Hour
+	}
+	return 48 * time.Hour
+}
+
+// Valid returns an error if the kind is unknown.
+func (k AccountTokenKind) Valid() error {
+	switch k {
+	case ActivateToken, ResetPasswordToken:
+		return nil
+	}
+	return &errors.Error{
+		Code: errors.Invalid,
+		Msg:  "unknown account token kind " + string(k),
+	}
+}
+
+// AccountToken is a single use secret mailed to a user, the links of the
+// activation and password reset mails carry it.
+type AccountToken struct {
+	Token     string           `json:"token"`
+	Kind      AccountTokenKind `json:"kind"`
+	UserID    ID               `json:"userID"`
+	CreatedAt time.Time        `json:"createdAt"`
+	ExpiresAt time.Time        `json:"expiresAt"`
+}
+
+// AccountTokenService represents a service for managing the account tokens of users.
+type AccountTokenService interface {
+	// CreateAccountToken creates a token of the kind for the user, valid for kind.TTL().
+	CreateAccountToken(ctx context.Context, kind AccountTokenKind, userID ID) (*AccountToken, error)
+
+	// ConsumeAccountToken deletes the token and returns it, unless it is of another
+	// kind or has expired.
+	ConsumeAccountToken(ctx context.Context, kind AccountTokenKind, token string) (*AccountToken, error)
+
+	// ActivateUser consumes the activation token and marks its user as activated.
+	ActivateUser(ctx context.Context, token string) (*User, error)
+}
+
+// AccountMailer sends the account tokens to their users, along with the link to use them.
+type AccountMailer interface {
+	SendAccountMail(ctx context.Context, u *User, t *AccountToken) error
+}
+
+// NotificationMailer renders the mail which tells the recipient of a notification
+// about it, it returns a nil mail when the recipient gets none.
+type NotificationMailer interface {
+	NotificationMail(recipient, sender *User, n *Notification) (*Mail, error)
+}
@@ -0,0 +1,146 @@
+package service
+
+import (
+	"context"
+
+	"github.com/ustackq/indagate/pkg/utils/errors"
+)
+
+// answer service op
+const (
+	OpFindAnswerByID = "FindAnswerByID"
+	OpFindAnswers    = "FindAnswers"
+	OpCreateAnswer   = "CreateAnswer"
+	OpUpdateAnswer   = "UpdateAnswer"
+	OpDeleteAnswer   = "DeleteAnswer"
+	OpSetBestAnswer  = "SetBestAnswer"
+)
+
+const (
+	// AnswerSortByVotes sorts answers by agree count.
+	AnswerSortByVotes = "votes"
+	// AnswerSortByTime sorts answers by creation time.
+	AnswerSortByTime = "createdAt"
+)
+
+var (
+	// ErrAnswerNotFound is returned when an answer can not be found.
+	ErrAnswerNotFound = &errors.Error{
+		Code: errors.NotFound,
+		Msg:  "answer not found",
+	}
+)
+
+// Answer represents an answer to a question.
+type Answer struct {
+	ID           ID     `json:"id,omitempty"`
+	QuestionID   ID     `json:"questionID"`
+	OrgID        ID     `json:"orgID"`
+	UserID       ID     `json:"userID"`
+	Content      string `json:"content"`
+	AgreeCount   int    `json:"agreeCount"`
+	AgainstCount int    `json:"againstCount"`
+	ThanksCount  int    `json:"thanksCount"`
+	CommentCount int    `json:"commentCount"`
+	BestAnswer   bool   `json:"bestAnswer"`
+	Anonymous    bool   `json:"anonymous"`
+	// Hidden answers are only shown to their moderators.
+	Hidden bool `json:"hidden"`
+	// Pending answers wait for approval and are only shown to their author and moderators.
+	Pending bool `json:"pending"`
+	// UnverifiedModify is the edit waiting for approval, it is applied once approved.
+	UnverifiedModify *AnswerUpdate `json:"unverifiedModify,omitempty"`
+	// UnverifiedModifyCount is the number of edits merged into UnverifiedModify.
+	UnverifiedModifyCount int `json:"unverifiedModifyCount"`
+	OperationLog
+}
+
+// Visible reports whether the answer is shown to everyone.
+func (a *Answer) Visible() bool {
+	return !a.Hidden && !a.Pending
+}
+
+// Valid returns an error if the answer misses required fields.
+func (a *Answer) Valid() error {
+	if a.Content == "" {
+		return &errors.Error{
+			Code: errors.EmptyValue,
+			Msg:  "answer content is empty",
+		}
+	}
+
+	if !a.QuestionID.Valid() {
+		return &errors.Error{
+			Code: errors.Invalid,
+			Msg:  "question id required",
+		}
+	}
+
+	if !a.UserID.Valid() {
+		return &errors.Error{
+			Code: errors.Invalid,
+			Msg:  "user id required",
+		}
+	}
+	return nil
+}
+
+// AnswerFilter represents a set of filter that restrict the returned answers.
+type AnswerFilter struct {
+	ID         *ID
+	QuestionID *ID
+	UserID     *ID
+}
+
+// AnswerUpdate represents updates to an answer.
+// Only fields which are set are updated.
+type AnswerUpdate struct {
+	Content   *string `json:"content,omitempty"`
+	Anonymous *bool   `json:"anonymous,omitempty"`
+}
+
+// Merge returns the update with the fields set by next overriding its own.
+func (u AnswerUpdate) Merge(next AnswerUpdate) AnswerUpdate {
+	if next.Content != nil {
+		u.Content = next.Content
+	}
+	if next.Anonymous != nil {
+		u.Anonymous = next.Anonymous
+	}
+	return u
+}
+
+// Valid returns an error if the update would empty the answer.
+func (u AnswerUpdate) Valid() error {
+	if u.Content != nil && *u.Content == "" {
+		return &errors.Error{
+			Code: errors.EmptyValue,
+			Msg:  "answer content is empty",
+		}
+	}
+	return nil
+}
+
+// AnswerService represents a service for managing answer data.
+type AnswerService interface {
+	// FindAnswerByID returns a single answer by ID.
+	FindAnswerByID(ctx context.Context, id ID) (*Answer, error)
+
+	// FindAnswers returns a list of answers that match filter and the total count of matching answers.
+	// Additional options provide pagination & sorting by AnswerSortByVotes or AnswerSortByTime.
+	FindAnswers(ctx context.Context, filter AnswerFilter, opt ...FindOptions) ([]*Answer, int, error)
+
+	// CreateAnswer creates a new answer and sets a.ID with the new identifier.
+	CreateAnswer(ctx context.Context, a *Answer) error
+
+	// UpdateAnswer updates a single answer with changeset.
+	// Returns the new answer state after update.
+	UpdateAnswer(ctx context.Context, id ID, upd AnswerUpdate) (*Answer, error)
+
+	// DeleteAnswer removes an answer by ID.
+	DeleteAnswer(ctx context.Context, id ID) error
+
+	// SetBestAnswer marks the answer as the best answer of the question.
+	// Returns the new question state after update.
+	SetBestAnswer(ctx context.Context, questionID, answerID ID) (*Question, error)
+}
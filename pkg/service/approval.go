@@ -0,0 +1,183 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/ustackq/indagate/pkg/utils/diff"
+	"github.com/ustackq/indagate/pkg/utils/errors"
+)
+
+// approval service op
+const (
+	OpFindApprovalByID = "FindApprovalByID"
+	OpFindApprovals    = "FindApprovals"
+	OpFindApprovalDiff = "FindApprovalDiff"
+	OpReviewApproval   = "ReviewApproval"
+)
+
+var (
+	// ErrApprovalNotFound is returned when an approval can not be found.
+	ErrApprovalNotFound = &errors.Error{
+		Code: errors.NotFound,
+		Msg:  "approval not found",
+	}
+
+	// ErrApprovalReviewed is returned when reviewing an approval twice.
+	ErrApprovalReviewed = &errors.Error{
+		Code: errors.Conflict,
+		Msg:  "approval has already been reviewed",
+	}
+)
+
+// ApprovalPolicy decides which posts wait for the approval of a reviewer before
+// being published, on top of the posts held by the content filters. The zero
+// value publishes everything right away.
+type ApprovalPolicy struct {
+	// ReputationThreshold reviews the posts of users whose reputation is below it.
+	ReputationThreshold int
+	// FirstPosts reviews the first posts of every user.
+	FirstPosts int
+	// Links reviews the posts containing links.
+	Links bool
+}
+
+// Enabled reports whether any post can wait for approval under the policy.
+func (p ApprovalPolicy) Enabled() bool {
+	return p.ReputationThreshold > 0 || p.FirstPosts > 0 || p.Links
+}
+
+// ApprovalReason is the rule of the policy which routed a post to the reviewers.
+type ApprovalReason string
+
+const (
+	// ReputationApprovalReason is given when the author's reputation is below the threshold.
+	ReputationApprovalReason ApprovalReason = "reputation"
+	// FirstPostsApprovalReason is given for the first posts of the author.
+	FirstPostsApprovalReason ApprovalReason = "firstPosts"
+	// LinksApprovalReason is given when the post contains links.
+	LinksApprovalReason ApprovalReason = "links"
+	// FilterApprovalReason is given when a content filter holds the post.
+	FilterApprovalReason ApprovalReason = "filter"
+)
+
+// ApprovalKind tells whether a new post or the edit of a post waits for approval.
+type ApprovalKind string
+
+const (
+	// CreateApproval holds back a new post, it is deleted when rejected.
+	CreateApproval ApprovalKind = "create"
+	// EditApproval holds back the UnverifiedModify of a post, it is discarded when rejected.
+	EditApproval ApprovalKind = "edit"
+)
+
+// ApprovalStatus is the state of an approval.
+type ApprovalStatus string
+
+const (
+	// ApprovalPending approvals wait for a reviewer.
+	ApprovalPending ApprovalStatus = "pending"
+	// ApprovalApproved approvals have published their post or edit.
+	ApprovalApproved ApprovalStatus = "approved"
+	// ApprovalRejected approvals have discarded their post or edit.
+	ApprovalRejected ApprovalStatus = "rejected"
+)
+
+// Valid returns an error if the status is unknown.
+func (s ApprovalStatus) Valid() error {
+	switch s {
+	case ApprovalPending, ApprovalApproved, ApprovalRejected:
+		return nil
+	}
+	return &errors.Error{
+		Code: errors.Invalid,
+		Msg:  fmt.Sprintf("unknown approval status %q", s),
+	}
+}
+
+// Approval is a post or an edit waiting for, or having received, the decision of a reviewer.
+type Approval struct {
+	ID   ID           `json:"id,omitempty"`
+	Kind ApprovalKind `json:"kind"`
+	// ItemType and ItemID are the question, answer or comment held back.
+	ItemType ItemType `json:"itemType"`
+	ItemID   ID       `json:"itemID"`
+	OrgID    ID       `json:"orgID"`
+	// UserID is the author of the post.
+	UserID  ID               `json:"userID"`
+	Reasons []ApprovalReason `json:"reasons"`
+	Status  ApprovalStatus   `json:"status"`
+	// Review is the decision of the reviewer once the approval is no longer pending.
+	Review    *ApprovalReview `json:"review,omitempty"`
+	CreatedAt time.Time       `json:"createdAt"`
+	UpdatedAt time.Time       `json:"updatedAt"`
+}
+
+// ApprovalReview records the decision of a reviewer.
+type ApprovalReview struct {
+	// Decision is either ApprovalApproved or ApprovalRejected.
+	Decision ApprovalStatus `json:"decision"`
+	// Comment explains the decision to the author, it is required for rejections.
+	Comment    string    `json:"comment,omitempty"`
+	ReviewerID ID        `json:"reviewerID"`
+	ReviewedAt time.Time `json:"reviewedAt"`
+}
+
+// Valid returns an error if the review misses required fields.
+func (r *ApprovalReview) Valid() error {
+	if r.Decision != ApprovalApproved && r.Decision != ApprovalRejected {
+		return &errors.Error{
+			Code: errors.Invalid,
+			Msg:  fmt.Sprintf("review decision must be %q or %q", ApprovalApproved, ApprovalRejected),
+		}
+	}
+
+	if r.Decision == ApprovalRejected && r.Comment == "" {
+		return &errors.Error{
+			Code: errors.EmptyValue,
+			Msg:  "rejection comment is empty",
+		}
+	}
+
+	if !r.ReviewerID.Valid() {
+		return &errors.Error{
+			Code: errors.Invalid,
+			Msg:  "reviewer id is invalid",
+		}
+	}
+	return nil
+}
+
+// ApprovalDiff is the difference a pending approval would make to a field of its post.
+type ApprovalDiff struct {
+	Field string      `json:"field"`
+	Lines []diff.Line `json:"lines"`
+}
+
+// ApprovalFilter represents a set of filters that restrict the returned approvals.
+type ApprovalFilter struct {
+	OrgID    *ID
+	UserID   *ID
+	ItemType *ItemType
+	Status   *ApprovalStatus
+}
+
+// ApprovalService represents a service for reviewing the posts held back by the ApprovalPolicy
+// and the content filters. Reviews train the spam filters.
+type ApprovalService interface {
+	// FindApprovalByID returns a single approval by ID.
+	FindApprovalByID(ctx context.Context, id ID) (*Approval, error)
+
+	// FindApprovals returns the approvals matching the filter, oldest first, and the
+	// total count of them.
+	FindApprovals(ctx context.Context, filter ApprovalFilter, opt ...FindOptions) ([]*Approval, int, error)
+
+	// FindApprovalDiff returns the changes a pending approval would publish, field by field.
+	// New posts are compared to empty fields, reviewed approvals have no changes left.
+	FindApprovalDiff(ctx context.Context, id ID) ([]*ApprovalDiff, error)
+
+	// ReviewApproval publishes or discards the post or edit of the approval and
+	// notifies its author of the decision.
+	ReviewApproval(ctx context.Context, id ID, r *ApprovalReview) (*Approval, error)
+}
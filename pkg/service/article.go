@@ -0,0 +1,211 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/ustackq/indagate/pkg/utils/errors"
+)
+
+// article service op
+const (
+	OpFindArticleByID = "FindArticleByID"
+	OpFindArticles    = "FindArticles"
+	OpCreateArticle   = "CreateArticle"
+	OpUpdateArticle   = "UpdateArticle"
+	OpDeleteArticle   = "DeleteArticle"
+	OpPublishArticle  = "PublishArticle"
+	OpArchiveArticle  = "ArchiveArticle"
+	OpViewArticle     = "ViewArticle"
+)
+
+const (
+	// ArticleSortByTime sorts articles by creation time.
+	ArticleSortByTime = "createdAt"
+	// ArticleSortByPublished sorts articles by publication time.
+	ArticleSortByPublished = "publishedAt"
+	// ArticleSortByVotes sorts articles by agree count.
+	ArticleSortByVotes = "votes"
+	// ArticleSortByViews sorts articles by view count.
+	ArticleSortByViews = "views"
+)
+
+var (
+	// ErrArticleNotFound is returned when an article can not be found.
+	ErrArticleNotFound = &errors.Error{
+		Code: errors.NotFound,
+		Msg:  "article not found",
+	}
+
+	// ErrArticleNotPublished is returned when interacting with an article which is not published.
+	ErrArticleNotPublished = &errors.Error{
+		Code: errors.Forbidden,
+		Msg:  "article is not published",
+	}
+
+	// ErrArticleArchived is returned when modifying an archived article.
+	ErrArticleArchived = &errors.Error{
+		Code: errors.Forbidden,
+		Msg:  "article is archived",
+	}
+)
+
+// ArticleState is the publication state of an article.
+type ArticleState string
+
+const (
+	// ArticleDraft is only visible to its author.
+	ArticleDraft ArticleState = "draft"
+	// ArticlePublished is visible to everyone and open to votes and comments.
+	ArticlePublished ArticleState = "published"
+	// ArticleArchived is still visible but can no longer be changed.
+	ArticleArchived ArticleState = "archived"
+)
+
+// articleTransitions lists the states an article can move to from each state.
+var articleTransitions = map[ArticleState][]ArticleState{
+	ArticleDraft:     {ArticlePublished},
+	ArticlePublished: {ArticleArchived},
+	ArticleArchived:  {ArticlePublished},
+}
+
+// Valid returns an error if the state is unknown.
+func (s ArticleState) Valid() error {
+	if _, ok := articleTransitions[s]; !ok {
+		return &errors.Error{
+			Code: errors.Invalid,
+			Msg:  fmt.Sprintf("unknown article state %q", s),
+		}
+	}
+	return nil
+}
+
+// CanTransition returns an error if an article can not move from s to next.
+func (s ArticleState) CanTransition(next ArticleState) error {
+	for _, to := range articleTransitions[s] {
+		if to == next {
+			return nil
+		}
+	}
+	return &errors.Error{
+		Code: errors.Conflict,
+		Msg:  fmt.Sprintf("article can not move from %s to %s", s, next),
+	}
+}
+
+// Article represents a long-form post written in an organization.
+type Article struct {
+	ID           ID           `json:"id,omitempty"`
+	OrgID        ID           `json:"orgID"`
+	UserID       ID           `json:"userID"`
+	Title        string       `json:"title"`
+	Abstract     string       `json:"abstract,omitempty"`
+	Content      string       `json:"content"`
+	State        ArticleState `json:"state"`
+	PublishedAt  *time.Time   `json:"publishedAt,omitempty"`
+	ArchivedAt   *time.Time   `json:"archivedAt,omitempty"`
+	ViewCount    int          `json:"viewCount"`
+	CommentCount int          `json:"commentCount"`
+	AgreeCount   int          `json:"agreeCount"`
+	AgainstCount int          `json:"againstCount"`
+	ThanksCount  int          `json:"thanksCount"`
+	OperationLog
+}
+
+// Valid returns an error if the article misses required fields.
+func (a *Article) Valid() error {
+	if a.Title == "" {
+		return &errors.Error{
+			Code: errors.EmptyValue,
+			Msg:  "article title is empty",
+		}
+	}
+
+	if a.Content == "" {
+		return &errors.Error{
+			Code: errors.EmptyValue,
+			Msg:  "article content is empty",
+		}
+	}
+
+	if !a.OrgID.Valid() {
+		return &errors.Error{
+			Code: errors.Invalid,
+			Msg:  "org id required",
+		}
+	}
+
+	if !a.UserID.Valid() {
+		return &errors.Error{
+			Code: errors.Invalid,
+			Msg:  "user id required",
+		}
+	}
+	return nil
+}
+
+// ArticleFilter represents a set of filter that restrict the returned articles.
+type ArticleFilter struct {
+	ID     *ID
+	OrgID  *ID
+	UserID *ID
+	State  *ArticleState
+}
+
+// ArticleUpdate represents updates to an article.
+// Only fields which are set are updated.
+type ArticleUpdate struct {
+	Title    *string `json:"title,omitempty"`
+	Abstract *string `json:"abstract,omitempty"`
+	Content  *string `json:"content,omitempty"`
+}
+
+// Valid returns an error if the update would empty the article.
+func (u ArticleUpdate) Valid() error {
+	if u.Title != nil && *u.Title == "" {
+		return &errors.Error{
+			Code: errors.EmptyValue,
+			Msg:  "article title is empty",
+		}
+	}
+
+	if u.Content != nil && *u.Content == "" {
+		return &errors.Error{
+			Code: errors.EmptyValue,
+			Msg:  "article content is empty",
+		}
+	}
+	return nil
+}
+
+// ArticleService represents a service for managing article data.
+type ArticleService interface {
+	// FindArticleByID returns a single article by ID.
+	FindArticleByID(ctx context.Context, id ID) (*Article, error)
+
+	// FindArticles returns a list of articles that match filter and the total count of matching articles.
+	// Additional options provide pagination & sorting by ArticleSortByTime, ArticleSortByPublished,
+	// ArticleSortByVotes or ArticleSortByViews.
+	FindArticles(ctx context.Context, filter ArticleFilter, opt ...FindOptions) ([]*Article, int, error)
+
+	// CreateArticle creates a new draft article and sets a.ID with the new identifier.
+	CreateArticle(ctx context.Context, a *Article) error
+
+	// UpdateArticle updates a single article with changeset.
+	// Returns the new article state after update.
+	UpdateArticle(ctx context.Context, id ID, upd ArticleUpdate) (*Article, error)
+
+	// DeleteArticle removes an article by ID.
+	DeleteArticle(ctx context.Context, id ID) error
+
+	// PublishArticle publishes a draft or archived article.
+	PublishArticle(ctx context.Context, id ID) (*Article, error)
+
+	// ArchiveArticle archives a published article.
+	ArchiveArticle(ctx context.Context, id ID) (*Article, error)
+
+	// ViewArticle counts a view of a published article, views of the same session are counted once.
+	// Returns the new article state.
+	ViewArticle(ctx context.Context, id, sessionID ID) (*Article, error)
+}
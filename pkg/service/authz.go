@@ -1,6 +1,10 @@
 package service
 
-import "github.com/ustackq/indagate/pkg/utils/errors"
+import (
+	"fmt"
+
+	"github.com/ustackq/indagate/pkg/utils/errors"
+)
 
 // Action is an enum defining all possible resource operation.
 type Action string
@@ -12,6 +16,28 @@ const (
 
 )
 
+// Valid returns an error if the action is unknown.
+func (a Action) Valid() error {
+	switch a {
+	case ReadAction, WriteAction, READWRITEACTION:
+		return nil
+	default:
+		return &errors.Error{
+			Code: errors.Invalid,
+			Msg:  fmt.Sprintf("unknown action %q", a),
+		}
+	}
+}
+
+// Implies reports whether holding action a grants action b,
+// READWRITE grants both READ and WRITE.
+func (a Action) Implies(b Action) bool {
+	if a == b {
+		return true
+	}
+	return a == READWRITEACTION && (b == ReadAction || b == WriteAction)
+}
+
 const (
 	// BucketsResourceType gives permissions to one or more buckets.
 	BucketsResourceType = ResourceType("buckets") // 1
@@ -20,6 +46,18 @@ const (
 	OrgsResourceType = ResourceType("orgs") // 3
 	// UsersResourceType gives permissions to one or more users.
 	UsersResourceType = ResourceType("users") // 7
+	// QuestionsResourceType gives permissions to one or more questions.
+	QuestionsResourceType = ResourceType("questions")
+	// AnswersResourceType gives permissions to one or more answers.
+	AnswersResourceType = ResourceType("answers")
+	// CommentsResourceType gives permissions to one or more comments.
+	CommentsResourceType = ResourceType("comments")
+	// TopicsResourceType gives permissions to one or more topics.
+	TopicsResourceType = ResourceType("topics")
+	// ArticlesResourceType gives permissions to one or more articles.
+	ArticlesResourceType = ResourceType("articles")
+	// ModerationResourceType gives permissions to moderate the reports of an org.
+	ModerationResourceType = ResourceType("moderation")
 )
 
 var (
@@ -29,9 +67,35 @@ var (
 	}
 )
 
+// AllResourceTypes is the list of all known resource types.
+var AllResourceTypes = []ResourceType{
+	BucketsResourceType,
+	OrgsResourceType,
+	UsersResourceType,
+	QuestionsResourceType,
+	AnswersResourceType,
+	CommentsResourceType,
+	TopicsResourceType,
+	ArticlesResourceType,
+	ModerationResourceType,
+}
+
 // ResourceType is an enum defining all resource types that have a permission model in indagate.
 type ResourceType string
 
+// Valid returns an error if the resource type is unknown.
+func (t ResourceType) Valid() error {
+	for _, rt := range AllResourceTypes {
+		if t == rt {
+			return nil
+		}
+	}
+	return &errors.Error{
+		Code: errors.Invalid,
+		Msg:  fmt.Sprintf("unknown resource type %q", t),
+	}
+}
+
 // Resource is an authorizable resource.
 type Resource struct {
 	Type  ResourceType `json:"type"`
@@ -81,17 +145,68 @@ func NewGlobalPermission(a Action, rt ResourceType) (*Permission, error) {
 	return p, p.Valid()
 }
 
+// Valid returns an error if the permission holds an unknown action or resource type,
+// or if it is scoped to an invalid ID.
 func (p *Permission) Valid() error {
+	if err := p.Action.Valid(); err != nil {
+		return err
+	}
 
+	if err := p.Resource.Type.Valid(); err != nil {
+		return err
+	}
+
+	if p.Resource.ID != nil && !p.Resource.ID.Valid() {
+		return &errors.Error{
+			Code: errors.Invalid,
+			Msg:  fmt.Sprintf("permission %s has an invalid resource id", p),
+		}
+	}
+
+	if p.Resource.OrgID != nil && !p.Resource.OrgID.Valid() {
+		return &errors.Error{
+			Code: errors.Invalid,
+			Msg:  fmt.Sprintf("permission %s has an invalid org id", p),
+		}
+	}
 	return nil
 }
 
+// String returns a human-readable form of the permission, e.g. READ:orgs/<org>/questions/<id>.
+func (p Permission) String() string {
+	return string(p.Action) + ":" + p.Resource.String()
+}
+
+// String returns a human-readable form of the resource.
+func (r Resource) String() string {
+	s := ""
+	if r.OrgID != nil {
+		s = string(OrgsResourceType) + "/" + r.OrgID.String() + "/"
+	}
+	s += string(r.Type)
+	if r.ID != nil {
+		s += "/" + r.ID.String()
+	}
+	return s
+}
+
+// PermissionAllowed reports whether any permission of ps grants p.
 func PermissionAllowed(p Permission, ps []*Permission) bool {
-	return true
+	for _, perm := range ps {
+		if perm != nil && perm.Matches(p) {
+			return true
+		}
+	}
+	return false
 }
 
-func (p Permission) Mathches(perm Permission) bool {
-	if p.Action != perm.Action {
+// Matches reports whether the held permission p grants the requested permission perm.
+//
+// A global permission (neither org nor id) grants every resource of its type,
+// an org scoped permission grants every resource of its type within the org,
+// and an id scoped permission only grants that very resource.
+func (p Permission) Matches(perm Permission) bool {
+	if !p.Action.Implies(perm.Action) {
 		return false
 	}
 
@@ -99,16 +214,26 @@ func (p Permission) Mathches(perm Permission) bool {
 		return false
 	}
 
-	if p.Resource.ID != nil {
-		pID := *p.Resource.ID
-		if perm.Resource.ID != nil {
-			permID := *perm.Resource.ID
-			if pID == permID {
-				return true
-			}
+	switch {
+	case p.Resource.ID != nil:
+		if perm.Resource.ID == nil || *p.Resource.ID != *perm.Resource.ID {
+			return false
+		}
+		// an org mismatch means the id has been reused in another org.
+		if p.Resource.OrgID != nil && perm.Resource.OrgID != nil {
+			return *p.Resource.OrgID == *perm.Resource.OrgID
 		}
+		return true
+	case p.Resource.OrgID != nil:
+		if perm.Resource.OrgID != nil {
+			return *p.Resource.OrgID == *perm.Resource.OrgID
+		}
+		// an org scoped permission on orgs grants the org itself.
+		return p.Resource.Type == OrgsResourceType &&
+			perm.Resource.ID != nil && *perm.Resource.ID == *p.Resource.OrgID
+	default:
+		return true
 	}
-	return false
 }
 
 // Authorizer whicih provider authorize feature component must be impelemented.
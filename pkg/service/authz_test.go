@@ -0,0 +1,311 @@
+package service_test
+
+import (
+	"testing"
+
+	"github.com/ustackq/indagate/pkg/service"
+)
+
+func idPtr(id service.ID) *service.ID {
+	return &id
+}
+
+const (
+	orgOne   = service.ID(1)
+	orgTwo   = service.ID(2)
+	userOne  = service.ID(11)
+	userTwo  = service.ID(12)
+	question = service.ID(21)
+)
+
+// orgPermission mirrors the permission requested by authorizer.OrgService.
+func orgPermission(a service.Action, id service.ID) service.Permission {
+	return service.Permission{
+		Action: a,
+		Resource: service.Resource{
+			Type: service.OrgsResourceType,
+			ID:   idPtr(id),
+		},
+	}
+}
+
+// userPermission mirrors the permission requested by authorizer.UserService
+// and authorizer.AuthorizationService.
+func userPermission(a service.Action, id service.ID) service.Permission {
+	return service.Permission{
+		Action: a,
+		Resource: service.Resource{
+			Type: service.UsersResourceType,
+			ID:   idPtr(id),
+		},
+	}
+}
+
+func globalPermission(a service.Action, rt service.ResourceType) service.Permission {
+	return service.Permission{
+		Action: a,
+		Resource: service.Resource{
+			Type: rt,
+		},
+	}
+}
+
+func orgScopedPermission(a service.Action, rt service.ResourceType, orgID service.ID) service.Permission {
+	return service.Permission{
+		Action: a,
+		Resource: service.Resource{
+			Type:  rt,
+			OrgID: idPtr(orgID),
+		},
+	}
+}
+
+func idScopedPermission(a service.Action, rt service.ResourceType, orgID, id service.ID) service.Permission {
+	return service.Permission{
+		Action: a,
+		Resource: service.Resource{
+			Type:  rt,
+			ID:    idPtr(id),
+			OrgID: idPtr(orgID),
+		},
+	}
+}
+
+func TestPermissionAllowed(t *testing.T) {
+	tests := []struct {
+		name       string
+		held       []service.Permission
+		requested  service.Permission
+		wantResult bool
+	}{
+		{
+			name:       "no permissions",
+			requested:  orgPermission(service.ReadAction, orgOne),
+			wantResult: false,
+		},
+		{
+			name:       "read org by id",
+			held:       []service.Permission{orgPermission(service.ReadAction, orgOne)},
+			requested:  orgPermission(service.ReadAction, orgOne),
+			wantResult: true,
+		},
+		{
+			name:       "read does not imply write",
+			held:       []service.Permission{orgPermission(service.ReadAction, orgOne)},
+			requested:  orgPermission(service.WriteAction, orgOne),
+			wantResult: false,
+		},
+		{
+			name:       "write does not imply read",
+			held:       []service.Permission{orgPermission(service.WriteAction, orgOne)},
+			requested:  orgPermission(service.ReadAction, orgOne),
+			wantResult: false,
+		},
+		{
+			name:       "readwrite implies read",
+			held:       []service.Permission{orgPermission(service.READWRITEACTION, orgOne)},
+			requested:  orgPermission(service.ReadAction, orgOne),
+			wantResult: true,
+		},
+		{
+			name:       "readwrite implies write",
+			held:       []service.Permission{orgPermission(service.READWRITEACTION, orgOne)},
+			requested:  orgPermission(service.WriteAction, orgOne),
+			wantResult: true,
+		},
+		{
+			name:       "read does not imply readwrite",
+			held:       []service.Permission{orgPermission(service.ReadAction, orgOne)},
+			requested:  orgPermission(service.READWRITEACTION, orgOne),
+			wantResult: false,
+		},
+		{
+			name:       "org id mismatch",
+			held:       []service.Permission{orgPermission(service.READWRITEACTION, orgOne)},
+			requested:  orgPermission(service.ReadAction, orgTwo),
+			wantResult: false,
+		},
+		{
+			name:       "global org read grants any org",
+			held:       []service.Permission{globalPermission(service.ReadAction, service.OrgsResourceType)},
+			requested:  orgPermission(service.ReadAction, orgTwo),
+			wantResult: true,
+		},
+		{
+			name:       "global org write grants create org",
+			held:       []service.Permission{globalPermission(service.WriteAction, service.OrgsResourceType)},
+			requested:  globalPermission(service.WriteAction, service.OrgsResourceType),
+			wantResult: true,
+		},
+		{
+			name:       "org id permission does not grant create org",
+			held:       []service.Permission{orgPermission(service.READWRITEACTION, orgOne)},
+			requested:  globalPermission(service.WriteAction, service.OrgsResourceType),
+			wantResult: false,
+		},
+		{
+			name:       "org scoped orgs permission grants the org itself",
+			held:       []service.Permission{orgScopedPermission(service.ReadAction, service.OrgsResourceType, orgOne)},
+			requested:  orgPermission(service.ReadAction, orgOne),
+			wantResult: true,
+		},
+		{
+			name:       "org scoped orgs permission does not grant another org",
+			held:       []service.Permission{orgScopedPermission(service.ReadAction, service.OrgsResourceType, orgOne)},
+			requested:  orgPermission(service.ReadAction, orgTwo),
+			wantResult: false,
+		},
+		{
+			name:       "resource type mismatch",
+			held:       []service.Permission{globalPermission(service.READWRITEACTION, service.OrgsResourceType)},
+			requested:  userPermission(service.ReadAction, userOne),
+			wantResult: false,
+		},
+		{
+			name:       "read own user",
+			held:       []service.Permission{userPermission(service.ReadAction, userOne)},
+			requested:  userPermission(service.ReadAction, userOne),
+			wantResult: true,
+		},
+		{
+			name:       "read another user",
+			held:       []service.Permission{userPermission(service.READWRITEACTION, userOne)},
+			requested:  userPermission(service.ReadAction, userTwo),
+			wantResult: false,
+		},
+		{
+			name:       "global user read grants any user",
+			held:       []service.Permission{globalPermission(service.ReadAction, service.UsersResourceType)},
+			requested:  userPermission(service.ReadAction, userTwo),
+			wantResult: true,
+		},
+		{
+			name:       "global user write grants create user",
+			held:       []service.Permission{globalPermission(service.WriteAction, service.UsersResourceType)},
+			requested:  globalPermission(service.WriteAction, service.UsersResourceType),
+			wantResult: true,
+		},
+		{
+			name:       "user id permission does not grant create user",
+			held:       []service.Permission{userPermission(service.READWRITEACTION, userOne)},
+			requested:  globalPermission(service.WriteAction, service.UsersResourceType),
+			wantResult: false,
+		},
+		{
+			name:       "write own authorizations",
+			held:       []service.Permission{userPermission(service.WriteAction, userOne)},
+			requested:  userPermission(service.WriteAction, userOne),
+			wantResult: true,
+		},
+		{
+			name: "any held permission may match",
+			held: []service.Permission{
+				orgPermission(service.ReadAction, orgOne),
+				userPermission(service.ReadAction, userOne),
+			},
+			requested:  userPermission(service.ReadAction, userOne),
+			wantResult: true,
+		},
+		{
+			name:       "org scoped permission grants resources in org",
+			held:       []service.Permission{orgScopedPermission(service.ReadAction, service.QuestionsResourceType, orgOne)},
+			requested:  idScopedPermission(service.ReadAction, service.QuestionsResourceType, orgOne, question),
+			wantResult: true,
+		},
+		{
+			name:       "org scoped permission does not grant resources in another org",
+			held:       []service.Permission{orgScopedPermission(service.ReadAction, service.QuestionsResourceType, orgOne)},
+			requested:  idScopedPermission(service.ReadAction, service.QuestionsResourceType, orgTwo, question),
+			wantResult: false,
+		},
+		{
+			name:       "id scoped permission does not grant org scope",
+			held:       []service.Permission{idScopedPermission(service.ReadAction, service.QuestionsResourceType, orgOne, question)},
+			requested:  orgScopedPermission(service.ReadAction, service.QuestionsResourceType, orgOne),
+			wantResult: false,
+		},
+		{
+			name:       "id scoped permission in another org",
+			held:       []service.Permission{idScopedPermission(service.ReadAction, service.QuestionsResourceType, orgOne, question)},
+			requested:  idScopedPermission(service.ReadAction, service.QuestionsResourceType, orgTwo, question),
+			wantResult: false,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ps := make([]*service.Permission, 0, len(tt.held))
+			for i := range tt.held {
+				ps = append(ps, &tt.held[i])
+			}
+
+			if got := service.PermissionAllowed(tt.requested, ps); got != tt.wantResult {
+				t.Errorf("PermissionAllowed(%s, %v) = %v, want %v", tt.requested, tt.held, got, tt.wantResult)
+			}
+		})
+	}
+}
+
+func TestPermission_Valid(t *testing.T) {
+	tests := []struct {
+		name    string
+		perm    service.Permission
+		wantErr bool
+	}{
+		{
+			name: "global",
+			perm: globalPermission(service.ReadAction, service.OrgsResourceType),
+		},
+		{
+			name: "org id",
+			perm: orgPermission(service.READWRITEACTION, orgOne),
+		},
+		{
+			name: "user id",
+			perm: userPermission(service.WriteAction, userOne),
+		},
+		{
+			name: "id in org",
+			perm: idScopedPermission(service.WriteAction, service.AnswersResourceType, orgOne, question),
+		},
+		{
+			name:    "unknown action",
+			perm:    globalPermission(service.Action("DELETE"), service.OrgsResourceType),
+			wantErr: true,
+		},
+		{
+			name:    "empty action",
+			perm:    globalPermission("", service.OrgsResourceType),
+			wantErr: true,
+		},
+		{
+			name:    "unknown resource type",
+			perm:    globalPermission(service.ReadAction, service.ResourceType("widgets")),
+			wantErr: true,
+		},
+		{
+			name:    "empty resource type",
+			perm:    globalPermission(service.ReadAction, ""),
+			wantErr: true,
+		},
+		{
+			name:    "invalid id",
+			perm:    orgPermission(service.ReadAction, 0),
+			wantErr: true,
+		},
+		{
+			name:    "invalid org id",
+			perm:    orgScopedPermission(service.ReadAction, service.QuestionsResourceType, 0),
+			wantErr: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := tt.perm.Valid()
+			if (err != nil) != tt.wantErr {
+				t.Errorf("Valid() error = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+}
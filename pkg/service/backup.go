@@ -0,0 +1,34 @@
+package service
+
+import (
+	"context"
+	"io"
+	"time"
+)
+
+// backup service op
+const (
+	OpBackup = "Backup"
+)
+
+// BackupManifest describes a database snapshot, it is used to verify
+// the snapshot before it replaces the data of a server.
+type BackupManifest struct {
+	Version   string         `json:"version"`
+	Commit    string         `json:"commit"`
+	CreatedAt time.Time      `json:"createdAt"`
+	Size      int64          `json:"size"`
+	Buckets   []BackupBucket `json:"buckets"`
+}
+
+// BackupBucket is a bucket of the snapshot and the number of keys it holds.
+type BackupBucket struct {
+	Name string `json:"name"`
+	Keys int    `json:"keys"`
+}
+
+// BackupService takes consistent snapshots of a running server.
+type BackupService interface {
+	// Backup writes a snapshot of the database to w and returns its manifest.
+	Backup(ctx context.Context, w io.Writer) (*BackupManifest, error)
+}
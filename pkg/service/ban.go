@@ -0,0 +1,305 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"net"
+	"strings"
+	"time"
+
+	"github.com/ustackq/indagate/pkg/utils/errors"
+)
+
+// ban service op
+const (
+	OpFindBanByID   = "FindBanByID"
+	OpFindBans      = "FindBans"
+	OpBanUser       = "BanUser"
+	OpLiftBan       = "LiftBan"
+	OpCheckUser     = "CheckUser"
+	OpFindIPBlocks  = "FindIPBlocks"
+	OpCreateIPBlock = "CreateIPBlock"
+	OpDeleteIPBlock = "DeleteIPBlock"
+	OpCheckIP       = "CheckIP"
+	OpFindBanAudits = "FindBanAudits"
+)
+
+var (
+	// ErrBanNotFound is returned when a ban can not be found.
+	ErrBanNotFound = &errors.Error{
+		Code: errors.NotFound,
+		Msg:  "ban not found",
+	}
+
+	// ErrBanLifted is returned when lifting a ban which is no longer in force.
+	ErrBanLifted = &errors.Error{
+		Code: errors.Conflict,
+		Msg:  "ban is no longer in force",
+	}
+
+	// ErrUserAlreadyBanned is returned when banning a user whose ban is in force,
+	// the ban has to be lifted first.
+	ErrUserAlreadyBanned = &errors.Error{
+		Code: errors.Conflict,
+		Msg:  "user is already banned",
+	}
+
+	// ErrUserBanned is returned for the requests of banned users.
+	ErrUserBanned = &errors.Error{
+		Code: errors.Forbidden,
+		Msg:  "user is banned",
+	}
+
+	// ErrIPBlockNotFound is returned when an IP block can not be found.
+	ErrIPBlockNotFound = &errors.Error{
+		Code: errors.NotFound,
+		Msg:  "ip block not found",
+	}
+
+	// ErrIPBlocked is returned for the requests coming from a blocked IP range.
+	ErrIPBlocked = &errors.Error{
+		Code: errors.Forbidden,
+		Msg:  "ip address is blocked",
+	}
+)
+
+// Ban keeps a user from signing in and using the API. Suspensions end at ExpiresAt,
+// permanent bans have none.
+type Ban struct {
+	ID     ID     `json:"id,omitempty"`
+	UserID ID     `json:"userID"`
+	Reason string `json:"reason"`
+	// ExpiresAt ends a suspension, permanent bans never expire.
+	ExpiresAt *time.Time `json:"expiresAt,omitempty"`
+	// CreatedBy is the admin who banned the user.
+	CreatedBy ID        `json:"createdBy"`
+	CreatedAt time.Time `json:"createdAt"`
+	// LiftedAt and LiftedBy are set when an admin lifts the ban before its end.
+	LiftedAt *time.Time `json:"liftedAt,omitempty"`
+	LiftedBy ID         `json:"liftedBy,omitempty"`
+}
+
+// Permanent reports whether the ban never expires.
+func (b *Ban) Permanent() bool {
+	return b.ExpiresAt == nil
+}
+
+// Active reports whether the ban is in force at now.
+func (b *Ban) Active(now time.Time) bool {
+	if b.LiftedAt != nil {
+		return false
+	}
+	return b.Permanent() || now.Before(*b.ExpiresAt)
+}
+
+// Valid returns an error if the ban misses required fields.
+func (b *Ban) Valid() error {
+	if !b.UserID.Valid() {
+		return &errors.Error{
+			Code: errors.Invalid,
+			Msg:  "banned user id is invalid",
+		}
+	}
+
+	if b.Reason == "" {
+		return &errors.Error{
+			Code: errors.EmptyValue,
+			Msg:  "ban reason is empty",
+		}
+	}
+
+	if !b.CreatedBy.Valid() {
+		return &errors.Error{
+			Code: errors.Invalid,
+			Msg:  "ban creator id is invalid",
+		}
+	}
+	return nil
+}
+
+// BanFilter represents a set of filters that restrict the returned bans.
+type BanFilter struct {
+	UserID *ID
+	// Active only returns the bans in force, or no longer in force when false.
+	Active *bool
+}
+
+// IPBlockScope tells which requests an IP block applies to.
+type IPBlockScope string
+
+const (
+	// AccessIPBlock rejects every request from the range.
+	AccessIPBlock IPBlockScope = "access"
+	// RegistrationIPBlock rejects the creation of users from the range.
+	RegistrationIPBlock IPBlockScope = "registration"
+)
+
+// Valid returns an error if the scope is unknown.
+func (s IPBlockScope) Valid() error {
+	switch s {
+	case AccessIPBlock, RegistrationIPBlock:
+		return nil
+	}
+	return &errors.Error{
+		Code: errors.Invalid,
+		Msg:  fmt.Sprintf("unknown ip block scope %q", s),
+	}
+}
+
+// IPBlock rejects the requests of the scope coming from the CIDR range.
+type IPBlock struct {
+	ID ID `json:"id,omitempty"`
+	// CIDR is the blocked range, single addresses are stored as /32 or /128 ranges.
+	CIDR   string       `json:"cidr"`
+	Scope  IPBlockScope `json:"scope"`
+	Reason string       `json:"reason"`
+	// ExpiresAt ends the block, blocks without one never expire.
+	ExpiresAt *time.Time `json:"expiresAt,omitempty"`
+	CreatedBy ID         `json:"createdBy"`
+	CreatedAt time.Time  `json:"createdAt"`
+}
+
+// ParseCIDR parses a CIDR range or a single IP address.
+func ParseCIDR(s string) (*net.IPNet, error) {
+	if !strings.Contains(s, "/") {
+		ip := net.ParseIP(s)
+		if ip == nil {
+			return nil, &errors.Error{
+				Code: errors.Invalid,
+				Msg:  fmt.Sprintf("invalid ip address %q", s),
+			}
+		}
+
+		bits := 8 * net.IPv6len
+		if v4 := ip.To4(); v4 != nil {
+			ip, bits = v4, 8*net.IPv4len
+		}
+		return &net.IPNet{IP: ip, Mask: net.CIDRMask(bits, bits)}, nil
+	}
+
+	_, n, err := net.ParseCIDR(s)
+	if err != nil {
+		return nil, &errors.Error{
+			Code: errors.Invalid,
+			Msg:  fmt.Sprintf("invalid cidr range %q", s),
+			Err:  err,
+		}
+	}
+	return n, nil
+}
+
+// Contains reports whether the ip is within the blocked range.
+func (b *IPBlock) Contains(ip net.IP) bool {
+	n, err := ParseCIDR(b.CIDR)
+	if err != nil {
+		return false
+	}
+	return n.Contains(ip)
+}
+
+// Active reports whether the block is in force at now.
+func (b *IPBlock) Active(now time.Time) bool {
+	return b.ExpiresAt == nil || now.Before(*b.ExpiresAt)
+}
+
+// Valid returns an error if the block misses required fields.
+func (b *IPBlock) Valid() error {
+	if _, err := ParseCIDR(b.CIDR); err != nil {
+		return err
+	}
+
+	if err := b.Scope.Valid(); err != nil {
+		return err
+	}
+
+	if b.Reason == "" {
+		return &errors.Error{
+			Code: errors.EmptyValue,
+			Msg:  "ip block reason is empty",
+		}
+	}
+
+	if !b.CreatedBy.Valid() {
+		return &errors.Error{
+			Code: errors.Invalid,
+			Msg:  "ip block creator id is invalid",
+		}
+	}
+	return nil
+}
+
+// IPBlockFilter represents a set of filters that restrict the returned IP blocks.
+type IPBlockFilter struct {
+	Scope *IPBlockScope
+}
+
+// BanAuditAction is the change recorded by an audit entry.
+type BanAuditAction string
+
+const (
+	// BanUserAudit records a new ban or suspension.
+	BanUserAudit BanAuditAction = "ban"
+	// LiftBanAudit records a ban lifted before its end.
+	LiftBanAudit BanAuditAction = "lift"
+	// BlockIPAudit records a new IP block.
+	BlockIPAudit BanAuditAction = "block"
+	// UnblockIPAudit records a deleted IP block.
+	UnblockIPAudit BanAuditAction = "unblock"
+)
+
+// BanAudit is an entry of the audit trail of bans and IP blocks, entries are never
+// changed nor deleted.
+type BanAudit struct {
+	ID     ID             `json:"id,omitempty"`
+	Action BanAuditAction `json:"action"`
+	// TargetID is the ban or the IP block changed.
+	TargetID ID `json:"targetID"`
+	// UserID is the banned user, CIDR the blocked range.
+	UserID ID     `json:"userID,omitempty"`
+	CIDR   string `json:"cidr,omitempty"`
+	// ActorID is the admin who made the change.
+	ActorID   ID        `json:"actorID"`
+	Reason    string    `json:"reason,omitempty"`
+	CreatedAt time.Time `json:"createdAt"`
+}
+
+// BanAuditFilter represents a set of filters that restrict the returned audit entries.
+type BanAuditFilter struct {
+	UserID  *ID
+	ActorID *ID
+}
+
+// BanService represents a service for banning users and blocking IP ranges,
+// every change is recorded in an audit trail.
+type BanService interface {
+	// FindBanByID returns a single ban by ID.
+	FindBanByID(ctx context.Context, id ID) (*Ban, error)
+
+	// FindBans returns the bans matching the filter, latest first, and the total count of them.
+	FindBans(ctx context.Context, filter BanFilter, opt ...FindOptions) ([]*Ban, int, error)
+
+	// BanUser bans the user until b.ExpiresAt, or for good, and sets b.ID with the new
+	// identifier. The sessions of the user are expired and the tokens deactivated.
+	BanUser(ctx context.Context, b *Ban) error
+
+	// LiftBan ends the ban before its end.
+	LiftBan(ctx context.Context, id ID, liftedBy ID) (*Ban, error)
+
+	// CheckUser returns an error with the Forbidden code if the user is banned.
+	CheckUser(ctx context.Context, userID ID) error
+
+	// FindIPBlocks returns the IP blocks matching the filter, latest first, and the total count of them.
+	FindIPBlocks(ctx context.Context, filter IPBlockFilter, opt ...FindOptions) ([]*IPBlock, int, error)
+
+	// CreateIPBlock blocks the range and sets b.ID with the new identifier.
+	CreateIPBlock(ctx context.Context, b *IPBlock) error
+
+	// DeleteIPBlock removes the block.
+	DeleteIPBlock(ctx context.Context, id ID, deletedBy ID) error
+
+	// CheckIP returns an error with the Forbidden code if the ip is blocked for the scope.
+	CheckIP(ctx context.Context, ip net.IP, scope IPBlockScope) error
+
+	// FindBanAudits returns the audit entries matching the filter, latest first, and the total count of them.
+	FindBanAudits(ctx context.Context, filter BanAuditFilter, opt ...FindOptions) ([]*BanAudit, int, error)
+}
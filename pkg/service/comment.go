@@ -0,0 +1,173 @@
+package service
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/ustackq/indagate/pkg/utils/errors"
+)
+
+// comment service op
+const (
+	OpFindCommentByID = "FindCommentByID"
+	OpFindComments    = "FindComments"
+	OpCreateComment   = "CreateComment"
+	OpUpdateComment   = "UpdateComment"
+	OpDeleteComment   = "DeleteComment"
+)
+
+const (
+	// CommentSortByTime sorts comments by creation time.
+	CommentSortByTime = "createdAt"
+	// CommentSortByVotes sorts comments by agree count.
+	CommentSortByVotes = "votes"
+)
+
+var (
+	// ErrCommentNotFound is returned when a comment can not be found.
+	ErrCommentNotFound = &errors.Error{
+		Code: errors.NotFound,
+		Msg:  "comment not found",
+	}
+
+	// ErrCommentDeleted is returned when changing a comment which has been deleted.
+	ErrCommentDeleted = &errors.Error{
+		Code: errors.Conflict,
+		Msg:  "comment has been deleted",
+	}
+
+	// ErrCommentEditWindowExpired is returned when editing a comment after its edit window.
+	ErrCommentEditWindowExpired = &errors.Error{
+		Code: errors.Forbidden,
+		Msg:  "comment can no longer be edited",
+	}
+)
+
+// CommentType is the type of item a comment is attached to.
+type CommentType string
+
+const (
+	// AnswerComment is a comment on an answer.
+	AnswerComment CommentType = "answers"
+	// ArticleComment is a comment on an article.
+	ArticleComment CommentType = "articles"
+)
+
+// AllCommentTypes is the list of all item types which can be commented on.
+var AllCommentTypes = []CommentType{
+	AnswerComment,
+	ArticleComment,
+}
+
+// Valid returns an error if the comment type is unknown.
+func (t CommentType) Valid() error {
+	for _, ct := range AllCommentTypes {
+		if t == ct {
+			return nil
+		}
+	}
+	return &errors.Error{
+		Code: errors.Invalid,
+		Msg:  fmt.Sprintf("unknown comment type %q", t),
+	}
+}
+
+// Comment represents a comment on an answer or an article. Replies refer to
+// the comment they answer, a deleted comment keeps its place in the thread.
+type Comment struct {
+	ID     ID          `json:"id,omitempty"`
+	Type   CommentType `json:"type"`
+	ItemID ID          `json:"itemID"`
+	OrgID  ID          `json:"orgID"`
+	UserID ID          `json:"userID"`
+	// ReplyTo is the comment replied to, top level comments have none.
+	ReplyTo ID `json:"replyTo,omitempty"`
+	// AtUID is the user mentioned by the comment.
+	AtUID        ID     `json:"atUID,omitempty"`
+	Content      string `json:"content"`
+	ReplyCount   int    `json:"replyCount"`
+	AgreeCount   int    `json:"agreeCount"`
+	AgainstCount int    `json:"againstCount"`
+	Deleted      bool   `json:"deleted"`
+	// Hidden comments are only shown to their moderators.
+	Hidden bool `json:"hidden"`
+	// Pending comments wait for approval and are only shown to their author and moderators.
+	Pending bool `json:"pending"`
+	OperationLog
+}
+
+// Valid returns an error if the comment misses required fields.
+func (c *Comment) Valid() error {
+	if err := c.Type.Valid(); err != nil {
+		return err
+	}
+
+	if c.Content == "" {
+		return &errors.Error{
+			Code: errors.EmptyValue,
+			Msg:  "comment content is empty",
+		}
+	}
+
+	if !c.ItemID.Valid() {
+		return &errors.Error{
+			Code: errors.Invalid,
+			Msg:  "item id required",
+		}
+	}
+
+	if !c.UserID.Valid() {
+		return &errors.Error{
+			Code: errors.Invalid,
+			Msg:  "user id required",
+		}
+	}
+	return nil
+}
+
+// CommentFilter represents a set of filter that restrict the returned comments.
+// Comments of an item are top level comments unless ReplyTo is set.
+type CommentFilter struct {
+	ID      *ID
+	Type    *CommentType
+	ItemID  *ID
+	ReplyTo *ID
+	UserID  *ID
+}
+
+// CommentUpdate represents updates to a comment.
+// Only fields which are set are updated.
+type CommentUpdate struct {
+	Content *string `json:"content,omitempty"`
+}
+
+// Valid returns an error if the update would empty the comment.
+func (u CommentUpdate) Valid() error {
+	if u.Content != nil && *u.Content == "" {
+		return &errors.Error{
+			Code: errors.EmptyValue,
+			Msg:  "comment content is empty",
+		}
+	}
+	return nil
+}
+
+// CommentService represents a service for managing comment data.
+type CommentService interface {
+	// FindCommentByID returns a single comment by ID.
+	FindCommentByID(ctx context.Context, id ID) (*Comment, error)
+
+	// FindComments returns a list of comments that match filter and the total count of matching comments.
+	// Additional options provide pagination & sorting by CommentSortByTime or CommentSortByVotes.
+	FindComments(ctx context.Context, filter CommentFilter, opt ...FindOptions) ([]*Comment, int, error)
+
+	// CreateComment creates a new comment and sets c.ID with the new identifier.
+	CreateComment(ctx context.Context, c *Comment) error
+
+	// UpdateComment updates a single comment with changeset within its edit window.
+	// Returns the new comment state after update.
+	UpdateComment(ctx context.Context, id ID, upd CommentUpdate) (*Comment, error)
+
+	// DeleteComment marks a comment as deleted, its replies are kept.
+	DeleteComment(ctx context.Context, id ID) error
+}
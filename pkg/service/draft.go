@@ -0,0 +1,114 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/ustackq/indagate/pkg/utils/errors"
+)
+
+// draft service op
+const (
+	OpFindDraft   = "FindDraft"
+	OpFindDrafts  = "FindDrafts"
+	OpPutDraft    = "PutDraft"
+	OpDeleteDraft = "DeleteDraft"
+)
+
+var (
+	// ErrDraftNotFound is returned when a draft can not be found.
+	ErrDraftNotFound = &errors.Error{
+		Code: errors.NotFound,
+		Msg:  "draft not found",
+	}
+)
+
+// DraftType is the kind of content a draft is written for.
+type DraftType string
+
+const (
+	// QuestionDraft is a question to be asked, its item is the organization.
+	QuestionDraft DraftType = "questions"
+	// AnswerDraft is an answer to be posted, its item is the question.
+	AnswerDraft DraftType = "answers"
+	// ArticleContentDraft is the content of an article, its item is the article.
+	ArticleContentDraft DraftType = "articles"
+)
+
+// AllDraftTypes is the list of all kinds of content which can be drafted.
+var AllDraftTypes = []DraftType{
+	QuestionDraft,
+	AnswerDraft,
+	ArticleContentDraft,
+}
+
+// Valid returns an error if the draft type is unknown.
+func (t DraftType) Valid() error {
+	for _, dt := range AllDraftTypes {
+		if t == dt {
+			return nil
+		}
+	}
+	return &errors.Error{
+		Code: errors.Invalid,
+		Msg:  fmt.Sprintf("unknown draft type %q", t),
+	}
+}
+
+// Draft is the unsaved work of a user on an item, a user has at most one draft per item.
+// Revision is incremented by every save so that concurrent editors can notice each other.
+type Draft struct {
+	UserID    ID        `json:"userID"`
+	Type      DraftType `json:"type"`
+	ItemID    ID        `json:"itemID"`
+	Data      string    `json:"data"`
+	Revision  int       `json:"revision"`
+	CreatedAt time.Time `json:"createdAt"`
+	UpdatedAt time.Time `json:"updatedAt"`
+}
+
+// Valid returns an error if the draft misses required fields.
+func (d *Draft) Valid() error {
+	if err := d.Type.Valid(); err != nil {
+		return err
+	}
+
+	if !d.UserID.Valid() {
+		return &errors.Error{
+			Code: errors.Invalid,
+			Msg:  "user id required",
+		}
+	}
+
+	if !d.ItemID.Valid() {
+		return &errors.Error{
+			Code: errors.Invalid,
+			Msg:  "item id required",
+		}
+	}
+	return nil
+}
+
+// DraftFilter represents a set of filter that restrict the returned drafts.
+type DraftFilter struct {
+	UserID ID
+	Type   *DraftType
+}
+
+// DraftService represents a service for managing the drafts of users.
+type DraftService interface {
+	// FindDraft returns the draft of the user on an item.
+	FindDraft(ctx context.Context, userID ID, t DraftType, itemID ID) (*Draft, error)
+
+	// FindDrafts returns the drafts of a user most recently updated first and the total count of matching drafts.
+	FindDrafts(ctx context.Context, filter DraftFilter, opt ...FindOptions) ([]*Draft, int, error)
+
+	// PutDraft saves the draft, the last write wins. d.Revision is the revision
+	// the draft was based on and is set to the new revision. The returned bool
+	// reports whether a newer revision than the one the draft was based on was overwritten.
+	PutDraft(ctx context.Context, d *Draft) (bool, error)
+
+	// DeleteDraft removes the draft of the user on an item.
+	DeleteDraft(ctx context.Context, userID ID, t DraftType, itemID ID) error
+}
@@ -0,0 +1,52 @@
+package service
+
+import (
+	"context"
+	"time"
+)
+
+// feed service op
+const (
+	OpFindFeed           = "FindFeed"
+	OpFindUserActivities = "FindUserActivities"
+)
+
+// ActivityAction is what a user did to be shown in the feeds of others.
+type ActivityAction string
+
+const (
+	// AskedActivity is recorded when a user asks a question.
+	AskedActivity ActivityAction = "asked"
+	// AnsweredActivity is recorded when a user answers a question.
+	AnsweredActivity ActivityAction = "answered"
+	// VotedActivity is recorded when a user up votes an item.
+	VotedActivity ActivityAction = "voted"
+	// FollowedActivity is recorded when a user follows a user, question or topic.
+	FollowedActivity ActivityAction = "followed"
+)
+
+// Activity is an action of a user on an item.
+type Activity struct {
+	ID     ID             `json:"id"`
+	UserID ID             `json:"userID"`
+	Action ActivityAction `json:"action"`
+	// OrgID is the organization of the item, it is zero for users.
+	OrgID ID `json:"orgID,omitempty"`
+	// ItemType is the kind of item, followed activities carry the FollowType.
+	ItemType ItemType `json:"itemType"`
+	ItemID   ID       `json:"itemID"`
+	// QuestionID is the question the item belongs to, if any.
+	QuestionID ID        `json:"questionID,omitempty"`
+	CreatedAt  time.Time `json:"createdAt"`
+}
+
+// FeedService represents a service for the activity feeds of users.
+type FeedService interface {
+	// FindFeed returns the activities of the users, questions and topics followed by
+	// the user, most recent first, and the total count of them.
+	FindFeed(ctx context.Context, userID ID, opt ...FindOptions) ([]*Activity, int, error)
+
+	// FindUserActivities returns the activities of the user, most recent first, and
+	// the total count of them.
+	FindUserActivities(ctx context.Context, userID ID, opt ...FindOptions) ([]*Activity, int, error)
+}
@@ -0,0 +1,120 @@
+package service
+
+import (
+	"context"
+	"strings"
+	"time"
+
+	"github.com/ustackq/indagate/pkg/utils/errors"
+)
+
+// ErrContentRejected is returned when the content filters reject a text.
+var ErrContentRejected = &errors.Error{
+	Code: errors.Forbidden,
+	Msg:  "content has been rejected",
+}
+
+// ContentKind is the kind of user content being filtered.
+type ContentKind string
+
+const (
+	QuestionContent = ContentKind("questions")
+	AnswerContent   = ContentKind("answers")
+	CommentContent  = ContentKind("comments")
+	MessageContent  = ContentKind("messages")
+)
+
+// Content is the user content submitted to the filters before it is written.
+type Content struct {
+	Kind   ContentKind `json:"kind"`
+	UserID ID          `json:"userID"`
+	// OrgID is the organization the content is posted in, messages have none.
+	OrgID ID `json:"orgID,omitempty"`
+	// Texts are the text fields of the content, like the content and detail of a question.
+	Texts []string `json:"texts"`
+}
+
+// Text returns the texts of the content joined by new lines.
+func (c *Content) Text() string {
+	return strings.Join(c.Texts, "\n")
+}
+
+// FilterVerdict is the decision of a content filter.
+type FilterVerdict string
+
+const (
+	// AllowContent lets the content be published.
+	AllowContent FilterVerdict = "allow"
+	// HoldContent makes the content wait for a reviewer, content which can not
+	// wait for review is rejected instead.
+	HoldContent FilterVerdict = "hold"
+	// RejectContent refuses the content.
+	RejectContent FilterVerdict = "reject"
+)
+
+// severity orders the verdicts from allow to reject.
+func (v FilterVerdict) severity() int {
+	switch v {
+	case HoldContent:
+		return 1
+	case RejectContent:
+		return 2
+	}
+	return 0
+}
+
+// FilterResult is the verdict of a filter on a content.
+type FilterResult struct {
+	Verdict FilterVerdict `json:"verdict"`
+	// Filter is the name of the filter which gave the verdict.
+	Filter string `json:"filter,omitempty"`
+	// Reason explains the verdict to the author and the reviewers.
+	Reason string `json:"reason,omitempty"`
+}
+
+// Allowed is the result of content no filter objected to.
+var Allowed = &FilterResult{Verdict: AllowContent}
+
+// ContentFilter decides whether a content can be published.
+type ContentFilter interface {
+	// Filter returns the verdict on the content.
+	Filter(ctx context.Context, c *Content) (*FilterResult, error)
+}
+
+// ContentFilters is a chain of filters, content is rejected by the first filter
+// rejecting it and otherwise held by the first filter holding it.
+type ContentFilters []ContentFilter
+
+// Filter runs the filters of the chain in order.
+func (fs ContentFilters) Filter(ctx context.Context, c *Content) (*FilterResult, error) {
+	res := Allowed
+	for _, f := range fs {
+		r, err := f.Filter(ctx, c)
+		if err != nil {
+			return nil, err
+		}
+
+		if r.Verdict.severity() > res.Verdict.severity() {
+			res = r
+		}
+		if res.Verdict == RejectContent {
+			break
+		}
+	}
+	return res, nil
+}
+
+// SpamTrainer learns to recognize spam from the decisions of moderators.
+type SpamTrainer interface {
+	// Train tells the trainer whether the content is spam.
+	Train(ctx context.Context, c *Content, spam bool) error
+}
+
+// SpamSample is a content labelled by a moderator decision, samples are kept to
+// train spam filters again.
+type SpamSample struct {
+	ID        ID        `json:"id,omitempty"`
+	Content   *Content  `json:"content"`
+	Spam      bool      `json:"spam"`
+	CreatedAt time.Time `json:"createdAt"`
+}
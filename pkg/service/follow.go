@@ -0,0 +1,126 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/ustackq/indagate/pkg/utils/errors"
+)
+
+// follow service op
+const (
+	OpFindFollow    = "FindFollow"
+	OpFindFollowers = "FindFollowers"
+	OpFindFollowing = "FindFollowing"
+	OpFollow        = "Follow"
+	OpUnfollow      = "Unfollow"
+)
+
+// DefaultFollowPageSize is the number of follows of a page when the cursor has no limit.
+const DefaultFollowPageSize = 20
+
+var (
+	// ErrFollowNotFound is returned when a user does not follow an item.
+	ErrFollowNotFound = &errors.Error{
+		Code: errors.NotFound,
+		Msg:  "follow not found",
+	}
+
+	// ErrSelfFollow is returned when users try to follow themselves.
+	ErrSelfFollow = &errors.Error{
+		Code: errors.Invalid,
+		Msg:  "users can not follow themselves",
+	}
+)
+
+// FollowType is the kind of item a user can follow.
+type FollowType string
+
+const (
+	// UserFollow makes the follower a fan of another user.
+	UserFollow FollowType = "users"
+	// QuestionFollow focuses a question.
+	QuestionFollow FollowType = "questions"
+	// TopicFollow focuses a topic, it is the same edge as a TopicFocus.
+	TopicFollow FollowType = "topics"
+)
+
+// AllFollowTypes is the list of all kinds of items which can be followed.
+var AllFollowTypes = []FollowType{
+	UserFollow,
+	QuestionFollow,
+	TopicFollow,
+}
+
+// Valid returns an error if the follow type is unknown.
+func (t FollowType) Valid() error {
+	for _, ft := range AllFollowTypes {
+		if t == ft {
+			return nil
+		}
+	}
+	return &errors.Error{
+		Code: errors.Invalid,
+		Msg:  fmt.Sprintf("unknown follow type %q", t),
+	}
+}
+
+// Follow is the edge from a user to a followed item.
+type Follow struct {
+	UserID    ID         `json:"userID"`
+	Type      FollowType `json:"type"`
+	ItemID    ID         `json:"itemID"`
+	CreatedAt time.Time  `json:"createdAt"`
+}
+
+// Valid returns an error if the follow is incomplete.
+func (f *Follow) Valid() error {
+	if !f.UserID.Valid() || !f.ItemID.Valid() {
+		return &errors.Error{
+			Code: errors.Invalid,
+			Msg:  "follow requires a user and an item",
+		}
+	}
+
+	if err := f.Type.Valid(); err != nil {
+		return err
+	}
+
+	if f.Type == UserFollow && f.UserID == f.ItemID {
+		return ErrSelfFollow
+	}
+	return nil
+}
+
+// FollowCursor selects a page of follows. Follows are ordered by the ID of the
+// other end of the edge and After is the last ID of the previous page.
+type FollowCursor struct {
+	After ID
+	Limit int
+}
+
+// FollowPage is a page of follows.
+type FollowPage struct {
+	Follows []*Follow `json:"follows"`
+	// Next is the cursor of the following page, it is zero on the last page.
+	Next ID `json:"next,omitempty"`
+}
+
+// FollowService represents a service for managing the follow graph.
+type FollowService interface {
+	// FindFollow returns the follow of the user on the item.
+	FindFollow(ctx context.Context, userID ID, t FollowType, itemID ID) (*Follow, error)
+
+	// FindFollowers returns a page of the follows on the item.
+	FindFollowers(ctx context.Context, t FollowType, itemID ID, cursor FollowCursor) (*FollowPage, error)
+
+	// FindFollowing returns a page of the items of the type followed by the user.
+	FindFollowing(ctx context.Context, userID ID, t FollowType, cursor FollowCursor) (*FollowPage, error)
+
+	// Follow makes f.UserID follow the item, following twice has no effect.
+	Follow(ctx context.Context, f *Follow) error
+
+	// Unfollow removes the follow of the user on the item.
+	Unfollow(ctx context.Context, userID ID, t FollowType, itemID ID) error
+}
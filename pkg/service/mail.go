@@ -0,0 +1,135 @@
+package service
+
+import (
+	"context"
+	"time"
+
+	"github.com/ustackq/indagate/pkg/utils/errors"
+)
+
+// mail service op
+const (
+	OpFindMailByID      = "FindMailByID"
+	OpFindMails         = "FindMails"
+	OpEnqueueMail       = "EnqueueMail"
+	OpPendingMails      = "PendingMails"
+	OpRecordMailAttempt = "RecordMailAttempt"
+	OpRetryMail         = "RetryMail"
+)
+
+var (
+	// ErrMailNotFound is returned when a mail can not be found.
+	ErrMailNotFound = &errors.Error{
+		Code: errors.NotFound,
+		Msg:  "mail not found",
+	}
+
+	// ErrMailNotPending is returned when recording a delivery attempt of a mail
+	// which was sent or given up on already.
+	ErrMailNotPending = &errors.Error{
+		Code: errors.Conflict,
+		Msg:  "mail is no longer pending",
+	}
+
+	// ErrMailNotDead is returned when retrying a mail which is not a dead letter.
+	ErrMailNotDead = &errors.Error{
+		Code: errors.Conflict,
+		Msg:  "only dead letters can be retried",
+	}
+)
+
+// MailStatus is the delivery state of a mail.
+type MailStatus string
+
+const (
+	// MailPending mails wait in the queue to be sent.
+	MailPending MailStatus = "pending"
+	// MailSent mails were accepted by the mail server.
+	MailSent MailStatus = "sent"
+	// MailDead mails were given up on, they stay in the store until retried.
+	MailDead MailStatus = "dead"
+)
+
+// Mail is an outbound email, mails are stored before they are sent so that none
+// is lost when the server restarts.
+type Mail struct {
+	ID      ID       `json:"id,omitempty"`
+	To      []string `json:"to"`
+	From    string   `json:"from,omitempty"`
+	Subject string   `json:"subject"`
+	// HTML is the body of the mail, Text its plain text alternative.
+	HTML string `json:"html,omitempty"`
+	Text string `json:"text"`
+	// Template is the name of the template the mail was rendered from, if any.
+	Template string     `json:"template,omitempty"`
+	Status   MailStatus `json:"status"`
+	// Attempts is the number of failed deliveries, LastError the error of the last one.
+	Attempts  int    `json:"attempts"`
+	LastError string `json:"lastError,omitempty"`
+	// NextAttemptAt is when a mail which failed is due for its next attempt.
+	NextAttemptAt *time.Time `json:"nextAttemptAt,omitempty"`
+	CreatedAt     time.Time  `json:"createdAt"`
+	SentAt        *time.Time `json:"sentAt,omitempty"`
+}
+
+// Valid returns an error if the mail misses required fields.
+func (m *Mail) Valid() error {
+	if len(m.To) == 0 {
+		return &errors.Error{
+			Code: errors.EmptyValue,
+			Msg:  "mail has no recipient",
+		}
+	}
+
+	if m.Subject == "" {
+		return &errors.Error{
+			Code: errors.EmptyValue,
+			Msg:  "mail subject is empty",
+		}
+	}
+
+	if m.Text == "" && m.HTML == "" {
+		return &errors.Error{
+			Code: errors.EmptyValue,
+			Msg:  "mail body is empty",
+		}
+	}
+	return nil
+}
+
+// MailFilter represents a set of filters that restrict the returned mails.
+type MailFilter struct {
+	Status *MailStatus
+}
+
+// MailAttempt is the outcome of a delivery attempt.
+type MailAttempt struct {
+	// Err is empty when the mail was sent.
+	Err string
+	// Dead gives up on the mail after a failed attempt.
+	Dead bool
+	// RetryAfter delays the next attempt of a mail which failed and is not dead.
+	RetryAfter time.Duration
+}
+
+// MailService represents a service for queueing outbound emails.
+type MailService interface {
+	// FindMailByID returns a single mail by ID.
+	FindMailByID(ctx context.Context, id ID) (*Mail, error)
+
+	// FindMails returns the mails matching the filter, latest first, and the total count of them.
+	FindMails(ctx context.Context, filter MailFilter, opt ...FindOptions) ([]*Mail, int, error)
+
+	// EnqueueMail stores the mail as pending and sets m.ID with the new identifier.
+	EnqueueMail(ctx context.Context, m *Mail) error
+
+	// PendingMails returns up to n pending mails due for an attempt, in the order they
+	// fell due, or all of them when n is not positive.
+	PendingMails(ctx context.Context, n int) ([]*Mail, error)
+
+	// RecordMailAttempt records the outcome of a delivery attempt of a pending mail.
+	RecordMailAttempt(ctx context.Context, id ID, a MailAttempt) (*Mail, error)
+
+	// RetryMail puts a dead letter back in the queue.
+	RetryMail(ctx context.Context, id ID) (*Mail, error)
+}
@@ -0,0 +1,197 @@
+package service
+
+import (
+	"context"
+	"time"
+
+	"github.com/ustackq/indagate/pkg/utils/errors"
+)
+
+// message service op
+const (
+	OpFindDialogByID   = "FindDialogByID"
+	OpFindDialogs      = "FindDialogs"
+	OpCreateDialog     = "CreateDialog"
+	OpDeleteDialog     = "DeleteDialog"
+	OpMarkDialogRead   = "MarkDialogRead"
+	OpFindMessages     = "FindMessages"
+	OpSendMessage      = "SendMessage"
+	OpDeleteMessage    = "DeleteMessage"
+	OpFindBlockedUsers = "FindBlockedUsers"
+	OpBlockUser        = "BlockUser"
+	OpUnblockUser      = "UnblockUser"
+)
+
+var (
+	// ErrDialogNotFound is returned when a dialog can not be found.
+	ErrDialogNotFound = &errors.Error{
+		Code: errors.NotFound,
+		Msg:  "dialog not found",
+	}
+
+	// ErrMessageNotFound is returned when a message can not be found.
+	ErrMessageNotFound = &errors.Error{
+		Code: errors.NotFound,
+		Msg:  "message not found",
+	}
+
+	// ErrNotDialogMember is returned when a user acts on a dialog they do not take part in.
+	ErrNotDialogMember = &errors.Error{
+		Code: errors.Forbidden,
+		Msg:  "user does not take part in the dialog",
+	}
+
+	// ErrSelfDialog is returned when users start a dialog with themselves.
+	ErrSelfDialog = &errors.Error{
+		Code: errors.Invalid,
+		Msg:  "users can not start a dialog with themselves",
+	}
+
+	// ErrUserBlocked is returned when messaging a user who blocked the sender.
+	ErrUserBlocked = &errors.Error{
+		Code: errors.Forbidden,
+		Msg:  "user does not accept messages from the sender",
+	}
+
+	// ErrMessageRateLimited is returned when a user sends messages too quickly.
+	ErrMessageRateLimited = &errors.Error{
+		Code: errors.TooManyRequests,
+		Msg:  "too many messages, try again later",
+	}
+)
+
+// Dialog is a one to one conversation. Each side keeps its own unread and
+// message counters, messages removed by one side stay visible to the other.
+type Dialog struct {
+	ID          ID `json:"id,omitempty"`
+	SenderID    ID `json:"senderID"`
+	RecipientID ID `json:"recipientID"`
+	// SenderUnread and RecipientUnread are the messages each side has not read.
+	SenderUnread    int `json:"senderUnread"`
+	RecipientUnread int `json:"recipientUnread"`
+	// SenderCount and RecipientCount are the messages each side has not removed.
+	SenderCount    int        `json:"senderCount"`
+	RecipientCount int        `json:"recipientCount"`
+	LastMessageAt  *time.Time `json:"lastMessageAt,omitempty"`
+	OperationLog
+}
+
+// Valid returns an error if the dialog misses required fields.
+func (d *Dialog) Valid() error {
+	if !d.SenderID.Valid() || !d.RecipientID.Valid() {
+		return &errors.Error{
+			Code: errors.Invalid,
+			Msg:  "sender and recipient ids required",
+		}
+	}
+
+	if d.SenderID == d.RecipientID {
+		return ErrSelfDialog
+	}
+	return nil
+}
+
+// Includes reports whether the user takes part in the dialog.
+func (d *Dialog) Includes(userID ID) bool {
+	return userID == d.SenderID || userID == d.RecipientID
+}
+
+// Peer returns the other side of the dialog.
+func (d *Dialog) Peer(userID ID) ID {
+	if userID == d.SenderID {
+		return d.RecipientID
+	}
+	return d.SenderID
+}
+
+// Unread returns the number of messages the user has not read.
+func (d *Dialog) Unread(userID ID) int {
+	if userID == d.SenderID {
+		return d.SenderUnread
+	}
+	return d.RecipientUnread
+}
+
+// Message is a message of a dialog.
+type Message struct {
+	ID       ID     `json:"id,omitempty"`
+	DialogID ID     `json:"dialogID"`
+	SenderID ID     `json:"senderID"`
+	Content  string `json:"content"`
+	// SenderRemoved and RecipientRemoved record which sides removed the message.
+	SenderRemoved    bool       `json:"senderRemoved,omitempty"`
+	RecipientRemoved bool       `json:"recipientRemoved,omitempty"`
+	ReadAt           *time.Time `json:"readAt,omitempty"`
+	CreatedAt        time.Time  `json:"createdAt"`
+}
+
+// Valid returns an error if the message misses required fields.
+func (m *Message) Valid() error {
+	if m.Content == "" {
+		return &errors.Error{
+			Code: errors.EmptyValue,
+			Msg:  "message content is empty",
+		}
+	}
+
+	if !m.DialogID.Valid() || !m.SenderID.Valid() {
+		return &errors.Error{
+			Code: errors.Invalid,
+			Msg:  "dialog and sender ids required",
+		}
+	}
+	return nil
+}
+
+// VisibleTo reports whether the user has not removed the message.
+func (m *Message) VisibleTo(userID ID) bool {
+	if userID == m.SenderID {
+		return !m.SenderRemoved
+	}
+	return !m.RecipientRemoved
+}
+
+// Block records that a user does not accept messages from another user.
+type Block struct {
+	UserID    ID        `json:"userID"`
+	BlockedID ID        `json:"blockedID"`
+	CreatedAt time.Time `json:"createdAt"`
+}
+
+// MessageService represents a service for private messages between users.
+type MessageService interface {
+	// FindDialogByID returns a single dialog by ID.
+	FindDialogByID(ctx context.Context, id ID) (*Dialog, error)
+
+	// FindDialogs returns the dialogs of a user with messages the user did not remove,
+	// most recently active first, and the total count of those dialogs.
+	FindDialogs(ctx context.Context, userID ID, opt ...FindOptions) ([]*Dialog, int, error)
+
+	// CreateDialog starts a dialog from d.SenderID to d.RecipientID, an existing
+	// dialog between both users is reused and copied to d.
+	CreateDialog(ctx context.Context, d *Dialog) error
+
+	// DeleteDialog removes all messages of the dialog for the user, the other side keeps them.
+	DeleteDialog(ctx context.Context, id, userID ID) error
+
+	// MarkDialogRead marks all messages of the dialog received by the user as read.
+	MarkDialogRead(ctx context.Context, id, userID ID) (*Dialog, error)
+
+	// FindMessages returns the messages of the dialog the user did not remove, oldest first.
+	FindMessages(ctx context.Context, dialogID, userID ID, opt ...FindOptions) ([]*Message, int, error)
+
+	// SendMessage adds the message to its dialog and sets m.ID with the new identifier.
+	SendMessage(ctx context.Context, m *Message) error
+
+	// DeleteMessage removes a message for the user, the other side keeps it.
+	DeleteMessage(ctx context.Context, id, userID ID) error
+
+	// FindBlockedUsers returns the users blocked by the user.
+	FindBlockedUsers(ctx context.Context, userID ID) ([]*Block, error)
+
+	// BlockUser stops the user from receiving messages of the blocked user.
+	BlockUser(ctx context.Context, userID, blockedID ID) (*Block, error)
+
+	// UnblockUser reverts BlockUser.
+	UnblockUser(ctx context.Context, userID, blockedID ID) error
+}
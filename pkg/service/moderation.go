@@ -0,0 +1,227 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/ustackq/indagate/pkg/utils/errors"
+)
+
+// moderation service op
+const (
+	OpReport              = "Report"
+	OpFindReports         = "FindReports"
+	OpFindModerationItem  = "FindModerationItem"
+	OpFindModerationQueue = "FindModerationQueue"
+	OpResolveReports      = "ResolveReports"
+)
+
+var (
+	// ErrModerationItemNotFound is returned when a target has never been reported.
+	ErrModerationItemNotFound = &errors.Error{
+		Code: errors.NotFound,
+		Msg:  "moderation item not found",
+	}
+
+	// ErrDuplicateReport is returned when users report a target they have already reported.
+	ErrDuplicateReport = &errors.Error{
+		Code: errors.Conflict,
+		Msg:  "target has already been reported",
+	}
+
+	// ErrReportsResolved is returned when resolving the reports of a target twice.
+	ErrReportsResolved = &errors.Error{
+		Code: errors.Conflict,
+		Msg:  "reports have already been resolved",
+	}
+)
+
+// ReportType is the kind of target which can be reported.
+type ReportType string
+
+const (
+	QuestionReport = ReportType("questions")
+	AnswerReport   = ReportType("answers")
+	CommentReport  = ReportType("comments")
+	UserReport     = ReportType("users")
+)
+
+// AllReportTypes is the list of all report types.
+var AllReportTypes = []ReportType{
+	QuestionReport,
+	AnswerReport,
+	CommentReport,
+	UserReport,
+}
+
+// Valid returns an error if the report type is unknown.
+func (t ReportType) Valid() error {
+	for _, rt := range AllReportTypes {
+		if t == rt {
+			return nil
+		}
+	}
+	return &errors.Error{
+		Code: errors.Invalid,
+		Msg:  fmt.Sprintf("unknown report type %q", t),
+	}
+}
+
+// Report is a complaint of a user about a target.
+type Report struct {
+	ID       ID         `json:"id,omitempty"`
+	Type     ReportType `json:"type"`
+	TargetID ID         `json:"targetID"`
+	// OrgID is the organization the target has been reported in, it must be the
+	// organization of reported content.
+	OrgID  ID     `json:"orgID"`
+	UserID ID     `json:"userID"`
+	Reason string `json:"reason"`
+	// Resolved is set once a moderator has resolved the reports of the target.
+	Resolved  bool      `json:"resolved"`
+	CreatedAt time.Time `json:"createdAt"`
+}
+
+// Valid returns an error if the report misses required fields.
+func (r *Report) Valid() error {
+	if err := r.Type.Valid(); err != nil {
+		return err
+	}
+
+	if !r.TargetID.Valid() {
+		return &errors.Error{
+			Code: errors.Invalid,
+			Msg:  "report target id is invalid",
+		}
+	}
+
+	if !r.OrgID.Valid() {
+		return &errors.Error{
+			Code: errors.Invalid,
+			Msg:  "report org id is invalid",
+		}
+	}
+
+	if r.Reason == "" {
+		return &errors.Error{
+			Code: errors.EmptyValue,
+			Msg:  "report reason is empty",
+		}
+	}
+	return nil
+}
+
+// ModerationStatus is the state of a moderation item.
+type ModerationStatus string
+
+const (
+	// ModerationOpen items wait for a moderator.
+	ModerationOpen ModerationStatus = "open"
+	// ModerationResolved items have been handled, a new report opens them again.
+	ModerationResolved ModerationStatus = "resolved"
+)
+
+// ModerationAction is what a moderator does to a reported target.
+type ModerationAction string
+
+const (
+	// DismissModeration leaves the target as it is.
+	DismissModeration ModerationAction = "dismiss"
+	// HideModeration hides the target from everyone but its moderators.
+	HideModeration ModerationAction = "hide"
+	// LockModeration forbids any further modification of the target.
+	LockModeration ModerationAction = "lock"
+	// DeleteModeration removes the target.
+	DeleteModeration ModerationAction = "delete"
+)
+
+// moderationActions defines the actions available on each report type.
+var moderationActions = map[ReportType][]ModerationAction{
+	QuestionReport: {DismissModeration, HideModeration, LockModeration, DeleteModeration},
+	AnswerReport:   {DismissModeration, HideModeration, DeleteModeration},
+	CommentReport:  {DismissModeration, HideModeration, DeleteModeration},
+	UserReport:     {DismissModeration},
+}
+
+// ValidFor returns an error unless the action can be applied to targets of the report type.
+func (a ModerationAction) ValidFor(t ReportType) error {
+	for _, action := range moderationActions[t] {
+		if a == action {
+			return nil
+		}
+	}
+	return &errors.Error{
+		Code: errors.Invalid,
+		Msg:  fmt.Sprintf("moderation action %q is not available on %s", a, t),
+	}
+}
+
+// Resolution records how a moderator resolved the reports of a target.
+type Resolution struct {
+	Action      ModerationAction `json:"action"`
+	Reason      string           `json:"reason"`
+	ModeratorID ID               `json:"moderatorID"`
+	ResolvedAt  time.Time        `json:"resolvedAt"`
+}
+
+// Valid returns an error if the resolution misses required fields.
+func (r *Resolution) Valid() error {
+	if r.Reason == "" {
+		return &errors.Error{
+			Code: errors.EmptyValue,
+			Msg:  "resolution reason is empty",
+		}
+	}
+
+	if !r.ModeratorID.Valid() {
+		return &errors.Error{
+			Code: errors.Invalid,
+			Msg:  "resolution moderator id is invalid",
+		}
+	}
+	return nil
+}
+
+// ModerationItem aggregates the reports of a target.
+type ModerationItem struct {
+	Type     ReportType       `json:"type"`
+	TargetID ID               `json:"targetID"`
+	OrgID    ID               `json:"orgID"`
+	Status   ModerationStatus `json:"status"`
+	// ReportCount is the number of reports since the item has been opened.
+	ReportCount int `json:"reportCount"`
+	// Reasons are the distinct reasons of these reports.
+	Reasons        []string  `json:"reasons"`
+	FirstReportAt  time.Time `json:"firstReportAt"`
+	LatestReportAt time.Time `json:"latestReportAt"`
+	// Resolution is the last resolution of the item.
+	Resolution *Resolution `json:"resolution,omitempty"`
+}
+
+// ModerationFilter represents a set of filters that restrict the moderation queue.
+type ModerationFilter struct {
+	OrgID  *ID
+	Type   *ReportType
+	Status *ModerationStatus
+}
+
+// ModerationService represents a service for reporting content and moderating the reports.
+// The reports of a target are aggregated per organization into a moderation item.
+type ModerationService interface {
+	// Report files a report and returns the moderation item of its target.
+	Report(ctx context.Context, r *Report) (*ModerationItem, error)
+
+	// FindReports returns the reports of a target within the org, newest first.
+	FindReports(ctx context.Context, orgID ID, t ReportType, targetID ID) ([]*Report, error)
+
+	// FindModerationItem returns the moderation item of a target within the org.
+	FindModerationItem(ctx context.Context, orgID ID, t ReportType, targetID ID) (*ModerationItem, error)
+
+	// FindModerationQueue returns the moderation items matching the filter, the most
+	// reported open items first, and the total count of them.
+	FindModerationQueue(ctx context.Context, filter ModerationFilter, opt ...FindOptions) ([]*ModerationItem, int, error)
+
+	// ResolveReports applies the action of the resolution to the target and closes its reports.
+	ResolveReports(ctx context.Context, orgID ID, t ReportType, targetID ID, res *Resolution) (*ModerationItem, error)
+}
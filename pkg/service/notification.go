@@ -0,0 +1,140 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/ustackq/indagate/pkg/utils/errors"
+)
+
+// notification service op
+const (
+	OpFindNotificationByID       = "FindNotificationByID"
+	OpFindNotifications          = "FindNotifications"
+	OpMarkNotificationRead       = "MarkNotificationRead"
+	OpMarkAllNotificationsRead   = "MarkAllNotificationsRead"
+	OpFindNotificationSettings   = "FindNotificationSettings"
+	OpUpdateNotificationSettings = "UpdateNotificationSettings"
+)
+
+var (
+	// ErrNotificationNotFound is returned when a notification can not be found.
+	ErrNotificationNotFound = &errors.Error{
+		Code: errors.NotFound,
+		Msg:  "notification not found",
+	}
+)
+
+// NotificationAction is what happened to the recipient's content or to the recipient.
+type NotificationAction string
+
+const (
+	// AnswerNotification is sent to the author of a question when it is answered.
+	AnswerNotification NotificationAction = "answer"
+	// CommentNotification is sent to the author of an item or comment when it is commented on.
+	CommentNotification NotificationAction = "comment"
+	// MentionNotification is sent to the user mentioned by a comment.
+	MentionNotification NotificationAction = "mention"
+	// VoteNotification is sent to the author of an item when it is up voted.
+	VoteNotification NotificationAction = "vote"
+	// FollowNotification is sent to a user when someone follows them.
+	FollowNotification NotificationAction = "follow"
+	// ApprovedNotification is sent to the author of a post or edit when a reviewer approves it.
+	ApprovedNotification NotificationAction = "approved"
+	// RejectedNotification is sent to the author of a post or edit when a reviewer rejects it.
+	RejectedNotification NotificationAction = "rejected"
+)
+
+// AllNotificationActions is the list of all actions users are notified of.
+var AllNotificationActions = []NotificationAction{
+	AnswerNotification,
+	CommentNotification,
+	MentionNotification,
+	VoteNotification,
+	FollowNotification,
+	ApprovedNotification,
+	RejectedNotification,
+}
+
+// Valid returns an error if the action is unknown.
+func (a NotificationAction) Valid() error {
+	for _, na := range AllNotificationActions {
+		if a == na {
+			return nil
+		}
+	}
+	return &errors.Error{
+		Code: errors.Invalid,
+		Msg:  fmt.Sprintf("unknown notification action %q", a),
+	}
+}
+
+// Notification tells the recipient that the sender acted on an item.
+type Notification struct {
+	ID          ID                 `json:"id,omitempty"`
+	RecipientID ID                 `json:"recipientID"`
+	SenderID    ID                 `json:"senderID"`
+	Action      NotificationAction `json:"action"`
+	// ItemType and ItemID are the item the sender created or acted on.
+	ItemType  ItemType  `json:"itemType,omitempty"`
+	ItemID    ID        `json:"itemID,omitempty"`
+	Read      bool      `json:"read"`
+	CreatedAt time.Time `json:"createdAt"`
+}
+
+// NotificationFilter represents a set of filter that restrict the returned notifications.
+type NotificationFilter struct {
+	RecipientID ID
+	Read        *bool
+}
+
+// NotificationSettings are the preferences of a user about notifications.
+type NotificationSettings struct {
+	UserID ID `json:"userID"`
+	// Disabled lists the actions the user does not want to be notified of.
+	Disabled []NotificationAction `json:"disabled"`
+}
+
+// Valid returns an error if the settings refer to unknown actions.
+func (s *NotificationSettings) Valid() error {
+	for _, a := range s.Disabled {
+		if err := a.Valid(); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// Allows reports whether the user wants to be notified of the action.
+func (s *NotificationSettings) Allows(a NotificationAction) bool {
+	for _, d := range s.Disabled {
+		if d == a {
+			return false
+		}
+	}
+	return true
+}
+
+// NotificationService represents a service for reading the notifications of users.
+// Notifications are created by the services whose actions they report.
+type NotificationService interface {
+	// FindNotificationByID returns a single notification by ID.
+	FindNotificationByID(ctx context.Context, id ID) (*Notification, error)
+
+	// FindNotifications returns the notifications of a recipient newest first and the total count of matching notifications.
+	FindNotifications(ctx context.Context, filter NotificationFilter, opt ...FindOptions) ([]*Notification, int, error)
+
+	// MarkNotificationRead marks a single notification as read.
+	MarkNotificationRead(ctx context.Context, id ID) (*Notification, error)
+
+	// MarkAllNotificationsRead marks all notifications of the recipient as read
+	// and returns the number of notifications which were unread.
+	MarkAllNotificationsRead(ctx context.Context, recipientID ID) (int, error)
+
+	// FindNotificationSettings returns the notification preferences of a user.
+	FindNotificationSettings(ctx context.Context, userID ID) (*NotificationSettings, error)
+
+	// UpdateNotificationSettings replaces the notification preferences of a user.
+	UpdateNotificationSettings(ctx context.Context, settings *NotificationSettings) error
+}
@@ -7,3 +7,24 @@ type FindOptions struct {
 	SortBy     string
 	Descending bool
 }
+
+// PageWindow returns the bounds [start, end) of the page described by opts
+// in a result set of n items.
+func PageWindow(n int, opts ...FindOptions) (int, int) {
+	if len(opts) == 0 {
+		return 0, n
+	}
+
+	start, end := int(opts[0].Offset), n
+	if start < 0 {
+		start = 0
+	}
+	if start > n {
+		start = n
+	}
+
+	if opts[0].Limit > 0 && start+int(opts[0].Limit) < n {
+		end = start + int(opts[0].Limit)
+	}
+	return start, end
+}
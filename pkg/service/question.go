@@ -0,0 +1,157 @@
+package service
+
+import (
+	"context"
+
+	"github.com/ustackq/indagate/pkg/utils/errors"
+)
+
+// question service op
+const (
+	OpFindQuestionByID = "FindQuestionByID"
+	OpFindQuestions    = "FindQuestions"
+	OpCreateQuestion   = "CreateQuestion"
+	OpUpdateQuestion   = "UpdateQuestion"
+	OpDeleteQuestion   = "DeleteQuestion"
+	OpLockQuestion     = "LockQuestion"
+	OpUnlockQuestion   = "UnlockQuestion"
+)
+
+var (
+	// ErrQuestionNotFound is returned when a question can not be found.
+	ErrQuestionNotFound = &errors.Error{
+		Code: errors.NotFound,
+		Msg:  "question not found",
+	}
+
+	// ErrQuestionLocked is returned when modifying a locked question.
+	ErrQuestionLocked = &errors.Error{
+		Code: errors.Forbidden,
+		Msg:  "question is locked",
+	}
+)
+
+// Question represents a question asked in an organization.
+type Question struct {
+	ID           ID     `json:"id,omitempty"`
+	OrgID        ID     `json:"orgID"`
+	UserID       ID     `json:"userID"`
+	Content      string `json:"content"`
+	Detail       string `json:"detail,omitempty"`
+	AnswerCount  int    `json:"answerCount"`
+	ViewCount    int    `json:"viewCount"`
+	FocusCount   int    `json:"focusCount"`
+	CommentCount int    `json:"commentCount"`
+	AgreeCount   int    `json:"agreeCount"`
+	AgainstCount int    `json:"againstCount"`
+	BestAnswer   ID     `json:"bestAnswer,omitempty"`
+	LastAnswer   ID     `json:"lastAnswer,omitempty"`
+	Lock         bool   `json:"lock"`
+	Anonymous    bool   `json:"anonymous"`
+	// Hidden questions are only shown to their moderators.
+	Hidden bool `json:"hidden"`
+	// Pending questions wait for approval and are only shown to their author and moderators.
+	Pending bool `json:"pending"`
+	// UnverifiedModify is the edit waiting for approval, it is applied once approved.
+	UnverifiedModify *QuestionUpdate `json:"unverifiedModify,omitempty"`
+	// UnverifiedModifyCount is the number of edits merged into UnverifiedModify.
+	UnverifiedModifyCount int `json:"unverifiedModifyCount"`
+	OperationLog
+}
+
+// Visible reports whether the question is shown to everyone.
+func (q *Question) Visible() bool {
+	return !q.Hidden && !q.Pending
+}
+
+// Valid returns an error if the question misses required fields.
+func (q *Question) Valid() error {
+	if q.Content == "" {
+		return &errors.Error{
+			Code: errors.EmptyValue,
+			Msg:  "question content is empty",
+		}
+	}
+
+	if !q.OrgID.Valid() {
+		return &errors.Error{
+			Code: errors.Invalid,
+			Msg:  "org id required",
+		}
+	}
+
+	if !q.UserID.Valid() {
+		return &errors.Error{
+			Code: errors.Invalid,
+			Msg:  "user id required",
+		}
+	}
+	return nil
+}
+
+// QuestionFilter represents a set of filter that restrict the returned questions.
+type QuestionFilter struct {
+	ID     *ID
+	OrgID  *ID
+	UserID *ID
+	Lock   *bool
+}
+
+// QuestionUpdate represents updates to a question.
+// Only fields which are set are updated.
+type QuestionUpdate struct {
+	Content   *string `json:"content,omitempty"`
+	Detail    *string `json:"detail,omitempty"`
+	Anonymous *bool   `json:"anonymous,omitempty"`
+}
+
+// Merge returns the update with the fields set by next overriding its own.
+func (u QuestionUpdate) Merge(next QuestionUpdate) QuestionUpdate {
+	if next.Content != nil {
+		u.Content = next.Content
+	}
+	if next.Detail != nil {
+		u.Detail = next.Detail
+	}
+	if next.Anonymous != nil {
+		u.Anonymous = next.Anonymous
+	}
+	return u
+}
+
+// Valid returns an error if the update would empty the question.
+func (u QuestionUpdate) Valid() error {
+	if u.Content != nil && *u.Content == "" {
+		return &errors.Error{
+			Code: errors.EmptyValue,
+			Msg:  "question content is empty",
+		}
+	}
+	return nil
+}
+
+// QuestionService represents a service for managing question data.
+type QuestionService interface {
+	// FindQuestionByID returns a single question by ID.
+	FindQuestionByID(ctx context.Context, id ID) (*Question, error)
+
+	// FindQuestions returns a list of questions that match filter and the total count of matching questions.
+	// Additional options provide pagination & sorting.
+	FindQuestions(ctx context.Context, filter QuestionFilter, opt ...FindOptions) ([]*Question, int, error)
+
+	// CreateQuestion creates a new question and sets q.ID with the new identifier.
+	CreateQuestion(ctx context.Context, q *Question) error
+
+	// UpdateQuestion updates a single question with changeset.
+	// Returns the new question state after update.
+	UpdateQuestion(ctx context.Context, id ID, upd QuestionUpdate) (*Question, error)
+
+	// DeleteQuestion removes a question by ID.
+	DeleteQuestion(ctx context.Context, id ID) error
+
+	// LockQuestion forbids any further modification of the question.
+	LockQuestion(ctx context.Context, id ID) error
+
+	// UnlockQuestion reverts LockQuestion.
+	UnlockQuestion(ctx context.Context, id ID) error
+}
@@ -0,0 +1,171 @@
+package service
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/ustackq/indagate/pkg/utils/errors"
+)
+
+// role service op
+const (
+	OpFindOrgRoles        = "FindOrgRoles"
+	OpSetUserRole         = "SetUserRole"
+	OpFindUserPermissions = "FindUserPermissions"
+)
+
+// Role is a named set of permissions a user holds within an org.
+type Role string
+
+const (
+	// RoleAdmin can read and write every resource of the org, including its roles.
+	RoleAdmin Role = "admin"
+	// RoleModerator can edit, lock and remove any question or answer of the org and handles its reports.
+	RoleModerator Role = "moderator"
+	// RoleTrusted can edit any question of the org.
+	RoleTrusted Role = "trusted"
+	// RoleMember can read the org and post questions and answers.
+	RoleMember Role = "member"
+	// RoleGuest can only read the org.
+	RoleGuest Role = "guest"
+)
+
+// AllRoles is the list of all built-in roles, from the most to the least privileged.
+var AllRoles = []Role{
+	RoleAdmin,
+	RoleModerator,
+	RoleTrusted,
+	RoleMember,
+	RoleGuest,
+}
+
+// rolePermissions defines the actions each role holds on the org scoped resource types.
+var rolePermissions = map[Role]map[ResourceType]Action{
+	RoleAdmin: {
+		OrgsResourceType:       READWRITEACTION,
+		BucketsResourceType:    READWRITEACTION,
+		QuestionsResourceType:  READWRITEACTION,
+		AnswersResourceType:    READWRITEACTION,
+		CommentsResourceType:   READWRITEACTION,
+		TopicsResourceType:     READWRITEACTION,
+		ArticlesResourceType:   READWRITEACTION,
+		ModerationResourceType: READWRITEACTION,
+	},
+	RoleModerator: {
+		OrgsResourceType:       ReadAction,
+		BucketsResourceType:    ReadAction,
+		QuestionsResourceType:  READWRITEACTION,
+		AnswersResourceType:    READWRITEACTION,
+		CommentsResourceType:   READWRITEACTION,
+		TopicsResourceType:     READWRITEACTION,
+		ArticlesResourceType:   READWRITEACTION,
+		ModerationResourceType: READWRITEACTION,
+	},
+	RoleTrusted: {
+		OrgsResourceType:      ReadAction,
+		BucketsResourceType:   ReadAction,
+		QuestionsResourceType: READWRITEACTION,
+		AnswersResourceType:   ReadAction,
+		CommentsResourceType:  ReadAction,
+		TopicsResourceType:    ReadAction,
+		ArticlesResourceType:  ReadAction,
+	},
+	RoleMember: {
+		OrgsResourceType:      ReadAction,
+		BucketsResourceType:   ReadAction,
+		QuestionsResourceType: ReadAction,
+		AnswersResourceType:   ReadAction,
+		CommentsResourceType:  ReadAction,
+		TopicsResourceType:    ReadAction,
+		ArticlesResourceType:  ReadAction,
+	},
+	RoleGuest: {
+		OrgsResourceType:      ReadAction,
+		QuestionsResourceType: ReadAction,
+		AnswersResourceType:   ReadAction,
+		CommentsResourceType:  ReadAction,
+		TopicsResourceType:    ReadAction,
+		ArticlesResourceType:  ReadAction,
+	},
+}
+
+// Valid returns an error if the role is not a built-in role.
+func (r Role) Valid() error {
+	if _, ok := rolePermissions[r]; !ok {
+		return &errors.Error{
+			Code: errors.Invalid,
+			Msg:  fmt.Sprintf("unknown role %q", r),
+		}
+	}
+	return nil
+}
+
+// Permissions expands the role into the permissions it grants within the org.
+func (r Role) Permissions(orgID ID) ([]*Permission, error) {
+	if err := r.Valid(); err != nil {
+		return nil, err
+	}
+
+	ps := make([]*Permission, 0, len(rolePermissions[r]))
+	for _, rt := range AllResourceTypes {
+		action, ok := rolePermissions[r][rt]
+		if !ok {
+			continue
+		}
+
+		p, err := NewPermission(action, rt, orgID)
+		if err != nil {
+			return nil, err
+		}
+		ps = append(ps, p)
+	}
+	return ps, nil
+}
+
+// MappingRole returns the role held through the mapping, mappings without an
+// explicit role fall back to admin for owners and member for members.
+func MappingRole(m *UserResourceMapping) Role {
+	if m.Role != "" {
+		return m.Role
+	}
+	if m.UserType == Owner {
+		return RoleAdmin
+	}
+	return RoleMember
+}
+
+// UserPermissions returns the permissions of a user holding the org mappings ms,
+// every user has full access to itself.
+func UserPermissions(userID ID, ms []*UserResourceMapping) ([]*Permission, error) {
+	self, err := NewGlobalPermission(READWRITEACTION, UsersResourceType)
+	if err != nil {
+		return nil, err
+	}
+	self.Resource.ID = &userID
+
+	ps := []*Permission{self}
+	for _, m := range ms {
+		if m.UserID != userID || m.ResourceType != OrgsResourceType {
+			continue
+		}
+
+		rps, err := MappingRole(m).Permissions(m.ResourceID)
+		if err != nil {
+			return nil, err
+		}
+		ps = append(ps, rps...)
+	}
+	return ps, nil
+}
+
+// RoleService manages the roles users hold within orgs.
+type RoleService interface {
+	// FindOrgRoles returns the role mappings of all users of the org.
+	FindOrgRoles(ctx context.Context, orgID ID) ([]*UserResourceMapping, error)
+
+	// SetUserRole assigns role to the user within the org, replacing its former role.
+	SetUserRole(ctx context.Context, orgID, userID ID, role Role) (*UserResourceMapping, error)
+
+	// FindUserPermissions returns the permissions granted to the user by all of its roles.
+	FindUserPermissions(ctx context.Context, userID ID) ([]*Permission, error)
+}
@@ -0,0 +1,93 @@
+package service
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/ustackq/indagate/pkg/utils/errors"
+)
+
+// search service op
+const (
+	OpSearch  = "Search"
+	OpReindex = "Reindex"
+)
+
+// SearchType is the kind of content which can be searched.
+type SearchType string
+
+const (
+	QuestionSearchType = SearchType("questions")
+	AnswerSearchType   = SearchType("answers")
+	ArticleSearchType  = SearchType("articles")
+	TopicSearchType    = SearchType("topics")
+)
+
+// AllSearchTypes is the list of all searchable types.
+var AllSearchTypes = []SearchType{
+	QuestionSearchType,
+	AnswerSearchType,
+	ArticleSearchType,
+	TopicSearchType,
+}
+
+// Valid returns an error if the search type is unknown.
+func (t SearchType) Valid() error {
+	for _, st := range AllSearchTypes {
+		if t == st {
+			return nil
+		}
+	}
+	return &errors.Error{
+		Code: errors.Invalid,
+		Msg:  fmt.Sprintf("unknown search type %q", t),
+	}
+}
+
+// SearchQuery is the full-text query of a search.
+type SearchQuery struct {
+	// Query is matched against the title and the body of the content, every term must match.
+	Query string
+	// Type limits the search to a single kind of content.
+	Type *SearchType
+	// TopicID limits the search to the content related to the topic.
+	TopicID *ID
+	// Readable, when set, drops the results it returns false for before they are
+	// counted and paged. Authorizers use it to hide what the caller can not read.
+	Readable func(r *SearchResult) (bool, error)
+}
+
+// Valid returns an error if the query has nothing to search.
+func (q SearchQuery) Valid() error {
+	if q.Query == "" {
+		return &errors.Error{
+			Code: errors.Invalid,
+			Msg:  "search query is empty",
+		}
+	}
+
+	if q.Type != nil {
+		return q.Type.Valid()
+	}
+	return nil
+}
+
+// SearchResult is a content matching a search query.
+type SearchResult struct {
+	Type  SearchType `json:"type"`
+	ID    ID         `json:"id"`
+	OrgID ID         `json:"orgID"`
+	// QuestionID is the question of an answer.
+	QuestionID ID `json:"questionID,omitempty"`
+	// Title is the title of the content, answers carry the title of their question.
+	Title string `json:"title"`
+	// Highlight is an HTML fragment of the content with the matched terms marked.
+	Highlight string  `json:"highlight"`
+	Score     float64 `json:"score"`
+}
+
+// SearchService represents a service for the full-text search of content.
+type SearchService interface {
+	// Search returns the content matching the query, best match first, and the total count of matches.
+	Search(ctx context.Context, q SearchQuery, opt ...FindOptions) ([]*SearchResult, int, error)
+}
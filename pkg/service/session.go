@@ -9,6 +9,17 @@ import (
 // ErrSessionExpired is the error message for expired sessions.
 const ErrSessionExpired = "session has expired"
 
+// ErrSessionNotFound is the error message for unknown sessions.
+const ErrSessionNotFound = "session not found"
+
+// session service op
+const (
+	OpFindSession   = "FindSession"
+	OpExpireSession = "ExpireSession"
+	OpCreateSession = "CreateSession"
+	OpRenewSession  = "RenewSession"
+)
+
 // RenewSessionTime is the the time to extend session, currently set to 5min.
 var RenewSessionTime = time.Duration(time.Second * 300)
 
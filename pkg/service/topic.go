@@ -0,0 +1,275 @@
+package service
+
+import (
+	"context"
+	"time"
+
+	"github.com/ustackq/indagate/pkg/utils/errors"
+)
+
+// topic service op
+const (
+	OpFindTopicByID       = "FindTopicByID"
+	OpFindTopicByURLToken = "FindTopicByURLToken"
+	OpFindTopics          = "FindTopics"
+	OpFindTopicPath       = "FindTopicPath"
+	OpCreateTopic         = "CreateTopic"
+	OpUpdateTopic         = "UpdateTopic"
+	OpDeleteTopic         = "DeleteTopic"
+	OpMergeTopic          = "MergeTopic"
+	OpFindTopicMerges     = "FindTopicMerges"
+	OpFindTopicRelations  = "FindTopicRelations"
+	OpAddTopicRelation    = "AddTopicRelation"
+	OpRemoveTopicRelation = "RemoveTopicRelation"
+	OpFindTopicFocus      = "FindTopicFocus"
+	OpFindFocusedTopics   = "FindFocusedTopics"
+	OpFocusTopic          = "FocusTopic"
+	OpUnfocusTopic        = "UnfocusTopic"
+)
+
+const (
+	// TopicSortByTitle sorts topics by title.
+	TopicSortByTitle = "title"
+	// TopicSortByDiscussions sorts topics by discuss count.
+	TopicSortByDiscussions = "discussCount"
+	// TopicSortByFocus sorts topics by focus count.
+	TopicSortByFocus = "focusCount"
+)
+
+var (
+	// ErrTopicNotFound is returned when a topic can not be found.
+	ErrTopicNotFound = &errors.Error{
+		Code: errors.NotFound,
+		Msg:  "topic not found",
+	}
+
+	// ErrTopicLocked is returned when modifying a locked topic.
+	ErrTopicLocked = &errors.Error{
+		Code: errors.Forbidden,
+		Msg:  "topic is locked",
+	}
+
+	// ErrTopicMerged is returned when changing a topic which has been merged into another.
+	ErrTopicMerged = &errors.Error{
+		Code: errors.Conflict,
+		Msg:  "topic has been merged",
+	}
+
+	// ErrTopicURLTokenExists is returned when the url token of a topic is already taken.
+	ErrTopicURLTokenExists = &errors.Error{
+		Code: errors.Conflict,
+		Msg:  "topic url token already exists",
+	}
+
+	// ErrTopicCycle is returned when a topic would become its own ancestor.
+	ErrTopicCycle = &errors.Error{
+		Code: errors.Invalid,
+		Msg:  "topic can not be placed below itself",
+	}
+
+	// ErrTopicRelationNotFound is returned when an item is not related to a topic.
+	ErrTopicRelationNotFound = &errors.Error{
+		Code: errors.NotFound,
+		Msg:  "topic relation not found",
+	}
+
+	// ErrTopicFocusNotFound is returned when a user does not focus a topic.
+	ErrTopicFocusNotFound = &errors.Error{
+		Code: errors.NotFound,
+		Msg:  "topic focus not found",
+	}
+)
+
+// Topic represents a topic of the taxonomy of an organization. Topics form a
+// tree through their parent, a merged topic redirects to the topic it was merged into.
+type Topic struct {
+	ID           ID     `json:"id,omitempty"`
+	OrgID        ID     `json:"orgID"`
+	Title        string `json:"title"`
+	Description  string `json:"description,omitempty"`
+	Avatar       string `json:"avatar,omitempty"`
+	URLToken     string `json:"urlToken"`
+	SEOTitle     string `json:"seoTitle,omitempty"`
+	ParentID     ID     `json:"parentID,omitempty"`
+	IsParent     bool   `json:"isParent"`
+	MergedID     ID     `json:"mergedID,omitempty"`
+	Lock         bool   `json:"lock"`
+	DiscussCount int    `json:"discussCount"`
+	FocusCount   int    `json:"focusCount"`
+	OperationLog
+}
+
+// Valid returns an error if the topic misses required fields.
+func (t *Topic) Valid() error {
+	if t.Title == "" {
+		return &errors.Error{
+			Code: errors.EmptyValue,
+			Msg:  "topic title is empty",
+		}
+	}
+
+	if !t.OrgID.Valid() {
+		return &errors.Error{
+			Code: errors.Invalid,
+			Msg:  "org id required",
+		}
+	}
+	return nil
+}
+
+// TopicFilter represents a set of filter that restrict the returned topics.
+// Merged topics are never returned by FindTopics.
+type TopicFilter struct {
+	ID       *ID
+	OrgID    *ID
+	ParentID *ID
+	URLToken *string
+}
+
+// TopicUpdate represents updates to a topic.
+// Only fields which are set are updated, a zero ParentID moves the topic to the root.
+type TopicUpdate struct {
+	Title       *string `json:"title,omitempty"`
+	Description *string `json:"description,omitempty"`
+	Avatar      *string `json:"avatar,omitempty"`
+	URLToken    *string `json:"urlToken,omitempty"`
+	SEOTitle    *string `json:"seoTitle,omitempty"`
+	ParentID    *ID     `json:"parentID,omitempty"`
+	Lock        *bool   `json:"lock,omitempty"`
+}
+
+// Valid returns an error if the update would empty the topic.
+func (u TopicUpdate) Valid() error {
+	if u.Title != nil && *u.Title == "" {
+		return &errors.Error{
+			Code: errors.EmptyValue,
+			Msg:  "topic title is empty",
+		}
+	}
+
+	if u.URLToken != nil && *u.URLToken == "" {
+		return &errors.Error{
+			Code: errors.EmptyValue,
+			Msg:  "topic url token is empty",
+		}
+	}
+	return nil
+}
+
+// TopicMerge records a topic merged into another one.
+type TopicMerge struct {
+	SourceID  ID        `json:"sourceID"`
+	TargetID  ID        `json:"targetID"`
+	UserID    ID        `json:"userID"`
+	CreatedAt time.Time `json:"createdAt"`
+}
+
+// TopicRelation relates an item to a topic.
+type TopicRelation struct {
+	TopicID   ID        `json:"topicID"`
+	ItemType  ItemType  `json:"itemType"`
+	ItemID    ID        `json:"itemID"`
+	UserID    ID        `json:"userID"`
+	CreatedAt time.Time `json:"createdAt"`
+}
+
+// Valid returns an error if the relation misses required fields.
+func (r *TopicRelation) Valid() error {
+	if !r.TopicID.Valid() {
+		return &errors.Error{
+			Code: errors.Invalid,
+			Msg:  "topic id required",
+		}
+	}
+
+	if err := r.ItemType.Valid(); err != nil {
+		return err
+	}
+
+	if !r.ItemID.Valid() {
+		return &errors.Error{
+			Code: errors.Invalid,
+			Msg:  "item id required",
+		}
+	}
+
+	if !r.UserID.Valid() {
+		return &errors.Error{
+			Code: errors.Invalid,
+			Msg:  "user id required",
+		}
+	}
+	return nil
+}
+
+// TopicRelationFilter represents a set of filter that restrict the returned relations,
+// either the topic or the item is required.
+type TopicRelationFilter struct {
+	TopicID  *ID
+	ItemType *ItemType
+	ItemID   *ID
+}
+
+// TopicFocus records a user focusing a topic.
+type TopicFocus struct {
+	TopicID   ID        `json:"topicID"`
+	UserID    ID        `json:"userID"`
+	CreatedAt time.Time `json:"createdAt"`
+}
+
+// TopicService represents a service for managing the topic taxonomy.
+type TopicService interface {
+	// FindTopicByID returns a single topic by ID, merged topics are returned as they are.
+	FindTopicByID(ctx context.Context, id ID) (*Topic, error)
+
+	// FindTopicByURLToken returns the topic of the url token, following merges.
+	FindTopicByURLToken(ctx context.Context, token string) (*Topic, error)
+
+	// FindTopics returns a list of topics that match filter and the total count of matching topics.
+	// Additional options provide pagination & sorting by TopicSortByTitle, TopicSortByDiscussions or TopicSortByFocus.
+	FindTopics(ctx context.Context, filter TopicFilter, opt ...FindOptions) ([]*Topic, int, error)
+
+	// FindTopicPath returns the ancestors of the topic from the root down to the topic itself.
+	FindTopicPath(ctx context.Context, id ID) ([]*Topic, error)
+
+	// CreateTopic creates a new topic and sets t.ID with the new identifier.
+	CreateTopic(ctx context.Context, t *Topic) error
+
+	// UpdateTopic updates a single topic with changeset.
+	// Returns the new topic state after update.
+	UpdateTopic(ctx context.Context, id ID, upd TopicUpdate) (*Topic, error)
+
+	// DeleteTopic removes a topic by ID, its children move up to its parent.
+	DeleteTopic(ctx context.Context, id ID) error
+
+	// MergeTopic merges the source topic into the target topic. Relations, focuses and
+	// children move to the target and the source redirects to it.
+	// Returns the new target state after merge.
+	MergeTopic(ctx context.Context, sourceID, targetID, userID ID) (*Topic, error)
+
+	// FindTopicMerges returns the merges into the topic.
+	FindTopicMerges(ctx context.Context, targetID ID) ([]*TopicMerge, error)
+
+	// FindTopicRelations returns a list of relations that match filter and the total count of matching relations.
+	FindTopicRelations(ctx context.Context, filter TopicRelationFilter, opt ...FindOptions) ([]*TopicRelation, int, error)
+
+	// AddTopicRelation relates an item to a topic.
+	AddTopicRelation(ctx context.Context, r *TopicRelation) error
+
+	// RemoveTopicRelation removes an item from a topic.
+	RemoveTopicRelation(ctx context.Context, topicID ID, itemType ItemType, itemID ID) error
+
+	// FindTopicFocus returns the focus of a user on a topic.
+	FindTopicFocus(ctx context.Context, topicID, userID ID) (*TopicFocus, error)
+
+	// FindFocusedTopics returns the topics focused by the user and the total count of them.
+	FindFocusedTopics(ctx context.Context, userID ID, opt ...FindOptions) ([]*Topic, int, error)
+
+	// FocusTopic makes the user focus the topic, focusing twice has no effect.
+	// Returns the new topic state.
+	FocusTopic(ctx context.Context, topicID, userID ID) (*Topic, error)
+
+	// UnfocusTopic removes the focus of the user on the topic.
+	// Returns the new topic state.
+	UnfocusTopic(ctx context.Context, topicID, userID ID) (*Topic, error)
+}
@@ -8,6 +8,22 @@ import (
 type User struct {
 	ID   ID     `json:"id,omitempty"`
 	Name string `json:"name"`
+	// Email receives the activation, password reset and notification mails of the user.
+	Email string `json:"email,omitempty"`
+	// Activated is set once the user followed the link of the activation mail.
+	Activated bool `json:"activated"`
+	// Reputation is earned by the votes on the user's content.
+	Reputation int `json:"reputation"`
+	// NotificationUnread is the number of unread notifications of the user.
+	NotificationUnread int `json:"notificationUnread"`
+	// InboxUnread is the number of unread private messages of the user.
+	InboxUnread int `json:"inboxUnread"`
+	// FansCount is the number of users following the user.
+	FansCount int `json:"fansCount"`
+	// FriendCount is the number of users the user follows.
+	FriendCount int `json:"friendCount"`
+	// FocusCount is the number of questions and topics the user follows.
+	FocusCount int `json:"focusCount"`
 }
 
 type UserFilter struct {
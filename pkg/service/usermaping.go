@@ -74,6 +74,7 @@ type UserResourceMapping struct {
 	MappingType  MappingType  `json:"mappingType"`
 	ResourceType ResourceType `json:"resourceType"`
 	ResourceID   ID           `json:"resourceID"`
+	Role         Role         `json:"role,omitempty"`
 }
 
 type UserResourceMappingFilter struct {
@@ -0,0 +1,161 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/ustackq/indagate/pkg/utils/errors"
+)
+
+// vote service op
+const (
+	OpFindVote     = "FindVote"
+	OpFindVoteItem = "FindVoteItem"
+	OpVote         = "Vote"
+	OpDeleteVote   = "DeleteVote"
+)
+
+var (
+	// ErrVoteNotFound is returned when a user has not voted on an item.
+	ErrVoteNotFound = &errors.Error{
+		Code: errors.NotFound,
+		Msg:  "vote not found",
+	}
+
+	// ErrSelfVote is returned when users vote on their own content.
+	ErrSelfVote = &errors.Error{
+		Code: errors.Forbidden,
+		Msg:  "users can't vote on their own content",
+	}
+)
+
+// ItemType is the kind of content which can be voted on.
+type ItemType string
+
+const (
+	QuestionItemType = ItemType("questions")
+	AnswerItemType   = ItemType("answers")
+	ArticleItemType  = ItemType("articles")
+	CommentItemType  = ItemType("comments")
+)
+
+// AllItemTypes is the list of all item types which can be voted on.
+var AllItemTypes = []ItemType{
+	QuestionItemType,
+	AnswerItemType,
+	ArticleItemType,
+	CommentItemType,
+}
+
+// Valid returns an error if the item type is unknown.
+func (t ItemType) Valid() error {
+	for _, it := range AllItemTypes {
+		if t == it {
+			return nil
+		}
+	}
+	return &errors.Error{
+		Code: errors.Invalid,
+		Msg:  fmt.Sprintf("unknown item type %q", t),
+	}
+}
+
+// VoteValue is the direction of a vote.
+type VoteValue int
+
+const (
+	UpVote   = VoteValue(1)
+	DownVote = VoteValue(-1)
+)
+
+// Valid returns an error unless v is an up or a down vote.
+func (v VoteValue) Valid() error {
+	if v != UpVote && v != DownVote {
+		return &errors.Error{
+			Code: errors.Invalid,
+			Msg:  "vote value must be 1 or -1",
+		}
+	}
+	return nil
+}
+
+// upVoteReputation is the reputation the author of an item earns per up vote.
+var upVoteReputation = map[ItemType]int{
+	QuestionItemType: 5,
+	AnswerItemType:   10,
+	ArticleItemType:  10,
+	CommentItemType:  2,
+}
+
+// downVoteReputation is the reputation the author of an item loses per down vote.
+const downVoteReputation = -2
+
+// Reputation returns the reputation the author of an item of type t earns by the vote.
+func (v VoteValue) Reputation(t ItemType) int {
+	switch v {
+	case UpVote:
+		return upVoteReputation[t]
+	case DownVote:
+		return downVoteReputation
+	}
+	return 0
+}
+
+// Vote is the vote of a user on an item, every user votes at most once per item.
+type Vote struct {
+	ItemType  ItemType  `json:"itemType"`
+	ItemID    ID        `json:"itemID"`
+	UserID    ID        `json:"userID"`
+	Value     VoteValue `json:"value"`
+	CreatedAt time.Time `json:"createdAt"`
+	UpdatedAt time.Time `json:"updatedAt"`
+}
+
+// Valid returns an error if the vote misses required fields.
+func (v *Vote) Valid() error {
+	if err := v.ItemType.Valid(); err != nil {
+		return err
+	}
+
+	if !v.ItemID.Valid() {
+		return &errors.Error{
+			Code: errors.Invalid,
+			Msg:  "item id required",
+		}
+	}
+
+	if !v.UserID.Valid() {
+		return &errors.Error{
+			Code: errors.Invalid,
+			Msg:  "user id required",
+		}
+	}
+	return v.Value.Valid()
+}
+
+// VoteItem is the voting state of an item.
+type VoteItem struct {
+	Type         ItemType `json:"type"`
+	ID           ID       `json:"id"`
+	OrgID        ID       `json:"orgID"`
+	AuthorID     ID       `json:"authorID"`
+	AgreeCount   int      `json:"agreeCount"`
+	AgainstCount int      `json:"againstCount"`
+}
+
+// VoteService represents a service for voting on items.
+type VoteService interface {
+	// FindVoteItem returns the voting state of an item.
+	FindVoteItem(ctx context.Context, itemType ItemType, itemID ID) (*VoteItem, error)
+
+	// FindVote returns the vote of a user on an item.
+	FindVote(ctx context.Context, itemType ItemType, itemID, userID ID) (*Vote, error)
+
+	// Vote records the vote or changes the previous vote of the user on the item,
+	// the item counters and the reputation of its author follow.
+	Vote(ctx context.Context, v *Vote) (*VoteItem, error)
+
+	// DeleteVote withdraws the vote of a user on an item.
+	DeleteVote(ctx context.Context, itemType ItemType, itemID, userID ID) (*VoteItem, error)
+}
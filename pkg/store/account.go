@@ -0,0 +1,200 @@
+package store
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/ustackq/indagate/pkg/service"
+	"github.com/ustackq/indagate/pkg/utils/errors"
+)
+
+var accountTokenBucket = []byte("accounttokenv1")
+
+// assert Service implement service.AccountTokenService
+var _ service.AccountTokenService = (*Service)(nil)
+
+func (s *Service) initializeAccountTokens(ctx context.Context, tx Impl) error {
+	if _, err := s.accountTokenBucket(tx); err != nil {
+		return err
+	}
+	return nil
+}
+
+func (s *Service) accountTokenBucket(tx Impl) (Bucket, error) {
+	b, err := tx.Bucket(accountTokenBucket)
+	if err != nil {
+		return nil, UnexpectedAccountTokenError(err)
+	}
+	return b, nil
+}
+
+// UnexpectedAccountTokenError wraps errors raised while retrieving the account token bucket.
+func UnexpectedAccountTokenError(err error) *errors.Error {
+	return &errors.Error{
+		Code: errors.Internal,
+		Msg:  fmt.Sprintf("unexpected error retrieving account token bucket; %v", err),
+		Op:   "accountTokenBucket",
+	}
+}
+
+func unmarshalAccountToken(v []byte) (*service.AccountToken, error) {
+	t := &service.AccountToken{}
+	if err := json.Unmarshal(v, t); err != nil {
+		return nil, &errors.Error{
+			Code: errors.Internal,
+			Msg:  "account token could not be unmarshalled",
+			Err:  err,
+			Op:   "unmarshalAccountToken",
+		}
+	}
+	return t, nil
+}
+
+// CreateAccountToken creates a token of the kind for the user, valid for kind.TTL().
+func (s *Service) CreateAccountToken(ctx context.Context, kind service.AccountTokenKind, userID service.ID) (*service.AccountToken, error) {
+	var t *service.AccountToken
+	err := s.store.Modify(ctx, func(tx Impl) error {
+		token, err := s.createAccountToken(ctx, tx, kind, userID)
+		if err != nil {
+			return err
+		}
+		t = token
+		return nil
+	})
+	if err != nil {
+		return nil, &errors.Error{
+			Err: err,
+			Op:  service.OpCreateAccountToken,
+		}
+	}
+	return t, nil
+}
+
+func (s *Service) createAccountToken(ctx context.Context, tx Impl, kind service.AccountTokenKind, userID service.ID) (*service.AccountToken, error) {
+	if err := kind.Valid(); err != nil {
+		return nil, err
+	}
+
+	if _, err := s.findUserByID(ctx, tx, userID); err != nil {
+		return nil, err
+	}
+
+	token, err := s.TokenGenerator.Token()
+	if err != nil {
+		return nil, errors.InternalErr(err)
+	}
+
+	now := s.time()
+	t := &service.AccountToken{
+		Token:     token,
+		Kind:      kind,
+		UserID:    userID,
+		CreatedAt: now,
+		ExpiresAt: now.Add(kind.TTL()),
+	}
+	if err := s.putAccountToken(ctx, tx, t); err != nil {
+		return nil, err
+	}
+	return t, nil
+}
+
+func (s *Service) putAccountToken(ctx context.Context, tx Impl, t *service.AccountToken) error {
+	v, err := json.Marshal(t)
+	if err != nil {
+		return &errors.Error{
+			Code: errors.Invalid,
+			Err:  err,
+		}
+	}
+
+	b, err := s.accountTokenBucket(tx)
+	if err != nil {
+		return err
+	}
+
+	if err := b.Put([]byte(t.Token), v); err != nil {
+		return errors.InternalErr(err)
+	}
+	return nil
+}
+
+// ConsumeAccountToken deletes the token and returns it, unless it is of another
+// kind or has expired.
+func (s *Service) ConsumeAccountToken(ctx context.Context, kind service.AccountTokenKind, token string) (*service.AccountToken, error) {
+	var t *service.AccountToken
+	err := s.store.Modify(ctx, func(tx Impl) error {
+		at, err := s.consumeAccountToken(ctx, tx, kind, token)
+		if err != nil {
+			return err
+		}
+		t = at
+		return nil
+	})
+	if err != nil {
+		return nil, &errors.Error{
+			Err: err,
+			Op:  service.OpConsumeAccountToken,
+		}
+	}
+	return t, nil
+}
+
+func (s *Service) consumeAccountToken(ctx context.Context, tx Impl, kind service.AccountTokenKind, token string) (*service.AccountToken, error) {
+	b, err := s.accountTokenBucket(tx)
+	if err != nil {
+		return nil, err
+	}
+
+	v, err := b.Get([]byte(token))
+	if IsNotFound(err) {
+		return nil, service.ErrAccountTokenNotFound
+	}
+	if err != nil {
+		return nil, errors.InternalErr(err)
+	}
+
+	t, err := unmarshalAccountToken(v)
+	if err != nil {
+		return nil, err
+	}
+
+	// a token of another kind is left for its own use, expired ones are never used.
+	if t.Kind != kind || !s.time().Before(t.ExpiresAt) {
+		return nil, service.ErrAccountTokenNotFound
+	}
+
+	if err := b.Delete([]byte(token)); err != nil {
+		return nil, errors.InternalErr(err)
+	}
+	return t, nil
+}
+
+// ActivateUser consumes the activation token and marks its user as activated.
+func (s *Service) ActivateUser(ctx context.Context, token string) (*service.User, error) {
+	var u *service.User
+	err := s.store.Modify(ctx, func(tx Impl) error {
+		t, err := s.consumeAccountToken(ctx, tx, service.ActivateToken, token)
+		if err != nil {
+			return err
+		}
+
+		user, err := s.findUserByID(ctx, tx, t.UserID)
+		if err != nil {
+			return err
+		}
+
+		user.Activated = true
+		if err := s.putUser(ctx, tx, user); err != nil {
+			return err
+		}
+		u = user
+		return nil
+	})
+	if err != nil {
+		return nil, &errors.Error{
+			Err: err,
+			Op:  service.OpActivateUser,
+		}
+	}
+	return u, nil
+}
@@ -0,0 +1,63 @@
+package store_test
+
+import (
+	"bytes"
+	"context"
+	"testing"
+
+	"github.com/ustackq/indagate/pkg/service"
+	"github.com/ustackq/indagate/pkg/utils/errors"
+)
+
+func TestAccountTokens(t *testing.T) {
+	ctx := context.Background()
+	s := newInmemService(t)
+
+	archive := `{"kind":"header","data":{"format":1}}
+{"kind":"user","data":{"id":2,"name":"alice","email":"alice@example.com"}}`
+	if _, err := s.Import(ctx, bytes.NewReader([]byte(archive))); err != nil {
+		t.Fatal(err)
+	}
+	alice := service.ID(2)
+
+	if _, err := s.CreateAccountToken(ctx, service.ActivateToken, service.ID(3)); errors.ErrorCode(err) != errors.NotFound {
+		t.Errorf("token of unknown user error = %v, want %s", err, errors.NotFound)
+	}
+
+	activate, err := s.CreateAccountToken(ctx, service.ActivateToken, alice)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := activate.ExpiresAt.Sub(activate.CreatedAt); got != service.ActivateToken.TTL() {
+		t.Errorf("token valid for %s, want %s", got, service.ActivateToken.TTL())
+	}
+
+	// a token is only used for its own kind, and left for it.
+	if _, err := s.ConsumeAccountToken(ctx, service.ResetPasswordToken, activate.Token); errors.ErrorCode(err) != errors.NotFound {
+		t.Errorf("token of another kind error = %v, want %s", err, errors.NotFound)
+	}
+
+	u, err := s.ActivateUser(ctx, activate.Token)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if u.ID != alice || !u.Activated {
+		t.Errorf("ActivateUser() = %+v, want alice activated", u)
+	}
+
+	if _, err := s.ActivateUser(ctx, activate.Token); errors.ErrorCode(err) != errors.NotFound {
+		t.Errorf("token used twice error = %v, want %s", err, errors.NotFound)
+	}
+
+	reset, err := s.CreateAccountToken(ctx, service.ResetPasswordToken, alice)
+	if err != nil {
+		t.Fatal(err)
+	}
+	consumed, err := s.ConsumeAccountToken(ctx, service.ResetPasswordToken, reset.Token)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if consumed.UserID != alice {
+		t.Errorf("consumed token of %s, want %s", consumed.UserID, alice)
+	}
+}
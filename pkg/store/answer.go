@@ -0,0 +1,747 @@
+package store
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"sort"
+
+	"github.com/ustackq/indagate/pkg/service"
+	"github.com/ustackq/indagate/pkg/utils/errors"
+)
+
+var (
+	answerBucket        = []byte("answersv1")
+	answerQuestionIndex = []byte("answerquestionindexv1")
+)
+
+// assert Service implement service.AnswerService
+var _ service.AnswerService = (*Service)(nil)
+
+func (s *Service) initializeAnswers(ctx context.Context, tx Impl) error {
+	if _, err := s.answerBucket(tx); err != nil {
+		return err
+	}
+	if _, err := s.answerQuestionIndexBucket(tx); err != nil {
+		return err
+	}
+	return nil
+}
+
+func (s *Service) answerBucket(tx Impl) (Bucket, error) {
+	b, err := tx.Bucket(answerBucket)
+	if err != nil {
+		return nil, UnexpectedAnswerError(err)
+	}
+	return b, nil
+}
+
+func (s *Service) answerQuestionIndexBucket(tx Impl) (Bucket, error) {
+	b, err := tx.Bucket(answerQuestionIndex)
+	if err != nil {
+		return nil, UnexpectedAnswerIndexError(err)
+	}
+	return b, nil
+}
+
+// UnexpectedAnswerError wraps errors raised while retrieving the answer bucket.
+func UnexpectedAnswerError(err error) *errors.Error {
+	return &errors.Error{
+		Code: errors.Internal,
+		Msg:  fmt.Sprintf("unexpected error retrieving answer bucket; %v", err),
+		Op:   "answerBucket",
+	}
+}
+
+// UnexpectedAnswerIndexError wraps errors raised while retrieving the answer index.
+func UnexpectedAnswerIndexError(err error) *errors.Error {
+	return &errors.Error{
+		Code: errors.Internal,
+		Msg:  fmt.Sprintf("unexpected error retrieving answer index; %v", err),
+		Op:   "answerIndex",
+	}
+}
+
+// answerQuestionIndexKey is the encoded question id followed by the encoded answer id,
+// so that all answers of a question share the same prefix.
+func answerQuestionIndexKey(questionID, answerID service.ID) ([]byte, error) {
+	qid, err := questionID.Encode()
+	if err != nil {
+		return nil, &errors.Error{
+			Code: errors.Invalid,
+			Err:  err,
+		}
+	}
+
+	aid, err := answerID.Encode()
+	if err != nil {
+		return nil, &errors.Error{
+			Code: errors.Invalid,
+			Err:  err,
+		}
+	}
+
+	key := make([]byte, 0, len(qid)+len(aid))
+	key = append(key, qid...)
+	return append(key, aid...), nil
+}
+
+// FindAnswerByID returns a single answer by ID.
+func (s *Service) FindAnswerByID(ctx context.Context, id service.ID) (*service.Answer, error) {
+	var a *service.Answer
+	err := s.store.View(ctx, func(tx Impl) error {
+		answer, err := s.findAnswerByID(ctx, tx, id)
+		if err != nil {
+			return err
+		}
+		a = answer
+		return nil
+	})
+	if err != nil {
+		return nil, &errors.Error{
+			Err: err,
+			Op:  service.OpFindAnswerByID,
+		}
+	}
+	return a, nil
+}
+
+func (s *Service) findAnswerByID(ctx context.Context, tx Impl, id service.ID) (*service.Answer, error) {
+	encodedID, err := id.Encode()
+	if err != nil {
+		return nil, &errors.Error{
+			Code: errors.Invalid,
+			Err:  err,
+		}
+	}
+
+	b, err := s.answerBucket(tx)
+	if err != nil {
+		return nil, err
+	}
+
+	v, err := b.Get(encodedID)
+	if IsNotFound(err) {
+		return nil, service.ErrAnswerNotFound
+	}
+
+	if err != nil {
+		return nil, &errors.Error{
+			Code: errors.Internal,
+			Err:  err,
+		}
+	}
+	return unmarshalAnswer(v)
+}
+
+func unmarshalAnswer(v []byte) (*service.Answer, error) {
+	a := &service.Answer{}
+	if err := json.Unmarshal(v, a); err != nil {
+		return nil, &errors.Error{
+			Code: errors.Internal,
+			Msg:  "answer could not be unmarshalled",
+			Err:  err,
+			Op:   "unmarshalAnswer",
+		}
+	}
+	return a, nil
+}
+
+// FindAnswers returns a list of answers that match filter and the total count of matching answers.
+func (s *Service) FindAnswers(ctx context.Context, filter service.AnswerFilter, opt ...service.FindOptions) ([]*service.Answer, int, error) {
+	if filter.ID != nil {
+		a, err := s.FindAnswerByID(ctx, *filter.ID)
+		if err != nil {
+			return nil, 0, &errors.Error{
+				Err: err,
+				Op:  service.OpFindAnswers,
+			}
+		}
+		if (filter.QuestionID != nil && a.QuestionID != *filter.QuestionID) || (filter.UserID != nil && a.UserID != *filter.UserID) {
+			return []*service.Answer{}, 0, nil
+		}
+		return []*service.Answer{a}, 1, nil
+	}
+
+	as := []*service.Answer{}
+	err := s.store.View(ctx, func(tx Impl) error {
+		answers, err := s.findAnswers(ctx, tx, filter)
+		if err != nil {
+			return err
+		}
+		as = answers
+		return nil
+	})
+	if err != nil {
+		return nil, 0, &errors.Error{
+			Err: err,
+			Op:  service.OpFindAnswers,
+		}
+	}
+
+	sortAnswers(as, opt...)
+	start, end := pageWindow(len(as), opt...)
+	return as[start:end], len(as), nil
+}
+
+func (s *Service) findAnswers(ctx context.Context, tx Impl, filter service.AnswerFilter) ([]*service.Answer, error) {
+	as := []*service.Answer{}
+	fn := func(a *service.Answer) bool {
+		if filter.UserID == nil || a.UserID == *filter.UserID {
+			as = append(as, a)
+		}
+		return true
+	}
+
+	// answers of a question are looked up by the question index.
+	if filter.QuestionID != nil {
+		if err := s.forEachQuestionAnswer(ctx, tx, *filter.QuestionID, fn); err != nil {
+			return nil, err
+		}
+		return as, nil
+	}
+
+	if err := s.forEachAnswer(ctx, tx, fn); err != nil {
+		return nil, err
+	}
+	return as, nil
+}
+
+func sortAnswers(as []*service.Answer, opts ...service.FindOptions) {
+	var opt service.FindOptions
+	if len(opts) > 0 {
+		opt = opts[0]
+	}
+
+	less := func(i, j int) bool {
+		return as[i].CreatedAt.Before(as[j].CreatedAt)
+	}
+	if opt.SortBy == service.AnswerSortByVotes {
+		less = func(i, j int) bool {
+			return as[i].AgreeCount-as[i].AgainstCount < as[j].AgreeCount-as[j].AgainstCount
+		}
+	}
+
+	if opt.Descending {
+		sort.SliceStable(as, func(i, j int) bool { return less(j, i) })
+		return
+	}
+	sort.SliceStable(as, less)
+}
+
+func (s *Service) forEachAnswer(ctx context.Context, tx Impl, fn func(*service.Answer) bool) error {
+	b, err := s.answerBucket(tx)
+	if err != nil {
+		return err
+	}
+
+	cur, err := b.Cursor()
+	if err != nil {
+		return err
+	}
+
+	for k, v := cur.First(); k != nil; k, v = cur.Next() {
+		a, err := unmarshalAnswer(v)
+		if err != nil {
+			return err
+		}
+		if !fn(a) {
+			break
+		}
+	}
+	return nil
+}
+
+func (s *Service) forEachQuestionAnswer(ctx context.Context, tx Impl, questionID service.ID, fn func(*service.Answer) bool) error {
+	prefix, err := questionID.Encode()
+	if err != nil {
+		return &errors.Error{
+			Code: errors.Invalid,
+			Err:  err,
+		}
+	}
+
+	idx, err := s.answerQuestionIndexBucket(tx)
+	if err != nil {
+		return err
+	}
+
+	cur, err := idx.Cursor()
+	if err != nil {
+		return err
+	}
+
+	for k, v := cur.Seek(prefix); k != nil && bytes.HasPrefix(k, prefix); k, v = cur.Next() {
+		var id service.ID
+		if err := id.Decode(v); err != nil {
+			return &errors.Error{
+				Code: errors.Invalid,
+				Err:  err,
+			}
+		}
+
+		a, err := s.findAnswerByID(ctx, tx, id)
+		if err != nil {
+			return err
+		}
+		if !fn(a) {
+			break
+		}
+	}
+	return nil
+}
+
+// CreateAnswer creates a new answer and sets a.ID with the new identifier.
+// The answer count and last answer of the question are updated in the same transaction.
+func (s *Service) CreateAnswer(ctx context.Context, a *service.Answer) error {
+	return s.store.Modify(ctx, func(tx Impl) error {
+		if err := s.createAnswer(ctx, tx, a); err != nil {
+			return &errors.Error{
+				Err: err,
+				Op:  service.OpCreateAnswer,
+			}
+		}
+		return nil
+	})
+}
+
+func (s *Service) createAnswer(ctx context.Context, tx Impl, a *service.Answer) error {
+	if err := a.Valid(); err != nil {
+		return err
+	}
+
+	q, err := s.findQuestionByID(ctx, tx, a.QuestionID)
+	if err != nil {
+		return err
+	}
+
+	if q.Lock {
+		return service.ErrQuestionLocked
+	}
+
+	a.OrgID = q.OrgID
+	reasons, err := s.approvalReasons(ctx, tx, answerContent(a))
+	if err != nil {
+		return err
+	}
+
+	a.ID = s.IDGenerator.ID()
+	a.BestAnswer = false
+	a.Pending = len(reasons) > 0
+	a.UnverifiedModify = nil
+	a.UnverifiedModifyCount = 0
+	a.CreatedAt = s.time()
+	a.UpdatedAt = a.CreatedAt
+	if err := s.putAnswer(ctx, tx, a); err != nil {
+		return err
+	}
+
+	key, err := answerQuestionIndexKey(a.QuestionID, a.ID)
+	if err != nil {
+		return err
+	}
+
+	idx, err := s.answerQuestionIndexBucket(tx)
+	if err != nil {
+		return err
+	}
+
+	encodedID, err := a.ID.Encode()
+	if err != nil {
+		return &errors.Error{
+			Code: errors.Invalid,
+			Err:  err,
+		}
+	}
+
+	if err := idx.Put(key, encodedID); err != nil {
+		return errors.InternalErr(err)
+	}
+
+	if err := s.deleteDraft(ctx, tx, a.UserID, service.AnswerDraft, a.QuestionID); err != nil {
+		return err
+	}
+
+	if a.Pending {
+		return s.submitApproval(ctx, tx, &service.Approval{
+			Kind:     service.CreateApproval,
+			ItemType: service.AnswerItemType,
+			ItemID:   a.ID,
+			OrgID:    a.OrgID,
+			UserID:   a.UserID,
+			Reasons:  reasons,
+		})
+	}
+	return s.publishAnswer(ctx, tx, q, a)
+}
+
+// publishAnswer makes a new answer searchable, notifies the asker, shows the answer
+// to the followers of its author and counts it on the question.
+func (s *Service) publishAnswer(ctx context.Context, tx Impl, q *service.Question, a *service.Answer) error {
+	if err := s.indexAnswer(ctx, tx, a); err != nil {
+		return err
+	}
+
+	if err := s.countPost(ctx, tx, a.UserID); err != nil {
+		return err
+	}
+
+	err := s.notify(ctx, tx, &service.Notification{
+		RecipientID: q.UserID,
+		SenderID:    a.UserID,
+		Action:      service.AnswerNotification,
+		ItemType:    service.AnswerItemType,
+		ItemID:      a.ID,
+	})
+	if err != nil {
+		return err
+	}
+
+	if !a.Anonymous {
+		err := s.recordActivity(ctx, tx, &service.Activity{
+			UserID:     a.UserID,
+			Action:     service.AnsweredActivity,
+			OrgID:      a.OrgID,
+			ItemType:   service.AnswerItemType,
+			ItemID:     a.ID,
+			QuestionID: a.QuestionID,
+		})
+		if err != nil {
+			return err
+		}
+	}
+
+	return s.refreshQuestionAnswers(ctx, tx, q)
+}
+
+func (s *Service) putAnswer(ctx context.Context, tx Impl, a *service.Answer) error {
+	v, err := json.Marshal(a)
+	if err != nil {
+		return &errors.Error{
+			Code: errors.Invalid,
+			Err:  err,
+		}
+	}
+
+	encodedID, err := a.ID.Encode()
+	if err != nil {
+		return &errors.Error{
+			Code: errors.Invalid,
+			Err:  err,
+		}
+	}
+
+	b, err := s.answerBucket(tx)
+	if err != nil {
+		return err
+	}
+
+	if err := b.Put(encodedID, v); err != nil {
+		return errors.InternalErr(err)
+	}
+	return nil
+}
+
+// refreshQuestionAnswers recounts the published answers of the question through the question
+// index and stores the answer count and the latest answer on the question.
+func (s *Service) refreshQuestionAnswers(ctx context.Context, tx Impl, q *service.Question) error {
+	var (
+		count int
+		last  *service.Answer
+	)
+	err := s.forEachQuestionAnswer(ctx, tx, q.ID, func(a *service.Answer) bool {
+		if a.Pending {
+			return true
+		}
+		count++
+		if last == nil || a.CreatedAt.After(last.CreatedAt) {
+			last = a
+		}
+		return true
+	})
+	if err != nil {
+		return err
+	}
+
+	q.AnswerCount = count
+	q.LastAnswer = 0
+	if last != nil {
+		q.LastAnswer = last.ID
+	}
+	q.UpdatedAt = s.time()
+	return s.putQuestion(ctx, tx, q)
+}
+
+// UpdateAnswer updates a single answer with changeset.
+func (s *Service) UpdateAnswer(ctx context.Context, id service.ID, upd service.AnswerUpdate) (*service.Answer, error) {
+	var a *service.Answer
+	err := s.store.Modify(ctx, func(tx Impl) error {
+		answer, err := s.updateAnswer(ctx, tx, id, upd)
+		if err != nil {
+			return &errors.Error{
+				Err: err,
+				Op:  service.OpUpdateAnswer,
+			}
+		}
+		a = answer
+		return nil
+	})
+	return a, err
+}
+
+func (s *Service) updateAnswer(ctx context.Context, tx Impl, id service.ID, upd service.AnswerUpdate) (*service.Answer, error) {
+	if err := upd.Valid(); err != nil {
+		return nil, err
+	}
+
+	a, err := s.findAnswerByID(ctx, tx, id)
+	if err != nil {
+		return nil, err
+	}
+
+	q, err := s.findQuestionByID(ctx, tx, a.QuestionID)
+	if err != nil {
+		return nil, err
+	}
+
+	if q.Lock {
+		return nil, service.ErrQuestionLocked
+	}
+
+	// pending answers are reviewed as a whole, edits of published ones are held
+	// back on their own when their author is under review.
+	if !a.Pending {
+		c := answerContent(a)
+		c.Texts = updateTexts(upd.Content)
+		reasons, err := s.approvalReasons(ctx, tx, c)
+		if err != nil {
+			return nil, err
+		}
+		if len(reasons) > 0 {
+			if err := s.holdAnswerUpdate(ctx, tx, a, upd, reasons); err != nil {
+				return nil, err
+			}
+			return a, nil
+		}
+	}
+
+	applyAnswerUpdate(a, upd)
+	a.UpdatedAt = s.time()
+
+	if err := s.putAnswer(ctx, tx, a); err != nil {
+		return nil, err
+	}
+
+	if err := s.indexAnswer(ctx, tx, a); err != nil {
+		return nil, err
+	}
+	return a, nil
+}
+
+func applyAnswerUpdate(a *service.Answer, upd service.AnswerUpdate) {
+	if upd.Content != nil {
+		a.Content = *upd.Content
+	}
+	if upd.Anonymous != nil {
+		a.Anonymous = *upd.Anonymous
+	}
+}
+
+// holdAnswerUpdate merges the update into the edit of the answer waiting for approval.
+func (s *Service) holdAnswerUpdate(ctx context.Context, tx Impl, a *service.Answer, upd service.AnswerUpdate, reasons []service.ApprovalReason) error {
+	if a.UnverifiedModify != nil {
+		upd = a.UnverifiedModify.Merge(upd)
+	}
+	a.UnverifiedModify = &upd
+	a.UnverifiedModifyCount++
+	if err := s.putAnswer(ctx, tx, a); err != nil {
+		return err
+	}
+
+	return s.submitApproval(ctx, tx, &service.Approval{
+		Kind:     service.EditApproval,
+		ItemType: service.AnswerItemType,
+		ItemID:   a.ID,
+		OrgID:    a.OrgID,
+		UserID:   a.UserID,
+		Reasons:  reasons,
+	})
+}
+
+// DeleteAnswer removes an answer by ID.
+// The answer count, last answer and best answer of the question are updated in the same transaction.
+func (s *Service) DeleteAnswer(ctx context.Context, id service.ID) error {
+	return s.store.Modify(ctx, func(tx Impl) error {
+		if err := s.deleteAnswer(ctx, tx, id); err != nil {
+			return &errors.Error{
+				Err: err,
+				Op:  service.OpDeleteAnswer,
+			}
+		}
+		return nil
+	})
+}
+
+func (s *Service) deleteAnswer(ctx context.Context, tx Impl, id service.ID) error {
+	a, err := s.findAnswerByID(ctx, tx, id)
+	if err != nil {
+		return err
+	}
+
+	if err := s.removeAnswer(ctx, tx, a); err != nil {
+		return err
+	}
+
+	q, err := s.findQuestionByID(ctx, tx, a.QuestionID)
+	if err != nil {
+		return err
+	}
+
+	if q.BestAnswer == a.ID {
+		q.BestAnswer = 0
+	}
+	return s.refreshQuestionAnswers(ctx, tx, q)
+}
+
+// removeAnswer deletes the answer, its comments, its votes and its question index entry.
+func (s *Service) removeAnswer(ctx context.Context, tx Impl, a *service.Answer) error {
+	if err := s.deleteItemComments(ctx, tx, service.AnswerComment, a.ID); err != nil {
+		return err
+	}
+
+	if err := s.deleteItemVotes(ctx, tx, service.AnswerItemType, a.ID, a.UserID); err != nil {
+		return err
+	}
+
+	err := s.deleteSubjectActivities(ctx, tx, service.QuestionItemType, a.QuestionID, func(activity *service.Activity) bool {
+		return activity.ItemType == service.AnswerItemType && activity.ItemID == a.ID
+	})
+	if err != nil {
+		return err
+	}
+
+	if err := s.unindexSearchDoc(ctx, tx, service.AnswerSearchType, a.ID); err != nil {
+		return err
+	}
+
+	if err := s.deleteItemApproval(ctx, tx, service.AnswerItemType, a.ID); err != nil {
+		return err
+	}
+
+	key, err := answerQuestionIndexKey(a.QuestionID, a.ID)
+	if err != nil {
+		return err
+	}
+
+	idx, err := s.answerQuestionIndexBucket(tx)
+	if err != nil {
+		return err
+	}
+
+	if err := idx.Delete(key); err != nil {
+		return errors.WrapperErr(err)
+	}
+
+	encodedID, err := a.ID.Encode()
+	if err != nil {
+		return &errors.Error{
+			Code: errors.Invalid,
+			Err:  err,
+		}
+	}
+
+	b, err := s.answerBucket(tx)
+	if err != nil {
+		return err
+	}
+
+	if err := b.Delete(encodedID); err != nil {
+		return errors.WrapperErr(err)
+	}
+	return nil
+}
+
+// deleteQuestionAnswers removes every answer of the question.
+func (s *Service) deleteQuestionAnswers(ctx context.Context, tx Impl, questionID service.ID) error {
+	as := []*service.Answer{}
+	err := s.forEachQuestionAnswer(ctx, tx, questionID, func(a *service.Answer) bool {
+		as = append(as, a)
+		return true
+	})
+	if err != nil {
+		return err
+	}
+
+	for _, a := range as {
+		if err := s.removeAnswer(ctx, tx, a); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// SetBestAnswer marks the answer as the best answer of the question.
+// The best answer, answer count and last answer of the question are updated in a single transaction.
+func (s *Service) SetBestAnswer(ctx context.Context, questionID, answerID service.ID) (*service.Question, error) {
+	var q *service.Question
+	err := s.store.Modify(ctx, func(tx Impl) error {
+		question, err := s.setBestAnswer(ctx, tx, questionID, answerID)
+		if err != nil {
+			return &errors.Error{
+				Err: err,
+				Op:  service.OpSetBestAnswer,
+			}
+		}
+		q = question
+		return nil
+	})
+	return q, err
+}
+
+func (s *Service) setBestAnswer(ctx context.Context, tx Impl, questionID, answerID service.ID) (*service.Question, error) {
+	q, err := s.findQuestionByID(ctx, tx, questionID)
+	if err != nil {
+		return nil, err
+	}
+
+	if q.Lock {
+		return nil, service.ErrQuestionLocked
+	}
+
+	a, err := s.findAnswerByID(ctx, tx, answerID)
+	if err != nil {
+		return nil, err
+	}
+
+	if a.QuestionID != q.ID {
+		return nil, &errors.Error{
+			Code: errors.Invalid,
+			Msg:  fmt.Sprintf("answer %s does not belong to question %s", a.ID, q.ID),
+		}
+	}
+
+	if q.BestAnswer.Valid() && q.BestAnswer != a.ID {
+		prev, err := s.findAnswerByID(ctx, tx, q.BestAnswer)
+		if err != nil && errors.ErrorCode(err) != errors.NotFound {
+			return nil, err
+		}
+
+		if prev != nil {
+			prev.BestAnswer = false
+			if err := s.putAnswer(ctx, tx, prev); err != nil {
+				return nil, err
+			}
+		}
+	}
+
+	a.BestAnswer = true
+	if err := s.putAnswer(ctx, tx, a); err != nil {
+		return nil, err
+	}
+
+	q.BestAnswer = a.ID
+	if err := s.refreshQuestionAnswers(ctx, tx, q); err != nil {
+		return nil, err
+	}
+	return q, nil
+}
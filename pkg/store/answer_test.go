@@ -0,0 +1,115 @@
+package store_test
+
+import (
+	"context"
+	"testing"
+
+	"github.com/ustackq/indagate/pkg/service"
+	"github.com/ustackq/indagate/pkg/utils/errors"
+)
+
+func TestAnswer(t *testing.T) {
+	ctx := context.Background()
+	s := newInmemService(t)
+
+	q := &service.Question{OrgID: service.ID(1), UserID: service.ID(2), Content: "why?"}
+	other := &service.Question{OrgID: service.ID(1), UserID: service.ID(2), Content: "how?"}
+	for _, question := range []*service.Question{q, other} {
+		if err := s.CreateQuestion(ctx, question); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	alice, bob := service.ID(11), service.ID(12)
+	first := &service.Answer{QuestionID: q.ID, UserID: alice, Content: "because"}
+	second := &service.Answer{QuestionID: q.ID, UserID: bob, Content: "why not"}
+	elsewhere := &service.Answer{QuestionID: other.ID, UserID: alice, Content: "somehow"}
+	for _, a := range []*service.Answer{first, second, elsewhere} {
+		if err := s.CreateAnswer(ctx, a); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if first.OrgID != q.OrgID {
+		t.Errorf("answer org = %s, want the org of the question %s", first.OrgID, q.OrgID)
+	}
+	if err := s.CreateAnswer(ctx, &service.Answer{QuestionID: service.ID(99), UserID: alice, Content: "lost"}); errors.ErrorCode(err) != errors.NotFound {
+		t.Errorf("answer a missing question error = %v, want %s", err, errors.NotFound)
+	}
+
+	found, err := s.FindQuestionByID(ctx, q.ID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if found.AnswerCount != 2 || found.LastAnswer != second.ID {
+		t.Errorf("question = %+v, want 2 answers, the last of bob", found)
+	}
+
+	// answers of a question are read from the question index.
+	for _, tt := range []struct {
+		name   string
+		filter service.AnswerFilter
+		want   []*service.Answer
+	}{
+		{name: "question", filter: service.AnswerFilter{QuestionID: &q.ID}, want: []*service.Answer{first, second}},
+		{name: "question and user", filter: service.AnswerFilter{QuestionID: &q.ID, UserID: &alice}, want: []*service.Answer{first}},
+		{name: "user", filter: service.AnswerFilter{UserID: &alice}, want: []*service.Answer{first, elsewhere}},
+		{name: "id", filter: service.AnswerFilter{ID: &second.ID}, want: []*service.Answer{second}},
+		{name: "id of another question", filter: service.AnswerFilter{ID: &elsewhere.ID, QuestionID: &q.ID}},
+	} {
+		t.Run(tt.name, func(t *testing.T) {
+			as, total, err := s.FindAnswers(ctx, tt.filter)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if total != len(tt.want) || len(as) != len(tt.want) {
+				t.Fatalf("found %d answers of %d, want %d", len(as), total, len(tt.want))
+			}
+			for i := range as {
+				if as[i].ID != tt.want[i].ID {
+					t.Errorf("answer %d = %q, want %q", i, as[i].Content, tt.want[i].Content)
+				}
+			}
+		})
+	}
+
+	// a single best answer per question.
+	if _, err := s.SetBestAnswer(ctx, q.ID, first.ID); err != nil {
+		t.Fatal(err)
+	}
+	best, err := s.SetBestAnswer(ctx, q.ID, second.ID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if best.BestAnswer != second.ID {
+		t.Errorf("best answer = %s, want %s", best.BestAnswer, second.ID)
+	}
+	if a, err := s.FindAnswerByID(ctx, first.ID); err != nil || a.BestAnswer {
+		t.Errorf("former best answer = %+v, %v, want it no longer best", a, err)
+	}
+	if _, err := s.SetBestAnswer(ctx, q.ID, elsewhere.ID); errors.ErrorCode(err) != errors.Invalid {
+		t.Errorf("best answer of another question error = %v, want %s", err, errors.Invalid)
+	}
+
+	// deleting the best answer clears it from the question and the index.
+	if err := s.DeleteAnswer(ctx, second.ID); err != nil {
+		t.Fatal(err)
+	}
+	found, err = s.FindQuestionByID(ctx, q.ID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if found.BestAnswer.Valid() || found.AnswerCount != 1 || found.LastAnswer != first.ID {
+		t.Errorf("question = %+v, want 1 answer and no best answer", found)
+	}
+	if as, total, err := s.FindAnswers(ctx, service.AnswerFilter{QuestionID: &q.ID}); err != nil || total != 1 || as[0].ID != first.ID {
+		t.Errorf("answers of the question = %+v, %d, %v, want the first answer", as, total, err)
+	}
+
+	// locked questions take no answers.
+	if err := s.LockQuestion(ctx, q.ID); err != nil {
+		t.Fatal(err)
+	}
+	if err := s.CreateAnswer(ctx, &service.Answer{QuestionID: q.ID, UserID: bob, Content: "late"}); errors.ErrorCode(err) != errors.Forbidden {
+		t.Errorf("answer a locked question error = %v, want %s", err, errors.Forbidden)
+	}
+}
@@ -0,0 +1,821 @@
+package store
+
+import (
+	"context"
+	"fmt"
+	"regexp"
+	"sort"
+	"strconv"
+
+	"github.com/ustackq/indagate/pkg/service"
+	"github.com/ustackq/indagate/pkg/utils/diff"
+	"github.com/ustackq/indagate/pkg/utils/errors"
+)
+
+var (
+	approvalBucket = []byte("approvalsv1")
+	// approvalItemBucket indexes the pending approval of an item by its item key.
+	approvalItemBucket = []byte("approvalitemsv1")
+	// approvalPostBucket counts the published posts of each user for the FirstPosts rule.
+	approvalPostBucket = []byte("approvalpostsv1")
+	approvalBuckets    = [][]byte{approvalBucket, approvalItemBucket, approvalPostBucket}
+)
+
+// linkPattern matches the links the ApprovalPolicy holds back.
+var linkPattern = regexp.MustCompile(`(?i)(https?://|www\.)\S+`)
+
+// assert Service implement service.ApprovalService
+var _ service.ApprovalService = (*Service)(nil)
+
+func (s *Service) initializeApprovals(ctx context.Context, tx Impl) error {
+	for _, b := range approvalBuckets {
+		if _, err := s.approvalBucket(tx, b); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (s *Service) approvalBucket(tx Impl, name []byte) (Bucket, error) {
+	b, err := tx.Bucket(name)
+	if err != nil {
+		return nil, UnexpectedApprovalError(err)
+	}
+	return b, nil
+}
+
+// UnexpectedApprovalError wraps errors raised while retrieving the approval buckets.
+func UnexpectedApprovalError(err error) *errors.Error {
+	return &errors.Error{
+		Code: errors.Internal,
+		Msg:  fmt.Sprintf("unexpected error retrieving approval bucket; %v", err),
+		Op:   "approvalBucket",
+	}
+}
+
+func unmarshalApproval(v []byte) (*service.Approval, error) {
+	a := &service.Approval{}
+	if err := json.Unmarshal(v, a); err != nil {
+		return nil, &errors.Error{
+			Code: errors.Internal,
+			Msg:  "approval could not be unmarshalled",
+			Err:  err,
+			Op:   "unmarshalApproval",
+		}
+	}
+	return a, nil
+}
+
+// approvalReasons returns the rules of the ApprovalPolicy and the content filters holding
+// back the content, none if it can be published right away. Deleted users have no reputation.
+func (s *Service) approvalReasons(ctx context.Context, tx Impl, c *service.Content) ([]service.ApprovalReason, error) {
+	policy := s.Config.ApprovalPolicy
+	reasons := []service.ApprovalReason{}
+	userID := c.UserID
+
+	r, err := s.filterContent(ctx, c)
+	if err != nil {
+		return nil, err
+	}
+	if r.Verdict == service.HoldContent {
+		reasons = append(reasons, service.FilterApprovalReason)
+	}
+
+	if policy.ReputationThreshold > 0 {
+		reputation := 0
+		u, err := s.findUserByID(ctx, tx, userID)
+		if err != nil && err != ErrUserNotFound {
+			return nil, err
+		}
+		if u != nil {
+			reputation = u.Reputation
+		}
+		if reputation < policy.ReputationThreshold {
+			reasons = append(reasons, service.ReputationApprovalReason)
+		}
+	}
+
+	if policy.FirstPosts > 0 {
+		n, err := s.userPostCount(ctx, tx, userID)
+		if err != nil {
+			return nil, err
+		}
+		if n < policy.FirstPosts {
+			reasons = append(reasons, service.FirstPostsApprovalReason)
+		}
+	}
+
+	if policy.Links {
+		for _, text := range c.Texts {
+			if linkPattern.MatchString(text) {
+				reasons = append(reasons, service.LinksApprovalReason)
+				break
+			}
+		}
+	}
+	return reasons, nil
+}
+
+// userPostCount returns the number of published posts of the user.
+func (s *Service) userPostCount(ctx context.Context, tx Impl, userID service.ID) (int, error) {
+	encodedID, err := userID.Encode()
+	if err != nil {
+		return 0, &errors.Error{
+			Code: errors.Invalid,
+			Err:  err,
+		}
+	}
+
+	b, err := s.approvalBucket(tx, approvalPostBucket)
+	if err != nil {
+		return 0, err
+	}
+
+	v, err := b.Get(encodedID)
+	if IsNotFound(err) {
+		return 0, nil
+	}
+	if err != nil {
+		return 0, errors.InternalErr(err)
+	}
+
+	var n int
+	if err := json.Unmarshal(v, &n); err != nil {
+		return 0, errors.InternalErr(err)
+	}
+	return n, nil
+}
+
+// countPost counts a post of the user which has just been published.
+func (s *Service) countPost(ctx context.Context, tx Impl, userID service.ID) error {
+	n, err := s.userPostCount(ctx, tx, userID)
+	if err != nil {
+		return err
+	}
+
+	b, err := s.approvalBucket(tx, approvalPostBucket)
+	if err != nil {
+		return err
+	}
+	return putPostCount(b, userID, n+1)
+}
+
+func putPostCount(b Bucket, userID service.ID, n int) error {
+	v, err := json.Marshal(n)
+	if err != nil {
+		return errors.InternalErr(err)
+	}
+
+	encodedID, err := userID.Encode()
+	if err != nil {
+		return &errors.Error{
+			Code: errors.Invalid,
+			Err:  err,
+		}
+	}
+
+	if err := b.Put(encodedID, v); err != nil {
+		return errors.InternalErr(err)
+	}
+	return nil
+}
+
+// countPosts recounts the published posts of every user from the questions and
+// answers in the store, for the posts which were not counted as they were published.
+func countPosts(ctx context.Context, tx Impl) error {
+	counts := map[service.ID]int{}
+
+	qb, err := tx.Bucket(questionBucket)
+	if err != nil {
+		return UnexpectedQuestionError(err)
+	}
+	err = forEach(qb, func(k, v []byte) error {
+		q, err := unmarshalQuestion(v)
+		if err != nil {
+			return err
+		}
+		if !q.Pending {
+			counts[q.UserID]++
+		}
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+
+	ab, err := tx.Bucket(answerBucket)
+	if err != nil {
+		return UnexpectedAnswerError(err)
+	}
+	err = forEach(ab, func(k, v []byte) error {
+		a, err := unmarshalAnswer(v)
+		if err != nil {
+			return err
+		}
+		if !a.Pending {
+			counts[a.UserID]++
+		}
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+
+	b, err := tx.Bucket(approvalPostBucket)
+	if err != nil {
+		return UnexpectedApprovalError(err)
+	}
+
+	// counters of users without published posts left are stale.
+	var stale [][]byte
+	err = forEach(b, func(k, v []byte) error {
+		var id service.ID
+		if err := id.Decode(k); err != nil {
+			return errors.InternalErr(err)
+		}
+		if counts[id] == 0 {
+			stale = append(stale, k)
+		}
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+	for _, k := range stale {
+		if err := b.Delete(k); err != nil && !IsNotFound(err) {
+			return errors.InternalErr(err)
+		}
+	}
+
+	for userID, n := range counts {
+		if err := putPostCount(b, userID, n); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// forEach calls fn with every key and value of the bucket.
+func forEach(b Bucket, fn func(k, v []byte) error) error {
+	cur, err := b.Cursor()
+	if err != nil {
+		return err
+	}
+
+	for k, v := cur.First(); k != nil; k, v = cur.Next() {
+		if err := fn(k, v); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// FindApprovalByID returns a single approval by ID.
+func (s *Service) FindApprovalByID(ctx context.Context, id service.ID) (*service.Approval, error) {
+	var a *service.Approval
+	err := s.store.View(ctx, func(tx Impl) error {
+		approval, err := s.findApprovalByID(ctx, tx, id)
+		if err != nil {
+			return err
+		}
+		a = approval
+		return nil
+	})
+	if err != nil {
+		return nil, &errors.Error{
+			Err: err,
+			Op:  service.OpFindApprovalByID,
+		}
+	}
+	return a, nil
+}
+
+func (s *Service) findApprovalByID(ctx context.Context, tx Impl, id service.ID) (*service.Approval, error) {
+	encodedID, err := id.Encode()
+	if err != nil {
+		return nil, &errors.Error{
+			Code: errors.Invalid,
+			Err:  err,
+		}
+	}
+
+	b, err := s.approvalBucket(tx, approvalBucket)
+	if err != nil {
+		return nil, err
+	}
+
+	v, err := b.Get(encodedID)
+	if IsNotFound(err) {
+		return nil, service.ErrApprovalNotFound
+	}
+	if err != nil {
+		return nil, errors.InternalErr(err)
+	}
+	return unmarshalApproval(v)
+}
+
+// findPendingApproval returns the approval the item waits for.
+func (s *Service) findPendingApproval(ctx context.Context, tx Impl, itemType service.ItemType, itemID service.ID) (*service.Approval, error) {
+	key, err := topicItemKey(itemType, itemID)
+	if err != nil {
+		return nil, err
+	}
+
+	b, err := s.approvalBucket(tx, approvalItemBucket)
+	if err != nil {
+		return nil, err
+	}
+
+	v, err := b.Get(key)
+	if IsNotFound(err) {
+		return nil, service.ErrApprovalNotFound
+	}
+	if err != nil {
+		return nil, errors.InternalErr(err)
+	}
+
+	var id service.ID
+	if err := id.Decode(v); err != nil {
+		return nil, errors.InternalErr(err)
+	}
+	return s.findApprovalByID(ctx, tx, id)
+}
+
+// putApproval stores the approval and indexes it under its item while it is pending.
+func (s *Service) putApproval(ctx context.Context, tx Impl, a *service.Approval) error {
+	v, err := json.Marshal(a)
+	if err != nil {
+		return &errors.Error{
+			Code: errors.Invalid,
+			Err:  err,
+		}
+	}
+
+	encodedID, err := a.ID.Encode()
+	if err != nil {
+		return &errors.Error{
+			Code: errors.Invalid,
+			Err:  err,
+		}
+	}
+
+	b, err := s.approvalBucket(tx, approvalBucket)
+	if err != nil {
+		return err
+	}
+
+	if err := b.Put(encodedID, v); err != nil {
+		return errors.InternalErr(err)
+	}
+
+	key, err := topicItemKey(a.ItemType, a.ItemID)
+	if err != nil {
+		return err
+	}
+
+	idx, err := s.approvalBucket(tx, approvalItemBucket)
+	if err != nil {
+		return err
+	}
+
+	if a.Status == service.ApprovalPending {
+		err = idx.Put(key, encodedID)
+	} else {
+		err = idx.Delete(key)
+	}
+	if err != nil && !IsNotFound(err) {
+		return errors.InternalErr(err)
+	}
+	return nil
+}
+
+// submitApproval holds back a new post or an edit, successive edits of a post share
+// the approval of its pending edit.
+func (s *Service) submitApproval(ctx context.Context, tx Impl, a *service.Approval) error {
+	now := s.time()
+	prev, err := s.findPendingApproval(ctx, tx, a.ItemType, a.ItemID)
+	if err != nil && err != service.ErrApprovalNotFound {
+		return err
+	}
+	if prev != nil {
+		for _, r := range a.Reasons {
+			if !containsApprovalReason(prev.Reasons, r) {
+				prev.Reasons = append(prev.Reasons, r)
+			}
+		}
+		prev.UpdatedAt = now
+		return s.putApproval(ctx, tx, prev)
+	}
+
+	a.ID = s.IDGenerator.ID()
+	a.Status = service.ApprovalPending
+	a.Review = nil
+	a.CreatedAt = now
+	a.UpdatedAt = now
+	return s.putApproval(ctx, tx, a)
+}
+
+func containsApprovalReason(rs []service.ApprovalReason, r service.ApprovalReason) bool {
+	for _, reason := range rs {
+		if reason == r {
+			return true
+		}
+	}
+	return false
+}
+
+// deleteItemApproval removes the pending approval of a deleted item.
+func (s *Service) deleteItemApproval(ctx context.Context, tx Impl, itemType service.ItemType, itemID service.ID) error {
+	a, err := s.findPendingApproval(ctx, tx, itemType, itemID)
+	if err == service.ErrApprovalNotFound {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+
+	key, err := topicItemKey(itemType, itemID)
+	if err != nil {
+		return err
+	}
+
+	idx, err := s.approvalBucket(tx, approvalItemBucket)
+	if err != nil {
+		return err
+	}
+
+	if err := idx.Delete(key); err != nil {
+		return errors.WrapperErr(err)
+	}
+
+	encodedID, err := a.ID.Encode()
+	if err != nil {
+		return &errors.Error{
+			Code: errors.Invalid,
+			Err:  err,
+		}
+	}
+
+	b, err := s.approvalBucket(tx, approvalBucket)
+	if err != nil {
+		return err
+	}
+
+	if err := b.Delete(encodedID); err != nil {
+		return errors.WrapperErr(err)
+	}
+	return nil
+}
+
+func filterApprovalFn(filter service.ApprovalFilter) func(a *service.Approval) bool {
+	return func(a *service.Approval) bool {
+		if filter.OrgID != nil && a.OrgID != *filter.OrgID {
+			return false
+		}
+		if filter.UserID != nil && a.UserID != *filter.UserID {
+			return false
+		}
+		if filter.ItemType != nil && a.ItemType != *filter.ItemType {
+			return false
+		}
+		if filter.Status != nil && a.Status != *filter.Status {
+			return false
+		}
+		return true
+	}
+}
+
+// FindApprovals returns the approvals matching the filter, oldest first, and the total count of them.
+func (s *Service) FindApprovals(ctx context.Context, filter service.ApprovalFilter, opt ...service.FindOptions) ([]*service.Approval, int, error) {
+	as := []*service.Approval{}
+	filterFn := filterApprovalFn(filter)
+	err := s.store.View(ctx, func(tx Impl) error {
+		b, err := s.approvalBucket(tx, approvalBucket)
+		if err != nil {
+			return err
+		}
+
+		cur, err := b.Cursor()
+		if err != nil {
+			return err
+		}
+
+		for k, v := cur.First(); k != nil; k, v = cur.Next() {
+			a, err := unmarshalApproval(v)
+			if err != nil {
+				return err
+			}
+			if filterFn(a) {
+				as = append(as, a)
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, 0, &errors.Error{
+			Err: err,
+			Op:  service.OpFindApprovals,
+		}
+	}
+
+	sort.SliceStable(as, func(i, j int) bool {
+		return as[i].CreatedAt.Before(as[j].CreatedAt)
+	})
+
+	start, end := pageWindow(len(as), opt...)
+	return as[start:end], len(as), nil
+}
+
+// FindApprovalDiff returns the changes a pending approval would publish, field by field.
+func (s *Service) FindApprovalDiff(ctx context.Context, id service.ID) ([]*service.ApprovalDiff, error) {
+	var ds []*service.ApprovalDiff
+	err := s.store.View(ctx, func(tx Impl) error {
+		d, err := s.findApprovalDiff(ctx, tx, id)
+		if err != nil {
+			return err
+		}
+		ds = d
+		return nil
+	})
+	if err != nil {
+		return nil, &errors.Error{
+			Err: err,
+			Op:  service.OpFindApprovalDiff,
+		}
+	}
+	return ds, nil
+}
+
+func (s *Service) findApprovalDiff(ctx context.Context, tx Impl, id service.ID) ([]*service.ApprovalDiff, error) {
+	a, err := s.findApprovalByID(ctx, tx, id)
+	if err != nil {
+		return nil, err
+	}
+
+	ds := []*service.ApprovalDiff{}
+	if a.Status != service.ApprovalPending {
+		return ds, nil
+	}
+
+	switch a.ItemType {
+	case service.QuestionItemType:
+		q, err := s.findQuestionByID(ctx, tx, a.ItemID)
+		if err != nil {
+			return nil, err
+		}
+
+		proposed := *q
+		if a.Kind == service.CreateApproval {
+			q = &service.Question{}
+		} else if q.UnverifiedModify != nil {
+			applyQuestionUpdate(&proposed, *q.UnverifiedModify)
+		}
+		ds = appendApprovalDiff(ds, "content", q.Content, proposed.Content)
+		ds = appendApprovalDiff(ds, "detail", q.Detail, proposed.Detail)
+		ds = appendApprovalDiff(ds, "anonymous", strconv.FormatBool(q.Anonymous), strconv.FormatBool(proposed.Anonymous))
+	case service.AnswerItemType:
+		an, err := s.findAnswerByID(ctx, tx, a.ItemID)
+		if err != nil {
+			return nil, err
+		}
+
+		proposed := *an
+		if a.Kind == service.CreateApproval {
+			an = &service.Answer{}
+		} else if an.UnverifiedModify != nil {
+			applyAnswerUpdate(&proposed, *an.UnverifiedModify)
+		}
+		ds = appendApprovalDiff(ds, "content", an.Content, proposed.Content)
+		ds = appendApprovalDiff(ds, "anonymous", strconv.FormatBool(an.Anonymous), strconv.FormatBool(proposed.Anonymous))
+	case service.CommentItemType:
+		c, err := s.findCommentByID(ctx, tx, a.ItemID)
+		if err != nil {
+			return nil, err
+		}
+		ds = appendApprovalDiff(ds, "content", "", c.Content)
+	}
+	return ds, nil
+}
+
+// approvalContent returns the content the approval would publish.
+func (s *Service) approvalContent(ctx context.Context, tx Impl, a *service.Approval) (*service.Content, error) {
+	switch {
+	case a.Kind == service.EditApproval && a.ItemType == service.QuestionItemType:
+		q, err := s.findQuestionByID(ctx, tx, a.ItemID)
+		if err != nil {
+			return nil, err
+		}
+		if q.UnverifiedModify != nil {
+			applyQuestionUpdate(q, *q.UnverifiedModify)
+		}
+		return questionContent(q), nil
+	case a.Kind == service.EditApproval && a.ItemType == service.AnswerItemType:
+		an, err := s.findAnswerByID(ctx, tx, a.ItemID)
+		if err != nil {
+			return nil, err
+		}
+		if an.UnverifiedModify != nil {
+			applyAnswerUpdate(an, *an.UnverifiedModify)
+		}
+		return answerContent(an), nil
+	}
+	return s.itemContent(ctx, tx, a.ItemType, a.ItemID)
+}
+
+// appendApprovalDiff appends the difference of the field unless it is unchanged.
+func appendApprovalDiff(ds []*service.ApprovalDiff, field, old, new string) []*service.ApprovalDiff {
+	lines := diff.Lines(old, new)
+	if !diff.Changed(lines) {
+		return ds
+	}
+	return append(ds, &service.ApprovalDiff{Field: field, Lines: lines})
+}
+
+// ReviewApproval publishes or discards the post or edit of the approval and notifies its author.
+// The decision trains the spam filters once committed.
+func (s *Service) ReviewApproval(ctx context.Context, id service.ID, r *service.ApprovalReview) (*service.Approval, error) {
+	var (
+		a      *service.Approval
+		sample *service.SpamSample
+	)
+	err := s.store.Modify(ctx, func(tx Impl) error {
+		approval, spam, err := s.reviewApproval(ctx, tx, id, r)
+		if err != nil {
+			return &errors.Error{
+				Err: err,
+				Op:  service.OpReviewApproval,
+			}
+		}
+		a, sample = approval, spam
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	s.trainSpam(ctx, sample)
+	return a, nil
+}
+
+func (s *Service) reviewApproval(ctx context.Context, tx Impl, id service.ID, r *service.ApprovalReview) (*service.Approval, *service.SpamSample, error) {
+	if err := r.Valid(); err != nil {
+		return nil, nil, err
+	}
+
+	a, err := s.findApprovalByID(ctx, tx, id)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	if a.Status != service.ApprovalPending {
+		return nil, nil, service.ErrApprovalReviewed
+	}
+
+	approved := r.Decision == service.ApprovalApproved
+
+	// the content is labelled before rejected posts are deleted.
+	c, err := s.approvalContent(ctx, tx, a)
+	if err != nil {
+		return nil, nil, err
+	}
+	sample, err := s.recordSpamSample(ctx, tx, c, !approved)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	switch a.ItemType {
+	case service.QuestionItemType:
+		err = s.reviewQuestion(ctx, tx, a, approved)
+	case service.AnswerItemType:
+		err = s.reviewAnswer(ctx, tx, a, approved)
+	case service.CommentItemType:
+		err = s.reviewComment(ctx, tx, a, approved)
+	}
+	if err != nil {
+		return nil, nil, err
+	}
+
+	// rejected new posts are deleted along with their pending approval, which
+	// is stored again with its review.
+	r.ReviewedAt = s.time()
+	a.Status = r.Decision
+	a.Review = r
+	a.UpdatedAt = r.ReviewedAt
+	if err := s.putApproval(ctx, tx, a); err != nil {
+		return nil, nil, err
+	}
+
+	action := service.ApprovedNotification
+	if !approved {
+		action = service.RejectedNotification
+	}
+	err = s.notify(ctx, tx, &service.Notification{
+		RecipientID: a.UserID,
+		SenderID:    r.ReviewerID,
+		Action:      action,
+		ItemType:    a.ItemType,
+		ItemID:      a.ItemID,
+	})
+	if err != nil {
+		return nil, nil, err
+	}
+	return a, sample, nil
+}
+
+func (s *Service) reviewQuestion(ctx context.Context, tx Impl, a *service.Approval, approved bool) error {
+	if a.Kind == service.CreateApproval && !approved {
+		return s.deleteQuestion(ctx, tx, a.ItemID)
+	}
+
+	q, err := s.findQuestionByID(ctx, tx, a.ItemID)
+	if err != nil {
+		return err
+	}
+
+	if a.Kind == service.CreateApproval {
+		q.Pending = false
+		q.UpdatedAt = s.time()
+		if err := s.putQuestion(ctx, tx, q); err != nil {
+			return err
+		}
+		return s.publishQuestion(ctx, tx, q)
+	}
+
+	if approved && q.UnverifiedModify != nil {
+		applyQuestionUpdate(q, *q.UnverifiedModify)
+		q.UpdatedAt = s.time()
+	}
+	q.UnverifiedModify = nil
+	q.UnverifiedModifyCount = 0
+	if err := s.putQuestion(ctx, tx, q); err != nil {
+		return err
+	}
+	return s.indexQuestion(ctx, tx, q)
+}
+
+func (s *Service) reviewAnswer(ctx context.Context, tx Impl, a *service.Approval, approved bool) error {
+	if a.Kind == service.CreateApproval && !approved {
+		return s.deleteAnswer(ctx, tx, a.ItemID)
+	}
+
+	an, err := s.findAnswerByID(ctx, tx, a.ItemID)
+	if err != nil {
+		return err
+	}
+
+	if a.Kind == service.CreateApproval {
+		q, err := s.findQuestionByID(ctx, tx, an.QuestionID)
+		if err != nil {
+			return err
+		}
+
+		an.Pending = false
+		an.UpdatedAt = s.time()
+		if err := s.putAnswer(ctx, tx, an); err != nil {
+			return err
+		}
+		return s.publishAnswer(ctx, tx, q, an)
+	}
+
+	if approved && an.UnverifiedModify != nil {
+		applyAnswerUpdate(an, *an.UnverifiedModify)
+		an.UpdatedAt = s.time()
+	}
+	an.UnverifiedModify = nil
+	an.UnverifiedModifyCount = 0
+	if err := s.putAnswer(ctx, tx, an); err != nil {
+		return err
+	}
+	return s.indexAnswer(ctx, tx, an)
+}
+
+func (s *Service) reviewComment(ctx context.Context, tx Impl, a *service.Approval, approved bool) error {
+	if !approved {
+		return s.deleteComment(ctx, tx, a.ItemID)
+	}
+
+	c, err := s.findCommentByID(ctx, tx, a.ItemID)
+	if err != nil {
+		return err
+	}
+
+	ct, err := commentableOf(c.Type)
+	if err != nil {
+		return err
+	}
+
+	var parent *service.Comment
+	if c.ReplyTo.Valid() {
+		parent, err = s.findCommentByID(ctx, tx, c.ReplyTo)
+		if err != nil {
+			return err
+		}
+	}
+
+	c.Pending = false
+	c.UpdatedAt = s.time()
+	if err := s.putComment(ctx, tx, c); err != nil {
+		return err
+	}
+	return s.publishComment(ctx, tx, ct, c, parent)
+}
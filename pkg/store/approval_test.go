@@ -0,0 +1,151 @@
+package store_test
+
+import (
+	"bytes"
+	"context"
+	"testing"
+
+	"github.com/ustackq/indagate/pkg/service"
+	"github.com/ustackq/indagate/pkg/utils/errors"
+)
+
+func TestApproval(t *testing.T) {
+	ctx := context.Background()
+	s := newInmemService(t)
+	s.Config.ApprovalPolicy = service.ApprovalPolicy{FirstPosts: 1, Links: true}
+
+	org, author, reviewer := service.ID(1), service.ID(2), service.ID(9)
+	review := func(id service.ID, decision service.ApprovalStatus, comment string) (*service.Approval, error) {
+		return s.ReviewApproval(ctx, id, &service.ApprovalReview{Decision: decision, Comment: comment, ReviewerID: reviewer})
+	}
+	pending := func() []*service.Approval {
+		t.Helper()
+		status := service.ApprovalPending
+		as, _, err := s.FindApprovals(ctx, service.ApprovalFilter{OrgID: &org, Status: &status})
+		if err != nil {
+			t.Fatal(err)
+		}
+		return as
+	}
+
+	// the first post of a user waits for approval and is not searchable.
+	q := &service.Question{OrgID: org, UserID: author, Content: "first question"}
+	if err := s.CreateQuestion(ctx, q); err != nil {
+		t.Fatal(err)
+	}
+	if !q.Pending {
+		t.Fatalf("first question = %+v, want it pending", q)
+	}
+	if _, n, err := s.Search(ctx, service.SearchQuery{Query: "question"}); err != nil || n != 0 {
+		t.Errorf("search pending question = %d, %v, want none", n, err)
+	}
+	as := pending()
+	if len(as) != 1 || as[0].Kind != service.CreateApproval || as[0].Reasons[0] != service.FirstPostsApprovalReason {
+		t.Fatalf("pending approvals = %+v, want the creation of the question", as)
+	}
+	if ds, err := s.FindApprovalDiff(ctx, as[0].ID); err != nil || len(ds) != 1 || ds[0].Field != "content" {
+		t.Errorf("creation diff = %+v, %v, want the inserted content", ds, err)
+	}
+	if _, err := review(as[0].ID, service.ApprovalRejected, ""); errors.ErrorCode(err) != errors.EmptyValue {
+		t.Errorf("rejection without comment error = %v, want %s", err, errors.EmptyValue)
+	}
+
+	// approving publishes the question and notifies the author.
+	if _, err := review(as[0].ID, service.ApprovalApproved, ""); err != nil {
+		t.Fatal(err)
+	}
+	if q, err := s.FindQuestionByID(ctx, q.ID); err != nil || q.Pending {
+		t.Errorf("approved question = %+v, %v, want it published", q, err)
+	}
+	if _, n, err := s.Search(ctx, service.SearchQuery{Query: "question"}); err != nil || n != 1 {
+		t.Errorf("search approved question = %d, %v, want it", n, err)
+	}
+	if _, err := review(as[0].ID, service.ApprovalApproved, ""); errors.ErrorCode(err) != errors.Conflict {
+		t.Errorf("review twice error = %v, want %s", err, errors.Conflict)
+	}
+	ns, _, err := s.FindNotifications(ctx, service.NotificationFilter{RecipientID: author})
+	if err != nil || len(ns) != 1 || ns[0].Action != service.ApprovedNotification {
+		t.Errorf("notifications = %d, %v, want the approval", len(ns), err)
+	}
+
+	// edits adding links are held back, others go through once the author has published a post.
+	withLink, detail := "see https://example.com", "more"
+	if _, err := s.UpdateQuestion(ctx, q.ID, service.QuestionUpdate{Content: &withLink}); err != nil {
+		t.Fatal(err)
+	}
+	updated, err := s.UpdateQuestion(ctx, q.ID, service.QuestionUpdate{Detail: &detail})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if updated.Content != "first question" || updated.UnverifiedModifyCount != 1 || updated.Detail != detail {
+		t.Errorf("updated question = %+v, want only the link held back", updated)
+	}
+	as = pending()
+	if len(as) != 1 || as[0].Kind != service.EditApproval {
+		t.Fatalf("pending approvals = %+v, want the edit of the question", as)
+	}
+	if _, err := review(as[0].ID, service.ApprovalRejected, "no ads"); err != nil {
+		t.Fatal(err)
+	}
+	if q, err := s.FindQuestionByID(ctx, q.ID); err != nil || q.Content != "first question" || q.UnverifiedModify != nil {
+		t.Errorf("rejected edit = %+v, %v, want the edit discarded", q, err)
+	}
+
+	// rejecting a new answer deletes it.
+	a := &service.Answer{QuestionID: q.ID, UserID: service.ID(3), Content: "visit www.example.com"}
+	if err := s.CreateAnswer(ctx, a); err != nil {
+		t.Fatal(err)
+	}
+	if q, err := s.FindQuestionByID(ctx, q.ID); err != nil || q.AnswerCount != 0 {
+		t.Errorf("question with pending answer = %+v, %v, want no answer counted", q, err)
+	}
+	as = pending()
+	if len(as) != 1 || len(as[0].Reasons) != 2 {
+		t.Fatalf("pending approvals = %+v, want the answer held for first post and links", as)
+	}
+	if _, err := review(as[0].ID, service.ApprovalRejected, "spam"); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := s.FindAnswerByID(ctx, a.ID); errors.ErrorCode(err) != errors.NotFound {
+		t.Errorf("rejected answer error = %v, want %s", err, errors.NotFound)
+	}
+	if a, err := s.FindApprovalByID(ctx, as[0].ID); err != nil || a.Status != service.ApprovalRejected || a.Review.Comment != "spam" {
+		t.Errorf("rejected approval = %+v, %v, want it kept with its review", a, err)
+	}
+}
+
+func TestApprovalImportedPosts(t *testing.T) {
+	ctx := context.Background()
+	src := newInmemService(t)
+
+	org, author, newcomer := service.ID(1), service.ID(2), service.ID(3)
+	if err := src.CreateQuestion(ctx, &service.Question{OrgID: org, UserID: author, Content: "old question"}); err != nil {
+		t.Fatal(err)
+	}
+	var archive bytes.Buffer
+	if err := src.Export(ctx, &archive); err != nil {
+		t.Fatal(err)
+	}
+
+	// the posts of the archive count as the first posts of their authors.
+	s := newInmemService(t)
+	s.Config.ApprovalPolicy = service.ApprovalPolicy{FirstPosts: 1}
+	if _, err := s.Import(ctx, &archive); err != nil {
+		t.Fatal(err)
+	}
+	for _, tt := range []struct {
+		userID  service.ID
+		pending bool
+	}{
+		{userID: author},
+		{userID: newcomer, pending: true},
+	} {
+		q := &service.Question{OrgID: org, UserID: tt.userID, Content: "new question"}
+		if err := s.CreateQuestion(ctx, q); err != nil {
+			t.Fatal(err)
+		}
+		if q.Pending != tt.pending {
+			t.Errorf("question of user %s pending = %t, want %t", tt.userID, q.Pending, tt.pending)
+		}
+	}
+}
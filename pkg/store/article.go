@@ -0,0 +1,656 @@
+package store
+
+import (
+	"context"
+	"fmt"
+	"sort"
+
+	"github.com/ustackq/indagate/pkg/service"
+	"github.com/ustackq/indagate/pkg/utils/errors"
+)
+
+var (
+	articleBucket     = []byte("articlesv1")
+	articleViewBucket = []byte("articleviewsv1")
+)
+
+// assert Service implement service.ArticleService
+var _ service.ArticleService = (*Service)(nil)
+
+func (s *Service) initializeArticles(ctx context.Context, tx Impl) error {
+	if _, err := s.articleBucket(tx); err != nil {
+		return err
+	}
+	if _, err := s.articleViewBucket(tx); err != nil {
+		return err
+	}
+	return nil
+}
+
+func (s *Service) articleBucket(tx Impl) (Bucket, error) {
+	b, err := tx.Bucket(articleBucket)
+	if err != nil {
+		return nil, UnexpectedArticleError(err)
+	}
+	return b, nil
+}
+
+func (s *Service) articleViewBucket(tx Impl) (Bucket, error) {
+	b, err := tx.Bucket(articleViewBucket)
+	if err != nil {
+		return nil, UnexpectedArticleError(err)
+	}
+	return b, nil
+}
+
+// UnexpectedArticleError wraps errors raised while retrieving the article buckets.
+func UnexpectedArticleError(err error) *errors.Error {
+	return &errors.Error{
+		Code: errors.Internal,
+		Msg:  fmt.Sprintf("unexpected error retrieving article bucket; %v", err),
+		Op:   "articleBucket",
+	}
+}
+
+// articleViewKey is the encoded article id followed by the encoded session id.
+func articleViewKey(articleID, sessionID service.ID) ([]byte, error) {
+	aid, err := articleID.Encode()
+	if err != nil {
+		return nil, &errors.Error{
+			Code: errors.Invalid,
+			Err:  err,
+		}
+	}
+
+	sid, err := sessionID.Encode()
+	if err != nil {
+		return nil, &errors.Error{
+			Code: errors.Invalid,
+			Err:  err,
+		}
+	}
+	return append(aid, sid...), nil
+}
+
+// FindArticleByID returns a single article by ID.
+func (s *Service) FindArticleByID(ctx context.Context, id service.ID) (*service.Article, error) {
+	var a *service.Article
+	err := s.store.View(ctx, func(tx Impl) error {
+		article, err := s.findArticleByID(ctx, tx, id)
+		if err != nil {
+			return err
+		}
+		a = article
+		return nil
+	})
+	if err != nil {
+		return nil, &errors.Error{
+			Err: err,
+			Op:  service.OpFindArticleByID,
+		}
+	}
+	return a, nil
+}
+
+func (s *Service) findArticleByID(ctx context.Context, tx Impl, id service.ID) (*service.Article, error) {
+	encodedID, err := id.Encode()
+	if err != nil {
+		return nil, &errors.Error{
+			Code: errors.Invalid,
+			Err:  err,
+		}
+	}
+
+	b, err := s.articleBucket(tx)
+	if err != nil {
+		return nil, err
+	}
+
+	v, err := b.Get(encodedID)
+	if IsNotFound(err) {
+		return nil, service.ErrArticleNotFound
+	}
+
+	if err != nil {
+		return nil, &errors.Error{
+			Code: errors.Internal,
+			Err:  err,
+		}
+	}
+	return unmarshalArticle(v)
+}
+
+// findPublishedArticle returns the article if it is published.
+func (s *Service) findPublishedArticle(ctx context.Context, tx Impl, id service.ID) (*service.Article, error) {
+	a, err := s.findArticleByID(ctx, tx, id)
+	if err != nil {
+		return nil, err
+	}
+
+	if a.State != service.ArticlePublished {
+		return nil, service.ErrArticleNotPublished
+	}
+	return a, nil
+}
+
+func unmarshalArticle(v []byte) (*service.Article, error) {
+	a := &service.Article{}
+	if err := json.Unmarshal(v, a); err != nil {
+		return nil, &errors.Error{
+			Code: errors.Internal,
+			Msg:  "article could not be unmarshalled",
+			Err:  err,
+			Op:   "unmarshalArticle",
+		}
+	}
+	return a, nil
+}
+
+func filterArticleFn(filter service.ArticleFilter) func(a *service.Article) bool {
+	return func(a *service.Article) bool {
+		if filter.ID != nil && a.ID != *filter.ID {
+			return false
+		}
+		if filter.OrgID != nil && a.OrgID != *filter.OrgID {
+			return false
+		}
+		if filter.UserID != nil && a.UserID != *filter.UserID {
+			return false
+		}
+		if filter.State != nil && a.State != *filter.State {
+			return false
+		}
+		return true
+	}
+}
+
+// FindArticles returns a list of articles that match filter and the total count of matching articles.
+func (s *Service) FindArticles(ctx context.Context, filter service.ArticleFilter, opt ...service.FindOptions) ([]*service.Article, int, error) {
+	as := []*service.Article{}
+	err := s.store.View(ctx, func(tx Impl) error {
+		filterFn := filterArticleFn(filter)
+		return s.forEachArticle(ctx, tx, func(a *service.Article) bool {
+			if filterFn(a) {
+				as = append(as, a)
+			}
+			return true
+		})
+	})
+	if err != nil {
+		return nil, 0, &errors.Error{
+			Err: err,
+			Op:  service.OpFindArticles,
+		}
+	}
+
+	sortArticles(as, opt...)
+	start, end := pageWindow(len(as), opt...)
+	return as[start:end], len(as), nil
+}
+
+func sortArticles(as []*service.Article, opts ...service.FindOptions) {
+	var opt service.FindOptions
+	if len(opts) > 0 {
+		opt = opts[0]
+	}
+
+	less := func(i, j int) bool {
+		return as[i].CreatedAt.Before(as[j].CreatedAt)
+	}
+	switch opt.SortBy {
+	case service.ArticleSortByPublished:
+		less = func(i, j int) bool {
+			if as[i].PublishedAt == nil || as[j].PublishedAt == nil {
+				return as[j].PublishedAt != nil
+			}
+			return as[i].PublishedAt.Before(*as[j].PublishedAt)
+		}
+	case service.ArticleSortByVotes:
+		less = func(i, j int) bool {
+			return as[i].AgreeCount < as[j].AgreeCount
+		}
+	case service.ArticleSortByViews:
+		less = func(i, j int) bool {
+			return as[i].ViewCount < as[j].ViewCount
+		}
+	}
+
+	if opt.Descending {
+		sort.SliceStable(as, func(i, j int) bool { return less(j, i) })
+		return
+	}
+	sort.SliceStable(as, less)
+}
+
+func (s *Service) forEachArticle(ctx context.Context, tx Impl, fn func(*service.Article) bool) error {
+	b, err := s.articleBucket(tx)
+	if err != nil {
+		return err
+	}
+
+	cur, err := b.Cursor()
+	if err != nil {
+		return err
+	}
+
+	for k, v := cur.First(); k != nil; k, v = cur.Next() {
+		a, err := unmarshalArticle(v)
+		if err != nil {
+			return err
+		}
+		if !fn(a) {
+			break
+		}
+	}
+	return nil
+}
+
+// CreateArticle creates a new draft article and sets a.ID with the new identifier.
+func (s *Service) CreateArticle(ctx context.Context, a *service.Article) error {
+	return s.store.Modify(ctx, func(tx Impl) error {
+		if err := s.createArticle(ctx, tx, a); err != nil {
+			return &errors.Error{
+				Err: err,
+				Op:  service.OpCreateArticle,
+			}
+		}
+		return nil
+	})
+}
+
+func (s *Service) createArticle(ctx context.Context, tx Impl, a *service.Article) error {
+	if err := a.Valid(); err != nil {
+		return err
+	}
+
+	a.ID = s.IDGenerator.ID()
+	a.State = service.ArticleDraft
+	a.PublishedAt = nil
+	a.ArchivedAt = nil
+	a.ViewCount = 0
+	a.CommentCount = 0
+	a.AgreeCount = 0
+	a.AgainstCount = 0
+	a.ThanksCount = 0
+	a.CreatedAt = s.time()
+	a.UpdatedAt = a.CreatedAt
+	return s.putArticle(ctx, tx, a)
+}
+
+func (s *Service) putArticle(ctx context.Context, tx Impl, a *service.Article) error {
+	v, err := json.Marshal(a)
+	if err != nil {
+		return &errors.Error{
+			Code: errors.Invalid,
+			Err:  err,
+		}
+	}
+
+	encodedID, err := a.ID.Encode()
+	if err != nil {
+		return &errors.Error{
+			Code: errors.Invalid,
+			Err:  err,
+		}
+	}
+
+	b, err := s.articleBucket(tx)
+	if err != nil {
+		return err
+	}
+
+	if err := b.Put(encodedID, v); err != nil {
+		return errors.InternalErr(err)
+	}
+	return nil
+}
+
+// UpdateArticle updates a single article with changeset.
+func (s *Service) UpdateArticle(ctx context.Context, id service.ID, upd service.ArticleUpdate) (*service.Article, error) {
+	var a *service.Article
+	err := s.store.Modify(ctx, func(tx Impl) error {
+		article, err := s.updateArticle(ctx, tx, id, upd)
+		if err != nil {
+			return &errors.Error{
+				Err: err,
+				Op:  service.OpUpdateArticle,
+			}
+		}
+		a = article
+		return nil
+	})
+	return a, err
+}
+
+func (s *Service) updateArticle(ctx context.Context, tx Impl, id service.ID, upd service.ArticleUpdate) (*service.Article, error) {
+	if err := upd.Valid(); err != nil {
+		return nil, err
+	}
+
+	a, err := s.findArticleByID(ctx, tx, id)
+	if err != nil {
+		return nil, err
+	}
+
+	if a.State == service.ArticleArchived {
+		return nil, service.ErrArticleArchived
+	}
+
+	if upd.Title != nil {
+		a.Title = *upd.Title
+	}
+	if upd.Abstract != nil {
+		a.Abstract = *upd.Abstract
+	}
+	if upd.Content != nil {
+		a.Content = *upd.Content
+	}
+	a.UpdatedAt = s.time()
+
+	if err := s.putArticle(ctx, tx, a); err != nil {
+		return nil, err
+	}
+
+	if err := s.indexArticle(ctx, tx, a); err != nil {
+		return nil, err
+	}
+	return a, nil
+}
+
+// PublishArticle publishes a draft or archived article.
+func (s *Service) PublishArticle(ctx context.Context, id service.ID) (*service.Article, error) {
+	var a *service.Article
+	err := s.store.Modify(ctx, func(tx Impl) error {
+		article, err := s.transitionArticle(ctx, tx, id, service.ArticlePublished)
+		if err != nil {
+			return &errors.Error{
+				Err: err,
+				Op:  service.OpPublishArticle,
+			}
+		}
+		a = article
+		return nil
+	})
+	return a, err
+}
+
+// ArchiveArticle archives a published article.
+func (s *Service) ArchiveArticle(ctx context.Context, id service.ID) (*service.Article, error) {
+	var a *service.Article
+	err := s.store.Modify(ctx, func(tx Impl) error {
+		article, err := s.transitionArticle(ctx, tx, id, service.ArticleArchived)
+		if err != nil {
+			return &errors.Error{
+				Err: err,
+				Op:  service.OpArchiveArticle,
+			}
+		}
+		a = article
+		return nil
+	})
+	return a, err
+}
+
+func (s *Service) transitionArticle(ctx context.Context, tx Impl, id service.ID, state service.ArticleState) (*service.Article, error) {
+	a, err := s.findArticleByID(ctx, tx, id)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := a.State.CanTransition(state); err != nil {
+		return nil, err
+	}
+
+	now := s.time()
+	switch state {
+	case service.ArticlePublished:
+		// republishing an archived article keeps the original publication time.
+		if a.PublishedAt == nil {
+			a.PublishedAt = &now
+		}
+		a.ArchivedAt = nil
+	case service.ArticleArchived:
+		a.ArchivedAt = &now
+	}
+	a.State = state
+	a.UpdatedAt = now
+
+	if err := s.putArticle(ctx, tx, a); err != nil {
+		return nil, err
+	}
+
+	if err := s.indexArticle(ctx, tx, a); err != nil {
+		return nil, err
+	}
+
+	if state == service.ArticlePublished {
+		if err := s.deleteDraft(ctx, tx, a.UserID, service.ArticleContentDraft, a.ID); err != nil {
+			return nil, err
+		}
+	}
+	return a, nil
+}
+
+// ViewArticle counts a view of a published article, views of the same session are counted once.
+func (s *Service) ViewArticle(ctx context.Context, id, sessionID service.ID) (*service.Article, error) {
+	var a *service.Article
+	err := s.store.Modify(ctx, func(tx Impl) error {
+		article, err := s.viewArticle(ctx, tx, id, sessionID)
+		if err != nil {
+			return err
+		}
+		a = article
+		return nil
+	})
+	if err != nil {
+		return nil, &errors.Error{
+			Err: err,
+			Op:  service.OpViewArticle,
+		}
+	}
+	return a, nil
+}
+
+func (s *Service) viewArticle(ctx context.Context, tx Impl, id, sessionID service.ID) (*service.Article, error) {
+	a, err := s.findArticleByID(ctx, tx, id)
+	if err != nil {
+		return nil, err
+	}
+
+	// drafts are only read by their authors and archived articles are frozen.
+	if a.State != service.ArticlePublished {
+		return a, nil
+	}
+
+	key, err := articleViewKey(id, sessionID)
+	if err != nil {
+		return nil, err
+	}
+
+	b, err := s.articleViewBucket(tx)
+	if err != nil {
+		return nil, err
+	}
+
+	_, err = b.Get(key)
+	if err == nil {
+		return a, nil
+	}
+	if !IsNotFound(err) {
+		return nil, errors.InternalErr(err)
+	}
+
+	v, err := s.time().MarshalText()
+	if err != nil {
+		return nil, errors.InternalErr(err)
+	}
+
+	if err := b.Put(key, v); err != nil {
+		return nil, errors.InternalErr(err)
+	}
+
+	a.ViewCount++
+	if err := s.putArticle(ctx, tx, a); err != nil {
+		return nil, err
+	}
+	return a, nil
+}
+
+// DeleteArticle removes an article by ID along with its comments, topic relations and views.
+func (s *Service) DeleteArticle(ctx context.Context, id service.ID) error {
+	return s.store.Modify(ctx, func(tx Impl) error {
+		if err := s.deleteArticle(ctx, tx, id); err != nil {
+			return &errors.Error{
+				Err: err,
+				Op:  service.OpDeleteArticle,
+			}
+		}
+		return nil
+	})
+}
+
+func (s *Service) deleteArticle(ctx context.Context, tx Impl, id service.ID) error {
+	a, err := s.findArticleByID(ctx, tx, id)
+	if err != nil {
+		return err
+	}
+
+	if err := s.deleteItemComments(ctx, tx, service.ArticleComment, id); err != nil {
+		return err
+	}
+
+	if err := s.deleteItemVotes(ctx, tx, service.ArticleItemType, id, a.UserID); err != nil {
+		return err
+	}
+
+	if err := s.deleteItemTopicRelations(ctx, tx, service.ArticleItemType, id); err != nil {
+		return err
+	}
+
+	if err := s.deleteArticleViews(ctx, tx, id); err != nil {
+		return err
+	}
+
+	if err := s.deleteItemDrafts(ctx, tx, service.ArticleContentDraft, id); err != nil {
+		return err
+	}
+
+	if err := s.deleteSubjectActivities(ctx, tx, service.ArticleItemType, id, nil); err != nil {
+		return err
+	}
+
+	if err := s.unindexSearchDoc(ctx, tx, service.ArticleSearchType, id); err != nil {
+		return err
+	}
+
+	encodedID, err := id.Encode()
+	if err != nil {
+		return &errors.Error{
+			Code: errors.Invalid,
+			Err:  err,
+		}
+	}
+
+	b, err := s.articleBucket(tx)
+	if err != nil {
+		return err
+	}
+
+	if err := b.Delete(encodedID); err != nil {
+		return errors.WrapperErr(err)
+	}
+	return nil
+}
+
+func (s *Service) deleteArticleViews(ctx context.Context, tx Impl, id service.ID) error {
+	prefix, err := id.Encode()
+	if err != nil {
+		return &errors.Error{
+			Code: errors.Invalid,
+			Err:  err,
+		}
+	}
+
+	b, err := s.articleViewBucket(tx)
+	if err != nil {
+		return err
+	}
+
+	keys := [][]byte{}
+	err = forEachPrefix(b, prefix, func(k, _ []byte) error {
+		keys = append(keys, append([]byte(nil), k...))
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+
+	for _, k := range keys {
+		if err := b.Delete(k); err != nil {
+			return errors.WrapperErr(err)
+		}
+	}
+	return nil
+}
+
+func (s *Service) articleOrg(ctx context.Context, tx Impl, id service.ID) (service.ID, error) {
+	a, err := s.findArticleByID(ctx, tx, id)
+	if err != nil {
+		return 0, err
+	}
+	return a.OrgID, nil
+}
+
+// publishedArticleOrg only lets published articles be commented on.
+func (s *Service) publishedArticleOrg(ctx context.Context, tx Impl, id service.ID) (service.ID, error) {
+	a, err := s.findPublishedArticle(ctx, tx, id)
+	if err != nil {
+		return 0, err
+	}
+	return a.OrgID, nil
+}
+
+func (s *Service) articleAuthor(ctx context.Context, tx Impl, id service.ID) (service.ID, error) {
+	a, err := s.findArticleByID(ctx, tx, id)
+	if err != nil {
+		return 0, err
+	}
+	return a.UserID, nil
+}
+
+func (s *Service) countArticleComments(ctx context.Context, tx Impl, id service.ID, n int) error {
+	a, err := s.findArticleByID(ctx, tx, id)
+	if err != nil {
+		return err
+	}
+
+	a.CommentCount = n
+	return s.putArticle(ctx, tx, a)
+}
+
+func (s *Service) findArticleVoteItem(ctx context.Context, tx Impl, id service.ID) (*service.VoteItem, error) {
+	a, err := s.findPublishedArticle(ctx, tx, id)
+	if err != nil {
+		return nil, err
+	}
+
+	return &service.VoteItem{
+		Type:         service.ArticleItemType,
+		ID:           a.ID,
+		OrgID:        a.OrgID,
+		AuthorID:     a.UserID,
+		AgreeCount:   a.AgreeCount,
+		AgainstCount: a.AgainstCount,
+	}, nil
+}
+
+func (s *Service) countArticleVote(ctx context.Context, tx Impl, id service.ID, agree, against int) error {
+	a, err := s.findArticleByID(ctx, tx, id)
+	if err != nil {
+		return err
+	}
+
+	a.AgreeCount += agree
+	a.AgainstCount += against
+	return s.putArticle(ctx, tx, a)
+}
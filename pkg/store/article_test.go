@@ -0,0 +1,87 @@
+package store_test
+
+import (
+	"context"
+	"testing"
+
+	"github.com/ustackq/indagate/pkg/service"
+	"github.com/ustackq/indagate/pkg/utils/errors"
+)
+
+func TestArticleLifecycle(t *testing.T) {
+	ctx := context.Background()
+	s := newInmemService(t)
+
+	a := &service.Article{OrgID: service.ID(1), UserID: service.ID(2), Title: "hello", Content: "world"}
+	if err := s.CreateArticle(ctx, a); err != nil {
+		t.Fatal(err)
+	}
+	if a.State != service.ArticleDraft {
+		t.Fatalf("new article state = %s, want %s", a.State, service.ArticleDraft)
+	}
+
+	// drafts can neither be voted on, commented on nor viewed.
+	v := &service.Vote{ItemType: service.ArticleItemType, ItemID: a.ID, UserID: service.ID(3), Value: service.UpVote}
+	if _, err := s.Vote(ctx, v); errors.ErrorCode(err) != errors.Forbidden {
+		t.Errorf("vote on draft error = %v, want %s", err, errors.Forbidden)
+	}
+	c := &service.Comment{Type: service.ArticleComment, ItemID: a.ID, UserID: service.ID(3), Content: "nice"}
+	if err := s.CreateComment(ctx, c); errors.ErrorCode(err) != errors.Forbidden {
+		t.Errorf("comment on draft error = %v, want %s", err, errors.Forbidden)
+	}
+	if _, err := s.ArchiveArticle(ctx, a.ID); errors.ErrorCode(err) != errors.Conflict {
+		t.Errorf("archive draft error = %v, want %s", err, errors.Conflict)
+	}
+
+	published, err := s.PublishArticle(ctx, a.ID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if published.State != service.ArticlePublished || published.PublishedAt == nil {
+		t.Fatalf("published article = %+v, want published with a publication time", published)
+	}
+
+	if _, err := s.Vote(ctx, v); err != nil {
+		t.Fatal(err)
+	}
+	if err := s.CreateComment(ctx, c); err != nil {
+		t.Fatal(err)
+	}
+
+	for _, session := range []service.ID{10, 10, 11} {
+		if _, err := s.ViewArticle(ctx, a.ID, session); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	got, err := s.FindArticleByID(ctx, a.ID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got.ViewCount != 2 || got.AgreeCount != 1 || got.CommentCount != 1 {
+		t.Errorf("article counters = views %d, agree %d, comments %d, want 2, 1, 1", got.ViewCount, got.AgreeCount, got.CommentCount)
+	}
+
+	if _, err := s.ArchiveArticle(ctx, a.ID); err != nil {
+		t.Fatal(err)
+	}
+	title := "edited"
+	if _, err := s.UpdateArticle(ctx, a.ID, service.ArticleUpdate{Title: &title}); errors.ErrorCode(err) != errors.Forbidden {
+		t.Errorf("update archived article error = %v, want %s", err, errors.Forbidden)
+	}
+
+	republished, err := s.PublishArticle(ctx, a.ID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !republished.PublishedAt.Equal(*published.PublishedAt) {
+		t.Errorf("republished at %s, want original publication time %s", republished.PublishedAt, published.PublishedAt)
+	}
+
+	if err := s.DeleteArticle(ctx, a.ID); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := s.FindCommentByID(ctx, c.ID); errors.ErrorCode(err) != errors.NotFound {
+		t.Errorf("comment of deleted article error = %v, want %s", err, errors.NotFound)
+	}
+}
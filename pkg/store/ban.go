@@ -0,0 +1,829 @@
+package store
+
+import (
+	"context"
+	"fmt"
+	"net"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/ustackq/indagate/pkg/service"
+	"github.com/ustackq/indagate/pkg/utils/errors"
+)
+
+var (
+	banBucket = []byte("bansv1")
+	// userBanBucket indexes the ban in force of each user by user ID.
+	userBanBucket  = []byte("userbansv1")
+	ipBlockBucket  = []byte("ipblocksv1")
+	banAuditBucket = []byte("banauditsv1")
+	banBuckets     = [][]byte{banBucket, userBanBucket, ipBlockBucket, banAuditBucket}
+)
+
+// assert Service implement service.BanService
+var _ service.BanService = (*Service)(nil)
+
+// ipBlockCache keeps the IP blocks in memory for CheckIP, which runs on every request.
+// It is invalidated once the writes changing the blocks are committed, a load which
+// raced such a write is not kept. Blocks written by another Service sharing the
+// store are not seen.
+type ipBlockCache struct {
+	mu  sync.RWMutex
+	gen uint64
+	// blocks is nil until loaded.
+	blocks []*service.IPBlock
+}
+
+func (c *ipBlockCache) invalidate() {
+	c.mu.Lock()
+	c.gen++
+	c.blocks = nil
+	c.mu.Unlock()
+}
+
+func (s *Service) initializeBans(ctx context.Context, tx Impl) error {
+	for _, b := range banBuckets {
+		if _, err := s.banBucket(tx, b); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (s *Service) banBucket(tx Impl, name []byte) (Bucket, error) {
+	b, err := tx.Bucket(name)
+	if err != nil {
+		return nil, UnexpectedBanError(err)
+	}
+	return b, nil
+}
+
+// UnexpectedBanError wraps errors raised while retrieving the ban buckets.
+func UnexpectedBanError(err error) *errors.Error {
+	return &errors.Error{
+		Code: errors.Internal,
+		Msg:  fmt.Sprintf("unexpected error retrieving ban bucket; %v", err),
+		Op:   "banBucket",
+	}
+}
+
+func unmarshalBan(v []byte) (*service.Ban, error) {
+	b := &service.Ban{}
+	if err := json.Unmarshal(v, b); err != nil {
+		return nil, &errors.Error{
+			Code: errors.Internal,
+			Msg:  "ban could not be unmarshalled",
+			Err:  err,
+			Op:   "unmarshalBan",
+		}
+	}
+	return b, nil
+}
+
+func unmarshalIPBlock(v []byte) (*service.IPBlock, error) {
+	b := &service.IPBlock{}
+	if err := json.Unmarshal(v, b); err != nil {
+		return nil, &errors.Error{
+			Code: errors.Internal,
+			Msg:  "ip block could not be unmarshalled",
+			Err:  err,
+			Op:   "unmarshalIPBlock",
+		}
+	}
+	return b, nil
+}
+
+func unmarshalBanAudit(v []byte) (*service.BanAudit, error) {
+	a := &service.BanAudit{}
+	if err := json.Unmarshal(v, a); err != nil {
+		return nil, &errors.Error{
+			Code: errors.Internal,
+			Msg:  "ban audit could not be unmarshalled",
+			Err:  err,
+			Op:   "unmarshalBanAudit",
+		}
+	}
+	return a, nil
+}
+
+// userBannedError tells banned users why and until when they are banned.
+func userBannedError(b *service.Ban) error {
+	msg := fmt.Sprintf("%s: %s", service.ErrUserBanned.Msg, b.Reason)
+	if !b.Permanent() {
+		msg = fmt.Sprintf("user is suspended until %s: %s", b.ExpiresAt.UTC().Format("2006-01-02 15:04 MST"), b.Reason)
+	}
+	return &errors.Error{
+		Code: service.ErrUserBanned.Code,
+		Msg:  msg,
+	}
+}
+
+// FindBanByID returns a single ban by ID.
+func (s *Service) FindBanByID(ctx context.Context, id service.ID) (*service.Ban, error) {
+	var b *service.Ban
+	err := s.store.View(ctx, func(tx Impl) error {
+		ban, err := s.findBanByID(ctx, tx, id)
+		if err != nil {
+			return err
+		}
+		b = ban
+		return nil
+	})
+	if err != nil {
+		return nil, &errors.Error{
+			Err: err,
+			Op:  service.OpFindBanByID,
+		}
+	}
+	return b, nil
+}
+
+func (s *Service) findBanByID(ctx context.Context, tx Impl, id service.ID) (*service.Ban, error) {
+	encodedID, err := id.Encode()
+	if err != nil {
+		return nil, &errors.Error{
+			Code: errors.Invalid,
+			Err:  err,
+		}
+	}
+
+	b, err := s.banBucket(tx, banBucket)
+	if err != nil {
+		return nil, err
+	}
+
+	v, err := b.Get(encodedID)
+	if IsNotFound(err) {
+		return nil, service.ErrBanNotFound
+	}
+	if err != nil {
+		return nil, errors.InternalErr(err)
+	}
+	return unmarshalBan(v)
+}
+
+// findUserBan returns the ban in force of the user, which may have expired since.
+func (s *Service) findUserBan(ctx context.Context, tx Impl, userID service.ID) (*service.Ban, error) {
+	encodedUserID, err := userID.Encode()
+	if err != nil {
+		return nil, &errors.Error{
+			Code: errors.Invalid,
+			Err:  err,
+		}
+	}
+
+	idx, err := s.banBucket(tx, userBanBucket)
+	if err != nil {
+		return nil, err
+	}
+
+	v, err := idx.Get(encodedUserID)
+	if IsNotFound(err) {
+		return nil, service.ErrBanNotFound
+	}
+	if err != nil {
+		return nil, errors.InternalErr(err)
+	}
+
+	var id service.ID
+	if err := id.Decode(v); err != nil {
+		return nil, errors.InternalErr(err)
+	}
+	return s.findBanByID(ctx, tx, id)
+}
+
+// putBan stores the ban and indexes it under its user while it is in force.
+func (s *Service) putBan(ctx context.Context, tx Impl, ban *service.Ban) error {
+	v, err := json.Marshal(ban)
+	if err != nil {
+		return &errors.Error{
+			Code: errors.Invalid,
+			Err:  err,
+		}
+	}
+
+	encodedID, err := ban.ID.Encode()
+	if err != nil {
+		return &errors.Error{
+			Code: errors.Invalid,
+			Err:  err,
+		}
+	}
+
+	encodedUserID, err := ban.UserID.Encode()
+	if err != nil {
+		return &errors.Error{
+			Code: errors.Invalid,
+			Err:  err,
+		}
+	}
+
+	b, err := s.banBucket(tx, banBucket)
+	if err != nil {
+		return err
+	}
+
+	if err := b.Put(encodedID, v); err != nil {
+		return errors.InternalErr(err)
+	}
+
+	idx, err := s.banBucket(tx, userBanBucket)
+	if err != nil {
+		return err
+	}
+
+	if ban.Active(s.time()) {
+		err = idx.Put(encodedUserID, encodedID)
+	} else {
+		err = idx.Delete(encodedUserID)
+	}
+	if err != nil && !IsNotFound(err) {
+		return errors.InternalErr(err)
+	}
+	return nil
+}
+
+func filterBanFn(filter service.BanFilter, now time.Time) func(b *service.Ban) bool {
+	return func(b *service.Ban) bool {
+		if filter.UserID != nil && b.UserID != *filter.UserID {
+			return false
+		}
+		if filter.Active != nil && b.Active(now) != *filter.Active {
+			return false
+		}
+		return true
+	}
+}
+
+// FindBans returns the bans matching the filter, latest first, and the total count of them.
+func (s *Service) FindBans(ctx context.Context, filter service.BanFilter, opt ...service.FindOptions) ([]*service.Ban, int, error) {
+	bs := []*service.Ban{}
+	filterFn := filterBanFn(filter, s.time())
+	err := s.store.View(ctx, func(tx Impl) error {
+		b, err := s.banBucket(tx, banBucket)
+		if err != nil {
+			return err
+		}
+
+		cur, err := b.Cursor()
+		if err != nil {
+			return err
+		}
+
+		for k, v := cur.First(); k != nil; k, v = cur.Next() {
+			ban, err := unmarshalBan(v)
+			if err != nil {
+				return err
+			}
+			if filterFn(ban) {
+				bs = append(bs, ban)
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, 0, &errors.Error{
+			Err: err,
+			Op:  service.OpFindBans,
+		}
+	}
+
+	sort.SliceStable(bs, func(i, j int) bool {
+		return bs[i].CreatedAt.After(bs[j].CreatedAt)
+	})
+
+	start, end := pageWindow(len(bs), opt...)
+	return bs[start:end], len(bs), nil
+}
+
+// BanUser bans the user and revokes the sessions and tokens of the user in the same transaction.
+func (s *Service) BanUser(ctx context.Context, b *service.Ban) error {
+	var revoked int
+	err := s.store.Modify(ctx, func(tx Impl) error {
+		n, err := s.banUser(ctx, tx, b)
+		if err != nil {
+			return &errors.Error{
+				Err: err,
+				Op:  service.OpBanUser,
+			}
+		}
+		revoked = n
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+
+	s.liveSessions.Sub(float64(revoked))
+	return nil
+}
+
+// banUser returns the number of sessions of the user expired by the ban.
+func (s *Service) banUser(ctx context.Context, tx Impl, b *service.Ban) (int, error) {
+	if err := b.Valid(); err != nil {
+		return 0, err
+	}
+
+	now := s.time()
+	if b.ExpiresAt != nil && !b.ExpiresAt.After(now) {
+		return 0, &errors.Error{
+			Code: errors.Invalid,
+			Msg:  "suspension must end in the future",
+		}
+	}
+
+	if _, err := s.findUserByID(ctx, tx, b.UserID); err != nil {
+		return 0, err
+	}
+
+	prev, err := s.findUserBan(ctx, tx, b.UserID)
+	if err != nil && err != service.ErrBanNotFound {
+		return 0, err
+	}
+	if prev != nil && prev.Active(now) {
+		return 0, service.ErrUserAlreadyBanned
+	}
+
+	b.ID = s.IDGenerator.ID()
+	b.CreatedAt = now
+	b.LiftedAt = nil
+	b.LiftedBy = 0
+	if err := s.putBan(ctx, tx, b); err != nil {
+		return 0, err
+	}
+
+	n, err := s.revokeUserAccess(ctx, tx, b.UserID)
+	if err != nil {
+		return 0, err
+	}
+
+	err = s.putBanAudit(ctx, tx, &service.BanAudit{
+		Action:   service.BanUserAudit,
+		TargetID: b.ID,
+		UserID:   b.UserID,
+		ActorID:  b.CreatedBy,
+		Reason:   b.Reason,
+	})
+	if err != nil {
+		return 0, err
+	}
+	return n, nil
+}
+
+// revokeUserAccess expires the sessions of the user and deactivates the tokens of the user,
+// it returns the number of sessions expired. Tokens stay inactive once the ban ends.
+func (s *Service) revokeUserAccess(ctx context.Context, tx Impl, userID service.ID) (int, error) {
+	b, err := s.sessionBucket(tx)
+	if err != nil {
+		return 0, err
+	}
+
+	cur, err := b.Cursor()
+	if err != nil {
+		return 0, err
+	}
+
+	// collect first, deleting while iterating would move the cursor.
+	var sessions []*service.Session
+	for k, v := cur.First(); k != nil; k, v = cur.Next() {
+		sess, err := unmarshalSession(v)
+		if err != nil {
+			return 0, err
+		}
+		if sess.UserID == userID {
+			sessions = append(sessions, sess)
+		}
+	}
+
+	for _, sess := range sessions {
+		if err := s.removeSession(ctx, tx, sess); err != nil {
+			return 0, err
+		}
+	}
+
+	auths, err := s.findAuthorization(ctx, tx, service.AuthorizationFilter{UserID: &userID})
+	if err != nil {
+		return 0, err
+	}
+
+	inactive := service.Inactive
+	for _, a := range auths {
+		if !service.IsActive(a) {
+			continue
+		}
+		if _, err := s.updateAuthorization(ctx, tx, a.ID, &service.AuthorizationUpdate{Status: &inactive}); err != nil {
+			return 0, err
+		}
+	}
+	return len(sessions), nil
+}
+
+// LiftBan ends the ban before its end.
+func (s *Service) LiftBan(ctx context.Context, id service.ID, liftedBy service.ID) (*service.Ban, error) {
+	var b *service.Ban
+	err := s.store.Modify(ctx, func(tx Impl) error {
+		ban, err := s.liftBan(ctx, tx, id, liftedBy)
+		if err != nil {
+			return &errors.Error{
+				Err: err,
+				Op:  service.OpLiftBan,
+			}
+		}
+		b = ban
+		return nil
+	})
+	return b, err
+}
+
+func (s *Service) liftBan(ctx context.Context, tx Impl, id service.ID, liftedBy service.ID) (*service.Ban, error) {
+	if !liftedBy.Valid() {
+		return nil, &errors.Error{
+			Code: errors.Invalid,
+			Msg:  "ban lifter id is invalid",
+		}
+	}
+
+	b, err := s.findBanByID(ctx, tx, id)
+	if err != nil {
+		return nil, err
+	}
+
+	now := s.time()
+	if !b.Active(now) {
+		return nil, service.ErrBanLifted
+	}
+
+	b.LiftedAt = &now
+	b.LiftedBy = liftedBy
+	if err := s.putBan(ctx, tx, b); err != nil {
+		return nil, err
+	}
+
+	err = s.putBanAudit(ctx, tx, &service.BanAudit{
+		Action:   service.LiftBanAudit,
+		TargetID: b.ID,
+		UserID:   b.UserID,
+		ActorID:  liftedBy,
+	})
+	if err != nil {
+		return nil, err
+	}
+	return b, nil
+}
+
+// CheckUser returns an error with the Forbidden code if the user is banned.
+func (s *Service) CheckUser(ctx context.Context, userID service.ID) error {
+	err := s.store.View(ctx, func(tx Impl) error {
+		return s.checkUser(ctx, tx, userID)
+	})
+	if err != nil {
+		return &errors.Error{
+			Err: err,
+			Op:  service.OpCheckUser,
+		}
+	}
+	return nil
+}
+
+func (s *Service) checkUser(ctx context.Context, tx Impl, userID service.ID) error {
+	b, err := s.findUserBan(ctx, tx, userID)
+	if err == service.ErrBanNotFound {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+
+	if b.Active(s.time()) {
+		return userBannedError(b)
+	}
+	return nil
+}
+
+func (s *Service) findIPBlockByID(ctx context.Context, tx Impl, id service.ID) (*service.IPBlock, error) {
+	encodedID, err := id.Encode()
+	if err != nil {
+		return nil, &errors.Error{
+			Code: errors.Invalid,
+			Err:  err,
+		}
+	}
+
+	b, err := s.banBucket(tx, ipBlockBucket)
+	if err != nil {
+		return nil, err
+	}
+
+	v, err := b.Get(encodedID)
+	if IsNotFound(err) {
+		return nil, service.ErrIPBlockNotFound
+	}
+	if err != nil {
+		return nil, errors.InternalErr(err)
+	}
+	return unmarshalIPBlock(v)
+}
+
+func (s *Service) putIPBlock(ctx context.Context, tx Impl, block *service.IPBlock) error {
+	v, err := json.Marshal(block)
+	if err != nil {
+		return &errors.Error{
+			Code: errors.Invalid,
+			Err:  err,
+		}
+	}
+
+	encodedID, err := block.ID.Encode()
+	if err != nil {
+		return &errors.Error{
+			Code: errors.Invalid,
+			Err:  err,
+		}
+	}
+
+	b, err := s.banBucket(tx, ipBlockBucket)
+	if err != nil {
+		return err
+	}
+
+	if err := b.Put(encodedID, v); err != nil {
+		return errors.InternalErr(err)
+	}
+	return nil
+}
+
+func (s *Service) forEachIPBlock(ctx context.Context, tx Impl, fn func(*service.IPBlock)) error {
+	b, err := s.banBucket(tx, ipBlockBucket)
+	if err != nil {
+		return err
+	}
+
+	cur, err := b.Cursor()
+	if err != nil {
+		return err
+	}
+
+	for k, v := cur.First(); k != nil; k, v = cur.Next() {
+		block, err := unmarshalIPBlock(v)
+		if err != nil {
+			return err
+		}
+		fn(block)
+	}
+	return nil
+}
+
+// FindIPBlocks returns the IP blocks matching the filter, latest first, and the total count of them.
+func (s *Service) FindIPBlocks(ctx context.Context, filter service.IPBlockFilter, opt ...service.FindOptions) ([]*service.IPBlock, int, error) {
+	bs := []*service.IPBlock{}
+	err := s.store.View(ctx, func(tx Impl) error {
+		return s.forEachIPBlock(ctx, tx, func(b *service.IPBlock) {
+			if filter.Scope == nil || b.Scope == *filter.Scope {
+				bs = append(bs, b)
+			}
+		})
+	})
+	if err != nil {
+		return nil, 0, &errors.Error{
+			Err: err,
+			Op:  service.OpFindIPBlocks,
+		}
+	}
+
+	sort.SliceStable(bs, func(i, j int) bool {
+		return bs[i].CreatedAt.After(bs[j].CreatedAt)
+	})
+
+	start, end := pageWindow(len(bs), opt...)
+	return bs[start:end], len(bs), nil
+}
+
+// CreateIPBlock blocks the range and sets b.ID with the new identifier.
+func (s *Service) CreateIPBlock(ctx context.Context, b *service.IPBlock) error {
+	defer s.ipBlocks.invalidate()
+	return s.store.Modify(ctx, func(tx Impl) error {
+		if err := s.createIPBlock(ctx, tx, b); err != nil {
+			return &errors.Error{
+				Err: err,
+				Op:  service.OpCreateIPBlock,
+			}
+		}
+		return nil
+	})
+}
+
+func (s *Service) createIPBlock(ctx context.Context, tx Impl, b *service.IPBlock) error {
+	if err := b.Valid(); err != nil {
+		return err
+	}
+
+	now := s.time()
+	if b.ExpiresAt != nil && !b.ExpiresAt.After(now) {
+		return &errors.Error{
+			Code: errors.Invalid,
+			Msg:  "ip block must end in the future",
+		}
+	}
+
+	n, err := service.ParseCIDR(b.CIDR)
+	if err != nil {
+		return err
+	}
+
+	b.ID = s.IDGenerator.ID()
+	b.CIDR = n.String()
+	b.CreatedAt = now
+	if err := s.putIPBlock(ctx, tx, b); err != nil {
+		return err
+	}
+
+	return s.putBanAudit(ctx, tx, &service.BanAudit{
+		Action:   service.BlockIPAudit,
+		TargetID: b.ID,
+		CIDR:     b.CIDR,
+		ActorID:  b.CreatedBy,
+		Reason:   b.Reason,
+	})
+}
+
+// DeleteIPBlock removes the block.
+func (s *Service) DeleteIPBlock(ctx context.Context, id service.ID, deletedBy service.ID) error {
+	defer s.ipBlocks.invalidate()
+	return s.store.Modify(ctx, func(tx Impl) error {
+		if err := s.deleteIPBlock(ctx, tx, id, deletedBy); err != nil {
+			return &errors.Error{
+				Err: err,
+				Op:  service.OpDeleteIPBlock,
+			}
+		}
+		return nil
+	})
+}
+
+func (s *Service) deleteIPBlock(ctx context.Context, tx Impl, id service.ID, deletedBy service.ID) error {
+	if !deletedBy.Valid() {
+		return &errors.Error{
+			Code: errors.Invalid,
+			Msg:  "ip block deleter id is invalid",
+		}
+	}
+
+	block, err := s.findIPBlockByID(ctx, tx, id)
+	if err != nil {
+		return err
+	}
+
+	encodedID, err := id.Encode()
+	if err != nil {
+		return &errors.Error{
+			Code: errors.Invalid,
+			Err:  err,
+		}
+	}
+
+	b, err := s.banBucket(tx, ipBlockBucket)
+	if err != nil {
+		return err
+	}
+
+	if err := b.Delete(encodedID); err != nil {
+		return errors.WrapperErr(err)
+	}
+
+	return s.putBanAudit(ctx, tx, &service.BanAudit{
+		Action:   service.UnblockIPAudit,
+		TargetID: block.ID,
+		CIDR:     block.CIDR,
+		ActorID:  deletedBy,
+	})
+}
+
+// CheckIP returns an error with the Forbidden code if the ip is blocked for the scope.
+func (s *Service) CheckIP(ctx context.Context, ip net.IP, scope service.IPBlockScope) error {
+	blocks, err := s.cachedIPBlocks(ctx)
+	if err != nil {
+		return &errors.Error{
+			Err: err,
+			Op:  service.OpCheckIP,
+		}
+	}
+
+	now := s.time()
+	for _, b := range blocks {
+		if b.Scope == scope && b.Active(now) && b.Contains(ip) {
+			return &errors.Error{
+				Err: &errors.Error{
+					Code: service.ErrIPBlocked.Code,
+					Msg:  fmt.Sprintf("%s: %s", service.ErrIPBlocked.Msg, b.Reason),
+				},
+				Op: service.OpCheckIP,
+			}
+		}
+	}
+	return nil
+}
+
+// cachedIPBlocks returns the IP blocks of the cache, loading them on a miss.
+func (s *Service) cachedIPBlocks(ctx context.Context) ([]*service.IPBlock, error) {
+	c := &s.ipBlocks
+	c.mu.RLock()
+	blocks, gen := c.blocks, c.gen
+	c.mu.RUnlock()
+	if blocks != nil {
+		return blocks, nil
+	}
+
+	blocks = []*service.IPBlock{}
+	err := s.store.View(ctx, func(tx Impl) error {
+		return s.forEachIPBlock(ctx, tx, func(b *service.IPBlock) {
+			blocks = append(blocks, b)
+		})
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	c.mu.Lock()
+	if c.gen == gen {
+		c.blocks = blocks
+	}
+	c.mu.Unlock()
+	return blocks, nil
+}
+
+// putBanAudit appends the entry to the audit trail.
+func (s *Service) putBanAudit(ctx context.Context, tx Impl, a *service.BanAudit) error {
+	a.ID = s.IDGenerator.ID()
+	a.CreatedAt = s.time()
+
+	v, err := json.Marshal(a)
+	if err != nil {
+		return &errors.Error{
+			Code: errors.Invalid,
+			Err:  err,
+		}
+	}
+
+	encodedID, err := a.ID.Encode()
+	if err != nil {
+		return &errors.Error{
+			Code: errors.Invalid,
+			Err:  err,
+		}
+	}
+
+	b, err := s.banBucket(tx, banAuditBucket)
+	if err != nil {
+		return err
+	}
+
+	if err := b.Put(encodedID, v); err != nil {
+		return errors.InternalErr(err)
+	}
+	return nil
+}
+
+// FindBanAudits returns the audit entries matching the filter, latest first, and the total count of them.
+func (s *Service) FindBanAudits(ctx context.Context, filter service.BanAuditFilter, opt ...service.FindOptions) ([]*service.BanAudit, int, error) {
+	as := []*service.BanAudit{}
+	err := s.store.View(ctx, func(tx Impl) error {
+		b, err := s.banBucket(tx, banAuditBucket)
+		if err != nil {
+			return err
+		}
+
+		cur, err := b.Cursor()
+		if err != nil {
+			return err
+		}
+
+		for k, v := cur.First(); k != nil; k, v = cur.Next() {
+			a, err := unmarshalBanAudit(v)
+			if err != nil {
+				return err
+			}
+			if filter.UserID != nil && a.UserID != *filter.UserID {
+				continue
+			}
+			if filter.ActorID != nil && a.ActorID != *filter.ActorID {
+				continue
+			}
+			as = append(as, a)
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, 0, &errors.Error{
+			Err: err,
+			Op:  service.OpFindBanAudits,
+		}
+	}
+
+	sort.SliceStable(as, func(i, j int) bool {
+		return as[i].CreatedAt.After(as[j].CreatedAt)
+	})
+
+	start, end := pageWindow(len(as), opt...)
+	return as[start:end], len(as), nil
+}
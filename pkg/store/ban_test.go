@@ -0,0 +1,136 @@
+package store_test
+
+import (
+	"bytes"
+	"context"
+	"net"
+	"testing"
+	"time"
+
+	"github.com/ustackq/indagate/pkg/service"
+	"github.com/ustackq/indagate/pkg/utils/errors"
+)
+
+func TestBanUser(t *testing.T) {
+	ctx := context.Background()
+	s := newInmemService(t)
+
+	archive := `{"kind":"header","data":{"format":1}}
+{"kind":"user","data":{"id":2,"name":"spammer"}}
+{"kind":"session","data":{"id":3,"key":"session-key","expiresAt":"2100-01-01T00:00:00Z","userID":2}}
+{"kind":"authorization","data":{"id":4,"org":1,"token":"token","userID":2,"active":"active"}}`
+	if _, err := s.Import(ctx, bytes.NewReader([]byte(archive))); err != nil {
+		t.Fatal(err)
+	}
+
+	user, admin := service.ID(2), service.ID(9)
+	b := &service.Ban{UserID: user, Reason: "spam", CreatedBy: admin}
+	if err := s.BanUser(ctx, b); err != nil {
+		t.Fatal(err)
+	}
+
+	// the sessions of banned users are expired and their tokens deactivated.
+	if _, err := s.FindSession(ctx, "session-key"); errors.ErrorCode(err) != errors.NotFound {
+		t.Errorf("find session error = %v, want %s", err, errors.NotFound)
+	}
+	if a, err := s.FindAuthorizationByToken(ctx, "token"); err != nil || a.Status != service.Inactive {
+		t.Errorf("authorization = %+v, %v, want it inactive", a, err)
+	}
+	if err := s.CheckUser(ctx, user); errors.ErrorCode(err) != errors.Forbidden {
+		t.Errorf("check user error = %v, want %s", err, errors.Forbidden)
+	}
+	if _, err := s.CreateSession(ctx, "spammer"); errors.ErrorCode(err) != errors.Forbidden {
+		t.Errorf("create session error = %v, want %s", err, errors.Forbidden)
+	}
+	if err := s.BanUser(ctx, &service.Ban{UserID: user, Reason: "again", CreatedBy: admin}); errors.ErrorCode(err) != errors.Conflict {
+		t.Errorf("second ban error = %v, want %s", err, errors.Conflict)
+	}
+
+	lifted, err := s.LiftBan(ctx, b.ID, admin)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if lifted.LiftedAt == nil || lifted.LiftedBy != admin {
+		t.Errorf("lifted ban = %+v, want lifted by %s", lifted, admin)
+	}
+	if err := s.CheckUser(ctx, user); err != nil {
+		t.Errorf("check user after lift error = %v", err)
+	}
+	if _, err := s.LiftBan(ctx, b.ID, admin); errors.ErrorCode(err) != errors.Conflict {
+		t.Errorf("second lift error = %v, want %s", err, errors.Conflict)
+	}
+
+	// suspensions end by themselves.
+	past := time.Now().Add(-time.Hour)
+	if err := s.BanUser(ctx, &service.Ban{UserID: user, Reason: "late", ExpiresAt: &past, CreatedBy: admin}); errors.ErrorCode(err) != errors.Invalid {
+		t.Errorf("ban ended in the past error = %v, want %s", err, errors.Invalid)
+	}
+
+	active := true
+	if bs, total, err := s.FindBans(ctx, service.BanFilter{UserID: &user, Active: &active}); err != nil || total != 0 || len(bs) != 0 {
+		t.Errorf("active bans = %+v, %d, %v, want none", bs, total, err)
+	}
+	if as, total, err := s.FindBanAudits(ctx, service.BanAuditFilter{UserID: &user}); err != nil || total != 2 || as[0].Action != service.LiftBanAudit || as[1].Action != service.BanUserAudit {
+		t.Errorf("audits = %+v, %d, %v, want the lift then the ban", as, total, err)
+	}
+}
+
+func TestIPBlock(t *testing.T) {
+	ctx := context.Background()
+	s := newInmemService(t)
+
+	admin := service.ID(9)
+	access := &service.IPBlock{CIDR: "10.0.0.0/8", Scope: service.AccessIPBlock, Reason: "abuse", CreatedBy: admin}
+	if err := s.CreateIPBlock(ctx, access); err != nil {
+		t.Fatal(err)
+	}
+	registration := &service.IPBlock{CIDR: "192.168.1.7", Scope: service.RegistrationIPBlock, Reason: "sock puppets", CreatedBy: admin}
+	if err := s.CreateIPBlock(ctx, registration); err != nil {
+		t.Fatal(err)
+	}
+	if registration.CIDR != "192.168.1.7/32" {
+		t.Errorf("registration block cidr = %q, want a /32 range", registration.CIDR)
+	}
+
+	for _, tt := range []struct {
+		ip    string
+		scope service.IPBlockScope
+		code  string
+	}{
+		{ip: "10.1.2.3", scope: service.AccessIPBlock, code: errors.Forbidden},
+		{ip: "192.168.0.1", scope: service.AccessIPBlock},
+		{ip: "192.168.1.7", scope: service.AccessIPBlock},
+		{ip: "192.168.1.7", scope: service.RegistrationIPBlock, code: errors.Forbidden},
+		{ip: "192.168.1.8", scope: service.RegistrationIPBlock},
+	} {
+		if err := s.CheckIP(ctx, net.ParseIP(tt.ip), tt.scope); errors.ErrorCode(err) != tt.code {
+			t.Errorf("check %s for %s error = %v, want %q", tt.ip, tt.scope, err, tt.code)
+		}
+	}
+
+	if err := s.DeleteIPBlock(ctx, access.ID, admin); err != nil {
+		t.Fatal(err)
+	}
+	if err := s.CheckIP(ctx, net.ParseIP("10.1.2.3"), service.AccessIPBlock); err != nil {
+		t.Errorf("check after unblock error = %v", err)
+	}
+	if as, total, err := s.FindBanAudits(ctx, service.BanAuditFilter{ActorID: &admin}); err != nil || total != 3 || as[0].Action != service.UnblockIPAudit {
+		t.Errorf("audits = %+v, %d, %v, want the unblock first", as, total, err)
+	}
+
+	// the blocks are cached, importing blocks refreshes them.
+	var archive bytes.Buffer
+	if err := s.Export(ctx, &archive); err != nil {
+		t.Fatal(err)
+	}
+	dst := newInmemService(t)
+	if err := dst.CheckIP(ctx, net.ParseIP("192.168.1.7"), service.RegistrationIPBlock); err != nil {
+		t.Fatalf("check before import error = %v", err)
+	}
+	if _, err := dst.Import(ctx, &archive); err != nil {
+		t.Fatal(err)
+	}
+	if err := dst.CheckIP(ctx, net.ParseIP("192.168.1.7"), service.RegistrationIPBlock); errors.ErrorCode(err) != errors.Forbidden {
+		t.Errorf("check after import error = %v, want %s", err, errors.Forbidden)
+	}
+}
@@ -35,7 +35,7 @@ func (c *Client) FindAuthorizationByID(ctx context.Context, id service.ID) (*ser
 	var err error
 	err = c.db.View(func(tx *bolt.Tx) error {
 		var se *errors.Error
-		a, se := c.findAuthorizationByID(ctx, tx, id)
+		a, se = c.findAuthorizationByID(ctx, tx, id)
 		if se != nil {
 			se.Op = getOp(service.OpFindAuthorizationByID)
 			err = se
@@ -149,9 +149,9 @@ func filterAuthorizationFn(filter service.AuthorizationFilter) func(auth *servic
 	return func(auth *service.Authorization) bool { return false }
 }
 
-// FindAuthorizations retives all authorizations that match a authorization filter.
+// FindAuthorization retives all authorizations that match a authorization filter.
 // Filters using ID, or Token should be efficient.
-func (c *Client) FindAuthorizations(ctx context.Context, filter service.AuthorizationFilter, opt ...service.FindOptions) ([]*service.Authorization, int, error) {
+func (c *Client) FindAuthorization(ctx context.Context, filter service.AuthorizationFilter, opt ...service.FindOptions) ([]*service.Authorization, int, error) {
 	if filter.ID != nil {
 		auth, err := c.FindAuthorizationByID(ctx, *filter.ID)
 		if err != nil {
@@ -349,5 +349,60 @@ func (c *Client) deleteAuthorization(ctx context.Context, tx *bolt.Tx, id servic
 			Err: err,
 		}
 	}
+
+	encodedID, e := id.Encode()
+	if e != nil {
+		return &errors.Error{
+			Code: errors.Invalid,
+			Err:  e,
+		}
+	}
+
+	if err := tx.Bucket(authorizationBucket).Delete(encodedID); err != nil {
+		return &errors.Error{
+			Err: err,
+		}
+	}
 	return nil
 }
+
+// DeleteAuthorization removes a authorization by ID.
+func (c *Client) DeleteAuthorization(ctx context.Context, id service.ID) error {
+	return c.db.Update(func(tx *bolt.Tx) error {
+		if err := c.deleteAuthorization(ctx, tx, id); err != nil {
+			err.Op = getOp(service.OpDeleteAuthorization)
+			return err
+		}
+		return nil
+	})
+}
+
+// UpdateAuthorization updates the status and description of a authorization.
+func (c *Client) UpdateAuthorization(ctx context.Context, id service.ID, update *service.AuthorizationUpdate) (*service.Authorization, error) {
+	var a *service.Authorization
+	err := c.db.Update(func(tx *bolt.Tx) error {
+		auth, err := c.findAuthorizationByID(ctx, tx, id)
+		if err != nil {
+			err.Op = getOp(service.OpUpdateAuthorization)
+			return err
+		}
+
+		if update.Status != nil {
+			auth.Status = *update.Status
+		}
+		if update.Description != nil {
+			auth.Description = *update.Description
+		}
+
+		if err := c.putAuthorization(ctx, tx, auth); err != nil {
+			err.Op = getOp(service.OpUpdateAuthorization)
+			return err
+		}
+		a = auth
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return a, nil
+}
@@ -0,0 +1,166 @@
+package bolt
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"time"
+
+	bolt "go.etcd.io/bbolt"
+
+	"github.com/ustackq/indagate/pkg/service"
+	"github.com/ustackq/indagate/pkg/utils/errors"
+	"github.com/ustackq/indagate/pkg/version"
+)
+
+var _ service.BackupService = (*Client)(nil)
+
+// Backup writes a consistent snapshot of the database to w, the server keeps serving
+// writes while the snapshot is taken.
+func (client *Client) Backup(ctx context.Context, w io.Writer) (*service.BackupManifest, error) {
+	var m *service.BackupManifest
+	err := client.db.View(func(tx *bolt.Tx) error {
+		manifest, err := newManifest(tx)
+		if err != nil {
+			return err
+		}
+		manifest.CreatedAt = client.time()
+
+		if _, err := tx.WriteTo(w); err != nil {
+			return err
+		}
+		m = manifest
+		return nil
+	})
+	if err != nil {
+		return nil, &errors.Error{
+			Code: errors.Internal,
+			Op:   getOp(service.OpBackup),
+			Err:  err,
+		}
+	}
+	return m, nil
+}
+
+func newManifest(tx *bolt.Tx) (*service.BackupManifest, error) {
+	info := version.Get()
+	m := &service.BackupManifest{
+		Version: info.GitVersion,
+		Commit:  info.GitCommit,
+		Size:    tx.Size(),
+	}
+
+	err := tx.ForEach(func(name []byte, b *bolt.Bucket) error {
+		bucket := service.BackupBucket{
+			Name: string(name),
+		}
+		if err := b.ForEach(func(k, v []byte) error {
+			bucket.Keys++
+			return nil
+		}); err != nil {
+			return err
+		}
+		m.Buckets = append(m.Buckets, bucket)
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return m, nil
+}
+
+// VerifyBackup checks the snapshot at path holds the buckets and keys listed by m.
+func VerifyBackup(path string, m *service.BackupManifest) error {
+	fi, err := os.Stat(path)
+	if err != nil {
+		return err
+	}
+	if fi.Size() != m.Size {
+		return fmt.Errorf("backup %s has %d bytes, manifest expects %d", path, fi.Size(), m.Size)
+	}
+
+	db, err := bolt.Open(path, 0600, &bolt.Options{Timeout: 1 * time.Second, ReadOnly: true})
+	if err != nil {
+		return fmt.Errorf("unable to open backup %s: %v", path, err)
+	}
+	defer db.Close()
+
+	return db.View(func(tx *bolt.Tx) error {
+		actual, err := newManifest(tx)
+		if err != nil {
+			return err
+		}
+
+		keys := make(map[string]int, len(actual.Buckets))
+		for _, b := range actual.Buckets {
+			keys[b.Name] = b.Keys
+		}
+
+		if len(actual.Buckets) != len(m.Buckets) {
+			return fmt.Errorf("backup %s has %d buckets, manifest expects %d", path, len(actual.Buckets), len(m.Buckets))
+		}
+		for _, b := range m.Buckets {
+			n, ok := keys[b.Name]
+			if !ok {
+				return fmt.Errorf("backup %s is missing bucket %s", path, b.Name)
+			}
+			if n != b.Keys {
+				return fmt.Errorf("bucket %s of backup %s has %d keys, manifest expects %d", b.Name, path, n, b.Keys)
+			}
+		}
+		return nil
+	})
+}
+
+// Restore verifies the snapshot at backupPath against m and replaces the database
+// file at dataPath with it. The server using dataPath must be stopped.
+func Restore(backupPath string, m *service.BackupManifest, dataPath string) error {
+	if err := VerifyBackup(backupPath, m); err != nil {
+		return err
+	}
+
+	// hold the lock of the current database to make sure no server is using it.
+	if _, err := os.Stat(dataPath); err == nil {
+		db, err := bolt.Open(dataPath, 0600, &bolt.Options{Timeout: 1 * time.Second})
+		if err != nil {
+			return fmt.Errorf("unable to lock %s, the server must be stopped: %v", dataPath, err)
+		}
+		defer db.Close()
+	}
+
+	if err := os.MkdirAll(filepath.Dir(dataPath), 0700); err != nil {
+		return fmt.Errorf("unable to create directory %s: %v", dataPath, err)
+	}
+
+	src, err := os.Open(backupPath)
+	if err != nil {
+		return err
+	}
+	defer src.Close()
+
+	// copy next to the data file first so that the replacement is a rename.
+	tmp := dataPath + ".restore"
+	dst, err := os.OpenFile(tmp, os.O_CREATE|os.O_TRUNC|os.O_WRONLY, 0600)
+	if err != nil {
+		return err
+	}
+
+	if _, err := io.Copy(dst, src); err != nil {
+		dst.Close()
+		os.Remove(tmp)
+		return err
+	}
+	if err := dst.Sync(); err != nil {
+		dst.Close()
+		os.Remove(tmp)
+		return err
+	}
+	if err := dst.Close(); err != nil {
+		os.Remove(tmp)
+		return err
+	}
+
+	return os.Rename(tmp, dataPath)
+}
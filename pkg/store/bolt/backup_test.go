@@ -0,0 +1,77 @@
+package bolt_test
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/ustackq/indagate/pkg/store"
+	"github.com/ustackq/indagate/pkg/store/bolt"
+)
+
+func TestBackupRestore(t *testing.T) {
+	ctx := context.Background()
+	dir := t.TempDir()
+
+	client := bolt.NewClient()
+	client.Path = filepath.Join(dir, "indagate.bolt")
+	if err := client.Open(ctx); err != nil {
+		t.Fatal(err)
+	}
+	defer client.Close()
+
+	kv := bolt.NewKVStore(client.Path)
+	kv.WithDB(client.DB())
+	err := kv.Modify(ctx, func(tx store.Impl) error {
+		b, err := tx.Bucket([]byte("testv1"))
+		if err != nil {
+			return err
+		}
+		for _, k := range []string{"a", "b", "c"} {
+			if err := b.Put([]byte(k), []byte(k)); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	snapshot := filepath.Join(dir, "backup.bolt")
+	f, err := os.Create(snapshot)
+	if err != nil {
+		t.Fatal(err)
+	}
+	m, err := client.Backup(ctx, f)
+	f.Close()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if len(m.Buckets) != 1 || m.Buckets[0].Name != "testv1" || m.Buckets[0].Keys != 3 {
+		t.Fatalf("manifest buckets = %+v, want testv1 with 3 keys", m.Buckets)
+	}
+	if err := bolt.VerifyBackup(snapshot, m); err != nil {
+		t.Fatalf("VerifyBackup() = %v", err)
+	}
+
+	// the database is in use, restoring over it must fail.
+	if err := bolt.Restore(snapshot, m, client.Path); err == nil {
+		t.Error("Restore() over an open database succeeded")
+	}
+
+	target := filepath.Join(dir, "restored", "indagate.bolt")
+	if err := bolt.Restore(snapshot, m, target); err != nil {
+		t.Fatalf("Restore() = %v", err)
+	}
+
+	m.Buckets[0].Keys = 4
+	if err := bolt.VerifyBackup(target, m); err == nil {
+		t.Error("VerifyBackup() with a wrong key count succeeded")
+	}
+	if err := bolt.Restore(snapshot, m, target); err == nil {
+		t.Error("Restore() with a wrong key count succeeded")
+	}
+}
@@ -36,10 +36,6 @@ func (kv *KVStore) Open(ctx context.Context) error {
 		return fmt.Errorf("unable to create directory %s: %v", kv.path, err)
 	}
 
-	if _, err := os.Stat(kv.path); err != nil {
-		return err
-	}
-
 	db, err := bolt.Open(kv.path, 0600, &bolt.Options{Timeout: 1 * time.Second})
 	if err != nil {
 		return fmt.Errorf("unable open boltdb file %s: %v", kv.path, err)
@@ -175,6 +171,10 @@ func (b *Bucket) Get(key []byte) ([]byte, error) {
 
 // Put sets the value at the provided key.
 func (b *Bucket) Put(key []byte, value []byte) error {
+	if len(key) > store.MaxKeySize {
+		return store.ErrKeyTooLarge
+	}
+
 	err := b.bucket.Put(key, value)
 	if err == bolt.ErrTxNotWritable {
 		return store.ErrTxNotWritable
@@ -199,6 +199,12 @@ func (b *Bucket) Cursor() (store.Cursor, error) {
 	}, nil
 }
 
+// KeyN returns the number of keys in the bucket, writes of the transaction
+// are only counted once committed.
+func (b *Bucket) KeyN() (int, error) {
+	return b.bucket.Stats().KeyN, nil
+}
+
 // Cursor is a struct for iterating through the entries
 // in the key value store.
 type Cursor struct {
@@ -0,0 +1,23 @@
+package bolt_test
+
+import (
+	"context"
+	"path/filepath"
+	"testing"
+
+	"github.com/ustackq/indagate/pkg/store"
+	"github.com/ustackq/indagate/pkg/store/bolt"
+	"github.com/ustackq/indagate/pkg/store/storetest"
+)
+
+func initKVStore(t *testing.T) (store.Store, func()) {
+	s := bolt.NewKVStore(filepath.Join(t.TempDir(), "indagate.bolt"))
+	if err := s.Open(context.Background()); err != nil {
+		t.Fatalf("failed to open bolt store: %v", err)
+	}
+	return s, func() { s.Close() }
+}
+
+func TestKVStore(t *testing.T) {
+	storetest.KVStore(initKVStore, t)
+}
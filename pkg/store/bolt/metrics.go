@@ -22,7 +22,10 @@ func (c *Client) Describe(ch chan<- *prometheus.Desc) {
 func (c *Client) Collect(ch chan<- prometheus.Metric) {
 	orgs := 0
 	c.db.View(func(tx *bolt.Tx) error {
-		orgs = tx.Bucket(store.OrgBucket).Stats().KeyN
+		// the bucket is only created once the store is initialized.
+		if b := tx.Bucket(store.OrgBucket); b != nil {
+			orgs = b.Stats().KeyN
+		}
 		return nil
 	})
 
@@ -0,0 +1,775 @@
+package store
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"sort"
+	"time"
+
+	"github.com/ustackq/indagate/pkg/service"
+	"github.com/ustackq/indagate/pkg/utils/errors"
+)
+
+// DefaultCommentEditWindow is the edit window of comments when none is configured.
+const DefaultCommentEditWindow = 15 * time.Minute
+
+var (
+	commentBucket    = []byte("commentsv1")
+	commentItemIndex = []byte("commentitemindexv1")
+)
+
+// assert Service implement service.CommentService
+var _ service.CommentService = (*Service)(nil)
+
+// commentable adapts an item type to the comment service.
+type commentable struct {
+	// org returns the organization of the item.
+	org func(s *Service, ctx context.Context, tx Impl, id service.ID) (service.ID, error)
+	// count sets the comment count of the item.
+	count func(s *Service, ctx context.Context, tx Impl, id service.ID, n int) error
+	// author returns the user notified of comments on the item.
+	author func(s *Service, ctx context.Context, tx Impl, id service.ID) (service.ID, error)
+}
+
+// commentables holds the item types which can be commented on.
+var commentables = map[service.CommentType]commentable{
+	service.AnswerComment: {
+		org:    (*Service).answerOrg,
+		count:  (*Service).countAnswerComments,
+		author: (*Service).answerAuthor,
+	},
+	service.ArticleComment: {
+		org:    (*Service).publishedArticleOrg,
+		count:  (*Service).countArticleComments,
+		author: (*Service).articleAuthor,
+	},
+}
+
+func commentableOf(t service.CommentType) (commentable, error) {
+	if err := t.Valid(); err != nil {
+		return commentable{}, err
+	}
+
+	c, ok := commentables[t]
+	if !ok {
+		return commentable{}, &errors.Error{
+			Code: errors.Invalid,
+			Msg:  fmt.Sprintf("commenting on %s is not supported", t),
+		}
+	}
+	return c, nil
+}
+
+func (s *Service) initializeComments(ctx context.Context, tx Impl) error {
+	if _, err := s.commentBucket(tx); err != nil {
+		return err
+	}
+	if _, err := s.commentItemIndexBucket(tx); err != nil {
+		return err
+	}
+	return nil
+}
+
+func (s *Service) commentBucket(tx Impl) (Bucket, error) {
+	b, err := tx.Bucket(commentBucket)
+	if err != nil {
+		return nil, UnexpectedCommentError(err)
+	}
+	return b, nil
+}
+
+func (s *Service) commentItemIndexBucket(tx Impl) (Bucket, error) {
+	b, err := tx.Bucket(commentItemIndex)
+	if err != nil {
+		return nil, UnexpectedCommentIndexError(err)
+	}
+	return b, nil
+}
+
+// UnexpectedCommentError wraps errors raised while retrieving the comment bucket.
+func UnexpectedCommentError(err error) *errors.Error {
+	return &errors.Error{
+		Code: errors.Internal,
+		Msg:  fmt.Sprintf("unexpected error retrieving comment bucket; %v", err),
+		Op:   "commentBucket",
+	}
+}
+
+// UnexpectedCommentIndexError wraps errors raised while retrieving the comment index.
+func UnexpectedCommentIndexError(err error) *errors.Error {
+	return &errors.Error{
+		Code: errors.Internal,
+		Msg:  fmt.Sprintf("unexpected error retrieving comment index; %v", err),
+		Op:   "commentIndex",
+	}
+}
+
+// commentItemPrefix is the comment type followed by the encoded item id,
+// it is shared by the index keys of all comments of the item.
+func commentItemPrefix(t service.CommentType, itemID service.ID) ([]byte, error) {
+	iid, err := itemID.Encode()
+	if err != nil {
+		return nil, &errors.Error{
+			Code: errors.Invalid,
+			Err:  err,
+		}
+	}
+
+	prefix := make([]byte, 0, len(t)+1+len(iid))
+	prefix = append(prefix, t...)
+	prefix = append(prefix, '/')
+	return append(prefix, iid...), nil
+}
+
+// commentItemIndexKey is the item prefix followed by the encoded comment id.
+func commentItemIndexKey(c *service.Comment) ([]byte, error) {
+	prefix, err := commentItemPrefix(c.Type, c.ItemID)
+	if err != nil {
+		return nil, err
+	}
+
+	cid, err := c.ID.Encode()
+	if err != nil {
+		return nil, &errors.Error{
+			Code: errors.Invalid,
+			Err:  err,
+		}
+	}
+	return append(prefix, cid...), nil
+}
+
+// commentEditWindow returns the configured edit window of comments.
+func (s *Service) commentEditWindow() time.Duration {
+	if s.Config.CommentEditWindow > 0 {
+		return s.Config.CommentEditWindow
+	}
+	return DefaultCommentEditWindow
+}
+
+// FindCommentByID returns a single comment by ID.
+func (s *Service) FindCommentByID(ctx context.Context, id service.ID) (*service.Comment, error) {
+	var c *service.Comment
+	err := s.store.View(ctx, func(tx Impl) error {
+		comment, err := s.findCommentByID(ctx, tx, id)
+		if err != nil {
+			return err
+		}
+		c = comment
+		return nil
+	})
+	if err != nil {
+		return nil, &errors.Error{
+			Err: err,
+			Op:  service.OpFindCommentByID,
+		}
+	}
+	return c, nil
+}
+
+func (s *Service) findCommentByID(ctx context.Context, tx Impl, id service.ID) (*service.Comment, error) {
+	encodedID, err := id.Encode()
+	if err != nil {
+		return nil, &errors.Error{
+			Code: errors.Invalid,
+			Err:  err,
+		}
+	}
+
+	b, err := s.commentBucket(tx)
+	if err != nil {
+		return nil, err
+	}
+
+	v, err := b.Get(encodedID)
+	if IsNotFound(err) {
+		return nil, service.ErrCommentNotFound
+	}
+
+	if err != nil {
+		return nil, &errors.Error{
+			Code: errors.Internal,
+			Err:  err,
+		}
+	}
+	return unmarshalComment(v)
+}
+
+func unmarshalComment(v []byte) (*service.Comment, error) {
+	c := &service.Comment{}
+	if err := json.Unmarshal(v, c); err != nil {
+		return nil, &errors.Error{
+			Code: errors.Internal,
+			Msg:  "comment could not be unmarshalled",
+			Err:  err,
+			Op:   "unmarshalComment",
+		}
+	}
+	return c, nil
+}
+
+// FindComments returns a list of comments that match filter and the total count of matching comments.
+func (s *Service) FindComments(ctx context.Context, filter service.CommentFilter, opt ...service.FindOptions) ([]*service.Comment, int, error) {
+	if filter.ID != nil {
+		c, err := s.FindCommentByID(ctx, *filter.ID)
+		if err != nil {
+			return nil, 0, &errors.Error{
+				Err: err,
+				Op:  service.OpFindComments,
+			}
+		}
+		return []*service.Comment{c}, 1, nil
+	}
+
+	cs := []*service.Comment{}
+	err := s.store.View(ctx, func(tx Impl) error {
+		comments, err := s.findComments(ctx, tx, filter)
+		if err != nil {
+			return err
+		}
+		cs = comments
+		return nil
+	})
+	if err != nil {
+		return nil, 0, &errors.Error{
+			Err: err,
+			Op:  service.OpFindComments,
+		}
+	}
+
+	sortComments(cs, opt...)
+	start, end := pageWindow(len(cs), opt...)
+	return cs[start:end], len(cs), nil
+}
+
+func (s *Service) findComments(ctx context.Context, tx Impl, filter service.CommentFilter) ([]*service.Comment, error) {
+	// comments of an item are the top level comments unless replies are asked for.
+	replyTo := filter.ReplyTo
+	if replyTo == nil && filter.ItemID != nil {
+		top := service.ID(0)
+		replyTo = &top
+	}
+
+	cs := []*service.Comment{}
+	fn := func(c *service.Comment) bool {
+		if filter.Type != nil && c.Type != *filter.Type {
+			return true
+		}
+		if replyTo != nil && c.ReplyTo != *replyTo {
+			return true
+		}
+		if filter.UserID != nil && c.UserID != *filter.UserID {
+			return true
+		}
+		cs = append(cs, c)
+		return true
+	}
+
+	// comments of an item are looked up by the item index.
+	if filter.ItemID != nil {
+		if filter.Type == nil {
+			return nil, &errors.Error{
+				Code: errors.Invalid,
+				Msg:  "comment type required to find the comments of an item",
+			}
+		}
+
+		if err := s.forEachItemComment(ctx, tx, *filter.Type, *filter.ItemID, fn); err != nil {
+			return nil, err
+		}
+		return cs, nil
+	}
+
+	if err := s.forEachComment(ctx, tx, fn); err != nil {
+		return nil, err
+	}
+	return cs, nil
+}
+
+func sortComments(cs []*service.Comment, opts ...service.FindOptions) {
+	var opt service.FindOptions
+	if len(opts) > 0 {
+		opt = opts[0]
+	}
+
+	less := func(i, j int) bool {
+		return cs[i].CreatedAt.Before(cs[j].CreatedAt)
+	}
+	if opt.SortBy == service.CommentSortByVotes {
+		less = func(i, j int) bool {
+			return cs[i].AgreeCount-cs[i].AgainstCount < cs[j].AgreeCount-cs[j].AgainstCount
+		}
+	}
+
+	if opt.Descending {
+		sort.SliceStable(cs, func(i, j int) bool { return less(j, i) })
+		return
+	}
+	sort.SliceStable(cs, less)
+}
+
+func (s *Service) forEachComment(ctx context.Context, tx Impl, fn func(*service.Comment) bool) error {
+	b, err := s.commentBucket(tx)
+	if err != nil {
+		return err
+	}
+
+	cur, err := b.Cursor()
+	if err != nil {
+		return err
+	}
+
+	for k, v := cur.First(); k != nil; k, v = cur.Next() {
+		c, err := unmarshalComment(v)
+		if err != nil {
+			return err
+		}
+		if !fn(c) {
+			break
+		}
+	}
+	return nil
+}
+
+func (s *Service) forEachItemComment(ctx context.Context, tx Impl, t service.CommentType, itemID service.ID, fn func(*service.Comment) bool) error {
+	prefix, err := commentItemPrefix(t, itemID)
+	if err != nil {
+		return err
+	}
+
+	idx, err := s.commentItemIndexBucket(tx)
+	if err != nil {
+		return err
+	}
+
+	cur, err := idx.Cursor()
+	if err != nil {
+		return err
+	}
+
+	for k, v := cur.Seek(prefix); k != nil && bytes.HasPrefix(k, prefix); k, v = cur.Next() {
+		var id service.ID
+		if err := id.Decode(v); err != nil {
+			return &errors.Error{
+				Code: errors.Invalid,
+				Err:  err,
+			}
+		}
+
+		c, err := s.findCommentByID(ctx, tx, id)
+		if err != nil {
+			return err
+		}
+		if !fn(c) {
+			break
+		}
+	}
+	return nil
+}
+
+// CreateComment creates a new comment and sets c.ID with the new identifier.
+// The comment counts of the item and of the comment replied to are updated in the same transaction.
+func (s *Service) CreateComment(ctx context.Context, c *service.Comment) error {
+	return s.store.Modify(ctx, func(tx Impl) error {
+		if err := s.createComment(ctx, tx, c); err != nil {
+			return &errors.Error{
+				Err: err,
+				Op:  service.OpCreateComment,
+			}
+		}
+		return nil
+	})
+}
+
+func (s *Service) createComment(ctx context.Context, tx Impl, c *service.Comment) error {
+	if err := c.Valid(); err != nil {
+		return err
+	}
+
+	ct, err := commentableOf(c.Type)
+	if err != nil {
+		return err
+	}
+
+	orgID, err := ct.org(s, ctx, tx, c.ItemID)
+	if err != nil {
+		return err
+	}
+
+	var parent *service.Comment
+	if c.ReplyTo.Valid() {
+		parent, err = s.findCommentByID(ctx, tx, c.ReplyTo)
+		if err != nil {
+			return err
+		}
+
+		if parent.Type != c.Type || parent.ItemID != c.ItemID {
+			return &errors.Error{
+				Code: errors.Invalid,
+				Msg:  fmt.Sprintf("comment %s does not belong to %s %s", parent.ID, c.Type, c.ItemID),
+			}
+		}
+
+		if parent.Deleted {
+			return service.ErrCommentDeleted
+		}
+	}
+
+	c.ID = s.IDGenerator.ID()
+	c.OrgID = orgID
+	c.ReplyCount = 0
+	c.AgreeCount = 0
+	c.AgainstCount = 0
+	c.Deleted = false
+	c.CreatedAt = s.time()
+	c.UpdatedAt = c.CreatedAt
+
+	r, err := s.filterContent(ctx, commentContent(c))
+	if err != nil {
+		return err
+	}
+	c.Pending = r.Verdict == service.HoldContent
+
+	if err := s.putComment(ctx, tx, c); err != nil {
+		return err
+	}
+
+	if err := s.indexComment(ctx, tx, c); err != nil {
+		return err
+	}
+
+	if c.Pending {
+		return s.submitApproval(ctx, tx, &service.Approval{
+			Kind:     service.CreateApproval,
+			ItemType: service.CommentItemType,
+			ItemID:   c.ID,
+			OrgID:    c.OrgID,
+			UserID:   c.UserID,
+			Reasons:  []service.ApprovalReason{service.FilterApprovalReason},
+		})
+	}
+	return s.publishComment(ctx, tx, ct, c, parent)
+}
+
+// publishComment counts the comment on its item and on the comment replied to,
+// and notifies the users concerned.
+func (s *Service) publishComment(ctx context.Context, tx Impl, ct commentable, c, parent *service.Comment) error {
+	if parent != nil {
+		if err := s.refreshCommentReplies(ctx, tx, parent); err != nil {
+			return err
+		}
+	}
+
+	if err := s.refreshItemComments(ctx, tx, ct, c.Type, c.ItemID); err != nil {
+		return err
+	}
+	return s.notifyComment(ctx, tx, ct, c, parent)
+}
+
+// notifyComment notifies the author of the comment replied to, or else the
+// author of the item, and the user mentioned by the comment.
+func (s *Service) notifyComment(ctx context.Context, tx Impl, ct commentable, c, parent *service.Comment) error {
+	var recipient service.ID
+	if parent != nil {
+		recipient = parent.UserID
+	} else {
+		author, err := ct.author(s, ctx, tx, c.ItemID)
+		if err != nil {
+			return err
+		}
+		recipient = author
+	}
+
+	err := s.notify(ctx, tx, &service.Notification{
+		RecipientID: recipient,
+		SenderID:    c.UserID,
+		Action:      service.CommentNotification,
+		ItemType:    service.CommentItemType,
+		ItemID:      c.ID,
+	})
+	if err != nil {
+		return err
+	}
+
+	if !c.AtUID.Valid() || c.AtUID == recipient {
+		return nil
+	}
+	return s.notify(ctx, tx, &service.Notification{
+		RecipientID: c.AtUID,
+		SenderID:    c.UserID,
+		Action:      service.MentionNotification,
+		ItemType:    service.CommentItemType,
+		ItemID:      c.ID,
+	})
+}
+
+func (s *Service) putComment(ctx context.Context, tx Impl, c *service.Comment) error {
+	v, err := json.Marshal(c)
+	if err != nil {
+		return &errors.Error{
+			Code: errors.Invalid,
+			Err:  err,
+		}
+	}
+
+	encodedID, err := c.ID.Encode()
+	if err != nil {
+		return &errors.Error{
+			Code: errors.Invalid,
+			Err:  err,
+		}
+	}
+
+	b, err := s.commentBucket(tx)
+	if err != nil {
+		return err
+	}
+
+	if err := b.Put(encodedID, v); err != nil {
+		return errors.InternalErr(err)
+	}
+	return nil
+}
+
+func (s *Service) indexComment(ctx context.Context, tx Impl, c *service.Comment) error {
+	key, err := commentItemIndexKey(c)
+	if err != nil {
+		return err
+	}
+
+	encodedID, err := c.ID.Encode()
+	if err != nil {
+		return &errors.Error{
+			Code: errors.Invalid,
+			Err:  err,
+		}
+	}
+
+	idx, err := s.commentItemIndexBucket(tx)
+	if err != nil {
+		return err
+	}
+
+	if err := idx.Put(key, encodedID); err != nil {
+		return errors.InternalErr(err)
+	}
+	return nil
+}
+
+// refreshItemComments recounts the comments of the item which are neither deleted
+// nor pending and stores the comment count on the item.
+func (s *Service) refreshItemComments(ctx context.Context, tx Impl, ct commentable, t service.CommentType, itemID service.ID) error {
+	count := 0
+	err := s.forEachItemComment(ctx, tx, t, itemID, func(c *service.Comment) bool {
+		if !c.Deleted && !c.Pending {
+			count++
+		}
+		return true
+	})
+	if err != nil {
+		return err
+	}
+	return ct.count(s, ctx, tx, itemID, count)
+}
+
+// refreshCommentReplies recounts the replies to the comment which are neither deleted nor pending.
+func (s *Service) refreshCommentReplies(ctx context.Context, tx Impl, parent *service.Comment) error {
+	count := 0
+	err := s.forEachItemComment(ctx, tx, parent.Type, parent.ItemID, func(c *service.Comment) bool {
+		if c.ReplyTo == parent.ID && !c.Deleted && !c.Pending {
+			count++
+		}
+		return true
+	})
+	if err != nil {
+		return err
+	}
+
+	parent.ReplyCount = count
+	return s.putComment(ctx, tx, parent)
+}
+
+// UpdateComment updates a single comment with changeset within its edit window.
+func (s *Service) UpdateComment(ctx context.Context, id service.ID, upd service.CommentUpdate) (*service.Comment, error) {
+	var c *service.Comment
+	err := s.store.Modify(ctx, func(tx Impl) error {
+		comment, err := s.updateComment(ctx, tx, id, upd)
+		if err != nil {
+			return &errors.Error{
+				Err: err,
+				Op:  service.OpUpdateComment,
+			}
+		}
+		c = comment
+		return nil
+	})
+	return c, err
+}
+
+func (s *Service) updateComment(ctx context.Context, tx Impl, id service.ID, upd service.CommentUpdate) (*service.Comment, error) {
+	if err := upd.Valid(); err != nil {
+		return nil, err
+	}
+
+	c, err := s.findCommentByID(ctx, tx, id)
+	if err != nil {
+		return nil, err
+	}
+
+	if c.Deleted {
+		return nil, service.ErrCommentDeleted
+	}
+
+	now := s.time()
+	if now.Sub(c.CreatedAt) > s.commentEditWindow() {
+		return nil, service.ErrCommentEditWindowExpired
+	}
+
+	if upd.Content != nil {
+		c.Content = *upd.Content
+		if err := s.filterUnreviewedContent(ctx, commentContent(c)); err != nil {
+			return nil, err
+		}
+	}
+	c.UpdatedAt = now
+
+	if err := s.putComment(ctx, tx, c); err != nil {
+		return nil, err
+	}
+	return c, nil
+}
+
+// DeleteComment marks a comment as deleted and clears its content, replies are kept
+// in place. The comment counts are updated in the same transaction.
+func (s *Service) DeleteComment(ctx context.Context, id service.ID) error {
+	return s.store.Modify(ctx, func(tx Impl) error {
+		if err := s.deleteComment(ctx, tx, id); err != nil {
+			return &errors.Error{
+				Err: err,
+				Op:  service.OpDeleteComment,
+			}
+		}
+		return nil
+	})
+}
+
+func (s *Service) deleteComment(ctx context.Context, tx Impl, id service.ID) error {
+	c, err := s.findCommentByID(ctx, tx, id)
+	if err != nil {
+		return err
+	}
+
+	if c.Deleted {
+		return service.ErrCommentDeleted
+	}
+
+	ct, err := commentableOf(c.Type)
+	if err != nil {
+		return err
+	}
+
+	c.Deleted = true
+	c.Content = ""
+	c.AtUID = 0
+	c.UpdatedAt = s.time()
+	if err := s.putComment(ctx, tx, c); err != nil {
+		return err
+	}
+
+	if err := s.deleteItemApproval(ctx, tx, service.CommentItemType, c.ID); err != nil {
+		return err
+	}
+
+	if c.ReplyTo.Valid() {
+		parent, err := s.findCommentByID(ctx, tx, c.ReplyTo)
+		if err != nil {
+			return err
+		}
+		if err := s.refreshCommentReplies(ctx, tx, parent); err != nil {
+			return err
+		}
+	}
+	return s.refreshItemComments(ctx, tx, ct, c.Type, c.ItemID)
+}
+
+// deleteItemComments removes every comment of an item which is removed itself.
+func (s *Service) deleteItemComments(ctx context.Context, tx Impl, t service.CommentType, itemID service.ID) error {
+	cs := []*service.Comment{}
+	err := s.forEachItemComment(ctx, tx, t, itemID, func(c *service.Comment) bool {
+		cs = append(cs, c)
+		return true
+	})
+	if err != nil {
+		return err
+	}
+
+	b, err := s.commentBucket(tx)
+	if err != nil {
+		return err
+	}
+
+	idx, err := s.commentItemIndexBucket(tx)
+	if err != nil {
+		return err
+	}
+
+	for _, c := range cs {
+		key, err := commentItemIndexKey(c)
+		if err != nil {
+			return err
+		}
+
+		if err := idx.Delete(key); err != nil {
+			return errors.WrapperErr(err)
+		}
+
+		encodedID, err := c.ID.Encode()
+		if err != nil {
+			return &errors.Error{
+				Code: errors.Invalid,
+				Err:  err,
+			}
+		}
+
+		if err := b.Delete(encodedID); err != nil {
+			return errors.WrapperErr(err)
+		}
+
+		if err := s.deleteItemVotes(ctx, tx, service.CommentItemType, c.ID, c.UserID); err != nil {
+			return err
+		}
+
+		if c.Pending {
+			if err := s.deleteItemApproval(ctx, tx, service.CommentItemType, c.ID); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+func (s *Service) answerOrg(ctx context.Context, tx Impl, id service.ID) (service.ID, error) {
+	a, err := s.findAnswerByID(ctx, tx, id)
+	if err != nil {
+		return 0, err
+	}
+	return a.OrgID, nil
+}
+
+func (s *Service) answerAuthor(ctx context.Context, tx Impl, id service.ID) (service.ID, error) {
+	a, err := s.findAnswerByID(ctx, tx, id)
+	if err != nil {
+		return 0, err
+	}
+	return a.UserID, nil
+}
+
+func (s *Service) countAnswerComments(ctx context.Context, tx Impl, id service.ID, n int) error {
+	a, err := s.findAnswerByID(ctx, tx, id)
+	if err != nil {
+		return err
+	}
+
+	a.CommentCount = n
+	return s.putAnswer(ctx, tx, a)
+}
@@ -0,0 +1,138 @@
+package store_test
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/ustackq/indagate/pkg/service"
+	"github.com/ustackq/indagate/pkg/store"
+	"github.com/ustackq/indagate/pkg/store/inmem"
+	"github.com/ustackq/indagate/pkg/utils/errors"
+)
+
+func newCommentedAnswer(t *testing.T, s *store.Service) *service.Answer {
+	t.Helper()
+	ctx := context.Background()
+
+	q := &service.Question{OrgID: service.ID(1), UserID: service.ID(2), Content: "why?"}
+	if err := s.CreateQuestion(ctx, q); err != nil {
+		t.Fatal(err)
+	}
+	a := &service.Answer{QuestionID: q.ID, UserID: service.ID(3), Content: "because"}
+	if err := s.CreateAnswer(ctx, a); err != nil {
+		t.Fatal(err)
+	}
+	return a
+}
+
+func TestComments(t *testing.T) {
+	ctx := context.Background()
+	s := newInmemService(t)
+	a := newCommentedAnswer(t, s)
+
+	comment := func(content string, replyTo service.ID) *service.Comment {
+		t.Helper()
+		c := &service.Comment{
+			Type:    service.AnswerComment,
+			ItemID:  a.ID,
+			UserID:  service.ID(4),
+			ReplyTo: replyTo,
+			Content: content,
+		}
+		if err := s.CreateComment(ctx, c); err != nil {
+			t.Fatal(err)
+		}
+		return c
+	}
+
+	top := comment("first", 0)
+	reply := comment("reply", top.ID)
+	comment("second", 0)
+
+	commentCount := func() int {
+		t.Helper()
+		got, err := s.FindAnswerByID(ctx, a.ID)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return got.CommentCount
+	}
+
+	if n := commentCount(); n != 3 {
+		t.Errorf("answer comment count = %d, want 3", n)
+	}
+
+	typ := service.AnswerComment
+	cs, total, err := s.FindComments(ctx, service.CommentFilter{Type: &typ, ItemID: &a.ID}, service.FindOptions{Limit: 1})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if total != 2 || len(cs) != 1 || cs[0].ID != top.ID {
+		t.Fatalf("top level comments = %d of %d, want first of 2", len(cs), total)
+	}
+	if cs[0].ReplyCount != 1 {
+		t.Errorf("top level reply count = %d, want 1", cs[0].ReplyCount)
+	}
+
+	if err := s.DeleteComment(ctx, top.ID); err != nil {
+		t.Fatal(err)
+	}
+	if n := commentCount(); n != 2 {
+		t.Errorf("answer comment count after delete = %d, want 2", n)
+	}
+
+	// the deleted comment keeps its place in the thread.
+	replies, _, err := s.FindComments(ctx, service.CommentFilter{Type: &typ, ItemID: &a.ID, ReplyTo: &top.ID})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(replies) != 1 || replies[0].ID != reply.ID {
+		t.Errorf("replies after delete = %+v, want reply %s", replies, reply.ID)
+	}
+
+	deleted, err := s.FindCommentByID(ctx, top.ID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !deleted.Deleted || deleted.Content != "" {
+		t.Errorf("deleted comment = %+v, want deleted without content", deleted)
+	}
+
+	content := "edited"
+	if _, err := s.UpdateComment(ctx, top.ID, service.CommentUpdate{Content: &content}); errors.ErrorCode(err) != errors.Conflict {
+		t.Errorf("UpdateComment of deleted comment error = %v, want %s", err, errors.Conflict)
+	}
+
+	c := &service.Comment{Type: service.AnswerComment, ItemID: a.ID, UserID: service.ID(4), ReplyTo: top.ID, Content: "late"}
+	if err := s.CreateComment(ctx, c); errors.ErrorCode(err) != errors.Conflict {
+		t.Errorf("reply to deleted comment error = %v, want %s", err, errors.Conflict)
+	}
+
+	if err := s.DeleteAnswer(ctx, a.ID); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := s.FindCommentByID(ctx, reply.ID); errors.ErrorCode(err) != errors.NotFound {
+		t.Errorf("comment of deleted answer error = %v, want %s", err, errors.NotFound)
+	}
+}
+
+func TestUpdateComment_EditWindow(t *testing.T) {
+	ctx := context.Background()
+	s := store.NewService(inmem.NewKVStore(), store.ServiceConfig{CommentEditWindow: time.Nanosecond})
+	if err := s.Init(ctx); err != nil {
+		t.Fatal(err)
+	}
+	a := newCommentedAnswer(t, s)
+
+	c := &service.Comment{Type: service.AnswerComment, ItemID: a.ID, UserID: service.ID(4), Content: "typo"}
+	if err := s.CreateComment(ctx, c); err != nil {
+		t.Fatal(err)
+	}
+
+	time.Sleep(time.Millisecond)
+	content := "fixed"
+	if _, err := s.UpdateComment(ctx, c.ID, service.CommentUpdate{Content: &content}); errors.ErrorCode(err) != errors.Forbidden {
+		t.Errorf("UpdateComment after edit window error = %v, want %s", err, errors.Forbidden)
+	}
+}
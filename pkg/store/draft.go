@@ -0,0 +1,351 @@
+package store
+
+import (
+	"context"
+	"fmt"
+	"sort"
+
+	"github.com/ustackq/indagate/pkg/service"
+	"github.com/ustackq/indagate/pkg/utils/errors"
+)
+
+var (
+	draftBucket = []byte("draftsv1")
+)
+
+// assert Service implement service.DraftService
+var _ service.DraftService = (*Service)(nil)
+
+// draftables holds the kinds of content which can be drafted, the function
+// returns an error if the item can not receive the drafted content.
+var draftables = map[service.DraftType]func(s *Service, ctx context.Context, tx Impl, id service.ID) error{
+	service.QuestionDraft:       (*Service).checkQuestionDraft,
+	service.AnswerDraft:         (*Service).checkAnswerDraft,
+	service.ArticleContentDraft: (*Service).checkArticleDraft,
+}
+
+func (s *Service) initializeDrafts(ctx context.Context, tx Impl) error {
+	if _, err := s.draftBucket(tx); err != nil {
+		return err
+	}
+	return nil
+}
+
+func (s *Service) draftBucket(tx Impl) (Bucket, error) {
+	b, err := tx.Bucket(draftBucket)
+	if err != nil {
+		return nil, UnexpectedDraftError(err)
+	}
+	return b, nil
+}
+
+// UnexpectedDraftError wraps errors raised while retrieving the draft bucket.
+func UnexpectedDraftError(err error) *errors.Error {
+	return &errors.Error{
+		Code: errors.Internal,
+		Msg:  fmt.Sprintf("unexpected error retrieving draft bucket; %v", err),
+		Op:   "draftBucket",
+	}
+}
+
+// draftKey is the encoded user id, the draft type and the encoded item id,
+// so that the drafts of a user share a prefix.
+func draftKey(userID service.ID, t service.DraftType, itemID service.ID) ([]byte, error) {
+	uid, err := userID.Encode()
+	if err != nil {
+		return nil, &errors.Error{
+			Code: errors.Invalid,
+			Err:  err,
+		}
+	}
+
+	iid, err := itemID.Encode()
+	if err != nil {
+		return nil, &errors.Error{
+			Code: errors.Invalid,
+			Err:  err,
+		}
+	}
+
+	key := make([]byte, 0, len(uid)+len(t)+1+len(iid))
+	key = append(key, uid...)
+	key = append(key, t...)
+	key = append(key, '/')
+	return append(key, iid...), nil
+}
+
+func unmarshalDraft(v []byte) (*service.Draft, error) {
+	d := &service.Draft{}
+	if err := json.Unmarshal(v, d); err != nil {
+		return nil, &errors.Error{
+			Code: errors.Internal,
+			Msg:  "draft could not be unmarshalled",
+			Err:  err,
+			Op:   "unmarshalDraft",
+		}
+	}
+	return d, nil
+}
+
+// FindDraft returns the draft of the user on an item.
+func (s *Service) FindDraft(ctx context.Context, userID service.ID, t service.DraftType, itemID service.ID) (*service.Draft, error) {
+	var d *service.Draft
+	err := s.store.View(ctx, func(tx Impl) error {
+		draft, err := s.findDraft(ctx, tx, userID, t, itemID)
+		if err != nil {
+			return err
+		}
+		d = draft
+		return nil
+	})
+	if err != nil {
+		return nil, &errors.Error{
+			Err: err,
+			Op:  service.OpFindDraft,
+		}
+	}
+	return d, nil
+}
+
+func (s *Service) findDraft(ctx context.Context, tx Impl, userID service.ID, t service.DraftType, itemID service.ID) (*service.Draft, error) {
+	key, err := draftKey(userID, t, itemID)
+	if err != nil {
+		return nil, err
+	}
+
+	b, err := s.draftBucket(tx)
+	if err != nil {
+		return nil, err
+	}
+
+	v, err := b.Get(key)
+	if IsNotFound(err) {
+		return nil, service.ErrDraftNotFound
+	}
+
+	if err != nil {
+		return nil, &errors.Error{
+			Code: errors.Internal,
+			Err:  err,
+		}
+	}
+	return unmarshalDraft(v)
+}
+
+// FindDrafts returns the drafts of a user most recently updated first.
+func (s *Service) FindDrafts(ctx context.Context, filter service.DraftFilter, opt ...service.FindOptions) ([]*service.Draft, int, error) {
+	ds := []*service.Draft{}
+	err := s.store.View(ctx, func(tx Impl) error {
+		prefix, err := filter.UserID.Encode()
+		if err != nil {
+			return &errors.Error{
+				Code: errors.Invalid,
+				Err:  err,
+			}
+		}
+
+		b, err := s.draftBucket(tx)
+		if err != nil {
+			return err
+		}
+
+		return forEachPrefix(b, prefix, func(k, v []byte) error {
+			d, err := unmarshalDraft(v)
+			if err != nil {
+				return err
+			}
+			if filter.Type == nil || d.Type == *filter.Type {
+				ds = append(ds, d)
+			}
+			return nil
+		})
+	})
+	if err != nil {
+		return nil, 0, &errors.Error{
+			Err: err,
+			Op:  service.OpFindDrafts,
+		}
+	}
+
+	sort.SliceStable(ds, func(i, j int) bool {
+		return ds[i].UpdatedAt.After(ds[j].UpdatedAt)
+	})
+	start, end := pageWindow(len(ds), opt...)
+	return ds[start:end], len(ds), nil
+}
+
+// PutDraft saves the draft, the last write wins and increments the revision.
+func (s *Service) PutDraft(ctx context.Context, d *service.Draft) (bool, error) {
+	var overwritten bool
+	err := s.store.Modify(ctx, func(tx Impl) error {
+		o, err := s.putDraft(ctx, tx, d)
+		if err != nil {
+			return err
+		}
+		overwritten = o
+		return nil
+	})
+	if err != nil {
+		return false, &errors.Error{
+			Err: err,
+			Op:  service.OpPutDraft,
+		}
+	}
+	return overwritten, nil
+}
+
+func (s *Service) putDraft(ctx context.Context, tx Impl, d *service.Draft) (bool, error) {
+	if err := d.Valid(); err != nil {
+		return false, err
+	}
+
+	if err := draftables[d.Type](s, ctx, tx, d.ItemID); err != nil {
+		return false, err
+	}
+
+	prev, err := s.findDraft(ctx, tx, d.UserID, d.Type, d.ItemID)
+	if err != nil && err != service.ErrDraftNotFound {
+		return false, err
+	}
+
+	now := s.time()
+	overwritten := false
+	d.CreatedAt = now
+	if prev != nil {
+		overwritten = d.Revision != prev.Revision
+		d.Revision = prev.Revision
+		d.CreatedAt = prev.CreatedAt
+	} else {
+		d.Revision = 0
+	}
+	d.Revision++
+	d.UpdatedAt = now
+
+	if err := s.storeDraft(ctx, tx, d); err != nil {
+		return false, err
+	}
+	return overwritten, nil
+}
+
+func (s *Service) storeDraft(ctx context.Context, tx Impl, d *service.Draft) error {
+	v, err := json.Marshal(d)
+	if err != nil {
+		return &errors.Error{
+			Code: errors.Invalid,
+			Err:  err,
+		}
+	}
+
+	key, err := draftKey(d.UserID, d.Type, d.ItemID)
+	if err != nil {
+		return err
+	}
+
+	b, err := s.draftBucket(tx)
+	if err != nil {
+		return err
+	}
+
+	if err := b.Put(key, v); err != nil {
+		return errors.InternalErr(err)
+	}
+	return nil
+}
+
+// DeleteDraft removes the draft of the user on an item.
+func (s *Service) DeleteDraft(ctx context.Context, userID service.ID, t service.DraftType, itemID service.ID) error {
+	return s.store.Modify(ctx, func(tx Impl) error {
+		if _, err := s.findDraft(ctx, tx, userID, t, itemID); err != nil {
+			return &errors.Error{
+				Err: err,
+				Op:  service.OpDeleteDraft,
+			}
+		}
+
+		if err := s.deleteDraft(ctx, tx, userID, t, itemID); err != nil {
+			return &errors.Error{
+				Err: err,
+				Op:  service.OpDeleteDraft,
+			}
+		}
+		return nil
+	})
+}
+
+// deleteDraft removes the draft if there is one.
+func (s *Service) deleteDraft(ctx context.Context, tx Impl, userID service.ID, t service.DraftType, itemID service.ID) error {
+	key, err := draftKey(userID, t, itemID)
+	if err != nil {
+		return err
+	}
+
+	b, err := s.draftBucket(tx)
+	if err != nil {
+		return err
+	}
+
+	if err := b.Delete(key); err != nil {
+		return errors.WrapperErr(err)
+	}
+	return nil
+}
+
+// deleteItemDrafts removes the drafts of all users on the item.
+func (s *Service) deleteItemDrafts(ctx context.Context, tx Impl, t service.DraftType, itemID service.ID) error {
+	b, err := s.draftBucket(tx)
+	if err != nil {
+		return err
+	}
+
+	cur, err := b.Cursor()
+	if err != nil {
+		return err
+	}
+
+	ds := []*service.Draft{}
+	for k, v := cur.First(); k != nil; k, v = cur.Next() {
+		d, err := unmarshalDraft(v)
+		if err != nil {
+			return err
+		}
+		if d.Type == t && d.ItemID == itemID {
+			ds = append(ds, d)
+		}
+	}
+
+	for _, d := range ds {
+		if err := s.deleteDraft(ctx, tx, d.UserID, d.Type, d.ItemID); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (s *Service) checkQuestionDraft(ctx context.Context, tx Impl, orgID service.ID) error {
+	_, err := s.findOrgnizationByID(ctx, tx, orgID)
+	return err
+}
+
+func (s *Service) checkAnswerDraft(ctx context.Context, tx Impl, questionID service.ID) error {
+	q, err := s.findQuestionByID(ctx, tx, questionID)
+	if err != nil {
+		return err
+	}
+
+	if q.Lock {
+		return service.ErrQuestionLocked
+	}
+	return nil
+}
+
+func (s *Service) checkArticleDraft(ctx context.Context, tx Impl, articleID service.ID) error {
+	a, err := s.findArticleByID(ctx, tx, articleID)
+	if err != nil {
+		return err
+	}
+
+	if a.State == service.ArticleArchived {
+		return service.ErrArticleArchived
+	}
+	return nil
+}
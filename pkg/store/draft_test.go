@@ -0,0 +1,67 @@
+package store_test
+
+import (
+	"context"
+	"testing"
+
+	"github.com/ustackq/indagate/pkg/service"
+	"github.com/ustackq/indagate/pkg/utils/errors"
+)
+
+func TestPutDraft(t *testing.T) {
+	ctx := context.Background()
+	s := newInmemService(t)
+
+	q := &service.Question{OrgID: service.ID(1), UserID: service.ID(2), Content: "why?"}
+	if err := s.CreateQuestion(ctx, q); err != nil {
+		t.Fatal(err)
+	}
+
+	put := func(data string, revision int) (*service.Draft, bool) {
+		t.Helper()
+		d := &service.Draft{UserID: service.ID(3), Type: service.AnswerDraft, ItemID: q.ID, Data: data, Revision: revision}
+		overwritten, err := s.PutDraft(ctx, d)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return d, overwritten
+	}
+
+	first, overwritten := put("one", 0)
+	if first.Revision != 1 || overwritten {
+		t.Fatalf("first save = revision %d, overwritten %v, want 1, false", first.Revision, overwritten)
+	}
+
+	// two tabs both based on the first revision, the last write wins.
+	if d, overwritten := put("two", first.Revision); d.Revision != 2 || overwritten {
+		t.Errorf("second save = revision %d, overwritten %v, want 2, false", d.Revision, overwritten)
+	}
+	if d, overwritten := put("three", first.Revision); d.Revision != 3 || !overwritten {
+		t.Errorf("stale save = revision %d, overwritten %v, want 3, true", d.Revision, overwritten)
+	}
+
+	got, err := s.FindDraft(ctx, service.ID(3), service.AnswerDraft, q.ID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got.Data != "three" || got.Revision != 3 {
+		t.Errorf("draft = %q at revision %d, want %q at 3", got.Data, got.Revision, "three")
+	}
+
+	ds, total, err := s.FindDrafts(ctx, service.DraftFilter{UserID: service.ID(3)})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if total != 1 || len(ds) != 1 {
+		t.Errorf("drafts of user = %d of %d, want 1", len(ds), total)
+	}
+
+	// posting the answer discards the draft.
+	a := &service.Answer{QuestionID: q.ID, UserID: service.ID(3), Content: "because"}
+	if err := s.CreateAnswer(ctx, a); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := s.FindDraft(ctx, service.ID(3), service.AnswerDraft, q.ID); errors.ErrorCode(err) != errors.NotFound {
+		t.Errorf("draft after answer error = %v, want %s", err, errors.NotFound)
+	}
+}
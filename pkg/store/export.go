@@ -0,0 +1,1173 @@
+package store
+
+import (
+	"bufio"
+	"context"
+	"fmt"
+	"io"
+	"time"
+
+	jsoniter "github.com/json-iterator/go"
+
+	"github.com/ustackq/indagate/pkg/service"
+	"github.com/ustackq/indagate/pkg/utils/errors"
+	"github.com/ustackq/indagate/pkg/version"
+)
+
+// ArchiveFormat is the version of the layout of export archives, archives
+// of another format are refused by Import.
+const ArchiveFormat = 1
+
+const (
+	// archiveHeaderKind is the kind of the first record of an archive.
+	archiveHeaderKind = "header"
+	// maxArchiveRecordSize bounds the length of an archive line.
+	maxArchiveRecordSize = 16 * 1024 * 1024
+)
+
+// ArchiveHeader is the first record of an export archive.
+type ArchiveHeader struct {
+	Format    int       `json:"format"`
+	Version   string    `json:"version"`
+	Commit    string    `json:"commit"`
+	CreatedAt time.Time `json:"createdAt"`
+}
+
+// archiveRecord is a line of an archive, archives are newline delimited JSON.
+type archiveRecord struct {
+	Kind string              `json:"kind"`
+	Data jsoniter.RawMessage `json:"data"`
+}
+
+// ImportConflict is an archived entity which was skipped because it already exists.
+type ImportConflict struct {
+	Kind string `json:"kind"`
+	Key  string `json:"key"`
+	Msg  string `json:"msg"`
+}
+
+// ImportResult reports the number of entities imported per kind and the skipped ones.
+type ImportResult struct {
+	Imported  map[string]int    `json:"imported"`
+	Conflicts []*ImportConflict `json:"conflicts"`
+}
+
+// archiveEntity describes how the entities of a bucket are archived.
+type archiveEntity struct {
+	kind   string
+	bucket []byte
+	// record returns the archived data of a key value pair, it is set for buckets
+	// whose keys are not part of their values. By default the value is archived.
+	record func(k, v []byte) ([]byte, error)
+	// put stores an archived entity together with its indexes, it returns an
+	// importConflictError without writing anything if the entity already exists.
+	put func(s *Service, ctx context.Context, tx Impl, v []byte) error
+}
+
+// archiveEntities are ordered so that entities are imported after the ones they refer to.
+//
+// Feeds are not archived, they are a cache which FindFeed rebuilds from the follows
+// and activities of a reader. Passwords are kept by the bolt client and saved with
+// its backups rather than in this store.
+var archiveEntities = []archiveEntity{
+	{kind: "user", bucket: userBucket, put: (*Service).importUser},
+	{kind: "accountToken", bucket: accountTokenBucket, put: (*Service).importAccountToken},
+	{kind: "org", bucket: OrgBucket, put: (*Service).importOrg},
+	{kind: "userResourceMapping", bucket: urmBucket, put: (*Service).importURM},
+	{kind: "authorization", bucket: authBucket, put: (*Service).importAuthorization},
+	{kind: "session", bucket: sessionBucket, put: (*Service).importSession},
+	{kind: "question", bucket: questionBucket, put: (*Service).importQuestion},
+	{kind: "answer", bucket: answerBucket, put: (*Service).importAnswer},
+	{kind: "article", bucket: articleBucket, put: (*Service).importArticle},
+	{kind: "articleView", bucket: articleViewBucket, record: archiveArticleView, put: (*Service).importArticleView},
+	{kind: "comment", bucket: commentBucket, put: (*Service).importComment},
+	{kind: "topic", bucket: topicBucket, put: (*Service).importTopic},
+	{kind: "topicMerge", bucket: topicMergeBucket, put: (*Service).importTopicMerge},
+	{kind: "topicRelation", bucket: topicRelationBucket, put: (*Service).importTopicRelation},
+	{kind: "topicFocus", bucket: topicFocusBucket, put: (*Service).importTopicFocus},
+	{kind: "follow", bucket: followBucket, put: (*Service).importFollow},
+	{kind: "draft", bucket: draftBucket, put: (*Service).importDraft},
+	{kind: "vote", bucket: voteBucket, put: (*Service).importVote},
+	{kind: "activity", bucket: activityBucket, put: (*Service).importActivity},
+	{kind: "notification", bucket: notificationBucket, put: (*Service).importNotification},
+	{kind: "notificationSettings", bucket: notificationSettingsBucket, put: (*Service).importNotificationSettings},
+	{kind: "dialog", bucket: dialogBucket, put: (*Service).importDialog},
+	{kind: "message", bucket: messageBucket, put: (*Service).importMessage},
+	{kind: "messageBlock", bucket: messageBlockBucket, put: (*Service).importMessageBlock},
+	{kind: "moderationItem", bucket: moderationItemBucket, put: (*Service).importModerationItem},
+	{kind: "report", bucket: reportBucket, put: (*Service).importReport},
+	{kind: "approval", bucket: approvalBucket, put: (*Service).importApproval},
+	{kind: "spamSample", bucket: spamSampleBucket, put: (*Service).importSpamSample},
+	{kind: "ban", bucket: banBucket, put: (*Service).importBan},
+	{kind: "ipBlock", bucket: ipBlockBucket, put: (*Service).importIPBlock},
+	{kind: "banAudit", bucket: banAuditBucket, put: (*Service).importBanAudit},
+	{kind: "mail", bucket: mailBucket, put: (*Service).importMail},
+}
+
+// Export writes all entities of the store to w as a newline delimited JSON archive,
+// the archive is taken from a single consistent view of the store.
+func (s *Service) Export(ctx context.Context, w io.Writer) error {
+	bw := bufio.NewWriter(w)
+	enc := json.NewEncoder(bw)
+
+	info := version.Get()
+	header, err := json.Marshal(&ArchiveHeader{
+		Format:    ArchiveFormat,
+		Version:   info.GitVersion,
+		Commit:    info.GitCommit,
+		CreatedAt: s.time(),
+	})
+	if err != nil {
+		return err
+	}
+	if err := enc.Encode(&archiveRecord{Kind: archiveHeaderKind, Data: header}); err != nil {
+		return err
+	}
+
+	err = s.store.View(ctx, func(tx Impl) error {
+		for _, e := range archiveEntities {
+			b, err := tx.Bucket(e.bucket)
+			if err != nil {
+				return err
+			}
+
+			cur, err := b.Cursor()
+			if err != nil {
+				return err
+			}
+
+			for k, v := cur.First(); k != nil; k, v = cur.Next() {
+				if e.record != nil {
+					if v, err = e.record(k, v); err != nil {
+						return err
+					}
+				}
+				if err := enc.Encode(&archiveRecord{Kind: e.kind, Data: v}); err != nil {
+					return err
+				}
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		return &errors.Error{
+			Err: err,
+			Op:  "Export",
+		}
+	}
+	return bw.Flush()
+}
+
+// Import stores the entities of an archive written by Export, keeping their IDs.
+// Entities which already exist are skipped and reported as conflicts, any other
+// error rolls back the whole import.
+func (s *Service) Import(ctx context.Context, r io.Reader) (*ImportResult, error) {
+	entities := make(map[string]archiveEntity, len(archiveEntities))
+	for _, e := range archiveEntities {
+		entities[e.kind] = e
+	}
+
+	res := &ImportResult{
+		Imported: map[string]int{},
+	}
+	// archives may block IP ranges.
+	defer s.ipBlocks.invalidate()
+
+	err := s.store.Modify(ctx, func(tx Impl) error {
+		scanner := bufio.NewScanner(r)
+		scanner.Buffer(make([]byte, 64*1024), maxArchiveRecordSize)
+
+		header := &ArchiveHeader{}
+		rec := &archiveRecord{}
+		if !scanner.Scan() || json.Unmarshal(scanner.Bytes(), rec) != nil || rec.Kind != archiveHeaderKind {
+			return &errors.Error{
+				Code: errors.Invalid,
+				Msg:  "archive does not start with a header",
+				Err:  scanner.Err(),
+			}
+		}
+		if err := json.Unmarshal(rec.Data, header); err != nil {
+			return &errors.Error{
+				Code: errors.Invalid,
+				Msg:  "invalid archive header",
+				Err:  err,
+			}
+		}
+		if header.Format != ArchiveFormat {
+			return &errors.Error{
+				Code: errors.Invalid,
+				Msg:  fmt.Sprintf("unsupported archive format %d, expected %d", header.Format, ArchiveFormat),
+			}
+		}
+
+		for line := 2; scanner.Scan(); line++ {
+			if len(scanner.Bytes()) == 0 {
+				continue
+			}
+
+			rec := &archiveRecord{}
+			if err := json.Unmarshal(scanner.Bytes(), rec); err != nil {
+				return &errors.Error{
+					Code: errors.Invalid,
+					Msg:  fmt.Sprintf("invalid archive record on line %d", line),
+					Err:  err,
+				}
+			}
+
+			e, ok := entities[rec.Kind]
+			if !ok {
+				return &errors.Error{
+					Code: errors.Invalid,
+					Msg:  fmt.Sprintf("unknown kind %q on line %d", rec.Kind, line),
+				}
+			}
+
+			err := e.put(s, ctx, tx, rec.Data)
+			if conflict, ok := err.(*importConflictError); ok {
+				res.Conflicts = append(res.Conflicts, &ImportConflict{
+					Kind: e.kind,
+					Key:  conflict.key,
+					Msg:  fmt.Sprintf("line %d: %s", line, conflict.msg),
+				})
+				continue
+			}
+			if err != nil {
+				return &errors.Error{
+					Err: err,
+					Msg: fmt.Sprintf("failed to import %s on line %d", e.kind, line),
+				}
+			}
+			res.Imported[e.kind]++
+		}
+
+		if err := scanner.Err(); err != nil {
+			return &errors.Error{
+				Code: errors.Invalid,
+				Msg:  "unable to read archive",
+				Err:  err,
+			}
+		}
+
+		// the full-text index and the post counters are not archived, they are rebuilt
+		// from the imported content.
+		if _, err := s.reindex(ctx, tx); err != nil {
+			return err
+		}
+		return countPosts(ctx, tx)
+	})
+	if err != nil {
+		return nil, &errors.Error{
+			Err: err,
+			Op:  "Import",
+		}
+	}
+	return res, nil
+}
+
+// importConflictError is returned by the import functions for entities which already exist.
+type importConflictError struct {
+	key string
+	msg string
+}
+
+func (e *importConflictError) Error() string {
+	return e.key + ": " + e.msg
+}
+
+func newImportConflict(key, msg string) error {
+	return &importConflictError{
+		key: key,
+		msg: msg,
+	}
+}
+
+// exists reports whether key is set in the bucket.
+func exists(b Bucket, key []byte) (bool, error) {
+	_, err := b.Get(key)
+	if IsNotFound(err) {
+		return false, nil
+	}
+	if err != nil {
+		return false, errors.InternalErr(err)
+	}
+	return true, nil
+}
+
+func invalidArchiveEntity(kind string, err error) error {
+	return &errors.Error{
+		Code: errors.Invalid,
+		Msg:  fmt.Sprintf("invalid %s", kind),
+		Err:  err,
+	}
+}
+
+// importNamed stores an entity indexed by its unique name.
+func importNamed(tx Impl, bucket, index []byte, id service.ID, name string, v []byte) error {
+	encodedID, err := id.Encode()
+	if err != nil {
+		return &errors.Error{
+			Code: errors.Invalid,
+			Err:  err,
+		}
+	}
+
+	b, err := tx.Bucket(bucket)
+	if err != nil {
+		return err
+	}
+	idx, err := tx.Bucket(index)
+	if err != nil {
+		return err
+	}
+
+	if ok, err := exists(b, encodedID); err != nil || ok {
+		if err != nil {
+			return err
+		}
+		return newImportConflict(id.String(), "id already exists")
+	}
+	if ok, err := exists(idx, []byte(name)); err != nil || ok {
+		if err != nil {
+			return err
+		}
+		return newImportConflict(id.String(), fmt.Sprintf("name %s already exists", name))
+	}
+
+	if err := b.Put(encodedID, v); err != nil {
+		return errors.InternalErr(err)
+	}
+	if err := idx.Put([]byte(name), encodedID); err != nil {
+		return errors.InternalErr(err)
+	}
+	return nil
+}
+
+func (s *Service) importUser(ctx context.Context, tx Impl, v []byte) error {
+	u := &service.User{}
+	if err := json.Unmarshal(v, u); err != nil {
+		return invalidArchiveEntity("user", err)
+	}
+	return importNamed(tx, userBucket, userIndex, u.ID, u.Name, v)
+}
+
+func (s *Service) importAccountToken(ctx context.Context, tx Impl, v []byte) error {
+	t, err := unmarshalAccountToken(v)
+	if err != nil {
+		return invalidArchiveEntity("account token", err)
+	}
+
+	b, err := s.accountTokenBucket(tx)
+	if err != nil {
+		return err
+	}
+	if ok, err := exists(b, []byte(t.Token)); err != nil || ok {
+		if err != nil {
+			return err
+		}
+		return newImportConflict(t.UserID.String(), "account token already exists")
+	}
+	return s.putAccountToken(ctx, tx, t)
+}
+
+func (s *Service) importOrg(ctx context.Context, tx Impl, v []byte) error {
+	o := &service.Organization{}
+	if err := json.Unmarshal(v, o); err != nil {
+		return invalidArchiveEntity("org", err)
+	}
+	return importNamed(tx, OrgBucket, OrgIndex, o.ID, o.Name, v)
+}
+
+func (s *Service) importURM(ctx context.Context, tx Impl, v []byte) error {
+	m, err := unmarshalURM(v)
+	if err != nil {
+		return err
+	}
+
+	key, err := urmKey(m.ResourceID, m.UserID)
+	if err != nil {
+		return err
+	}
+
+	b, err := s.urmBucket(tx)
+	if err != nil {
+		return err
+	}
+	if ok, err := exists(b, key); err != nil || ok {
+		if err != nil {
+			return err
+		}
+		return newImportConflict(fmt.Sprintf("%s/%s", m.ResourceID, m.UserID), "mapping already exists")
+	}
+	return s.putUserResourceMapping(ctx, tx, m)
+}
+
+func (s *Service) importAuthorization(ctx context.Context, tx Impl, v []byte) error {
+	a := &service.Authorization{}
+	if err := json.Unmarshal(v, a); err != nil {
+		return invalidArchiveEntity("authorization", err)
+	}
+
+	encodedID, err := a.ID.Encode()
+	if err != nil {
+		return &errors.Error{
+			Code: errors.Invalid,
+			Err:  err,
+		}
+	}
+
+	b, err := tx.Bucket(authBucket)
+	if err != nil {
+		return err
+	}
+	idx, err := authIndexBucket(tx)
+	if err != nil {
+		return err
+	}
+
+	if ok, err := exists(b, encodedID); err != nil || ok {
+		if err != nil {
+			return err
+		}
+		return newImportConflict(a.ID.String(), "id already exists")
+	}
+	if ok, err := exists(idx, []byte(a.Token)); err != nil || ok {
+		if err != nil {
+			return err
+		}
+		return newImportConflict(a.ID.String(), "token already exists")
+	}
+	return s.putAuthorization(ctx, tx, a)
+}
+
+func (s *Service) importSession(ctx context.Context, tx Impl, v []byte) error {
+	sess := &service.Session{}
+	if err := json.Unmarshal(v, sess); err != nil {
+		return invalidArchiveEntity("session", err)
+	}
+
+	b, err := s.sessionBucket(tx)
+	if err != nil {
+		return err
+	}
+	if ok, err := exists(b, []byte(sess.Key)); err != nil || ok {
+		if err != nil {
+			return err
+		}
+		return newImportConflict(sess.ID.String(), "session key already exists")
+	}
+	return s.putSession(ctx, tx, sess)
+}
+
+func (s *Service) importQuestion(ctx context.Context, tx Impl, v []byte) error {
+	q := &service.Question{}
+	if err := json.Unmarshal(v, q); err != nil {
+		return invalidArchiveEntity("question", err)
+	}
+
+	encodedID, err := q.ID.Encode()
+	if err != nil {
+		return &errors.Error{
+			Code: errors.Invalid,
+			Err:  err,
+		}
+	}
+
+	b, err := s.questionBucket(tx)
+	if err != nil {
+		return err
+	}
+	if ok, err := exists(b, encodedID); err != nil || ok {
+		if err != nil {
+			return err
+		}
+		return newImportConflict(q.ID.String(), "id already exists")
+	}
+	return s.putQuestion(ctx, tx, q)
+}
+
+func (s *Service) importAnswer(ctx context.Context, tx Impl, v []byte) error {
+	a := &service.Answer{}
+	if err := json.Unmarshal(v, a); err != nil {
+		return invalidArchiveEntity("answer", err)
+	}
+
+	encodedID, err := a.ID.Encode()
+	if err != nil {
+		return &errors.Error{
+			Code: errors.Invalid,
+			Err:  err,
+		}
+	}
+
+	b, err := s.answerBucket(tx)
+	if err != nil {
+		return err
+	}
+	if ok, err := exists(b, encodedID); err != nil || ok {
+		if err != nil {
+			return err
+		}
+		return newImportConflict(a.ID.String(), "id already exists")
+	}
+	if err := s.putAnswer(ctx, tx, a); err != nil {
+		return err
+	}
+
+	key, err := answerQuestionIndexKey(a.QuestionID, a.ID)
+	if err != nil {
+		return err
+	}
+
+	idx, err := s.answerQuestionIndexBucket(tx)
+	if err != nil {
+		return err
+	}
+
+	if err := idx.Put(key, encodedID); err != nil {
+		return errors.InternalErr(err)
+	}
+	return nil
+}
+
+func (s *Service) importArticle(ctx context.Context, tx Impl, v []byte) error {
+	a := &service.Article{}
+	if err := json.Unmarshal(v, a); err != nil {
+		return invalidArchiveEntity("article", err)
+	}
+
+	encodedID, err := a.ID.Encode()
+	if err != nil {
+		return &errors.Error{
+			Code: errors.Invalid,
+			Err:  err,
+		}
+	}
+
+	b, err := s.articleBucket(tx)
+	if err != nil {
+		return err
+	}
+	if ok, err := exists(b, encodedID); err != nil || ok {
+		if err != nil {
+			return err
+		}
+		return newImportConflict(a.ID.String(), "id already exists")
+	}
+	return s.putArticle(ctx, tx, a)
+}
+
+// archivedArticleView is an article view, its bucket keeps the article and the
+// session in the key and the time of the view in the value.
+type archivedArticleView struct {
+	ArticleID service.ID `json:"articleID"`
+	SessionID service.ID `json:"sessionID"`
+	ViewedAt  time.Time  `json:"viewedAt"`
+}
+
+func archiveArticleView(k, v []byte) ([]byte, error) {
+	if len(k) != 2*service.IDLen {
+		return nil, fmt.Errorf("invalid article view key %q", k)
+	}
+
+	view := &archivedArticleView{}
+	if err := view.ArticleID.Decode(k[:service.IDLen]); err != nil {
+		return nil, err
+	}
+	if err := view.SessionID.Decode(k[service.IDLen:]); err != nil {
+		return nil, err
+	}
+	if err := view.ViewedAt.UnmarshalText(v); err != nil {
+		return nil, err
+	}
+	return json.Marshal(view)
+}
+
+func (s *Service) importArticleView(ctx context.Context, tx Impl, v []byte) error {
+	view := &archivedArticleView{}
+	if err := json.Unmarshal(v, view); err != nil {
+		return invalidArchiveEntity("article view", err)
+	}
+
+	key, err := articleViewKey(view.ArticleID, view.SessionID)
+	if err != nil {
+		return err
+	}
+
+	b, err := s.articleViewBucket(tx)
+	if err != nil {
+		return err
+	}
+	if ok, err := exists(b, key); err != nil || ok {
+		if err != nil {
+			return err
+		}
+		return newImportConflict(string(key), "article view already exists")
+	}
+
+	viewedAt, err := view.ViewedAt.MarshalText()
+	if err != nil {
+		return errors.InternalErr(err)
+	}
+	if err := b.Put(key, viewedAt); err != nil {
+		return errors.InternalErr(err)
+	}
+	return nil
+}
+
+func (s *Service) importDraft(ctx context.Context, tx Impl, v []byte) error {
+	d := &service.Draft{}
+	if err := json.Unmarshal(v, d); err != nil {
+		return invalidArchiveEntity("draft", err)
+	}
+
+	key, err := draftKey(d.UserID, d.Type, d.ItemID)
+	if err != nil {
+		return err
+	}
+
+	b, err := s.draftBucket(tx)
+	if err != nil {
+		return err
+	}
+	if ok, err := exists(b, key); err != nil || ok {
+		if err != nil {
+			return err
+		}
+		return newImportConflict(fmt.Sprintf("%s/%s/%s", d.UserID, d.Type, d.ItemID), "draft already exists")
+	}
+	return s.storeDraft(ctx, tx, d)
+}
+
+func (s *Service) importComment(ctx context.Context, tx Impl, v []byte) error {
+	c := &service.Comment{}
+	if err := json.Unmarshal(v, c); err != nil {
+		return invalidArchiveEntity("comment", err)
+	}
+
+	encodedID, err := c.ID.Encode()
+	if err != nil {
+		return &errors.Error{
+			Code: errors.Invalid,
+			Err:  err,
+		}
+	}
+
+	b, err := s.commentBucket(tx)
+	if err != nil {
+		return err
+	}
+	if ok, err := exists(b, encodedID); err != nil || ok {
+		if err != nil {
+			return err
+		}
+		return newImportConflict(c.ID.String(), "id already exists")
+	}
+	if err := s.putComment(ctx, tx, c); err != nil {
+		return err
+	}
+	return s.indexComment(ctx, tx, c)
+}
+
+func (s *Service) importTopic(ctx context.Context, tx Impl, v []byte) error {
+	t := &service.Topic{}
+	if err := json.Unmarshal(v, t); err != nil {
+		return invalidArchiveEntity("topic", err)
+	}
+
+	encodedID, err := t.ID.Encode()
+	if err != nil {
+		return &errors.Error{
+			Code: errors.Invalid,
+			Err:  err,
+		}
+	}
+
+	b, err := s.topicBucket(tx, topicBucket)
+	if err != nil {
+		return err
+	}
+	if ok, err := exists(b, encodedID); err != nil || ok {
+		if err != nil {
+			return err
+		}
+		return newImportConflict(t.ID.String(), "id already exists")
+	}
+
+	err = s.indexURLToken(ctx, tx, t)
+	if err == service.ErrTopicURLTokenExists {
+		return newImportConflict(t.ID.String(), fmt.Sprintf("url token %s already exists", t.URLToken))
+	}
+	if err != nil {
+		return err
+	}
+	return s.putTopic(ctx, tx, t)
+}
+
+func (s *Service) importTopicMerge(ctx context.Context, tx Impl, v []byte) error {
+	m := &service.TopicMerge{}
+	if err := json.Unmarshal(v, m); err != nil {
+		return invalidArchiveEntity("topic merge", err)
+	}
+
+	key, err := idsKey(m.TargetID, m.SourceID)
+	if err != nil {
+		return err
+	}
+
+	b, err := s.topicBucket(tx, topicMergeBucket)
+	if err != nil {
+		return err
+	}
+	if ok, err := exists(b, key); err != nil || ok {
+		if err != nil {
+			return err
+		}
+		return newImportConflict(fmt.Sprintf("%s/%s", m.SourceID, m.TargetID), "topic merge already exists")
+	}
+	return s.putTopicMerge(ctx, tx, m)
+}
+
+func (s *Service) importTopicRelation(ctx context.Context, tx Impl, v []byte) error {
+	r := &service.TopicRelation{}
+	if err := json.Unmarshal(v, r); err != nil {
+		return invalidArchiveEntity("topic relation", err)
+	}
+
+	_, err := s.findTopicRelation(ctx, tx, r.TopicID, r.ItemType, r.ItemID)
+	if err == nil {
+		return newImportConflict(fmt.Sprintf("%s/%s/%s", r.TopicID, r.ItemType, r.ItemID), "topic relation already exists")
+	}
+	if err != service.ErrTopicRelationNotFound {
+		return err
+	}
+	return s.putTopicRelation(ctx, tx, r)
+}
+
+func (s *Service) importTopicFocus(ctx context.Context, tx Impl, v []byte) error {
+	f := &service.TopicFocus{}
+	if err := json.Unmarshal(v, f); err != nil {
+		return invalidArchiveEntity("topic focus", err)
+	}
+
+	_, err := s.findTopicFocus(ctx, tx, f.TopicID, f.UserID)
+	if err == nil {
+		return newImportConflict(fmt.Sprintf("%s/%s", f.TopicID, f.UserID), "topic focus already exists")
+	}
+	if err != service.ErrTopicFocusNotFound {
+		return err
+	}
+	return s.putTopicFocus(ctx, tx, f)
+}
+
+func (s *Service) importVote(ctx context.Context, tx Impl, v []byte) error {
+	vote := &service.Vote{}
+	if err := json.Unmarshal(v, vote); err != nil {
+		return invalidArchiveEntity("vote", err)
+	}
+
+	key, err := voteKey(vote.ItemType, vote.ItemID, vote.UserID)
+	if err != nil {
+		return err
+	}
+
+	b, err := s.voteBucket(tx)
+	if err != nil {
+		return err
+	}
+	if ok, err := exists(b, key); err != nil || ok {
+		if err != nil {
+			return err
+		}
+		return newImportConflict(fmt.Sprintf("%s/%s/%s", vote.ItemType, vote.ItemID, vote.UserID), "vote already exists")
+	}
+
+	// the counters of the item were archived with it.
+	return s.putVote(ctx, tx, vote)
+}
+
+func (s *Service) importNotification(ctx context.Context, tx Impl, v []byte) error {
+	n := &service.Notification{}
+	if err := json.Unmarshal(v, n); err != nil {
+		return invalidArchiveEntity("notification", err)
+	}
+
+	encodedID, err := n.ID.Encode()
+	if err != nil {
+		return &errors.Error{
+			Code: errors.Invalid,
+			Err:  err,
+		}
+	}
+
+	b, err := s.notificationBucket(tx, notificationBucket)
+	if err != nil {
+		return err
+	}
+	if ok, err := exists(b, encodedID); err != nil || ok {
+		if err != nil {
+			return err
+		}
+		return newImportConflict(n.ID.String(), "id already exists")
+	}
+	if err := s.putNotification(ctx, tx, n); err != nil {
+		return err
+	}
+	return s.indexNotification(ctx, tx, n)
+}
+
+func (s *Service) importNotificationSettings(ctx context.Context, tx Impl, v []byte) error {
+	settings := &service.NotificationSettings{}
+	if err := json.Unmarshal(v, settings); err != nil {
+		return invalidArchiveEntity("notificationSettings", err)
+	}
+
+	encodedID, err := settings.UserID.Encode()
+	if err != nil {
+		return &errors.Error{
+			Code: errors.Invalid,
+			Err:  err,
+		}
+	}
+
+	b, err := s.notificationBucket(tx, notificationSettingsBucket)
+	if err != nil {
+		return err
+	}
+	if ok, err := exists(b, encodedID); err != nil || ok {
+		if err != nil {
+			return err
+		}
+		return newImportConflict(settings.UserID.String(), "notification settings already exist")
+	}
+	return s.putNotificationSettings(ctx, tx, settings)
+}
+
+func (s *Service) importDialog(ctx context.Context, tx Impl, v []byte) error {
+	d := &service.Dialog{}
+	if err := json.Unmarshal(v, d); err != nil {
+		return invalidArchiveEntity("dialog", err)
+	}
+
+	encodedID, err := d.ID.Encode()
+	if err != nil {
+		return &errors.Error{
+			Code: errors.Invalid,
+			Err:  err,
+		}
+	}
+
+	b, err := s.messageBucket(tx, dialogBucket)
+	if err != nil {
+		return err
+	}
+	if ok, err := exists(b, encodedID); err != nil || ok {
+		if err != nil {
+			return err
+		}
+		return newImportConflict(d.ID.String(), "id already exists")
+	}
+
+	pairKey, err := dialogPairKey(d.SenderID, d.RecipientID)
+	if err != nil {
+		return err
+	}
+
+	pairs, err := s.messageBucket(tx, dialogPairIndex)
+	if err != nil {
+		return err
+	}
+	if ok, err := exists(pairs, pairKey); err != nil || ok {
+		if err != nil {
+			return err
+		}
+		return newImportConflict(d.ID.String(), "dialog between the users already exists")
+	}
+
+	if err := s.putDialog(ctx, tx, d); err != nil {
+		return err
+	}
+	return s.indexDialog(ctx, tx, d)
+}
+
+func (s *Service) importMessage(ctx context.Context, tx Impl, v []byte) error {
+	m := &service.Message{}
+	if err := json.Unmarshal(v, m); err != nil {
+		return invalidArchiveEntity("message", err)
+	}
+
+	encodedID, err := m.ID.Encode()
+	if err != nil {
+		return &errors.Error{
+			Code: errors.Invalid,
+			Err:  err,
+		}
+	}
+
+	b, err := s.messageBucket(tx, messageBucket)
+	if err != nil {
+		return err
+	}
+	if ok, err := exists(b, encodedID); err != nil || ok {
+		if err != nil {
+			return err
+		}
+		return newImportConflict(m.ID.String(), "id already exists")
+	}
+
+	// the counters of the dialog were archived with it.
+	if err := s.putMessage(ctx, tx, m); err != nil {
+		return err
+	}
+	return s.indexMessage(ctx, tx, m)
+}
+
+func (s *Service) importMessageBlock(ctx context.Context, tx Impl, v []byte) error {
+	block := &service.Block{}
+	if err := json.Unmarshal(v, block); err != nil {
+		return invalidArchiveEntity("messageBlock", err)
+	}
+
+	key, err := idsKey(block.UserID, block.BlockedID)
+	if err != nil {
+		return err
+	}
+
+	b, err := s.messageBucket(tx, messageBlockBucket)
+	if err != nil {
+		return err
+	}
+	if ok, err := exists(b, key); err != nil || ok {
+		if err != nil {
+			return err
+		}
+		return newImportConflict(fmt.Sprintf("%s/%s", block.UserID, block.BlockedID), "block already exists")
+	}
+	return s.putBlock(ctx, tx, block)
+}
+
+func (s *Service) importModerationItem(ctx context.Context, tx Impl, v []byte) error {
+	m, err := unmarshalModerationItem(v)
+	if err != nil {
+		return invalidArchiveEntity("moderationItem", err)
+	}
+
+	key, err := moderationItemKey(m.OrgID, m.Type, m.TargetID)
+	if err != nil {
+		return err
+	}
+
+	b, err := s.moderationBucket(tx, moderationItemBucket)
+	if err != nil {
+		return err
+	}
+	if ok, err := exists(b, key); err != nil || ok {
+		if err != nil {
+			return err
+		}
+		return newImportConflict(fmt.Sprintf("%s/%s/%s", m.OrgID, m.Type, m.TargetID), "moderation item already exists")
+	}
+	return s.putModerationItem(ctx, tx, m)
+}
+
+func (s *Service) importReport(ctx context.Context, tx Impl, v []byte) error {
+	r, err := unmarshalReport(v)
+	if err != nil {
+		return invalidArchiveEntity("report", err)
+	}
+
+	key, err := moderationItemKey(r.OrgID, r.Type, r.TargetID, r.ID)
+	if err != nil {
+		return err
+	}
+
+	b, err := s.moderationBucket(tx, reportBucket)
+	if err != nil {
+		return err
+	}
+	if ok, err := exists(b, key); err != nil || ok {
+		if err != nil {
+			return err
+		}
+		return newImportConflict(r.ID.String(), "report already exists")
+	}
+	return s.putReport(ctx, tx, r)
+}
+
+func (s *Service) importApproval(ctx context.Context, tx Impl, v []byte) error {
+	a, err := unmarshalApproval(v)
+	if err != nil {
+		return invalidArchiveEntity("approval", err)
+	}
+
+	if _, err := s.findApprovalByID(ctx, tx, a.ID); err != service.ErrApprovalNotFound {
+		if err != nil {
+			return err
+		}
+		return newImportConflict(a.ID.String(), "approval already exists")
+	}
+	return s.putApproval(ctx, tx, a)
+}
+
+// importSpamSample stores the sample, it trains the spam filters from the next start.
+func (s *Service) importSpamSample(ctx context.Context, tx Impl, v []byte) error {
+	sample, err := unmarshalSpamSample(v)
+	if err != nil {
+		return invalidArchiveEntity("spam sample", err)
+	}
+
+	encodedID, err := sample.ID.Encode()
+	if err != nil {
+		return &errors.Error{
+			Code: errors.Invalid,
+			Err:  err,
+		}
+	}
+
+	b, err := s.spamSampleBucket(tx)
+	if err != nil {
+		return err
+	}
+	if ok, err := exists(b, encodedID); err != nil || ok {
+		if err != nil {
+			return err
+		}
+		return newImportConflict(sample.ID.String(), "spam sample already exists")
+	}
+	return s.putSpamSample(ctx, tx, sample)
+}
+
+// importBan stores the ban and indexes it under its user while it is in force.
+func (s *Service) importBan(ctx context.Context, tx Impl, v []byte) error {
+	b, err := unmarshalBan(v)
+	if err != nil {
+		return invalidArchiveEntity("ban", err)
+	}
+
+	if _, err := s.findBanByID(ctx, tx, b.ID); err != service.ErrBanNotFound {
+		if err != nil {
+			return err
+		}
+		return newImportConflict(b.ID.String(), "ban already exists")
+	}
+	return s.putBan(ctx, tx, b)
+}
+
+func (s *Service) importIPBlock(ctx context.Context, tx Impl, v []byte) error {
+	b, err := unmarshalIPBlock(v)
+	if err != nil {
+		return invalidArchiveEntity("ip block", err)
+	}
+
+	if _, err := s.findIPBlockByID(ctx, tx, b.ID); err != service.ErrIPBlockNotFound {
+		if err != nil {
+			return err
+		}
+		return newImportConflict(b.ID.String(), "ip block already exists")
+	}
+	return s.putIPBlock(ctx, tx, b)
+}
+
+// importBanAudit stores the audit entry as archived, keeping its time.
+func (s *Service) importBanAudit(ctx context.Context, tx Impl, v []byte) error {
+	a, err := unmarshalBanAudit(v)
+	if err != nil {
+		return invalidArchiveEntity("ban audit", err)
+	}
+
+	encodedID, err := a.ID.Encode()
+	if err != nil {
+		return &errors.Error{
+			Code: errors.Invalid,
+			Err:  err,
+		}
+	}
+
+	b, err := s.banBucket(tx, banAuditBucket)
+	if err != nil {
+		return err
+	}
+	if ok, err := exists(b, encodedID); err != nil || ok {
+		if err != nil {
+			return err
+		}
+		return newImportConflict(a.ID.String(), "ban audit already exists")
+	}
+
+	if err := b.Put(encodedID, v); err != nil {
+		return errors.InternalErr(err)
+	}
+	return nil
+}
+
+func (s *Service) importFollow(ctx context.Context, tx Impl, v []byte) error {
+	f, err := unmarshalFollow(v)
+	if err != nil {
+		return invalidArchiveEntity("follow", err)
+	}
+
+	key, err := followersKey(f.Type, f.ItemID, f.UserID)
+	if err != nil {
+		return err
+	}
+
+	b, err := s.followBucket(tx, followBucket)
+	if err != nil {
+		return err
+	}
+	if ok, err := exists(b, key); err != nil || ok {
+		if err != nil {
+			return err
+		}
+		return newImportConflict(fmt.Sprintf("%s/%s/%s", f.Type, f.ItemID, f.UserID), "follow already exists")
+	}
+
+	// the counters of both ends were archived with them.
+	return s.putFollow(ctx, tx, f)
+}
+
+// importActivity stores the activity with its indexes, the feeds are rebuilt as
+// users read them.
+func (s *Service) importActivity(ctx context.Context, tx Impl, v []byte) error {
+	a, err := unmarshalActivity(v)
+	if err != nil {
+		return invalidArchiveEntity("activity", err)
+	}
+
+	encodedID, err := a.ID.Encode()
+	if err != nil {
+		return &errors.Error{
+			Code: errors.Invalid,
+			Err:  err,
+		}
+	}
+
+	b, err := s.feedBucket(tx, activityBucket)
+	if err != nil {
+		return err
+	}
+	if ok, err := exists(b, encodedID); err != nil || ok {
+		if err != nil {
+			return err
+		}
+		return newImportConflict(a.ID.String(), "id already exists")
+	}
+	if err := s.putActivity(ctx, tx, a); err != nil {
+		return err
+	}
+	return s.indexActivity(ctx, tx, a)
+}
+
+func (s *Service) importMail(ctx context.Context, tx Impl, v []byte) error {
+	m, err := unmarshalMail(v)
+	if err != nil {
+		return invalidArchiveEntity("mail", err)
+	}
+
+	if _, err := s.findMailByID(ctx, tx, m.ID); err != service.ErrMailNotFound {
+		if err != nil {
+			return err
+		}
+		return newImportConflict(m.ID.String(), "mail already exists")
+	}
+	return s.putMail(ctx, tx, m)
+}
@@ -0,0 +1,155 @@
+package store_test
+
+import (
+	"bytes"
+	"context"
+	"testing"
+
+	"github.com/ustackq/indagate/pkg/service"
+	"github.com/ustackq/indagate/pkg/store"
+	"github.com/ustackq/indagate/pkg/store/inmem"
+)
+
+func newInmemService(t *testing.T) *store.Service {
+	t.Helper()
+
+	s := store.NewService(inmem.NewKVStore())
+	if err := s.Init(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+	return s
+}
+
+func TestExportImport(t *testing.T) {
+	ctx := context.Background()
+	src := newInmemService(t)
+
+	orgID := service.ID(1)
+	q := &service.Question{OrgID: orgID, UserID: service.ID(2), Content: "why?"}
+	if err := src.CreateQuestion(ctx, q); err != nil {
+		t.Fatal(err)
+	}
+	a := &service.Answer{QuestionID: q.ID, UserID: service.ID(3), Content: "because"}
+	if err := src.CreateAnswer(ctx, a); err != nil {
+		t.Fatal(err)
+	}
+
+	var archive bytes.Buffer
+	if err := src.Export(ctx, &archive); err != nil {
+		t.Fatal(err)
+	}
+
+	dst := newInmemService(t)
+	res, err := dst.Import(ctx, bytes.NewReader(archive.Bytes()))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if res.Imported["question"] != 1 || res.Imported["answer"] != 1 || len(res.Conflicts) != 0 {
+		t.Fatalf("Import() = %+v, want 1 question and 1 answer without conflicts", res)
+	}
+
+	got, err := dst.FindQuestionByID(ctx, q.ID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got.Content != q.Content || got.AnswerCount != 1 {
+		t.Errorf("imported question = %+v, want %+v", got, q)
+	}
+
+	// answers are found through the rebuilt question index.
+	as, _, err := dst.FindAnswers(ctx, service.AnswerFilter{QuestionID: &q.ID})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(as) != 1 || as[0].ID != a.ID {
+		t.Errorf("imported answers = %+v, want answer %s", as, a.ID)
+	}
+
+	res, err = dst.Import(ctx, bytes.NewReader(archive.Bytes()))
+	if err != nil {
+		t.Fatal(err)
+	}
+	// the question, the answer, the notification of the answer and the
+	// activities of asking and answering.
+	if len(res.Imported) != 0 || len(res.Conflicts) != 5 {
+		t.Errorf("second Import() = %+v, want 5 conflicts", res)
+	}
+}
+
+func TestExportImport_ViewsAndFeeds(t *testing.T) {
+	ctx := context.Background()
+	src := newInmemService(t)
+
+	a := &service.Article{OrgID: service.ID(1), UserID: service.ID(2), Title: "hello", Content: "world"}
+	if err := src.CreateArticle(ctx, a); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := src.PublishArticle(ctx, a.ID); err != nil {
+		t.Fatal(err)
+	}
+	session := service.ID(10)
+	if _, err := src.ViewArticle(ctx, a.ID, session); err != nil {
+		t.Fatal(err)
+	}
+
+	q := &service.Question{OrgID: service.ID(1), UserID: service.ID(2), Content: "why?"}
+	if err := src.CreateQuestion(ctx, q); err != nil {
+		t.Fatal(err)
+	}
+	reader := service.ID(3)
+	if err := src.Follow(ctx, &service.Follow{UserID: reader, Type: service.QuestionFollow, ItemID: q.ID}); err != nil {
+		t.Fatal(err)
+	}
+	want, _, err := src.FindFeed(ctx, reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var archive bytes.Buffer
+	if err := src.Export(ctx, &archive); err != nil {
+		t.Fatal(err)
+	}
+
+	dst := newInmemService(t)
+	res, err := dst.Import(ctx, bytes.NewReader(archive.Bytes()))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if res.Imported["articleView"] != 1 {
+		t.Fatalf("Import() = %+v, want 1 article view", res)
+	}
+
+	// the view of the session was archived, so viewing again is not counted.
+	got, err := dst.ViewArticle(ctx, a.ID, session)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got.ViewCount != 1 {
+		t.Errorf("imported article views = %d, want 1", got.ViewCount)
+	}
+
+	// feeds are not archived, they are rebuilt from the follows and activities.
+	as, _, err := dst.FindFeed(ctx, reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(as) != len(want) || len(as) == 0 || as[0].ID != want[0].ID {
+		t.Errorf("imported feed = %+v, want %+v", as, want)
+	}
+}
+
+func TestImport_InvalidArchive(t *testing.T) {
+	ctx := context.Background()
+	s := newInmemService(t)
+
+	for _, archive := range []string{
+		``,
+		`{"kind":"question","data":{}}`,
+		`{"kind":"header","data":{"format":99}}`,
+		"{\"kind\":\"header\",\"data\":{\"format\":1}}\n{\"kind\":\"unknown\",\"data\":{}}",
+	} {
+		if _, err := s.Import(ctx, bytes.NewReader([]byte(archive))); err == nil {
+			t.Errorf("Import(%q) succeeded, want an error", archive)
+		}
+	}
+}
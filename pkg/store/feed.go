@@ -0,0 +1,871 @@
+package store
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"time"
+
+	"github.com/ustackq/indagate/pkg/service"
+	"github.com/ustackq/indagate/pkg/utils/errors"
+)
+
+const (
+	// DefaultFeedActiveWindow is how long after reading their feed users get activities
+	// fanned out to them when none is configured.
+	DefaultFeedActiveWindow = 7 * 24 * time.Hour
+	// maxFeedEntries bounds the activities kept in the feed of a user, the oldest
+	// entries are dropped as new ones are fanned out.
+	maxFeedEntries = 500
+)
+
+var (
+	activityBucket       = []byte("activitiesv1")
+	activityUserIndex    = []byte("activityuserindexv1")
+	activitySubjectIndex = []byte("activitysubjectindexv1")
+	feedBucket           = []byte("feedsv1")
+	feedReaderBucket     = []byte("feedreadersv1")
+	feedBuckets          = [][]byte{activityBucket, activityUserIndex, activitySubjectIndex, feedBucket, feedReaderBucket}
+)
+
+// assert Service implement service.FeedService
+var _ service.FeedService = (*Service)(nil)
+
+func (s *Service) initializeFeeds(ctx context.Context, tx Impl) error {
+	for _, b := range feedBuckets {
+		if _, err := s.feedBucket(tx, b); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (s *Service) feedBucket(tx Impl, name []byte) (Bucket, error) {
+	b, err := tx.Bucket(name)
+	if err != nil {
+		return nil, UnexpectedFeedError(err)
+	}
+	return b, nil
+}
+
+// UnexpectedFeedError wraps errors raised while retrieving the feed buckets.
+func UnexpectedFeedError(err error) *errors.Error {
+	return &errors.Error{
+		Code: errors.Internal,
+		Msg:  fmt.Sprintf("unexpected error retrieving feed bucket; %v", err),
+		Op:   "feedBucket",
+	}
+}
+
+// feedActiveWindow returns the configured active window of feed readers.
+func (s *Service) feedActiveWindow() time.Duration {
+	if s.Config.FeedActiveWindow > 0 {
+		return s.Config.FeedActiveWindow
+	}
+	return DefaultFeedActiveWindow
+}
+
+// activitySubject returns the item which relates the activity to followed questions
+// and topics: the question of the item, or the article itself.
+func activitySubject(a *service.Activity) (service.ItemType, service.ID, bool) {
+	if a.QuestionID.Valid() {
+		return service.QuestionItemType, a.QuestionID, true
+	}
+	if a.ItemType == service.ArticleItemType {
+		return service.ArticleItemType, a.ItemID, true
+	}
+	return "", 0, false
+}
+
+// activityQuestion returns the question of a question or an answer.
+func (s *Service) activityQuestion(ctx context.Context, tx Impl, itemType service.ItemType, itemID service.ID) (service.ID, error) {
+	switch itemType {
+	case service.QuestionItemType:
+		return itemID, nil
+	case service.AnswerItemType:
+		a, err := s.findAnswerByID(ctx, tx, itemID)
+		if err != nil {
+			return 0, err
+		}
+		return a.QuestionID, nil
+	}
+	return 0, nil
+}
+
+func unmarshalActivity(v []byte) (*service.Activity, error) {
+	a := &service.Activity{}
+	if err := json.Unmarshal(v, a); err != nil {
+		return nil, &errors.Error{
+			Code: errors.Internal,
+			Msg:  "activity could not be unmarshalled",
+			Err:  err,
+			Op:   "unmarshalActivity",
+		}
+	}
+	return a, nil
+}
+
+func (s *Service) findActivityByID(ctx context.Context, tx Impl, id service.ID) (*service.Activity, error) {
+	encodedID, err := id.Encode()
+	if err != nil {
+		return nil, &errors.Error{
+			Code: errors.Invalid,
+			Err:  err,
+		}
+	}
+
+	b, err := s.feedBucket(tx, activityBucket)
+	if err != nil {
+		return nil, err
+	}
+
+	v, err := b.Get(encodedID)
+	if err != nil {
+		return nil, err
+	}
+	return unmarshalActivity(v)
+}
+
+// forEachActivityIndex calls fn with the activities referenced by the index entries under prefix.
+func (s *Service) forEachActivityIndex(ctx context.Context, tx Impl, idx Bucket, prefix []byte, fn func(*service.Activity) error) error {
+	ids := []service.ID{}
+	err := forEachPrefix(idx, prefix, func(_, v []byte) error {
+		var id service.ID
+		if err := id.Decode(v); err != nil {
+			return &errors.Error{
+				Code: errors.Invalid,
+				Err:  err,
+			}
+		}
+		ids = append(ids, id)
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+
+	for _, id := range ids {
+		a, err := s.findActivityByID(ctx, tx, id)
+		if IsNotFound(err) {
+			continue
+		}
+		if err != nil {
+			return err
+		}
+		if err := fn(a); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func sortActivities(as []*service.Activity) {
+	sort.SliceStable(as, func(i, j int) bool {
+		return as[i].CreatedAt.After(as[j].CreatedAt)
+	})
+}
+
+// recordActivity stores the activity and fans it out to the feeds of the active
+// users following its user, question or topics.
+func (s *Service) recordActivity(ctx context.Context, tx Impl, a *service.Activity) error {
+	a.ID = s.IDGenerator.ID()
+	a.CreatedAt = s.time()
+	if err := s.putActivity(ctx, tx, a); err != nil {
+		return err
+	}
+	if err := s.indexActivity(ctx, tx, a); err != nil {
+		return err
+	}
+	return s.fanOutActivity(ctx, tx, a)
+}
+
+func (s *Service) putActivity(ctx context.Context, tx Impl, a *service.Activity) error {
+	v, err := json.Marshal(a)
+	if err != nil {
+		return &errors.Error{
+			Code: errors.Invalid,
+			Err:  err,
+		}
+	}
+
+	encodedID, err := a.ID.Encode()
+	if err != nil {
+		return &errors.Error{
+			Code: errors.Invalid,
+			Err:  err,
+		}
+	}
+
+	b, err := s.feedBucket(tx, activityBucket)
+	if err != nil {
+		return err
+	}
+
+	if err := b.Put(encodedID, v); err != nil {
+		return errors.InternalErr(err)
+	}
+	return nil
+}
+
+// indexActivity indexes the activity by user and by subject.
+func (s *Service) indexActivity(ctx context.Context, tx Impl, a *service.Activity) error {
+	encodedID, err := a.ID.Encode()
+	if err != nil {
+		return &errors.Error{
+			Code: errors.Invalid,
+			Err:  err,
+		}
+	}
+
+	key, err := idsKey(a.UserID, a.ID)
+	if err != nil {
+		return err
+	}
+
+	users, err := s.feedBucket(tx, activityUserIndex)
+	if err != nil {
+		return err
+	}
+
+	if err := users.Put(key, encodedID); err != nil {
+		return errors.InternalErr(err)
+	}
+
+	subjectType, subjectID, ok := activitySubject(a)
+	if !ok {
+		return nil
+	}
+
+	key, err = topicItemKey(subjectType, subjectID, a.ID)
+	if err != nil {
+		return err
+	}
+
+	subjects, err := s.feedBucket(tx, activitySubjectIndex)
+	if err != nil {
+		return err
+	}
+
+	if err := subjects.Put(key, encodedID); err != nil {
+		return errors.InternalErr(err)
+	}
+	return nil
+}
+
+// feedAudience returns the users following the user, question or topics of the activity.
+func (s *Service) feedAudience(ctx context.Context, tx Impl, a *service.Activity) (map[service.ID]bool, error) {
+	audience := map[service.ID]bool{}
+	follows, err := s.followBucket(tx, followBucket)
+	if err != nil {
+		return nil, err
+	}
+
+	addFollowers := func(t service.FollowType, itemID service.ID) error {
+		prefix, err := followersKey(t, itemID)
+		if err != nil {
+			return err
+		}
+		return forEachPrefix(follows, prefix, func(_, v []byte) error {
+			f, err := unmarshalFollow(v)
+			if err != nil {
+				return err
+			}
+			audience[f.UserID] = true
+			return nil
+		})
+	}
+
+	if err := addFollowers(service.UserFollow, a.UserID); err != nil {
+		return nil, err
+	}
+
+	subjectType, subjectID, ok := activitySubject(a)
+	if !ok {
+		return audience, nil
+	}
+
+	if subjectType == service.QuestionItemType {
+		if err := addFollowers(service.QuestionFollow, subjectID); err != nil {
+			return nil, err
+		}
+	}
+
+	rs, err := s.findTopicRelations(ctx, tx, service.TopicRelationFilter{ItemType: &subjectType, ItemID: &subjectID})
+	if err != nil {
+		return nil, err
+	}
+	for _, r := range rs {
+		focuses, err := s.findTopicFocuses(ctx, tx, r.TopicID)
+		if err != nil {
+			return nil, err
+		}
+		for _, f := range focuses {
+			audience[f.UserID] = true
+		}
+	}
+	return audience, nil
+}
+
+func (s *Service) fanOutActivity(ctx context.Context, tx Impl, a *service.Activity) error {
+	audience, err := s.feedAudience(ctx, tx, a)
+	if err != nil {
+		return err
+	}
+
+	for userID := range audience {
+		if userID == a.UserID {
+			continue
+		}
+
+		active, err := s.isFeedActive(ctx, tx, userID)
+		if err != nil {
+			return err
+		}
+		if !active {
+			continue
+		}
+
+		if err := s.putFeedEntry(ctx, tx, userID, a.ID); err != nil {
+			return err
+		}
+		if err := s.trimFeed(ctx, tx, userID); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// trimFeed drops the oldest entries of the feed of the user beyond maxFeedEntries,
+// the keys of the entries are ordered by the time of their activity.
+func (s *Service) trimFeed(ctx context.Context, tx Impl, userID service.ID) error {
+	prefix, err := idsKey(userID)
+	if err != nil {
+		return err
+	}
+
+	b, err := s.feedBucket(tx, feedBucket)
+	if err != nil {
+		return err
+	}
+
+	keys := [][]byte{}
+	err = forEachPrefix(b, prefix, func(k, _ []byte) error {
+		keys = append(keys, append([]byte{}, k...))
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+	if len(keys) <= maxFeedEntries {
+		return nil
+	}
+
+	for _, k := range keys[:len(keys)-maxFeedEntries] {
+		if err := b.Delete(k); err != nil {
+			return errors.WrapperErr(err)
+		}
+	}
+	return nil
+}
+
+func (s *Service) putFeedEntry(ctx context.Context, tx Impl, userID, activityID service.ID) error {
+	key, err := idsKey(userID, activityID)
+	if err != nil {
+		return err
+	}
+
+	encodedID, err := activityID.Encode()
+	if err != nil {
+		return &errors.Error{
+			Code: errors.Invalid,
+			Err:  err,
+		}
+	}
+
+	b, err := s.feedBucket(tx, feedBucket)
+	if err != nil {
+		return err
+	}
+
+	if err := b.Put(key, encodedID); err != nil {
+		return errors.InternalErr(err)
+	}
+	return nil
+}
+
+// isFeedActive reports whether the user read the feed within the active window,
+// the feeds of active users are written as activities are recorded.
+func (s *Service) isFeedActive(ctx context.Context, tx Impl, userID service.ID) (bool, error) {
+	readAt, err := s.feedReadAt(ctx, tx, userID)
+	if err != nil {
+		return false, err
+	}
+	return readAt != nil && s.time().Sub(*readAt) < s.feedActiveWindow(), nil
+}
+
+// feedReadAt returns when the user last read the feed, or nil if the user never did.
+func (s *Service) feedReadAt(ctx context.Context, tx Impl, userID service.ID) (*time.Time, error) {
+	encodedID, err := userID.Encode()
+	if err != nil {
+		return nil, &errors.Error{
+			Code: errors.Invalid,
+			Err:  err,
+		}
+	}
+
+	b, err := s.feedBucket(tx, feedReaderBucket)
+	if err != nil {
+		return nil, err
+	}
+
+	v, err := b.Get(encodedID)
+	if IsNotFound(err) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, errors.InternalErr(err)
+	}
+
+	readAt := &time.Time{}
+	if err := json.Unmarshal(v, readAt); err != nil {
+		return nil, &errors.Error{
+			Code: errors.Internal,
+			Err:  err,
+		}
+	}
+	return readAt, nil
+}
+
+func (s *Service) markFeedRead(ctx context.Context, tx Impl, userID service.ID) error {
+	encodedID, err := userID.Encode()
+	if err != nil {
+		return &errors.Error{
+			Code: errors.Invalid,
+			Err:  err,
+		}
+	}
+
+	v, err := json.Marshal(s.time())
+	if err != nil {
+		return &errors.Error{
+			Code: errors.Internal,
+			Err:  err,
+		}
+	}
+
+	b, err := s.feedBucket(tx, feedReaderBucket)
+	if err != nil {
+		return err
+	}
+
+	if err := b.Put(encodedID, v); err != nil {
+		return errors.InternalErr(err)
+	}
+	return nil
+}
+
+// FindFeed returns the activities of the users, questions and topics followed by
+// the user, most recent first. The feeds of active users are read from their feed
+// bucket, others are merged from the followed items and stored for the next read.
+func (s *Service) FindFeed(ctx context.Context, userID service.ID, opt ...service.FindOptions) ([]*service.Activity, int, error) {
+	var as []*service.Activity
+	err := s.store.View(ctx, func(tx Impl) error {
+		readAt, err := s.feedReadAt(ctx, tx, userID)
+		if err != nil || readAt == nil {
+			return err
+		}
+
+		// the read time is refreshed once half the active window is over, so that
+		// regular readers stay active without a write on every read.
+		if s.time().Sub(*readAt) >= s.feedActiveWindow()/2 {
+			return nil
+		}
+
+		as, err = s.readFeed(ctx, tx, userID)
+		return err
+	})
+	if err == nil && as == nil {
+		err = s.store.Modify(ctx, func(tx Impl) error {
+			feed, err := s.feed(ctx, tx, userID)
+			if err != nil {
+				return err
+			}
+			as = feed
+			return nil
+		})
+	}
+	if err != nil {
+		return nil, 0, &errors.Error{
+			Err: err,
+			Op:  service.OpFindFeed,
+		}
+	}
+
+	start, end := pageWindow(len(as), opt...)
+	return as[start:end], len(as), nil
+}
+
+func (s *Service) feed(ctx context.Context, tx Impl, userID service.ID) ([]*service.Activity, error) {
+	active, err := s.isFeedActive(ctx, tx, userID)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := s.markFeedRead(ctx, tx, userID); err != nil {
+		return nil, err
+	}
+
+	if active {
+		return s.readFeed(ctx, tx, userID)
+	}
+
+	// activities were not fanned out to the user while inactive.
+	as, err := s.fanInFeed(ctx, tx, userID)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := s.clearFeed(ctx, tx, userID); err != nil {
+		return nil, err
+	}
+
+	backfill := as
+	if len(backfill) > maxFeedEntries {
+		backfill = backfill[:maxFeedEntries]
+	}
+	for _, a := range backfill {
+		if err := s.putFeedEntry(ctx, tx, userID, a.ID); err != nil {
+			return nil, err
+		}
+	}
+	return as, nil
+}
+
+// readFeed returns the activities of the feed bucket of the user, entries of
+// removed activities are skipped until they are trimmed.
+func (s *Service) readFeed(ctx context.Context, tx Impl, userID service.ID) ([]*service.Activity, error) {
+	prefix, err := idsKey(userID)
+	if err != nil {
+		return nil, err
+	}
+
+	b, err := s.feedBucket(tx, feedBucket)
+	if err != nil {
+		return nil, err
+	}
+
+	as := []*service.Activity{}
+	err = forEachPrefix(b, prefix, func(_, v []byte) error {
+		var id service.ID
+		if err := id.Decode(v); err != nil {
+			return &errors.Error{
+				Code: errors.Invalid,
+				Err:  err,
+			}
+		}
+
+		a, err := s.findActivityByID(ctx, tx, id)
+		if IsNotFound(err) {
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+		as = append(as, a)
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	sortActivities(as)
+	return as, nil
+}
+
+func (s *Service) clearFeed(ctx context.Context, tx Impl, userID service.ID) error {
+	prefix, err := idsKey(userID)
+	if err != nil {
+		return err
+	}
+
+	b, err := s.feedBucket(tx, feedBucket)
+	if err != nil {
+		return err
+	}
+
+	keys := [][]byte{}
+	err = forEachPrefix(b, prefix, func(k, _ []byte) error {
+		keys = append(keys, append([]byte{}, k...))
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+
+	for _, k := range keys {
+		if err := b.Delete(k); err != nil {
+			return errors.WrapperErr(err)
+		}
+	}
+	return nil
+}
+
+// fanInFeed merges the activities of the users, questions and topics followed by the user.
+func (s *Service) fanInFeed(ctx context.Context, tx Impl, userID service.ID) ([]*service.Activity, error) {
+	seen := map[service.ID]bool{}
+	as := []*service.Activity{}
+	add := func(a *service.Activity) error {
+		if a.UserID != userID && !seen[a.ID] {
+			seen[a.ID] = true
+			as = append(as, a)
+		}
+		return nil
+	}
+
+	users, err := s.feedBucket(tx, activityUserIndex)
+	if err != nil {
+		return nil, err
+	}
+
+	subjects, err := s.feedBucket(tx, activitySubjectIndex)
+	if err != nil {
+		return nil, err
+	}
+
+	addSubject := func(itemType service.ItemType, itemID service.ID) error {
+		prefix, err := topicItemKey(itemType, itemID)
+		if err != nil {
+			return err
+		}
+		return s.forEachActivityIndex(ctx, tx, subjects, prefix, add)
+	}
+
+	following, err := s.followBucket(tx, followingIndexBucket)
+	if err != nil {
+		return nil, err
+	}
+
+	for _, t := range []service.FollowType{service.UserFollow, service.QuestionFollow} {
+		prefix, err := followingKey(userID, t)
+		if err != nil {
+			return nil, err
+		}
+
+		fs := []*service.Follow{}
+		err = forEachPrefix(following, prefix, func(_, v []byte) error {
+			f, err := unmarshalFollow(v)
+			if err != nil {
+				return err
+			}
+			fs = append(fs, f)
+			return nil
+		})
+		if err != nil {
+			return nil, err
+		}
+
+		for _, f := range fs {
+			if f.Type == service.QuestionFollow {
+				err = addSubject(service.QuestionItemType, f.ItemID)
+			} else {
+				var prefix []byte
+				if prefix, err = idsKey(f.ItemID); err == nil {
+					err = s.forEachActivityIndex(ctx, tx, users, prefix, add)
+				}
+			}
+			if err != nil {
+				return nil, err
+			}
+		}
+	}
+
+	prefix, err := idsKey(userID)
+	if err != nil {
+		return nil, err
+	}
+
+	focuses, err := s.topicBucket(tx, topicUserFocusIndex)
+	if err != nil {
+		return nil, err
+	}
+
+	topicIDs := []service.ID{}
+	err = forEachPrefix(focuses, prefix, func(_, v []byte) error {
+		var topicID service.ID
+		if err := topicID.Decode(v); err != nil {
+			return &errors.Error{
+				Code: errors.Invalid,
+				Err:  err,
+			}
+		}
+		topicIDs = append(topicIDs, topicID)
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	for _, topicID := range topicIDs {
+		id := topicID
+		rs, err := s.findTopicRelations(ctx, tx, service.TopicRelationFilter{TopicID: &id})
+		if err != nil {
+			return nil, err
+		}
+		for _, r := range rs {
+			if err := addSubject(r.ItemType, r.ItemID); err != nil {
+				return nil, err
+			}
+		}
+	}
+
+	sortActivities(as)
+	return as, nil
+}
+
+// FindUserActivities returns the activities of the user, most recent first.
+func (s *Service) FindUserActivities(ctx context.Context, userID service.ID, opt ...service.FindOptions) ([]*service.Activity, int, error) {
+	as := []*service.Activity{}
+	err := s.store.View(ctx, func(tx Impl) error {
+		prefix, err := idsKey(userID)
+		if err != nil {
+			return err
+		}
+
+		idx, err := s.feedBucket(tx, activityUserIndex)
+		if err != nil {
+			return err
+		}
+
+		return s.forEachActivityIndex(ctx, tx, idx, prefix, func(a *service.Activity) error {
+			as = append(as, a)
+			return nil
+		})
+	})
+	if err != nil {
+		return nil, 0, &errors.Error{
+			Err: err,
+			Op:  service.OpFindUserActivities,
+		}
+	}
+
+	sortActivities(as)
+	start, end := pageWindow(len(as), opt...)
+	return as[start:end], len(as), nil
+}
+
+// retractActivity removes the activities of the user for an action undone on the item.
+func (s *Service) retractActivity(ctx context.Context, tx Impl, userID service.ID, action service.ActivityAction, itemType service.ItemType, itemID service.ID) error {
+	prefix, err := idsKey(userID)
+	if err != nil {
+		return err
+	}
+
+	idx, err := s.feedBucket(tx, activityUserIndex)
+	if err != nil {
+		return err
+	}
+
+	as := []*service.Activity{}
+	err = s.forEachActivityIndex(ctx, tx, idx, prefix, func(a *service.Activity) error {
+		if a.Action == action && a.ItemType == itemType && a.ItemID == itemID {
+			as = append(as, a)
+		}
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+
+	for _, a := range as {
+		if err := s.removeActivity(ctx, tx, a); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// deleteSubjectActivities removes the activities of a deleted question or article,
+// or only the ones matched by fn if it is not nil. Feed entries are skipped on read.
+func (s *Service) deleteSubjectActivities(ctx context.Context, tx Impl, subjectType service.ItemType, subjectID service.ID, fn func(*service.Activity) bool) error {
+	prefix, err := topicItemKey(subjectType, subjectID)
+	if err != nil {
+		return err
+	}
+
+	idx, err := s.feedBucket(tx, activitySubjectIndex)
+	if err != nil {
+		return err
+	}
+
+	as := []*service.Activity{}
+	err = s.forEachActivityIndex(ctx, tx, idx, prefix, func(a *service.Activity) error {
+		if fn == nil || fn(a) {
+			as = append(as, a)
+		}
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+
+	for _, a := range as {
+		if err := s.removeActivity(ctx, tx, a); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// removeActivity deletes the activity and its index entries.
+func (s *Service) removeActivity(ctx context.Context, tx Impl, a *service.Activity) error {
+	encodedID, err := a.ID.Encode()
+	if err != nil {
+		return &errors.Error{
+			Code: errors.Invalid,
+			Err:  err,
+		}
+	}
+
+	b, err := s.feedBucket(tx, activityBucket)
+	if err != nil {
+		return err
+	}
+
+	if err := b.Delete(encodedID); err != nil {
+		return errors.WrapperErr(err)
+	}
+
+	key, err := idsKey(a.UserID, a.ID)
+	if err != nil {
+		return err
+	}
+
+	users, err := s.feedBucket(tx, activityUserIndex)
+	if err != nil {
+		return err
+	}
+
+	if err := users.Delete(key); err != nil {
+		return errors.WrapperErr(err)
+	}
+
+	subjectType, subjectID, ok := activitySubject(a)
+	if !ok {
+		return nil
+	}
+
+	key, err = topicItemKey(subjectType, subjectID, a.ID)
+	if err != nil {
+		return err
+	}
+
+	subjects, err := s.feedBucket(tx, activitySubjectIndex)
+	if err != nil {
+		return err
+	}
+
+	if err := subjects.Delete(key); err != nil {
+		return errors.WrapperErr(err)
+	}
+	return nil
+}
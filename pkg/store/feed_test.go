@@ -0,0 +1,114 @@
+package store_test
+
+import (
+	"bytes"
+	"context"
+	"testing"
+
+	"github.com/ustackq/indagate/pkg/service"
+)
+
+func TestFeed(t *testing.T) {
+	ctx := context.Background()
+	s := newInmemService(t)
+
+	feed := func(userID service.ID) []*service.Activity {
+		t.Helper()
+		as, _, err := s.FindFeed(ctx, userID)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return as
+	}
+
+	q := &service.Question{OrgID: service.ID(1), UserID: service.ID(2), Content: "why?"}
+	if err := s.CreateQuestion(ctx, q); err != nil {
+		t.Fatal(err)
+	}
+
+	reader := service.ID(1)
+	if err := s.Follow(ctx, &service.Follow{UserID: reader, Type: service.QuestionFollow, ItemID: q.ID}); err != nil {
+		t.Fatal(err)
+	}
+
+	// the first read merges the activities of the followed question, without the reader's own.
+	as := feed(reader)
+	if len(as) != 1 || as[0].Action != service.AskedActivity {
+		t.Fatalf("feed = %+v, want the question being asked", as)
+	}
+
+	// the reader is active now, so the answer is written to the feed.
+	a := &service.Answer{QuestionID: q.ID, UserID: service.ID(3), Content: "because"}
+	if err := s.CreateAnswer(ctx, a); err != nil {
+		t.Fatal(err)
+	}
+	vote := &service.Vote{ItemType: service.QuestionItemType, ItemID: q.ID, UserID: service.ID(5), Value: service.UpVote}
+	if _, err := s.Vote(ctx, vote); err != nil {
+		t.Fatal(err)
+	}
+	if as := feed(reader); len(as) != 3 {
+		t.Fatalf("feed = %d activities, want 3", len(as))
+	}
+
+	// retracted votes and deleted answers leave the feed.
+	if _, err := s.DeleteVote(ctx, service.QuestionItemType, q.ID, service.ID(5)); err != nil {
+		t.Fatal(err)
+	}
+	if err := s.DeleteAnswer(ctx, a.ID); err != nil {
+		t.Fatal(err)
+	}
+	if as := feed(reader); len(as) != 1 {
+		t.Errorf("feed = %d activities, want 1", len(as))
+	}
+
+	// focusing a topic brings the activities of its questions.
+	topic := createTopic(t, s, "go", 0)
+	if err := s.AddTopicRelation(ctx, &service.TopicRelation{TopicID: topic.ID, ItemType: service.QuestionItemType, ItemID: q.ID, UserID: q.UserID}); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := s.FocusTopic(ctx, topic.ID, service.ID(4)); err != nil {
+		t.Fatal(err)
+	}
+	if as := feed(service.ID(4)); len(as) != 2 {
+		t.Errorf("topic feed = %d activities, want the question being asked and followed", len(as))
+	}
+
+	if _, n, err := s.FindUserActivities(ctx, reader); err != nil || n != 1 {
+		t.Errorf("activities of the reader = %d, %v, want 1", n, err)
+	}
+}
+
+func TestFeedTrim(t *testing.T) {
+	ctx := context.Background()
+	s := newInmemService(t)
+
+	archive := `{"kind":"header","data":{"format":1}}
+{"kind":"user","data":{"id":2,"name":"author"}}`
+	if _, err := s.Import(ctx, bytes.NewReader([]byte(archive))); err != nil {
+		t.Fatal(err)
+	}
+
+	reader, author := service.ID(1), service.ID(2)
+	if err := s.Follow(ctx, &service.Follow{UserID: reader, Type: service.UserFollow, ItemID: author}); err != nil {
+		t.Fatal(err)
+	}
+	if _, _, err := s.FindFeed(ctx, reader); err != nil {
+		t.Fatal(err)
+	}
+
+	// the feed of an active reader keeps the latest 500 activities fanned out to it.
+	var last *service.Question
+	for i := 0; i < 501; i++ {
+		last = &service.Question{OrgID: service.ID(1), UserID: author, Content: "why?"}
+		if err := s.CreateQuestion(ctx, last); err != nil {
+			t.Fatal(err)
+		}
+	}
+	as, total, err := s.FindFeed(ctx, reader, service.FindOptions{Limit: 1})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if total != 500 || len(as) != 1 || as[0].ItemID != last.ID {
+		t.Errorf("feed = %+v, %d, want the last question of 500 activities", as, total)
+	}
+}
@@ -0,0 +1,241 @@
+package store
+
+import (
+	"context"
+	"fmt"
+
+	"go.uber.org/zap"
+
+	"github.com/ustackq/indagate/pkg/service"
+	"github.com/ustackq/indagate/pkg/utils/errors"
+)
+
+// spamSampleBucket keeps the contents labelled by moderator decisions to train
+// the spam filters again when the service starts.
+var spamSampleBucket = []byte("spamsamplesv1")
+
+func (s *Service) initializeSpamSamples(ctx context.Context, tx Impl) error {
+	if _, err := s.spamSampleBucket(tx); err != nil {
+		return err
+	}
+	return nil
+}
+
+func (s *Service) spamSampleBucket(tx Impl) (Bucket, error) {
+	b, err := tx.Bucket(spamSampleBucket)
+	if err != nil {
+		return nil, UnexpectedSpamSampleError(err)
+	}
+	return b, nil
+}
+
+// UnexpectedSpamSampleError wraps errors raised while retrieving the spam sample bucket.
+func UnexpectedSpamSampleError(err error) *errors.Error {
+	return &errors.Error{
+		Code: errors.Internal,
+		Msg:  fmt.Sprintf("unexpected error retrieving spam sample bucket; %v", err),
+		Op:   "spamSampleBucket",
+	}
+}
+
+// filterContent runs the content filters on content about to be written, rejected
+// content returns an error.
+func (s *Service) filterContent(ctx context.Context, c *service.Content) (*service.FilterResult, error) {
+	if s.ContentFilter == nil {
+		return service.Allowed, nil
+	}
+
+	r, err := s.ContentFilter.Filter(ctx, c)
+	if err != nil {
+		return nil, err
+	}
+
+	if r.Verdict == service.RejectContent {
+		return nil, rejectedContentError(r)
+	}
+	return r, nil
+}
+
+// filterUnreviewedContent runs the content filters on content which can not wait
+// for review, held content is rejected.
+func (s *Service) filterUnreviewedContent(ctx context.Context, c *service.Content) error {
+	r, err := s.filterContent(ctx, c)
+	if err != nil {
+		return err
+	}
+
+	if r.Verdict == service.HoldContent {
+		return rejectedContentError(r)
+	}
+	return nil
+}
+
+func rejectedContentError(r *service.FilterResult) error {
+	return &errors.Error{
+		Code: service.ErrContentRejected.Code,
+		Msg:  fmt.Sprintf("%s: %s", service.ErrContentRejected.Msg, r.Reason),
+	}
+}
+
+func unmarshalSpamSample(v []byte) (*service.SpamSample, error) {
+	sample := &service.SpamSample{}
+	if err := json.Unmarshal(v, sample); err != nil {
+		return nil, &errors.Error{
+			Code: errors.Internal,
+			Msg:  "spam sample could not be unmarshalled",
+			Err:  err,
+			Op:   "unmarshalSpamSample",
+		}
+	}
+	return sample, nil
+}
+
+// recordSpamSample keeps the content labelled by a moderator decision, it is
+// trained once the decision is committed.
+func (s *Service) recordSpamSample(ctx context.Context, tx Impl, c *service.Content, spam bool) (*service.SpamSample, error) {
+	sample := &service.SpamSample{
+		ID:        s.IDGenerator.ID(),
+		Content:   c,
+		Spam:      spam,
+		CreatedAt: s.time(),
+	}
+	if err := s.putSpamSample(ctx, tx, sample); err != nil {
+		return nil, err
+	}
+	return sample, nil
+}
+
+func (s *Service) putSpamSample(ctx context.Context, tx Impl, sample *service.SpamSample) error {
+	v, err := json.Marshal(sample)
+	if err != nil {
+		return &errors.Error{
+			Code: errors.Invalid,
+			Err:  err,
+		}
+	}
+
+	encodedID, err := sample.ID.Encode()
+	if err != nil {
+		return &errors.Error{
+			Code: errors.Invalid,
+			Err:  err,
+		}
+	}
+
+	b, err := s.spamSampleBucket(tx)
+	if err != nil {
+		return err
+	}
+
+	if err := b.Put(encodedID, v); err != nil {
+		return errors.InternalErr(err)
+	}
+	return nil
+}
+
+// trainSpam trains the spam filters with committed samples, failures are only
+// logged as the decisions themselves have been written.
+func (s *Service) trainSpam(ctx context.Context, samples ...*service.SpamSample) {
+	if s.SpamTrainer == nil {
+		return
+	}
+
+	for _, sample := range samples {
+		if sample == nil {
+			continue
+		}
+		if err := s.SpamTrainer.Train(ctx, sample.Content, sample.Spam); err != nil {
+			s.Logger.Warn("failed to train spam filter", zap.Stringer("sample", sample.ID), zap.Error(err))
+		}
+	}
+}
+
+// trainSpamSamples trains the spam filters with every kept sample.
+func (s *Service) trainSpamSamples(ctx context.Context) error {
+	if s.SpamTrainer == nil {
+		return nil
+	}
+
+	var samples []*service.SpamSample
+	err := s.store.View(ctx, func(tx Impl) error {
+		b, err := s.spamSampleBucket(tx)
+		if err != nil {
+			return err
+		}
+
+		cur, err := b.Cursor()
+		if err != nil {
+			return err
+		}
+
+		for k, v := cur.First(); k != nil; k, v = cur.Next() {
+			sample, err := unmarshalSpamSample(v)
+			if err != nil {
+				return err
+			}
+			samples = append(samples, sample)
+		}
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+
+	s.trainSpam(ctx, samples...)
+	return nil
+}
+
+// itemContent returns the content of a question, answer or comment as filtered.
+func (s *Service) itemContent(ctx context.Context, tx Impl, itemType service.ItemType, id service.ID) (*service.Content, error) {
+	switch itemType {
+	case service.QuestionItemType:
+		q, err := s.findQuestionByID(ctx, tx, id)
+		if err != nil {
+			return nil, err
+		}
+		return questionContent(q), nil
+	case service.AnswerItemType:
+		a, err := s.findAnswerByID(ctx, tx, id)
+		if err != nil {
+			return nil, err
+		}
+		return answerContent(a), nil
+	case service.CommentItemType:
+		c, err := s.findCommentByID(ctx, tx, id)
+		if err != nil {
+			return nil, err
+		}
+		return commentContent(c), nil
+	}
+	return nil, &errors.Error{
+		Code: errors.Invalid,
+		Msg:  fmt.Sprintf("%s have no content to filter", itemType),
+	}
+}
+
+func questionContent(q *service.Question) *service.Content {
+	return &service.Content{
+		Kind:   service.QuestionContent,
+		UserID: q.UserID,
+		OrgID:  q.OrgID,
+		Texts:  []string{q.Content, q.Detail},
+	}
+}
+
+func answerContent(a *service.Answer) *service.Content {
+	return &service.Content{
+		Kind:   service.AnswerContent,
+		UserID: a.UserID,
+		OrgID:  a.OrgID,
+		Texts:  []string{a.Content},
+	}
+}
+
+func commentContent(c *service.Comment) *service.Content {
+	return &service.Content{
+		Kind:   service.CommentContent,
+		UserID: c.UserID,
+		OrgID:  c.OrgID,
+		Texts:  []string{c.Content},
+	}
+}
@@ -0,0 +1,127 @@
+package store_test
+
+import (
+	"context"
+	"testing"
+
+	"github.com/ustackq/indagate/pkg/filter"
+	"github.com/ustackq/indagate/pkg/service"
+	"github.com/ustackq/indagate/pkg/store"
+	"github.com/ustackq/indagate/pkg/store/inmem"
+	"github.com/ustackq/indagate/pkg/utils/errors"
+)
+
+func TestContentFilter(t *testing.T) {
+	ctx := context.Background()
+	kv := inmem.NewKVStore()
+	s := store.NewService(kv)
+
+	reject, err := filter.NewKeywordFilter(service.RejectContent)
+	if err != nil {
+		t.Fatal(err)
+	}
+	reject.SetWords([]string{"casino", "赌博"})
+	hold, err := filter.NewKeywordFilter(service.HoldContent)
+	if err != nil {
+		t.Fatal(err)
+	}
+	hold.SetWords([]string{"cheap"})
+	bayes := filter.NewBayes()
+	bayes.MinSamples = 1
+	s.ContentFilter = service.ContentFilters{reject, hold}
+	s.SpamTrainer = bayes
+	if err := s.Init(ctx); err != nil {
+		t.Fatal(err)
+	}
+
+	org, author, reviewer := service.ID(1), service.ID(2), service.ID(9)
+
+	// rejected words fail the write, in Chinese as in English.
+	for _, content := range []string{"best Casino in town", "网上赌博平台"} {
+		q := &service.Question{OrgID: org, UserID: author, Content: content}
+		if err := s.CreateQuestion(ctx, q); errors.ErrorCode(err) != errors.Forbidden {
+			t.Errorf("create %q error = %v, want %s", content, err, errors.Forbidden)
+		}
+	}
+
+	// words within other words are not matched.
+	q := &service.Question{OrgID: org, UserID: author, Content: "which class is cheaper?"}
+	if err := s.CreateQuestion(ctx, q); err != nil {
+		t.Fatal(err)
+	}
+	if q.Pending {
+		t.Errorf("question = %+v, want it published", q)
+	}
+	a := &service.Answer{QuestionID: q.ID, UserID: author, Content: "the first one"}
+	if err := s.CreateAnswer(ctx, a); err != nil {
+		t.Fatal(err)
+	}
+
+	// held comments wait for approval and are not counted.
+	c := &service.Comment{Type: service.AnswerComment, ItemID: a.ID, UserID: author, Content: "cheap pills here"}
+	if err := s.CreateComment(ctx, c); err != nil {
+		t.Fatal(err)
+	}
+	if !c.Pending {
+		t.Fatalf("comment = %+v, want it pending", c)
+	}
+	if a, err := s.FindAnswerByID(ctx, a.ID); err != nil || a.CommentCount != 0 {
+		t.Errorf("answer = %+v, %v, want no comment counted", a, err)
+	}
+	status := service.ApprovalPending
+	as, _, err := s.FindApprovals(ctx, service.ApprovalFilter{OrgID: &org, Status: &status})
+	if err != nil || len(as) != 1 || as[0].ItemType != service.CommentItemType || as[0].Reasons[0] != service.FilterApprovalReason {
+		t.Fatalf("pending approvals = %+v, %v, want the held comment", as, err)
+	}
+
+	// comment edits and messages can not wait for review, held content is rejected.
+	cheap := "cheap, really"
+	ok := &service.Comment{Type: service.AnswerComment, ItemID: a.ID, UserID: author, Content: "thanks"}
+	if err := s.CreateComment(ctx, ok); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := s.UpdateComment(ctx, ok.ID, service.CommentUpdate{Content: &cheap}); errors.ErrorCode(err) != errors.Forbidden {
+		t.Errorf("update comment error = %v, want %s", err, errors.Forbidden)
+	}
+	d := &service.Dialog{SenderID: author, RecipientID: reviewer}
+	if err := s.CreateDialog(ctx, d); err != nil {
+		t.Fatal(err)
+	}
+	m := &service.Message{DialogID: d.ID, SenderID: author, Content: "cheap watches"}
+	if err := s.SendMessage(ctx, m); errors.ErrorCode(err) != errors.Forbidden {
+		t.Errorf("send message error = %v, want %s", err, errors.Forbidden)
+	}
+
+	// rejecting the comment trains it as spam, dismissing a report as ham.
+	if _, err := s.ReviewApproval(ctx, as[0].ID, &service.ApprovalReview{Decision: service.ApprovalRejected, Comment: "spam", ReviewerID: reviewer}); err != nil {
+		t.Fatal(err)
+	}
+	if c, err := s.FindCommentByID(ctx, c.ID); err != nil || !c.Deleted {
+		t.Errorf("rejected comment = %+v, %v, want it deleted", c, err)
+	}
+	if _, ok := bayes.Score("pills"); ok {
+		t.Errorf("scored without ham samples")
+	}
+	r := &service.Report{OrgID: org, Type: service.AnswerReport, TargetID: a.ID, UserID: reviewer, Reason: "spam"}
+	if _, err := s.Report(ctx, r); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := s.ResolveReports(ctx, org, service.AnswerReport, a.ID, &service.Resolution{Action: service.DismissModeration, Reason: "fine", ModeratorID: reviewer}); err != nil {
+		t.Fatal(err)
+	}
+	if score, ok := bayes.Score("pills"); !ok || score < 0.5 {
+		t.Errorf("spam score = %v, %v, want spam", score, ok)
+	}
+
+	// samples train the filter again when the service starts.
+	restarted := store.NewService(kv)
+	retrained := filter.NewBayes()
+	retrained.MinSamples = 1
+	restarted.SpamTrainer = retrained
+	if err := restarted.Init(ctx); err != nil {
+		t.Fatal(err)
+	}
+	if score, ok := retrained.Score("the first one"); !ok || score >= 0.5 {
+		t.Errorf("ham score = %v, %v, want ham", score, ok)
+	}
+}
@@ -0,0 +1,600 @@
+package store
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+
+	"github.com/ustackq/indagate/pkg/service"
+	"github.com/ustackq/indagate/pkg/utils/errors"
+)
+
+var (
+	followBucket         = []byte("followsv1")
+	followingIndexBucket = []byte("followingindexv1")
+)
+
+// assert Service implement service.FollowService
+var _ service.FollowService = (*Service)(nil)
+
+func (s *Service) initializeFollows(ctx context.Context, tx Impl) error {
+	if _, err := s.followBucket(tx, followBucket); err != nil {
+		return err
+	}
+	if _, err := s.followBucket(tx, followingIndexBucket); err != nil {
+		return err
+	}
+	return nil
+}
+
+func (s *Service) followBucket(tx Impl, name []byte) (Bucket, error) {
+	b, err := tx.Bucket(name)
+	if err != nil {
+		return nil, UnexpectedFollowError(err)
+	}
+	return b, nil
+}
+
+// UnexpectedFollowError wraps errors raised while retrieving the follow buckets.
+func UnexpectedFollowError(err error) *errors.Error {
+	return &errors.Error{
+		Code: errors.Internal,
+		Msg:  fmt.Sprintf("unexpected error retrieving follow bucket; %v", err),
+		Op:   "followBucket",
+	}
+}
+
+// followersKey is the type and item of the follow followed by the encoded follower ids.
+func followersKey(t service.FollowType, itemID service.ID, userIDs ...service.ID) ([]byte, error) {
+	ids, err := idsKey(append([]service.ID{itemID}, userIDs...)...)
+	if err != nil {
+		return nil, err
+	}
+	return append([]byte(t+"/"), ids...), nil
+}
+
+// followingKey is the follower and the type followed by the encoded item ids.
+func followingKey(userID service.ID, t service.FollowType, itemIDs ...service.ID) ([]byte, error) {
+	user, err := idsKey(userID)
+	if err != nil {
+		return nil, err
+	}
+
+	ids, err := idsKey(itemIDs...)
+	if err != nil {
+		return nil, err
+	}
+
+	key := append(user, []byte(t+"/")...)
+	return append(key, ids...), nil
+}
+
+// pageEdges walks the entries of b under prefix whose keys end with the encoded ID
+// of the other end of an edge, starting after the cursor. It returns the cursor of the
+// next page if entries remain.
+func pageEdges(b Bucket, prefix []byte, cursor service.FollowCursor, fn func(k, v []byte) error) (service.ID, error) {
+	limit := cursor.Limit
+	if limit <= 0 {
+		limit = service.DefaultFollowPageSize
+	}
+
+	start := prefix
+	if cursor.After.Valid() {
+		after, err := idsKey(cursor.After)
+		if err != nil {
+			return 0, err
+		}
+		start = append(append([]byte{}, prefix...), after...)
+	}
+
+	cur, err := b.Cursor()
+	if err != nil {
+		return 0, err
+	}
+
+	n := 0
+	var last []byte
+	for k, v := cur.Seek(start); k != nil && bytes.HasPrefix(k, prefix); k, v = cur.Next() {
+		if bytes.Equal(k, start) && cursor.After.Valid() {
+			continue
+		}
+
+		if n == limit {
+			var next service.ID
+			if err := next.Decode(last[len(last)-service.IDLen:]); err != nil {
+				return 0, &errors.Error{
+					Code: errors.Internal,
+					Err:  err,
+				}
+			}
+			return next, nil
+		}
+
+		if err := fn(k, v); err != nil {
+			return 0, err
+		}
+		last = k
+		n++
+	}
+	return 0, nil
+}
+
+func unmarshalFollow(v []byte) (*service.Follow, error) {
+	f := &service.Follow{}
+	if err := json.Unmarshal(v, f); err != nil {
+		return nil, &errors.Error{
+			Code: errors.Internal,
+			Msg:  "follow could not be unmarshalled",
+			Err:  err,
+			Op:   "unmarshalFollow",
+		}
+	}
+	return f, nil
+}
+
+func topicFocusFollow(tf *service.TopicFocus) *service.Follow {
+	return &service.Follow{
+		UserID:    tf.UserID,
+		Type:      service.TopicFollow,
+		ItemID:    tf.TopicID,
+		CreatedAt: tf.CreatedAt,
+	}
+}
+
+// FindFollow returns the follow of the user on the item.
+func (s *Service) FindFollow(ctx context.Context, userID service.ID, t service.FollowType, itemID service.ID) (*service.Follow, error) {
+	var f *service.Follow
+	err := s.store.View(ctx, func(tx Impl) error {
+		follow, err := s.findFollow(ctx, tx, userID, t, itemID)
+		if err != nil {
+			return err
+		}
+		f = follow
+		return nil
+	})
+	if err != nil {
+		return nil, &errors.Error{
+			Err: err,
+			Op:  service.OpFindFollow,
+		}
+	}
+	return f, nil
+}
+
+func (s *Service) findFollow(ctx context.Context, tx Impl, userID service.ID, t service.FollowType, itemID service.ID) (*service.Follow, error) {
+	if err := t.Valid(); err != nil {
+		return nil, err
+	}
+
+	// topic follows are the focuses of the topics.
+	if t == service.TopicFollow {
+		tf, err := s.findTopicFocus(ctx, tx, itemID, userID)
+		if err == service.ErrTopicFocusNotFound {
+			return nil, service.ErrFollowNotFound
+		}
+		if err != nil {
+			return nil, err
+		}
+		return topicFocusFollow(tf), nil
+	}
+
+	key, err := followersKey(t, itemID, userID)
+	if err != nil {
+		return nil, err
+	}
+
+	b, err := s.followBucket(tx, followBucket)
+	if err != nil {
+		return nil, err
+	}
+
+	v, err := b.Get(key)
+	if IsNotFound(err) {
+		return nil, service.ErrFollowNotFound
+	}
+	if err != nil {
+		return nil, &errors.Error{
+			Code: errors.Internal,
+			Err:  err,
+		}
+	}
+	return unmarshalFollow(v)
+}
+
+// FindFollowers returns a page of the follows on the item ordered by follower.
+func (s *Service) FindFollowers(ctx context.Context, t service.FollowType, itemID service.ID, cursor service.FollowCursor) (*service.FollowPage, error) {
+	page := &service.FollowPage{Follows: []*service.Follow{}}
+	err := s.store.View(ctx, func(tx Impl) error {
+		if err := t.Valid(); err != nil {
+			return err
+		}
+
+		var (
+			b      Bucket
+			prefix []byte
+			err    error
+		)
+		if t == service.TopicFollow {
+			b, err = s.topicBucket(tx, topicFocusBucket)
+			if err != nil {
+				return err
+			}
+			prefix, err = idsKey(itemID)
+		} else {
+			b, err = s.followBucket(tx, followBucket)
+			if err != nil {
+				return err
+			}
+			prefix, err = followersKey(t, itemID)
+		}
+		if err != nil {
+			return err
+		}
+
+		page.Next, err = pageEdges(b, prefix, cursor, func(_, v []byte) error {
+			if t == service.TopicFollow {
+				tf, err := unmarshalTopicFocus(v)
+				if err != nil {
+					return err
+				}
+				page.Follows = append(page.Follows, topicFocusFollow(tf))
+				return nil
+			}
+
+			f, err := unmarshalFollow(v)
+			if err != nil {
+				return err
+			}
+			page.Follows = append(page.Follows, f)
+			return nil
+		})
+		return err
+	})
+	if err != nil {
+		return nil, &errors.Error{
+			Err: err,
+			Op:  service.OpFindFollowers,
+		}
+	}
+	return page, nil
+}
+
+// FindFollowing returns a page of the items of the type followed by the user ordered by item.
+func (s *Service) FindFollowing(ctx context.Context, userID service.ID, t service.FollowType, cursor service.FollowCursor) (*service.FollowPage, error) {
+	page := &service.FollowPage{Follows: []*service.Follow{}}
+	err := s.store.View(ctx, func(tx Impl) error {
+		if err := t.Valid(); err != nil {
+			return err
+		}
+
+		if t == service.TopicFollow {
+			idx, err := s.topicBucket(tx, topicUserFocusIndex)
+			if err != nil {
+				return err
+			}
+
+			prefix, err := idsKey(userID)
+			if err != nil {
+				return err
+			}
+
+			page.Next, err = pageEdges(idx, prefix, cursor, func(_, v []byte) error {
+				var topicID service.ID
+				if err := topicID.Decode(v); err != nil {
+					return &errors.Error{
+						Code: errors.Invalid,
+						Err:  err,
+					}
+				}
+
+				tf, err := s.findTopicFocus(ctx, tx, topicID, userID)
+				if err != nil {
+					return err
+				}
+				page.Follows = append(page.Follows, topicFocusFollow(tf))
+				return nil
+			})
+			return err
+		}
+
+		idx, err := s.followBucket(tx, followingIndexBucket)
+		if err != nil {
+			return err
+		}
+
+		prefix, err := followingKey(userID, t)
+		if err != nil {
+			return err
+		}
+
+		page.Next, err = pageEdges(idx, prefix, cursor, func(_, v []byte) error {
+			f, err := unmarshalFollow(v)
+			if err != nil {
+				return err
+			}
+			page.Follows = append(page.Follows, f)
+			return nil
+		})
+		return err
+	})
+	if err != nil {
+		return nil, &errors.Error{
+			Err: err,
+			Op:  service.OpFindFollowing,
+		}
+	}
+	return page, nil
+}
+
+// Follow makes f.UserID follow the item, following twice has no effect.
+func (s *Service) Follow(ctx context.Context, f *service.Follow) error {
+	return s.store.Modify(ctx, func(tx Impl) error {
+		if err := s.follow(ctx, tx, f); err != nil {
+			return &errors.Error{
+				Err: err,
+				Op:  service.OpFollow,
+			}
+		}
+		return nil
+	})
+}
+
+func (s *Service) follow(ctx context.Context, tx Impl, f *service.Follow) error {
+	if err := f.Valid(); err != nil {
+		return err
+	}
+
+	existing, err := s.findFollow(ctx, tx, f.UserID, f.Type, f.ItemID)
+	if err == nil {
+		*f = *existing
+		return nil
+	}
+	if err != service.ErrFollowNotFound {
+		return err
+	}
+
+	switch f.Type {
+	case service.TopicFollow:
+		// focusTopic counts the focus of the user.
+		if _, err := s.focusTopic(ctx, tx, f.ItemID, f.UserID); err != nil {
+			return err
+		}
+		tf, err := s.findTopicFocus(ctx, tx, f.ItemID, f.UserID)
+		if err != nil {
+			return err
+		}
+		*f = *topicFocusFollow(tf)
+		return nil
+	case service.UserFollow:
+		if _, err := s.findUserByID(ctx, tx, f.ItemID); err != nil {
+			return err
+		}
+	case service.QuestionFollow:
+		if _, err := s.findQuestionByID(ctx, tx, f.ItemID); err != nil {
+			return err
+		}
+	}
+
+	f.CreatedAt = s.time()
+	if err := s.putFollow(ctx, tx, f); err != nil {
+		return err
+	}
+	if err := s.adjustFollowCounts(ctx, tx, f, 1); err != nil {
+		return err
+	}
+
+	activity := &service.Activity{
+		UserID:   f.UserID,
+		Action:   service.FollowedActivity,
+		ItemType: service.ItemType(f.Type),
+		ItemID:   f.ItemID,
+	}
+	if f.Type == service.QuestionFollow {
+		q, err := s.findQuestionByID(ctx, tx, f.ItemID)
+		if err != nil {
+			return err
+		}
+		activity.OrgID = q.OrgID
+		activity.QuestionID = q.ID
+	}
+
+	if f.Type == service.UserFollow {
+		err := s.notify(ctx, tx, &service.Notification{
+			RecipientID: f.ItemID,
+			SenderID:    f.UserID,
+			Action:      service.FollowNotification,
+		})
+		if err != nil {
+			return err
+		}
+	}
+	return s.recordActivity(ctx, tx, activity)
+}
+
+// putFollow stores both directions of the edge.
+func (s *Service) putFollow(ctx context.Context, tx Impl, f *service.Follow) error {
+	v, err := json.Marshal(f)
+	if err != nil {
+		return &errors.Error{
+			Code: errors.Invalid,
+			Err:  err,
+		}
+	}
+
+	key, err := followersKey(f.Type, f.ItemID, f.UserID)
+	if err != nil {
+		return err
+	}
+
+	b, err := s.followBucket(tx, followBucket)
+	if err != nil {
+		return err
+	}
+
+	if err := b.Put(key, v); err != nil {
+		return errors.InternalErr(err)
+	}
+
+	idxKey, err := followingKey(f.UserID, f.Type, f.ItemID)
+	if err != nil {
+		return err
+	}
+
+	idx, err := s.followBucket(tx, followingIndexBucket)
+	if err != nil {
+		return err
+	}
+
+	if err := idx.Put(idxKey, v); err != nil {
+		return errors.InternalErr(err)
+	}
+	return nil
+}
+
+// Unfollow removes the follow of the user on the item.
+func (s *Service) Unfollow(ctx context.Context, userID service.ID, t service.FollowType, itemID service.ID) error {
+	return s.store.Modify(ctx, func(tx Impl) error {
+		if err := s.unfollow(ctx, tx, userID, t, itemID); err != nil {
+			return &errors.Error{
+				Err: err,
+				Op:  service.OpUnfollow,
+			}
+		}
+		return nil
+	})
+}
+
+func (s *Service) unfollow(ctx context.Context, tx Impl, userID service.ID, t service.FollowType, itemID service.ID) error {
+	f, err := s.findFollow(ctx, tx, userID, t, itemID)
+	if err != nil {
+		return err
+	}
+
+	if t == service.TopicFollow {
+		tf, err := s.findTopicFocus(ctx, tx, itemID, userID)
+		if err != nil {
+			return err
+		}
+		_, err = s.unfocusTopic(ctx, tx, tf)
+		return err
+	}
+
+	if err := s.removeFollowEntry(ctx, tx, f); err != nil {
+		return err
+	}
+
+	if err := s.adjustFollowCounts(ctx, tx, f, -1); err != nil {
+		return err
+	}
+	return s.retractActivity(ctx, tx, userID, service.FollowedActivity, service.ItemType(t), itemID)
+}
+
+// removeFollowEntry deletes both directions of the edge.
+func (s *Service) removeFollowEntry(ctx context.Context, tx Impl, f *service.Follow) error {
+	key, err := followersKey(f.Type, f.ItemID, f.UserID)
+	if err != nil {
+		return err
+	}
+
+	b, err := s.followBucket(tx, followBucket)
+	if err != nil {
+		return err
+	}
+
+	if err := b.Delete(key); err != nil {
+		return errors.WrapperErr(err)
+	}
+
+	idxKey, err := followingKey(f.UserID, f.Type, f.ItemID)
+	if err != nil {
+		return err
+	}
+
+	idx, err := s.followBucket(tx, followingIndexBucket)
+	if err != nil {
+		return err
+	}
+
+	if err := idx.Delete(idxKey); err != nil {
+		return errors.WrapperErr(err)
+	}
+	return nil
+}
+
+// deleteItemFollows removes the follows on a deleted item.
+func (s *Service) deleteItemFollows(ctx context.Context, tx Impl, t service.FollowType, itemID service.ID) error {
+	prefix, err := followersKey(t, itemID)
+	if err != nil {
+		return err
+	}
+
+	b, err := s.followBucket(tx, followBucket)
+	if err != nil {
+		return err
+	}
+
+	fs := []*service.Follow{}
+	err = forEachPrefix(b, prefix, func(_, v []byte) error {
+		f, err := unmarshalFollow(v)
+		if err != nil {
+			return err
+		}
+		fs = append(fs, f)
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+
+	for _, f := range fs {
+		if err := s.removeFollowEntry(ctx, tx, f); err != nil {
+			return err
+		}
+
+		// the counters of the item are gone with it.
+		if err := s.adjustUserFollowCounts(ctx, tx, f.UserID, 0, 0, -1); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// adjustFollowCounts updates the counters of both ends of a user or question follow.
+func (s *Service) adjustFollowCounts(ctx context.Context, tx Impl, f *service.Follow, delta int) error {
+	switch f.Type {
+	case service.UserFollow:
+		if err := s.adjustUserFollowCounts(ctx, tx, f.UserID, 0, delta, 0); err != nil {
+			return err
+		}
+		return s.adjustUserFollowCounts(ctx, tx, f.ItemID, delta, 0, 0)
+	case service.QuestionFollow:
+		q, err := s.findQuestionByID(ctx, tx, f.ItemID)
+		if err != nil {
+			return err
+		}
+
+		q.FocusCount += delta
+		if err := s.putQuestion(ctx, tx, q); err != nil {
+			return err
+		}
+		return s.adjustUserFollowCounts(ctx, tx, f.UserID, 0, 0, delta)
+	}
+	return nil
+}
+
+// adjustUserFollowCounts adds the deltas to the follow counters of the user, users
+// which have been deleted are skipped.
+func (s *Service) adjustUserFollowCounts(ctx context.Context, tx Impl, userID service.ID, fans, friends, focus int) error {
+	u, err := s.findUserByID(ctx, tx, userID)
+	if err == ErrUserNotFound {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+
+	u.FansCount += fans
+	u.FriendCount += friends
+	u.FocusCount += focus
+	return s.putUser(ctx, tx, u)
+}
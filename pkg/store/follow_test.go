@@ -0,0 +1,96 @@
+package store_test
+
+import (
+	"context"
+	"testing"
+
+	"github.com/ustackq/indagate/pkg/service"
+	"github.com/ustackq/indagate/pkg/utils/errors"
+)
+
+func TestFollows(t *testing.T) {
+	ctx := context.Background()
+	s := newInmemService(t)
+
+	q := &service.Question{OrgID: service.ID(1), UserID: service.ID(100), Content: "why?"}
+	if err := s.CreateQuestion(ctx, q); err != nil {
+		t.Fatal(err)
+	}
+
+	for _, userID := range []service.ID{1, 2, 3} {
+		if err := s.Follow(ctx, &service.Follow{UserID: userID, Type: service.QuestionFollow, ItemID: q.ID}); err != nil {
+			t.Fatal(err)
+		}
+	}
+	// following twice has no effect.
+	if err := s.Follow(ctx, &service.Follow{UserID: 1, Type: service.QuestionFollow, ItemID: q.ID}); err != nil {
+		t.Fatal(err)
+	}
+
+	got, err := s.FindQuestionByID(ctx, q.ID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got.FocusCount != 3 {
+		t.Errorf("focus count = %d, want 3", got.FocusCount)
+	}
+
+	// the followers are paged by the cursor.
+	var followers []service.ID
+	cursor := service.FollowCursor{Limit: 2}
+	for {
+		page, err := s.FindFollowers(ctx, service.QuestionFollow, q.ID, cursor)
+		if err != nil {
+			t.Fatal(err)
+		}
+		for _, f := range page.Follows {
+			followers = append(followers, f.UserID)
+		}
+		if !page.Next.Valid() {
+			break
+		}
+		cursor.After = page.Next
+	}
+	if len(followers) != 3 || followers[0] != 1 || followers[2] != 3 {
+		t.Errorf("followers = %v, want [1 2 3]", followers)
+	}
+
+	topic := createTopic(t, s, "go", 0)
+	if err := s.Follow(ctx, &service.Follow{UserID: 1, Type: service.TopicFollow, ItemID: topic.ID}); err != nil {
+		t.Fatal(err)
+	}
+	// topic follows are the focuses of the topic.
+	if _, err := s.FindTopicFocus(ctx, topic.ID, 1); err != nil {
+		t.Errorf("FindTopicFocus() error = %v", err)
+	}
+
+	page, err := s.FindFollowing(ctx, 1, service.TopicFollow, service.FollowCursor{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(page.Follows) != 1 || page.Follows[0].ItemID != topic.ID || page.Next.Valid() {
+		t.Errorf("following = %v, want the topic", page.Follows)
+	}
+
+	if err := s.Unfollow(ctx, 2, service.QuestionFollow, q.ID); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := s.FindFollow(ctx, 2, service.QuestionFollow, q.ID); errors.ErrorCode(err) != errors.NotFound {
+		t.Errorf("FindFollow() error = %v, want not found", err)
+	}
+	if page, err := s.FindFollowing(ctx, 2, service.QuestionFollow, service.FollowCursor{}); err != nil || len(page.Follows) != 0 {
+		t.Errorf("following = %v, %v, want none", page, err)
+	}
+
+	// deleting the question removes its follows.
+	if err := s.DeleteQuestion(ctx, q.ID); err != nil {
+		t.Fatal(err)
+	}
+	if page, err := s.FindFollowing(ctx, 1, service.QuestionFollow, service.FollowCursor{}); err != nil || len(page.Follows) != 0 {
+		t.Errorf("following = %v, %v, want none after delete", page, err)
+	}
+
+	if err := s.Follow(ctx, &service.Follow{UserID: 1, Type: service.UserFollow, ItemID: 1}); errors.ErrorCode(err) != errors.Invalid {
+		t.Errorf("Follow() error = %v, want invalid", err)
+	}
+}
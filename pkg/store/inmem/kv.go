@@ -0,0 +1,287 @@
+package inmem
+
+import (
+	"context"
+	"sort"
+	"sync"
+
+	"github.com/ustackq/indagate/pkg/store"
+	"github.com/ustackq/indagate/pkg/tracing"
+)
+
+// KVStore is an in-memory implementation of store.Store, every bucket is an
+// ordered map. Modify transactions are serialized and work on copies of the
+// buckets they touch, which replace the committed buckets on success, so a
+// View always reads a consistent snapshot.
+type KVStore struct {
+	// writer serializes Modify transactions.
+	writer sync.Mutex
+
+	mu      sync.RWMutex
+	buckets map[string]*bucket
+}
+
+// NewKVStore returns an empty in-memory store.
+func NewKVStore() *KVStore {
+	return &KVStore{
+		buckets: map[string]*bucket{},
+	}
+}
+
+// Flush removes all data of the store.
+func (kv *KVStore) Flush(ctx context.Context) {
+	kv.writer.Lock()
+	defer kv.writer.Unlock()
+
+	kv.mu.Lock()
+	kv.buckets = map[string]*bucket{}
+	kv.mu.Unlock()
+}
+
+func (kv *KVStore) snapshot() map[string]*bucket {
+	kv.mu.RLock()
+	defer kv.mu.RUnlock()
+	return kv.buckets
+}
+
+// View opens up a transaction on a snapshot of the store. Implementing interface store.Store.
+func (kv *KVStore) View(ctx context.Context, fn func(tx store.Impl) error) error {
+	span, ctx := tracing.StartSpanFromContext(ctx)
+	defer span.End()
+
+	return fn(&Tx{
+		ctx:      ctx,
+		snapshot: kv.snapshot(),
+	})
+}
+
+// Modify opens up a transaction that will write to the store, none of the writes
+// are visible until fn returns without error.
+func (kv *KVStore) Modify(ctx context.Context, fn func(tx store.Impl) error) error {
+	span, ctx := tracing.StartSpanFromContext(ctx)
+	defer span.End()
+
+	kv.writer.Lock()
+	defer kv.writer.Unlock()
+
+	tx := &Tx{
+		ctx:      ctx,
+		snapshot: kv.snapshot(),
+		writable: true,
+		dirty:    map[string]*bucket{},
+	}
+
+	if err := fn(tx); err != nil {
+		return err
+	}
+
+	if len(tx.dirty) == 0 {
+		return nil
+	}
+
+	buckets := make(map[string]*bucket, len(tx.snapshot)+len(tx.dirty))
+	for name, b := range tx.snapshot {
+		buckets[name] = b
+	}
+	for name, b := range tx.dirty {
+		buckets[name] = b
+	}
+
+	kv.mu.Lock()
+	kv.buckets = buckets
+	kv.mu.Unlock()
+	return nil
+}
+
+// Tx is an in-memory transaction, It implements store.Impl.
+type Tx struct {
+	ctx      context.Context
+	snapshot map[string]*bucket
+	writable bool
+	// dirty holds the copies of the buckets accessed by a writable transaction.
+	dirty map[string]*bucket
+}
+
+// Context returns the context for the transaction.
+func (tx *Tx) Context() context.Context {
+	return tx.ctx
+}
+
+// WithContext sets the context for the transaction.
+func (tx *Tx) WithContext(ctx context.Context) {
+	tx.ctx = ctx
+}
+
+// Bucket retrieves the bucket according args, creating it if it doesn't exist.
+func (tx *Tx) Bucket(name []byte) (store.Bucket, error) {
+	if !tx.writable {
+		b, ok := tx.snapshot[string(name)]
+		if !ok {
+			b = newBucket()
+		}
+		return &Bucket{bucket: b}, nil
+	}
+
+	b, ok := tx.dirty[string(name)]
+	if !ok {
+		if committed, ok := tx.snapshot[string(name)]; ok {
+			b = committed.clone()
+		} else {
+			b = newBucket()
+		}
+		tx.dirty[string(name)] = b
+	}
+	return &Bucket{bucket: b, writable: true}, nil
+}
+
+type bucket struct {
+	// keys is kept sorted.
+	keys   []string
+	values map[string][]byte
+}
+
+func newBucket() *bucket {
+	return &bucket{
+		values: map[string][]byte{},
+	}
+}
+
+func (b *bucket) clone() *bucket {
+	c := &bucket{
+		keys:   make([]string, len(b.keys)),
+		values: make(map[string][]byte, len(b.values)),
+	}
+	copy(c.keys, b.keys)
+	for k, v := range b.values {
+		c.values[k] = v
+	}
+	return c
+}
+
+// search returns the index of the first key greater than or equal to key.
+func (b *bucket) search(key string) int {
+	return sort.SearchStrings(b.keys, key)
+}
+
+// Bucket implements store.Bucket.
+type Bucket struct {
+	bucket   *bucket
+	writable bool
+}
+
+// Get retrieves the value at the provided key.
+func (b *Bucket) Get(key []byte) ([]byte, error) {
+	v, ok := b.bucket.values[string(key)]
+	if !ok {
+		return nil, store.ErrKeyNotFound
+	}
+	return v, nil
+}
+
+// Put sets the value at the provided key.
+func (b *Bucket) Put(key []byte, value []byte) error {
+	if !b.writable {
+		return store.ErrTxNotWritable
+	}
+	if len(key) > store.MaxKeySize {
+		return store.ErrKeyTooLarge
+	}
+
+	k := string(key)
+	if _, ok := b.bucket.values[k]; !ok {
+		i := b.bucket.search(k)
+		b.bucket.keys = append(b.bucket.keys, "")
+		copy(b.bucket.keys[i+1:], b.bucket.keys[i:])
+		b.bucket.keys[i] = k
+	}
+
+	// values are never modified in place, committed snapshots share them.
+	v := make([]byte, len(value))
+	copy(v, value)
+	b.bucket.values[k] = v
+	return nil
+}
+
+// Delete removes the provided key.
+func (b *Bucket) Delete(key []byte) error {
+	if !b.writable {
+		return store.ErrTxNotWritable
+	}
+
+	k := string(key)
+	if _, ok := b.bucket.values[k]; !ok {
+		return nil
+	}
+
+	i := b.bucket.search(k)
+	b.bucket.keys = append(b.bucket.keys[:i], b.bucket.keys[i+1:]...)
+	delete(b.bucket.values, k)
+	return nil
+}
+
+// Cursor retrieves a cursor for iterating through the entries
+// in the key value store.
+func (b *Bucket) Cursor() (store.Cursor, error) {
+	return &Cursor{
+		bucket: b.bucket,
+	}, nil
+}
+
+// KeyN returns the number of keys in the bucket.
+func (b *Bucket) KeyN() (int, error) {
+	return len(b.bucket.keys), nil
+}
+
+// Cursor is a struct for iterating through the entries in key order,
+// it stays valid while the bucket is modified.
+type Cursor struct {
+	bucket *bucket
+	key    string
+	valid  bool
+}
+
+func (c *Cursor) at(i int) ([]byte, []byte) {
+	if i < 0 || i >= len(c.bucket.keys) {
+		c.valid = false
+		return nil, nil
+	}
+
+	c.key, c.valid = c.bucket.keys[i], true
+	return []byte(c.key), c.bucket.values[c.key]
+}
+
+// Seek moves the cursor to the first key greater than or equal to prefix.
+func (c *Cursor) Seek(prefix []byte) ([]byte, []byte) {
+	return c.at(c.bucket.search(string(prefix)))
+}
+
+// First retrieves the first key value pair in the bucket.
+func (c *Cursor) First() ([]byte, []byte) {
+	return c.at(0)
+}
+
+// Last retrieves the last key value pair in the bucket.
+func (c *Cursor) Last() ([]byte, []byte) {
+	return c.at(len(c.bucket.keys) - 1)
+}
+
+// Next retrieves the next key in the bucket.
+func (c *Cursor) Next() ([]byte, []byte) {
+	if !c.valid {
+		return nil, nil
+	}
+
+	i := c.bucket.search(c.key)
+	if i < len(c.bucket.keys) && c.bucket.keys[i] == c.key {
+		i++
+	}
+	return c.at(i)
+}
+
+// Prev retrieves the previous key in the bucket.
+func (c *Cursor) Prev() ([]byte, []byte) {
+	if !c.valid {
+		return nil, nil
+	}
+	return c.at(c.bucket.search(c.key) - 1)
+}
@@ -0,0 +1,83 @@
+package inmem_test
+
+import (
+	"context"
+	"testing"
+
+	"github.com/ustackq/indagate/pkg/store"
+	"github.com/ustackq/indagate/pkg/store/inmem"
+	"github.com/ustackq/indagate/pkg/store/storetest"
+)
+
+func initKVStore(t *testing.T) (store.Store, func()) {
+	return inmem.NewKVStore(), func() {}
+}
+
+func TestKVStore(t *testing.T) {
+	storetest.KVStore(initKVStore, t)
+}
+
+func TestKVStore_SnapshotIsolation(t *testing.T) {
+	ctx := context.Background()
+	s := inmem.NewKVStore()
+	bucket := []byte("testv1")
+
+	put := func(k, v string) error {
+		return s.Modify(ctx, func(tx store.Impl) error {
+			b, err := tx.Bucket(bucket)
+			if err != nil {
+				return err
+			}
+			return b.Put([]byte(k), []byte(v))
+		})
+	}
+
+	if err := put("a", "1"); err != nil {
+		t.Fatal(err)
+	}
+
+	err := s.View(ctx, func(tx store.Impl) error {
+		b, err := tx.Bucket(bucket)
+		if err != nil {
+			return err
+		}
+		c, err := b.Cursor()
+		if err != nil {
+			return err
+		}
+		c.First()
+
+		// writes committed while the view runs are not seen by it.
+		if err := put("a", "2"); err != nil {
+			return err
+		}
+		if err := put("b", "2"); err != nil {
+			return err
+		}
+
+		if v, err := b.Get([]byte("a")); err != nil || string(v) != "1" {
+			t.Errorf("Get(a) in snapshot = %q, %v, want %q", v, err, "1")
+		}
+		if k, _ := c.Next(); k != nil {
+			t.Errorf("Next() in snapshot = %q, want nil", k)
+		}
+		return nil
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	err = s.View(ctx, func(tx store.Impl) error {
+		b, err := tx.Bucket(bucket)
+		if err != nil {
+			return err
+		}
+		if v, err := b.Get([]byte("a")); err != nil || string(v) != "2" {
+			t.Errorf("Get(a) after commit = %q, %v, want %q", v, err, "2")
+		}
+		return nil
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+}
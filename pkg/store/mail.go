@@ -0,0 +1,451 @@
+package store
+
+import (
+	"context"
+	"encoding/binary"
+	"fmt"
+	"sort"
+	"time"
+
+	"github.com/ustackq/indagate/pkg/service"
+	"github.com/ustackq/indagate/pkg/utils/errors"
+)
+
+var (
+	mailBucket = []byte("mailsv1")
+	// mailQueueBucket indexes the pending mails by the time they are due, see mailQueueKey.
+	mailQueueBucket = []byte("mailqueuev2")
+	// mailQueueV1Bucket indexed the pending mails by ID, it is left empty by the
+	// migration to mailQueueBucket.
+	mailQueueV1Bucket = []byte("mailqueuev1")
+)
+
+// assert Service implement service.MailService
+var _ service.MailService = (*Service)(nil)
+
+func (s *Service) initializeMails(ctx context.Context, tx Impl) error {
+	if _, err := s.mailBucket(tx, mailBucket); err != nil {
+		return err
+	}
+	if _, err := s.mailBucket(tx, mailQueueBucket); err != nil {
+		return err
+	}
+	if _, err := s.mailBucket(tx, mailQueueV1Bucket); err != nil {
+		return err
+	}
+	return nil
+}
+
+func (s *Service) mailBucket(tx Impl, name []byte) (Bucket, error) {
+	b, err := tx.Bucket(name)
+	if err != nil {
+		return nil, UnexpectedMailError(err)
+	}
+	return b, nil
+}
+
+// UnexpectedMailError wraps errors raised while retrieving the mail buckets.
+func UnexpectedMailError(err error) *errors.Error {
+	return &errors.Error{
+		Code: errors.Internal,
+		Msg:  fmt.Sprintf("unexpected error retrieving mail bucket; %v", err),
+		Op:   "mailBucket",
+	}
+}
+
+// mailQueueKey is the big endian time the mail is due followed by its ID, so that
+// a cursor walks the queue from the first mail due to the last one. A mail is due
+// when it is queued, and again at NextAttemptAt after a failed attempt.
+func mailQueueKey(m *service.Mail, encodedID []byte) []byte {
+	due := m.CreatedAt
+	if m.NextAttemptAt != nil {
+		due = *m.NextAttemptAt
+	}
+
+	k := make([]byte, 8, 8+len(encodedID))
+	binary.BigEndian.PutUint64(k, uint64(due.UnixNano()))
+	return append(k, encodedID...)
+}
+
+func unmarshalMail(v []byte) (*service.Mail, error) {
+	m := &service.Mail{}
+	if err := json.Unmarshal(v, m); err != nil {
+		return nil, &errors.Error{
+			Code: errors.Internal,
+			Msg:  "mail could not be unmarshalled",
+			Err:  err,
+			Op:   "unmarshalMail",
+		}
+	}
+	return m, nil
+}
+
+// FindMailByID returns a single mail by ID.
+func (s *Service) FindMailByID(ctx context.Context, id service.ID) (*service.Mail, error) {
+	var m *service.Mail
+	err := s.store.View(ctx, func(tx Impl) error {
+		mail, err := s.findMailByID(ctx, tx, id)
+		if err != nil {
+			return err
+		}
+		m = mail
+		return nil
+	})
+	if err != nil {
+		return nil, &errors.Error{
+			Err: err,
+			Op:  service.OpFindMailByID,
+		}
+	}
+	return m, nil
+}
+
+func (s *Service) findMailByID(ctx context.Context, tx Impl, id service.ID) (*service.Mail, error) {
+	encodedID, err := id.Encode()
+	if err != nil {
+		return nil, &errors.Error{
+			Code: errors.Invalid,
+			Err:  err,
+		}
+	}
+
+	b, err := s.mailBucket(tx, mailBucket)
+	if err != nil {
+		return nil, err
+	}
+
+	v, err := b.Get(encodedID)
+	if IsNotFound(err) {
+		return nil, service.ErrMailNotFound
+	}
+	if err != nil {
+		return nil, errors.InternalErr(err)
+	}
+	return unmarshalMail(v)
+}
+
+// putMail stores the mail and keeps it in the queue while it is pending.
+func (s *Service) putMail(ctx context.Context, tx Impl, m *service.Mail) error {
+	v, err := json.Marshal(m)
+	if err != nil {
+		return &errors.Error{
+			Code: errors.Invalid,
+			Err:  err,
+		}
+	}
+
+	encodedID, err := m.ID.Encode()
+	if err != nil {
+		return &errors.Error{
+			Code: errors.Invalid,
+			Err:  err,
+		}
+	}
+
+	// the mail leaves the queue at the time it was due, before it is put back at
+	// the time it is due now.
+	prev, err := s.findMailByID(ctx, tx, m.ID)
+	if err != nil && err != service.ErrMailNotFound {
+		return err
+	}
+
+	b, err := s.mailBucket(tx, mailBucket)
+	if err != nil {
+		return err
+	}
+
+	if err := b.Put(encodedID, v); err != nil {
+		return errors.InternalErr(err)
+	}
+
+	idx, err := s.mailBucket(tx, mailQueueBucket)
+	if err != nil {
+		return err
+	}
+
+	if prev != nil && prev.Status == service.MailPending {
+		if err := idx.Delete(mailQueueKey(prev, encodedID)); err != nil && !IsNotFound(err) {
+			return errors.InternalErr(err)
+		}
+	}
+
+	if m.Status == service.MailPending {
+		if err := idx.Put(mailQueueKey(m, encodedID), encodedID); err != nil {
+			return errors.InternalErr(err)
+		}
+	}
+	return nil
+}
+
+// FindMails returns the mails matching the filter, latest first, and the total count of them.
+func (s *Service) FindMails(ctx context.Context, filter service.MailFilter, opt ...service.FindOptions) ([]*service.Mail, int, error) {
+	ms := []*service.Mail{}
+	err := s.store.View(ctx, func(tx Impl) error {
+		b, err := s.mailBucket(tx, mailBucket)
+		if err != nil {
+			return err
+		}
+
+		cur, err := b.Cursor()
+		if err != nil {
+			return err
+		}
+
+		for k, v := cur.First(); k != nil; k, v = cur.Next() {
+			m, err := unmarshalMail(v)
+			if err != nil {
+				return err
+			}
+			if filter.Status != nil && m.Status != *filter.Status {
+				continue
+			}
+			ms = append(ms, m)
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, 0, &errors.Error{
+			Err: err,
+			Op:  service.OpFindMails,
+		}
+	}
+
+	sort.SliceStable(ms, func(i, j int) bool {
+		return ms[i].CreatedAt.After(ms[j].CreatedAt)
+	})
+
+	start, end := pageWindow(len(ms), opt...)
+	return ms[start:end], len(ms), nil
+}
+
+// EnqueueMail stores the mail as pending and sets m.ID with the new identifier.
+func (s *Service) EnqueueMail(ctx context.Context, m *service.Mail) error {
+	err := s.store.Modify(ctx, func(tx Impl) error {
+		return s.enqueueMail(ctx, tx, m)
+	})
+	if err != nil {
+		return &errors.Error{
+			Err: err,
+			Op:  service.OpEnqueueMail,
+		}
+	}
+	return nil
+}
+
+func (s *Service) enqueueMail(ctx context.Context, tx Impl, m *service.Mail) error {
+	if err := m.Valid(); err != nil {
+		return err
+	}
+
+	m.ID = s.IDGenerator.ID()
+	m.Status = service.MailPending
+	m.Attempts = 0
+	m.LastError = ""
+	m.NextAttemptAt = nil
+	m.CreatedAt = s.time()
+	m.SentAt = nil
+	return s.putMail(ctx, tx, m)
+}
+
+// PendingMails returns up to n pending mails due for an attempt, in the order they
+// fell due. The queue is ordered by due time so the walk stops at the first mail
+// which is not due yet.
+func (s *Service) PendingMails(ctx context.Context, n int) ([]*service.Mail, error) {
+	ms := []*service.Mail{}
+	now := s.time()
+	err := s.store.View(ctx, func(tx Impl) error {
+		idx, err := s.mailBucket(tx, mailQueueBucket)
+		if err != nil {
+			return err
+		}
+
+		cur, err := idx.Cursor()
+		if err != nil {
+			return err
+		}
+
+		for k, v := cur.First(); k != nil; k, v = cur.Next() {
+			due := time.Unix(0, int64(binary.BigEndian.Uint64(k[:8])))
+			if due.After(now) {
+				break
+			}
+
+			var id service.ID
+			if err := id.Decode(v); err != nil {
+				return errors.InternalErr(err)
+			}
+			m, err := s.findMailByID(ctx, tx, id)
+			if err != nil {
+				return err
+			}
+
+			ms = append(ms, m)
+			if n > 0 && len(ms) == n {
+				break
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, &errors.Error{
+			Err: err,
+			Op:  service.OpPendingMails,
+		}
+	}
+	return ms, nil
+}
+
+// RecordMailAttempt records the outcome of a delivery attempt of a pending mail.
+func (s *Service) RecordMailAttempt(ctx context.Context, id service.ID, a service.MailAttempt) (*service.Mail, error) {
+	var m *service.Mail
+	err := s.store.Modify(ctx, func(tx Impl) error {
+		mail, err := s.recordMailAttempt(ctx, tx, id, a)
+		if err != nil {
+			return err
+		}
+		m = mail
+		return nil
+	})
+	if err != nil {
+		return nil, &errors.Error{
+			Err: err,
+			Op:  service.OpRecordMailAttempt,
+		}
+	}
+	return m, nil
+}
+
+func (s *Service) recordMailAttempt(ctx context.Context, tx Impl, id service.ID, a service.MailAttempt) (*service.Mail, error) {
+	m, err := s.findMailByID(ctx, tx, id)
+	if err != nil {
+		return nil, err
+	}
+
+	if m.Status != service.MailPending {
+		return nil, service.ErrMailNotPending
+	}
+
+	now := s.time()
+	m.NextAttemptAt = nil
+	if a.Err == "" {
+		m.Status = service.MailSent
+		m.SentAt = &now
+	} else {
+		m.Attempts++
+		m.LastError = a.Err
+		if a.Dead {
+			m.Status = service.MailDead
+		} else {
+			next := now.Add(a.RetryAfter)
+			m.NextAttemptAt = &next
+		}
+	}
+
+	if err := s.putMail(ctx, tx, m); err != nil {
+		return nil, err
+	}
+	return m, nil
+}
+
+// RetryMail puts a dead letter back in the queue, with all its attempts ahead of it.
+func (s *Service) RetryMail(ctx context.Context, id service.ID) (*service.Mail, error) {
+	var m *service.Mail
+	err := s.store.Modify(ctx, func(tx Impl) error {
+		mail, err := s.findMailByID(ctx, tx, id)
+		if err != nil {
+			return err
+		}
+
+		if mail.Status != service.MailDead {
+			return service.ErrMailNotDead
+		}
+
+		mail.Status = service.MailPending
+		mail.Attempts = 0
+		mail.NextAttemptAt = nil
+		if err := s.putMail(ctx, tx, mail); err != nil {
+			return err
+		}
+		m = mail
+		return nil
+	})
+	if err != nil {
+		return nil, &errors.Error{
+			Err: err,
+			Op:  service.OpRetryMail,
+		}
+	}
+	return m, nil
+}
+
+// indexMailsByDueTime moves the pending mails from the queue ordered by ID to the
+// queue ordered by due time.
+func indexMailsByDueTime(ctx context.Context, tx Impl) error {
+	return rebuildMailQueue(tx, mailQueueV1Bucket, mailQueueBucket, mailQueueKey)
+}
+
+// indexMailsByID reverts indexMailsByDueTime.
+func indexMailsByID(ctx context.Context, tx Impl) error {
+	return rebuildMailQueue(tx, mailQueueBucket, mailQueueV1Bucket, func(m *service.Mail, encodedID []byte) []byte {
+		return encodedID
+	})
+}
+
+// rebuildMailQueue empties the from queue and puts the pending mails in the to
+// queue at the given keys.
+func rebuildMailQueue(tx Impl, from, to []byte, key func(m *service.Mail, encodedID []byte) []byte) error {
+	fb, err := tx.Bucket(from)
+	if err != nil {
+		return UnexpectedMailError(err)
+	}
+
+	// collect first, deleting while iterating would move the cursor.
+	var keys [][]byte
+	err = forEach(fb, func(k, v []byte) error {
+		keys = append(keys, append([]byte(nil), k...))
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+	for _, k := range keys {
+		if err := fb.Delete(k); err != nil && !IsNotFound(err) {
+			return errors.InternalErr(err)
+		}
+	}
+
+	b, err := tx.Bucket(mailBucket)
+	if err != nil {
+		return UnexpectedMailError(err)
+	}
+	tb, err := tx.Bucket(to)
+	if err != nil {
+		return UnexpectedMailError(err)
+	}
+
+	var pending []*service.Mail
+	err = forEach(b, func(k, v []byte) error {
+		m, err := unmarshalMail(v)
+		if err != nil {
+			return err
+		}
+		if m.Status == service.MailPending {
+			pending = append(pending, m)
+		}
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+
+	for _, m := range pending {
+		encodedID, err := m.ID.Encode()
+		if err != nil {
+			return errors.InternalErr(err)
+		}
+		if err := tb.Put(key(m, encodedID), encodedID); err != nil {
+			return errors.InternalErr(err)
+		}
+	}
+	return nil
+}
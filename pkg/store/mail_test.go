@@ -0,0 +1,210 @@
+package store_test
+
+import (
+	"bytes"
+	"context"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/ustackq/indagate/pkg/mail"
+	"github.com/ustackq/indagate/pkg/mail/mailtest"
+	"github.com/ustackq/indagate/pkg/service"
+	"github.com/ustackq/indagate/pkg/store"
+	"github.com/ustackq/indagate/pkg/store/inmem"
+	"github.com/ustackq/indagate/pkg/utils/errors"
+	"github.com/ustackq/indagate/pkg/utils/wait"
+)
+
+func TestMailQueue(t *testing.T) {
+	ctx := context.Background()
+	kv := inmem.NewKVStore()
+	s := store.NewService(kv)
+	if err := s.Init(ctx); err != nil {
+		t.Fatal(err)
+	}
+
+	srv, err := mailtest.NewServer()
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer srv.Close()
+
+	newMailer := func(s *store.Service) *mail.Mailer {
+		sender := mail.NewSMTPSender(mail.SMTPConfig{Host: srv.Host(), Port: srv.Port(), Timeout: time.Second})
+		m := mail.NewMailer(s, sender, "Indagate <noreply@example.com>")
+		m.Backoff = wait.Backoff{Duration: time.Millisecond, Factor: 2, Steps: 3}
+		return m
+	}
+	m := newMailer(s)
+
+	// each Flush attempts a mail at most once, flush runs n of them far enough
+	// apart for the backoff of the mailers to be over.
+	flush := func(m *mail.Mailer, n int) {
+		for i := 0; i < n; i++ {
+			if i > 0 {
+				time.Sleep(10 * time.Millisecond)
+			}
+			m.Flush(ctx)
+		}
+	}
+
+	// mails are stored before they are sent, with a plain text alternative.
+	sent, err := m.Send(ctx, mail.ActivateTemplate, []string{"alice@example.com"}, mail.ActivateData{
+		Username: "alice",
+		Link:     "https://example.com/activate?code=a&b",
+		Expires:  24 * time.Hour,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if sent.Status != service.MailPending || sent.Subject != "Activate your account" {
+		t.Errorf("enqueued mail = %+v, want it pending", sent)
+	}
+	if !strings.Contains(sent.Text, "https://example.com/activate?code=a&b") || !strings.Contains(sent.Text, "24 hours") || strings.Contains(sent.Text, "<p>") {
+		t.Errorf("text = %q, want the link in plain text", sent.Text)
+	}
+
+	// pending mails survive a restart.
+	restarted := store.NewService(kv)
+	if err := restarted.Init(ctx); err != nil {
+		t.Fatal(err)
+	}
+	if ms, err := restarted.PendingMails(ctx, 0); err != nil || len(ms) != 1 || ms[0].ID != sent.ID {
+		t.Fatalf("pending mails = %+v, %v, want the enqueued mail", ms, err)
+	}
+
+	// temporary failures are retried once the backoff is over, not in the same Flush.
+	srv.Fail(451)
+	retrying := newMailer(restarted)
+	retrying.Flush(ctx)
+	got, err := restarted.FindMailByID(ctx, sent.ID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got.Status != service.MailPending || got.Attempts != 1 || got.NextAttemptAt == nil {
+		t.Fatalf("mail = %+v, want it pending after a failed attempt", got)
+	}
+	flush(retrying, 2)
+	if got, err = restarted.FindMailByID(ctx, sent.ID); err != nil {
+		t.Fatal(err)
+	}
+	if got.Status != service.MailSent || got.Attempts != 1 || got.SentAt == nil {
+		t.Errorf("mail = %+v, want it sent after a failed attempt", got)
+	}
+	msgs := srv.Messages()
+	if len(msgs) != 1 || msgs[0].From != "noreply@example.com" || msgs[0].To[0] != "alice@example.com" {
+		t.Fatalf("received = %+v, want the mail to alice", msgs)
+	}
+	if !bytes.Contains(msgs[0].Data, []byte("multipart/alternative")) || !bytes.Contains(msgs[0].Data, []byte("text/html")) {
+		t.Errorf("received data = %s, want html and text parts", msgs[0].Data)
+	}
+
+	// permanent failures and exhausted retries make dead letters.
+	m = newMailer(restarted)
+	for _, codes := range [][]int{{550}, {451, 421, 452}} {
+		srv.Fail(codes...)
+		dead, err := m.Send(ctx, mail.NotifyTemplate, []string{"bob@example.com"}, mail.NotifyData{Username: "bob", Subject: "New answer", Content: "Your question was answered."})
+		if err != nil {
+			t.Fatal(err)
+		}
+		flush(m, len(codes))
+		if dead, err = restarted.FindMailByID(ctx, dead.ID); err != nil || dead.Status != service.MailDead || dead.Attempts != len(codes) {
+			t.Errorf("mail = %+v, %v, want it dead after %d attempts", dead, err, len(codes))
+		}
+	}
+
+	status := service.MailDead
+	deads, total, err := restarted.FindMails(ctx, service.MailFilter{Status: &status})
+	if err != nil || total != 2 {
+		t.Fatalf("dead letters = %+v, %d, %v, want 2", deads, total, err)
+	}
+	if _, err := restarted.RetryMail(ctx, sent.ID); errors.ErrorCode(err) != errors.Conflict {
+		t.Errorf("retry sent mail error = %v, want %s", err, errors.Conflict)
+	}
+	retried, err := restarted.RetryMail(ctx, deads[0].ID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if retried.Status != service.MailPending || retried.Attempts != 0 {
+		t.Errorf("retried mail = %+v, want it pending", retried)
+	}
+	m.Flush(ctx)
+	if got, err := restarted.FindMailByID(ctx, retried.ID); err != nil || got.Status != service.MailSent {
+		t.Errorf("retried mail = %+v, %v, want it sent", got, err)
+	}
+
+	// mails are not attempted before they are due.
+	m.Backoff.Duration = time.Hour
+	srv.Fail(451)
+	later, err := m.Send(ctx, mail.NotifyTemplate, []string{"carol@example.com"}, mail.NotifyData{Username: "carol", Subject: "New answer", Content: "Your question was answered."})
+	if err != nil {
+		t.Fatal(err)
+	}
+	flush(m, 2)
+	if got, err := restarted.FindMailByID(ctx, later.ID); err != nil || got.Status != service.MailPending || got.Attempts != 1 {
+		t.Errorf("mail = %+v, %v, want it pending after a single attempt", got, err)
+	}
+	if ms, err := restarted.PendingMails(ctx, 0); err != nil || len(ms) != 0 {
+		t.Errorf("pending mails = %+v, %v, want none due", ms, err)
+	}
+}
+
+func TestPendingMailsDueOrder(t *testing.T) {
+	ctx := context.Background()
+	s := newInmemService(t)
+
+	enqueue := func(to string) *service.Mail {
+		t.Helper()
+		m := &service.Mail{To: []string{to}, Subject: "hi", Text: "hi"}
+		if err := s.EnqueueMail(ctx, m); err != nil {
+			t.Fatal(err)
+		}
+		return m
+	}
+	ids := func(ms []*service.Mail) []service.ID {
+		out := []service.ID{}
+		for _, m := range ms {
+			out = append(out, m.ID)
+		}
+		return out
+	}
+
+	// a failed mail is due again after the mails queued before its next attempt.
+	first, second := enqueue("a@example.com"), enqueue("b@example.com")
+	time.Sleep(time.Millisecond)
+	if _, err := s.RecordMailAttempt(ctx, first.ID, service.MailAttempt{Err: "busy"}); err != nil {
+		t.Fatal(err)
+	}
+	later := enqueue("c@example.com")
+	if _, err := s.RecordMailAttempt(ctx, later.ID, service.MailAttempt{Err: "busy", RetryAfter: time.Hour}); err != nil {
+		t.Fatal(err)
+	}
+
+	want := []service.ID{second.ID, first.ID}
+	ms, err := s.PendingMails(ctx, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := ids(ms); !reflect.DeepEqual(got, want) {
+		t.Errorf("pending mails = %v, want %v", got, want)
+	}
+	if ms, err := s.PendingMails(ctx, 1); err != nil || len(ms) != 1 || ms[0].ID != second.ID {
+		t.Errorf("first pending mail = %+v, %v, want %s", ms, err, second.ID)
+	}
+
+	// the queue survives reverting and reapplying its migration.
+	if err := s.MigrateDown(ctx, 2); err != nil {
+		t.Fatal(err)
+	}
+	if err := s.MigrateUp(ctx, 0); err != nil {
+		t.Fatal(err)
+	}
+	if ms, err = s.PendingMails(ctx, 0); err != nil {
+		t.Fatal(err)
+	}
+	if got := ids(ms); !reflect.DeepEqual(got, want) {
+		t.Errorf("pending mails after migrations = %v, want %v", got, want)
+	}
+}
@@ -0,0 +1,881 @@
+package store
+
+import (
+	"context"
+	"fmt"
+	"sort"
+
+	"github.com/ustackq/indagate/pkg/service"
+	"github.com/ustackq/indagate/pkg/utils/errors"
+)
+
+var (
+	dialogBucket       = []byte("dialogsv1")
+	dialogPairIndex    = []byte("dialogpairindexv1")
+	dialogUserIndex    = []byte("dialoguserindexv1")
+	messageBucket      = []byte("messagesv1")
+	messageDialogIndex = []byte("messagedialogindexv1")
+	messageBlockBucket = []byte("messageblocksv1")
+	messageBuckets     = [][]byte{dialogBucket, dialogPairIndex, dialogUserIndex, messageBucket, messageDialogIndex, messageBlockBucket}
+)
+
+// assert Service implement service.MessageService
+var _ service.MessageService = (*Service)(nil)
+
+func (s *Service) initializeMessages(ctx context.Context, tx Impl) error {
+	for _, b := range messageBuckets {
+		if _, err := s.messageBucket(tx, b); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (s *Service) messageBucket(tx Impl, name []byte) (Bucket, error) {
+	b, err := tx.Bucket(name)
+	if err != nil {
+		return nil, UnexpectedMessageError(err)
+	}
+	return b, nil
+}
+
+// UnexpectedMessageError wraps errors raised while retrieving the message buckets.
+func UnexpectedMessageError(err error) *errors.Error {
+	return &errors.Error{
+		Code: errors.Internal,
+		Msg:  fmt.Sprintf("unexpected error retrieving message bucket; %v", err),
+		Op:   "messageBucket",
+	}
+}
+
+// dialogPairKey is the same for both directions of a dialog.
+func dialogPairKey(a, b service.ID) ([]byte, error) {
+	if b < a {
+		a, b = b, a
+	}
+	return idsKey(a, b)
+}
+
+// FindDialogByID returns a single dialog by ID.
+func (s *Service) FindDialogByID(ctx context.Context, id service.ID) (*service.Dialog, error) {
+	var d *service.Dialog
+	err := s.store.View(ctx, func(tx Impl) error {
+		dialog, err := s.findDialogByID(ctx, tx, id)
+		if err != nil {
+			return err
+		}
+		d = dialog
+		return nil
+	})
+	if err != nil {
+		return nil, &errors.Error{
+			Err: err,
+			Op:  service.OpFindDialogByID,
+		}
+	}
+	return d, nil
+}
+
+func (s *Service) findDialogByID(ctx context.Context, tx Impl, id service.ID) (*service.Dialog, error) {
+	encodedID, err := id.Encode()
+	if err != nil {
+		return nil, &errors.Error{
+			Code: errors.Invalid,
+			Err:  err,
+		}
+	}
+
+	b, err := s.messageBucket(tx, dialogBucket)
+	if err != nil {
+		return nil, err
+	}
+
+	v, err := b.Get(encodedID)
+	if IsNotFound(err) {
+		return nil, service.ErrDialogNotFound
+	}
+
+	if err != nil {
+		return nil, &errors.Error{
+			Code: errors.Internal,
+			Err:  err,
+		}
+	}
+
+	d := &service.Dialog{}
+	if err := json.Unmarshal(v, d); err != nil {
+		return nil, &errors.Error{
+			Code: errors.Internal,
+			Err:  err,
+		}
+	}
+	return d, nil
+}
+
+// findMemberDialog returns the dialog if the user takes part in it.
+func (s *Service) findMemberDialog(ctx context.Context, tx Impl, id, userID service.ID) (*service.Dialog, error) {
+	d, err := s.findDialogByID(ctx, tx, id)
+	if err != nil {
+		return nil, err
+	}
+
+	if !d.Includes(userID) {
+		return nil, service.ErrNotDialogMember
+	}
+	return d, nil
+}
+
+// FindDialogs returns the dialogs of a user most recently active first.
+func (s *Service) FindDialogs(ctx context.Context, userID service.ID, opt ...service.FindOptions) ([]*service.Dialog, int, error) {
+	ds := []*service.Dialog{}
+	err := s.store.View(ctx, func(tx Impl) error {
+		prefix, err := idsKey(userID)
+		if err != nil {
+			return err
+		}
+
+		idx, err := s.messageBucket(tx, dialogUserIndex)
+		if err != nil {
+			return err
+		}
+
+		return forEachPrefix(idx, prefix, func(_, v []byte) error {
+			var id service.ID
+			if err := id.Decode(v); err != nil {
+				return err
+			}
+
+			d, err := s.findDialogByID(ctx, tx, id)
+			if err != nil {
+				return err
+			}
+
+			// dialogs the user emptied come back with the next message.
+			if dialogCount(d, userID) > 0 || (d.LastMessageAt == nil && d.SenderID == userID) {
+				ds = append(ds, d)
+			}
+			return nil
+		})
+	})
+	if err != nil {
+		return nil, 0, &errors.Error{
+			Err: err,
+			Op:  service.OpFindDialogs,
+		}
+	}
+
+	sort.SliceStable(ds, func(i, j int) bool {
+		return ds[i].UpdatedAt.After(ds[j].UpdatedAt)
+	})
+	start, end := pageWindow(len(ds), opt...)
+	return ds[start:end], len(ds), nil
+}
+
+func dialogCount(d *service.Dialog, userID service.ID) int {
+	if userID == d.SenderID {
+		return d.SenderCount
+	}
+	return d.RecipientCount
+}
+
+// CreateDialog starts a dialog or reuses the existing dialog between both users.
+func (s *Service) CreateDialog(ctx context.Context, d *service.Dialog) error {
+	return s.store.Modify(ctx, func(tx Impl) error {
+		if err := s.createDialog(ctx, tx, d); err != nil {
+			return &errors.Error{
+				Err: err,
+				Op:  service.OpCreateDialog,
+			}
+		}
+		return nil
+	})
+}
+
+func (s *Service) createDialog(ctx context.Context, tx Impl, d *service.Dialog) error {
+	if err := d.Valid(); err != nil {
+		return err
+	}
+
+	blocked, err := s.isBlocked(ctx, tx, d.RecipientID, d.SenderID)
+	if err != nil {
+		return err
+	}
+	if blocked {
+		return service.ErrUserBlocked
+	}
+
+	pairKey, err := dialogPairKey(d.SenderID, d.RecipientID)
+	if err != nil {
+		return err
+	}
+
+	pairs, err := s.messageBucket(tx, dialogPairIndex)
+	if err != nil {
+		return err
+	}
+
+	v, err := pairs.Get(pairKey)
+	if err == nil {
+		var id service.ID
+		if err := id.Decode(v); err != nil {
+			return err
+		}
+
+		existing, err := s.findDialogByID(ctx, tx, id)
+		if err != nil {
+			return err
+		}
+		*d = *existing
+		return nil
+	}
+	if !IsNotFound(err) {
+		return errors.InternalErr(err)
+	}
+
+	d.ID = s.IDGenerator.ID()
+	d.SenderUnread, d.RecipientUnread = 0, 0
+	d.SenderCount, d.RecipientCount = 0, 0
+	d.LastMessageAt = nil
+	d.CreatedAt = s.time()
+	d.UpdatedAt = d.CreatedAt
+	if err := s.putDialog(ctx, tx, d); err != nil {
+		return err
+	}
+	return s.indexDialog(ctx, tx, d)
+}
+
+func (s *Service) putDialog(ctx context.Context, tx Impl, d *service.Dialog) error {
+	v, err := json.Marshal(d)
+	if err != nil {
+		return &errors.Error{
+			Code: errors.Invalid,
+			Err:  err,
+		}
+	}
+
+	encodedID, err := d.ID.Encode()
+	if err != nil {
+		return &errors.Error{
+			Code: errors.Invalid,
+			Err:  err,
+		}
+	}
+
+	b, err := s.messageBucket(tx, dialogBucket)
+	if err != nil {
+		return err
+	}
+
+	if err := b.Put(encodedID, v); err != nil {
+		return errors.InternalErr(err)
+	}
+	return nil
+}
+
+// indexDialog indexes the dialog by pair of users and by each of them.
+func (s *Service) indexDialog(ctx context.Context, tx Impl, d *service.Dialog) error {
+	encodedID, err := d.ID.Encode()
+	if err != nil {
+		return &errors.Error{
+			Code: errors.Invalid,
+			Err:  err,
+		}
+	}
+
+	pairKey, err := dialogPairKey(d.SenderID, d.RecipientID)
+	if err != nil {
+		return err
+	}
+
+	pairs, err := s.messageBucket(tx, dialogPairIndex)
+	if err != nil {
+		return err
+	}
+
+	if err := pairs.Put(pairKey, encodedID); err != nil {
+		return errors.InternalErr(err)
+	}
+
+	users, err := s.messageBucket(tx, dialogUserIndex)
+	if err != nil {
+		return err
+	}
+
+	for _, userID := range []service.ID{d.SenderID, d.RecipientID} {
+		key, err := idsKey(userID, d.ID)
+		if err != nil {
+			return err
+		}
+		if err := users.Put(key, encodedID); err != nil {
+			return errors.InternalErr(err)
+		}
+	}
+	return nil
+}
+
+// FindMessages returns the messages of the dialog the user did not remove, oldest first.
+func (s *Service) FindMessages(ctx context.Context, dialogID, userID service.ID, opt ...service.FindOptions) ([]*service.Message, int, error) {
+	ms := []*service.Message{}
+	err := s.store.View(ctx, func(tx Impl) error {
+		if _, err := s.findMemberDialog(ctx, tx, dialogID, userID); err != nil {
+			return err
+		}
+
+		return s.forEachDialogMessage(ctx, tx, dialogID, func(m *service.Message) {
+			if m.VisibleTo(userID) {
+				ms = append(ms, m)
+			}
+		})
+	})
+	if err != nil {
+		return nil, 0, &errors.Error{
+			Err: err,
+			Op:  service.OpFindMessages,
+		}
+	}
+
+	sortMessages(ms)
+	start, end := pageWindow(len(ms), opt...)
+	return ms[start:end], len(ms), nil
+}
+
+func sortMessages(ms []*service.Message) {
+	sort.SliceStable(ms, func(i, j int) bool {
+		return ms[i].CreatedAt.Before(ms[j].CreatedAt)
+	})
+}
+
+func (s *Service) forEachDialogMessage(ctx context.Context, tx Impl, dialogID service.ID, fn func(*service.Message)) error {
+	prefix, err := idsKey(dialogID)
+	if err != nil {
+		return err
+	}
+
+	idx, err := s.messageBucket(tx, messageDialogIndex)
+	if err != nil {
+		return err
+	}
+
+	return forEachPrefix(idx, prefix, func(_, v []byte) error {
+		var id service.ID
+		if err := id.Decode(v); err != nil {
+			return err
+		}
+
+		m, err := s.findMessageByID(ctx, tx, id)
+		if err != nil {
+			return err
+		}
+		fn(m)
+		return nil
+	})
+}
+
+func (s *Service) findMessageByID(ctx context.Context, tx Impl, id service.ID) (*service.Message, error) {
+	encodedID, err := id.Encode()
+	if err != nil {
+		return nil, &errors.Error{
+			Code: errors.Invalid,
+			Err:  err,
+		}
+	}
+
+	b, err := s.messageBucket(tx, messageBucket)
+	if err != nil {
+		return nil, err
+	}
+
+	v, err := b.Get(encodedID)
+	if IsNotFound(err) {
+		return nil, service.ErrMessageNotFound
+	}
+
+	if err != nil {
+		return nil, &errors.Error{
+			Code: errors.Internal,
+			Err:  err,
+		}
+	}
+
+	m := &service.Message{}
+	if err := json.Unmarshal(v, m); err != nil {
+		return nil, &errors.Error{
+			Code: errors.Internal,
+			Err:  err,
+		}
+	}
+	return m, nil
+}
+
+// SendMessage adds the message to its dialog, the recipient's unread counters are incremented.
+func (s *Service) SendMessage(ctx context.Context, m *service.Message) error {
+	return s.store.Modify(ctx, func(tx Impl) error {
+		if err := s.sendMessage(ctx, tx, m); err != nil {
+			return &errors.Error{
+				Err: err,
+				Op:  service.OpSendMessage,
+			}
+		}
+		return nil
+	})
+}
+
+func (s *Service) sendMessage(ctx context.Context, tx Impl, m *service.Message) error {
+	if err := m.Valid(); err != nil {
+		return err
+	}
+
+	d, err := s.findMemberDialog(ctx, tx, m.DialogID, m.SenderID)
+	if err != nil {
+		return err
+	}
+
+	recipientID := d.Peer(m.SenderID)
+	blocked, err := s.isBlocked(ctx, tx, recipientID, m.SenderID)
+	if err != nil {
+		return err
+	}
+	if blocked {
+		return service.ErrUserBlocked
+	}
+
+	err = s.filterUnreviewedContent(ctx, &service.Content{
+		Kind:   service.MessageContent,
+		UserID: m.SenderID,
+		Texts:  []string{m.Content},
+	})
+	if err != nil {
+		return err
+	}
+
+	now := s.time()
+	m.ID = s.IDGenerator.ID()
+	m.SenderRemoved, m.RecipientRemoved = false, false
+	m.ReadAt = nil
+	m.CreatedAt = now
+	if err := s.putMessage(ctx, tx, m); err != nil {
+		return err
+	}
+
+	if err := s.indexMessage(ctx, tx, m); err != nil {
+		return err
+	}
+
+	d.SenderCount++
+	d.RecipientCount++
+	if recipientID == d.SenderID {
+		d.SenderUnread++
+	} else {
+		d.RecipientUnread++
+	}
+	d.LastMessageAt = &now
+	d.UpdatedAt = now
+	if err := s.putDialog(ctx, tx, d); err != nil {
+		return err
+	}
+	return s.adjustInboxUnread(ctx, tx, recipientID, 1)
+}
+
+func (s *Service) putMessage(ctx context.Context, tx Impl, m *service.Message) error {
+	v, err := json.Marshal(m)
+	if err != nil {
+		return &errors.Error{
+			Code: errors.Invalid,
+			Err:  err,
+		}
+	}
+
+	encodedID, err := m.ID.Encode()
+	if err != nil {
+		return &errors.Error{
+			Code: errors.Invalid,
+			Err:  err,
+		}
+	}
+
+	b, err := s.messageBucket(tx, messageBucket)
+	if err != nil {
+		return err
+	}
+
+	if err := b.Put(encodedID, v); err != nil {
+		return errors.InternalErr(err)
+	}
+	return nil
+}
+
+func (s *Service) indexMessage(ctx context.Context, tx Impl, m *service.Message) error {
+	key, err := idsKey(m.DialogID, m.ID)
+	if err != nil {
+		return err
+	}
+
+	idx, err := s.messageBucket(tx, messageDialogIndex)
+	if err != nil {
+		return err
+	}
+
+	encodedID, err := m.ID.Encode()
+	if err != nil {
+		return &errors.Error{
+			Code: errors.Invalid,
+			Err:  err,
+		}
+	}
+
+	if err := idx.Put(key, encodedID); err != nil {
+		return errors.InternalErr(err)
+	}
+	return nil
+}
+
+// MarkDialogRead marks all messages of the dialog received by the user as read.
+func (s *Service) MarkDialogRead(ctx context.Context, id, userID service.ID) (*service.Dialog, error) {
+	var d *service.Dialog
+	err := s.store.Modify(ctx, func(tx Impl) error {
+		dialog, err := s.markDialogRead(ctx, tx, id, userID)
+		if err != nil {
+			return err
+		}
+		d = dialog
+		return nil
+	})
+	if err != nil {
+		return nil, &errors.Error{
+			Err: err,
+			Op:  service.OpMarkDialogRead,
+		}
+	}
+	return d, nil
+}
+
+func (s *Service) markDialogRead(ctx context.Context, tx Impl, id, userID service.ID) (*service.Dialog, error) {
+	d, err := s.findMemberDialog(ctx, tx, id, userID)
+	if err != nil {
+		return nil, err
+	}
+
+	unread := d.Unread(userID)
+	if unread == 0 {
+		return d, nil
+	}
+
+	now := s.time()
+	err = s.forEachDialogMessage(ctx, tx, id, func(m *service.Message) {
+		if m.SenderID != userID && m.ReadAt == nil {
+			m.ReadAt = &now
+			err = s.putMessage(ctx, tx, m)
+		}
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	if userID == d.SenderID {
+		d.SenderUnread = 0
+	} else {
+		d.RecipientUnread = 0
+	}
+	if err := s.putDialog(ctx, tx, d); err != nil {
+		return nil, err
+	}
+
+	if err := s.adjustInboxUnread(ctx, tx, userID, -unread); err != nil {
+		return nil, err
+	}
+	return d, nil
+}
+
+// DeleteMessage removes a message for the user, the other side keeps it.
+func (s *Service) DeleteMessage(ctx context.Context, id, userID service.ID) error {
+	return s.store.Modify(ctx, func(tx Impl) error {
+		m, err := s.findMessageByID(ctx, tx, id)
+		if err != nil {
+			return &errors.Error{
+				Err: err,
+				Op:  service.OpDeleteMessage,
+			}
+		}
+
+		d, err := s.findMemberDialog(ctx, tx, m.DialogID, userID)
+		if err != nil {
+			return &errors.Error{
+				Err: err,
+				Op:  service.OpDeleteMessage,
+			}
+		}
+
+		if !m.VisibleTo(userID) {
+			return &errors.Error{
+				Err: service.ErrMessageNotFound,
+				Op:  service.OpDeleteMessage,
+			}
+		}
+
+		if err := s.removeMessage(ctx, tx, d, m, userID); err != nil {
+			return &errors.Error{
+				Err: err,
+				Op:  service.OpDeleteMessage,
+			}
+		}
+		return s.putDialog(ctx, tx, d)
+	})
+}
+
+// removeMessage hides the message from the user and updates the counters of
+// the dialog, the caller stores the dialog.
+func (s *Service) removeMessage(ctx context.Context, tx Impl, d *service.Dialog, m *service.Message, userID service.ID) error {
+	if userID == m.SenderID {
+		m.SenderRemoved = true
+	} else {
+		m.RecipientRemoved = true
+	}
+
+	if userID == d.SenderID {
+		d.SenderCount--
+	} else {
+		d.RecipientCount--
+	}
+
+	// removing an unread message reads it.
+	if m.SenderID != userID && m.ReadAt == nil {
+		now := s.time()
+		m.ReadAt = &now
+		if userID == d.SenderID {
+			d.SenderUnread--
+		} else {
+			d.RecipientUnread--
+		}
+		if err := s.adjustInboxUnread(ctx, tx, userID, -1); err != nil {
+			return err
+		}
+	}
+
+	if m.SenderRemoved && m.RecipientRemoved {
+		return s.purgeMessage(ctx, tx, m)
+	}
+	return s.putMessage(ctx, tx, m)
+}
+
+// purgeMessage deletes a message both sides removed.
+func (s *Service) purgeMessage(ctx context.Context, tx Impl, m *service.Message) error {
+	key, err := idsKey(m.DialogID, m.ID)
+	if err != nil {
+		return err
+	}
+
+	idx, err := s.messageBucket(tx, messageDialogIndex)
+	if err != nil {
+		return err
+	}
+
+	if err := idx.Delete(key); err != nil {
+		return errors.WrapperErr(err)
+	}
+
+	encodedID, err := m.ID.Encode()
+	if err != nil {
+		return &errors.Error{
+			Code: errors.Invalid,
+			Err:  err,
+		}
+	}
+
+	b, err := s.messageBucket(tx, messageBucket)
+	if err != nil {
+		return err
+	}
+
+	if err := b.Delete(encodedID); err != nil {
+		return errors.WrapperErr(err)
+	}
+	return nil
+}
+
+// DeleteDialog removes all messages of the dialog for the user.
+func (s *Service) DeleteDialog(ctx context.Context, id, userID service.ID) error {
+	return s.store.Modify(ctx, func(tx Impl) error {
+		if err := s.deleteDialog(ctx, tx, id, userID); err != nil {
+			return &errors.Error{
+				Err: err,
+				Op:  service.OpDeleteDialog,
+			}
+		}
+		return nil
+	})
+}
+
+func (s *Service) deleteDialog(ctx context.Context, tx Impl, id, userID service.ID) error {
+	d, err := s.findMemberDialog(ctx, tx, id, userID)
+	if err != nil {
+		return err
+	}
+
+	ms := []*service.Message{}
+	err = s.forEachDialogMessage(ctx, tx, id, func(m *service.Message) {
+		if m.VisibleTo(userID) {
+			ms = append(ms, m)
+		}
+	})
+	if err != nil {
+		return err
+	}
+
+	for _, m := range ms {
+		if err := s.removeMessage(ctx, tx, d, m, userID); err != nil {
+			return err
+		}
+	}
+	return s.putDialog(ctx, tx, d)
+}
+
+// adjustInboxUnread adds delta to the unread messages of the user, users
+// which have been deleted are skipped.
+func (s *Service) adjustInboxUnread(ctx context.Context, tx Impl, userID service.ID, delta int) error {
+	if delta == 0 {
+		return nil
+	}
+
+	u, err := s.findUserByID(ctx, tx, userID)
+	if err == ErrUserNotFound {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+
+	u.InboxUnread += delta
+	if u.InboxUnread < 0 {
+		u.InboxUnread = 0
+	}
+	return s.putUser(ctx, tx, u)
+}
+
+func (s *Service) isBlocked(ctx context.Context, tx Impl, userID, blockedID service.ID) (bool, error) {
+	key, err := idsKey(userID, blockedID)
+	if err != nil {
+		return false, err
+	}
+
+	b, err := s.messageBucket(tx, messageBlockBucket)
+	if err != nil {
+		return false, err
+	}
+
+	_, err = b.Get(key)
+	if IsNotFound(err) {
+		return false, nil
+	}
+	if err != nil {
+		return false, errors.InternalErr(err)
+	}
+	return true, nil
+}
+
+// FindBlockedUsers returns the users blocked by the user.
+func (s *Service) FindBlockedUsers(ctx context.Context, userID service.ID) ([]*service.Block, error) {
+	bs := []*service.Block{}
+	err := s.store.View(ctx, func(tx Impl) error {
+		prefix, err := idsKey(userID)
+		if err != nil {
+			return err
+		}
+
+		b, err := s.messageBucket(tx, messageBlockBucket)
+		if err != nil {
+			return err
+		}
+
+		return forEachPrefix(b, prefix, func(_, v []byte) error {
+			block := &service.Block{}
+			if err := json.Unmarshal(v, block); err != nil {
+				return &errors.Error{
+					Code: errors.Internal,
+					Err:  err,
+				}
+			}
+			bs = append(bs, block)
+			return nil
+		})
+	})
+	if err != nil {
+		return nil, &errors.Error{
+			Err: err,
+			Op:  service.OpFindBlockedUsers,
+		}
+	}
+	return bs, nil
+}
+
+// BlockUser stops the user from receiving messages of the blocked user.
+func (s *Service) BlockUser(ctx context.Context, userID, blockedID service.ID) (*service.Block, error) {
+	block := &service.Block{
+		UserID:    userID,
+		BlockedID: blockedID,
+		CreatedAt: s.time(),
+	}
+	err := s.store.Modify(ctx, func(tx Impl) error {
+		if userID == blockedID {
+			return &errors.Error{
+				Code: errors.Invalid,
+				Msg:  "users can not block themselves",
+			}
+		}
+		return s.putBlock(ctx, tx, block)
+	})
+	if err != nil {
+		return nil, &errors.Error{
+			Err: err,
+			Op:  service.OpBlockUser,
+		}
+	}
+	return block, nil
+}
+
+func (s *Service) putBlock(ctx context.Context, tx Impl, block *service.Block) error {
+	v, err := json.Marshal(block)
+	if err != nil {
+		return &errors.Error{
+			Code: errors.Invalid,
+			Err:  err,
+		}
+	}
+
+	key, err := idsKey(block.UserID, block.BlockedID)
+	if err != nil {
+		return err
+	}
+
+	b, err := s.messageBucket(tx, messageBlockBucket)
+	if err != nil {
+		return err
+	}
+
+	if err := b.Put(key, v); err != nil {
+		return errors.InternalErr(err)
+	}
+	return nil
+}
+
+// UnblockUser reverts BlockUser.
+func (s *Service) UnblockUser(ctx context.Context, userID, blockedID service.ID) error {
+	return s.store.Modify(ctx, func(tx Impl) error {
+		key, err := idsKey(userID, blockedID)
+		if err != nil {
+			return err
+		}
+
+		b, err := s.messageBucket(tx, messageBlockBucket)
+		if err != nil {
+			return err
+		}
+
+		if err := b.Delete(key); err != nil {
+			return &errors.Error{
+				Err: errors.WrapperErr(err),
+				Op:  service.OpUnblockUser,
+			}
+		}
+		return nil
+	})
+}
@@ -0,0 +1,100 @@
+package store_test
+
+import (
+	"context"
+	"testing"
+
+	"github.com/ustackq/indagate/pkg/service"
+	"github.com/ustackq/indagate/pkg/utils/errors"
+)
+
+func TestMessages(t *testing.T) {
+	ctx := context.Background()
+	s := newInmemService(t)
+
+	alice, bob := service.ID(1), service.ID(2)
+	d := &service.Dialog{SenderID: alice, RecipientID: bob}
+	if err := s.CreateDialog(ctx, d); err != nil {
+		t.Fatal(err)
+	}
+
+	// the dialog between both users is reused whoever starts it.
+	reused := &service.Dialog{SenderID: bob, RecipientID: alice}
+	if err := s.CreateDialog(ctx, reused); err != nil {
+		t.Fatal(err)
+	}
+	if reused.ID != d.ID {
+		t.Fatalf("dialog = %s, want %s", reused.ID, d.ID)
+	}
+
+	send := func(senderID service.ID, content string) *service.Message {
+		t.Helper()
+		m := &service.Message{DialogID: d.ID, SenderID: senderID, Content: content}
+		if err := s.SendMessage(ctx, m); err != nil {
+			t.Fatal(err)
+		}
+		return m
+	}
+
+	first := send(alice, "hello")
+	send(alice, "are you there?")
+	send(bob, "yes")
+
+	got, err := s.FindDialogByID(ctx, d.ID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got.Unread(bob) != 2 || got.Unread(alice) != 1 {
+		t.Errorf("unread = %d/%d, want 2/1", got.Unread(bob), got.Unread(alice))
+	}
+
+	if got, err = s.MarkDialogRead(ctx, d.ID, bob); err != nil {
+		t.Fatal(err)
+	}
+	if got.Unread(bob) != 0 {
+		t.Errorf("unread = %d after reading, want 0", got.Unread(bob))
+	}
+
+	// outsiders can not read the dialog.
+	if _, _, err := s.FindMessages(ctx, d.ID, service.ID(3)); errors.ErrorCode(err) != errors.Forbidden {
+		t.Errorf("FindMessages() error = %v, want forbidden", err)
+	}
+
+	// removing a message only hides it from one side.
+	if err := s.DeleteMessage(ctx, first.ID, alice); err != nil {
+		t.Fatal(err)
+	}
+	if _, n, err := s.FindMessages(ctx, d.ID, alice); err != nil || n != 2 {
+		t.Errorf("messages of the sender = %d, %v, want 2", n, err)
+	}
+	if _, n, err := s.FindMessages(ctx, d.ID, bob); err != nil || n != 3 {
+		t.Errorf("messages of the recipient = %d, %v, want 3", n, err)
+	}
+
+	// deleting the dialog empties it for bob only.
+	if err := s.DeleteDialog(ctx, d.ID, bob); err != nil {
+		t.Fatal(err)
+	}
+	if _, n, err := s.FindDialogs(ctx, bob); err != nil || n != 0 {
+		t.Errorf("dialogs of bob = %d, %v, want 0", n, err)
+	}
+	if _, n, err := s.FindDialogs(ctx, alice); err != nil || n != 1 {
+		t.Errorf("dialogs of alice = %d, %v, want 1", n, err)
+	}
+
+	if _, err := s.BlockUser(ctx, bob, alice); err != nil {
+		t.Fatal(err)
+	}
+	m := &service.Message{DialogID: d.ID, SenderID: alice, Content: "please"}
+	if err := s.SendMessage(ctx, m); errors.ErrorCode(err) != errors.Forbidden {
+		t.Fatalf("SendMessage() error = %v, want forbidden", err)
+	}
+
+	if err := s.UnblockUser(ctx, bob, alice); err != nil {
+		t.Fatal(err)
+	}
+	send(alice, "please")
+	if _, n, err := s.FindDialogs(ctx, bob); err != nil || n != 1 {
+		t.Errorf("dialogs of bob = %d, %v, want 1 after a new message", n, err)
+	}
+}
@@ -0,0 +1,354 @@
+package store
+
+import (
+	"context"
+	"encoding/binary"
+	"fmt"
+	"time"
+
+	"go.uber.org/zap"
+
+	"github.com/ustackq/indagate/pkg/service"
+	"github.com/ustackq/indagate/pkg/utils/errors"
+)
+
+var (
+	migrationBucket = []byte("migrationsv1")
+)
+
+// Migration is a versioned change of the data kept in the store, every
+// applied migration is recorded in the migration bucket.
+type Migration struct {
+	// ID orders the migrations, it must never be reused.
+	ID   uint64
+	Name string
+	Up   func(ctx context.Context, tx Impl) error
+	// Down reverts Up, migrations without Down can't be reverted.
+	Down func(ctx context.Context, tx Impl) error
+}
+
+// MigrationState is a migration together with the time it was applied.
+type MigrationState struct {
+	ID        uint64     `json:"id"`
+	Name      string     `json:"name"`
+	AppliedAt *time.Time `json:"appliedAt,omitempty"`
+}
+
+// Migrations is the registry of all the migrations of the store, ordered by ID.
+var Migrations = []Migration{
+	{
+		ID:   1,
+		Name: "add roles to user resource mappings",
+		Up:   addURMRoles,
+		Down: removeURMRoles,
+	},
+	{
+		ID:   2,
+		Name: "count the published posts of users",
+		Up:   countPosts,
+		// the counters are kept up to date as posts are published, they are left in place.
+		Down: func(ctx context.Context, tx Impl) error { return nil },
+	},
+	{
+		ID:   3,
+		Name: "index pending mails by due time",
+		Up:   indexMailsByDueTime,
+		Down: indexMailsByID,
+	},
+}
+
+func (s *Service) initializeMigrations(ctx context.Context, tx Impl) error {
+	if _, err := s.migrationBucket(tx); err != nil {
+		return err
+	}
+	return nil
+}
+
+func (s *Service) migrationBucket(tx Impl) (Bucket, error) {
+	b, err := tx.Bucket(migrationBucket)
+	if err != nil {
+		return nil, UnexpectedMigrationError(err)
+	}
+	return b, nil
+}
+
+// UnexpectedMigrationError wraps errors raised while retrieving the migration bucket.
+func UnexpectedMigrationError(err error) *errors.Error {
+	return &errors.Error{
+		Code: errors.Internal,
+		Msg:  fmt.Sprintf("unexpected error retrieving migration bucket; %v", err),
+		Op:   "migrationBucket",
+	}
+}
+
+func migrationKey(id uint64) []byte {
+	key := make([]byte, 8)
+	binary.BigEndian.PutUint64(key, id)
+	return key
+}
+
+// validateMigrations ensures the migrations are ordered by unique IDs.
+func validateMigrations(ms []Migration) error {
+	var last uint64
+	for _, m := range ms {
+		if m.ID <= last {
+			return &errors.Error{
+				Code: errors.Invalid,
+				Msg:  fmt.Sprintf("migration %d %q is out of order", m.ID, m.Name),
+			}
+		}
+		if m.Up == nil {
+			return &errors.Error{
+				Code: errors.Invalid,
+				Msg:  fmt.Sprintf("migration %d %q has no up function", m.ID, m.Name),
+			}
+		}
+		last = m.ID
+	}
+	return nil
+}
+
+// MigrationStatus returns the state of every registered migration.
+func (s *Service) MigrationStatus(ctx context.Context) ([]*MigrationState, error) {
+	var states []*MigrationState
+	err := s.store.View(ctx, func(tx Impl) error {
+		applied, err := s.appliedMigrations(ctx, tx)
+		if err != nil {
+			return err
+		}
+
+		for _, m := range s.Migrations {
+			state := &MigrationState{
+				ID:   m.ID,
+				Name: m.Name,
+			}
+			if a, ok := applied[m.ID]; ok {
+				state.AppliedAt = a.AppliedAt
+			}
+			states = append(states, state)
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, &errors.Error{
+			Err: err,
+			Op:  "MigrationStatus",
+		}
+	}
+	return states, nil
+}
+
+func (s *Service) appliedMigrations(ctx context.Context, tx Impl) (map[uint64]*MigrationState, error) {
+	b, err := s.migrationBucket(tx)
+	if err != nil {
+		return nil, err
+	}
+
+	cur, err := b.Cursor()
+	if err != nil {
+		return nil, err
+	}
+
+	applied := map[uint64]*MigrationState{}
+	for k, v := cur.First(); k != nil; k, v = cur.Next() {
+		state := &MigrationState{}
+		if err := json.Unmarshal(v, state); err != nil {
+			return nil, &errors.Error{
+				Code: errors.Internal,
+				Err:  err,
+			}
+		}
+		applied[state.ID] = state
+	}
+	return applied, nil
+}
+
+// MigrateUp applies the pending migrations up to and including target in order,
+// a zero target applies all of them. Every migration runs in a transaction of its own.
+func (s *Service) MigrateUp(ctx context.Context, target uint64) error {
+	if err := validateMigrations(s.Migrations); err != nil {
+		return err
+	}
+
+	for _, m := range s.Migrations {
+		if target != 0 && m.ID > target {
+			break
+		}
+
+		m := m
+		err := s.store.Modify(ctx, func(tx Impl) error {
+			applied, err := s.appliedMigrations(ctx, tx)
+			if err != nil {
+				return err
+			}
+			if _, ok := applied[m.ID]; ok {
+				return nil
+			}
+
+			s.Logger.Info("Applying migration", zap.Uint64("id", m.ID), zap.String("name", m.Name))
+			if err := m.Up(ctx, tx); err != nil {
+				return err
+			}
+			return s.putMigrationState(ctx, tx, m)
+		})
+		if err != nil {
+			return &errors.Error{
+				Err: err,
+				Msg: fmt.Sprintf("failed to apply migration %d %q", m.ID, m.Name),
+				Op:  "MigrateUp",
+			}
+		}
+	}
+	return nil
+}
+
+// MigrateDown reverts the applied migrations with an ID greater than target, newest first.
+func (s *Service) MigrateDown(ctx context.Context, target uint64) error {
+	if err := validateMigrations(s.Migrations); err != nil {
+		return err
+	}
+
+	for i := len(s.Migrations) - 1; i >= 0; i-- {
+		m := s.Migrations[i]
+		if m.ID <= target {
+			break
+		}
+
+		err := s.store.Modify(ctx, func(tx Impl) error {
+			applied, err := s.appliedMigrations(ctx, tx)
+			if err != nil {
+				return err
+			}
+			if _, ok := applied[m.ID]; !ok {
+				return nil
+			}
+
+			if m.Down == nil {
+				return &errors.Error{
+					Code: errors.Conflict,
+					Msg:  fmt.Sprintf("migration %d %q can't be reverted", m.ID, m.Name),
+				}
+			}
+
+			s.Logger.Info("Reverting migration", zap.Uint64("id", m.ID), zap.String("name", m.Name))
+			if err := m.Down(ctx, tx); err != nil {
+				return err
+			}
+			return s.deleteMigrationState(ctx, tx, m)
+		})
+		if err != nil {
+			return &errors.Error{
+				Err: err,
+				Msg: fmt.Sprintf("failed to revert migration %d %q", m.ID, m.Name),
+				Op:  "MigrateDown",
+			}
+		}
+	}
+	return nil
+}
+
+func (s *Service) putMigrationState(ctx context.Context, tx Impl, m Migration) error {
+	now := s.time()
+	v, err := json.Marshal(&MigrationState{
+		ID:        m.ID,
+		Name:      m.Name,
+		AppliedAt: &now,
+	})
+	if err != nil {
+		return &errors.Error{
+			Code: errors.Internal,
+			Err:  err,
+		}
+	}
+
+	b, err := s.migrationBucket(tx)
+	if err != nil {
+		return err
+	}
+
+	if err := b.Put(migrationKey(m.ID), v); err != nil {
+		return errors.InternalErr(err)
+	}
+	return nil
+}
+
+func (s *Service) deleteMigrationState(ctx context.Context, tx Impl, m Migration) error {
+	b, err := s.migrationBucket(tx)
+	if err != nil {
+		return err
+	}
+
+	if err := b.Delete(migrationKey(m.ID)); err != nil {
+		return errors.InternalErr(err)
+	}
+	return nil
+}
+
+// addURMRoles stores the role implied by the user type on the mappings created before roles existed.
+func addURMRoles(ctx context.Context, tx Impl) error {
+	return rewriteURMs(tx, func(m *service.UserResourceMapping) bool {
+		if m.Role != "" || m.ResourceType != service.OrgsResourceType {
+			return false
+		}
+		m.Role = service.MappingRole(m)
+		return true
+	})
+}
+
+// removeURMRoles only clears the roles addURMRoles sets, the mappings of users
+// given another role since keep it as there is no way to tell it without a role.
+func removeURMRoles(ctx context.Context, tx Impl) error {
+	return rewriteURMs(tx, func(m *service.UserResourceMapping) bool {
+		if m.Role == "" || m.ResourceType != service.OrgsResourceType {
+			return false
+		}
+
+		def := *m
+		def.Role = ""
+		if m.Role != service.MappingRole(&def) {
+			return false
+		}
+		m.Role = ""
+		return true
+	})
+}
+
+// rewriteURMs stores every mapping changed by fn.
+func rewriteURMs(tx Impl, fn func(m *service.UserResourceMapping) bool) error {
+	b, err := tx.Bucket(urmBucket)
+	if err != nil {
+		return UnexpectedURMError(err)
+	}
+
+	cur, err := b.Cursor()
+	if err != nil {
+		return err
+	}
+
+	changed := map[string][]byte{}
+	for k, v := cur.First(); k != nil; k, v = cur.Next() {
+		m, err := unmarshalURM(v)
+		if err != nil {
+			return err
+		}
+		if !fn(m) {
+			continue
+		}
+
+		v, err := json.Marshal(m)
+		if err != nil {
+			return &errors.Error{
+				Code: errors.Internal,
+				Err:  err,
+			}
+		}
+		changed[string(k)] = v
+	}
+
+	for k, v := range changed {
+		if err := b.Put([]byte(k), v); err != nil {
+			return errors.InternalErr(err)
+		}
+	}
+	return nil
+}
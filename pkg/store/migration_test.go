@@ -0,0 +1,160 @@
+package store_test
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"reflect"
+	"testing"
+
+	"github.com/ustackq/indagate/pkg/service"
+	"github.com/ustackq/indagate/pkg/store"
+	"github.com/ustackq/indagate/pkg/store/inmem"
+	ierrors "github.com/ustackq/indagate/pkg/utils/errors"
+)
+
+func TestMigrations(t *testing.T) {
+	ctx := context.Background()
+
+	var calls []string
+	migration := func(id uint64) store.Migration {
+		return store.Migration{
+			ID:   id,
+			Name: fmt.Sprintf("migration %d", id),
+			Up: func(ctx context.Context, tx store.Impl) error {
+				calls = append(calls, fmt.Sprintf("up %d", id))
+				return nil
+			},
+			Down: func(ctx context.Context, tx store.Impl) error {
+				calls = append(calls, fmt.Sprintf("down %d", id))
+				return nil
+			},
+		}
+	}
+
+	s := store.NewService(inmem.NewKVStore(), store.ServiceConfig{SkipMigrations: true})
+	s.Migrations = []store.Migration{migration(1), migration(2), migration(3)}
+	if err := s.Init(ctx); err != nil {
+		t.Fatal(err)
+	}
+	if len(calls) != 0 {
+		t.Fatalf("Init applied %v, want no migration", calls)
+	}
+
+	applied := func() []uint64 {
+		t.Helper()
+		states, err := s.MigrationStatus(ctx)
+		if err != nil {
+			t.Fatal(err)
+		}
+		ids := []uint64{}
+		for _, state := range states {
+			if state.AppliedAt != nil {
+				ids = append(ids, state.ID)
+			}
+		}
+		return ids
+	}
+
+	for _, tt := range []struct {
+		name    string
+		migrate func() error
+		calls   []string
+		applied []uint64
+	}{
+		{name: "up to target", migrate: func() error { return s.MigrateUp(ctx, 2) }, calls: []string{"up 1", "up 2"}, applied: []uint64{1, 2}},
+		{name: "up", migrate: func() error { return s.MigrateUp(ctx, 0) }, calls: []string{"up 3"}, applied: []uint64{1, 2, 3}},
+		{name: "up again", migrate: func() error { return s.MigrateUp(ctx, 0) }, calls: []string{}, applied: []uint64{1, 2, 3}},
+		{name: "down to target", migrate: func() error { return s.MigrateDown(ctx, 1) }, calls: []string{"down 3", "down 2"}, applied: []uint64{1}},
+		{name: "down again", migrate: func() error { return s.MigrateDown(ctx, 1) }, calls: []string{}, applied: []uint64{1}},
+		{name: "down", migrate: func() error { return s.MigrateDown(ctx, 0) }, calls: []string{"down 1"}, applied: []uint64{}},
+	} {
+		calls = []string{}
+		if err := tt.migrate(); err != nil {
+			t.Fatalf("%s: %v", tt.name, err)
+		}
+		if !reflect.DeepEqual(calls, tt.calls) {
+			t.Errorf("%s: calls = %v, want %v", tt.name, calls, tt.calls)
+		}
+		if ids := applied(); !reflect.DeepEqual(ids, tt.applied) {
+			t.Errorf("%s: applied = %v, want %v", tt.name, ids, tt.applied)
+		}
+	}
+
+	// a failed migration is not recorded and stops the ones after it.
+	errFail := errors.New("fail")
+	failing := migration(2)
+	failing.Up = func(ctx context.Context, tx store.Impl) error { return errFail }
+	s.Migrations = []store.Migration{migration(1), failing, migration(3)}
+	calls = []string{}
+	if err := s.MigrateUp(ctx, 0); err == nil {
+		t.Fatal("MigrateUp with a failing migration succeeded")
+	}
+	if ids := applied(); !reflect.DeepEqual(ids, []uint64{1}) {
+		t.Errorf("applied after failure = %v, want [1]", ids)
+	}
+
+	// migrations without Down can not be reverted.
+	irreversible := migration(1)
+	irreversible.Down = nil
+	s.Migrations = []store.Migration{irreversible}
+	if err := s.MigrateDown(ctx, 0); ierrors.ErrorCode(err) != ierrors.Conflict {
+		t.Errorf("revert irreversible migration error = %v, want %s", err, ierrors.Conflict)
+	}
+
+	s.Migrations = []store.Migration{migration(2), migration(1)}
+	if err := s.MigrateUp(ctx, 0); ierrors.ErrorCode(err) != ierrors.Invalid {
+		t.Errorf("migrate out of order error = %v, want %s", err, ierrors.Invalid)
+	}
+}
+
+func TestURMRolesMigration(t *testing.T) {
+	ctx := context.Background()
+	s := newInmemService(t)
+
+	archive := `{"kind":"header","data":{"format":1}}
+{"kind":"user","data":{"id":2,"name":"alice"}}`
+	if _, err := s.Import(ctx, bytes.NewReader([]byte(archive))); err != nil {
+		t.Fatal(err)
+	}
+
+	user, moderated, joined := service.ID(2), service.ID(10), service.ID(11)
+	if _, err := s.SetUserRole(ctx, moderated, user, service.RoleModerator); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := s.SetUserRole(ctx, joined, user, service.RoleMember); err != nil {
+		t.Fatal(err)
+	}
+
+	roles := func() map[service.ID]service.Role {
+		t.Helper()
+		got := map[service.ID]service.Role{}
+		for _, orgID := range []service.ID{moderated, joined} {
+			ms, err := s.FindOrgRoles(ctx, orgID)
+			if err != nil {
+				t.Fatal(err)
+			}
+			for _, m := range ms {
+				got[orgID] = m.Role
+			}
+		}
+		return got
+	}
+	want := map[service.ID]service.Role{moderated: service.RoleModerator, joined: service.RoleMember}
+
+	// reverting the roles keeps the ones which are not the default of the mapping.
+	if err := s.MigrateDown(ctx, 0); err != nil {
+		t.Fatal(err)
+	}
+	if got := roles(); !reflect.DeepEqual(got, want) {
+		t.Errorf("roles after down = %v, want %v", got, want)
+	}
+
+	if err := s.MigrateUp(ctx, 0); err != nil {
+		t.Fatal(err)
+	}
+	if got := roles(); !reflect.DeepEqual(got, want) {
+		t.Errorf("roles after up = %v, want %v", got, want)
+	}
+}
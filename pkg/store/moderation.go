@@ -0,0 +1,596 @@
+package store
+
+import (
+	"context"
+	"fmt"
+	"sort"
+
+	"github.com/ustackq/indagate/pkg/service"
+	"github.com/ustackq/indagate/pkg/utils/errors"
+)
+
+var (
+	moderationItemBucket = []byte("moderationitemsv1")
+	reportBucket         = []byte("reportsv1")
+	moderationBuckets    = [][]byte{moderationItemBucket, reportBucket}
+)
+
+// assert Service implement service.ModerationService
+var _ service.ModerationService = (*Service)(nil)
+
+// reportable is the behavior of a reportable target type.
+type reportable struct {
+	// org returns the organization of the target, false if the target belongs to none.
+	org func(s *Service, ctx context.Context, tx Impl, id service.ID) (service.ID, bool, error)
+	// moderate applies a moderation action other than dismiss to the target.
+	moderate func(s *Service, ctx context.Context, tx Impl, id service.ID, action service.ModerationAction) error
+	// itemType is the type of the targets whose content trains the spam filters.
+	itemType service.ItemType
+}
+
+// reportables holds the target types which can be reported.
+var reportables = map[service.ReportType]reportable{
+	service.QuestionReport: {
+		org:      (*Service).questionReportOrg,
+		moderate: (*Service).moderateQuestion,
+		itemType: service.QuestionItemType,
+	},
+	service.AnswerReport: {
+		org:      (*Service).answerReportOrg,
+		moderate: (*Service).moderateAnswer,
+		itemType: service.AnswerItemType,
+	},
+	service.CommentReport: {
+		org:      (*Service).commentReportOrg,
+		moderate: (*Service).moderateComment,
+		itemType: service.CommentItemType,
+	},
+	service.UserReport: {
+		org: (*Service).userReportOrg,
+	},
+}
+
+func (s *Service) initializeModeration(ctx context.Context, tx Impl) error {
+	for _, b := range moderationBuckets {
+		if _, err := s.moderationBucket(tx, b); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (s *Service) moderationBucket(tx Impl, name []byte) (Bucket, error) {
+	b, err := tx.Bucket(name)
+	if err != nil {
+		return nil, UnexpectedModerationError(err)
+	}
+	return b, nil
+}
+
+// UnexpectedModerationError wraps errors raised while retrieving the moderation buckets.
+func UnexpectedModerationError(err error) *errors.Error {
+	return &errors.Error{
+		Code: errors.Internal,
+		Msg:  fmt.Sprintf("unexpected error retrieving moderation bucket; %v", err),
+		Op:   "moderationBucket",
+	}
+}
+
+// moderationItemKey returns the key of the moderation item of a target, prefixed by the
+// org to scan the queue of an org. The keys of the reports of the item start with it.
+func moderationItemKey(orgID service.ID, t service.ReportType, targetID service.ID, ids ...service.ID) ([]byte, error) {
+	org, err := idsKey(orgID)
+	if err != nil {
+		return nil, err
+	}
+
+	key, err := topicItemKey(service.ItemType(t), targetID, ids...)
+	if err != nil {
+		return nil, err
+	}
+	return append(org, key...), nil
+}
+
+func (s *Service) questionReportOrg(ctx context.Context, tx Impl, id service.ID) (service.ID, bool, error) {
+	q, err := s.findQuestionByID(ctx, tx, id)
+	if err != nil {
+		return 0, false, err
+	}
+	return q.OrgID, true, nil
+}
+
+func (s *Service) answerReportOrg(ctx context.Context, tx Impl, id service.ID) (service.ID, bool, error) {
+	a, err := s.findAnswerByID(ctx, tx, id)
+	if err != nil {
+		return 0, false, err
+	}
+	return a.OrgID, true, nil
+}
+
+func (s *Service) commentReportOrg(ctx context.Context, tx Impl, id service.ID) (service.ID, bool, error) {
+	c, err := s.findCommentByID(ctx, tx, id)
+	if err != nil {
+		return 0, false, err
+	}
+	if c.Deleted {
+		return 0, false, service.ErrCommentDeleted
+	}
+	return c.OrgID, true, nil
+}
+
+// userReportOrg checks the user exists, users are reported in any org they take part in.
+func (s *Service) userReportOrg(ctx context.Context, tx Impl, id service.ID) (service.ID, bool, error) {
+	if _, err := s.findUserByID(ctx, tx, id); err != nil {
+		return 0, false, err
+	}
+	return 0, false, nil
+}
+
+func (s *Service) moderateQuestion(ctx context.Context, tx Impl, id service.ID, action service.ModerationAction) error {
+	switch action {
+	case service.HideModeration:
+		q, err := s.findQuestionByID(ctx, tx, id)
+		if err != nil {
+			return err
+		}
+
+		q.Hidden = true
+		if err := s.putQuestion(ctx, tx, q); err != nil {
+			return err
+		}
+		return s.indexQuestion(ctx, tx, q)
+	case service.LockModeration:
+		return s.setQuestionLock(ctx, tx, id, true)
+	case service.DeleteModeration:
+		return s.deleteQuestion(ctx, tx, id)
+	}
+	return nil
+}
+
+func (s *Service) moderateAnswer(ctx context.Context, tx Impl, id service.ID, action service.ModerationAction) error {
+	switch action {
+	case service.HideModeration:
+		a, err := s.findAnswerByID(ctx, tx, id)
+		if err != nil {
+			return err
+		}
+
+		a.Hidden = true
+		if err := s.putAnswer(ctx, tx, a); err != nil {
+			return err
+		}
+		return s.indexAnswer(ctx, tx, a)
+	case service.DeleteModeration:
+		return s.deleteAnswer(ctx, tx, id)
+	}
+	return nil
+}
+
+func (s *Service) moderateComment(ctx context.Context, tx Impl, id service.ID, action service.ModerationAction) error {
+	switch action {
+	case service.HideModeration:
+		c, err := s.findCommentByID(ctx, tx, id)
+		if err != nil {
+			return err
+		}
+
+		c.Hidden = true
+		return s.putComment(ctx, tx, c)
+	case service.DeleteModeration:
+		return s.deleteComment(ctx, tx, id)
+	}
+	return nil
+}
+
+func unmarshalModerationItem(v []byte) (*service.ModerationItem, error) {
+	m := &service.ModerationItem{}
+	if err := json.Unmarshal(v, m); err != nil {
+		return nil, &errors.Error{
+			Code: errors.Internal,
+			Msg:  "moderation item could not be unmarshalled",
+			Err:  err,
+			Op:   "unmarshalModerationItem",
+		}
+	}
+	return m, nil
+}
+
+func unmarshalReport(v []byte) (*service.Report, error) {
+	r := &service.Report{}
+	if err := json.Unmarshal(v, r); err != nil {
+		return nil, &errors.Error{
+			Code: errors.Internal,
+			Msg:  "report could not be unmarshalled",
+			Err:  err,
+			Op:   "unmarshalReport",
+		}
+	}
+	return r, nil
+}
+
+// FindModerationItem returns the moderation item of a target within the org.
+func (s *Service) FindModerationItem(ctx context.Context, orgID service.ID, t service.ReportType, targetID service.ID) (*service.ModerationItem, error) {
+	var m *service.ModerationItem
+	err := s.store.View(ctx, func(tx Impl) error {
+		item, err := s.findModerationItem(ctx, tx, orgID, t, targetID)
+		if err != nil {
+			return err
+		}
+		m = item
+		return nil
+	})
+	if err != nil {
+		return nil, &errors.Error{
+			Err: err,
+			Op:  service.OpFindModerationItem,
+		}
+	}
+	return m, nil
+}
+
+func (s *Service) findModerationItem(ctx context.Context, tx Impl, orgID service.ID, t service.ReportType, targetID service.ID) (*service.ModerationItem, error) {
+	key, err := moderationItemKey(orgID, t, targetID)
+	if err != nil {
+		return nil, err
+	}
+
+	b, err := s.moderationBucket(tx, moderationItemBucket)
+	if err != nil {
+		return nil, err
+	}
+
+	v, err := b.Get(key)
+	if IsNotFound(err) {
+		return nil, service.ErrModerationItemNotFound
+	}
+	if err != nil {
+		return nil, errors.InternalErr(err)
+	}
+	return unmarshalModerationItem(v)
+}
+
+func (s *Service) putModerationItem(ctx context.Context, tx Impl, m *service.ModerationItem) error {
+	v, err := json.Marshal(m)
+	if err != nil {
+		return &errors.Error{
+			Code: errors.Invalid,
+			Err:  err,
+		}
+	}
+
+	key, err := moderationItemKey(m.OrgID, m.Type, m.TargetID)
+	if err != nil {
+		return err
+	}
+
+	b, err := s.moderationBucket(tx, moderationItemBucket)
+	if err != nil {
+		return err
+	}
+
+	if err := b.Put(key, v); err != nil {
+		return errors.InternalErr(err)
+	}
+	return nil
+}
+
+func (s *Service) putReport(ctx context.Context, tx Impl, r *service.Report) error {
+	v, err := json.Marshal(r)
+	if err != nil {
+		return &errors.Error{
+			Code: errors.Invalid,
+			Err:  err,
+		}
+	}
+
+	key, err := moderationItemKey(r.OrgID, r.Type, r.TargetID, r.ID)
+	if err != nil {
+		return err
+	}
+
+	b, err := s.moderationBucket(tx, reportBucket)
+	if err != nil {
+		return err
+	}
+
+	if err := b.Put(key, v); err != nil {
+		return errors.InternalErr(err)
+	}
+	return nil
+}
+
+// forEachReport calls fn with every report of the target within the org.
+func (s *Service) forEachReport(ctx context.Context, tx Impl, orgID service.ID, t service.ReportType, targetID service.ID, fn func(*service.Report) error) error {
+	prefix, err := moderationItemKey(orgID, t, targetID)
+	if err != nil {
+		return err
+	}
+
+	b, err := s.moderationBucket(tx, reportBucket)
+	if err != nil {
+		return err
+	}
+
+	return forEachPrefix(b, prefix, func(k, v []byte) error {
+		r, err := unmarshalReport(v)
+		if err != nil {
+			return err
+		}
+		return fn(r)
+	})
+}
+
+// Report files a report and returns the moderation item of its target.
+func (s *Service) Report(ctx context.Context, r *service.Report) (*service.ModerationItem, error) {
+	var m *service.ModerationItem
+	err := s.store.Modify(ctx, func(tx Impl) error {
+		item, err := s.report(ctx, tx, r)
+		if err != nil {
+			return &errors.Error{
+				Err: err,
+				Op:  service.OpReport,
+			}
+		}
+		m = item
+		return nil
+	})
+	return m, err
+}
+
+func (s *Service) report(ctx context.Context, tx Impl, r *service.Report) (*service.ModerationItem, error) {
+	if err := r.Valid(); err != nil {
+		return nil, err
+	}
+
+	orgID, ok, err := reportables[r.Type].org(s, ctx, tx, r.TargetID)
+	if err != nil {
+		return nil, err
+	}
+	if ok && orgID != r.OrgID {
+		return nil, &errors.Error{
+			Code: errors.Invalid,
+			Msg:  fmt.Sprintf("reported %s does not belong to org %s", r.Type, r.OrgID),
+		}
+	}
+
+	now := s.time()
+	m, err := s.findModerationItem(ctx, tx, r.OrgID, r.Type, r.TargetID)
+	if err == service.ErrModerationItemNotFound {
+		m = &service.ModerationItem{
+			Type:     r.Type,
+			TargetID: r.TargetID,
+			OrgID:    r.OrgID,
+			Status:   service.ModerationResolved,
+		}
+		err = nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	// a report on a resolved item opens it again, its reports are counted afresh.
+	if m.Status == service.ModerationResolved {
+		m.Status = service.ModerationOpen
+		m.ReportCount = 0
+		m.Reasons = nil
+		m.FirstReportAt = now
+	}
+
+	err = s.forEachReport(ctx, tx, r.OrgID, r.Type, r.TargetID, func(prev *service.Report) error {
+		if prev.UserID == r.UserID && !prev.Resolved {
+			return service.ErrDuplicateReport
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	r.ID = s.IDGenerator.ID()
+	r.Resolved = false
+	r.CreatedAt = now
+	if err := s.putReport(ctx, tx, r); err != nil {
+		return nil, err
+	}
+
+	m.ReportCount++
+	m.LatestReportAt = now
+	if !containsString(m.Reasons, r.Reason) {
+		m.Reasons = append(m.Reasons, r.Reason)
+	}
+	if err := s.putModerationItem(ctx, tx, m); err != nil {
+		return nil, err
+	}
+	return m, nil
+}
+
+func containsString(ss []string, s string) bool {
+	for _, str := range ss {
+		if str == s {
+			return true
+		}
+	}
+	return false
+}
+
+// FindReports returns the reports of a target within the org, newest first.
+func (s *Service) FindReports(ctx context.Context, orgID service.ID, t service.ReportType, targetID service.ID) ([]*service.Report, error) {
+	rs := []*service.Report{}
+	err := s.store.View(ctx, func(tx Impl) error {
+		if _, err := s.findModerationItem(ctx, tx, orgID, t, targetID); err != nil {
+			return err
+		}
+
+		return s.forEachReport(ctx, tx, orgID, t, targetID, func(r *service.Report) error {
+			rs = append(rs, r)
+			return nil
+		})
+	})
+	if err != nil {
+		return nil, &errors.Error{
+			Err: err,
+			Op:  service.OpFindReports,
+		}
+	}
+
+	sort.SliceStable(rs, func(i, j int) bool {
+		return rs[i].CreatedAt.After(rs[j].CreatedAt)
+	})
+	return rs, nil
+}
+
+func filterModerationItemFn(filter service.ModerationFilter) func(m *service.ModerationItem) bool {
+	return func(m *service.ModerationItem) bool {
+		if filter.Type != nil && m.Type != *filter.Type {
+			return false
+		}
+		if filter.Status != nil && m.Status != *filter.Status {
+			return false
+		}
+		return true
+	}
+}
+
+// FindModerationQueue returns the moderation items matching the filter, the most reported
+// open items first, and the total count of them.
+func (s *Service) FindModerationQueue(ctx context.Context, filter service.ModerationFilter, opt ...service.FindOptions) ([]*service.ModerationItem, int, error) {
+	ms := []*service.ModerationItem{}
+	filterFn := filterModerationItemFn(filter)
+	err := s.store.View(ctx, func(tx Impl) error {
+		var prefix []byte
+		if filter.OrgID != nil {
+			org, err := idsKey(*filter.OrgID)
+			if err != nil {
+				return err
+			}
+			prefix = org
+		}
+
+		b, err := s.moderationBucket(tx, moderationItemBucket)
+		if err != nil {
+			return err
+		}
+
+		return forEachPrefix(b, prefix, func(k, v []byte) error {
+			m, err := unmarshalModerationItem(v)
+			if err != nil {
+				return err
+			}
+			if filterFn(m) {
+				ms = append(ms, m)
+			}
+			return nil
+		})
+	})
+	if err != nil {
+		return nil, 0, &errors.Error{
+			Err: err,
+			Op:  service.OpFindModerationQueue,
+		}
+	}
+
+	sort.SliceStable(ms, func(i, j int) bool {
+		if (ms[i].Status == service.ModerationOpen) != (ms[j].Status == service.ModerationOpen) {
+			return ms[i].Status == service.ModerationOpen
+		}
+		if ms[i].ReportCount != ms[j].ReportCount {
+			return ms[i].ReportCount > ms[j].ReportCount
+		}
+		return ms[i].LatestReportAt.After(ms[j].LatestReportAt)
+	})
+
+	start, end := pageWindow(len(ms), opt...)
+	return ms[start:end], len(ms), nil
+}
+
+// ResolveReports applies the action of the resolution to the target and closes its reports.
+func (s *Service) ResolveReports(ctx context.Context, orgID service.ID, t service.ReportType, targetID service.ID, res *service.Resolution) (*service.ModerationItem, error) {
+	var (
+		m      *service.ModerationItem
+		sample *service.SpamSample
+	)
+	err := s.store.Modify(ctx, func(tx Impl) error {
+		item, spam, err := s.resolveReports(ctx, tx, orgID, t, targetID, res)
+		if err != nil {
+			return &errors.Error{
+				Err: err,
+				Op:  service.OpResolveReports,
+			}
+		}
+		m, sample = item, spam
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	s.trainSpam(ctx, sample)
+	return m, nil
+}
+
+func (s *Service) resolveReports(ctx context.Context, tx Impl, orgID service.ID, t service.ReportType, targetID service.ID, res *service.Resolution) (*service.ModerationItem, *service.SpamSample, error) {
+	if err := res.Valid(); err != nil {
+		return nil, nil, err
+	}
+
+	if err := res.Action.ValidFor(t); err != nil {
+		return nil, nil, err
+	}
+
+	m, err := s.findModerationItem(ctx, tx, orgID, t, targetID)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	if m.Status == service.ModerationResolved {
+		return nil, nil, service.ErrReportsResolved
+	}
+
+	// the content is labelled before deleted targets are gone, locks say nothing about spam.
+	var sample *service.SpamSample
+	if itemType := reportables[t].itemType; itemType != "" && res.Action != service.LockModeration {
+		c, err := s.itemContent(ctx, tx, itemType, targetID)
+		if err != nil && errors.ErrorCode(err) != errors.NotFound {
+			return nil, nil, err
+		}
+
+		if c != nil {
+			sample, err = s.recordSpamSample(ctx, tx, c, res.Action != service.DismissModeration)
+			if err != nil {
+				return nil, nil, err
+			}
+		}
+	}
+
+	if res.Action != service.DismissModeration {
+		if err := reportables[t].moderate(s, ctx, tx, targetID, res.Action); err != nil {
+			return nil, nil, err
+		}
+	}
+
+	var open []*service.Report
+	err = s.forEachReport(ctx, tx, orgID, t, targetID, func(r *service.Report) error {
+		if !r.Resolved {
+			open = append(open, r)
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, nil, err
+	}
+
+	for _, r := range open {
+		r.Resolved = true
+		if err := s.putReport(ctx, tx, r); err != nil {
+			return nil, nil, err
+		}
+	}
+
+	res.ResolvedAt = s.time()
+	m.Status = service.ModerationResolved
+	m.Resolution = res
+	if err := s.putModerationItem(ctx, tx, m); err != nil {
+		return nil, nil, err
+	}
+	return m, sample, nil
+}
@@ -0,0 +1,104 @@
+package store_test
+
+import (
+	"context"
+	"testing"
+
+	"github.com/ustackq/indagate/pkg/service"
+	"github.com/ustackq/indagate/pkg/utils/errors"
+)
+
+func TestModeration(t *testing.T) {
+	ctx := context.Background()
+	s := newInmemService(t)
+	org := service.ID(1)
+
+	q := &service.Question{OrgID: org, UserID: service.ID(2), Content: "spam question"}
+	if err := s.CreateQuestion(ctx, q); err != nil {
+		t.Fatal(err)
+	}
+	a := &service.Answer{QuestionID: q.ID, UserID: service.ID(3), Content: "rude answer"}
+	if err := s.CreateAnswer(ctx, a); err != nil {
+		t.Fatal(err)
+	}
+
+	report := func(typ service.ReportType, targetID, userID service.ID, reason string) (*service.ModerationItem, error) {
+		return s.Report(ctx, &service.Report{Type: typ, TargetID: targetID, OrgID: org, UserID: userID, Reason: reason})
+	}
+
+	// reports of a target are aggregated, each user reports it once.
+	if _, err := report(service.QuestionReport, q.ID, service.ID(5), "spam"); err != nil {
+		t.Fatal(err)
+	}
+	m, err := report(service.QuestionReport, q.ID, service.ID(6), "spam")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if m.ReportCount != 2 || len(m.Reasons) != 1 || m.Status != service.ModerationOpen {
+		t.Errorf("moderation item = %+v, want 2 open reports with a single reason", m)
+	}
+	if _, err := report(service.QuestionReport, q.ID, service.ID(5), "again"); errors.ErrorCode(err) != errors.Conflict {
+		t.Errorf("duplicate report error = %v, want %s", err, errors.Conflict)
+	}
+	_, err = s.Report(ctx, &service.Report{Type: service.AnswerReport, TargetID: a.ID, OrgID: service.ID(9), UserID: service.ID(5), Reason: "rude"})
+	if errors.ErrorCode(err) != errors.Invalid {
+		t.Errorf("report in another org error = %v, want %s", err, errors.Invalid)
+	}
+	if _, err := report(service.AnswerReport, a.ID, service.ID(5), "rude"); err != nil {
+		t.Fatal(err)
+	}
+
+	// the most reported items come first.
+	ms, n, err := s.FindModerationQueue(ctx, service.ModerationFilter{OrgID: &org})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if n != 2 || ms[0].TargetID != q.ID {
+		t.Fatalf("queue = %d items, want the question first", n)
+	}
+
+	// hiding the question keeps it but removes it from search.
+	res := &service.Resolution{Action: service.HideModeration, Reason: "spam", ModeratorID: service.ID(7)}
+	if _, err := s.ResolveReports(ctx, org, service.QuestionReport, q.ID, res); err != nil {
+		t.Fatal(err)
+	}
+	if q, err := s.FindQuestionByID(ctx, q.ID); err != nil || !q.Hidden {
+		t.Errorf("hidden question = %+v, %v, want it hidden", q, err)
+	}
+	if _, n, err := s.Search(ctx, service.SearchQuery{Query: "spam"}); err != nil || n != 0 {
+		t.Errorf("search hidden question = %d, %v, want none", n, err)
+	}
+	if _, err := s.ResolveReports(ctx, org, service.QuestionReport, q.ID, res); errors.ErrorCode(err) != errors.Conflict {
+		t.Errorf("resolve twice error = %v, want %s", err, errors.Conflict)
+	}
+
+	// answers can not be locked but can be deleted.
+	lock := &service.Resolution{Action: service.LockModeration, Reason: "rude", ModeratorID: service.ID(7)}
+	if _, err := s.ResolveReports(ctx, org, service.AnswerReport, a.ID, lock); errors.ErrorCode(err) != errors.Invalid {
+		t.Errorf("lock answer error = %v, want %s", err, errors.Invalid)
+	}
+	del := &service.Resolution{Action: service.DeleteModeration, Reason: "rude", ModeratorID: service.ID(7)}
+	if _, err := s.ResolveReports(ctx, org, service.AnswerReport, a.ID, del); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := s.FindAnswerByID(ctx, a.ID); errors.ErrorCode(err) != errors.NotFound {
+		t.Errorf("deleted answer error = %v, want %s", err, errors.NotFound)
+	}
+
+	// a new report opens the item again.
+	m, err = report(service.QuestionReport, q.ID, service.ID(5), "still spam")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if m.Status != service.ModerationOpen || m.ReportCount != 1 || m.Resolution == nil {
+		t.Errorf("reopened item = %+v, want a single open report and the last resolution", m)
+	}
+
+	open := service.ModerationOpen
+	if _, n, err := s.FindModerationQueue(ctx, service.ModerationFilter{OrgID: &org, Status: &open}); err != nil || n != 1 {
+		t.Errorf("open queue = %d, %v, want the question", n, err)
+	}
+	if rs, err := s.FindReports(ctx, org, service.QuestionReport, q.ID); err != nil || len(rs) != 3 || rs[0].Resolved {
+		t.Errorf("reports = %d, %v, want 3 with the open one first", len(rs), err)
+	}
+}
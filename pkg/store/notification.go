@@ -0,0 +1,493 @@
+package store
+
+import (
+	"context"
+	"fmt"
+	"sort"
+
+	"go.uber.org/zap"
+
+	"github.com/ustackq/indagate/pkg/service"
+	"github.com/ustackq/indagate/pkg/utils/errors"
+)
+
+var (
+	notificationBucket         = []byte("notificationsv1")
+	notificationRecipientIndex = []byte("notificationrecipientindexv1")
+	notificationSettingsBucket = []byte("notificationsettingsv1")
+)
+
+// assert Service implement service.NotificationService
+var _ service.NotificationService = (*Service)(nil)
+
+func (s *Service) initializeNotifications(ctx context.Context, tx Impl) error {
+	for _, b := range [][]byte{notificationBucket, notificationRecipientIndex, notificationSettingsBucket} {
+		if _, err := s.notificationBucket(tx, b); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (s *Service) notificationBucket(tx Impl, name []byte) (Bucket, error) {
+	b, err := tx.Bucket(name)
+	if err != nil {
+		return nil, UnexpectedNotificationError(err)
+	}
+	return b, nil
+}
+
+// UnexpectedNotificationError wraps errors raised while retrieving the notification buckets.
+func UnexpectedNotificationError(err error) *errors.Error {
+	return &errors.Error{
+		Code: errors.Internal,
+		Msg:  fmt.Sprintf("unexpected error retrieving notification bucket; %v", err),
+		Op:   "notificationBucket",
+	}
+}
+
+// notificationRecipientIndexKey is the encoded recipient id followed by the encoded notification id.
+func notificationRecipientIndexKey(n *service.Notification) ([]byte, error) {
+	rid, err := n.RecipientID.Encode()
+	if err != nil {
+		return nil, &errors.Error{
+			Code: errors.Invalid,
+			Err:  err,
+		}
+	}
+
+	nid, err := n.ID.Encode()
+	if err != nil {
+		return nil, &errors.Error{
+			Code: errors.Invalid,
+			Err:  err,
+		}
+	}
+	return append(rid, nid...), nil
+}
+
+func unmarshalNotification(v []byte) (*service.Notification, error) {
+	n := &service.Notification{}
+	if err := json.Unmarshal(v, n); err != nil {
+		return nil, &errors.Error{
+			Code: errors.Internal,
+			Msg:  "notification could not be unmarshalled",
+			Err:  err,
+			Op:   "unmarshalNotification",
+		}
+	}
+	return n, nil
+}
+
+// FindNotificationByID returns a single notification by ID.
+func (s *Service) FindNotificationByID(ctx context.Context, id service.ID) (*service.Notification, error) {
+	var n *service.Notification
+	err := s.store.View(ctx, func(tx Impl) error {
+		notification, err := s.findNotificationByID(ctx, tx, id)
+		if err != nil {
+			return err
+		}
+		n = notification
+		return nil
+	})
+	if err != nil {
+		return nil, &errors.Error{
+			Err: err,
+			Op:  service.OpFindNotificationByID,
+		}
+	}
+	return n, nil
+}
+
+func (s *Service) findNotificationByID(ctx context.Context, tx Impl, id service.ID) (*service.Notification, error) {
+	encodedID, err := id.Encode()
+	if err != nil {
+		return nil, &errors.Error{
+			Code: errors.Invalid,
+			Err:  err,
+		}
+	}
+
+	b, err := s.notificationBucket(tx, notificationBucket)
+	if err != nil {
+		return nil, err
+	}
+
+	v, err := b.Get(encodedID)
+	if IsNotFound(err) {
+		return nil, service.ErrNotificationNotFound
+	}
+
+	if err != nil {
+		return nil, &errors.Error{
+			Code: errors.Internal,
+			Err:  err,
+		}
+	}
+	return unmarshalNotification(v)
+}
+
+// FindNotifications returns the notifications of a recipient newest first.
+func (s *Service) FindNotifications(ctx context.Context, filter service.NotificationFilter, opt ...service.FindOptions) ([]*service.Notification, int, error) {
+	ns := []*service.Notification{}
+	err := s.store.View(ctx, func(tx Impl) error {
+		return s.forEachRecipientNotification(ctx, tx, filter.RecipientID, func(n *service.Notification) {
+			if filter.Read == nil || n.Read == *filter.Read {
+				ns = append(ns, n)
+			}
+		})
+	})
+	if err != nil {
+		return nil, 0, &errors.Error{
+			Err: err,
+			Op:  service.OpFindNotifications,
+		}
+	}
+
+	sort.SliceStable(ns, func(i, j int) bool {
+		return ns[i].CreatedAt.After(ns[j].CreatedAt)
+	})
+	start, end := pageWindow(len(ns), opt...)
+	return ns[start:end], len(ns), nil
+}
+
+func (s *Service) forEachRecipientNotification(ctx context.Context, tx Impl, recipientID service.ID, fn func(*service.Notification)) error {
+	prefix, err := recipientID.Encode()
+	if err != nil {
+		return &errors.Error{
+			Code: errors.Invalid,
+			Err:  err,
+		}
+	}
+
+	idx, err := s.notificationBucket(tx, notificationRecipientIndex)
+	if err != nil {
+		return err
+	}
+
+	return forEachPrefix(idx, prefix, func(_, v []byte) error {
+		var id service.ID
+		if err := id.Decode(v); err != nil {
+			return err
+		}
+
+		n, err := s.findNotificationByID(ctx, tx, id)
+		if err != nil {
+			return err
+		}
+		fn(n)
+		return nil
+	})
+}
+
+// notify delivers the notification unless it is meant for the sender itself
+// or the recipient has disabled the action.
+func (s *Service) notify(ctx context.Context, tx Impl, n *service.Notification) error {
+	if !n.RecipientID.Valid() || n.RecipientID == n.SenderID {
+		return nil
+	}
+
+	settings, err := s.findNotificationSettings(ctx, tx, n.RecipientID)
+	if err != nil {
+		return err
+	}
+	if !settings.Allows(n.Action) {
+		return nil
+	}
+
+	n.ID = s.IDGenerator.ID()
+	n.Read = false
+	n.CreatedAt = s.time()
+	if err := s.putNotification(ctx, tx, n); err != nil {
+		return err
+	}
+
+	if err := s.indexNotification(ctx, tx, n); err != nil {
+		return err
+	}
+
+	if err := s.adjustNotificationUnread(ctx, tx, n.RecipientID, 1); err != nil {
+		return err
+	}
+	return s.mailNotification(ctx, tx, n)
+}
+
+// mailNotification queues the mail of the notification with it, so that the mail
+// is sent if and only if the notification is committed. A mail which can not be
+// rendered is logged and dropped, it does not fail the action notified of.
+func (s *Service) mailNotification(ctx context.Context, tx Impl, n *service.Notification) error {
+	if s.NotificationMailer == nil {
+		return nil
+	}
+
+	recipient, err := s.findUserByID(ctx, tx, n.RecipientID)
+	if err == ErrUserNotFound {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+
+	// the sender may have been deleted since, the mail is sent without its name.
+	var sender *service.User
+	if n.SenderID.Valid() {
+		sender, err = s.findUserByID(ctx, tx, n.SenderID)
+		if err != nil && err != ErrUserNotFound {
+			return err
+		}
+	}
+
+	m, err := s.NotificationMailer.NotificationMail(recipient, sender, n)
+	if err != nil {
+		s.Logger.Error("failed to render notification mail", zap.Stringer("notification", n.ID), zap.Error(err))
+		return nil
+	}
+	if m == nil {
+		return nil
+	}
+	return s.enqueueMail(ctx, tx, m)
+}
+
+func (s *Service) putNotification(ctx context.Context, tx Impl, n *service.Notification) error {
+	v, err := json.Marshal(n)
+	if err != nil {
+		return &errors.Error{
+			Code: errors.Invalid,
+			Err:  err,
+		}
+	}
+
+	encodedID, err := n.ID.Encode()
+	if err != nil {
+		return &errors.Error{
+			Code: errors.Invalid,
+			Err:  err,
+		}
+	}
+
+	b, err := s.notificationBucket(tx, notificationBucket)
+	if err != nil {
+		return err
+	}
+
+	if err := b.Put(encodedID, v); err != nil {
+		return errors.InternalErr(err)
+	}
+	return nil
+}
+
+func (s *Service) indexNotification(ctx context.Context, tx Impl, n *service.Notification) error {
+	key, err := notificationRecipientIndexKey(n)
+	if err != nil {
+		return err
+	}
+
+	encodedID, err := n.ID.Encode()
+	if err != nil {
+		return &errors.Error{
+			Code: errors.Invalid,
+			Err:  err,
+		}
+	}
+
+	idx, err := s.notificationBucket(tx, notificationRecipientIndex)
+	if err != nil {
+		return err
+	}
+
+	if err := idx.Put(key, encodedID); err != nil {
+		return errors.InternalErr(err)
+	}
+	return nil
+}
+
+// adjustNotificationUnread adds delta to the unread counter of the user, users
+// which have been deleted are skipped.
+func (s *Service) adjustNotificationUnread(ctx context.Context, tx Impl, userID service.ID, delta int) error {
+	if delta == 0 {
+		return nil
+	}
+
+	u, err := s.findUserByID(ctx, tx, userID)
+	if err == ErrUserNotFound {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+
+	u.NotificationUnread += delta
+	if u.NotificationUnread < 0 {
+		u.NotificationUnread = 0
+	}
+	return s.putUser(ctx, tx, u)
+}
+
+// MarkNotificationRead marks a single notification as read.
+func (s *Service) MarkNotificationRead(ctx context.Context, id service.ID) (*service.Notification, error) {
+	var n *service.Notification
+	err := s.store.Modify(ctx, func(tx Impl) error {
+		notification, err := s.findNotificationByID(ctx, tx, id)
+		if err != nil {
+			return err
+		}
+
+		if err := s.markNotificationRead(ctx, tx, notification); err != nil {
+			return err
+		}
+		n = notification
+		return nil
+	})
+	if err != nil {
+		return nil, &errors.Error{
+			Err: err,
+			Op:  service.OpMarkNotificationRead,
+		}
+	}
+	return n, nil
+}
+
+func (s *Service) markNotificationRead(ctx context.Context, tx Impl, n *service.Notification) error {
+	if n.Read {
+		return nil
+	}
+
+	n.Read = true
+	if err := s.putNotification(ctx, tx, n); err != nil {
+		return err
+	}
+	return s.adjustNotificationUnread(ctx, tx, n.RecipientID, -1)
+}
+
+// MarkAllNotificationsRead marks all notifications of the recipient as read.
+func (s *Service) MarkAllNotificationsRead(ctx context.Context, recipientID service.ID) (int, error) {
+	count := 0
+	err := s.store.Modify(ctx, func(tx Impl) error {
+		unread := []*service.Notification{}
+		err := s.forEachRecipientNotification(ctx, tx, recipientID, func(n *service.Notification) {
+			if !n.Read {
+				unread = append(unread, n)
+			}
+		})
+		if err != nil {
+			return err
+		}
+
+		for _, n := range unread {
+			if err := s.markNotificationRead(ctx, tx, n); err != nil {
+				return err
+			}
+		}
+		count = len(unread)
+		return nil
+	})
+	if err != nil {
+		return 0, &errors.Error{
+			Err: err,
+			Op:  service.OpMarkAllNotificationsRead,
+		}
+	}
+	return count, nil
+}
+
+// FindNotificationSettings returns the notification preferences of a user,
+// users which never changed them are notified of everything.
+func (s *Service) FindNotificationSettings(ctx context.Context, userID service.ID) (*service.NotificationSettings, error) {
+	var settings *service.NotificationSettings
+	err := s.store.View(ctx, func(tx Impl) error {
+		ns, err := s.findNotificationSettings(ctx, tx, userID)
+		if err != nil {
+			return err
+		}
+		settings = ns
+		return nil
+	})
+	if err != nil {
+		return nil, &errors.Error{
+			Err: err,
+			Op:  service.OpFindNotificationSettings,
+		}
+	}
+	return settings, nil
+}
+
+func (s *Service) findNotificationSettings(ctx context.Context, tx Impl, userID service.ID) (*service.NotificationSettings, error) {
+	encodedID, err := userID.Encode()
+	if err != nil {
+		return nil, &errors.Error{
+			Code: errors.Invalid,
+			Err:  err,
+		}
+	}
+
+	b, err := s.notificationBucket(tx, notificationSettingsBucket)
+	if err != nil {
+		return nil, err
+	}
+
+	v, err := b.Get(encodedID)
+	if IsNotFound(err) {
+		return &service.NotificationSettings{UserID: userID, Disabled: []service.NotificationAction{}}, nil
+	}
+
+	if err != nil {
+		return nil, &errors.Error{
+			Code: errors.Internal,
+			Err:  err,
+		}
+	}
+
+	settings := &service.NotificationSettings{}
+	if err := json.Unmarshal(v, settings); err != nil {
+		return nil, &errors.Error{
+			Code: errors.Internal,
+			Err:  err,
+		}
+	}
+	return settings, nil
+}
+
+// UpdateNotificationSettings replaces the notification preferences of a user.
+func (s *Service) UpdateNotificationSettings(ctx context.Context, settings *service.NotificationSettings) error {
+	return s.store.Modify(ctx, func(tx Impl) error {
+		if err := s.putNotificationSettings(ctx, tx, settings); err != nil {
+			return &errors.Error{
+				Err: err,
+				Op:  service.OpUpdateNotificationSettings,
+			}
+		}
+		return nil
+	})
+}
+
+func (s *Service) putNotificationSettings(ctx context.Context, tx Impl, settings *service.NotificationSettings) error {
+	if err := settings.Valid(); err != nil {
+		return err
+	}
+
+	v, err := json.Marshal(settings)
+	if err != nil {
+		return &errors.Error{
+			Code: errors.Invalid,
+			Err:  err,
+		}
+	}
+
+	encodedID, err := settings.UserID.Encode()
+	if err != nil {
+		return &errors.Error{
+			Code: errors.Invalid,
+			Err:  err,
+		}
+	}
+
+	b, err := s.notificationBucket(tx, notificationSettingsBucket)
+	if err != nil {
+		return err
+	}
+
+	if err := b.Put(encodedID, v); err != nil {
+		return errors.InternalErr(err)
+	}
+	return nil
+}
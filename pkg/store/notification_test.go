@@ -0,0 +1,130 @@
+package store_test
+
+import (
+	"bytes"
+	"context"
+	"strings"
+	"testing"
+
+	"github.com/ustackq/indagate/pkg/mail"
+	"github.com/ustackq/indagate/pkg/service"
+)
+
+func TestNotifications(t *testing.T) {
+	ctx := context.Background()
+	s := newInmemService(t)
+
+	asker := service.ID(2)
+	q := &service.Question{OrgID: service.ID(1), UserID: asker, Content: "why?"}
+	if err := s.CreateQuestion(ctx, q); err != nil {
+		t.Fatal(err)
+	}
+
+	answer := func(userID service.ID) *service.Answer {
+		t.Helper()
+		a := &service.Answer{QuestionID: q.ID, UserID: userID, Content: "because"}
+		if err := s.CreateAnswer(ctx, a); err != nil {
+			t.Fatal(err)
+		}
+		return a
+	}
+
+	unread := func() int {
+		t.Helper()
+		read := false
+		_, n, err := s.FindNotifications(ctx, service.NotificationFilter{RecipientID: asker, Read: &read})
+		if err != nil {
+			t.Fatal(err)
+		}
+		return n
+	}
+
+	a := answer(service.ID(10))
+	// answering your own question is not worth a notification.
+	answer(asker)
+
+	comment := &service.Comment{Type: service.AnswerComment, ItemID: a.ID, UserID: service.ID(11), AtUID: asker, Content: "@asker look"}
+	if err := s.CreateComment(ctx, comment); err != nil {
+		t.Fatal(err)
+	}
+
+	ns, total, err := s.FindNotifications(ctx, service.NotificationFilter{RecipientID: asker})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if total != 2 || ns[0].Action != service.MentionNotification || ns[1].Action != service.AnswerNotification {
+		t.Fatalf("notifications = %d, want a mention and an answer", total)
+	}
+	if n := unread(); n != 2 {
+		t.Errorf("unread = %d, want 2", n)
+	}
+
+	if _, err := s.MarkNotificationRead(ctx, ns[1].ID); err != nil {
+		t.Fatal(err)
+	}
+	if n := unread(); n != 1 {
+		t.Errorf("unread after mark read = %d, want 1", n)
+	}
+
+	settings := &service.NotificationSettings{UserID: asker, Disabled: []service.NotificationAction{service.AnswerNotification}}
+	if err := s.UpdateNotificationSettings(ctx, settings); err != nil {
+		t.Fatal(err)
+	}
+	answer(service.ID(12))
+	if n := unread(); n != 1 {
+		t.Errorf("unread after disabled action = %d, want 1", n)
+	}
+
+	marked, err := s.MarkAllNotificationsRead(ctx, asker)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if marked != 1 || unread() != 0 {
+		t.Errorf("MarkAllNotificationsRead() = %d leaving %d unread, want 1 leaving 0", marked, unread())
+	}
+}
+
+func TestNotificationMails(t *testing.T) {
+	ctx := context.Background()
+	s := newInmemService(t)
+	s.NotificationMailer = mail.NewMailer(s, nil, "Indagate <noreply@example.com>")
+
+	archive := `{"kind":"header","data":{"format":1}}
+{"kind":"user","data":{"id":2,"name":"asker","email":"asker@example.com"}}
+{"kind":"user","data":{"id":3,"name":"quiet"}}
+{"kind":"user","data":{"id":10,"name":"answerer"}}`
+	if _, err := s.Import(ctx, bytes.NewReader([]byte(archive))); err != nil {
+		t.Fatal(err)
+	}
+
+	ask := func(userID service.ID) {
+		t.Helper()
+		q := &service.Question{OrgID: service.ID(1), UserID: userID, Content: "why?"}
+		if err := s.CreateQuestion(ctx, q); err != nil {
+			t.Fatal(err)
+		}
+		a := &service.Answer{QuestionID: q.ID, UserID: service.ID(10), Content: "because"}
+		if err := s.CreateAnswer(ctx, a); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	// the mail is queued with the notification, users without an email get none.
+	ask(service.ID(2))
+	ask(service.ID(3))
+
+	ms, total, err := s.FindMails(ctx, service.MailFilter{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if total != 1 {
+		t.Fatalf("mails = %d, want 1", total)
+	}
+	m := ms[0]
+	if len(m.To) != 1 || m.To[0] != "asker@example.com" || m.Template != mail.NotifyTemplate || m.Status != service.MailPending {
+		t.Errorf("mail = %+v, want a pending notification to asker", m)
+	}
+	if !strings.Contains(m.Text, "answerer answered your question") {
+		t.Errorf("mail text = %q, want the answer notification", m.Text)
+	}
+}
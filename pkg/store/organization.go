@@ -68,7 +68,7 @@ func (s *Service) findOrgnizationByID(ctx context.Context, tx Impl, id service.I
 		return nil, err
 	}
 
-	var org *service.Organization
+	org := &service.Organization{}
 	if err := json.Unmarshal(v, org); err != nil {
 		return nil, &errors.Error{
 			Err: err,
@@ -0,0 +1,11 @@
+package store
+
+import (
+	"github.com/ustackq/indagate/pkg/service"
+)
+
+// pageWindow returns the bounds [start, end) of the page described by opts
+// in a result set of n items.
+func pageWindow(n int, opts ...service.FindOptions) (int, int) {
+	return service.PageWindow(n, opts...)
+}
@@ -0,0 +1,530 @@
+package store
+
+import (
+	"context"
+	"fmt"
+	"sort"
+
+	"github.com/ustackq/indagate/pkg/service"
+	"github.com/ustackq/indagate/pkg/utils/errors"
+)
+
+var (
+	questionBucket = []byte("questionsv1")
+)
+
+// assert Service implement service.QuestionService
+var _ service.QuestionService = (*Service)(nil)
+
+func (s *Service) initializeQuestions(ctx context.Context, tx Impl) error {
+	if _, err := s.questionBucket(tx); err != nil {
+		return err
+	}
+	return nil
+}
+
+func (s *Service) questionBucket(tx Impl) (Bucket, error) {
+	b, err := tx.Bucket(questionBucket)
+	if err != nil {
+		return nil, UnexpectedQuestionError(err)
+	}
+	return b, nil
+}
+
+// UnexpectedQuestionError wraps errors raised while retrieving the question bucket.
+func UnexpectedQuestionError(err error) *errors.Error {
+	return &errors.Error{
+		Code: errors.Internal,
+		Msg:  fmt.Sprintf("unexpected error retrieving question bucket; %v", err),
+		Op:   "questionBucket",
+	}
+}
+
+// FindQuestionByID returns a single question by ID.
+func (s *Service) FindQuestionByID(ctx context.Context, id service.ID) (*service.Question, error) {
+	var q *service.Question
+	err := s.store.View(ctx, func(tx Impl) error {
+		question, err := s.findQuestionByID(ctx, tx, id)
+		if err != nil {
+			return err
+		}
+		q = question
+		return nil
+	})
+	if err != nil {
+		return nil, &errors.Error{
+			Err: err,
+			Op:  service.OpFindQuestionByID,
+		}
+	}
+	return q, nil
+}
+
+func (s *Service) findQuestionByID(ctx context.Context, tx Impl, id service.ID) (*service.Question, error) {
+	encodedID, err := id.Encode()
+	if err != nil {
+		return nil, &errors.Error{
+			Code: errors.Invalid,
+			Err:  err,
+		}
+	}
+
+	b, err := s.questionBucket(tx)
+	if err != nil {
+		return nil, err
+	}
+
+	v, err := b.Get(encodedID)
+	if IsNotFound(err) {
+		return nil, service.ErrQuestionNotFound
+	}
+
+	if err != nil {
+		return nil, &errors.Error{
+			Code: errors.Internal,
+			Err:  err,
+		}
+	}
+	return unmarshalQuestion(v)
+}
+
+func unmarshalQuestion(v []byte) (*service.Question, error) {
+	q := &service.Question{}
+	if err := json.Unmarshal(v, q); err != nil {
+		return nil, &errors.Error{
+			Code: errors.Internal,
+			Msg:  "question could not be unmarshalled",
+			Err:  err,
+			Op:   "unmarshalQuestion",
+		}
+	}
+	return q, nil
+}
+
+func filterQuestionFn(filter service.QuestionFilter) func(q *service.Question) bool {
+	return func(q *service.Question) bool {
+		if filter.ID != nil && q.ID != *filter.ID {
+			return false
+		}
+		if filter.OrgID != nil && q.OrgID != *filter.OrgID {
+			return false
+		}
+		if filter.UserID != nil && q.UserID != *filter.UserID {
+			return false
+		}
+		if filter.Lock != nil && q.Lock != *filter.Lock {
+			return false
+		}
+		return true
+	}
+}
+
+// FindQuestions returns a list of questions that match filter and the total count of matching questions.
+func (s *Service) FindQuestions(ctx context.Context, filter service.QuestionFilter, opt ...service.FindOptions) ([]*service.Question, int, error) {
+	if filter.ID != nil {
+		q, err := s.FindQuestionByID(ctx, *filter.ID)
+		if err != nil {
+			return nil, 0, &errors.Error{
+				Err: err,
+				Op:  service.OpFindQuestions,
+			}
+		}
+		if !filterQuestionFn(filter)(q) {
+			return []*service.Question{}, 0, nil
+		}
+		return []*service.Question{q}, 1, nil
+	}
+
+	qs := []*service.Question{}
+	err := s.store.View(ctx, func(tx Impl) error {
+		filterFn := filterQuestionFn(filter)
+		return s.forEachQuestion(ctx, tx, func(q *service.Question) bool {
+			if filterFn(q) {
+				qs = append(qs, q)
+			}
+			return true
+		})
+	})
+	if err != nil {
+		return nil, 0, &errors.Error{
+			Err: err,
+			Op:  service.OpFindQuestions,
+		}
+	}
+
+	sortQuestions(qs, opt...)
+	start, end := pageWindow(len(qs), opt...)
+	return qs[start:end], len(qs), nil
+}
+
+func sortQuestions(qs []*service.Question, opts ...service.FindOptions) {
+	var opt service.FindOptions
+	if len(opts) > 0 {
+		opt = opts[0]
+	}
+
+	less := func(i, j int) bool {
+		return qs[i].CreatedAt.Before(qs[j].CreatedAt)
+	}
+	switch opt.SortBy {
+	case "updatedAt":
+		less = func(i, j int) bool {
+			return qs[i].UpdatedAt.Before(qs[j].UpdatedAt)
+		}
+	case "answerCount":
+		less = func(i, j int) bool {
+			return qs[i].AnswerCount < qs[j].AnswerCount
+		}
+	case "viewCount":
+		less = func(i, j int) bool {
+			return qs[i].ViewCount < qs[j].ViewCount
+		}
+	}
+
+	if opt.Descending {
+		sort.SliceStable(qs, func(i, j int) bool { return less(j, i) })
+		return
+	}
+	sort.SliceStable(qs, less)
+}
+
+func (s *Service) forEachQuestion(ctx context.Context, tx Impl, fn func(*service.Question) bool) error {
+	b, err := s.questionBucket(tx)
+	if err != nil {
+		return err
+	}
+
+	cur, err := b.Cursor()
+	if err != nil {
+		return err
+	}
+
+	for k, v := cur.First(); k != nil; k, v = cur.Next() {
+		q, err := unmarshalQuestion(v)
+		if err != nil {
+			return err
+		}
+		if !fn(q) {
+			break
+		}
+	}
+	return nil
+}
+
+// CreateQuestion creates a new question and sets q.ID with the new identifier.
+func (s *Service) CreateQuestion(ctx context.Context, q *service.Question) error {
+	return s.store.Modify(ctx, func(tx Impl) error {
+		if err := s.createQuestion(ctx, tx, q); err != nil {
+			return &errors.Error{
+				Err: err,
+				Op:  service.OpCreateQuestion,
+			}
+		}
+		return nil
+	})
+}
+
+func (s *Service) createQuestion(ctx context.Context, tx Impl, q *service.Question) error {
+	if err := q.Valid(); err != nil {
+		return err
+	}
+
+	reasons, err := s.approvalReasons(ctx, tx, questionContent(q))
+	if err != nil {
+		return err
+	}
+
+	q.ID = s.IDGenerator.ID()
+	q.Pending = len(reasons) > 0
+	q.UnverifiedModify = nil
+	q.UnverifiedModifyCount = 0
+	q.CreatedAt = s.time()
+	q.UpdatedAt = q.CreatedAt
+	if err := s.putQuestion(ctx, tx, q); err != nil {
+		return err
+	}
+
+	if err := s.deleteDraft(ctx, tx, q.UserID, service.QuestionDraft, q.OrgID); err != nil {
+		return err
+	}
+
+	if q.Pending {
+		return s.submitApproval(ctx, tx, &service.Approval{
+			Kind:     service.CreateApproval,
+			ItemType: service.QuestionItemType,
+			ItemID:   q.ID,
+			OrgID:    q.OrgID,
+			UserID:   q.UserID,
+			Reasons:  reasons,
+		})
+	}
+	return s.publishQuestion(ctx, tx, q)
+}
+
+// publishQuestion makes a new question searchable and shows it to the followers of its asker.
+func (s *Service) publishQuestion(ctx context.Context, tx Impl, q *service.Question) error {
+	if err := s.indexQuestion(ctx, tx, q); err != nil {
+		return err
+	}
+
+	if err := s.countPost(ctx, tx, q.UserID); err != nil {
+		return err
+	}
+
+	// anonymous askers are not shown to their followers.
+	if q.Anonymous {
+		return nil
+	}
+	return s.recordActivity(ctx, tx, &service.Activity{
+		UserID:     q.UserID,
+		Action:     service.AskedActivity,
+		OrgID:      q.OrgID,
+		ItemType:   service.QuestionItemType,
+		ItemID:     q.ID,
+		QuestionID: q.ID,
+	})
+}
+
+func (s *Service) putQuestion(ctx context.Context, tx Impl, q *service.Question) error {
+	v, err := json.Marshal(q)
+	if err != nil {
+		return &errors.Error{
+			Code: errors.Invalid,
+			Err:  err,
+		}
+	}
+
+	encodedID, err := q.ID.Encode()
+	if err != nil {
+		return &errors.Error{
+			Code: errors.Invalid,
+			Err:  err,
+		}
+	}
+
+	b, err := s.questionBucket(tx)
+	if err != nil {
+		return err
+	}
+
+	if err := b.Put(encodedID, v); err != nil {
+		return errors.InternalErr(err)
+	}
+	return nil
+}
+
+// UpdateQuestion updates a single question with changeset.
+func (s *Service) UpdateQuestion(ctx context.Context, id service.ID, upd service.QuestionUpdate) (*service.Question, error) {
+	var q *service.Question
+	err := s.store.Modify(ctx, func(tx Impl) error {
+		question, err := s.updateQuestion(ctx, tx, id, upd)
+		if err != nil {
+			return &errors.Error{
+				Err: err,
+				Op:  service.OpUpdateQuestion,
+			}
+		}
+		q = question
+		return nil
+	})
+	return q, err
+}
+
+func (s *Service) updateQuestion(ctx context.Context, tx Impl, id service.ID, upd service.QuestionUpdate) (*service.Question, error) {
+	if err := upd.Valid(); err != nil {
+		return nil, err
+	}
+
+	q, err := s.findQuestionByID(ctx, tx, id)
+	if err != nil {
+		return nil, err
+	}
+
+	if q.Lock {
+		return nil, service.ErrQuestionLocked
+	}
+
+	// pending questions are reviewed as a whole, edits of published ones are held
+	// back on their own when their author is under review.
+	if !q.Pending {
+		c := questionContent(q)
+		c.Texts = updateTexts(upd.Content, upd.Detail)
+		reasons, err := s.approvalReasons(ctx, tx, c)
+		if err != nil {
+			return nil, err
+		}
+		if len(reasons) > 0 {
+			if err := s.holdQuestionUpdate(ctx, tx, q, upd, reasons); err != nil {
+				return nil, err
+			}
+			return q, nil
+		}
+	}
+
+	applyQuestionUpdate(q, upd)
+	q.UpdatedAt = s.time()
+
+	if err := s.putQuestion(ctx, tx, q); err != nil {
+		return nil, err
+	}
+
+	if err := s.indexQuestion(ctx, tx, q); err != nil {
+		return nil, err
+	}
+	return q, nil
+}
+
+func applyQuestionUpdate(q *service.Question, upd service.QuestionUpdate) {
+	if upd.Content != nil {
+		q.Content = *upd.Content
+	}
+	if upd.Detail != nil {
+		q.Detail = *upd.Detail
+	}
+	if upd.Anonymous != nil {
+		q.Anonymous = *upd.Anonymous
+	}
+}
+
+// holdQuestionUpdate merges the update into the edit of the question waiting for approval.
+func (s *Service) holdQuestionUpdate(ctx context.Context, tx Impl, q *service.Question, upd service.QuestionUpdate, reasons []service.ApprovalReason) error {
+	if q.UnverifiedModify != nil {
+		upd = q.UnverifiedModify.Merge(upd)
+	}
+	q.UnverifiedModify = &upd
+	q.UnverifiedModifyCount++
+	if err := s.putQuestion(ctx, tx, q); err != nil {
+		return err
+	}
+
+	return s.submitApproval(ctx, tx, &service.Approval{
+		Kind:     service.EditApproval,
+		ItemType: service.QuestionItemType,
+		ItemID:   q.ID,
+		OrgID:    q.OrgID,
+		UserID:   q.UserID,
+		Reasons:  reasons,
+	})
+}
+
+// updateTexts returns the texts set by an update.
+func updateTexts(fields ...*string) []string {
+	texts := []string{}
+	for _, f := range fields {
+		if f != nil {
+			texts = append(texts, *f)
+		}
+	}
+	return texts
+}
+
+// DeleteQuestion removes a question by ID.
+func (s *Service) DeleteQuestion(ctx context.Context, id service.ID) error {
+	return s.store.Modify(ctx, func(tx Impl) error {
+		if err := s.deleteQuestion(ctx, tx, id); err != nil {
+			return &errors.Error{
+				Err: err,
+				Op:  service.OpDeleteQuestion,
+			}
+		}
+		return nil
+	})
+}
+
+func (s *Service) deleteQuestion(ctx context.Context, tx Impl, id service.ID) error {
+	q, err := s.findQuestionByID(ctx, tx, id)
+	if err != nil {
+		return err
+	}
+
+	if err := s.deleteQuestionAnswers(ctx, tx, id); err != nil {
+		return err
+	}
+
+	if err := s.deleteItemVotes(ctx, tx, service.QuestionItemType, id, q.UserID); err != nil {
+		return err
+	}
+
+	if err := s.deleteItemTopicRelations(ctx, tx, service.QuestionItemType, id); err != nil {
+		return err
+	}
+
+	if err := s.deleteItemDrafts(ctx, tx, service.AnswerDraft, id); err != nil {
+		return err
+	}
+
+	if err := s.deleteItemFollows(ctx, tx, service.QuestionFollow, id); err != nil {
+		return err
+	}
+
+	if err := s.deleteSubjectActivities(ctx, tx, service.QuestionItemType, id, nil); err != nil {
+		return err
+	}
+
+	if err := s.unindexSearchDoc(ctx, tx, service.QuestionSearchType, id); err != nil {
+		return err
+	}
+
+	if err := s.deleteItemApproval(ctx, tx, service.QuestionItemType, id); err != nil {
+		return err
+	}
+
+	encodedID, err := id.Encode()
+	if err != nil {
+		return &errors.Error{
+			Code: errors.Invalid,
+			Err:  err,
+		}
+	}
+
+	b, err := s.questionBucket(tx)
+	if err != nil {
+		return err
+	}
+
+	if err := b.Delete(encodedID); err != nil {
+		return errors.WrapperErr(err)
+	}
+	return nil
+}
+
+// LockQuestion forbids any further modification of the question.
+func (s *Service) LockQuestion(ctx context.Context, id service.ID) error {
+	return s.store.Modify(ctx, func(tx Impl) error {
+		if err := s.setQuestionLock(ctx, tx, id, true); err != nil {
+			return &errors.Error{
+				Err: err,
+				Op:  service.OpLockQuestion,
+			}
+		}
+		return nil
+	})
+}
+
+// UnlockQuestion reverts LockQuestion.
+func (s *Service) UnlockQuestion(ctx context.Context, id service.ID) error {
+	return s.store.Modify(ctx, func(tx Impl) error {
+		if err := s.setQuestionLock(ctx, tx, id, false); err != nil {
+			return &errors.Error{
+				Err: err,
+				Op:  service.OpUnlockQuestion,
+			}
+		}
+		return nil
+	})
+}
+
+func (s *Service) setQuestionLock(ctx context.Context, tx Impl, id service.ID, lock bool) error {
+	q, err := s.findQuestionByID(ctx, tx, id)
+	if err != nil {
+		return err
+	}
+
+	if q.Lock == lock {
+		return nil
+	}
+
+	q.Lock = lock
+	q.UpdatedAt = s.time()
+	return s.putQuestion(ctx, tx, q)
+}
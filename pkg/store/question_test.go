@@ -0,0 +1,126 @@
+package store_test
+
+import (
+	"context"
+	"testing"
+
+	"github.com/ustackq/indagate/pkg/service"
+	"github.com/ustackq/indagate/pkg/utils/errors"
+)
+
+func TestQuestion(t *testing.T) {
+	ctx := context.Background()
+	s := newInmemService(t)
+
+	if err := s.CreateQuestion(ctx, &service.Question{OrgID: service.ID(1), UserID: service.ID(2)}); errors.ErrorCode(err) != errors.EmptyValue {
+		t.Errorf("create question without content error = %v, want %s", err, errors.EmptyValue)
+	}
+
+	q := &service.Question{OrgID: service.ID(1), UserID: service.ID(2), Content: "why?"}
+	if err := s.CreateQuestion(ctx, q); err != nil {
+		t.Fatal(err)
+	}
+	if !q.ID.Valid() || q.CreatedAt.IsZero() {
+		t.Fatalf("created question = %+v, want an id and a creation time", q)
+	}
+
+	found, err := s.FindQuestionByID(ctx, q.ID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if found.Content != "why?" || found.OrgID != q.OrgID {
+		t.Errorf("found question = %+v, want %+v", found, q)
+	}
+
+	content, detail := "why not?", "details"
+	updated, err := s.UpdateQuestion(ctx, q.ID, service.QuestionUpdate{Content: &content, Detail: &detail})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if updated.Content != content || updated.Detail != detail {
+		t.Errorf("updated question = %+v, want content %q and detail %q", updated, content, detail)
+	}
+
+	// locked questions can not be edited.
+	if err := s.LockQuestion(ctx, q.ID); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := s.UpdateQuestion(ctx, q.ID, service.QuestionUpdate{Content: &content}); errors.ErrorCode(err) != errors.Forbidden {
+		t.Errorf("update locked question error = %v, want %s", err, errors.Forbidden)
+	}
+	if err := s.UnlockQuestion(ctx, q.ID); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := s.DeleteQuestion(ctx, q.ID); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := s.FindQuestionByID(ctx, q.ID); errors.ErrorCode(err) != errors.NotFound {
+		t.Errorf("find deleted question error = %v, want %s", err, errors.NotFound)
+	}
+	if err := s.DeleteQuestion(ctx, q.ID); errors.ErrorCode(err) != errors.NotFound {
+		t.Errorf("delete question twice error = %v, want %s", err, errors.NotFound)
+	}
+}
+
+func TestFindQuestions(t *testing.T) {
+	ctx := context.Background()
+	s := newInmemService(t)
+
+	orgOne, orgTwo := service.ID(1), service.ID(2)
+	alice, bob := service.ID(11), service.ID(12)
+	qs := []*service.Question{
+		{OrgID: orgOne, UserID: alice, Content: "first"},
+		{OrgID: orgOne, UserID: bob, Content: "second"},
+		{OrgID: orgTwo, UserID: alice, Content: "third"},
+	}
+	for _, q := range qs {
+		if err := s.CreateQuestion(ctx, q); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := s.LockQuestion(ctx, qs[1].ID); err != nil {
+		t.Fatal(err)
+	}
+
+	locked := true
+	for _, tt := range []struct {
+		name   string
+		filter service.QuestionFilter
+		want   []*service.Question
+	}{
+		{name: "all", want: qs},
+		{name: "org", filter: service.QuestionFilter{OrgID: &orgOne}, want: qs[:2]},
+		{name: "user", filter: service.QuestionFilter{UserID: &alice}, want: []*service.Question{qs[0], qs[2]}},
+		{name: "org and user", filter: service.QuestionFilter{OrgID: &orgOne, UserID: &bob}, want: qs[1:2]},
+		{name: "locked", filter: service.QuestionFilter{Lock: &locked}, want: qs[1:2]},
+		{name: "id", filter: service.QuestionFilter{ID: &qs[2].ID}, want: qs[2:]},
+		// the other fields of the filter apply to single ID lookups as well.
+		{name: "id of another org", filter: service.QuestionFilter{ID: &qs[2].ID, OrgID: &orgOne}},
+		{name: "id of another user", filter: service.QuestionFilter{ID: &qs[0].ID, UserID: &bob}},
+	} {
+		t.Run(tt.name, func(t *testing.T) {
+			found, total, err := s.FindQuestions(ctx, tt.filter)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if total != len(tt.want) || len(found) != len(tt.want) {
+				t.Fatalf("found %d questions of %d, want %d", len(found), total, len(tt.want))
+			}
+			for i := range found {
+				if found[i].ID != tt.want[i].ID {
+					t.Errorf("question %d = %s, want %s", i, found[i].Content, tt.want[i].Content)
+				}
+			}
+		})
+	}
+
+	// pages are cut after sorting, the total counts every match.
+	found, total, err := s.FindQuestions(ctx, service.QuestionFilter{}, service.FindOptions{Offset: 1, Limit: 1, Descending: true})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if total != 3 || len(found) != 1 || found[0].ID != qs[1].ID {
+		t.Errorf("page = %+v, %d, want the second question of 3", found, total)
+	}
+}
@@ -0,0 +1,133 @@
+package store
+
+import (
+	"context"
+
+	"github.com/ustackq/indagate/pkg/service"
+	"github.com/ustackq/indagate/pkg/utils/errors"
+)
+
+// assert Service implement service.RoleService
+var _ service.RoleService = (*Service)(nil)
+
+// FindOrgRoles returns the role mappings of all users of the org.
+func (s *Service) FindOrgRoles(ctx context.Context, orgID service.ID) ([]*service.UserResourceMapping, error) {
+	var ms []*service.UserResourceMapping
+	err := s.store.View(ctx, func(tx Impl) error {
+		mappings, err := s.findOrgRoles(ctx, tx, orgID)
+		if err != nil {
+			return err
+		}
+		ms = mappings
+		return nil
+	})
+	if err != nil {
+		return nil, &errors.Error{
+			Err: err,
+			Op:  service.OpFindOrgRoles,
+		}
+	}
+	return ms, nil
+}
+
+func (s *Service) findOrgRoles(ctx context.Context, tx Impl, orgID service.ID) ([]*service.UserResourceMapping, error) {
+	ms, err := s.findUserResourceMappings(ctx, tx, service.UserResourceMappingFilter{
+		ResourceID:   orgID,
+		ResourceType: service.OrgsResourceType,
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	for _, m := range ms {
+		m.Role = service.MappingRole(m)
+	}
+	return ms, nil
+}
+
+// SetUserRole assigns role to the user within the org, replacing its former role.
+func (s *Service) SetUserRole(ctx context.Context, orgID, userID service.ID, role service.Role) (*service.UserResourceMapping, error) {
+	var m *service.UserResourceMapping
+	err := s.store.Modify(ctx, func(tx Impl) error {
+		mapping, err := s.setUserRole(ctx, tx, orgID, userID, role)
+		if err != nil {
+			return err
+		}
+		m = mapping
+		return nil
+	})
+	if err != nil {
+		return nil, &errors.Error{
+			Err: err,
+			Op:  service.OpSetUserRole,
+		}
+	}
+	return m, nil
+}
+
+func (s *Service) setUserRole(ctx context.Context, tx Impl, orgID, userID service.ID, role service.Role) (*service.UserResourceMapping, error) {
+	if err := role.Valid(); err != nil {
+		return nil, err
+	}
+
+	if _, err := s.findUserByID(ctx, tx, userID); err != nil {
+		return nil, err
+	}
+
+	m, err := s.findUserResourceMapping(ctx, tx, orgID, userID)
+	if err != nil && errors.ErrorCode(err) != errors.NotFound {
+		return nil, err
+	}
+
+	if m == nil {
+		m = &service.UserResourceMapping{
+			UserID:       userID,
+			MappingType:  service.UserMappingType,
+			ResourceType: service.OrgsResourceType,
+			ResourceID:   orgID,
+		}
+	}
+
+	// owners are the admins of an org, everyone else is a member.
+	m.UserType = service.Member
+	if role == service.RoleAdmin {
+		m.UserType = service.Owner
+	}
+	m.Role = role
+
+	if err := s.putUserResourceMapping(ctx, tx, m); err != nil {
+		return nil, err
+	}
+	return m, nil
+}
+
+// FindUserPermissions returns the permissions granted to the user by all of its roles.
+func (s *Service) FindUserPermissions(ctx context.Context, userID service.ID) ([]*service.Permission, error) {
+	var ps []*service.Permission
+	err := s.store.View(ctx, func(tx Impl) error {
+		permissions, err := s.findUserPermissions(ctx, tx, userID)
+		if err != nil {
+			return err
+		}
+		ps = permissions
+		return nil
+	})
+	if err != nil {
+		return nil, &errors.Error{
+			Err: err,
+			Op:  service.OpFindUserPermissions,
+		}
+	}
+	return ps, nil
+}
+
+func (s *Service) findUserPermissions(ctx context.Context, tx Impl, userID service.ID) ([]*service.Permission, error) {
+	ms, err := s.findUserResourceMappings(ctx, tx, service.UserResourceMappingFilter{
+		UserID:       userID,
+		ResourceType: service.OrgsResourceType,
+	})
+	if err != nil {
+		return nil, err
+	}
+	return service.UserPermissions(userID, ms)
+}
@@ -0,0 +1,87 @@
+package store_test
+
+import (
+	"bytes"
+	"context"
+	"testing"
+
+	"github.com/ustackq/indagate/pkg/service"
+	"github.com/ustackq/indagate/pkg/utils/errors"
+)
+
+func TestUserPermissions(t *testing.T) {
+	ctx := context.Background()
+	s := newInmemService(t)
+
+	archive := `{"kind":"header","data":{"format":1}}
+{"kind":"user","data":{"id":2,"name":"alice"}}`
+	if _, err := s.Import(ctx, bytes.NewReader([]byte(archive))); err != nil {
+		t.Fatal(err)
+	}
+
+	user, moderated, guarded := service.ID(2), service.ID(10), service.ID(11)
+	if _, err := s.SetUserRole(ctx, moderated, user, service.RoleMember); err != nil {
+		t.Fatal(err)
+	}
+	// the former role of the user in the org is replaced.
+	if _, err := s.SetUserRole(ctx, moderated, user, service.RoleModerator); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := s.SetUserRole(ctx, guarded, user, service.RoleGuest); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := s.SetUserRole(ctx, moderated, user, service.Role("owner")); errors.ErrorCode(err) != errors.Invalid {
+		t.Errorf("set unknown role error = %v, want %s", err, errors.Invalid)
+	}
+
+	ms, err := s.FindOrgRoles(ctx, moderated)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(ms) != 1 || ms[0].Role != service.RoleModerator || ms[0].UserType != service.Member {
+		t.Errorf("roles of the org = %+v, want alice as moderator", ms)
+	}
+
+	ps, err := s.FindUserPermissions(ctx, user)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for _, tt := range []struct {
+		action service.Action
+		rt     service.ResourceType
+		orgID  service.ID
+		want   bool
+	}{
+		{action: service.WriteAction, rt: service.QuestionsResourceType, orgID: moderated, want: true},
+		{action: service.WriteAction, rt: service.ModerationResourceType, orgID: moderated, want: true},
+		{action: service.WriteAction, rt: service.OrgsResourceType, orgID: moderated},
+		{action: service.ReadAction, rt: service.QuestionsResourceType, orgID: guarded, want: true},
+		{action: service.WriteAction, rt: service.QuestionsResourceType, orgID: guarded},
+		{action: service.ReadAction, rt: service.BucketsResourceType, orgID: guarded},
+		{action: service.ReadAction, rt: service.QuestionsResourceType, orgID: service.ID(12)},
+	} {
+		p, err := service.NewPermission(tt.action, tt.rt, tt.orgID)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if got := service.PermissionAllowed(*p, ps); got != tt.want {
+			t.Errorf("allowed %s = %t, want %t", p, got, tt.want)
+		}
+	}
+
+	// every user has full access to itself.
+	self := service.Permission{Action: service.WriteAction, Resource: service.Resource{Type: service.UsersResourceType, ID: &user}}
+	if !service.PermissionAllowed(self, ps) {
+		t.Errorf("allowed %s = false, want true", self)
+	}
+
+	// sessions carry the permissions of the roles.
+	sess, err := s.CreateSession(ctx, "alice")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(sess.Permissions) != len(ps) {
+		t.Errorf("session permissions = %v, want %v", sess.Permissions, ps)
+	}
+}
@@ -0,0 +1,675 @@
+package store
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"math"
+	"sort"
+
+	"github.com/ustackq/indagate/pkg/search"
+	"github.com/ustackq/indagate/pkg/service"
+	"github.com/ustackq/indagate/pkg/utils/errors"
+)
+
+const (
+	// searchTitleBoost weights the terms of titles over the terms of bodies.
+	searchTitleBoost = 3
+	// searchFragmentSize is the size in bytes of the highlighted fragments.
+	searchFragmentSize = 200
+)
+
+// The full-text index lives in the buckets of the store, next to the content it
+// indexes, so that it is updated in the same transaction as the content.
+var (
+	searchDocBucket     = []byte("searchdocsv1")
+	searchPostingBucket = []byte("searchpostingsv1")
+	searchMetaBucket    = []byte("searchmetav1")
+	searchBuckets       = [][]byte{searchDocBucket, searchPostingBucket, searchMetaBucket}
+
+	searchDocCountKey = []byte("docs")
+)
+
+// assert Service implement service.SearchService
+var _ service.SearchService = (*Service)(nil)
+
+// searchDoc is an indexed content.
+type searchDoc struct {
+	Type       service.SearchType `json:"type"`
+	ID         service.ID         `json:"id"`
+	OrgID      service.ID         `json:"orgID"`
+	QuestionID service.ID         `json:"questionID,omitempty"`
+	Title      string             `json:"title,omitempty"`
+	Body       string             `json:"body,omitempty"`
+	// Terms holds the frequency of the terms of the document, title terms are boosted.
+	Terms []searchTerm `json:"terms"`
+}
+
+type searchTerm struct {
+	Term string `json:"term"`
+	Freq int    `json:"freq"`
+}
+
+// searchables holds the documents of each searchable type, used to rebuild the index.
+var searchables = map[service.SearchType]func(*Service, context.Context, Impl) ([]*searchDoc, error){
+	service.QuestionSearchType: (*Service).questionSearchDocs,
+	service.AnswerSearchType:   (*Service).answerSearchDocs,
+	service.ArticleSearchType:  (*Service).articleSearchDocs,
+	service.TopicSearchType:    (*Service).topicSearchDocs,
+}
+
+func (s *Service) initializeSearch(ctx context.Context, tx Impl) error {
+	for _, b := range searchBuckets {
+		if _, err := s.searchBucket(tx, b); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (s *Service) searchBucket(tx Impl, name []byte) (Bucket, error) {
+	b, err := tx.Bucket(name)
+	if err != nil {
+		return nil, UnexpectedSearchError(err)
+	}
+	return b, nil
+}
+
+// UnexpectedSearchError wraps errors raised while retrieving the search buckets.
+func UnexpectedSearchError(err error) *errors.Error {
+	return &errors.Error{
+		Code: errors.Internal,
+		Msg:  fmt.Sprintf("unexpected error retrieving search bucket; %v", err),
+		Op:   "searchBucket",
+	}
+}
+
+func searchDocKey(t service.SearchType, id service.ID) ([]byte, error) {
+	encodedID, err := id.Encode()
+	if err != nil {
+		return nil, &errors.Error{
+			Code: errors.Invalid,
+			Err:  err,
+		}
+	}
+
+	return append([]byte(t+"/"), encodedID...), nil
+}
+
+// searchPostingPrefix returns the prefix of the postings of term, terms never contain a zero byte.
+func searchPostingPrefix(term string) []byte {
+	return append([]byte(term), 0)
+}
+
+// questionSearchDoc sets the question of the question to itself, which relates both questions
+// and answers to the topics of their question.
+func questionSearchDoc(q *service.Question) *searchDoc {
+	return &searchDoc{
+		Type:       service.QuestionSearchType,
+		ID:         q.ID,
+		OrgID:      q.OrgID,
+		QuestionID: q.ID,
+		Title:      q.Content,
+		Body:       q.Detail,
+	}
+}
+
+// answerSearchDoc indexes the content of the answer only, the title of its question
+// is looked up when it is shown.
+func answerSearchDoc(a *service.Answer) *searchDoc {
+	return &searchDoc{
+		Type:       service.AnswerSearchType,
+		ID:         a.ID,
+		OrgID:      a.OrgID,
+		QuestionID: a.QuestionID,
+		Body:       a.Content,
+	}
+}
+
+func articleSearchDoc(a *service.Article) *searchDoc {
+	body := a.Content
+	if a.Abstract != "" {
+		body = a.Abstract + "\n" + a.Content
+	}
+	return &searchDoc{
+		Type:  service.ArticleSearchType,
+		ID:    a.ID,
+		OrgID: a.OrgID,
+		Title: a.Title,
+		Body:  body,
+	}
+}
+
+func topicSearchDoc(t *service.Topic) *searchDoc {
+	return &searchDoc{
+		Type:  service.TopicSearchType,
+		ID:    t.ID,
+		OrgID: t.OrgID,
+		Title: t.Title,
+		Body:  t.Description,
+	}
+}
+
+// questionSearchDocs returns the questions which are neither hidden nor pending approval.
+func (s *Service) questionSearchDocs(ctx context.Context, tx Impl) ([]*searchDoc, error) {
+	docs := []*searchDoc{}
+	err := s.forEachQuestion(ctx, tx, func(q *service.Question) bool {
+		if q.Visible() {
+			docs = append(docs, questionSearchDoc(q))
+		}
+		return true
+	})
+	return docs, err
+}
+
+// answerSearchDocs returns the answers which are neither hidden nor pending approval.
+func (s *Service) answerSearchDocs(ctx context.Context, tx Impl) ([]*searchDoc, error) {
+	docs := []*searchDoc{}
+	err := s.forEachAnswer(ctx, tx, func(a *service.Answer) bool {
+		if a.Visible() {
+			docs = append(docs, answerSearchDoc(a))
+		}
+		return true
+	})
+	return docs, err
+}
+
+// articleSearchDocs returns the published articles, drafts and archived articles are not searchable.
+func (s *Service) articleSearchDocs(ctx context.Context, tx Impl) ([]*searchDoc, error) {
+	docs := []*searchDoc{}
+	err := s.forEachArticle(ctx, tx, func(a *service.Article) bool {
+		if a.State == service.ArticlePublished {
+			docs = append(docs, articleSearchDoc(a))
+		}
+		return true
+	})
+	return docs, err
+}
+
+// topicSearchDocs returns the live topics, merged topics are found through their target.
+func (s *Service) topicSearchDocs(ctx context.Context, tx Impl) ([]*searchDoc, error) {
+	docs := []*searchDoc{}
+	err := s.forEachTopic(ctx, tx, func(t *service.Topic) bool {
+		if !t.MergedID.Valid() {
+			docs = append(docs, topicSearchDoc(t))
+		}
+		return true
+	})
+	return docs, err
+}
+
+// indexQuestion indexes a question and removes a hidden or pending one from the index.
+func (s *Service) indexQuestion(ctx context.Context, tx Impl, q *service.Question) error {
+	if !q.Visible() {
+		return s.unindexSearchDoc(ctx, tx, service.QuestionSearchType, q.ID)
+	}
+	return s.indexSearchDoc(ctx, tx, questionSearchDoc(q))
+}
+
+// indexAnswer indexes an answer and removes a hidden or pending one from the index.
+func (s *Service) indexAnswer(ctx context.Context, tx Impl, a *service.Answer) error {
+	if !a.Visible() {
+		return s.unindexSearchDoc(ctx, tx, service.AnswerSearchType, a.ID)
+	}
+	return s.indexSearchDoc(ctx, tx, answerSearchDoc(a))
+}
+
+// indexArticle indexes a published article and removes any other from the index.
+func (s *Service) indexArticle(ctx context.Context, tx Impl, a *service.Article) error {
+	if a.State != service.ArticlePublished {
+		return s.unindexSearchDoc(ctx, tx, service.ArticleSearchType, a.ID)
+	}
+	return s.indexSearchDoc(ctx, tx, articleSearchDoc(a))
+}
+
+// indexTopic indexes a live topic and removes a merged one from the index.
+func (s *Service) indexTopic(ctx context.Context, tx Impl, t *service.Topic) error {
+	if t.MergedID.Valid() {
+		return s.unindexSearchDoc(ctx, tx, service.TopicSearchType, t.ID)
+	}
+	return s.indexSearchDoc(ctx, tx, topicSearchDoc(t))
+}
+
+func unmarshalSearchDoc(v []byte) (*searchDoc, error) {
+	d := &searchDoc{}
+	if err := json.Unmarshal(v, d); err != nil {
+		return nil, &errors.Error{
+			Code: errors.Internal,
+			Msg:  "search document could not be unmarshalled",
+			Err:  err,
+			Op:   "unmarshalSearchDoc",
+		}
+	}
+	return d, nil
+}
+
+func (s *Service) findSearchDoc(ctx context.Context, tx Impl, key []byte) (*searchDoc, error) {
+	b, err := s.searchBucket(tx, searchDocBucket)
+	if err != nil {
+		return nil, err
+	}
+
+	v, err := b.Get(key)
+	if err != nil {
+		return nil, err
+	}
+	return unmarshalSearchDoc(v)
+}
+
+// indexSearchDoc replaces the document in the index.
+func (s *Service) indexSearchDoc(ctx context.Context, tx Impl, d *searchDoc) error {
+	if err := s.unindexSearchDoc(ctx, tx, d.Type, d.ID); err != nil {
+		return err
+	}
+
+	tfs := search.Frequencies(d.Body)
+	for term, tf := range search.Frequencies(d.Title) {
+		tfs[term] += tf * searchTitleBoost
+	}
+
+	d.Terms = make([]searchTerm, 0, len(tfs))
+	for term, tf := range tfs {
+		d.Terms = append(d.Terms, searchTerm{Term: term, Freq: tf})
+	}
+	sort.Slice(d.Terms, func(i, j int) bool {
+		return d.Terms[i].Term < d.Terms[j].Term
+	})
+
+	key, err := searchDocKey(d.Type, d.ID)
+	if err != nil {
+		return err
+	}
+
+	v, err := json.Marshal(d)
+	if err != nil {
+		return &errors.Error{
+			Code: errors.Invalid,
+			Err:  err,
+		}
+	}
+
+	b, err := s.searchBucket(tx, searchDocBucket)
+	if err != nil {
+		return err
+	}
+
+	if err := b.Put(key, v); err != nil {
+		return errors.InternalErr(err)
+	}
+
+	pb, err := s.searchBucket(tx, searchPostingBucket)
+	if err != nil {
+		return err
+	}
+
+	for _, t := range d.Terms {
+		v, err := json.Marshal(t.Freq)
+		if err != nil {
+			return errors.InternalErr(err)
+		}
+		if err := pb.Put(append(searchPostingPrefix(t.Term), key...), v); err != nil {
+			return errors.InternalErr(err)
+		}
+	}
+	return s.adjustSearchDocCount(ctx, tx, 1)
+}
+
+// unindexSearchDoc removes the document and its postings from the index, if it is indexed.
+func (s *Service) unindexSearchDoc(ctx context.Context, tx Impl, t service.SearchType, id service.ID) error {
+	key, err := searchDocKey(t, id)
+	if err != nil {
+		return err
+	}
+
+	d, err := s.findSearchDoc(ctx, tx, key)
+	if IsNotFound(err) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+
+	pb, err := s.searchBucket(tx, searchPostingBucket)
+	if err != nil {
+		return err
+	}
+
+	for _, t := range d.Terms {
+		if err := pb.Delete(append(searchPostingPrefix(t.Term), key...)); err != nil {
+			return errors.WrapperErr(err)
+		}
+	}
+
+	b, err := s.searchBucket(tx, searchDocBucket)
+	if err != nil {
+		return err
+	}
+
+	if err := b.Delete(key); err != nil {
+		return errors.WrapperErr(err)
+	}
+	return s.adjustSearchDocCount(ctx, tx, -1)
+}
+
+func (s *Service) searchDocCount(ctx context.Context, tx Impl) (int, error) {
+	b, err := s.searchBucket(tx, searchMetaBucket)
+	if err != nil {
+		return 0, err
+	}
+
+	v, err := b.Get(searchDocCountKey)
+	if IsNotFound(err) {
+		return 0, nil
+	}
+	if err != nil {
+		return 0, err
+	}
+
+	var n int
+	if err := json.Unmarshal(v, &n); err != nil {
+		return 0, errors.InternalErr(err)
+	}
+	return n, nil
+}
+
+func (s *Service) adjustSearchDocCount(ctx context.Context, tx Impl, delta int) error {
+	n, err := s.searchDocCount(ctx, tx)
+	if err != nil {
+		return err
+	}
+
+	n += delta
+	if n < 0 {
+		n = 0
+	}
+
+	v, err := json.Marshal(n)
+	if err != nil {
+		return errors.InternalErr(err)
+	}
+
+	b, err := s.searchBucket(tx, searchMetaBucket)
+	if err != nil {
+		return err
+	}
+
+	if err := b.Put(searchDocCountKey, v); err != nil {
+		return errors.InternalErr(err)
+	}
+	return nil
+}
+
+// Search returns the content matching the query, best match first.
+// Documents are scored by the TF-IDF of the query terms, all of which must match.
+func (s *Service) Search(ctx context.Context, q service.SearchQuery, opt ...service.FindOptions) ([]*service.SearchResult, int, error) {
+	var (
+		rs    []*service.SearchResult
+		total int
+	)
+	err := s.store.View(ctx, func(tx Impl) error {
+		results, n, err := s.search(ctx, tx, q, opt...)
+		if err != nil {
+			return err
+		}
+		rs, total = results, n
+		return nil
+	})
+	if err != nil {
+		return nil, 0, &errors.Error{
+			Err: err,
+			Op:  service.OpSearch,
+		}
+	}
+	return rs, total, nil
+}
+
+func (s *Service) search(ctx context.Context, tx Impl, q service.SearchQuery, opts ...service.FindOptions) ([]*service.SearchResult, int, error) {
+	if err := q.Valid(); err != nil {
+		return nil, 0, err
+	}
+
+	terms := search.Terms(q.Query)
+	if len(terms) == 0 {
+		return []*service.SearchResult{}, 0, nil
+	}
+
+	var typePrefix []byte
+	if q.Type != nil {
+		typePrefix = []byte(*q.Type + "/")
+	}
+
+	scores, err := s.scoreSearchDocs(ctx, tx, terms, typePrefix)
+	if err != nil {
+		return nil, 0, err
+	}
+
+	var inTopic func(d *searchDoc) bool
+	if q.TopicID != nil {
+		if inTopic, err = s.searchTopicFilter(ctx, tx, *q.TopicID); err != nil {
+			return nil, 0, err
+		}
+	}
+
+	docs := make(map[*service.SearchResult]*searchDoc, len(scores))
+	rs := make([]*service.SearchResult, 0, len(scores))
+	for key, score := range scores {
+		d, err := s.findSearchDoc(ctx, tx, []byte(key))
+		if err != nil {
+			return nil, 0, err
+		}
+
+		if inTopic != nil && !inTopic(d) {
+			continue
+		}
+
+		r := &service.SearchResult{
+			Type:       d.Type,
+			ID:         d.ID,
+			OrgID:      d.OrgID,
+			QuestionID: d.QuestionID,
+			Title:      d.Title,
+			Score:      score,
+		}
+		if d.Type != service.AnswerSearchType {
+			r.QuestionID = 0
+		}
+		if q.Readable != nil {
+			ok, err := q.Readable(r)
+			if err != nil {
+				return nil, 0, err
+			}
+			if !ok {
+				continue
+			}
+		}
+		docs[r] = d
+		rs = append(rs, r)
+	}
+
+	sort.SliceStable(rs, func(i, j int) bool {
+		if rs[i].Score != rs[j].Score {
+			return rs[i].Score > rs[j].Score
+		}
+		return rs[i].ID > rs[j].ID
+	})
+
+	total := len(rs)
+	start, end := pageWindow(total, opts...)
+	rs = rs[start:end]
+	for _, r := range rs {
+		if err := s.highlightSearchResult(ctx, tx, r, docs[r], terms); err != nil {
+			return nil, 0, err
+		}
+	}
+	return rs, total, nil
+}
+
+// scoreSearchDocs returns the score of the documents matching all the terms by their key.
+func (s *Service) scoreSearchDocs(ctx context.Context, tx Impl, terms []string, typePrefix []byte) (map[string]float64, error) {
+	n, err := s.searchDocCount(ctx, tx)
+	if err != nil {
+		return nil, err
+	}
+
+	pb, err := s.searchBucket(tx, searchPostingBucket)
+	if err != nil {
+		return nil, err
+	}
+
+	var scores map[string]float64
+	for i, term := range terms {
+		prefix := searchPostingPrefix(term)
+		tfs := map[string]int{}
+		err := forEachPrefix(pb, prefix, func(k, v []byte) error {
+			var tf int
+			if err := json.Unmarshal(v, &tf); err != nil {
+				return errors.InternalErr(err)
+			}
+			tfs[string(k[len(prefix):])] = tf
+			return nil
+		})
+		if err != nil {
+			return nil, err
+		}
+
+		// the document frequency counts documents of every type, so that the
+		// scores of a query do not depend on its type filter.
+		idf := math.Log(1 + float64(n)/float64(len(tfs)+1))
+		next := map[string]float64{}
+		for key, tf := range tfs {
+			if !bytes.HasPrefix([]byte(key), typePrefix) {
+				continue
+			}
+			if _, ok := scores[key]; i > 0 && !ok {
+				continue
+			}
+			next[key] = scores[key] + (1+math.Log(float64(tf)))*idf
+		}
+
+		scores = next
+		if len(scores) == 0 {
+			break
+		}
+	}
+	return scores, nil
+}
+
+// searchTopicFilter returns whether documents are related to the topic: questions and articles
+// through their topic relations and answers through their question.
+func (s *Service) searchTopicFilter(ctx context.Context, tx Impl, topicID service.ID) (func(d *searchDoc) bool, error) {
+	relations, err := s.findTopicRelations(ctx, tx, service.TopicRelationFilter{TopicID: &topicID})
+	if err != nil {
+		return nil, err
+	}
+
+	questions := map[service.ID]bool{}
+	articles := map[service.ID]bool{}
+	for _, r := range relations {
+		switch r.ItemType {
+		case service.QuestionItemType:
+			questions[r.ItemID] = true
+		case service.ArticleItemType:
+			articles[r.ItemID] = true
+		}
+	}
+
+	return func(d *searchDoc) bool {
+		switch d.Type {
+		case service.QuestionSearchType, service.AnswerSearchType:
+			return questions[d.QuestionID]
+		case service.ArticleSearchType:
+			return articles[d.ID]
+		}
+		return false
+	}, nil
+}
+
+// highlightSearchResult sets the title of answers from their question and the highlighted
+// fragment of the body, or of the title when the body does not match.
+func (s *Service) highlightSearchResult(ctx context.Context, tx Impl, r *service.SearchResult, d *searchDoc, terms []string) error {
+	if d.Type == service.AnswerSearchType {
+		q, err := s.findQuestionByID(ctx, tx, d.QuestionID)
+		if err != nil && err != service.ErrQuestionNotFound {
+			return err
+		}
+		if q != nil {
+			r.Title = q.Content
+		}
+	}
+
+	matches := search.Frequencies(d.Body)
+	for _, term := range terms {
+		if matches[term] > 0 {
+			r.Highlight = search.Highlight(d.Body, terms, searchFragmentSize)
+			return nil
+		}
+	}
+	r.Highlight = search.Highlight(d.Title, terms, searchFragmentSize)
+	return nil
+}
+
+// Reindex rebuilds the full-text index from the content of the store and returns the
+// number of indexed documents.
+func (s *Service) Reindex(ctx context.Context) (int, error) {
+	var n int
+	err := s.store.Modify(ctx, func(tx Impl) error {
+		count, err := s.reindex(ctx, tx)
+		if err != nil {
+			return &errors.Error{
+				Err: err,
+				Op:  service.OpReindex,
+			}
+		}
+		n = count
+		return nil
+	})
+	return n, err
+}
+
+func (s *Service) reindex(ctx context.Context, tx Impl) (int, error) {
+	for _, name := range searchBuckets {
+		if err := s.clearSearchBucket(ctx, tx, name); err != nil {
+			return 0, err
+		}
+	}
+
+	var n int
+	for _, t := range service.AllSearchTypes {
+		docs, err := searchables[t](s, ctx, tx)
+		if err != nil {
+			return 0, err
+		}
+
+		for _, d := range docs {
+			if err := s.indexSearchDoc(ctx, tx, d); err != nil {
+				return 0, err
+			}
+		}
+		n += len(docs)
+	}
+	return n, nil
+}
+
+func (s *Service) clearSearchBucket(ctx context.Context, tx Impl, name []byte) error {
+	b, err := s.searchBucket(tx, name)
+	if err != nil {
+		return err
+	}
+
+	cur, err := b.Cursor()
+	if err != nil {
+		return err
+	}
+
+	var keys [][]byte
+	for k, _ := cur.First(); k != nil; k, _ = cur.Next() {
+		keys = append(keys, append([]byte(nil), k...))
+	}
+
+	for _, k := range keys {
+		if err := b.Delete(k); err != nil {
+			return errors.WrapperErr(err)
+		}
+	}
+	return nil
+}
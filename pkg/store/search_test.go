@@ -0,0 +1,150 @@
+package store_test
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	"github.com/ustackq/indagate/pkg/service"
+	"github.com/ustackq/indagate/pkg/store"
+)
+
+func searchIDs(t *testing.T, s *store.Service, q service.SearchQuery) []service.ID {
+	t.Helper()
+
+	rs, _, err := s.Search(context.Background(), q)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	ids := []service.ID{}
+	for _, r := range rs {
+		ids = append(ids, r.ID)
+	}
+	return ids
+}
+
+func TestSearch(t *testing.T) {
+	ctx := context.Background()
+	s := newInmemService(t)
+
+	q := &service.Question{OrgID: service.ID(1), UserID: service.ID(2), Content: "Tuning the garbage collector", Detail: "long GC pauses"}
+	if err := s.CreateQuestion(ctx, q); err != nil {
+		t.Fatal(err)
+	}
+	a := &service.Answer{QuestionID: q.ID, UserID: service.ID(3), Content: "Lower GOGC to collect garbage sooner"}
+	if err := s.CreateAnswer(ctx, a); err != nil {
+		t.Fatal(err)
+	}
+	article := &service.Article{OrgID: service.ID(1), UserID: service.ID(2), Title: "Garbage", Content: "a draft"}
+	if err := s.CreateArticle(ctx, article); err != nil {
+		t.Fatal(err)
+	}
+
+	// titles weigh more than bodies and drafts are not searchable.
+	if ids := searchIDs(t, s, service.SearchQuery{Query: "garbage"}); len(ids) != 2 || ids[0] != q.ID || ids[1] != a.ID {
+		t.Fatalf("search garbage = %v, want the question then the answer", ids)
+	}
+	if _, err := s.PublishArticle(ctx, article.ID); err != nil {
+		t.Fatal(err)
+	}
+	articles := service.ArticleSearchType
+	if ids := searchIDs(t, s, service.SearchQuery{Query: "garbage", Type: &articles}); len(ids) != 1 || ids[0] != article.ID {
+		t.Errorf("search published articles = %v, want the article", ids)
+	}
+
+	// every term must match.
+	if ids := searchIDs(t, s, service.SearchQuery{Query: "garbage GOGC"}); len(ids) != 1 || ids[0] != a.ID {
+		t.Errorf("search garbage GOGC = %v, want the answer", ids)
+	}
+
+	// answers are found through the topics of their question, articles and topics are not related.
+	topic := createTopic(t, s, "Garbage collection", 0)
+	if err := s.AddTopicRelation(ctx, &service.TopicRelation{TopicID: topic.ID, ItemType: service.QuestionItemType, ItemID: q.ID, UserID: q.UserID}); err != nil {
+		t.Fatal(err)
+	}
+	if ids := searchIDs(t, s, service.SearchQuery{Query: "garbage"}); len(ids) != 4 {
+		t.Errorf("search garbage = %v, want the question, the answer, the article and the topic", ids)
+	}
+	if ids := searchIDs(t, s, service.SearchQuery{Query: "garbage", TopicID: &topic.ID}); len(ids) != 2 {
+		t.Errorf("search garbage in topic = %v, want the question and the answer", ids)
+	}
+
+	// unreadable results are dropped before the page and the total.
+	readable := service.SearchQuery{Query: "garbage", Readable: func(r *service.SearchResult) (bool, error) {
+		return r.Type != service.QuestionSearchType, nil
+	}}
+	if rs, total, err := s.Search(ctx, readable, service.FindOptions{Limit: 1}); err != nil || total != 3 || len(rs) != 1 || rs[0].ID == q.ID {
+		t.Errorf("search readable = %+v, %d, %v, want 1 of 3 results without the question", rs, total, err)
+	}
+
+	// updates and deletes are reflected in the index.
+	content := "Tuning the allocator"
+	if _, err := s.UpdateQuestion(ctx, q.ID, service.QuestionUpdate{Content: &content}); err != nil {
+		t.Fatal(err)
+	}
+	if err := s.DeleteAnswer(ctx, a.ID); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := s.ArchiveArticle(ctx, article.ID); err != nil {
+		t.Fatal(err)
+	}
+	if ids := searchIDs(t, s, service.SearchQuery{Query: "garbage"}); len(ids) != 1 || ids[0] != topic.ID {
+		t.Errorf("search garbage = %v, want the topic", ids)
+	}
+
+	n, err := s.Reindex(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if n != 2 {
+		t.Errorf("reindexed %d documents, want the question and the topic", n)
+	}
+	if ids := searchIDs(t, s, service.SearchQuery{Query: "allocator"}); len(ids) != 1 || ids[0] != q.ID {
+		t.Errorf("search allocator after reindex = %v, want the question", ids)
+	}
+}
+
+func TestSearchCJK(t *testing.T) {
+	ctx := context.Background()
+	s := newInmemService(t)
+
+	q := &service.Question{OrgID: service.ID(1), UserID: service.ID(2), Content: "如何设计数据库索引", Detail: "用Go写的数据库需要什么索引？"}
+	if err := s.CreateQuestion(ctx, q); err != nil {
+		t.Fatal(err)
+	}
+
+	rs, n, err := s.Search(ctx, service.SearchQuery{Query: "数据库"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if n != 1 || rs[0].ID != q.ID {
+		t.Fatalf("search 数据库 = %d results, want the question", n)
+	}
+	if want := "用Go写的<mark>数据库</mark>需要什么索引？"; rs[0].Highlight != want {
+		t.Errorf("highlight = %q, want %q", rs[0].Highlight, want)
+	}
+
+	// the bigrams of the query must all match.
+	if ids := searchIDs(t, s, service.SearchQuery{Query: "数据表"}); len(ids) != 0 {
+		t.Errorf("search 数据表 = %v, want none", ids)
+	}
+	if ids := searchIDs(t, s, service.SearchQuery{Query: "go"}); len(ids) != 1 {
+		t.Errorf("search go = %v, want the question", ids)
+	}
+}
+
+func TestSearchLongTerms(t *testing.T) {
+	ctx := context.Background()
+	s := newInmemService(t)
+
+	// words longer than the index keys are cut, in the documents and the queries alike.
+	word := strings.Repeat("ab", store.MaxKeySize)
+	q := &service.Question{OrgID: service.ID(1), UserID: service.ID(2), Content: "what is " + word}
+	if err := s.CreateQuestion(ctx, q); err != nil {
+		t.Fatal(err)
+	}
+	if ids := searchIDs(t, s, service.SearchQuery{Query: word}); len(ids) != 1 || ids[0] != q.ID {
+		t.Errorf("search long word = %v, want the question", ids)
+	}
+}
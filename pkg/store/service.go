@@ -2,6 +2,7 @@ package store
 
 import (
 	"context"
+	"github.com/prometheus/client_golang/prometheus"
 	"github.com/ustackq/indagate/pkg/service"
 	"github.com/ustackq/indagate/pkg/utils/generator"
 	"go.uber.org/zap"
@@ -15,7 +16,21 @@ type Service struct {
 	Hash           *service.BCrypt
 	IDGenerator    service.IDGenerator
 	TokenGenerator generator.TokenGenerator
-	time           func() time.Time
+	// ContentFilter is run on user content before it is written, nil allows everything.
+	ContentFilter service.ContentFilter
+	// SpamTrainer learns from the moderator decisions, it is trained again with the
+	// kept decisions by Init.
+	SpamTrainer service.SpamTrainer
+	// NotificationMailer renders the mails queued along with the notifications,
+	// nil sends none.
+	NotificationMailer service.NotificationMailer
+	// Migrations are applied by Init, defaults to the registered Migrations.
+	Migrations []Migration
+	time       func() time.Time
+	// liveSessions is the number of unexpired sessions, set by SweepSessions.
+	liveSessions prometheus.Gauge
+	// ipBlocks caches the IP blocks checked on every request.
+	ipBlocks ipBlockCache
 }
 
 func NewService(s Store, configs ...ServiceConfig) *Service {
@@ -25,6 +40,7 @@ func NewService(s Store, configs ...ServiceConfig) *Service {
 		IDGenerator:    generator.NewIDGenerator(),
 		TokenGenerator: generator.NewTokenGenerator(64),
 		Hash:           &service.BCrypt{},
+		Migrations:     Migrations,
 		store:          s,
 	}
 	if len(configs) > 0 {
@@ -32,21 +48,106 @@ func NewService(s Store, configs ...ServiceConfig) *Service {
 	} else {
 		service.Config.SessionLength = time.Minute * 60
 	}
+	service.initMetrics()
 	return service
 }
 
 // ServiceConfig allows admin to configure session service.
 type ServiceConfig struct {
 	SessionLength time.Duration
+	// CommentEditWindow is how long authors can edit their comments, defaults to DefaultCommentEditWindow.
+	CommentEditWindow time.Duration
+	// FeedActiveWindow is how long after reading their feed users get activities fanned
+	// out to them, defaults to DefaultFeedActiveWindow.
+	FeedActiveWindow time.Duration
+	// ApprovalPolicy decides which posts and edits wait for the approval of a reviewer.
+	ApprovalPolicy service.ApprovalPolicy
+	// SkipMigrations leaves pending migrations to be applied by MigrateUp.
+	SkipMigrations bool
 }
 
+// Init creates the buckets of all services and applies the pending migrations.
 func (s *Service) Init(ctx context.Context) error {
-
-	return s.store.Modify(ctx, func(tx Impl) error {
+	err := s.store.Modify(ctx, func(tx Impl) error {
+		if err := s.initializeMigrations(ctx, tx); err != nil {
+			return err
+		}
 		if err := s.initializeAuth(ctx, tx); err != nil {
 			return err
 		}
+		if err := s.initializaUsers(ctx, tx); err != nil {
+			return err
+		}
+		if err := s.initializeAccountTokens(ctx, tx); err != nil {
+			return err
+		}
+		if err := s.initializeURMs(ctx, tx); err != nil {
+			return err
+		}
+		if err := s.initializeSessions(ctx, tx); err != nil {
+			return err
+		}
+		if err := s.initializeQuestions(ctx, tx); err != nil {
+			return err
+		}
 		// TODO: other service
-		return s.initializaUsers(ctx, tx)
+		if err := s.initializeAnswers(ctx, tx); err != nil {
+			return err
+		}
+		if err := s.initializeArticles(ctx, tx); err != nil {
+			return err
+		}
+		if err := s.initializeNotifications(ctx, tx); err != nil {
+			return err
+		}
+		if err := s.initializeMessages(ctx, tx); err != nil {
+			return err
+		}
+		if err := s.initializeFollows(ctx, tx); err != nil {
+			return err
+		}
+		if err := s.initializeFeeds(ctx, tx); err != nil {
+			return err
+		}
+		if err := s.initializeDrafts(ctx, tx); err != nil {
+			return err
+		}
+		if err := s.initializeVotes(ctx, tx); err != nil {
+			return err
+		}
+		if err := s.initializeComments(ctx, tx); err != nil {
+			return err
+		}
+		if err := s.initializeTopics(ctx, tx); err != nil {
+			return err
+		}
+		if err := s.initializeSearch(ctx, tx); err != nil {
+			return err
+		}
+		if err := s.initializeModeration(ctx, tx); err != nil {
+			return err
+		}
+		if err := s.initializeApprovals(ctx, tx); err != nil {
+			return err
+		}
+		if err := s.initializeSpamSamples(ctx, tx); err != nil {
+			return err
+		}
+		if err := s.initializeBans(ctx, tx); err != nil {
+			return err
+		}
+		return s.initializeMails(ctx, tx)
 	})
+	if err != nil {
+		return err
+	}
+
+	if err := s.trainSpamSamples(ctx); err != nil {
+		return err
+	}
+
+	if s.Config.SkipMigrations {
+		return nil
+	}
+	return s.MigrateUp(ctx, 0)
 }
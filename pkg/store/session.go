@@ -0,0 +1,419 @@
+package store
+
+import (
+	"context"
+	"encoding/binary"
+	"fmt"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"go.uber.org/zap"
+
+	"github.com/ustackq/indagate/pkg/service"
+	"github.com/ustackq/indagate/pkg/utils/errors"
+	"github.com/ustackq/indagate/pkg/utils/wait"
+)
+
+var (
+	sessionBucket      = []byte("sessionsv1")
+	sessionExpiryIndex = []byte("sessionexpiryindexv1")
+)
+
+// DefaultSessionSweepPeriod is the default interval between two sweeps of expired sessions.
+const DefaultSessionSweepPeriod = time.Minute
+
+// assert Service implement service.SessionService
+var _ service.SessionService = (*Service)(nil)
+
+func (s *Service) initMetrics() {
+	s.liveSessions = prometheus.NewGauge(prometheus.GaugeOpts{
+		Namespace: "indagate",
+		Subsystem: "sessions",
+		Name:      "live",
+		Help:      "Number of unexpired sessions in the store",
+	})
+}
+
+// PrometheusCollectors returns all prometheus collectors of the store service.
+func (s *Service) PrometheusCollectors() []prometheus.Collector {
+	return []prometheus.Collector{
+		s.liveSessions,
+	}
+}
+
+func (s *Service) initializeSessions(ctx context.Context, tx Impl) error {
+	if _, err := s.sessionBucket(tx); err != nil {
+		return err
+	}
+
+	if _, err := s.sessionExpiryIndexBucket(tx); err != nil {
+		return err
+	}
+	return nil
+}
+
+func (s *Service) sessionBucket(tx Impl) (Bucket, error) {
+	b, err := tx.Bucket(sessionBucket)
+	if err != nil {
+		return nil, UnexpectedSessionError(err)
+	}
+	return b, nil
+}
+
+func (s *Service) sessionExpiryIndexBucket(tx Impl) (Bucket, error) {
+	b, err := tx.Bucket(sessionExpiryIndex)
+	if err != nil {
+		return nil, UnexpectedSessionIndexError(err)
+	}
+	return b, nil
+}
+
+// UnexpectedSessionError wraps errors raised while retrieving the session bucket.
+func UnexpectedSessionError(err error) *errors.Error {
+	return &errors.Error{
+		Code: errors.Internal,
+		Msg:  fmt.Sprintf("unexpected error retrieving session bucket; %v", err),
+		Op:   "sessionBucket",
+	}
+}
+
+// UnexpectedSessionIndexError wraps errors raised while retrieving the session expiry index.
+func UnexpectedSessionIndexError(err error) *errors.Error {
+	return &errors.Error{
+		Code: errors.Internal,
+		Msg:  fmt.Sprintf("unexpected error retrieving session expiry index; %v", err),
+		Op:   "sessionExpiryIndexBucket",
+	}
+}
+
+// sessionExpiryIndexKey is the big endian expiration time followed by the session key,
+// so that a cursor walks the index from the soonest to the latest expiration.
+func sessionExpiryIndexKey(expiresAt time.Time, key string) []byte {
+	k := make([]byte, 8, 8+len(key))
+	binary.BigEndian.PutUint64(k, uint64(expiresAt.UnixNano()))
+	return append(k, key...)
+}
+
+// FindSession retrieves the session found at the provided key.
+func (s *Service) FindSession(ctx context.Context, key string) (*service.Session, error) {
+	var sess *service.Session
+	err := s.store.View(ctx, func(tx Impl) error {
+		session, err := s.findSession(ctx, tx, key)
+		if err != nil {
+			return err
+		}
+		sess = session
+		return nil
+	})
+	if err != nil {
+		return nil, &errors.Error{
+			Err: err,
+			Op:  service.OpFindSession,
+		}
+	}
+
+	if err := sess.Expired(); err != nil {
+		return nil, &errors.Error{
+			Err: err,
+			Op:  service.OpFindSession,
+		}
+	}
+	return sess, nil
+}
+
+func (s *Service) findSession(ctx context.Context, tx Impl, key string) (*service.Session, error) {
+	b, err := s.sessionBucket(tx)
+	if err != nil {
+		return nil, err
+	}
+
+	v, err := b.Get([]byte(key))
+	if IsNotFound(err) {
+		return nil, &errors.Error{
+			Code: errors.NotFound,
+			Msg:  service.ErrSessionNotFound,
+		}
+	}
+
+	if err != nil {
+		return nil, &errors.Error{
+			Code: errors.Internal,
+			Err:  err,
+		}
+	}
+	return unmarshalSession(v)
+}
+
+func unmarshalSession(v []byte) (*service.Session, error) {
+	sess := &service.Session{}
+	if err := json.Unmarshal(v, sess); err != nil {
+		return nil, &errors.Error{
+			Code: errors.Internal,
+			Msg:  "session could not be unmarshalled",
+			Err:  err,
+			Op:   "unmarshalSession",
+		}
+	}
+	return sess, nil
+}
+
+// CreateSession creates a session for the named user.
+func (s *Service) CreateSession(ctx context.Context, user string) (*service.Session, error) {
+	var sess *service.Session
+	err := s.store.Modify(ctx, func(tx Impl) error {
+		session, err := s.createSession(ctx, tx, user)
+		if err != nil {
+			return err
+		}
+		sess = session
+		return nil
+	})
+	if err != nil {
+		return nil, &errors.Error{
+			Err: err,
+			Op:  service.OpCreateSession,
+		}
+	}
+
+	return sess, nil
+}
+
+func (s *Service) createSession(ctx context.Context, tx Impl, user string) (*service.Session, error) {
+	sess, err := s.newSession(ctx, tx, user)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := s.putSession(ctx, tx, sess); err != nil {
+		return nil, err
+	}
+	return sess, nil
+}
+
+// newSession builds a session for the named user whose permissions are
+// expanded from the roles the user holds, banned users get none.
+func (s *Service) newSession(ctx context.Context, tx Impl, userName string) (*service.Session, error) {
+	u, err := s.findUserByName(ctx, tx, userName)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := s.checkUser(ctx, tx, u.ID); err != nil {
+		return nil, err
+	}
+
+	ps, err := s.findUserPermissions(ctx, tx, u.ID)
+	if err != nil {
+		return nil, err
+	}
+
+	key, err := s.TokenGenerator.Token()
+	if err != nil {
+		return nil, &errors.Error{
+			Code: errors.Internal,
+			Err:  err,
+		}
+	}
+
+	now := s.time()
+	return &service.Session{
+		ID:          s.IDGenerator.ID(),
+		Key:         key,
+		CreatedAt:   now,
+		ExpiresAt:   now.Add(s.Config.SessionLength),
+		UserID:      u.ID,
+		Permissions: ps,
+	}, nil
+}
+
+func (s *Service) putSession(ctx context.Context, tx Impl, sess *service.Session) error {
+	v, err := json.Marshal(sess)
+	if err != nil {
+		return &errors.Error{
+			Code: errors.Invalid,
+			Err:  err,
+		}
+	}
+
+	b, err := s.sessionBucket(tx)
+	if err != nil {
+		return err
+	}
+
+	idx, err := s.sessionExpiryIndexBucket(tx)
+	if err != nil {
+		return err
+	}
+
+	if err := b.Put([]byte(sess.Key), v); err != nil {
+		return errors.InternalErr(err)
+	}
+
+	if err := idx.Put(sessionExpiryIndexKey(sess.ExpiresAt, sess.Key), []byte(sess.Key)); err != nil {
+		return errors.InternalErr(err)
+	}
+	return nil
+}
+
+func (s *Service) removeSession(ctx context.Context, tx Impl, sess *service.Session) error {
+	b, err := s.sessionBucket(tx)
+	if err != nil {
+		return err
+	}
+
+	idx, err := s.sessionExpiryIndexBucket(tx)
+	if err != nil {
+		return err
+	}
+
+	if err := idx.Delete(sessionExpiryIndexKey(sess.ExpiresAt, sess.Key)); err != nil {
+		return errors.WrapperErr(err)
+	}
+
+	if err := b.Delete([]byte(sess.Key)); err != nil {
+		return errors.WrapperErr(err)
+	}
+	return nil
+}
+
+// ExpireSession removes the session at the provided key.
+func (s *Service) ExpireSession(ctx context.Context, key string) error {
+	err := s.store.Modify(ctx, func(tx Impl) error {
+		sess, err := s.findSession(ctx, tx, key)
+		if err != nil {
+			return err
+		}
+		return s.removeSession(ctx, tx, sess)
+	})
+	if err != nil {
+		return &errors.Error{
+			Err: err,
+			Op:  service.OpExpireSession,
+		}
+	}
+
+	return nil
+}
+
+// RenewSession extends the expiration of the session to newExpiration, it never
+// shortens a session and never extends it beyond RenewSessionTime from now.
+func (s *Service) RenewSession(ctx context.Context, session *service.Session, newExpiration time.Time) error {
+	if session == nil {
+		return &errors.Error{
+			Code: errors.Invalid,
+			Msg:  "session is nil",
+			Op:   service.OpRenewSession,
+		}
+	}
+
+	if limit := s.time().Add(service.RenewSessionTime); newExpiration.After(limit) {
+		newExpiration = limit
+	}
+
+	if !newExpiration.After(session.ExpiresAt) {
+		return nil
+	}
+
+	err := s.store.Modify(ctx, func(tx Impl) error {
+		sess, err := s.findSession(ctx, tx, session.Key)
+		if err != nil {
+			return err
+		}
+
+		if err := s.removeSession(ctx, tx, sess); err != nil {
+			return err
+		}
+
+		sess.ExpiresAt = newExpiration
+		if err := s.putSession(ctx, tx, sess); err != nil {
+			return err
+		}
+
+		session.ExpiresAt = newExpiration
+		return nil
+	})
+	if err != nil {
+		return &errors.Error{
+			Err: err,
+			Op:  service.OpRenewSession,
+		}
+	}
+	return nil
+}
+
+// SweepSessions deletes all sessions expired by now and returns the number of live
+// sessions left, which the live sessions gauge is set to.
+func (s *Service) SweepSessions(ctx context.Context) (int, error) {
+	err := s.store.Modify(ctx, func(tx Impl) error {
+		return s.sweepSessions(ctx, tx, s.time())
+	})
+	if err != nil {
+		return 0, &errors.Error{
+			Err: err,
+			Op:  "SweepSessions",
+		}
+	}
+
+	// the sessions are counted once the sweep is committed, bolt only counts committed keys.
+	var live int
+	err = s.store.View(ctx, func(tx Impl) error {
+		b, err := s.sessionBucket(tx)
+		if err != nil {
+			return err
+		}
+		live, err = countKeys(b)
+		return err
+	})
+	if err != nil {
+		return 0, &errors.Error{
+			Err: err,
+			Op:  "SweepSessions",
+		}
+	}
+
+	s.liveSessions.Set(float64(live))
+	return live, nil
+}
+
+// sweepSessions deletes the sessions expired by now, the expiry index is ordered
+// by expiration so the sweep stops at the first live session.
+func (s *Service) sweepSessions(ctx context.Context, tx Impl, now time.Time) error {
+	idx, err := s.sessionExpiryIndexBucket(tx)
+	if err != nil {
+		return err
+	}
+
+	cur, err := idx.Cursor()
+	if err != nil {
+		return err
+	}
+
+	// collect first, deleting while iterating would move the cursor.
+	var expired []*service.Session
+	for k, v := cur.First(); k != nil; k, v = cur.Next() {
+		expiresAt := time.Unix(0, int64(binary.BigEndian.Uint64(k[:8])))
+		if expiresAt.After(now) {
+			break
+		}
+		expired = append(expired, &service.Session{
+			Key:       string(v),
+			ExpiresAt: expiresAt,
+		})
+	}
+
+	for _, sess := range expired {
+		if err := s.removeSession(ctx, tx, sess); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// RunSessionSweeper deletes expired sessions every period until stopCh is closed.
+func (s *Service) RunSessionSweeper(ctx context.Context, period time.Duration, stopCh <-chan struct{}) {
+	wait.Until(func() {
+		if _, err := s.SweepSessions(ctx); err != nil {
+			s.Logger.Error("failed to sweep expired sessions", zap.Error(err))
+		}
+	}, period, stopCh)
+}
@@ -0,0 +1,131 @@
+package store_test
+
+import (
+	"bytes"
+	"context"
+	"testing"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+
+	"github.com/ustackq/indagate/pkg/service"
+	"github.com/ustackq/indagate/pkg/store"
+	"github.com/ustackq/indagate/pkg/store/inmem"
+	"github.com/ustackq/indagate/pkg/utils/errors"
+)
+
+func TestSession(t *testing.T) {
+	ctx := context.Background()
+	kv := inmem.NewKVStore()
+	s := store.NewService(kv, store.ServiceConfig{SessionLength: time.Minute})
+	if err := s.Init(ctx); err != nil {
+		t.Fatal(err)
+	}
+
+	archive := `{"kind":"header","data":{"format":1}}
+{"kind":"user","data":{"id":2,"name":"alice"}}`
+	if _, err := s.Import(ctx, bytes.NewReader([]byte(archive))); err != nil {
+		t.Fatal(err)
+	}
+
+	sess, err := s.CreateSession(ctx, "alice")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if sess.UserID != service.ID(2) || sess.ExpiresAt.Sub(sess.CreatedAt) != time.Minute {
+		t.Errorf("session = %+v, want one of alice lasting a minute", sess)
+	}
+	if _, err := s.CreateSession(ctx, "bob"); errors.ErrorCode(err) != errors.NotFound {
+		t.Errorf("create session of an unknown user error = %v, want %s", err, errors.NotFound)
+	}
+
+	// renewals never shorten a session and extend it by RenewSessionTime at most.
+	expiresAt := sess.ExpiresAt
+	if err := s.RenewSession(ctx, sess, expiresAt.Add(-time.Second)); err != nil {
+		t.Fatal(err)
+	}
+	if found, err := s.FindSession(ctx, sess.Key); err != nil || !found.ExpiresAt.Equal(expiresAt) {
+		t.Errorf("session renewed earlier = %+v, %v, want it to expire at %s", found, err, expiresAt)
+	}
+
+	if err := s.RenewSession(ctx, sess, expiresAt.Add(time.Hour)); err != nil {
+		t.Fatal(err)
+	}
+	found, err := s.FindSession(ctx, sess.Key)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !found.ExpiresAt.After(expiresAt) || found.ExpiresAt.After(time.Now().Add(service.RenewSessionTime)) {
+		t.Errorf("renewed session expires at %s, want after %s and within %s", found.ExpiresAt, expiresAt, service.RenewSessionTime)
+	}
+
+	if err := s.ExpireSession(ctx, sess.Key); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := s.FindSession(ctx, sess.Key); errors.ErrorCode(err) != errors.NotFound {
+		t.Errorf("find expired session error = %v, want %s", err, errors.NotFound)
+	}
+	if err := s.ExpireSession(ctx, sess.Key); errors.ErrorCode(err) != errors.NotFound {
+		t.Errorf("expire session twice error = %v, want %s", err, errors.NotFound)
+	}
+}
+
+func TestSweepSessions(t *testing.T) {
+	ctx := context.Background()
+	kv := inmem.NewKVStore()
+	s := store.NewService(kv, store.ServiceConfig{SessionLength: time.Hour})
+	if err := s.Init(ctx); err != nil {
+		t.Fatal(err)
+	}
+	// sessions created through past share the store and are expired already.
+	past := store.NewService(kv, store.ServiceConfig{SessionLength: -time.Minute})
+
+	archive := `{"kind":"header","data":{"format":1}}
+{"kind":"user","data":{"id":2,"name":"alice"}}`
+	if _, err := s.Import(ctx, bytes.NewReader([]byte(archive))); err != nil {
+		t.Fatal(err)
+	}
+
+	live, err := s.CreateSession(ctx, "alice")
+	if err != nil {
+		t.Fatal(err)
+	}
+	var expired []*service.Session
+	for i := 0; i < 3; i++ {
+		sess, err := past.CreateSession(ctx, "alice")
+		if err != nil {
+			t.Fatal(err)
+		}
+		expired = append(expired, sess)
+	}
+
+	if n, err := s.SweepSessions(ctx); err != nil || n != 1 {
+		t.Fatalf("sweep = %d, %v, want 1 live session", n, err)
+	}
+	for _, sess := range expired {
+		// the expired session is gone, it can not be expired anymore.
+		if err := s.ExpireSession(ctx, sess.Key); errors.ErrorCode(err) != errors.NotFound {
+			t.Errorf("expire swept session error = %v, want %s", err, errors.NotFound)
+		}
+	}
+	if _, err := s.FindSession(ctx, live.Key); err != nil {
+		t.Errorf("find live session error = %v", err)
+	}
+
+	if n, err := s.SweepSessions(ctx); err != nil || n != 1 {
+		t.Errorf("second sweep = %d, %v, want 1 live session", n, err)
+	}
+
+	// each service reports its own gauge, past never swept.
+	for svc, want := range map[*store.Service]float64{s: 1, past: 0} {
+		reg := prometheus.NewRegistry()
+		reg.MustRegister(svc.PrometheusCollectors()...)
+		mfs, err := reg.Gather()
+		if err != nil {
+			t.Fatal(err)
+		}
+		if len(mfs) != 1 || mfs[0].GetMetric()[0].GetGauge().GetValue() != want {
+			t.Errorf("live sessions metrics = %v, want %v", mfs, want)
+		}
+	}
+}
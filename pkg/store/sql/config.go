@@ -0,0 +1,42 @@
+package sql
+
+import (
+	"fmt"
+	"net/url"
+	"os"
+	"path"
+	"strings"
+)
+
+// Config describes how to reach the database backing the store.
+type Config struct {
+	Type, Host, Port, Name, User, Passwd, SSLMode, Path string
+}
+
+// ConnStr returns the data source name described by c.
+func ConnStr(c Config) (string, error) {
+	connStr := ""
+	Param := "?"
+	if strings.Contains(c.Name, Param) {
+		Param = "&"
+	}
+	// Checkout SQL Type
+	switch c.Type {
+	case "mysql":
+		connStr = fmt.Sprintf("%s:%s@tcp(%s)/%s%scharset=utf8&parseTime=true",
+			c.User, c.Passwd, c.Host, c.Name, Param)
+	case "postgres":
+		connStr = fmt.Sprintf("postgres://%s:%s@%s:%s/%s%ssslmode=%s",
+			url.QueryEscape(c.User), url.QueryEscape(c.Passwd), c.Host, c.Port, c.Name, Param, c.SSLMode)
+	case "mssql":
+		connStr = fmt.Sprintf("server=%s; port=%s; database=%s; user id=%s; password=%s;", c.Host, c.Port, c.Name, c.User, c.Passwd)
+	case "sqlite3":
+		if err := os.MkdirAll(path.Dir(c.Path), os.ModePerm); err != nil {
+			return "", fmt.Errorf("Fail to create directories: %v", err)
+		}
+		connStr = "file:" + c.Path + "?cache=shared&mode=rwc"
+	default:
+		return "", fmt.Errorf("Unknown database type: %s", c.Type)
+	}
+	return connStr, nil
+}
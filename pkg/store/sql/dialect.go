@@ -0,0 +1,93 @@
+package sql
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// dialect holds the statements which differ between the supported databases.
+type dialect struct {
+	keyType   string
+	valueType string
+	// upsert is a format string taking the table name.
+	upsert string
+	// tableExists selects a row if the table named by its argument exists.
+	tableExists string
+	// numbered placeholders ($1, $2) instead of question marks.
+	numbered bool
+}
+
+var dialects = map[string]dialect{
+	"mysql": {
+		// keys are at most store.MaxKeySize bytes.
+		keyType:     "VARBINARY(512)",
+		valueType:   "LONGBLOB",
+		upsert:      "INSERT INTO %s (k, v) VALUES (?, ?) ON DUPLICATE KEY UPDATE v = VALUES(v)",
+		tableExists: "SELECT 1 FROM information_schema.tables WHERE table_schema = DATABASE() AND table_name = ?",
+	},
+	"postgres": {
+		keyType:     "BYTEA",
+		valueType:   "BYTEA",
+		upsert:      "INSERT INTO %s (k, v) VALUES (?, ?) ON CONFLICT (k) DO UPDATE SET v = EXCLUDED.v",
+		tableExists: "SELECT 1 FROM information_schema.tables WHERE table_schema = current_schema() AND table_name = ?",
+		numbered:    true,
+	},
+	"sqlite3": {
+		keyType:     "BLOB",
+		valueType:   "BLOB",
+		upsert:      "INSERT OR REPLACE INTO %s (k, v) VALUES (?, ?)",
+		tableExists: "SELECT 1 FROM sqlite_master WHERE type = 'table' AND name = ?",
+	},
+}
+
+func dialectOf(driver string) (dialect, error) {
+	d, ok := dialects[driver]
+	if !ok {
+		return dialect{}, fmt.Errorf("unsupported sql driver %s: expected mysql, postgres or sqlite3", driver)
+	}
+	return d, nil
+}
+
+// rebind rewrites the question mark placeholders of query for the dialect.
+func (d dialect) rebind(query string) string {
+	if !d.numbered {
+		return query
+	}
+
+	var b strings.Builder
+	n := 0
+	for _, r := range query {
+		if r != '?' {
+			b.WriteRune(r)
+			continue
+		}
+		n++
+		b.WriteString("$" + strconv.Itoa(n))
+	}
+	return b.String()
+}
+
+func (d dialect) createTable(table string) string {
+	return fmt.Sprintf("CREATE TABLE IF NOT EXISTS %s (k %s NOT NULL PRIMARY KEY, v %s NOT NULL)", table, d.keyType, d.valueType)
+}
+
+// tableName maps a bucket name onto a table name. Lowercase letters and digits are
+// kept, every other byte is written as an underscore followed by its two hex digits,
+// so that distinct buckets never share a table.
+func tableName(bucket []byte) string {
+	const hex = "0123456789abcdef"
+
+	var b strings.Builder
+	b.WriteString("kv_")
+	for _, c := range bucket {
+		if c >= 'a' && c <= 'z' || c >= '0' && c <= '9' {
+			b.WriteByte(c)
+			continue
+		}
+		b.WriteByte('_')
+		b.WriteByte(hex[c>>4])
+		b.WriteByte(hex[c&0x0f])
+	}
+	return b.String()
+}
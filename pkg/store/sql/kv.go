@@ -0,0 +1,420 @@
+package sql
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"sync"
+
+	"go.uber.org/zap"
+
+	"github.com/ustackq/indagate/pkg/store"
+	"github.com/ustackq/indagate/pkg/tracing"
+)
+
+// cursorBatchSize is the number of rows a cursor reads ahead when moving forward.
+const cursorBatchSize = 100
+
+// KVStore implements store.Store on top of database/sql, every bucket is
+// stored in a table of its own holding ordered key value pairs.
+type KVStore struct {
+	driver  string
+	dsn     string
+	dialect dialect
+	db      *sql.DB
+	logger  *zap.Logger
+
+	mu     sync.RWMutex
+	tables map[string]bool
+}
+
+// NewKVStore returns a store for the given driver (mysql, postgres or sqlite3) and data source name.
+func NewKVStore(driver, dsn string) *KVStore {
+	return &KVStore{
+		driver: driver,
+		dsn:    dsn,
+		logger: zap.NewNop(),
+		tables: map[string]bool{},
+	}
+}
+
+// Open connects to the database.
+func (kv *KVStore) Open(ctx context.Context) error {
+	span, ctx := tracing.StartSpanFromContext(ctx)
+	defer span.End()
+
+	d, err := dialectOf(kv.driver)
+	if err != nil {
+		return err
+	}
+	kv.dialect = d
+
+	db, err := sql.Open(kv.driver, kv.dsn)
+	if err != nil {
+		return fmt.Errorf("unable to open %s database: %v", kv.driver, err)
+	}
+
+	// sqlite only allows a single writer, serialize transactions instead of failing with "database is locked".
+	if kv.driver == "sqlite3" {
+		db.SetMaxOpenConns(1)
+	}
+
+	if err := db.PingContext(ctx); err != nil {
+		db.Close()
+		return fmt.Errorf("unable to reach %s database: %v", kv.driver, err)
+	}
+
+	kv.db = db
+
+	kv.logger.Info("Resources opened", zap.String("driver", kv.driver))
+	return nil
+}
+
+// Close closes the database.
+func (kv *KVStore) Close() error {
+	if kv.db != nil {
+		return kv.db.Close()
+	}
+
+	return nil
+}
+
+func (kv *KVStore) WithLogger(l *zap.Logger) {
+	kv.logger = l
+}
+
+// WithDB sets an already opened database of the store driver.
+func (kv *KVStore) WithDB(db *sql.DB) error {
+	d, err := dialectOf(kv.driver)
+	if err != nil {
+		return err
+	}
+	kv.dialect = d
+	kv.db = db
+	return nil
+}
+
+// View opens up a transaction that will not write to any data. Implementing interface store.Store.
+func (kv *KVStore) View(ctx context.Context, fn func(tx store.Impl) error) error {
+	span, ctx := tracing.StartSpanFromContext(ctx)
+	defer span.End()
+
+	return kv.transaction(ctx, false, fn)
+}
+
+// Modify opens up a transaction that will write to the database, all writes of fn
+// are rolled back if it returns an error.
+func (kv *KVStore) Modify(ctx context.Context, fn func(tx store.Impl) error) error {
+	span, ctx := tracing.StartSpanFromContext(ctx)
+	defer span.End()
+
+	return kv.transaction(ctx, true, fn)
+}
+
+func (kv *KVStore) transaction(ctx context.Context, writable bool, fn func(tx store.Impl) error) (err error) {
+	stx, err := kv.db.BeginTx(ctx, &sql.TxOptions{ReadOnly: !writable})
+	if err != nil {
+		return err
+	}
+
+	tx := &Tx{
+		kv:       kv,
+		tx:       stx,
+		ctx:      ctx,
+		writable: writable,
+		created:  map[string]bool{},
+	}
+
+	defer func() {
+		if err != nil {
+			stx.Rollback()
+		}
+	}()
+
+	if err = fn(tx); err != nil {
+		return err
+	}
+
+	// cursors can not return their errors, fn saw them as the end of the bucket.
+	if err = tx.err; err != nil {
+		return err
+	}
+
+	if err = stx.Commit(); err != nil {
+		return err
+	}
+
+	kv.mu.Lock()
+	for table := range tx.created {
+		kv.tables[table] = true
+	}
+	kv.mu.Unlock()
+	return nil
+}
+
+func (kv *KVStore) hasTable(table string) bool {
+	kv.mu.RLock()
+	defer kv.mu.RUnlock()
+	return kv.tables[table]
+}
+
+// Tx wraps a database/sql transaction, It implements store.Impl.
+type Tx struct {
+	kv       *KVStore
+	tx       *sql.Tx
+	ctx      context.Context
+	writable bool
+	// tables created by the transaction, only known to the store once committed.
+	created map[string]bool
+	// err is the first error of a cursor of the transaction, the transaction is
+	// rolled back and every later call of its buckets fails with it.
+	err error
+}
+
+// Context returns the context for the transaction.
+func (tx *Tx) Context() context.Context {
+	return tx.ctx
+}
+
+// WithContext sets the context for the transaction.
+func (tx *Tx) WithContext(ctx context.Context) {
+	tx.ctx = ctx
+}
+
+// Bucket retrieves the bucket according args, creating its table if it doesn't exist.
+// Read only transactions can't create tables, their buckets without one are empty.
+//
+// MySQL commits implicitly on CREATE TABLE, store.Service.Init creates all buckets
+// up front so that it never happens in the middle of a transaction.
+func (tx *Tx) Bucket(b []byte) (store.Bucket, error) {
+	if tx.err != nil {
+		return nil, tx.err
+	}
+
+	table := tableName(b)
+	if tx.kv.hasTable(table) || tx.created[table] {
+		return &Bucket{tx: tx, table: table}, nil
+	}
+
+	if !tx.writable {
+		exists, err := tx.tableExists(table)
+		if err != nil {
+			return nil, err
+		}
+		if !exists {
+			return &Bucket{tx: tx, table: table, missing: true}, nil
+		}
+
+		tx.kv.mu.Lock()
+		tx.kv.tables[table] = true
+		tx.kv.mu.Unlock()
+		return &Bucket{tx: tx, table: table}, nil
+	}
+
+	if _, err := tx.tx.ExecContext(tx.ctx, tx.kv.dialect.createTable(table)); err != nil {
+		return nil, err
+	}
+	tx.created[table] = true
+
+	return &Bucket{tx: tx, table: table}, nil
+}
+
+// tableExists looks the table up in the catalog of the database.
+func (tx *Tx) tableExists(table string) (bool, error) {
+	var one int
+	err := tx.tx.QueryRowContext(tx.ctx, tx.query(tx.kv.dialect.tableExists), table).Scan(&one)
+	if err == sql.ErrNoRows {
+		return false, nil
+	}
+	if err != nil {
+		return false, err
+	}
+	return true, nil
+}
+
+func (tx *Tx) query(query string) string {
+	return tx.kv.dialect.rebind(query)
+}
+
+// Bucket implements store.Bucket.
+type Bucket struct {
+	tx    *Tx
+	table string
+	// missing buckets have no table yet, they are only read by read only transactions.
+	missing bool
+}
+
+// Get retrieves the value at the provided key.
+func (b *Bucket) Get(key []byte) ([]byte, error) {
+	if b.tx.err != nil {
+		return nil, b.tx.err
+	}
+
+	if b.missing {
+		return nil, store.ErrKeyNotFound
+	}
+
+	var v []byte
+	err := b.tx.tx.QueryRowContext(b.tx.ctx, b.tx.query("SELECT v FROM "+b.table+" WHERE k = ?"), key).Scan(&v)
+	if err == sql.ErrNoRows {
+		return nil, store.ErrKeyNotFound
+	}
+
+	if err != nil {
+		return nil, err
+	}
+	return v, nil
+}
+
+// Put sets the value at the provided key.
+func (b *Bucket) Put(key []byte, value []byte) error {
+	if b.tx.err != nil {
+		return b.tx.err
+	}
+	if !b.tx.writable {
+		return store.ErrTxNotWritable
+	}
+	if len(key) > store.MaxKeySize {
+		return store.ErrKeyTooLarge
+	}
+
+	_, err := b.tx.tx.ExecContext(b.tx.ctx, b.tx.query(fmt.Sprintf(b.tx.kv.dialect.upsert, b.table)), key, value)
+	return err
+}
+
+// Delete removes the provided key.
+func (b *Bucket) Delete(key []byte) error {
+	if b.tx.err != nil {
+		return b.tx.err
+	}
+	if !b.tx.writable {
+		return store.ErrTxNotWritable
+	}
+
+	_, err := b.tx.tx.ExecContext(b.tx.ctx, b.tx.query("DELETE FROM "+b.table+" WHERE k = ?"), key)
+	return err
+}
+
+// KeyN returns the number of keys in the bucket.
+func (b *Bucket) KeyN() (int, error) {
+	if b.tx.err != nil {
+		return 0, b.tx.err
+	}
+
+	if b.missing {
+		return 0, nil
+	}
+
+	var n int
+	err := b.tx.tx.QueryRowContext(b.tx.ctx, "SELECT COUNT(*) FROM "+b.table).Scan(&n)
+	return n, err
+}
+
+// Cursor retrieves a cursor for iterating through the entries
+// in the key value store.
+func (b *Bucket) Cursor() (store.Cursor, error) {
+	if b.tx.err != nil {
+		return nil, b.tx.err
+	}
+	return &Cursor{
+		bucket: b,
+	}, nil
+}
+
+type pair struct {
+	k, v []byte
+}
+
+// Cursor is a struct for iterating through the entries in key order,
+// rows are read ahead in batches when moving forward.
+type Cursor struct {
+	bucket *Bucket
+	buf    []pair
+	pos    int
+}
+
+// load reads the rows of query ahead. Query errors end the iteration and are
+// recorded on the transaction, which returns them.
+func (c *Cursor) load(query string, args ...interface{}) ([]byte, []byte) {
+	c.buf, c.pos = nil, 0
+
+	tx := c.bucket.tx
+	if tx.err != nil || c.bucket.missing {
+		return nil, nil
+	}
+
+	rows, err := tx.tx.QueryContext(tx.ctx, tx.query(query), args...)
+	if err != nil {
+		tx.err = err
+		return nil, nil
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var p pair
+		if err := rows.Scan(&p.k, &p.v); err != nil {
+			c.buf, tx.err = nil, err
+			return nil, nil
+		}
+		c.buf = append(c.buf, p)
+	}
+
+	if err := rows.Err(); err != nil {
+		c.buf, tx.err = nil, err
+		return nil, nil
+	}
+	return c.current()
+}
+
+func (c *Cursor) current() ([]byte, []byte) {
+	if c.pos >= len(c.buf) {
+		return nil, nil
+	}
+	p := c.buf[c.pos]
+	return p.k, p.v
+}
+
+// Seek moves the cursor to the first key greater than or equal to prefix.
+func (c *Cursor) Seek(prefix []byte) ([]byte, []byte) {
+	return c.load(fmt.Sprintf("SELECT k, v FROM %s WHERE k >= ? ORDER BY k LIMIT %d", c.bucket.table, cursorBatchSize), prefix)
+}
+
+// First retrieves the first key value pair in the bucket.
+func (c *Cursor) First() ([]byte, []byte) {
+	return c.load(fmt.Sprintf("SELECT k, v FROM %s ORDER BY k LIMIT %d", c.bucket.table, cursorBatchSize))
+}
+
+// Last retrieves the last key value pair in the bucket.
+func (c *Cursor) Last() ([]byte, []byte) {
+	return c.load(fmt.Sprintf("SELECT k, v FROM %s ORDER BY k DESC LIMIT 1", c.bucket.table))
+}
+
+// Next retrieves the next key in the bucket.
+func (c *Cursor) Next() ([]byte, []byte) {
+	if c.pos >= len(c.buf) {
+		return nil, nil
+	}
+
+	if c.pos+1 < len(c.buf) {
+		c.pos++
+		return c.current()
+	}
+
+	last := c.buf[c.pos].k
+	return c.load(fmt.Sprintf("SELECT k, v FROM %s WHERE k > ? ORDER BY k LIMIT %d", c.bucket.table, cursorBatchSize), last)
+}
+
+// Prev retrieves the previous key in the bucket.
+func (c *Cursor) Prev() ([]byte, []byte) {
+	if c.pos >= len(c.buf) {
+		return nil, nil
+	}
+
+	if c.pos > 0 {
+		c.pos--
+		return c.current()
+	}
+
+	first := c.buf[c.pos].k
+	return c.load(fmt.Sprintf("SELECT k, v FROM %s WHERE k < ? ORDER BY k DESC LIMIT 1", c.bucket.table), first)
+}
@@ -0,0 +1,340 @@
+package sql_test
+
+import (
+	"bytes"
+	"context"
+	"database/sql"
+	"database/sql/driver"
+	"errors"
+	"path/filepath"
+	"strings"
+	"sync/atomic"
+	"testing"
+
+	"github.com/mattn/go-sqlite3"
+
+	"github.com/ustackq/indagate/pkg/service"
+	"github.com/ustackq/indagate/pkg/store"
+	isql "github.com/ustackq/indagate/pkg/store/sql"
+	"github.com/ustackq/indagate/pkg/store/storetest"
+)
+
+func newTestStore(t *testing.T) *isql.KVStore {
+	t.Helper()
+
+	dsn, err := isql.ConnStr(isql.Config{
+		Type: "sqlite3",
+		Path: filepath.Join(t.TempDir(), "indagate.db"),
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	s := isql.NewKVStore("sqlite3", dsn)
+	if err := s.Open(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { s.Close() })
+	return s
+}
+
+func TestKVStore(t *testing.T) {
+	ctx := context.Background()
+	s := newTestStore(t)
+	bucket := []byte("testv1")
+
+	err := s.Modify(ctx, func(tx store.Impl) error {
+		b, err := tx.Bucket(bucket)
+		if err != nil {
+			return err
+		}
+		for _, k := range []string{"b", "d", "a", "c"} {
+			if err := b.Put([]byte(k), []byte("value-"+k)); err != nil {
+				return err
+			}
+		}
+		// overwrite an existing key.
+		return b.Put([]byte("c"), []byte("value-C"))
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	err = s.View(ctx, func(tx store.Impl) error {
+		b, err := tx.Bucket(bucket)
+		if err != nil {
+			return err
+		}
+
+		v, err := b.Get([]byte("c"))
+		if err != nil {
+			return err
+		}
+		if string(v) != "value-C" {
+			t.Errorf("Get(c) = %q, want %q", v, "value-C")
+		}
+
+		if _, err := b.Get([]byte("z")); !store.IsNotFound(err) {
+			t.Errorf("Get(z) error = %v, want %v", err, store.ErrKeyNotFound)
+		}
+
+		if err := b.Put([]byte("z"), []byte("z")); err != store.ErrTxNotWritable {
+			t.Errorf("Put in View error = %v, want %v", err, store.ErrTxNotWritable)
+		}
+
+		cur, err := b.Cursor()
+		if err != nil {
+			return err
+		}
+
+		var keys []byte
+		for k, _ := cur.First(); k != nil; k, _ = cur.Next() {
+			keys = append(keys, k...)
+		}
+		if string(keys) != "abcd" {
+			t.Errorf("forward keys = %q, want %q", keys, "abcd")
+		}
+
+		keys = keys[:0]
+		for k, _ := cur.Last(); k != nil; k, _ = cur.Prev() {
+			keys = append(keys, k...)
+		}
+		if string(keys) != "dcba" {
+			t.Errorf("backward keys = %q, want %q", keys, "dcba")
+		}
+
+		if k, _ := cur.Seek([]byte("bb")); !bytes.Equal(k, []byte("c")) {
+			t.Errorf("Seek(bb) = %q, want %q", k, "c")
+		}
+		return nil
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestKVStore_Rollback(t *testing.T) {
+	ctx := context.Background()
+	s := newTestStore(t)
+	bucket := []byte("testv1")
+	errAbort := errors.New("abort")
+
+	err := s.Modify(ctx, func(tx store.Impl) error {
+		b, err := tx.Bucket(bucket)
+		if err != nil {
+			return err
+		}
+		if err := b.Put([]byte("a"), []byte("a")); err != nil {
+			return err
+		}
+		return errAbort
+	})
+	if err != errAbort {
+		t.Fatalf("Modify error = %v, want %v", err, errAbort)
+	}
+
+	err = s.View(ctx, func(tx store.Impl) error {
+		b, err := tx.Bucket(bucket)
+		if err != nil {
+			return err
+		}
+		if _, err := b.Get([]byte("a")); !store.IsNotFound(err) {
+			t.Errorf("Get(a) after rollback error = %v, want %v", err, store.ErrKeyNotFound)
+		}
+		return nil
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestKVStore_BucketNames(t *testing.T) {
+	ctx := context.Background()
+	s := newTestStore(t)
+
+	// buckets differing only by case or by the bytes an identifier can't hold have tables of their own.
+	buckets := []string{"userIndexv1", "userindexv1", "user_indexv1", "user-indexv1", "user_2dindexv1"}
+	err := s.Modify(ctx, func(tx store.Impl) error {
+		for _, name := range buckets {
+			b, err := tx.Bucket([]byte(name))
+			if err != nil {
+				return err
+			}
+			if err := b.Put([]byte("k"), []byte(name)); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	err = s.View(ctx, func(tx store.Impl) error {
+		for _, name := range buckets {
+			b, err := tx.Bucket([]byte(name))
+			if err != nil {
+				return err
+			}
+			v, err := b.Get([]byte("k"))
+			if err != nil {
+				return err
+			}
+			if string(v) != name {
+				t.Errorf("bucket %s holds %s", name, v)
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestKVStore_ViewMissingBucket(t *testing.T) {
+	ctx := context.Background()
+	db, err := sql.Open("sqlite3", filepath.Join(t.TempDir(), "indagate.db"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer db.Close()
+	db.SetMaxOpenConns(1)
+
+	s := isql.NewKVStore("sqlite3", "")
+	if err := s.WithDB(db); err != nil {
+		t.Fatal(err)
+	}
+
+	// read only transactions see a bucket without a table as empty, and leave it so.
+	err = s.View(ctx, func(tx store.Impl) error {
+		b, err := tx.Bucket([]byte("missingv1"))
+		if err != nil {
+			return err
+		}
+		if _, err := b.Get([]byte("k")); !store.IsNotFound(err) {
+			t.Errorf("Get(k) error = %v, want %v", err, store.ErrKeyNotFound)
+		}
+		cur, err := b.Cursor()
+		if err != nil {
+			return err
+		}
+		if k, _ := cur.First(); k != nil {
+			t.Errorf("First() = %s, want nil", k)
+		}
+		if err := b.Put([]byte("k"), []byte("v")); err != store.ErrTxNotWritable {
+			t.Errorf("Put(k) error = %v, want %v", err, store.ErrTxNotWritable)
+		}
+		return nil
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var n int
+	if err := db.QueryRow("SELECT COUNT(*) FROM sqlite_master WHERE type = 'table'").Scan(&n); err != nil {
+		t.Fatal(err)
+	}
+	if n != 0 {
+		t.Errorf("tables after View = %d, want 0", n)
+	}
+}
+
+func TestKVStore_Service(t *testing.T) {
+	ctx := context.Background()
+	svc := store.NewService(newTestStore(t))
+	if err := svc.Init(ctx); err != nil {
+		t.Fatal(err)
+	}
+
+	orgID := service.ID(1)
+	for _, content := range []string{"first?", "second?"} {
+		q := &service.Question{
+			OrgID:   orgID,
+			UserID:  service.ID(2),
+			Content: content,
+		}
+		if err := svc.CreateQuestion(ctx, q); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	qs, total, err := svc.FindQuestions(ctx, service.QuestionFilter{OrgID: &orgID})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if total != 2 || len(qs) != 2 {
+		t.Fatalf("FindQuestions returned %d of %d questions, want 2 of 2", len(qs), total)
+	}
+	if qs[0].Content != "first?" {
+		t.Errorf("first question content = %q, want %q", qs[0].Content, "first?")
+	}
+}
+
+func TestKVStore_Conformance(t *testing.T) {
+	storetest.KVStore(func(t *testing.T) (store.Store, func()) {
+		return newTestStore(t), func() {}
+	}, t)
+}
+
+// faultyDriver is sqlite whose reads of key value pairs fail while failing is set.
+type faultyDriver struct {
+	sqlite3.SQLiteDriver
+	failing int32
+}
+
+func (d *faultyDriver) Open(name string) (driver.Conn, error) {
+	conn, err := d.SQLiteDriver.Open(name)
+	if err != nil {
+		return nil, err
+	}
+	return &faultyConn{Conn: conn, driver: d}, nil
+}
+
+// faultyConn hides the query interfaces of the sqlite connection, so that every
+// query is prepared.
+type faultyConn struct {
+	driver.Conn
+	driver *faultyDriver
+}
+
+// BeginTx keeps the transactions of the sqlite connection, read only ones included.
+func (c *faultyConn) BeginTx(ctx context.Context, opts driver.TxOptions) (driver.Tx, error) {
+	return c.Conn.(driver.ConnBeginTx).BeginTx(ctx, opts)
+}
+
+func (c *faultyConn) Prepare(query string) (driver.Stmt, error) {
+	if atomic.LoadInt32(&c.driver.failing) == 1 && strings.HasPrefix(query, "SELECT k, v") {
+		return nil, errors.New("connection lost")
+	}
+	return c.Conn.Prepare(query)
+}
+
+var faulty = &faultyDriver{}
+
+func init() {
+	sql.Register("sqlite3-faulty", faulty)
+}
+
+func TestKVStore_QueryErrors(t *testing.T) {
+	storetest.KVStoreQueryErrors(func(t *testing.T) (store.Store, func(bool), func()) {
+		db, err := sql.Open("sqlite3-faulty", filepath.Join(t.TempDir(), "indagate.db"))
+		if err != nil {
+			t.Fatal(err)
+		}
+		db.SetMaxOpenConns(1)
+
+		s := isql.NewKVStore("sqlite3", "")
+		if err := s.WithDB(db); err != nil {
+			t.Fatal(err)
+		}
+
+		fail := func(on bool) {
+			var v int32
+			if on {
+				v = 1
+			}
+			atomic.StoreInt32(&faulty.failing, v)
+		}
+		return s, fail, func() { db.Close() }
+	}, t)
+}
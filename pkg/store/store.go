@@ -6,13 +6,21 @@ import (
 )
 
 const (
-	BblotStore string = "bblot"
-	MysqlStore string = "mysql"
+	BblotStore    string = "bblot"
+	MysqlStore    string = "mysql"
+	PostgresStore string = "postgres"
+	SQLiteStore   string = "sqlite3"
+	MemoryStore   string = "memory"
 )
 
+// MaxKeySize is the size in bytes of the longest key a bucket stores, it is the
+// size of the key column of the MySQL store.
+const MaxKeySize = 512
+
 var (
 	ErrKeyNotFound   = errors.New("key not found")
 	ErrTxNotWritable = errors.New("transaction is not writebale")
+	ErrKeyTooLarge   = errors.New("key is too large")
 )
 
 func IsNotFound(err error) bool {
@@ -36,10 +44,35 @@ type Impl interface {
 type Bucket interface {
 	Get(key []byte) ([]byte, error)
 	Cursor() (Cursor, error)
+	// Put fails with ErrKeyTooLarge for keys longer than MaxKeySize.
 	Put(key, value []byte) error
 	Delete(key []byte) error
 }
 
+// KeyCounter is implemented by buckets which can count their keys without
+// iterating them.
+type KeyCounter interface {
+	KeyN() (int, error)
+}
+
+// countKeys returns the number of keys in the bucket.
+func countKeys(b Bucket) (int, error) {
+	if kc, ok := b.(KeyCounter); ok {
+		return kc.KeyN()
+	}
+
+	cur, err := b.Cursor()
+	if err != nil {
+		return 0, err
+	}
+
+	n := 0
+	for k, _ := cur.First(); k != nil; k, _ = cur.Next() {
+		n++
+	}
+	return n, nil
+}
+
 // Cursor data selector
 type Cursor interface {
 	Seek(prefix []byte) (k, v []byte)
@@ -0,0 +1,427 @@
+// Package storetest holds the conformance suite every store.Store implementation has to pass.
+package storetest
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/ustackq/indagate/pkg/service"
+	"github.com/ustackq/indagate/pkg/store"
+)
+
+var testBucket = []byte("storetestv1")
+
+// KVStore runs the conformance suite against the stores returned by init,
+// every subtest gets a fresh store which is released by the returned func.
+func KVStore(init func(t *testing.T) (store.Store, func()), t *testing.T) {
+	tests := []struct {
+		name string
+		fn   func(t *testing.T, s store.Store)
+	}{
+		{name: "PutGetDelete", fn: putGetDelete},
+		{name: "ViewNotWritable", fn: viewNotWritable},
+		{name: "Rollback", fn: rollback},
+		{name: "Cursor", fn: cursor},
+		{name: "CursorSeek", fn: cursorSeek},
+		{name: "KeyN", fn: keyN},
+		{name: "KeySize", fn: keySize},
+		{name: "Service", fn: storeService},
+		{name: "Migrations", fn: migrations},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			s, done := init(t)
+			defer done()
+			tt.fn(t, s)
+		})
+	}
+}
+
+func put(t *testing.T, s store.Store, pairs ...string) {
+	t.Helper()
+
+	err := s.Modify(context.Background(), func(tx store.Impl) error {
+		b, err := tx.Bucket(testBucket)
+		if err != nil {
+			return err
+		}
+		for i := 0; i+1 < len(pairs); i += 2 {
+			if err := b.Put([]byte(pairs[i]), []byte(pairs[i+1])); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		t.Fatalf("failed to put %v: %v", pairs, err)
+	}
+}
+
+func get(t *testing.T, s store.Store, key string) (string, error) {
+	t.Helper()
+
+	var v []byte
+	err := s.View(context.Background(), func(tx store.Impl) error {
+		b, err := tx.Bucket(testBucket)
+		if err != nil {
+			return err
+		}
+		v, err = b.Get([]byte(key))
+		return err
+	})
+	return string(v), err
+}
+
+func putGetDelete(t *testing.T, s store.Store) {
+	put(t, s, "a", "1", "b", "2")
+	put(t, s, "b", "3")
+
+	if v, err := get(t, s, "a"); err != nil || v != "1" {
+		t.Errorf("Get(a) = %q, %v, want %q", v, err, "1")
+	}
+	if v, err := get(t, s, "b"); err != nil || v != "3" {
+		t.Errorf("Get(b) = %q, %v, want %q", v, err, "3")
+	}
+	if _, err := get(t, s, "z"); !store.IsNotFound(err) {
+		t.Errorf("Get(z) error = %v, want %v", err, store.ErrKeyNotFound)
+	}
+
+	err := s.Modify(context.Background(), func(tx store.Impl) error {
+		b, err := tx.Bucket(testBucket)
+		if err != nil {
+			return err
+		}
+		if err := b.Delete([]byte("a")); err != nil {
+			return err
+		}
+		// deleting a missing key is not an error.
+		return b.Delete([]byte("z"))
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err := get(t, s, "a"); !store.IsNotFound(err) {
+		t.Errorf("Get(a) after delete error = %v, want %v", err, store.ErrKeyNotFound)
+	}
+}
+
+func viewNotWritable(t *testing.T, s store.Store) {
+	put(t, s, "a", "1")
+
+	err := s.View(context.Background(), func(tx store.Impl) error {
+		b, err := tx.Bucket(testBucket)
+		if err != nil {
+			return err
+		}
+		if err := b.Put([]byte("b"), []byte("2")); err != store.ErrTxNotWritable {
+			t.Errorf("Put in View error = %v, want %v", err, store.ErrTxNotWritable)
+		}
+		if err := b.Delete([]byte("a")); err != store.ErrTxNotWritable {
+			t.Errorf("Delete in View error = %v, want %v", err, store.ErrTxNotWritable)
+		}
+		return nil
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+}
+
+func rollback(t *testing.T, s store.Store) {
+	put(t, s, "a", "1")
+	errAbort := errors.New("abort")
+
+	err := s.Modify(context.Background(), func(tx store.Impl) error {
+		b, err := tx.Bucket(testBucket)
+		if err != nil {
+			return err
+		}
+		if err := b.Put([]byte("a"), []byte("2")); err != nil {
+			return err
+		}
+		if err := b.Put([]byte("b"), []byte("2")); err != nil {
+			return err
+		}
+		return errAbort
+	})
+	if err != errAbort {
+		t.Fatalf("Modify error = %v, want %v", err, errAbort)
+	}
+
+	if v, err := get(t, s, "a"); err != nil || v != "1" {
+		t.Errorf("Get(a) after rollback = %q, %v, want %q", v, err, "1")
+	}
+	if _, err := get(t, s, "b"); !store.IsNotFound(err) {
+		t.Errorf("Get(b) after rollback error = %v, want %v", err, store.ErrKeyNotFound)
+	}
+}
+
+func keys(t *testing.T, s store.Store, walk func(c store.Cursor) []byte) string {
+	t.Helper()
+
+	var ks []byte
+	err := s.View(context.Background(), func(tx store.Impl) error {
+		b, err := tx.Bucket(testBucket)
+		if err != nil {
+			return err
+		}
+		c, err := b.Cursor()
+		if err != nil {
+			return err
+		}
+		ks = walk(c)
+		return nil
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	return string(ks)
+}
+
+func cursor(t *testing.T, s store.Store) {
+	put(t, s, "c", "3", "a", "1", "d", "4", "b", "2")
+
+	forward := keys(t, s, func(c store.Cursor) (ks []byte) {
+		for k, _ := c.First(); k != nil; k, _ = c.Next() {
+			ks = append(ks, k...)
+		}
+		return ks
+	})
+	if forward != "abcd" {
+		t.Errorf("forward keys = %q, want %q", forward, "abcd")
+	}
+
+	backward := keys(t, s, func(c store.Cursor) (ks []byte) {
+		for k, _ := c.Last(); k != nil; k, _ = c.Prev() {
+			ks = append(ks, k...)
+		}
+		return ks
+	})
+	if backward != "dcba" {
+		t.Errorf("backward keys = %q, want %q", backward, "dcba")
+	}
+
+	// values follow their keys.
+	err := s.View(context.Background(), func(tx store.Impl) error {
+		b, err := tx.Bucket(testBucket)
+		if err != nil {
+			return err
+		}
+		c, err := b.Cursor()
+		if err != nil {
+			return err
+		}
+		if k, v := c.First(); string(k) != "a" || string(v) != "1" {
+			t.Errorf("First() = %q, %q, want %q, %q", k, v, "a", "1")
+		}
+		if k, v := c.Last(); string(k) != "d" || string(v) != "4" {
+			t.Errorf("Last() = %q, %q, want %q, %q", k, v, "d", "4")
+		}
+		return nil
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+}
+
+func cursorSeek(t *testing.T, s store.Store) {
+	put(t, s, "aa", "1", "ab", "2", "ba", "3", "bb", "4")
+
+	err := s.View(context.Background(), func(tx store.Impl) error {
+		b, err := tx.Bucket(testBucket)
+		if err != nil {
+			return err
+		}
+		c, err := b.Cursor()
+		if err != nil {
+			return err
+		}
+
+		if k, v := c.Seek([]byte("b")); string(k) != "ba" || string(v) != "3" {
+			t.Errorf("Seek(b) = %q, %q, want %q, %q", k, v, "ba", "3")
+		}
+		if k, _ := c.Next(); string(k) != "bb" {
+			t.Errorf("Next() after Seek(b) = %q, want %q", k, "bb")
+		}
+		if k, _ := c.Next(); k != nil {
+			t.Errorf("Next() past the end = %q, want nil", k)
+		}
+
+		if k, _ := c.Seek([]byte("ab")); string(k) != "ab" {
+			t.Errorf("Seek(ab) = %q, want %q", k, "ab")
+		}
+		if k, _ := c.Prev(); string(k) != "aa" {
+			t.Errorf("Prev() after Seek(ab) = %q, want %q", k, "aa")
+		}
+		if k, _ := c.Prev(); k != nil {
+			t.Errorf("Prev() before the start = %q, want nil", k)
+		}
+
+		if k, _ := c.Seek([]byte("c")); k != nil {
+			t.Errorf("Seek(c) = %q, want nil", k)
+		}
+		return nil
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+}
+
+func keyN(t *testing.T, s store.Store) {
+	put(t, s, "a", "1", "b", "2", "c", "3")
+
+	err := s.View(context.Background(), func(tx store.Impl) error {
+		b, err := tx.Bucket(testBucket)
+		if err != nil {
+			return err
+		}
+		// counting keys is optional.
+		kc, ok := b.(store.KeyCounter)
+		if !ok {
+			return nil
+		}
+		if n, err := kc.KeyN(); err != nil || n != 3 {
+			t.Errorf("KeyN() = %d, %v, want 3", n, err)
+		}
+		return nil
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+}
+
+func keySize(t *testing.T, s store.Store) {
+	longest := strings.Repeat("k", store.MaxKeySize)
+	put(t, s, longest, "1")
+	if v, err := get(t, s, longest); err != nil || v != "1" {
+		t.Errorf("Get(longest key) = %q, %v, want %q", v, err, "1")
+	}
+
+	err := s.Modify(context.Background(), func(tx store.Impl) error {
+		b, err := tx.Bucket(testBucket)
+		if err != nil {
+			return err
+		}
+		return b.Put([]byte(longest+"k"), []byte("2"))
+	})
+	if err != store.ErrKeyTooLarge {
+		t.Errorf("Put(too large key) error = %v, want %v", err, store.ErrKeyTooLarge)
+	}
+}
+
+func storeService(t *testing.T, s store.Store) {
+	ctx := context.Background()
+	svc := store.NewService(s)
+	if err := svc.Init(ctx); err != nil {
+		t.Fatal(err)
+	}
+
+	orgID := service.ID(1)
+	for _, content := range []string{"first?", "second?"} {
+		q := &service.Question{
+			OrgID:   orgID,
+			UserID:  service.ID(2),
+			Content: content,
+		}
+		if err := svc.CreateQuestion(ctx, q); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	qs, total, err := svc.FindQuestions(ctx, service.QuestionFilter{OrgID: &orgID})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if total != 2 || len(qs) != 2 {
+		t.Fatalf("FindQuestions returned %d of %d questions, want 2 of 2", len(qs), total)
+	}
+	if qs[0].Content != "first?" {
+		t.Errorf("first question content = %q, want %q", qs[0].Content, "first?")
+	}
+}
+
+func migrations(t *testing.T, s store.Store) {
+	ctx := context.Background()
+	svc := store.NewService(s)
+	if err := svc.Init(ctx); err != nil {
+		t.Fatal(err)
+	}
+
+	pending := func() int {
+		t.Helper()
+		states, err := svc.MigrationStatus(ctx)
+		if err != nil {
+			t.Fatal(err)
+		}
+		n := 0
+		for _, state := range states {
+			if state.AppliedAt == nil {
+				n++
+			}
+		}
+		return n
+	}
+
+	if n := pending(); n != 0 {
+		t.Errorf("%d migrations pending after Init, want 0", n)
+	}
+
+	if err := svc.MigrateDown(ctx, 0); err != nil {
+		t.Fatal(err)
+	}
+	if n := pending(); n != len(store.Migrations) {
+		t.Errorf("%d migrations pending after MigrateDown, want %d", n, len(store.Migrations))
+	}
+
+	if err := svc.MigrateUp(ctx, 0); err != nil {
+		t.Fatal(err)
+	}
+	if n := pending(); n != 0 {
+		t.Errorf("%d migrations pending after MigrateUp, want 0", n)
+	}
+}
+
+// KVStoreQueryErrors checks that failed reads of a cursor are not taken for the
+// end of the bucket. The stores returned by init fail to read the bucket while
+// the fail func is set to true.
+func KVStoreQueryErrors(init func(t *testing.T) (s store.Store, fail func(bool), done func()), t *testing.T) {
+	s, fail, done := init(t)
+	defer done()
+
+	put(t, s, "a", "1", "b", "2")
+
+	scan := func(tx store.Impl) error {
+		b, err := tx.Bucket(testBucket)
+		if err != nil {
+			return err
+		}
+		if err := b.Put([]byte("c"), []byte("3")); err != nil && err != store.ErrTxNotWritable {
+			return err
+		}
+
+		c, err := b.Cursor()
+		if err != nil {
+			return err
+		}
+		fail(true)
+		defer fail(false)
+		for k, _ := c.First(); k != nil; k, _ = c.Next() {
+		}
+		return nil
+	}
+
+	if err := s.View(context.Background(), scan); err == nil {
+		t.Error("View with a failed cursor succeeded, want its error")
+	}
+
+	// the writes of the transaction are rolled back.
+	if err := s.Modify(context.Background(), scan); err == nil {
+		t.Error("Modify with a failed cursor succeeded, want its error")
+	}
+	if _, err := get(t, s, "c"); !store.IsNotFound(err) {
+		t.Errorf("Get(c) after a failed cursor error = %v, want %v", err, store.ErrKeyNotFound)
+	}
+}
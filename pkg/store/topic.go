@@ -0,0 +1,1709 @@
+package store
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/ustackq/indagate/pkg/service"
+	"github.com/ustackq/indagate/pkg/utils/errors"
+)
+
+// maxTopicDepth bounds the walks up the topic tree and along merge redirects.
+const maxTopicDepth = 64
+
+var (
+	topicBucket         = []byte("topicsv1")
+	topicURLTokenIndex  = []byte("topicurltokenindexv1")
+	topicRelationBucket = []byte("topicrelationsv1")
+	topicItemIndex      = []byte("topicitemindexv1")
+	topicFocusBucket    = []byte("topicfocusv1")
+	topicUserFocusIndex = []byte("topicuserfocusindexv1")
+	topicMergeBucket    = []byte("topicmergesv1")
+	topicBuckets        = [][]byte{topicBucket, topicURLTokenIndex, topicRelationBucket, topicItemIndex, topicFocusBucket, topicUserFocusIndex, topicMergeBucket}
+)
+
+// assert Service implement service.TopicService
+var _ service.TopicService = (*Service)(nil)
+
+// topicables holds the item types which can be related to topics,
+// the function returns the organization of the item.
+var topicables = map[service.ItemType]func(s *Service, ctx context.Context, tx Impl, id service.ID) (service.ID, error){
+	service.QuestionItemType: (*Service).questionOrg,
+	service.ArticleItemType:  (*Service).articleOrg,
+}
+
+func (s *Service) initializeTopics(ctx context.Context, tx Impl) error {
+	for _, b := range topicBuckets {
+		if _, err := s.topicBucket(tx, b); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// topicBucket returns one of the buckets of the topic service.
+func (s *Service) topicBucket(tx Impl, name []byte) (Bucket, error) {
+	b, err := tx.Bucket(name)
+	if err != nil {
+		return nil, UnexpectedTopicError(err)
+	}
+	return b, nil
+}
+
+// UnexpectedTopicError wraps errors raised while retrieving the topic buckets.
+func UnexpectedTopicError(err error) *errors.Error {
+	return &errors.Error{
+		Code: errors.Internal,
+		Msg:  fmt.Sprintf("unexpected error retrieving topic bucket; %v", err),
+		Op:   "topicBucket",
+	}
+}
+
+// idsKey concatenates the encoded ids, every encoded id has the same length
+// so that the leading ids of a key can be used as a prefix.
+func idsKey(ids ...service.ID) ([]byte, error) {
+	key := []byte{}
+	for _, id := range ids {
+		encodedID, err := id.Encode()
+		if err != nil {
+			return nil, &errors.Error{
+				Code: errors.Invalid,
+				Err:  err,
+			}
+		}
+		key = append(key, encodedID...)
+	}
+	return key, nil
+}
+
+// topicItemKey is the item type followed by the encoded item id and topic ids.
+func topicItemKey(itemType service.ItemType, itemID service.ID, topicIDs ...service.ID) ([]byte, error) {
+	ids, err := idsKey(append([]service.ID{itemID}, topicIDs...)...)
+	if err != nil {
+		return nil, err
+	}
+
+	key := make([]byte, 0, len(itemType)+1+len(ids))
+	key = append(key, itemType...)
+	key = append(key, '/')
+	return append(key, ids...), nil
+}
+
+// topicRelationKey is the encoded topic id followed by the item of the relation.
+func topicRelationKey(topicID service.ID, itemType service.ItemType, itemID service.ID) ([]byte, error) {
+	tid, err := idsKey(topicID)
+	if err != nil {
+		return nil, err
+	}
+
+	item, err := topicItemKey(itemType, itemID)
+	if err != nil {
+		return nil, err
+	}
+	return append(tid, item...), nil
+}
+
+// normalizeURLToken lower cases the token and replaces all white space by dashes.
+func normalizeURLToken(token string) string {
+	return strings.ToLower(strings.Join(strings.Fields(token), "-"))
+}
+
+// FindTopicByID returns a single topic by ID.
+func (s *Service) FindTopicByID(ctx context.Context, id service.ID) (*service.Topic, error) {
+	var t *service.Topic
+	err := s.store.View(ctx, func(tx Impl) error {
+		topic, err := s.findTopicByID(ctx, tx, id)
+		if err != nil {
+			return err
+		}
+		t = topic
+		return nil
+	})
+	if err != nil {
+		return nil, &errors.Error{
+			Err: err,
+			Op:  service.OpFindTopicByID,
+		}
+	}
+	return t, nil
+}
+
+func (s *Service) findTopicByID(ctx context.Context, tx Impl, id service.ID) (*service.Topic, error) {
+	encodedID, err := id.Encode()
+	if err != nil {
+		return nil, &errors.Error{
+			Code: errors.Invalid,
+			Err:  err,
+		}
+	}
+
+	b, err := s.topicBucket(tx, topicBucket)
+	if err != nil {
+		return nil, err
+	}
+
+	v, err := b.Get(encodedID)
+	if IsNotFound(err) {
+		return nil, service.ErrTopicNotFound
+	}
+
+	if err != nil {
+		return nil, &errors.Error{
+			Code: errors.Internal,
+			Err:  err,
+		}
+	}
+	return unmarshalTopic(v)
+}
+
+func unmarshalTopic(v []byte) (*service.Topic, error) {
+	t := &service.Topic{}
+	if err := json.Unmarshal(v, t); err != nil {
+		return nil, &errors.Error{
+			Code: errors.Internal,
+			Msg:  "topic could not be unmarshalled",
+			Err:  err,
+			Op:   "unmarshalTopic",
+		}
+	}
+	return t, nil
+}
+
+// findLiveTopicByID returns the topic unless it has been merged.
+func (s *Service) findLiveTopicByID(ctx context.Context, tx Impl, id service.ID) (*service.Topic, error) {
+	t, err := s.findTopicByID(ctx, tx, id)
+	if err != nil {
+		return nil, err
+	}
+
+	if t.MergedID.Valid() {
+		return nil, service.ErrTopicMerged
+	}
+	return t, nil
+}
+
+// FindTopicByURLToken returns the topic of the url token, following merges.
+func (s *Service) FindTopicByURLToken(ctx context.Context, token string) (*service.Topic, error) {
+	var t *service.Topic
+	err := s.store.View(ctx, func(tx Impl) error {
+		topic, err := s.findTopicByURLToken(ctx, tx, token)
+		if err != nil {
+			return err
+		}
+		t = topic
+		return nil
+	})
+	if err != nil {
+		return nil, &errors.Error{
+			Err: err,
+			Op:  service.OpFindTopicByURLToken,
+		}
+	}
+	return t, nil
+}
+
+func (s *Service) findTopicByURLToken(ctx context.Context, tx Impl, token string) (*service.Topic, error) {
+	idx, err := s.topicBucket(tx, topicURLTokenIndex)
+	if err != nil {
+		return nil, err
+	}
+
+	v, err := idx.Get([]byte(normalizeURLToken(token)))
+	if IsNotFound(err) {
+		return nil, service.ErrTopicNotFound
+	}
+
+	if err != nil {
+		return nil, &errors.Error{
+			Code: errors.Internal,
+			Err:  err,
+		}
+	}
+
+	var id service.ID
+	if err := id.Decode(v); err != nil {
+		return nil, &errors.Error{
+			Code: errors.Invalid,
+			Err:  err,
+		}
+	}
+
+	for i := 0; i < maxTopicDepth; i++ {
+		t, err := s.findTopicByID(ctx, tx, id)
+		if err != nil {
+			return nil, err
+		}
+
+		if !t.MergedID.Valid() {
+			return t, nil
+		}
+		id = t.MergedID
+	}
+
+	return nil, &errors.Error{
+		Code: errors.Internal,
+		Msg:  fmt.Sprintf("topic url token %s redirects more than %d times", token, maxTopicDepth),
+	}
+}
+
+// FindTopics returns a list of topics that match filter and the total count of matching topics.
+func (s *Service) FindTopics(ctx context.Context, filter service.TopicFilter, opt ...service.FindOptions) ([]*service.Topic, int, error) {
+	if filter.ID != nil {
+		t, err := s.FindTopicByID(ctx, *filter.ID)
+		if err != nil {
+			return nil, 0, &errors.Error{
+				Err: err,
+				Op:  service.OpFindTopics,
+			}
+		}
+		return []*service.Topic{t}, 1, nil
+	}
+
+	if filter.URLToken != nil {
+		t, err := s.FindTopicByURLToken(ctx, *filter.URLToken)
+		if err != nil {
+			return nil, 0, &errors.Error{
+				Err: err,
+				Op:  service.OpFindTopics,
+			}
+		}
+		return []*service.Topic{t}, 1, nil
+	}
+
+	ts := []*service.Topic{}
+	err := s.store.View(ctx, func(tx Impl) error {
+		return s.forEachTopic(ctx, tx, func(t *service.Topic) bool {
+			if t.MergedID.Valid() {
+				return true
+			}
+			if filter.OrgID != nil && t.OrgID != *filter.OrgID {
+				return true
+			}
+			if filter.ParentID != nil && t.ParentID != *filter.ParentID {
+				return true
+			}
+			ts = append(ts, t)
+			return true
+		})
+	})
+	if err != nil {
+		return nil, 0, &errors.Error{
+			Err: err,
+			Op:  service.OpFindTopics,
+		}
+	}
+
+	sortTopics(ts, opt...)
+	start, end := pageWindow(len(ts), opt...)
+	return ts[start:end], len(ts), nil
+}
+
+func sortTopics(ts []*service.Topic, opts ...service.FindOptions) {
+	var opt service.FindOptions
+	if len(opts) > 0 {
+		opt = opts[0]
+	}
+
+	var less func(i, j int) bool
+	switch opt.SortBy {
+	case service.TopicSortByTitle:
+		less = func(i, j int) bool { return ts[i].Title < ts[j].Title }
+	case service.TopicSortByDiscussions:
+		less = func(i, j int) bool { return ts[i].DiscussCount < ts[j].DiscussCount }
+	case service.TopicSortByFocus:
+		less = func(i, j int) bool { return ts[i].FocusCount < ts[j].FocusCount }
+	default:
+		less = func(i, j int) bool { return ts[i].CreatedAt.Before(ts[j].CreatedAt) }
+	}
+
+	if opt.Descending {
+		sort.SliceStable(ts, func(i, j int) bool { return less(j, i) })
+		return
+	}
+	sort.SliceStable(ts, less)
+}
+
+func (s *Service) forEachTopic(ctx context.Context, tx Impl, fn func(*service.Topic) bool) error {
+	b, err := s.topicBucket(tx, topicBucket)
+	if err != nil {
+		return err
+	}
+
+	cur, err := b.Cursor()
+	if err != nil {
+		return err
+	}
+
+	for k, v := cur.First(); k != nil; k, v = cur.Next() {
+		t, err := unmarshalTopic(v)
+		if err != nil {
+			return err
+		}
+		if !fn(t) {
+			break
+		}
+	}
+	return nil
+}
+
+// forEachPrefix calls fn with the values of all keys of the bucket starting with prefix.
+func forEachPrefix(b Bucket, prefix []byte, fn func(k, v []byte) error) error {
+	cur, err := b.Cursor()
+	if err != nil {
+		return err
+	}
+
+	for k, v := cur.Seek(prefix); k != nil && bytes.HasPrefix(k, prefix); k, v = cur.Next() {
+		if err := fn(k, v); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// FindTopicPath returns the ancestors of the topic from the root down to the topic itself.
+func (s *Service) FindTopicPath(ctx context.Context, id service.ID) ([]*service.Topic, error) {
+	var path []*service.Topic
+	err := s.store.View(ctx, func(tx Impl) error {
+		ts, err := s.findTopicPath(ctx, tx, id)
+		if err != nil {
+			return err
+		}
+		path = ts
+		return nil
+	})
+	if err != nil {
+		return nil, &errors.Error{
+			Err: err,
+			Op:  service.OpFindTopicPath,
+		}
+	}
+	return path, nil
+}
+
+func (s *Service) findTopicPath(ctx context.Context, tx Impl, id service.ID) ([]*service.Topic, error) {
+	var path []*service.Topic
+	for i := 0; id.Valid(); i++ {
+		if i == maxTopicDepth {
+			return nil, &errors.Error{
+				Code: errors.Internal,
+				Msg:  fmt.Sprintf("topic tree is deeper than %d levels", maxTopicDepth),
+			}
+		}
+
+		t, err := s.findTopicByID(ctx, tx, id)
+		if err != nil {
+			return nil, err
+		}
+		path = append(path, t)
+		id = t.ParentID
+	}
+
+	for i, j := 0, len(path)-1; i < j; i, j = i+1, j-1 {
+		path[i], path[j] = path[j], path[i]
+	}
+	return path, nil
+}
+
+// validParent checks the parent of a topic exists in the same organization and
+// does not have the topic as one of its ancestors.
+func (s *Service) validParent(ctx context.Context, tx Impl, t *service.Topic, parentID service.ID) error {
+	if !parentID.Valid() {
+		return nil
+	}
+
+	path, err := s.findTopicPath(ctx, tx, parentID)
+	if err != nil {
+		return err
+	}
+
+	parent := path[len(path)-1]
+	if parent.MergedID.Valid() {
+		return service.ErrTopicMerged
+	}
+
+	if parent.OrgID != t.OrgID {
+		return &errors.Error{
+			Code: errors.Invalid,
+			Msg:  fmt.Sprintf("parent topic %s belongs to another organization", parent.ID),
+		}
+	}
+
+	for _, ancestor := range path {
+		if ancestor.ID == t.ID {
+			return service.ErrTopicCycle
+		}
+	}
+	return nil
+}
+
+// CreateTopic creates a new topic and sets t.ID with the new identifier.
+func (s *Service) CreateTopic(ctx context.Context, t *service.Topic) error {
+	return s.store.Modify(ctx, func(tx Impl) error {
+		if err := s.createTopic(ctx, tx, t); err != nil {
+			return &errors.Error{
+				Err: err,
+				Op:  service.OpCreateTopic,
+			}
+		}
+		return nil
+	})
+}
+
+func (s *Service) createTopic(ctx context.Context, tx Impl, t *service.Topic) error {
+	if err := t.Valid(); err != nil {
+		return err
+	}
+
+	if t.URLToken == "" {
+		t.URLToken = t.Title
+	}
+	t.URLToken = normalizeURLToken(t.URLToken)
+
+	t.ID = s.IDGenerator.ID()
+	if err := s.validParent(ctx, tx, t, t.ParentID); err != nil {
+		return err
+	}
+
+	if err := s.indexURLToken(ctx, tx, t); err != nil {
+		return err
+	}
+
+	t.IsParent = false
+	t.MergedID = 0
+	t.DiscussCount = 0
+	t.FocusCount = 0
+	t.CreatedAt = s.time()
+	t.UpdatedAt = t.CreatedAt
+	if err := s.putTopic(ctx, tx, t); err != nil {
+		return err
+	}
+
+	if err := s.indexTopic(ctx, tx, t); err != nil {
+		return err
+	}
+	return s.refreshTopicChildren(ctx, tx, t.ParentID)
+}
+
+func (s *Service) putTopic(ctx context.Context, tx Impl, t *service.Topic) error {
+	v, err := json.Marshal(t)
+	if err != nil {
+		return &errors.Error{
+			Code: errors.Invalid,
+			Err:  err,
+		}
+	}
+
+	encodedID, err := t.ID.Encode()
+	if err != nil {
+		return &errors.Error{
+			Code: errors.Invalid,
+			Err:  err,
+		}
+	}
+
+	b, err := s.topicBucket(tx, topicBucket)
+	if err != nil {
+		return err
+	}
+
+	if err := b.Put(encodedID, v); err != nil {
+		return errors.InternalErr(err)
+	}
+	return nil
+}
+
+// indexURLToken points the url token of the topic at it, the token must not be used yet.
+func (s *Service) indexURLToken(ctx context.Context, tx Impl, t *service.Topic) error {
+	idx, err := s.topicBucket(tx, topicURLTokenIndex)
+	if err != nil {
+		return err
+	}
+
+	if ok, err := exists(idx, []byte(t.URLToken)); err != nil || ok {
+		if err != nil {
+			return err
+		}
+		return service.ErrTopicURLTokenExists
+	}
+
+	encodedID, err := t.ID.Encode()
+	if err != nil {
+		return &errors.Error{
+			Code: errors.Invalid,
+			Err:  err,
+		}
+	}
+
+	if err := idx.Put([]byte(t.URLToken), encodedID); err != nil {
+		if err == ErrKeyTooLarge {
+			return &errors.Error{
+				Code: errors.Invalid,
+				Msg:  fmt.Sprintf("topic url token is longer than %d bytes", MaxKeySize),
+			}
+		}
+		return errors.InternalErr(err)
+	}
+	return nil
+}
+
+// refreshTopicChildren marks the topic as parent if any topic which is not merged is below it.
+func (s *Service) refreshTopicChildren(ctx context.Context, tx Impl, id service.ID) error {
+	if !id.Valid() {
+		return nil
+	}
+
+	t, err := s.findTopicByID(ctx, tx, id)
+	if err != nil {
+		return err
+	}
+
+	isParent := false
+	err = s.forEachTopic(ctx, tx, func(child *service.Topic) bool {
+		isParent = child.ParentID == id && !child.MergedID.Valid()
+		return !isParent
+	})
+	if err != nil {
+		return err
+	}
+
+	if t.IsParent == isParent {
+		return nil
+	}
+
+	t.IsParent = isParent
+	t.UpdatedAt = s.time()
+	return s.putTopic(ctx, tx, t)
+}
+
+// moveTopicChildren moves the children of a topic below another one.
+func (s *Service) moveTopicChildren(ctx context.Context, tx Impl, fromID, toID service.ID) error {
+	children := []*service.Topic{}
+	err := s.forEachTopic(ctx, tx, func(t *service.Topic) bool {
+		if t.ParentID == fromID {
+			children = append(children, t)
+		}
+		return true
+	})
+	if err != nil {
+		return err
+	}
+
+	for _, child := range children {
+		child.ParentID = toID
+		child.UpdatedAt = s.time()
+		if err := s.putTopic(ctx, tx, child); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// UpdateTopic updates a single topic with changeset.
+func (s *Service) UpdateTopic(ctx context.Context, id service.ID, upd service.TopicUpdate) (*service.Topic, error) {
+	var t *service.Topic
+	err := s.store.Modify(ctx, func(tx Impl) error {
+		topic, err := s.updateTopic(ctx, tx, id, upd)
+		if err != nil {
+			return &errors.Error{
+				Err: err,
+				Op:  service.OpUpdateTopic,
+			}
+		}
+		t = topic
+		return nil
+	})
+	return t, err
+}
+
+func (s *Service) updateTopic(ctx context.Context, tx Impl, id service.ID, upd service.TopicUpdate) (*service.Topic, error) {
+	if err := upd.Valid(); err != nil {
+		return nil, err
+	}
+
+	t, err := s.findLiveTopicByID(ctx, tx, id)
+	if err != nil {
+		return nil, err
+	}
+
+	// a locked topic can only be unlocked.
+	if t.Lock && (upd.Lock == nil || *upd.Lock) {
+		return nil, service.ErrTopicLocked
+	}
+
+	if upd.Title != nil {
+		t.Title = *upd.Title
+	}
+	if upd.Description != nil {
+		t.Description = *upd.Description
+	}
+	if upd.Avatar != nil {
+		t.Avatar = *upd.Avatar
+	}
+	if upd.SEOTitle != nil {
+		t.SEOTitle = *upd.SEOTitle
+	}
+	if upd.Lock != nil {
+		t.Lock = *upd.Lock
+	}
+
+	if upd.URLToken != nil && normalizeURLToken(*upd.URLToken) != t.URLToken {
+		if err := s.removeURLToken(ctx, tx, t.URLToken); err != nil {
+			return nil, err
+		}
+
+		t.URLToken = normalizeURLToken(*upd.URLToken)
+		if err := s.indexURLToken(ctx, tx, t); err != nil {
+			return nil, err
+		}
+	}
+
+	prevParentID := t.ParentID
+	if upd.ParentID != nil && *upd.ParentID != t.ParentID {
+		if err := s.validParent(ctx, tx, t, *upd.ParentID); err != nil {
+			return nil, err
+		}
+		t.ParentID = *upd.ParentID
+	}
+
+	t.UpdatedAt = s.time()
+	if err := s.putTopic(ctx, tx, t); err != nil {
+		return nil, err
+	}
+
+	if err := s.indexTopic(ctx, tx, t); err != nil {
+		return nil, err
+	}
+
+	if prevParentID != t.ParentID {
+		if err := s.refreshTopicChildren(ctx, tx, prevParentID); err != nil {
+			return nil, err
+		}
+		if err := s.refreshTopicChildren(ctx, tx, t.ParentID); err != nil {
+			return nil, err
+		}
+	}
+	return t, nil
+}
+
+func (s *Service) removeURLToken(ctx context.Context, tx Impl, token string) error {
+	idx, err := s.topicBucket(tx, topicURLTokenIndex)
+	if err != nil {
+		return err
+	}
+
+	if err := idx.Delete([]byte(token)); err != nil {
+		return errors.WrapperErr(err)
+	}
+	return nil
+}
+
+// DeleteTopic removes a topic by ID, its children move up to its parent.
+// The relations, focuses and the topics merged into it are removed as well.
+func (s *Service) DeleteTopic(ctx context.Context, id service.ID) error {
+	return s.store.Modify(ctx, func(tx Impl) error {
+		if err := s.deleteTopic(ctx, tx, id); err != nil {
+			return &errors.Error{
+				Err: err,
+				Op:  service.OpDeleteTopic,
+			}
+		}
+		return nil
+	})
+}
+
+func (s *Service) deleteTopic(ctx context.Context, tx Impl, id service.ID) error {
+	t, err := s.findLiveTopicByID(ctx, tx, id)
+	if err != nil {
+		return err
+	}
+
+	if err := s.moveTopicChildren(ctx, tx, t.ID, t.ParentID); err != nil {
+		return err
+	}
+
+	if err := s.removeTopic(ctx, tx, t); err != nil {
+		return err
+	}
+	return s.refreshTopicChildren(ctx, tx, t.ParentID)
+}
+
+// removeTopic deletes the topic with its relations, focuses and url token,
+// together with the topics merged into it.
+func (s *Service) removeTopic(ctx context.Context, tx Impl, t *service.Topic) error {
+	merges, err := s.findTopicMerges(ctx, tx, t.ID)
+	if err != nil {
+		return err
+	}
+
+	mb, err := s.topicBucket(tx, topicMergeBucket)
+	if err != nil {
+		return err
+	}
+
+	for _, m := range merges {
+		source, err := s.findTopicByID(ctx, tx, m.SourceID)
+		if err != nil && err != service.ErrTopicNotFound {
+			return err
+		}
+		if source != nil {
+			if err := s.removeTopic(ctx, tx, source); err != nil {
+				return err
+			}
+		}
+
+		key, err := idsKey(m.TargetID, m.SourceID)
+		if err != nil {
+			return err
+		}
+		if err := mb.Delete(key); err != nil {
+			return errors.WrapperErr(err)
+		}
+	}
+
+	relations, err := s.findTopicRelations(ctx, tx, service.TopicRelationFilter{TopicID: &t.ID})
+	if err != nil {
+		return err
+	}
+	for _, r := range relations {
+		if err := s.removeTopicRelationEntry(ctx, tx, r); err != nil {
+			return err
+		}
+	}
+
+	focuses, err := s.findTopicFocuses(ctx, tx, t.ID)
+	if err != nil {
+		return err
+	}
+	for _, f := range focuses {
+		if err := s.removeTopicFocusEntry(ctx, tx, f); err != nil {
+			return err
+		}
+
+		if err := s.adjustUserFollowCounts(ctx, tx, f.UserID, 0, 0, -1); err != nil {
+			return err
+		}
+
+		if err := s.retractActivity(ctx, tx, f.UserID, service.FollowedActivity, service.ItemType(service.TopicFollow), f.TopicID); err != nil {
+			return err
+		}
+	}
+
+	if err := s.removeURLToken(ctx, tx, t.URLToken); err != nil {
+		return err
+	}
+
+	if err := s.unindexSearchDoc(ctx, tx, service.TopicSearchType, t.ID); err != nil {
+		return err
+	}
+
+	encodedID, err := t.ID.Encode()
+	if err != nil {
+		return &errors.Error{
+			Code: errors.Invalid,
+			Err:  err,
+		}
+	}
+
+	b, err := s.topicBucket(tx, topicBucket)
+	if err != nil {
+		return err
+	}
+
+	if err := b.Delete(encodedID); err != nil {
+		return errors.WrapperErr(err)
+	}
+	return nil
+}
+
+// MergeTopic merges the source topic into the target topic in a single transaction.
+func (s *Service) MergeTopic(ctx context.Context, sourceID, targetID, userID service.ID) (*service.Topic, error) {
+	var t *service.Topic
+	err := s.store.Modify(ctx, func(tx Impl) error {
+		topic, err := s.mergeTopic(ctx, tx, sourceID, targetID, userID)
+		if err != nil {
+			return &errors.Error{
+				Err: err,
+				Op:  service.OpMergeTopic,
+			}
+		}
+		t = topic
+		return nil
+	})
+	return t, err
+}
+
+func (s *Service) mergeTopic(ctx context.Context, tx Impl, sourceID, targetID, userID service.ID) (*service.Topic, error) {
+	if sourceID == targetID {
+		return nil, &errors.Error{
+			Code: errors.Invalid,
+			Msg:  "topic can not be merged into itself",
+		}
+	}
+
+	source, err := s.findLiveTopicByID(ctx, tx, sourceID)
+	if err != nil {
+		return nil, err
+	}
+
+	target, err := s.findLiveTopicByID(ctx, tx, targetID)
+	if err != nil {
+		return nil, err
+	}
+
+	if source.Lock || target.Lock {
+		return nil, service.ErrTopicLocked
+	}
+
+	// the target must not be below the source, which leaves the tree with the merge.
+	if err := s.validParent(ctx, tx, source, target.ID); err != nil {
+		return nil, err
+	}
+
+	relations, err := s.findTopicRelations(ctx, tx, service.TopicRelationFilter{TopicID: &source.ID})
+	if err != nil {
+		return nil, err
+	}
+	for _, r := range relations {
+		if err := s.removeTopicRelationEntry(ctx, tx, r); err != nil {
+			return nil, err
+		}
+
+		_, err := s.findTopicRelation(ctx, tx, target.ID, r.ItemType, r.ItemID)
+		if err == nil {
+			continue
+		}
+		if err != service.ErrTopicRelationNotFound {
+			return nil, err
+		}
+
+		r.TopicID = target.ID
+		if err := s.putTopicRelation(ctx, tx, r); err != nil {
+			return nil, err
+		}
+	}
+
+	focuses, err := s.findTopicFocuses(ctx, tx, source.ID)
+	if err != nil {
+		return nil, err
+	}
+	for _, f := range focuses {
+		if err := s.removeTopicFocusEntry(ctx, tx, f); err != nil {
+			return nil, err
+		}
+
+		// users focusing both topics now focus one less.
+		_, err := s.findTopicFocus(ctx, tx, target.ID, f.UserID)
+		if err == nil {
+			if err := s.adjustUserFollowCounts(ctx, tx, f.UserID, 0, 0, -1); err != nil {
+				return nil, err
+			}
+			continue
+		}
+		if err != service.ErrTopicFocusNotFound {
+			return nil, err
+		}
+
+		f.TopicID = target.ID
+		if err := s.putTopicFocus(ctx, tx, f); err != nil {
+			return nil, err
+		}
+	}
+
+	if err := s.moveTopicChildren(ctx, tx, source.ID, target.ID); err != nil {
+		return nil, err
+	}
+
+	now := s.time()
+	source.MergedID = target.ID
+	source.IsParent = false
+	source.DiscussCount = 0
+	source.FocusCount = 0
+	source.UpdatedAt = now
+	if err := s.putTopic(ctx, tx, source); err != nil {
+		return nil, err
+	}
+
+	if err := s.indexTopic(ctx, tx, source); err != nil {
+		return nil, err
+	}
+
+	if err := s.putTopicMerge(ctx, tx, &service.TopicMerge{
+		SourceID:  source.ID,
+		TargetID:  target.ID,
+		UserID:    userID,
+		CreatedAt: now,
+	}); err != nil {
+		return nil, err
+	}
+
+	if err := s.refreshTopicChildren(ctx, tx, source.ParentID); err != nil {
+		return nil, err
+	}
+	if err := s.refreshTopicChildren(ctx, tx, target.ID); err != nil {
+		return nil, err
+	}
+	return s.refreshTopicCounts(ctx, tx, target.ID)
+}
+
+func (s *Service) putTopicMerge(ctx context.Context, tx Impl, m *service.TopicMerge) error {
+	v, err := json.Marshal(m)
+	if err != nil {
+		return &errors.Error{
+			Code: errors.Invalid,
+			Err:  err,
+		}
+	}
+
+	key, err := idsKey(m.TargetID, m.SourceID)
+	if err != nil {
+		return err
+	}
+
+	b, err := s.topicBucket(tx, topicMergeBucket)
+	if err != nil {
+		return err
+	}
+
+	if err := b.Put(key, v); err != nil {
+		return errors.InternalErr(err)
+	}
+	return nil
+}
+
+// FindTopicMerges returns the merges into the topic.
+func (s *Service) FindTopicMerges(ctx context.Context, targetID service.ID) ([]*service.TopicMerge, error) {
+	var ms []*service.TopicMerge
+	err := s.store.View(ctx, func(tx Impl) error {
+		merges, err := s.findTopicMerges(ctx, tx, targetID)
+		if err != nil {
+			return err
+		}
+		ms = merges
+		return nil
+	})
+	if err != nil {
+		return nil, &errors.Error{
+			Err: err,
+			Op:  service.OpFindTopicMerges,
+		}
+	}
+	return ms, nil
+}
+
+func (s *Service) findTopicMerges(ctx context.Context, tx Impl, targetID service.ID) ([]*service.TopicMerge, error) {
+	prefix, err := idsKey(targetID)
+	if err != nil {
+		return nil, err
+	}
+
+	b, err := s.topicBucket(tx, topicMergeBucket)
+	if err != nil {
+		return nil, err
+	}
+
+	ms := []*service.TopicMerge{}
+	err = forEachPrefix(b, prefix, func(k, v []byte) error {
+		m := &service.TopicMerge{}
+		if err := json.Unmarshal(v, m); err != nil {
+			return &errors.Error{
+				Code: errors.Internal,
+				Err:  err,
+			}
+		}
+		ms = append(ms, m)
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return ms, nil
+}
+
+// refreshTopicCounts recounts the relations and focuses of the topic.
+func (s *Service) refreshTopicCounts(ctx context.Context, tx Impl, id service.ID) (*service.Topic, error) {
+	t, err := s.findTopicByID(ctx, tx, id)
+	if err != nil {
+		return nil, err
+	}
+
+	relations, err := s.findTopicRelations(ctx, tx, service.TopicRelationFilter{TopicID: &id})
+	if err != nil {
+		return nil, err
+	}
+
+	focuses, err := s.findTopicFocuses(ctx, tx, id)
+	if err != nil {
+		return nil, err
+	}
+
+	t.DiscussCount = len(relations)
+	t.FocusCount = len(focuses)
+	if err := s.putTopic(ctx, tx, t); err != nil {
+		return nil, err
+	}
+	return t, nil
+}
+
+// FindTopicRelations returns a list of relations that match filter and the total count of matching relations.
+func (s *Service) FindTopicRelations(ctx context.Context, filter service.TopicRelationFilter, opt ...service.FindOptions) ([]*service.TopicRelation, int, error) {
+	rs := []*service.TopicRelation{}
+	err := s.store.View(ctx, func(tx Impl) error {
+		relations, err := s.findTopicRelations(ctx, tx, filter)
+		if err != nil {
+			return err
+		}
+		rs = relations
+		return nil
+	})
+	if err != nil {
+		return nil, 0, &errors.Error{
+			Err: err,
+			Op:  service.OpFindTopicRelations,
+		}
+	}
+
+	sort.SliceStable(rs, func(i, j int) bool {
+		if len(opt) > 0 && opt[0].Descending {
+			return rs[j].CreatedAt.Before(rs[i].CreatedAt)
+		}
+		return rs[i].CreatedAt.Before(rs[j].CreatedAt)
+	})
+	start, end := pageWindow(len(rs), opt...)
+	return rs[start:end], len(rs), nil
+}
+
+func (s *Service) findTopicRelations(ctx context.Context, tx Impl, filter service.TopicRelationFilter) ([]*service.TopicRelation, error) {
+	byItem := filter.ItemType != nil && filter.ItemID != nil
+	if filter.TopicID != nil && byItem {
+		r, err := s.findTopicRelation(ctx, tx, *filter.TopicID, *filter.ItemType, *filter.ItemID)
+		if err == service.ErrTopicRelationNotFound {
+			return []*service.TopicRelation{}, nil
+		}
+		if err != nil {
+			return nil, err
+		}
+		return []*service.TopicRelation{r}, nil
+	}
+
+	rs := []*service.TopicRelation{}
+	if filter.TopicID != nil {
+		prefix, err := idsKey(*filter.TopicID)
+		if err != nil {
+			return nil, err
+		}
+
+		b, err := s.topicBucket(tx, topicRelationBucket)
+		if err != nil {
+			return nil, err
+		}
+
+		err = forEachPrefix(b, prefix, func(k, v []byte) error {
+			r, err := unmarshalTopicRelation(v)
+			if err != nil {
+				return err
+			}
+			if filter.ItemType == nil || r.ItemType == *filter.ItemType {
+				rs = append(rs, r)
+			}
+			return nil
+		})
+		if err != nil {
+			return nil, err
+		}
+		return rs, nil
+	}
+
+	if !byItem {
+		return nil, &errors.Error{
+			Code: errors.Invalid,
+			Msg:  "topic or item required to find topic relations",
+		}
+	}
+
+	prefix, err := topicItemKey(*filter.ItemType, *filter.ItemID)
+	if err != nil {
+		return nil, err
+	}
+
+	idx, err := s.topicBucket(tx, topicItemIndex)
+	if err != nil {
+		return nil, err
+	}
+
+	err = forEachPrefix(idx, prefix, func(k, v []byte) error {
+		var topicID service.ID
+		if err := topicID.Decode(v); err != nil {
+			return &errors.Error{
+				Code: errors.Invalid,
+				Err:  err,
+			}
+		}
+
+		r, err := s.findTopicRelation(ctx, tx, topicID, *filter.ItemType, *filter.ItemID)
+		if err != nil {
+			return err
+		}
+		rs = append(rs, r)
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return rs, nil
+}
+
+func (s *Service) findTopicRelation(ctx context.Context, tx Impl, topicID service.ID, itemType service.ItemType, itemID service.ID) (*service.TopicRelation, error) {
+	key, err := topicRelationKey(topicID, itemType, itemID)
+	if err != nil {
+		return nil, err
+	}
+
+	b, err := s.topicBucket(tx, topicRelationBucket)
+	if err != nil {
+		return nil, err
+	}
+
+	v, err := b.Get(key)
+	if IsNotFound(err) {
+		return nil, service.ErrTopicRelationNotFound
+	}
+
+	if err != nil {
+		return nil, &errors.Error{
+			Code: errors.Internal,
+			Err:  err,
+		}
+	}
+	return unmarshalTopicRelation(v)
+}
+
+func unmarshalTopicRelation(v []byte) (*service.TopicRelation, error) {
+	r := &service.TopicRelation{}
+	if err := json.Unmarshal(v, r); err != nil {
+		return nil, &errors.Error{
+			Code: errors.Internal,
+			Msg:  "topic relation could not be unmarshalled",
+			Err:  err,
+			Op:   "unmarshalTopicRelation",
+		}
+	}
+	return r, nil
+}
+
+// AddTopicRelation relates an item to a topic, relating it twice has no effect.
+func (s *Service) AddTopicRelation(ctx context.Context, r *service.TopicRelation) error {
+	return s.store.Modify(ctx, func(tx Impl) error {
+		if err := s.addTopicRelation(ctx, tx, r); err != nil {
+			return &errors.Error{
+				Err: err,
+				Op:  service.OpAddTopicRelation,
+			}
+		}
+		return nil
+	})
+}
+
+func (s *Service) addTopicRelation(ctx context.Context, tx Impl, r *service.TopicRelation) error {
+	if err := r.Valid(); err != nil {
+		return err
+	}
+
+	orgOf, ok := topicables[r.ItemType]
+	if !ok {
+		return &errors.Error{
+			Code: errors.Invalid,
+			Msg:  fmt.Sprintf("%s can not be related to topics", r.ItemType),
+		}
+	}
+
+	t, err := s.findLiveTopicByID(ctx, tx, r.TopicID)
+	if err != nil {
+		return err
+	}
+
+	if t.Lock {
+		return service.ErrTopicLocked
+	}
+
+	orgID, err := orgOf(s, ctx, tx, r.ItemID)
+	if err != nil {
+		return err
+	}
+
+	if orgID != t.OrgID {
+		return &errors.Error{
+			Code: errors.Invalid,
+			Msg:  fmt.Sprintf("%s %s belongs to another organization than topic %s", r.ItemType, r.ItemID, t.ID),
+		}
+	}
+
+	prev, err := s.findTopicRelation(ctx, tx, r.TopicID, r.ItemType, r.ItemID)
+	if err == nil {
+		*r = *prev
+		return nil
+	}
+	if err != service.ErrTopicRelationNotFound {
+		return err
+	}
+
+	r.CreatedAt = s.time()
+	if err := s.putTopicRelation(ctx, tx, r); err != nil {
+		return err
+	}
+
+	_, err = s.refreshTopicCounts(ctx, tx, t.ID)
+	return err
+}
+
+// putTopicRelation stores the relation and its item index entry.
+func (s *Service) putTopicRelation(ctx context.Context, tx Impl, r *service.TopicRelation) error {
+	v, err := json.Marshal(r)
+	if err != nil {
+		return &errors.Error{
+			Code: errors.Invalid,
+			Err:  err,
+		}
+	}
+
+	key, err := topicRelationKey(r.TopicID, r.ItemType, r.ItemID)
+	if err != nil {
+		return err
+	}
+
+	b, err := s.topicBucket(tx, topicRelationBucket)
+	if err != nil {
+		return err
+	}
+
+	if err := b.Put(key, v); err != nil {
+		return errors.InternalErr(err)
+	}
+
+	idxKey, err := topicItemKey(r.ItemType, r.ItemID, r.TopicID)
+	if err != nil {
+		return err
+	}
+
+	encodedID, err := r.TopicID.Encode()
+	if err != nil {
+		return &errors.Error{
+			Code: errors.Invalid,
+			Err:  err,
+		}
+	}
+
+	idx, err := s.topicBucket(tx, topicItemIndex)
+	if err != nil {
+		return err
+	}
+
+	if err := idx.Put(idxKey, encodedID); err != nil {
+		return errors.InternalErr(err)
+	}
+	return nil
+}
+
+// RemoveTopicRelation removes an item from a topic.
+func (s *Service) RemoveTopicRelation(ctx context.Context, topicID service.ID, itemType service.ItemType, itemID service.ID) error {
+	return s.store.Modify(ctx, func(tx Impl) error {
+		if err := s.removeTopicRelation(ctx, tx, topicID, itemType, itemID); err != nil {
+			return &errors.Error{
+				Err: err,
+				Op:  service.OpRemoveTopicRelation,
+			}
+		}
+		return nil
+	})
+}
+
+func (s *Service) removeTopicRelation(ctx context.Context, tx Impl, topicID service.ID, itemType service.ItemType, itemID service.ID) error {
+	t, err := s.findLiveTopicByID(ctx, tx, topicID)
+	if err != nil {
+		return err
+	}
+
+	if t.Lock {
+		return service.ErrTopicLocked
+	}
+
+	r, err := s.findTopicRelation(ctx, tx, topicID, itemType, itemID)
+	if err != nil {
+		return err
+	}
+
+	if err := s.removeTopicRelationEntry(ctx, tx, r); err != nil {
+		return err
+	}
+
+	_, err = s.refreshTopicCounts(ctx, tx, t.ID)
+	return err
+}
+
+// removeTopicRelationEntry deletes the relation and its item index entry.
+func (s *Service) removeTopicRelationEntry(ctx context.Context, tx Impl, r *service.TopicRelation) error {
+	key, err := topicRelationKey(r.TopicID, r.ItemType, r.ItemID)
+	if err != nil {
+		return err
+	}
+
+	b, err := s.topicBucket(tx, topicRelationBucket)
+	if err != nil {
+		return err
+	}
+
+	if err := b.Delete(key); err != nil {
+		return errors.WrapperErr(err)
+	}
+
+	idxKey, err := topicItemKey(r.ItemType, r.ItemID, r.TopicID)
+	if err != nil {
+		return err
+	}
+
+	idx, err := s.topicBucket(tx, topicItemIndex)
+	if err != nil {
+		return err
+	}
+
+	if err := idx.Delete(idxKey); err != nil {
+		return errors.WrapperErr(err)
+	}
+	return nil
+}
+
+// deleteItemTopicRelations removes an item which is removed itself from all of its topics.
+func (s *Service) deleteItemTopicRelations(ctx context.Context, tx Impl, itemType service.ItemType, itemID service.ID) error {
+	relations, err := s.findTopicRelations(ctx, tx, service.TopicRelationFilter{ItemType: &itemType, ItemID: &itemID})
+	if err != nil {
+		return err
+	}
+
+	for _, r := range relations {
+		if err := s.removeTopicRelationEntry(ctx, tx, r); err != nil {
+			return err
+		}
+		if _, err := s.refreshTopicCounts(ctx, tx, r.TopicID); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// FindTopicFocus returns the focus of a user on a topic.
+func (s *Service) FindTopicFocus(ctx context.Context, topicID, userID service.ID) (*service.TopicFocus, error) {
+	var f *service.TopicFocus
+	err := s.store.View(ctx, func(tx Impl) error {
+		focus, err := s.findTopicFocus(ctx, tx, topicID, userID)
+		if err != nil {
+			return err
+		}
+		f = focus
+		return nil
+	})
+	if err != nil {
+		return nil, &errors.Error{
+			Err: err,
+			Op:  service.OpFindTopicFocus,
+		}
+	}
+	return f, nil
+}
+
+func (s *Service) findTopicFocus(ctx context.Context, tx Impl, topicID, userID service.ID) (*service.TopicFocus, error) {
+	key, err := idsKey(topicID, userID)
+	if err != nil {
+		return nil, err
+	}
+
+	b, err := s.topicBucket(tx, topicFocusBucket)
+	if err != nil {
+		return nil, err
+	}
+
+	v, err := b.Get(key)
+	if IsNotFound(err) {
+		return nil, service.ErrTopicFocusNotFound
+	}
+
+	if err != nil {
+		return nil, &errors.Error{
+			Code: errors.Internal,
+			Err:  err,
+		}
+	}
+	return unmarshalTopicFocus(v)
+}
+
+func unmarshalTopicFocus(v []byte) (*service.TopicFocus, error) {
+	f := &service.TopicFocus{}
+	if err := json.Unmarshal(v, f); err != nil {
+		return nil, &errors.Error{
+			Code: errors.Internal,
+			Msg:  "topic focus could not be unmarshalled",
+			Err:  err,
+			Op:   "unmarshalTopicFocus",
+		}
+	}
+	return f, nil
+}
+
+func (s *Service) findTopicFocuses(ctx context.Context, tx Impl, topicID service.ID) ([]*service.TopicFocus, error) {
+	prefix, err := idsKey(topicID)
+	if err != nil {
+		return nil, err
+	}
+
+	b, err := s.topicBucket(tx, topicFocusBucket)
+	if err != nil {
+		return nil, err
+	}
+
+	fs := []*service.TopicFocus{}
+	err = forEachPrefix(b, prefix, func(k, v []byte) error {
+		f, err := unmarshalTopicFocus(v)
+		if err != nil {
+			return err
+		}
+		fs = append(fs, f)
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return fs, nil
+}
+
+// FindFocusedTopics returns the topics focused by the user and the total count of them.
+func (s *Service) FindFocusedTopics(ctx context.Context, userID service.ID, opt ...service.FindOptions) ([]*service.Topic, int, error) {
+	ts := []*service.Topic{}
+	err := s.store.View(ctx, func(tx Impl) error {
+		prefix, err := idsKey(userID)
+		if err != nil {
+			return err
+		}
+
+		idx, err := s.topicBucket(tx, topicUserFocusIndex)
+		if err != nil {
+			return err
+		}
+
+		return forEachPrefix(idx, prefix, func(k, v []byte) error {
+			var topicID service.ID
+			if err := topicID.Decode(v); err != nil {
+				return &errors.Error{
+					Code: errors.Invalid,
+					Err:  err,
+				}
+			}
+
+			t, err := s.findTopicByID(ctx, tx, topicID)
+			if err != nil {
+				return err
+			}
+			ts = append(ts, t)
+			return nil
+		})
+	})
+	if err != nil {
+		return nil, 0, &errors.Error{
+			Err: err,
+			Op:  service.OpFindFocusedTopics,
+		}
+	}
+
+	sortTopics(ts, opt...)
+	start, end := pageWindow(len(ts), opt...)
+	return ts[start:end], len(ts), nil
+}
+
+// FocusTopic makes the user focus the topic, focusing twice has no effect.
+func (s *Service) FocusTopic(ctx context.Context, topicID, userID service.ID) (*service.Topic, error) {
+	var t *service.Topic
+	err := s.store.Modify(ctx, func(tx Impl) error {
+		topic, err := s.focusTopic(ctx, tx, topicID, userID)
+		if err != nil {
+			return err
+		}
+		t = topic
+		return nil
+	})
+	if err != nil {
+		return nil, &errors.Error{
+			Err: err,
+			Op:  service.OpFocusTopic,
+		}
+	}
+	return t, nil
+}
+
+func (s *Service) focusTopic(ctx context.Context, tx Impl, topicID, userID service.ID) (*service.Topic, error) {
+	t, err := s.findLiveTopicByID(ctx, tx, topicID)
+	if err != nil {
+		return nil, err
+	}
+
+	_, err = s.findTopicFocus(ctx, tx, topicID, userID)
+	if err == nil {
+		return t, nil
+	}
+	if err != service.ErrTopicFocusNotFound {
+		return nil, err
+	}
+
+	f := &service.TopicFocus{
+		TopicID:   topicID,
+		UserID:    userID,
+		CreatedAt: s.time(),
+	}
+	if err := s.putTopicFocus(ctx, tx, f); err != nil {
+		return nil, err
+	}
+
+	if err := s.adjustUserFollowCounts(ctx, tx, userID, 0, 0, 1); err != nil {
+		return nil, err
+	}
+
+	err = s.recordActivity(ctx, tx, &service.Activity{
+		UserID:   userID,
+		Action:   service.FollowedActivity,
+		OrgID:    t.OrgID,
+		ItemType: service.ItemType(service.TopicFollow),
+		ItemID:   topicID,
+	})
+	if err != nil {
+		return nil, err
+	}
+	return s.refreshTopicCounts(ctx, tx, topicID)
+}
+
+// putTopicFocus stores the focus and its user index entry.
+func (s *Service) putTopicFocus(ctx context.Context, tx Impl, f *service.TopicFocus) error {
+	v, err := json.Marshal(f)
+	if err != nil {
+		return &errors.Error{
+			Code: errors.Invalid,
+			Err:  err,
+		}
+	}
+
+	key, err := idsKey(f.TopicID, f.UserID)
+	if err != nil {
+		return err
+	}
+
+	b, err := s.topicBucket(tx, topicFocusBucket)
+	if err != nil {
+		return err
+	}
+
+	if err := b.Put(key, v); err != nil {
+		return errors.InternalErr(err)
+	}
+
+	idxKey, err := idsKey(f.UserID, f.TopicID)
+	if err != nil {
+		return err
+	}
+
+	encodedID, err := f.TopicID.Encode()
+	if err != nil {
+		return &errors.Error{
+			Code: errors.Invalid,
+			Err:  err,
+		}
+	}
+
+	idx, err := s.topicBucket(tx, topicUserFocusIndex)
+	if err != nil {
+		return err
+	}
+
+	if err := idx.Put(idxKey, encodedID); err != nil {
+		return errors.InternalErr(err)
+	}
+	return nil
+}
+
+// UnfocusTopic removes the focus of the user on the topic.
+func (s *Service) UnfocusTopic(ctx context.Context, topicID, userID service.ID) (*service.Topic, error) {
+	var t *service.Topic
+	err := s.store.Modify(ctx, func(tx Impl) error {
+		f, err := s.findTopicFocus(ctx, tx, topicID, userID)
+		if err != nil {
+			return err
+		}
+
+		topic, err := s.unfocusTopic(ctx, tx, f)
+		if err != nil {
+			return err
+		}
+		t = topic
+		return nil
+	})
+	if err != nil {
+		return nil, &errors.Error{
+			Err: err,
+			Op:  service.OpUnfocusTopic,
+		}
+	}
+	return t, nil
+}
+
+func (s *Service) unfocusTopic(ctx context.Context, tx Impl, f *service.TopicFocus) (*service.Topic, error) {
+	if err := s.removeTopicFocusEntry(ctx, tx, f); err != nil {
+		return nil, err
+	}
+
+	if err := s.adjustUserFollowCounts(ctx, tx, f.UserID, 0, 0, -1); err != nil {
+		return nil, err
+	}
+
+	if err := s.retractActivity(ctx, tx, f.UserID, service.FollowedActivity, service.ItemType(service.TopicFollow), f.TopicID); err != nil {
+		return nil, err
+	}
+	return s.refreshTopicCounts(ctx, tx, f.TopicID)
+}
+
+// removeTopicFocusEntry deletes the focus and its user index entry.
+func (s *Service) removeTopicFocusEntry(ctx context.Context, tx Impl, f *service.TopicFocus) error {
+	key, err := idsKey(f.TopicID, f.UserID)
+	if err != nil {
+		return err
+	}
+
+	b, err := s.topicBucket(tx, topicFocusBucket)
+	if err != nil {
+		return err
+	}
+
+	if err := b.Delete(key); err != nil {
+		return errors.WrapperErr(err)
+	}
+
+	idxKey, err := idsKey(f.UserID, f.TopicID)
+	if err != nil {
+		return err
+	}
+
+	idx, err := s.topicBucket(tx, topicUserFocusIndex)
+	if err != nil {
+		return err
+	}
+
+	if err := idx.Delete(idxKey); err != nil {
+		return errors.WrapperErr(err)
+	}
+	return nil
+}
+
+func (s *Service) questionOrg(ctx context.Context, tx Impl, id service.ID) (service.ID, error) {
+	q, err := s.findQuestionByID(ctx, tx, id)
+	if err != nil {
+		return 0, err
+	}
+	return q.OrgID, nil
+}
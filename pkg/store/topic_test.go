@@ -0,0 +1,151 @@
+package store_test
+
+import (
+	"context"
+	"testing"
+
+	"github.com/ustackq/indagate/pkg/service"
+	"github.com/ustackq/indagate/pkg/store"
+	"github.com/ustackq/indagate/pkg/utils/errors"
+)
+
+func createTopic(t *testing.T, s *store.Service, title string, parentID service.ID) *service.Topic {
+	t.Helper()
+
+	topic := &service.Topic{OrgID: service.ID(1), Title: title, ParentID: parentID}
+	if err := s.CreateTopic(context.Background(), topic); err != nil {
+		t.Fatal(err)
+	}
+	return topic
+}
+
+func TestTopicTree(t *testing.T) {
+	ctx := context.Background()
+	s := newInmemService(t)
+
+	root := createTopic(t, s, "Programming Languages", 0)
+	golang := createTopic(t, s, "Go", root.ID)
+	generics := createTopic(t, s, "Generics", golang.ID)
+
+	if root.URLToken != "programming-languages" {
+		t.Errorf("url token = %q, want %q", root.URLToken, "programming-languages")
+	}
+
+	got, err := s.FindTopicByID(ctx, root.ID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !got.IsParent {
+		t.Errorf("root topic is not marked as parent")
+	}
+
+	children, total, err := s.FindTopics(ctx, service.TopicFilter{ParentID: &root.ID})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if total != 1 || children[0].ID != golang.ID {
+		t.Errorf("children of root = %d topics, want Go", total)
+	}
+
+	path, err := s.FindTopicPath(ctx, generics.ID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(path) != 3 || path[0].ID != root.ID || path[2].ID != generics.ID {
+		t.Errorf("path of generics has %d topics, want root, Go and generics", len(path))
+	}
+
+	// a topic can not be moved below one of its descendants.
+	if _, err := s.UpdateTopic(ctx, root.ID, service.TopicUpdate{ParentID: &generics.ID}); err == nil || errors.ErrorCode(err) != errors.Invalid {
+		t.Errorf("UpdateTopic into a cycle error = %v, want %s", err, errors.Invalid)
+	}
+
+	dup := &service.Topic{OrgID: service.ID(1), Title: "go"}
+	if err := s.CreateTopic(ctx, dup); errors.ErrorCode(err) != errors.Conflict {
+		t.Errorf("CreateTopic with a taken url token error = %v, want %s", err, errors.Conflict)
+	}
+
+	if err := s.DeleteTopic(ctx, golang.ID); err != nil {
+		t.Fatal(err)
+	}
+	got, err = s.FindTopicByID(ctx, generics.ID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got.ParentID != root.ID {
+		t.Errorf("parent after delete = %s, want %s", got.ParentID, root.ID)
+	}
+}
+
+func TestMergeTopic(t *testing.T) {
+	ctx := context.Background()
+	s := newInmemService(t)
+
+	q := &service.Question{OrgID: service.ID(1), UserID: service.ID(2), Content: "why?"}
+	if err := s.CreateQuestion(ctx, q); err != nil {
+		t.Fatal(err)
+	}
+
+	source := createTopic(t, s, "golang", 0)
+	target := createTopic(t, s, "Go", 0)
+	child := createTopic(t, s, "Goroutines", source.ID)
+
+	for _, topicID := range []service.ID{source.ID, target.ID} {
+		r := &service.TopicRelation{TopicID: topicID, ItemType: service.QuestionItemType, ItemID: q.ID, UserID: service.ID(2)}
+		if err := s.AddTopicRelation(ctx, r); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if _, err := s.FocusTopic(ctx, source.ID, service.ID(3)); err != nil {
+		t.Fatal(err)
+	}
+
+	merged, err := s.MergeTopic(ctx, source.ID, target.ID, service.ID(4))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if merged.DiscussCount != 1 || merged.FocusCount != 1 || !merged.IsParent {
+		t.Errorf("merged topic = %+v, want 1 relation, 1 focus and a child", merged)
+	}
+
+	got, err := s.FindTopicByURLToken(ctx, source.URLToken)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got.ID != target.ID {
+		t.Errorf("url token of the source resolves to %s, want %s", got.ID, target.ID)
+	}
+
+	got, err = s.FindTopicByID(ctx, child.ID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got.ParentID != target.ID {
+		t.Errorf("parent of the child = %s, want %s", got.ParentID, target.ID)
+	}
+
+	typ := service.QuestionItemType
+	rs, _, err := s.FindTopicRelations(ctx, service.TopicRelationFilter{ItemType: &typ, ItemID: &q.ID})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(rs) != 1 || rs[0].TopicID != target.ID {
+		t.Errorf("relations of the question = %+v, want the target only", rs)
+	}
+
+	if _, err := s.FindTopicFocus(ctx, target.ID, service.ID(3)); err != nil {
+		t.Errorf("focus was not moved to the target: %v", err)
+	}
+
+	if _, err := s.MergeTopic(ctx, source.ID, target.ID, service.ID(4)); errors.ErrorCode(err) != errors.Conflict {
+		t.Errorf("merging a merged topic error = %v, want %s", err, errors.Conflict)
+	}
+
+	ts, _, err := s.FindTopics(ctx, service.TopicFilter{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(ts) != 2 {
+		t.Errorf("FindTopics returned %d topics, want the merged topic to be left out", len(ts))
+	}
+}
@@ -105,11 +105,8 @@ func (s *Service) findUserByID(ctx context.Context, tx Impl, id service.ID) (*se
 	}
 
 	v, err := b.Get(encodedID)
-	if errors.IsNotFound(err) {
-		return nil, &errors.Error{
-			Code: errors.NotFound,
-			Msg:  "user not found",
-		}
+	if IsNotFound(err) {
+		return nil, ErrUserNotFound
 	}
 
 	if err != nil {
@@ -133,3 +130,50 @@ func unmarshalUser(v []byte) (*service.User, error) {
 	}
 	return u, nil
 }
+
+func (s *Service) putUser(ctx context.Context, tx Impl, u *service.User) error {
+	v, err := json.Marshal(u)
+	if err != nil {
+		return &errors.Error{
+			Code: errors.Invalid,
+			Err:  err,
+		}
+	}
+
+	encodedID, err := u.ID.Encode()
+	if err != nil {
+		return &errors.Error{
+			Code: errors.Invalid,
+			Err:  err,
+		}
+	}
+
+	b, err := s.userBucket(tx)
+	if err != nil {
+		return err
+	}
+
+	if err := b.Put(encodedID, v); err != nil {
+		return errors.InternalErr(err)
+	}
+	return nil
+}
+
+// adjustReputation adds delta to the reputation of the user, users which
+// have been deleted since they authored an item are skipped.
+func (s *Service) adjustReputation(ctx context.Context, tx Impl, userID service.ID, delta int) error {
+	if delta == 0 {
+		return nil
+	}
+
+	u, err := s.findUserByID(ctx, tx, userID)
+	if err == ErrUserNotFound {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+
+	u.Reputation += delta
+	return s.putUser(ctx, tx, u)
+}
@@ -0,0 +1,270 @@
+package store
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/ustackq/indagate/pkg/service"
+	"github.com/ustackq/indagate/pkg/utils/errors"
+)
+
+var (
+	urmBucket = []byte("userresourcemappingsv1")
+
+	// ErrURMNotFound is returned when a user resource mapping can not be found.
+	ErrURMNotFound = &errors.Error{
+		Code: errors.NotFound,
+		Msg:  "user to resource mapping not found",
+	}
+)
+
+// assert Service implement service.UserResourceMappingService
+var _ service.UserResourceMappingService = (*Service)(nil)
+
+func (s *Service) initializeURMs(ctx context.Context, tx Impl) error {
+	if _, err := s.urmBucket(tx); err != nil {
+		return err
+	}
+	return nil
+}
+
+func (s *Service) urmBucket(tx Impl) (Bucket, error) {
+	b, err := tx.Bucket(urmBucket)
+	if err != nil {
+		return nil, UnexpectedURMError(err)
+	}
+	return b, nil
+}
+
+// UnexpectedURMError wraps errors raised while retrieving the user resource mapping bucket.
+func UnexpectedURMError(err error) *errors.Error {
+	return &errors.Error{
+		Code: errors.Internal,
+		Msg:  fmt.Sprintf("unexpected error retrieving user resource mapping bucket; %v", err),
+		Op:   "urmBucket",
+	}
+}
+
+// urmKey is the encoded resource id followed by the encoded user id,
+// so that all mappings of a resource are stored next to each other.
+func urmKey(resourceID, userID service.ID) ([]byte, error) {
+	encodedResourceID, err := resourceID.Encode()
+	if err != nil {
+		return nil, &errors.Error{
+			Code: errors.Invalid,
+			Err:  err,
+		}
+	}
+
+	encodedUserID, err := userID.Encode()
+	if err != nil {
+		return nil, &errors.Error{
+			Code: errors.Invalid,
+			Err:  err,
+		}
+	}
+
+	return append(encodedResourceID, encodedUserID...), nil
+}
+
+func unmarshalURM(v []byte) (*service.UserResourceMapping, error) {
+	m := &service.UserResourceMapping{}
+	if err := json.Unmarshal(v, m); err != nil {
+		return nil, &errors.Error{
+			Code: errors.Internal,
+			Msg:  "user resource mapping could not be unmarshalled",
+			Err:  err,
+			Op:   "unmarshalURM",
+		}
+	}
+	return m, nil
+}
+
+func filterURMFn(filter service.UserResourceMappingFilter) func(m *service.UserResourceMapping) bool {
+	return func(m *service.UserResourceMapping) bool {
+		if filter.ResourceID.Valid() && m.ResourceID != filter.ResourceID {
+			return false
+		}
+		if filter.ResourceType != "" && m.ResourceType != filter.ResourceType {
+			return false
+		}
+		if filter.UserID.Valid() && m.UserID != filter.UserID {
+			return false
+		}
+		if filter.UserType != "" && m.UserType != filter.UserType {
+			return false
+		}
+		return true
+	}
+}
+
+// FindUserResourceMappings returns a list of UserResourceMappings that match filter and the total count of matching mappings.
+func (s *Service) FindUserResourceMappings(ctx context.Context, filter service.UserResourceMappingFilter, opt ...service.FindOptions) ([]*service.UserResourceMapping, int, error) {
+	var ms []*service.UserResourceMapping
+	err := s.store.View(ctx, func(tx Impl) error {
+		mappings, err := s.findUserResourceMappings(ctx, tx, filter)
+		if err != nil {
+			return err
+		}
+		ms = mappings
+		return nil
+	})
+	if err != nil {
+		return nil, 0, &errors.Error{
+			Err: err,
+			Op:  "FindUserResourceMappings",
+		}
+	}
+
+	start, end := pageWindow(len(ms), opt...)
+	return ms[start:end], len(ms), nil
+}
+
+func (s *Service) findUserResourceMappings(ctx context.Context, tx Impl, filter service.UserResourceMappingFilter) ([]*service.UserResourceMapping, error) {
+	ms := []*service.UserResourceMapping{}
+	filterFn := filterURMFn(filter)
+	err := s.forEachURM(ctx, tx, func(m *service.UserResourceMapping) bool {
+		if filterFn(m) {
+			ms = append(ms, m)
+		}
+		return true
+	})
+	return ms, err
+}
+
+func (s *Service) findUserResourceMapping(ctx context.Context, tx Impl, resourceID, userID service.ID) (*service.UserResourceMapping, error) {
+	key, err := urmKey(resourceID, userID)
+	if err != nil {
+		return nil, err
+	}
+
+	b, err := s.urmBucket(tx)
+	if err != nil {
+		return nil, err
+	}
+
+	v, err := b.Get(key)
+	if IsNotFound(err) {
+		return nil, ErrURMNotFound
+	}
+
+	if err != nil {
+		return nil, &errors.Error{
+			Code: errors.Internal,
+			Err:  err,
+		}
+	}
+	return unmarshalURM(v)
+}
+
+func (s *Service) forEachURM(ctx context.Context, tx Impl, fn func(*service.UserResourceMapping) bool) error {
+	b, err := s.urmBucket(tx)
+	if err != nil {
+		return err
+	}
+
+	cur, err := b.Cursor()
+	if err != nil {
+		return err
+	}
+
+	for k, v := cur.First(); k != nil; k, v = cur.Next() {
+		m, err := unmarshalURM(v)
+		if err != nil {
+			return err
+		}
+		if !fn(m) {
+			break
+		}
+	}
+	return nil
+}
+
+// CreateUserResourceMapping creates a user resource mapping, an existing mapping
+// of the user to the resource is replaced.
+func (s *Service) CreateUserResourceMapping(ctx context.Context, m *service.UserResourceMapping) error {
+	return s.store.Modify(ctx, func(tx Impl) error {
+		if err := s.createUserResourceMapping(ctx, tx, m); err != nil {
+			return &errors.Error{
+				Err: err,
+				Op:  "CreateUserResourceMapping",
+			}
+		}
+		return nil
+	})
+}
+
+func (s *Service) createUserResourceMapping(ctx context.Context, tx Impl, m *service.UserResourceMapping) error {
+	if err := m.UserType.Valid(); err != nil {
+		return &errors.Error{
+			Code: errors.Invalid,
+			Err:  err,
+		}
+	}
+
+	if m.Role != "" {
+		if err := m.Role.Valid(); err != nil {
+			return err
+		}
+	}
+	return s.putUserResourceMapping(ctx, tx, m)
+}
+
+func (s *Service) putUserResourceMapping(ctx context.Context, tx Impl, m *service.UserResourceMapping) error {
+	v, err := json.Marshal(m)
+	if err != nil {
+		return &errors.Error{
+			Code: errors.Invalid,
+			Err:  err,
+		}
+	}
+
+	key, err := urmKey(m.ResourceID, m.UserID)
+	if err != nil {
+		return err
+	}
+
+	b, err := s.urmBucket(tx)
+	if err != nil {
+		return err
+	}
+
+	if err := b.Put(key, v); err != nil {
+		return errors.InternalErr(err)
+	}
+	return nil
+}
+
+// DeleteUserResourceMapping deletes a user resource mapping.
+func (s *Service) DeleteUserResourceMapping(ctx context.Context, resourceID, userID service.ID) error {
+	return s.store.Modify(ctx, func(tx Impl) error {
+		if err := s.deleteUserResourceMapping(ctx, tx, resourceID, userID); err != nil {
+			return &errors.Error{
+				Err: err,
+				Op:  "DeleteUserResourceMapping",
+			}
+		}
+		return nil
+	})
+}
+
+func (s *Service) deleteUserResourceMapping(ctx context.Context, tx Impl, resourceID, userID service.ID) error {
+	if _, err := s.findUserResourceMapping(ctx, tx, resourceID, userID); err != nil {
+		return err
+	}
+
+	key, err := urmKey(resourceID, userID)
+	if err != nil {
+		return err
+	}
+
+	b, err := s.urmBucket(tx)
+	if err != nil {
+		return err
+	}
+
+	if err := b.Delete(key); err != nil {
+		return errors.WrapperErr(err)
+	}
+	return nil
+}
@@ -0,0 +1,536 @@
+package store
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/ustackq/indagate/pkg/service"
+	"github.com/ustackq/indagate/pkg/utils/errors"
+)
+
+var (
+	voteBucket = []byte("votesv1")
+)
+
+// assert Service implement service.VoteService
+var _ service.VoteService = (*Service)(nil)
+
+// votable adapts an item type to the vote service.
+type votable struct {
+	// find returns the voting state of the item.
+	find func(s *Service, ctx context.Context, tx Impl, id service.ID) (*service.VoteItem, error)
+	// count adds the deltas to the agree and against counters of the item.
+	count func(s *Service, ctx context.Context, tx Impl, id service.ID, agree, against int) error
+}
+
+// votables holds the item types which can be voted on.
+var votables = map[service.ItemType]votable{
+	service.QuestionItemType: {
+		find:  (*Service).findQuestionVoteItem,
+		count: (*Service).countQuestionVote,
+	},
+	service.AnswerItemType: {
+		find:  (*Service).findAnswerVoteItem,
+		count: (*Service).countAnswerVote,
+	},
+	service.ArticleItemType: {
+		find:  (*Service).findArticleVoteItem,
+		count: (*Service).countArticleVote,
+	},
+	service.CommentItemType: {
+		find:  (*Service).findCommentVoteItem,
+		count: (*Service).countCommentVote,
+	},
+}
+
+func votableOf(t service.ItemType) (votable, error) {
+	if err := t.Valid(); err != nil {
+		return votable{}, err
+	}
+
+	v, ok := votables[t]
+	if !ok {
+		return votable{}, &errors.Error{
+			Code: errors.Invalid,
+			Msg:  fmt.Sprintf("voting on %s is not supported", t),
+		}
+	}
+	return v, nil
+}
+
+func (s *Service) initializeVotes(ctx context.Context, tx Impl) error {
+	if _, err := s.voteBucket(tx); err != nil {
+		return err
+	}
+	return nil
+}
+
+func (s *Service) voteBucket(tx Impl) (Bucket, error) {
+	b, err := tx.Bucket(voteBucket)
+	if err != nil {
+		return nil, UnexpectedVoteError(err)
+	}
+	return b, nil
+}
+
+// UnexpectedVoteError wraps errors raised while retrieving the vote bucket.
+func UnexpectedVoteError(err error) *errors.Error {
+	return &errors.Error{
+		Code: errors.Internal,
+		Msg:  fmt.Sprintf("unexpected error retrieving vote bucket; %v", err),
+		Op:   "voteBucket",
+	}
+}
+
+// voteKey is the item type, the encoded item id and the encoded user id.
+func voteKey(itemType service.ItemType, itemID, userID service.ID) ([]byte, error) {
+	prefix, err := voteItemPrefix(itemType, itemID)
+	if err != nil {
+		return nil, err
+	}
+
+	uid, err := userID.Encode()
+	if err != nil {
+		return nil, &errors.Error{
+			Code: errors.Invalid,
+			Err:  err,
+		}
+	}
+	return append(prefix, uid...), nil
+}
+
+// voteItemPrefix is the prefix of the keys of all votes on the item.
+func voteItemPrefix(itemType service.ItemType, itemID service.ID) ([]byte, error) {
+	iid, err := itemID.Encode()
+	if err != nil {
+		return nil, &errors.Error{
+			Code: errors.Invalid,
+			Err:  err,
+		}
+	}
+
+	// room is left for the user id appended by voteKey, ids encode to the same size.
+	key := make([]byte, 0, len(itemType)+1+2*len(iid))
+	key = append(key, itemType...)
+	key = append(key, '/')
+	return append(key, iid...), nil
+}
+
+// FindVoteItem returns the voting state of an item.
+func (s *Service) FindVoteItem(ctx context.Context, itemType service.ItemType, itemID service.ID) (*service.VoteItem, error) {
+	var item *service.VoteItem
+	err := s.store.View(ctx, func(tx Impl) error {
+		vt, err := votableOf(itemType)
+		if err != nil {
+			return err
+		}
+
+		i, err := vt.find(s, ctx, tx, itemID)
+		if err != nil {
+			return err
+		}
+		item = i
+		return nil
+	})
+	if err != nil {
+		return nil, &errors.Error{
+			Err: err,
+			Op:  service.OpFindVoteItem,
+		}
+	}
+	return item, nil
+}
+
+// FindVote returns the vote of a user on an item.
+func (s *Service) FindVote(ctx context.Context, itemType service.ItemType, itemID, userID service.ID) (*service.Vote, error) {
+	var v *service.Vote
+	err := s.store.View(ctx, func(tx Impl) error {
+		vote, err := s.findVote(ctx, tx, itemType, itemID, userID)
+		if err != nil {
+			return err
+		}
+		v = vote
+		return nil
+	})
+	if err != nil {
+		return nil, &errors.Error{
+			Err: err,
+			Op:  service.OpFindVote,
+		}
+	}
+	return v, nil
+}
+
+func (s *Service) findVote(ctx context.Context, tx Impl, itemType service.ItemType, itemID, userID service.ID) (*service.Vote, error) {
+	key, err := voteKey(itemType, itemID, userID)
+	if err != nil {
+		return nil, err
+	}
+
+	b, err := s.voteBucket(tx)
+	if err != nil {
+		return nil, err
+	}
+
+	v, err := b.Get(key)
+	if IsNotFound(err) {
+		return nil, service.ErrVoteNotFound
+	}
+
+	if err != nil {
+		return nil, &errors.Error{
+			Code: errors.Internal,
+			Err:  err,
+		}
+	}
+
+	vote := &service.Vote{}
+	if err := json.Unmarshal(v, vote); err != nil {
+		return nil, &errors.Error{
+			Code: errors.Internal,
+			Err:  err,
+		}
+	}
+	return vote, nil
+}
+
+// Vote records the vote of the user on the item, a previous vote of the user
+// is replaced. Counters and reputation are updated in the same transaction.
+func (s *Service) Vote(ctx context.Context, v *service.Vote) (*service.VoteItem, error) {
+	var item *service.VoteItem
+	err := s.store.Modify(ctx, func(tx Impl) error {
+		i, err := s.vote(ctx, tx, v)
+		if err != nil {
+			return err
+		}
+		item = i
+		return nil
+	})
+	if err != nil {
+		return nil, &errors.Error{
+			Err: err,
+			Op:  service.OpVote,
+		}
+	}
+	return item, nil
+}
+
+func (s *Service) vote(ctx context.Context, tx Impl, v *service.Vote) (*service.VoteItem, error) {
+	if err := v.Valid(); err != nil {
+		return nil, err
+	}
+
+	vt, err := votableOf(v.ItemType)
+	if err != nil {
+		return nil, err
+	}
+
+	item, err := vt.find(s, ctx, tx, v.ItemID)
+	if err != nil {
+		return nil, err
+	}
+
+	if item.AuthorID == v.UserID {
+		return nil, service.ErrSelfVote
+	}
+
+	prev, err := s.findVote(ctx, tx, v.ItemType, v.ItemID, v.UserID)
+	if err != nil && err != service.ErrVoteNotFound {
+		return nil, err
+	}
+
+	now := s.time()
+	v.CreatedAt = now
+	if prev != nil {
+		if prev.Value == v.Value {
+			*v = *prev
+			return item, nil
+		}
+		v.CreatedAt = prev.CreatedAt
+	}
+	v.UpdatedAt = now
+
+	if err := s.putVote(ctx, tx, v); err != nil {
+		return nil, err
+	}
+
+	if prev != nil {
+		if err := s.applyVote(ctx, tx, vt, item, prev, -1); err != nil {
+			return nil, err
+		}
+	}
+	if err := s.applyVote(ctx, tx, vt, item, v, 1); err != nil {
+		return nil, err
+	}
+
+	// only up votes are worth telling the author about.
+	if v.Value == service.UpVote {
+		err := s.notify(ctx, tx, &service.Notification{
+			RecipientID: item.AuthorID,
+			SenderID:    v.UserID,
+			Action:      service.VoteNotification,
+			ItemType:    v.ItemType,
+			ItemID:      v.ItemID,
+		})
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	if err := s.recordVoteActivity(ctx, tx, item, prev, v); err != nil {
+		return nil, err
+	}
+	return vt.find(s, ctx, tx, v.ItemID)
+}
+
+// recordVoteActivity records up votes on questions, answers and articles, and retracts
+// the activity of an up vote which was changed.
+func (s *Service) recordVoteActivity(ctx context.Context, tx Impl, item *service.VoteItem, prev, v *service.Vote) error {
+	if item.Type == service.CommentItemType {
+		return nil
+	}
+
+	if prev != nil && prev.Value == service.UpVote {
+		if err := s.retractActivity(ctx, tx, prev.UserID, service.VotedActivity, prev.ItemType, prev.ItemID); err != nil {
+			return err
+		}
+	}
+
+	if v == nil || v.Value != service.UpVote {
+		return nil
+	}
+
+	questionID, err := s.activityQuestion(ctx, tx, v.ItemType, v.ItemID)
+	if err != nil {
+		return err
+	}
+
+	return s.recordActivity(ctx, tx, &service.Activity{
+		UserID:     v.UserID,
+		Action:     service.VotedActivity,
+		OrgID:      item.OrgID,
+		ItemType:   v.ItemType,
+		ItemID:     v.ItemID,
+		QuestionID: questionID,
+	})
+}
+
+// applyVote adds the vote sign times to the counters of the item and the reputation of its author.
+func (s *Service) applyVote(ctx context.Context, tx Impl, vt votable, item *service.VoteItem, v *service.Vote, sign int) error {
+	agree, against := 0, 0
+	if v.Value == service.UpVote {
+		agree = sign
+	} else {
+		against = sign
+	}
+
+	if err := vt.count(s, ctx, tx, item.ID, agree, against); err != nil {
+		return err
+	}
+	return s.adjustReputation(ctx, tx, item.AuthorID, sign*v.Value.Reputation(v.ItemType))
+}
+
+func (s *Service) putVote(ctx context.Context, tx Impl, v *service.Vote) error {
+	b, err := json.Marshal(v)
+	if err != nil {
+		return &errors.Error{
+			Code: errors.Invalid,
+			Err:  err,
+		}
+	}
+
+	key, err := voteKey(v.ItemType, v.ItemID, v.UserID)
+	if err != nil {
+		return err
+	}
+
+	bkt, err := s.voteBucket(tx)
+	if err != nil {
+		return err
+	}
+
+	if err := bkt.Put(key, b); err != nil {
+		return errors.InternalErr(err)
+	}
+	return nil
+}
+
+// DeleteVote withdraws the vote of the user on the item and reverts its effects.
+func (s *Service) DeleteVote(ctx context.Context, itemType service.ItemType, itemID, userID service.ID) (*service.VoteItem, error) {
+	var item *service.VoteItem
+	err := s.store.Modify(ctx, func(tx Impl) error {
+		i, err := s.deleteVote(ctx, tx, itemType, itemID, userID)
+		if err != nil {
+			return err
+		}
+		item = i
+		return nil
+	})
+	if err != nil {
+		return nil, &errors.Error{
+			Err: err,
+			Op:  service.OpDeleteVote,
+		}
+	}
+	return item, nil
+}
+
+func (s *Service) deleteVote(ctx context.Context, tx Impl, itemType service.ItemType, itemID, userID service.ID) (*service.VoteItem, error) {
+	vt, err := votableOf(itemType)
+	if err != nil {
+		return nil, err
+	}
+
+	item, err := vt.find(s, ctx, tx, itemID)
+	if err != nil {
+		return nil, err
+	}
+
+	prev, err := s.findVote(ctx, tx, itemType, itemID, userID)
+	if err != nil {
+		return nil, err
+	}
+
+	key, err := voteKey(itemType, itemID, userID)
+	if err != nil {
+		return nil, err
+	}
+
+	b, err := s.voteBucket(tx)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := b.Delete(key); err != nil {
+		return nil, errors.InternalErr(err)
+	}
+
+	if err := s.applyVote(ctx, tx, vt, item, prev, -1); err != nil {
+		return nil, err
+	}
+
+	if err := s.recordVoteActivity(ctx, tx, item, prev, nil); err != nil {
+		return nil, err
+	}
+	return vt.find(s, ctx, tx, itemID)
+}
+
+// deleteItemVotes removes the votes on a deleted item. The reputation the votes
+// earned the author is taken back, as if the voters had withdrawn them.
+func (s *Service) deleteItemVotes(ctx context.Context, tx Impl, itemType service.ItemType, itemID, authorID service.ID) error {
+	prefix, err := voteItemPrefix(itemType, itemID)
+	if err != nil {
+		return err
+	}
+
+	b, err := s.voteBucket(tx)
+	if err != nil {
+		return err
+	}
+
+	keys := [][]byte{}
+	reputation := 0
+	err = forEachPrefix(b, prefix, func(k, v []byte) error {
+		vote := &service.Vote{}
+		if err := json.Unmarshal(v, vote); err != nil {
+			return &errors.Error{
+				Code: errors.Internal,
+				Err:  err,
+			}
+		}
+		keys = append(keys, append([]byte{}, k...))
+		reputation += vote.Value.Reputation(itemType)
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+
+	for _, k := range keys {
+		if err := b.Delete(k); err != nil {
+			return errors.WrapperErr(err)
+		}
+	}
+	return s.adjustReputation(ctx, tx, authorID, -reputation)
+}
+
+func (s *Service) findQuestionVoteItem(ctx context.Context, tx Impl, id service.ID) (*service.VoteItem, error) {
+	q, err := s.findQuestionByID(ctx, tx, id)
+	if err != nil {
+		return nil, err
+	}
+
+	return &service.VoteItem{
+		Type:         service.QuestionItemType,
+		ID:           q.ID,
+		OrgID:        q.OrgID,
+		AuthorID:     q.UserID,
+		AgreeCount:   q.AgreeCount,
+		AgainstCount: q.AgainstCount,
+	}, nil
+}
+
+func (s *Service) countQuestionVote(ctx context.Context, tx Impl, id service.ID, agree, against int) error {
+	q, err := s.findQuestionByID(ctx, tx, id)
+	if err != nil {
+		return err
+	}
+
+	q.AgreeCount += agree
+	q.AgainstCount += against
+	return s.putQuestion(ctx, tx, q)
+}
+
+func (s *Service) findAnswerVoteItem(ctx context.Context, tx Impl, id service.ID) (*service.VoteItem, error) {
+	a, err := s.findAnswerByID(ctx, tx, id)
+	if err != nil {
+		return nil, err
+	}
+
+	return &service.VoteItem{
+		Type:         service.AnswerItemType,
+		ID:           a.ID,
+		OrgID:        a.OrgID,
+		AuthorID:     a.UserID,
+		AgreeCount:   a.AgreeCount,
+		AgainstCount: a.AgainstCount,
+	}, nil
+}
+
+func (s *Service) countAnswerVote(ctx context.Context, tx Impl, id service.ID, agree, against int) error {
+	a, err := s.findAnswerByID(ctx, tx, id)
+	if err != nil {
+		return err
+	}
+
+	a.AgreeCount += agree
+	a.AgainstCount += against
+	return s.putAnswer(ctx, tx, a)
+}
+
+func (s *Service) findCommentVoteItem(ctx context.Context, tx Impl, id service.ID) (*service.VoteItem, error) {
+	c, err := s.findCommentByID(ctx, tx, id)
+	if err != nil {
+		return nil, err
+	}
+
+	return &service.VoteItem{
+		Type:         service.CommentItemType,
+		ID:           c.ID,
+		OrgID:        c.OrgID,
+		AuthorID:     c.UserID,
+		AgreeCount:   c.AgreeCount,
+		AgainstCount: c.AgainstCount,
+	}, nil
+}
+
+func (s *Service) countCommentVote(ctx context.Context, tx Impl, id service.ID, agree, against int) error {
+	c, err := s.findCommentByID(ctx, tx, id)
+	if err != nil {
+		return err
+	}
+
+	c.AgreeCount += agree
+	c.AgainstCount += against
+	return s.putComment(ctx, tx, c)
+}
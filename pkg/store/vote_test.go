@@ -0,0 +1,151 @@
+package store_test
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"testing"
+
+	"github.com/ustackq/indagate/pkg/service"
+	"github.com/ustackq/indagate/pkg/store"
+	"github.com/ustackq/indagate/pkg/utils/errors"
+)
+
+func TestVote(t *testing.T) {
+	ctx := context.Background()
+	s := newInmemService(t)
+
+	author, voter := service.ID(2), service.ID(3)
+	q := &service.Question{OrgID: service.ID(1), UserID: author, Content: "why?"}
+	if err := s.CreateQuestion(ctx, q); err != nil {
+		t.Fatal(err)
+	}
+
+	vote := func(v service.VoteValue) *service.VoteItem {
+		t.Helper()
+		item, err := s.Vote(ctx, &service.Vote{
+			ItemType: service.QuestionItemType,
+			ItemID:   q.ID,
+			UserID:   voter,
+			Value:    v,
+		})
+		if err != nil {
+			t.Fatal(err)
+		}
+		return item
+	}
+
+	if item := vote(service.UpVote); item.AgreeCount != 1 || item.AgainstCount != 0 {
+		t.Errorf("after up vote item = %+v, want 1 agree", item)
+	}
+
+	// voting twice the same way does not count twice.
+	if item := vote(service.UpVote); item.AgreeCount != 1 {
+		t.Errorf("after second up vote item = %+v, want 1 agree", item)
+	}
+
+	if item := vote(service.DownVote); item.AgreeCount != 0 || item.AgainstCount != 1 {
+		t.Errorf("after changed vote item = %+v, want 1 against", item)
+	}
+
+	item, err := s.DeleteVote(ctx, service.QuestionItemType, q.ID, voter)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if item.AgreeCount != 0 || item.AgainstCount != 0 {
+		t.Errorf("after delete item = %+v, want no votes", item)
+	}
+
+	if _, err := s.FindVote(ctx, service.QuestionItemType, q.ID, voter); errors.ErrorCode(err) != errors.NotFound {
+		t.Errorf("FindVote after delete error = %v, want %s", err, errors.NotFound)
+	}
+
+	_, err = s.Vote(ctx, &service.Vote{
+		ItemType: service.QuestionItemType,
+		ItemID:   q.ID,
+		UserID:   author,
+		Value:    service.UpVote,
+	})
+	if errors.ErrorCode(err) != errors.Forbidden {
+		t.Errorf("self vote error = %v, want %s", err, errors.Forbidden)
+	}
+}
+
+// reputation reads the reputation of a user from an export of the store.
+func reputation(t *testing.T, s *store.Service, userID service.ID) int {
+	t.Helper()
+
+	var archive bytes.Buffer
+	if err := s.Export(context.Background(), &archive); err != nil {
+		t.Fatal(err)
+	}
+	dec := json.NewDecoder(&archive)
+	for dec.More() {
+		var record struct {
+			Kind string          `json:"kind"`
+			Data json.RawMessage `json:"data"`
+		}
+		if err := dec.Decode(&record); err != nil {
+			t.Fatal(err)
+		}
+		if record.Kind != "user" {
+			continue
+		}
+		u := &service.User{}
+		if err := json.Unmarshal(record.Data, u); err != nil {
+			t.Fatal(err)
+		}
+		if u.ID == userID {
+			return u.Reputation
+		}
+	}
+	t.Fatalf("user %s not exported", userID)
+	return 0
+}
+
+func TestVotesOfDeletedItems(t *testing.T) {
+	ctx := context.Background()
+	s := newInmemService(t)
+
+	archive := `{"kind":"header","data":{"format":1}}
+{"kind":"user","data":{"id":2,"name":"author"}}`
+	if _, err := s.Import(ctx, bytes.NewReader([]byte(archive))); err != nil {
+		t.Fatal(err)
+	}
+
+	author, voter := service.ID(2), service.ID(3)
+	q := &service.Question{OrgID: service.ID(1), UserID: author, Content: "why?"}
+	if err := s.CreateQuestion(ctx, q); err != nil {
+		t.Fatal(err)
+	}
+	a := &service.Answer{QuestionID: q.ID, UserID: author, Content: "because"}
+	if err := s.CreateAnswer(ctx, a); err != nil {
+		t.Fatal(err)
+	}
+
+	for _, v := range []*service.Vote{
+		{ItemType: service.QuestionItemType, ItemID: q.ID, UserID: voter, Value: service.UpVote},
+		{ItemType: service.AnswerItemType, ItemID: a.ID, UserID: voter, Value: service.UpVote},
+	} {
+		if _, err := s.Vote(ctx, v); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if r := reputation(t, s, author); r == 0 {
+		t.Fatal("author reputation = 0, want reputation from the votes")
+	}
+
+	// the votes of deleted items are removed with the reputation they earned.
+	if err := s.DeleteQuestion(ctx, q.ID); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := s.FindVote(ctx, service.QuestionItemType, q.ID, voter); errors.ErrorCode(err) != errors.NotFound {
+		t.Errorf("vote on deleted question error = %v, want %s", err, errors.NotFound)
+	}
+	if _, err := s.FindVote(ctx, service.AnswerItemType, a.ID, voter); errors.ErrorCode(err) != errors.NotFound {
+		t.Errorf("vote on deleted answer error = %v, want %s", err, errors.NotFound)
+	}
+	if r := reputation(t, s, author); r != 0 {
+		t.Errorf("author reputation = %d, want none left", r)
+	}
+}
@@ -24,6 +24,7 @@ const (
 	MethodNotAllowed = "method not allowed"
 	Unauthorized     = "unauthorized"
 	Conflict         = "conflict"
+	TooManyRequests  = "too many requests"
 )
 
 var (
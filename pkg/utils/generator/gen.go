@@ -1,14 +1,31 @@
 package generator
 
 import (
+	"sync"
+	"time"
+
 	"github.com/ustackq/indagate/pkg/service"
 )
 
+const (
+	machineBits  = 10
+	sequenceBits = 12
+	machineMask  = 1<<machineBits - 1
+)
+
 type Generator struct {
+	mu      sync.Mutex
 	state   uint64
 	machine uint64
 }
 
+// NewGenerator returns a generator for the machine, only the low 10 bits of machine are used.
+func NewGenerator(machine uint64) *Generator {
+	return &Generator{
+		machine: machine & machineMask,
+	}
+}
+
 // IDGenerator implement
 type idGenerator struct {
 	Generator *Generator
@@ -16,7 +33,17 @@ type idGenerator struct {
 
 type IDGeneratorOp func(*idGenerator)
 
+// Next returns an increasing id made of the milliseconds since the unix epoch,
+// the machine and a sequence number within the millisecond.
 func (g *Generator) Next() service.ID {
-	// TODO: complete actual Next
-	return 0
+	g.mu.Lock()
+	defer g.mu.Unlock()
+
+	ms := uint64(time.Now().UnixNano() / int64(time.Millisecond))
+	next := ms<<(machineBits+sequenceBits) | g.machine<<sequenceBits
+	if next <= g.state {
+		next = g.state + 1
+	}
+	g.state = next
+	return service.ID(next)
 }
@@ -23,7 +23,7 @@ func NewIDGenerator(opts ...IDGeneratorOp) service.IDGenerator {
 		f(g)
 	}
 	if g.Generator == nil {
-		// TODO
+		g.Generator = NewGenerator(uint64(rand.Int63()))
 	}
 	return g
 }
@@ -7,6 +7,7 @@ import (
 	jsoniter "github.com/json-iterator/go"
 	"github.com/prometheus/client_golang/prometheus"
 	ihttp "github.com/ustackq/indagate/pkg/http"
+	"go.uber.org/zap"
 )
 
 var json = jsoniter.ConfigCompatibleWithStandardLibrary
@@ -20,14 +21,24 @@ func NewPlatformHandler(b *ihttp.APIBackend) *PlatformHandler {
 	h := ihttp.NewAuthenticationHandler()
 	h.Handler = ihttp.NewAPIHandler(b)
 	h.AuthenticationService = b.AuthenticationService
+	h.SessionService = b.SessionService
+	h.SessionRenewDisabled = b.SessionRenewDisabled
+	h.BanService = b.BanService
 	h.RegisterNoAuthRouter("GET", "/api/v1")
 	h.RegisterNoAuthRouter("POST", "/api/v1/signin")
 	h.RegisterNoAuthRouter("POST", "/api/v1/signout")
 	h.RegisterNoAuthRouter("GET", "/api/v1/setup")
 	h.RegisterNoAuthRouter("POST", "/api/v1/setup")
 	h.RegisterNoAuthRouter("GET", "/api/v1/swagger.json")
+	h.RegisterNoAuthRouter("POST", "/api/v1/account/activate")
+	h.RegisterNoAuthRouter("POST", "/api/v1/account/password/reset")
+	h.RegisterNoAuthRouter("PUT", "/api/v1/account/password")
+	// blocked ranges are rejected before anything else.
+	ih := ihttp.NewIPBlockHandler(b.BanService, h)
+	ih.Logger = b.Logger.With(zap.String("handler", "ipblock"))
+	ih.TrustedProxies = b.TrustedProxies
 	return &PlatformHandler{
-		APIHandler: h,
+		APIHandler: ih,
 	}
 }
 